@@ -17,6 +17,7 @@ type AgentQARequest struct {
 	Query            string   `json:"query"`                        // Required query text
 	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty"` // Optional KBs for this query
 	AgentEnabled     bool     `json:"agent_enabled"`                // Whether to run in agent mode
+	AgentID          string   `json:"agent_id,omitempty"`           // Optional custom agent ID
 	WebSearchEnabled bool     `json:"web_search_enabled"`           // Whether to enable web search
 	SummaryModelID   string   `json:"summary_model_id,omitempty"`   // Optional summary model override
 	MCPServiceIDs    []string `json:"mcp_service_ids,omitempty"`    // Optional MCP service allow list
@@ -33,6 +34,10 @@ const (
 	AgentResponseTypeAnswer     AgentResponseType = "answer"
 	AgentResponseTypeReflection AgentResponseType = "reflection"
 	AgentResponseTypeError      AgentResponseType = "error"
+	// AgentResponseTypeToolApproval is a tool call waiting for approval (Done false) or its decision (Done true)
+	AgentResponseTypeToolApproval AgentResponseType = "tool_approval"
+	// AgentResponseTypeArtifact is a chart or file produced by a tool, see ListMessageArtifacts
+	AgentResponseTypeArtifact AgentResponseType = "artifact"
 )
 
 // AgentStreamResponse agent streaming response
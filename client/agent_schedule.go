@@ -0,0 +1,235 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The Agent Schedule related interfaces are used to run custom agents headlessly
+// on a cron expression or on knowledge events, and to inspect their runs
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Knowledge events that can trigger an agent schedule
+const (
+	AgentScheduleEventKnowledgeParsed  = "knowledge.parsed"
+	AgentScheduleEventKnowledgeFailed  = "knowledge.failed"
+	AgentScheduleEventKnowledgeDeleted = "knowledge.deleted"
+)
+
+// Agent schedule run statuses
+const (
+	AgentRunPending   = "pending"
+	AgentRunRunning   = "running"
+	AgentRunSucceeded = "succeeded"
+	AgentRunFailed    = "failed"
+)
+
+// AgentSchedule runs a custom agent with a fixed prompt on a cron expression, on knowledge events, or both
+type AgentSchedule struct {
+	ID          string `json:"id"`
+	TenantID    uint64 `json:"tenant_id"`
+	AgentID     string `json:"agent_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Prompt      string `json:"prompt"`
+	// Cron is a standard 5-field expression or a descriptor such as @daily, empty for event-only schedules
+	Cron     string `json:"cron"`
+	Timezone string `json:"timezone"` // IANA name, UTC by default
+	// TriggerEvents are the knowledge events that start a run,
+	// optionally limited to TriggerKnowledgeBaseIDs
+	TriggerEvents           []string `json:"trigger_events"`
+	TriggerKnowledgeBaseIDs []string `json:"trigger_knowledge_base_ids"`
+	// OutputKnowledgeBaseID receives each answer as a knowledge entry, empty to keep answers in the session only
+	OutputKnowledgeBaseID string     `json:"output_knowledge_base_id"`
+	NotifyWebhook         bool       `json:"notify_webhook"` // publish agent_run.finished to the webhooks
+	SessionID             string     `json:"session_id"`     // session collecting the runs, set on the first run
+	Enabled               *bool      `json:"enabled,omitempty"`
+	NextRunAt             *time.Time `json:"next_run_at"`
+	LastRunAt             *time.Time `json:"last_run_at"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// AgentRunEvent is a knowledge event that triggered a run
+type AgentRunEvent struct {
+	Type            string    `json:"type"`
+	KnowledgeID     string    `json:"knowledge_id"`
+	KnowledgeBaseID string    `json:"knowledge_base_id"`
+	Title           string    `json:"title"`
+	OccurredAt      time.Time `json:"occurred_at"`
+}
+
+// AgentScheduleRun is a run of an agent schedule
+type AgentScheduleRun struct {
+	ID                string          `json:"id"`
+	TenantID          uint64          `json:"tenant_id"`
+	ScheduleID        string          `json:"schedule_id"`
+	Trigger           string          `json:"trigger"` // cron, event or manual
+	Events            []AgentRunEvent `json:"events,omitempty"`
+	Status            string          `json:"status"`
+	SessionID         string          `json:"session_id"`
+	MessageID         string          `json:"message_id"`
+	Answer            string          `json:"answer"`
+	OutputKnowledgeID string          `json:"output_knowledge_id,omitempty"`
+	Usage             *AgentUsage     `json:"usage,omitempty"`
+	Error             string          `json:"error,omitempty"`
+	StartedAt         *time.Time      `json:"started_at"`
+	FinishedAt        *time.Time      `json:"finished_at"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// AgentScheduleResponse agent schedule response
+type AgentScheduleResponse struct {
+	Success bool          `json:"success"`
+	Data    AgentSchedule `json:"data"`
+}
+
+// AgentScheduleListResponse agent schedule list response
+type AgentScheduleListResponse struct {
+	Success bool            `json:"success"`
+	Data    []AgentSchedule `json:"data"`
+}
+
+// AgentScheduleRunResponse agent schedule run response
+type AgentScheduleRunResponse struct {
+	Success bool             `json:"success"`
+	Data    AgentScheduleRun `json:"data"`
+}
+
+// AgentScheduleRunPage a page of agent schedule runs
+type AgentScheduleRunPage struct {
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+	Data     []AgentScheduleRun `json:"data"`
+}
+
+// CreateAgentSchedule creates an agent schedule, a nil Enabled enables it
+func (c *Client) CreateAgentSchedule(ctx context.Context, schedule *AgentSchedule) (*AgentSchedule, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/agent-schedules", schedule, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentScheduleResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetAgentSchedule gets an agent schedule
+func (c *Client) GetAgentSchedule(ctx context.Context, scheduleID string) (*AgentSchedule, error) {
+	path := fmt.Sprintf("/api/v1/agent-schedules/%s", scheduleID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentScheduleResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// ListAgentSchedules lists the agent schedules of the tenant
+func (c *Client) ListAgentSchedules(ctx context.Context) ([]AgentSchedule, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/agent-schedules", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentScheduleListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// UpdateAgentSchedule replaces the settings of an agent schedule, a nil Enabled enables it
+func (c *Client) UpdateAgentSchedule(ctx context.Context, schedule *AgentSchedule) (*AgentSchedule, error) {
+	path := fmt.Sprintf("/api/v1/agent-schedules/%s", schedule.ID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, schedule, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentScheduleResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// DeleteAgentSchedule deletes an agent schedule, its session and runs are kept
+func (c *Client) DeleteAgentSchedule(ctx context.Context, scheduleID string) error {
+	path := fmt.Sprintf("/api/v1/agent-schedules/%s", scheduleID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// RunAgentSchedule queues a run of an agent schedule now and returns the pending run
+func (c *Client) RunAgentSchedule(ctx context.Context, scheduleID string) (*AgentScheduleRun, error) {
+	path := fmt.Sprintf("/api/v1/agent-schedules/%s/run", scheduleID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentScheduleRunResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// ListAgentScheduleRuns lists the runs of an agent schedule, newest first
+func (c *Client) ListAgentScheduleRuns(ctx context.Context,
+	scheduleID string, page int, pageSize int,
+) (*AgentScheduleRunPage, error) {
+	path := fmt.Sprintf("/api/v1/agent-schedules/%s/runs", scheduleID)
+	query := url.Values{}
+	query.Add("page", strconv.Itoa(page))
+	query.Add("page_size", strconv.Itoa(pageSize))
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                 `json:"success"`
+		Data    AgentScheduleRunPage `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetAgentScheduleRun gets a run of an agent schedule
+func (c *Client) GetAgentScheduleRun(ctx context.Context, scheduleID, runID string) (*AgentScheduleRun, error) {
+	path := fmt.Sprintf("/api/v1/agent-schedules/%s/runs/%s", scheduleID, runID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentScheduleRunResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
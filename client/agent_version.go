@@ -0,0 +1,213 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The Agent Version related interfaces are used to export and import agents,
+// and to browse, compare and roll back the versions of an agent's settings
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AgentVersion is an immutable snapshot of the settings of a custom agent
+type AgentVersion struct {
+	ID          string            `json:"id"`
+	TenantID    uint64            `json:"tenant_id"`
+	AgentID     string            `json:"agent_id"`
+	Version     int               `json:"version"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Avatar      string            `json:"avatar"`
+	Config      CustomAgentConfig `json:"config"`
+	Note        string            `json:"note"` // What produced the version, e.g. "updated" or "rolled back to version 3"
+	CreatedBy   string            `json:"created_by"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// AgentSettingChange is a setting that differs between two versions of an agent
+type AgentSettingChange struct {
+	Path string      `json:"path"` // Dotted path of the setting, e.g. "config.model_id"
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AgentVersionDiff lists the settings changed from one version of an agent to another
+type AgentVersionDiff struct {
+	AgentID     string               `json:"agent_id"`
+	FromVersion int                  `json:"from_version"`
+	ToVersion   int                  `json:"to_version"`
+	Changes     []AgentSettingChange `json:"changes"`
+}
+
+// AgentReferenceMapping maps a reference of an export to a resource of the importing tenant
+type AgentReferenceMapping struct {
+	Kind     string `json:"kind"` // model, knowledge_base, mcp_service, sub_agent or data_source
+	ID       string `json:"id"`   // ID in the exporting environment
+	TargetID string `json:"target_id"`
+}
+
+// AgentImportRequest is the request to preview or perform an agent import
+type AgentImportRequest struct {
+	Content        string                  `json:"content"` // YAML or JSON export document
+	Mappings       []AgentReferenceMapping `json:"mappings,omitempty"`
+	Name           string                  `json:"name,omitempty"`            // Overrides the exported name
+	SkipUnresolved bool                    `json:"skip_unresolved,omitempty"` // Remove unresolved references instead of failing
+}
+
+// AgentReferenceCandidate is a resource a reference may be mapped to
+type AgentReferenceCandidate struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// AgentReferenceResolution is how a reference of an export resolves in the importing tenant
+type AgentReferenceResolution struct {
+	Kind       string                    `json:"kind"`
+	ID         string                    `json:"id"`
+	Name       string                    `json:"name"`
+	Type       string                    `json:"type,omitempty"`
+	Status     string                    `json:"status"` // mapped, matched, ambiguous or missing
+	TargetID   string                    `json:"target_id,omitempty"`
+	Candidates []AgentReferenceCandidate `json:"candidates,omitempty"`
+}
+
+// AgentImportPlan is the outcome of resolving the references of an export
+type AgentImportPlan struct {
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	AgentMode   string                     `json:"agent_mode"`
+	References  []AgentReferenceResolution `json:"references"`
+	Unresolved  int                        `json:"unresolved"`
+}
+
+// ExportAgent exports an agent as a "yaml" or "json" document
+func (c *Client) ExportAgent(ctx context.Context, agentID, format string) ([]byte, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/export", agentID)
+	query := url.Values{}
+	if format != "" {
+		query.Add("format", format)
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, query)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent export: %w", err)
+	}
+	return data, nil
+}
+
+// PreviewAgentImport resolves the references of an export without importing it
+func (c *Client) PreviewAgentImport(ctx context.Context, request *AgentImportRequest) (*AgentImportPlan, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/agents/import/preview", request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool            `json:"success"`
+		Data    AgentImportPlan `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// ImportAgent creates a custom agent from an export
+func (c *Client) ImportAgent(ctx context.Context, request *AgentImportRequest) (*CustomAgent, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/agents/import", request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CustomAgentResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// ListAgentVersions lists the versions of an agent, newest first
+func (c *Client) ListAgentVersions(ctx context.Context, agentID string) ([]AgentVersion, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/versions", agentID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool           `json:"success"`
+		Data    []AgentVersion `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetAgentVersion gets a version of an agent
+func (c *Client) GetAgentVersion(ctx context.Context, agentID string, version int) (*AgentVersion, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/versions/%d", agentID, version)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    AgentVersion `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// DiffAgentVersions compares a version of an agent with an earlier one, from 0 compares with the previous version
+func (c *Client) DiffAgentVersions(ctx context.Context, agentID string, from, to int) (*AgentVersionDiff, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/versions/%d/diff", agentID, to)
+	query := url.Values{}
+	if from > 0 {
+		query.Add("from", strconv.Itoa(from))
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool             `json:"success"`
+		Data    AgentVersionDiff `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// RollbackAgent restores the settings of a version of an agent, recording them as a new version
+func (c *Client) RollbackAgent(ctx context.Context, agentID string, version int) (*CustomAgent, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/versions/%d/rollback", agentID, version)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CustomAgentResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ArtifactType is the kind of artifact a tool attached to an assistant message
+type ArtifactType string
+
+const (
+	ArtifactChart ArtifactType = "chart" // Vega-Lite chart specification
+	ArtifactCSV   ArtifactType = "csv"
+	ArtifactXLSX  ArtifactType = "xlsx"
+)
+
+// MessageArtifact is a chart or file produced by a tool call, referenced from the answer as <artifact id="..."/>
+type MessageArtifact struct {
+	ID          string          `json:"id"`
+	SessionID   string          `json:"session_id"`
+	MessageID   string          `json:"message_id"`
+	ToolCallID  string          `json:"tool_call_id"`
+	Type        ArtifactType    `json:"type"`
+	Title       string          `json:"title"`
+	Spec        json.RawMessage `json:"spec,omitempty"` // Vega-Lite specification of a chart
+	FileName    string          `json:"file_name,omitempty"`
+	ContentType string          `json:"content_type,omitempty"`
+	Size        int64           `json:"size,omitempty"`
+	RowCount    int             `json:"row_count"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// ListMessageArtifacts lists the artifacts of an assistant message, without file content
+func (c *Client) ListMessageArtifacts(ctx context.Context, sessionID, messageID string) ([]MessageArtifact, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/messages/%s/artifacts", sessionID, messageID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool              `json:"success"`
+		Data    []MessageArtifact `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetArtifact gets an artifact of a session
+func (c *Client) GetArtifact(ctx context.Context, sessionID, artifactID string) (*MessageArtifact, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/artifacts/%s", sessionID, artifactID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool            `json:"success"`
+		Data    MessageArtifact `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// DownloadArtifact downloads the file of an artifact, or the Vega-Lite specification of a chart
+func (c *Client) DownloadArtifact(ctx context.Context, sessionID, artifactID string) ([]byte, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/artifacts/%s/download", sessionID, artifactID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return data, nil
+}
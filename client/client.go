@@ -6,18 +6,42 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 )
 
+// Default retry settings, see WithRetry
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff     = 30 * time.Second
+)
+
 // Client is the client for interacting with the WeKnora service
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
 	token      string
+	// Retry settings for idempotent requests
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// APIError is returned when the server responds with a non-2xx status code
+type APIError struct {
+	StatusCode int    // HTTP status code
+	Body       string // Raw response body
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("HTTP error %d: %s", e.StatusCode, e.Body)
 }
 
 // ClientOption defines client configuration options
@@ -37,6 +61,23 @@ func WithToken(token string) ClientOption {
 	}
 }
 
+// WithRetry sets how often idempotent requests (GET, PUT, DELETE) are retried after network errors,
+// 429 and 502-504 responses. The delay starts at backoff and doubles on each attempt, a Retry-After
+// header from the server takes precedence. Set maxRetries to 0 to disable retries.
+func WithRetry(maxRetries int, backoff time.Duration) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithHTTPClient sets the underlying HTTP client, e.g. to use a custom transport
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
 // NewClient creates a new client instance
 func NewClient(baseURL string, options ...ClientOption) *Client {
 	client := &Client{
@@ -44,6 +85,8 @@ func NewClient(baseURL string, options ...ClientOption) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		maxRetries:   DefaultMaxRetries,
+		retryBackoff: DefaultRetryBackoff,
 	}
 
 	for _, option := range options {
@@ -53,17 +96,17 @@ func NewClient(baseURL string, options ...ClientOption) *Client {
 	return client
 }
 
-// doRequest executes an HTTP request
+// doRequest executes an HTTP request, retrying idempotent requests on transient failures
 func (c *Client) doRequest(ctx context.Context,
 	method, path string, body interface{}, query url.Values,
 ) (*http.Response, error) {
-	var reqBody io.Reader
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonData)
 	}
 
 	url := fmt.Sprintf("%s%s", c.baseURL, path)
@@ -71,20 +114,95 @@ func (c *Client) doRequest(ctx context.Context,
 		url = fmt.Sprintf("%s?%s", url, query.Encode())
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	retries := 0
+	if isIdempotent(method) {
+		retries = c.maxRetries
 	}
+	for attempt := 0; ; attempt++ {
+		var reqBody io.Reader
+		if jsonData != nil {
+			reqBody = bytes.NewReader(jsonData)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		c.setCommonHeaders(ctx, req)
+
+		resp, err := c.httpClient.Do(req)
+		if attempt >= retries || !shouldRetry(resp, err) {
+			return resp, err
+		}
 
-	req.Header.Set("Content-Type", "application/json")
+		delay := c.retryDelay(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// setCommonHeaders sets the authentication and tracing headers of a request
+func (c *Client) setCommonHeaders(ctx context.Context, req *http.Request) {
 	if c.token != "" {
 		req.Header.Set("X-API-Key", c.token)
 	}
 	if requestID := ctx.Value("RequestID"); requestID != nil {
 		req.Header.Set("X-Request-ID", requestID.(string))
 	}
+}
 
-	return c.httpClient.Do(req)
+// isIdempotent reports whether repeating a request has the same effect as sending it once
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// shouldRetry reports whether a failed attempt is worth repeating
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		// Cancelled or expired contexts are final
+		return resp == nil && !isContextError(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// isContextError reports whether err was caused by a cancelled or expired context
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// retryDelay returns how long to wait before the next attempt
+func (c *Client) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			if delay := time.Duration(seconds) * time.Second; delay < maxRetryBackoff {
+				return delay
+			}
+			return maxRetryBackoff
+		}
+	}
+	delay := c.retryBackoff << attempt
+	if delay <= 0 || delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	// Add up to 20% jitter so concurrent clients do not retry in lockstep
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
 }
 
 // parseResponse parses an HTTP response
@@ -93,7 +211,7 @@ func parseResponse(resp *http.Response, target interface{}) error {
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	if target == nil {
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestServer answers with the given status codes in turn, then 200
+func newTestServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+		if call <= len(statuses) {
+			if statuses[call-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(statuses[call-1])
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestDoRequestRetriesIdempotentRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		statuses  []int
+		wantCalls int32
+		wantCode  int
+	}{
+		{"GET retries 5xx", http.MethodGet, []int{http.StatusBadGateway, http.StatusServiceUnavailable}, 3, http.StatusOK},
+		{"DELETE retries 429", http.MethodDelete, []int{http.StatusTooManyRequests}, 2, http.StatusOK},
+		{"PUT retries 504", http.MethodPut, []int{http.StatusGatewayTimeout}, 2, http.StatusOK},
+		{"GET gives up after max retries", http.MethodGet,
+			[]int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable}, 3,
+			http.StatusServiceUnavailable},
+		{"GET does not retry 500", http.MethodGet, []int{http.StatusInternalServerError}, 1,
+			http.StatusInternalServerError},
+		{"POST is never retried", http.MethodPost, []int{http.StatusServiceUnavailable}, 1,
+			http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := newTestServer(t, tt.statuses...)
+			c := NewClient(server.URL, WithRetry(2, time.Millisecond))
+
+			resp, err := c.doRequest(context.Background(), tt.method, "/test", map[string]string{"k": "v"}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoRequestStopsRetryingOnCancel(t *testing.T) {
+	server, calls := newTestServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	c := NewClient(server.URL, WithRetry(3, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.doRequest(ctx, http.MethodGet, "/test", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	c := NewClient("http://localhost", WithRetry(3, time.Second))
+	withRetryAfter := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+	}
+
+	if got := c.retryDelay(0, withRetryAfter("2")); got != 2*time.Second {
+		t.Errorf("Retry-After 2: delay = %v, want 2s", got)
+	}
+	if got := c.retryDelay(0, withRetryAfter("3600")); got != maxRetryBackoff {
+		t.Errorf("Retry-After 3600: delay = %v, want %v", got, maxRetryBackoff)
+	}
+	// An HTTP date or missing header falls back to exponential backoff with up to 20% jitter
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		got := c.retryDelay(attempt, withRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT"))
+		if got < want || got > want+want/5 {
+			t.Errorf("attempt %d: delay = %v, want %v plus jitter", attempt, got, want)
+		}
+	}
+	if got := c.retryDelay(10, nil); got < maxRetryBackoff || got > maxRetryBackoff+maxRetryBackoff/5 {
+		t.Errorf("attempt 10: delay = %v, want capped at %v", got, maxRetryBackoff)
+	}
+}
@@ -0,0 +1,279 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The Credential related interfaces are used to manage model provider credentials
+// The Provider related interfaces describe the supported model providers and their preset models
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// QuotaConfig represents the usage quota of a credential
+type QuotaConfig struct {
+	DailyLimit     int64   `json:"daily_limit,omitempty"`
+	MonthlyLimit   int64   `json:"monthly_limit,omitempty"`
+	TokenLimit     int64   `json:"token_limit,omitempty"`
+	AlertThreshold float64 `json:"alert_threshold,omitempty"`
+}
+
+// ProviderCredential represents the credential of a model provider
+// Secret values (api_key, secret_key) are masked in responses
+type ProviderCredential struct {
+	ID          string            `json:"id"`
+	TenantID    uint64            `json:"tenant_id"`
+	Provider    string            `json:"provider"`
+	Name        string            `json:"name"`
+	Credentials map[string]string `json:"credentials"`
+	BaseURL     string            `json:"base_url"`
+	IsDefault   bool              `json:"is_default"`
+	Status      string            `json:"status"`
+	QuotaConfig *QuotaConfig      `json:"quota_config,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// CreateCredentialRequest credential creation request
+type CreateCredentialRequest struct {
+	Provider    string            `json:"provider"`
+	Name        string            `json:"name"`
+	Credentials map[string]string `json:"credentials"`
+	BaseURL     string            `json:"base_url"`
+	IsDefault   bool              `json:"is_default"`
+	QuotaConfig *QuotaConfig      `json:"quota_config,omitempty"`
+}
+
+// UpdateCredentialRequest credential update request
+type UpdateCredentialRequest struct {
+	Name        string            `json:"name"`
+	Credentials map[string]string `json:"credentials"`
+	BaseURL     string            `json:"base_url"`
+	IsDefault   bool              `json:"is_default"`
+	QuotaConfig *QuotaConfig      `json:"quota_config,omitempty"`
+}
+
+// CredentialResponse credential response
+type CredentialResponse struct {
+	Success bool               `json:"success"`
+	Data    ProviderCredential `json:"data"`
+}
+
+// CredentialListResponse credential list response
+type CredentialListResponse struct {
+	Success bool                 `json:"success"`
+	Data    []ProviderCredential `json:"data"`
+}
+
+// ModelProvider describes a supported model provider
+type ModelProvider struct {
+	Name           string             `json:"name"`
+	DisplayName    string             `json:"display_name"`
+	Description    string             `json:"description"`
+	Icon           string             `json:"icon,omitempty"`
+	Website        string             `json:"website,omitempty"`
+	DocsURL        string             `json:"docs_url,omitempty"`
+	AuthConfig     ProviderAuthConfig `json:"auth_config"`
+	SupportedTypes []string           `json:"supported_types"`
+	PresetModels   []PresetModel      `json:"preset_models,omitempty"`
+	Endpoints      map[string]string  `json:"endpoints"`
+	Features       ProviderFeatures   `json:"features"`
+}
+
+// ProviderAuthConfig describes the credential fields a provider requires
+type ProviderAuthConfig struct {
+	Type   string `json:"type"`
+	Fields []struct {
+		Key         string `json:"key"`
+		Label       string `json:"label"`
+		Type        string `json:"type"`
+		Required    bool   `json:"required"`
+		Placeholder string `json:"placeholder"`
+		HelpText    string `json:"help_text,omitempty"`
+	} `json:"fields"`
+	HelpText string `json:"help_text,omitempty"`
+	HelpURL  string `json:"help_url,omitempty"`
+}
+
+// PresetModel describes a model preset by a provider
+type PresetModel struct {
+	ModelID      string   `json:"model_id"`
+	DisplayName  string   `json:"display_name"`
+	ModelType    string   `json:"model_type"` // chat, embedding, rerank or vllm
+	Capabilities []string `json:"capabilities"`
+	ContextSize  int      `json:"context_size"`
+	Pricing      *struct {
+		InputPrice  float64 `json:"input_price"`
+		OutputPrice float64 `json:"output_price"`
+		Currency    string  `json:"currency"`
+	} `json:"pricing,omitempty"`
+	Deprecated bool `json:"deprecated,omitempty"`
+}
+
+// ProviderFeatures describes the features of a provider
+type ProviderFeatures struct {
+	SupportsStreaming    bool `json:"supports_streaming"`
+	SupportsFunctionCall bool `json:"supports_function_call"`
+	SupportsVision       bool `json:"supports_vision"`
+	SupportsJSON         bool `json:"supports_json_mode"`
+	SupportsCustomModel  bool `json:"supports_custom_model"`
+}
+
+// CreateCredential creates a provider credential
+func (c *Client) CreateCredential(ctx context.Context, request *CreateCredentialRequest) (*ProviderCredential, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/credentials", request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CredentialResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetCredential gets a provider credential
+func (c *Client) GetCredential(ctx context.Context, credentialID string) (*ProviderCredential, error) {
+	path := fmt.Sprintf("/api/v1/credentials/%s", credentialID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CredentialResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// ListCredentials lists the credentials of the current tenant, optionally of a single provider
+func (c *Client) ListCredentials(ctx context.Context, provider string) ([]ProviderCredential, error) {
+	query := url.Values{}
+	if provider != "" {
+		query.Add("provider", provider)
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/credentials", nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CredentialListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// UpdateCredential updates a provider credential
+func (c *Client) UpdateCredential(ctx context.Context,
+	credentialID string, request *UpdateCredentialRequest,
+) (*ProviderCredential, error) {
+	path := fmt.Sprintf("/api/v1/credentials/%s", credentialID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CredentialResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// DeleteCredential deletes a provider credential
+func (c *Client) DeleteCredential(ctx context.Context, credentialID string) error {
+	path := fmt.Sprintf("/api/v1/credentials/%s", credentialID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// TestCredential tests the connection to a provider with a credential
+// A failed connection test is returned as an error with the server's message
+func (c *Client) TestCredential(ctx context.Context, credentialID string) error {
+	path := fmt.Sprintf("/api/v1/credentials/%s/test", credentialID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return err
+	}
+	if !response.Success {
+		return fmt.Errorf("credential test failed: %s", response.Message)
+	}
+	return nil
+}
+
+// ListProviders lists the supported model providers
+func (c *Client) ListProviders(ctx context.Context) ([]ModelProvider, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/providers", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool            `json:"success"`
+		Data    []ModelProvider `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetProvider gets a model provider by name
+func (c *Client) GetProvider(ctx context.Context, provider string) (*ModelProvider, error) {
+	path := fmt.Sprintf("/api/v1/providers/%s", provider)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool          `json:"success"`
+		Data    ModelProvider `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetProviderModels lists the preset models of a provider, optionally of a single model type
+func (c *Client) GetProviderModels(ctx context.Context, provider string, modelType string) ([]PresetModel, error) {
+	path := fmt.Sprintf("/api/v1/providers/%s/models", provider)
+	query := url.Values{}
+	if modelType != "" {
+		query.Add("model_type", modelType)
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool          `json:"success"`
+		Data    []PresetModel `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -0,0 +1,250 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The Custom Agent related interfaces are used to manage configurable agents
+// Agents can be created, retrieved, updated, deleted and copied
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Agent modes
+const (
+	AgentModeQuickAnswer    = "quick-answer"    // RAG mode for quick Q&A
+	AgentModeSmartReasoning = "smart-reasoning" // ReAct mode for multi-step reasoning
+)
+
+// CustomAgent represents a configurable agent
+type CustomAgent struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Avatar      string            `json:"avatar"`
+	IsBuiltin   bool              `json:"is_builtin"`
+	TenantID    uint64            `json:"tenant_id"`
+	CreatedBy   string            `json:"created_by"`
+	Config      CustomAgentConfig `json:"config"`
+	Version     int               `json:"version"` // Current version of the settings, see ListAgentVersions
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// CustomAgentConfig represents the configuration of a custom agent
+type CustomAgentConfig struct {
+	// Basic settings
+	AgentMode       string `json:"agent_mode"`
+	SystemPrompt    string `json:"system_prompt"`
+	ContextTemplate string `json:"context_template"`
+
+	// Model settings
+	ModelID             string  `json:"model_id"`
+	RerankModelID       string  `json:"rerank_model_id"`
+	Temperature         float64 `json:"temperature"`
+	MaxCompletionTokens int     `json:"max_completion_tokens"`
+
+	// Agent mode settings
+	MaxIterations     int               `json:"max_iterations"`
+	AllowedTools      []string          `json:"allowed_tools"`
+	ReflectionEnabled bool              `json:"reflection_enabled"`
+	MCPSelectionMode  string            `json:"mcp_selection_mode"` // all, selected or none
+	MCPServices       []string          `json:"mcp_services"`
+	MCPPrompt         *MCPPromptBinding `json:"mcp_prompt,omitempty"`
+	SubAgents         []string          `json:"sub_agents,omitempty"`   // agents this agent can delegate sub-tasks to
+	DataSources       []string          `json:"data_sources,omitempty"` // data sources the agent can query read-only
+	Budget            *AgentBudget      `json:"budget,omitempty"`       // limits of one run, nil for unlimited
+
+	// Knowledge base settings
+	KBSelectionMode    string   `json:"kb_selection_mode"` // all, selected or none
+	KnowledgeBases     []string `json:"knowledge_bases"`
+	SupportedFileTypes []string `json:"supported_file_types"`
+
+	// FAQ strategy settings
+	FAQPriorityEnabled       bool    `json:"faq_priority_enabled"`
+	FAQDirectAnswerThreshold float64 `json:"faq_direct_answer_threshold"`
+	FAQScoreBoost            float64 `json:"faq_score_boost"`
+
+	// Web search settings
+	WebSearchEnabled    bool `json:"web_search_enabled"`
+	WebSearchMaxResults int  `json:"web_search_max_results"`
+
+	// Multi-turn conversation settings
+	MultiTurnEnabled bool `json:"multi_turn_enabled"`
+	HistoryTurns     int  `json:"history_turns"`
+
+	// Long-term memory settings
+	MemoryEnabled          bool   `json:"memory_enabled"`
+	MemoryEmbeddingModelID string `json:"memory_embedding_model_id"`
+
+	// Retrieval strategy settings
+	EmbeddingTopK    int     `json:"embedding_top_k"`
+	KeywordThreshold float64 `json:"keyword_threshold"`
+	VectorThreshold  float64 `json:"vector_threshold"`
+	RerankTopK       int     `json:"rerank_top_k"`
+	RerankThreshold  float64 `json:"rerank_threshold"`
+	// local (default) or global, answering from the summaries of the knowledge graph communities
+	KnowledgeSearchMode string `json:"knowledge_search_mode"`
+
+	// Advanced settings
+	EnableQueryExpansion bool   `json:"enable_query_expansion"`
+	EnableRewrite        bool   `json:"enable_rewrite"`
+	RewritePromptSystem  string `json:"rewrite_prompt_system"`
+	RewritePromptUser    string `json:"rewrite_prompt_user"`
+	FallbackStrategy     string `json:"fallback_strategy"` // fixed or model
+	FallbackResponse     string `json:"fallback_response"`
+	FallbackPrompt       string `json:"fallback_prompt"`
+}
+
+// MCPPromptBinding binds a prompt of an MCP service as the system prompt of an agent
+type MCPPromptBinding struct {
+	ServiceID  string            `json:"service_id"`
+	PromptName string            `json:"prompt_name"`
+	Arguments  map[string]string `json:"arguments"`
+}
+
+// AgentBudget limits the resources one agent run may consume, 0 means unlimited
+type AgentBudget struct {
+	MaxTokens          int `json:"max_tokens"`
+	MaxDurationSeconds int `json:"max_duration_seconds"`
+	MaxToolCalls       int `json:"max_tool_calls"`
+}
+
+// AgentUsage is the consumption of an agent run, sent in the data of the complete event
+type AgentUsage struct {
+	Rounds           int          `json:"rounds"`
+	LLMCalls         int          `json:"llm_calls"`
+	PromptTokens     int          `json:"prompt_tokens"`
+	CompletionTokens int          `json:"completion_tokens"`
+	TotalTokens      int          `json:"total_tokens"`
+	ToolCalls        int          `json:"tool_calls"`
+	DurationMs       int64        `json:"duration_ms"`
+	Budget           *AgentBudget `json:"budget,omitempty"`
+	BudgetExceeded   string       `json:"budget_exceeded,omitempty"` // tokens, duration or tool_calls
+}
+
+// CustomAgentRequest is the request body for creating or updating an agent
+type CustomAgentRequest struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Avatar      string            `json:"avatar"`
+	Config      CustomAgentConfig `json:"config"`
+}
+
+// CustomAgentResponse custom agent response
+type CustomAgentResponse struct {
+	Success bool        `json:"success"`
+	Data    CustomAgent `json:"data"`
+}
+
+// CustomAgentListResponse custom agent list response
+type CustomAgentListResponse struct {
+	Success bool          `json:"success"`
+	Data    []CustomAgent `json:"data"`
+}
+
+// CreateAgent creates a custom agent
+func (c *Client) CreateAgent(ctx context.Context, request *CustomAgentRequest) (*CustomAgent, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/agents", request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CustomAgentResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetAgent gets a custom or built-in agent
+func (c *Client) GetAgent(ctx context.Context, agentID string) (*CustomAgent, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s", agentID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CustomAgentResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// ListAgents lists the built-in and custom agents of the current tenant
+func (c *Client) ListAgents(ctx context.Context) ([]CustomAgent, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/agents", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CustomAgentListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// UpdateAgent updates a custom agent, replacing its whole configuration
+func (c *Client) UpdateAgent(ctx context.Context, agentID string, request *CustomAgentRequest) (*CustomAgent, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s", agentID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CustomAgentResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// DeleteAgent deletes a custom agent, built-in agents cannot be deleted
+func (c *Client) DeleteAgent(ctx context.Context, agentID string) error {
+	path := fmt.Sprintf("/api/v1/agents/%s", agentID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// CopyAgent copies an agent into a new custom agent
+func (c *Client) CopyAgent(ctx context.Context, agentID string) (*CustomAgent, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/copy", agentID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CustomAgentResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetAgentPlaceholders gets the prompt placeholders available to agents, grouped by prompt field
+func (c *Client) GetAgentPlaceholders(ctx context.Context) (map[string]json.RawMessage, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/agents/placeholders", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                       `json:"success"`
+		Data    map[string]json.RawMessage `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
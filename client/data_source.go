@@ -0,0 +1,161 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The Data Source related interfaces are used to manage the external databases agents can query read-only
+// Data sources can be created, retrieved, updated, deleted and their allowed tables described
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Data source types
+const (
+	DataSourcePostgres = "postgres"
+	DataSourceMySQL    = "mysql"
+	DataSourceSQLite   = "sqlite"
+)
+
+// DataSource represents an external database agents can query read-only
+type DataSource struct {
+	ID            string               `json:"id"`
+	TenantID      uint64               `json:"tenant_id"`
+	Name          string               `json:"name"`
+	Description   string               `json:"description"`
+	Type          string               `json:"type"` // postgres, mysql or sqlite
+	Connection    DataSourceConnection `json:"connection"`
+	AllowedTables []string             `json:"allowed_tables"` // optionally schema-qualified, e.g. sales.orders
+	RowLimit      int                  `json:"row_limit"`      // 0 uses the default limit
+	Enabled       *bool                `json:"enabled,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+// DataSourceConnection represents how to connect to a data source
+// The password is never returned, leave it empty on update to keep the current one
+type DataSourceConnection struct {
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Database string `json:"database,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	SSLMode  string `json:"ssl_mode,omitempty"`
+	Path     string `json:"path,omitempty"` // SQLite file relative to the server's SQLite directory
+}
+
+// DataSourceTable describes an allowed table of a data source, without columns if it does not exist
+type DataSourceTable struct {
+	Name    string             `json:"name"`
+	Columns []DataSourceColumn `json:"columns"`
+}
+
+// DataSourceColumn describes a column of a data source table
+type DataSourceColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// DataSourceResponse data source response
+type DataSourceResponse struct {
+	Success bool       `json:"success"`
+	Data    DataSource `json:"data"`
+}
+
+// DataSourceListResponse data source list response
+type DataSourceListResponse struct {
+	Success bool         `json:"success"`
+	Data    []DataSource `json:"data"`
+}
+
+// CreateDataSource creates a data source
+func (c *Client) CreateDataSource(ctx context.Context, dataSource *DataSource) (*DataSource, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/data-sources", dataSource, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DataSourceResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetDataSource gets a data source
+func (c *Client) GetDataSource(ctx context.Context, dataSourceID string) (*DataSource, error) {
+	path := fmt.Sprintf("/api/v1/data-sources/%s", dataSourceID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DataSourceResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// ListDataSources lists the data sources of the tenant
+func (c *Client) ListDataSources(ctx context.Context) ([]DataSource, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/data-sources", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DataSourceListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// UpdateDataSource replaces the settings of a data source, a nil Enabled enables it
+func (c *Client) UpdateDataSource(ctx context.Context, dataSource *DataSource) (*DataSource, error) {
+	path := fmt.Sprintf("/api/v1/data-sources/%s", dataSource.ID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, dataSource, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DataSourceResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// DeleteDataSource deletes a data source
+func (c *Client) DeleteDataSource(ctx context.Context, dataSourceID string) error {
+	path := fmt.Sprintf("/api/v1/data-sources/%s", dataSourceID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// GetDataSourceSchema connects to a data source and describes its allowed tables
+func (c *Client) GetDataSourceSchema(ctx context.Context, dataSourceID string) ([]DataSourceTable, error) {
+	path := fmt.Sprintf("/api/v1/data-sources/%s/schema", dataSourceID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool              `json:"success"`
+		Data    []DataSourceTable `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -6,61 +6,123 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 )
 
+// EvaluationStatus represents the status of an evaluation task
+type EvaluationStatus int
+
+// Evaluation task statuses
+const (
+	EvaluationStatusPending EvaluationStatus = iota // Task is waiting to start
+	EvaluationStatusRunning                         // Task is in progress
+	EvaluationStatusSuccess                         // Task completed successfully
+	EvaluationStatusFailed                          // Task failed
+)
+
+// String returns the name of the status
+func (s EvaluationStatus) String() string {
+	switch s {
+	case EvaluationStatusPending:
+		return "pending"
+	case EvaluationStatusRunning:
+		return "running"
+	case EvaluationStatusSuccess:
+		return "success"
+	case EvaluationStatusFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Done reports whether the task has finished, successfully or not
+func (s EvaluationStatus) Done() bool {
+	return s == EvaluationStatusSuccess || s == EvaluationStatusFailed
+}
+
 // EvaluationTask represents an evaluation task
 // Contains basic information about a model evaluation task
 type EvaluationTask struct {
-	ID          string `json:"id"`           // Task unique identifier
-	Status      string `json:"status"`       // Task status: pending, running, completed, failed
-	Progress    int    `json:"progress"`     // Task progress, integer value 0-100
-	DatasetID   string `json:"dataset_id"`   // Evaluation dataset ID
-	EmbeddingID string `json:"embedding_id"` // Embedding model ID
-	ChatID      string `json:"chat_id"`      // Chat model ID
-	RerankID    string `json:"rerank_id"`    // Reranking model ID
-	CreatedAt   string `json:"created_at"`   // Task creation time
-	CompleteAt  string `json:"complete_at"`  // Task completion time
-	ErrorMsg    string `json:"error_msg"`    // Error message, has value when task fails
+	ID        string           `json:"id"`                // Task unique identifier
+	TenantID  uint64           `json:"tenant_id"`         // Tenant ID
+	DatasetID string           `json:"dataset_id"`        // Evaluation dataset ID
+	StartTime time.Time        `json:"start_time"`        // Task start time
+	Status    EvaluationStatus `json:"status"`            // Task status
+	ErrMsg    string           `json:"err_msg,omitempty"` // Error message, has value when task fails
+	Total     int              `json:"total,omitempty"`   // Total number of samples
+	Finished  int              `json:"finished,omitempty"`
+}
+
+// EvaluationMetrics contains the metrics of an evaluation task
+type EvaluationMetrics struct {
+	RetrievalMetrics  RetrievalMetrics  `json:"retrieval_metrics"`
+	GenerationMetrics GenerationMetrics `json:"generation_metrics"`
 }
 
-// EvaluationResult represents the evaluation results
-// Contains detailed evaluation result information
-type EvaluationResult struct {
-	TaskID       string                   `json:"task_id"`       // Associated task ID
-	Status       string                   `json:"status"`        // Task status
-	Progress     int                      `json:"progress"`      // Task progress
-	TotalQueries int                      `json:"total_queries"` // Total number of queries
-	TotalSamples int                      `json:"total_samples"` // Total number of samples
-	Metrics      map[string]float64       `json:"metrics"`       // Evaluation metrics collection
-	QueriesStat  []map[string]interface{} `json:"queries_stat"`  // Statistics for each query
-	CreatedAt    string                   `json:"created_at"`    // Creation time
-	CompleteAt   string                   `json:"complete_at"`   // Completion time
-	ErrorMsg     string                   `json:"error_msg"`     // Error message
+// RetrievalMetrics contains metrics for retrieval evaluation
+type RetrievalMetrics struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	NDCG3     float64 `json:"ndcg3"`  // Normalized Discounted Cumulative Gain at 3
+	NDCG10    float64 `json:"ndcg10"` // Normalized Discounted Cumulative Gain at 10
+	MRR       float64 `json:"mrr"`    // Mean Reciprocal Rank
+	MAP       float64 `json:"map"`    // Mean Average Precision
+}
+
+// GenerationMetrics contains metrics for text generation evaluation
+type GenerationMetrics struct {
+	BLEU1  float64 `json:"bleu1"`
+	BLEU2  float64 `json:"bleu2"`
+	BLEU4  float64 `json:"bleu4"`
+	ROUGE1 float64 `json:"rouge1"`
+	ROUGE2 float64 `json:"rouge2"`
+	ROUGEL float64 `json:"rougel"`
+}
+
+// Map returns the metrics by name, e.g. "precision", "ndcg10" or "rougel"
+func (m *EvaluationMetrics) Map() map[string]float64 {
+	r, g := m.RetrievalMetrics, m.GenerationMetrics
+	return map[string]float64{
+		"precision": r.Precision,
+		"recall":    r.Recall,
+		"ndcg3":     r.NDCG3,
+		"ndcg10":    r.NDCG10,
+		"mrr":       r.MRR,
+		"map":       r.MAP,
+		"bleu1":     g.BLEU1,
+		"bleu2":     g.BLEU2,
+		"bleu4":     g.BLEU4,
+		"rouge1":    g.ROUGE1,
+		"rouge2":    g.ROUGE2,
+		"rougel":    g.ROUGEL,
+	}
+}
+
+// EvaluationDetail represents an evaluation task with its parameters and metrics
+type EvaluationDetail struct {
+	Task   *EvaluationTask    `json:"task"`
+	Params json.RawMessage    `json:"params"`           // Retrieval and generation parameters used by the task
+	Metric *EvaluationMetrics `json:"metric,omitempty"` // Metrics of the finished samples
 }
 
 // EvaluationRequest represents an evaluation request
 // Parameters used to start a new evaluation task
 type EvaluationRequest struct {
-	DatasetID        string `json:"dataset_id"`   // Dataset ID to evaluate
-	EmbeddingModelID string `json:"embedding_id"` // Embedding model ID
-	ChatModelID      string `json:"chat_id"`      // Chat model ID
-	RerankModelID    string `json:"rerank_id"`    // Reranking model ID
-}
-
-// EvaluationTaskResponse represents an evaluation task response
-// API response structure for evaluation tasks
-type EvaluationTaskResponse struct {
-	Success bool           `json:"success"` // Whether operation was successful
-	Data    EvaluationTask `json:"data"`    // Evaluation task data
+	DatasetID       string `json:"dataset_id"`        // Dataset ID to evaluate
+	KnowledgeBaseID string `json:"knowledge_base_id"` // Knowledge base to evaluate, a temporary one is created if empty
+	ChatModelID     string `json:"chat_id"`           // Chat model ID
+	RerankModelID   string `json:"rerank_id"`         // Reranking model ID
 }
 
-// EvaluationResultResponse represents an evaluation result response
-// API response structure for evaluation results
-type EvaluationResultResponse struct {
-	Success bool             `json:"success"` // Whether operation was successful
-	Data    EvaluationResult `json:"data"`    // Evaluation result data
+// EvaluationResponse represents an evaluation response
+type EvaluationResponse struct {
+	Success bool             `json:"success"`
+	Data    EvaluationDetail `json:"data"`
 }
 
 // StartEvaluation starts an evaluation task
@@ -70,15 +132,15 @@ type EvaluationResultResponse struct {
 //   - request: Evaluation request parameters, including dataset ID and model IDs
 //
 // Returns:
-//   - *EvaluationTask: Created evaluation task information
+//   - *EvaluationDetail: Created evaluation task information
 //   - error: Error information if the request fails
-func (c *Client) StartEvaluation(ctx context.Context, request *EvaluationRequest) (*EvaluationTask, error) {
-	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/evaluation", request, nil)
+func (c *Client) StartEvaluation(ctx context.Context, request *EvaluationRequest) (*EvaluationDetail, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/evaluation/", request, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var response EvaluationTaskResponse
+	var response EvaluationResponse
 	if err := parseResponse(resp, &response); err != nil {
 		return nil, err
 	}
@@ -87,27 +149,62 @@ func (c *Client) StartEvaluation(ctx context.Context, request *EvaluationRequest
 }
 
 // GetEvaluationResult retrieves evaluation results
-// Retrieves detailed results for an evaluation task by task ID
+// Retrieves the status and metrics of an evaluation task by task ID
 // Parameters:
 //   - ctx: Context, used for passing request context information
 //   - taskID: Evaluation task ID, used to identify the specific evaluation task to query
 //
 // Returns:
-//   - *EvaluationResult: Detailed evaluation task results
+//   - *EvaluationDetail: Evaluation task status and metrics
 //   - error: Error information if the request fails
-func (c *Client) GetEvaluationResult(ctx context.Context, taskID string) (*EvaluationResult, error) {
+func (c *Client) GetEvaluationResult(ctx context.Context, taskID string) (*EvaluationDetail, error) {
 	queryParams := url.Values{}
 	queryParams.Add("task_id", taskID)
 
-	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/evaluation", nil, queryParams)
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/evaluation/", nil, queryParams)
 	if err != nil {
 		return nil, err
 	}
 
-	var response EvaluationResultResponse
+	var response EvaluationResponse
 	if err := parseResponse(resp, &response); err != nil {
 		return nil, err
 	}
 
 	return &response.Data, nil
 }
+
+// WaitForEvaluation polls an evaluation task until it finishes or ctx is done
+// The progress callback, if not nil, is called after each poll
+// A failed task is returned along with an error carrying its error message
+func (c *Client) WaitForEvaluation(ctx context.Context,
+	taskID string, interval time.Duration, progress func(*EvaluationDetail),
+) (*EvaluationDetail, error) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		detail, err := c.GetEvaluationResult(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			progress(detail)
+		}
+		if detail.Task != nil && detail.Task.Status.Done() {
+			if detail.Task.Status == EvaluationStatusFailed {
+				return detail, fmt.Errorf("evaluation task %s failed: %s", taskID, detail.Task.ErrMsg)
+			}
+			return detail, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -158,12 +159,7 @@ func (c *Client) CreateKnowledgeFromFile(ctx context.Context,
 
 	// Set request headers
 	req.Header.Set("Content-Type", writer.FormDataContentType())
-	if c.token != "" {
-		req.Header.Set("X-API-Key", c.token)
-	}
-	if requestID := ctx.Value("RequestID"); requestID != nil {
-		req.Header.Set("X-Request-ID", requestID.(string))
-	}
+	c.setCommonHeaders(ctx, req)
 
 	// Set the request body
 	req.Body = io.NopCloser(body)
@@ -383,3 +379,81 @@ func (c *Client) UpdateImageInfo(ctx context.Context,
 
 	return parseResponse(resp, &response)
 }
+
+// KnowledgeFileSearchParams represents the parameters for searching knowledge files by name
+type KnowledgeFileSearchParams struct {
+	Keyword   string   // Keyword matched against file names and titles
+	Offset    int      // Number of entries to skip
+	Limit     int      // Maximum number of entries, the server defaults to 20
+	FileTypes []string // Optional file type filter, e.g. csv, xlsx
+}
+
+// GlobalSearchResult represents a chunk matched by a global knowledge search
+type GlobalSearchResult struct {
+	ID                string  `json:"id"`
+	Content           string  `json:"content"`
+	KnowledgeID       string  `json:"knowledge_id"`
+	KnowledgeTitle    string  `json:"knowledge_title"`
+	KnowledgeBaseID   string  `json:"knowledge_base_id"`
+	KnowledgeBaseName string  `json:"knowledge_base_name"`
+	Score             float64 `json:"score"`
+	MatchType         string  `json:"match_type"`
+}
+
+// SearchKnowledgeFiles searches the knowledge files of the current tenant by name
+// Returns the matched entries and whether more entries are available
+func (c *Client) SearchKnowledgeFiles(ctx context.Context, params *KnowledgeFileSearchParams) ([]Knowledge, bool, error) {
+	queryParams := url.Values{}
+	queryParams.Add("keyword", params.Keyword)
+	queryParams.Add("offset", strconv.Itoa(params.Offset))
+	if params.Limit > 0 {
+		queryParams.Add("limit", strconv.Itoa(params.Limit))
+	}
+	if len(params.FileTypes) > 0 {
+		queryParams.Add("file_types", strings.Join(params.FileTypes, ","))
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/knowledge/search", nil, queryParams)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var response struct {
+		Success bool        `json:"success"`
+		Data    []Knowledge `json:"data"`
+		HasMore bool        `json:"has_more"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, false, err
+	}
+	return response.Data, response.HasMore, nil
+}
+
+// GlobalSearchKnowledge searches the content of knowledge bases, all of the tenant's if knowledgeBaseIDs is empty
+// Returns the matched chunks of the page and the total number of matches
+func (c *Client) GlobalSearchKnowledge(ctx context.Context,
+	keyword string, page int, pageSize int, knowledgeBaseIDs []string,
+) ([]GlobalSearchResult, int64, error) {
+	queryParams := url.Values{}
+	queryParams.Add("keyword", keyword)
+	queryParams.Add("page", strconv.Itoa(page))
+	queryParams.Add("page_size", strconv.Itoa(pageSize))
+	if len(knowledgeBaseIDs) > 0 {
+		queryParams.Add("kb_ids", strings.Join(knowledgeBaseIDs, ","))
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/knowledge/global-search", nil, queryParams)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var response struct {
+		Success bool                 `json:"success"`
+		Data    []GlobalSearchResult `json:"data"`
+		Total   int64                `json:"total"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, 0, err
+	}
+	return response.Data, response.Total, nil
+}
@@ -0,0 +1,212 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The Knowledge Graph related interfaces are used to browse, query and correct
+// the entities and relations extracted from the documents of a knowledge base
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// GraphEntity is an entity of the knowledge graph of a knowledge base
+type GraphEntity struct {
+	Name         string   `json:"name"`
+	Attributes   []string `json:"attributes"`
+	Chunks       []string `json:"chunks"`        // Chunks the entity was extracted from
+	KnowledgeIDs []string `json:"knowledge_ids"` // Documents the entity was extracted from
+	Degree       int      `json:"degree"`        // Number of relations of the entity
+}
+
+// GraphData is a subgraph of entities and relations
+type GraphData struct {
+	Node     []GraphNode     `json:"node"`
+	Relation []GraphRelation `json:"relation"`
+}
+
+// GraphPath is a path between two entities, Relation[i] connects Node[i] and Node[i+1]
+type GraphPath struct {
+	Length   int             `json:"length"`
+	Node     []string        `json:"node"`
+	Relation []GraphRelation `json:"relation"`
+}
+
+// GraphEntityPage a page of graph entities
+type GraphEntityPage struct {
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+	Data     []GraphEntity `json:"data"`
+}
+
+// GraphEntityUpdate corrects an entity, nil fields are left unchanged
+type GraphEntityUpdate struct {
+	Name       *string   `json:"name,omitempty"` // Merged into the entity of that name if it exists
+	Attributes *[]string `json:"attributes,omitempty"`
+}
+
+// GraphCommunity is a group of closely related entities with a summary used by global search
+type GraphCommunity struct {
+	ID              string    `json:"id"`
+	KnowledgeBaseID string    `json:"knowledge_base_id"`
+	Title           string    `json:"title"`
+	Summary         string    `json:"summary"`
+	Entities        []string  `json:"entities"`       // Most connected first
+	Size            int       `json:"size"`           // Number of entities
+	RelationCount   int       `json:"relation_count"` // Number of relations inside the community
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// GraphCommunityRebuild is a scheduled rebuild of the communities of a knowledge base
+type GraphCommunityRebuild struct {
+	TaskID          string `json:"task_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	ResolveEntities bool   `json:"resolve_entities"`
+}
+
+// ListGraphEntities lists the entities of a knowledge base whose name contains the keyword, ordered by name
+func (c *Client) ListGraphEntities(ctx context.Context,
+	knowledgeBaseID, keyword string, page, pageSize int,
+) (*GraphEntityPage, error) {
+	query := url.Values{}
+	query.Add("keyword", keyword)
+	query.Add("page", strconv.Itoa(page))
+	query.Add("page_size", strconv.Itoa(pageSize))
+	var data GraphEntityPage
+	if err := c.graphRequest(ctx, http.MethodGet, knowledgeBaseID, "entities", query, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetGraphEntity gets an entity of a knowledge base by name
+func (c *Client) GetGraphEntity(ctx context.Context, knowledgeBaseID, name string) (*GraphEntity, error) {
+	var data GraphEntity
+	query := url.Values{"name": {name}}
+	if err := c.graphRequest(ctx, http.MethodGet, knowledgeBaseID, "entity", query, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// UpdateGraphEntity corrects the name or attributes of an entity
+func (c *Client) UpdateGraphEntity(ctx context.Context,
+	knowledgeBaseID, name string, update *GraphEntityUpdate,
+) (*GraphEntity, error) {
+	var data GraphEntity
+	query := url.Values{"name": {name}}
+	if err := c.graphRequest(ctx, http.MethodPut, knowledgeBaseID, "entity", query, update, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// MergeGraphEntities merges entities into the target entity, which is created if it does not exist
+func (c *Client) MergeGraphEntities(ctx context.Context,
+	knowledgeBaseID string, sources []string, target string,
+) (*GraphEntity, error) {
+	var data GraphEntity
+	body := map[string]interface{}{"sources": sources, "target": target}
+	if err := c.graphRequest(ctx, http.MethodPost, knowledgeBaseID, "entities/merge", nil, body, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetGraphNeighbourhood returns the entities within depth hops of an entity, 0 for the default depth
+func (c *Client) GetGraphNeighbourhood(ctx context.Context,
+	knowledgeBaseID, name string, depth int,
+) (*GraphData, error) {
+	query := url.Values{"name": {name}}
+	if depth > 0 {
+		query.Add("depth", strconv.Itoa(depth))
+	}
+	var data GraphData
+	if err := c.graphRequest(ctx, http.MethodGet, knowledgeBaseID, "neighbourhood", query, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// FindGraphPath finds a shortest path between two entities, 0 for the default maximum depth
+func (c *Client) FindGraphPath(ctx context.Context,
+	knowledgeBaseID, from, to string, maxDepth int,
+) (*GraphPath, error) {
+	query := url.Values{"from": {from}, "to": {to}}
+	if maxDepth > 0 {
+		query.Add("max_depth", strconv.Itoa(maxDepth))
+	}
+	var data GraphPath
+	if err := c.graphRequest(ctx, http.MethodGet, knowledgeBaseID, "path", query, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// ListGraphEntityChunks lists the chunks an entity was extracted from
+func (c *Client) ListGraphEntityChunks(ctx context.Context, knowledgeBaseID, name string) ([]Chunk, error) {
+	var data []Chunk
+	query := url.Values{"name": {name}}
+	if err := c.graphRequest(ctx, http.MethodGet, knowledgeBaseID, "entity/chunks", query, nil, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListGraphRelationChunks lists the chunks the relations between two entities were extracted from,
+// only the relations of the given type if it is not empty
+func (c *Client) ListGraphRelationChunks(ctx context.Context,
+	knowledgeBaseID, source, target, relationType string,
+) ([]Chunk, error) {
+	query := url.Values{"source": {source}, "target": {target}}
+	if relationType != "" {
+		query.Add("type", relationType)
+	}
+	var data []Chunk
+	if err := c.graphRequest(ctx, http.MethodGet, knowledgeBaseID, "relation/chunks", query, nil, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListGraphCommunities lists the communities of the knowledge graph of a knowledge base, largest first
+func (c *Client) ListGraphCommunities(ctx context.Context, knowledgeBaseID string) ([]GraphCommunity, error) {
+	var data []GraphCommunity
+	if err := c.graphRequest(ctx, http.MethodGet, knowledgeBaseID, "communities", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// RebuildGraphCommunities schedules the rebuild of the communities of a knowledge base, merging
+// duplicate entities first if resolveEntities is set. The communities are replaced once the task completes.
+func (c *Client) RebuildGraphCommunities(ctx context.Context,
+	knowledgeBaseID string, resolveEntities bool,
+) (*GraphCommunityRebuild, error) {
+	var data GraphCommunityRebuild
+	body := map[string]interface{}{"resolve_entities": resolveEntities}
+	if err := c.graphRequest(ctx, http.MethodPost, knowledgeBaseID, "communities/rebuild", nil, body,
+		&data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// graphRequest calls a knowledge graph endpoint of a knowledge base and decodes the data of the response
+func (c *Client) graphRequest(ctx context.Context, method, knowledgeBaseID, path string,
+	query url.Values, body interface{}, data interface{},
+) error {
+	resp, err := c.doRequest(ctx, method, fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/%s", knowledgeBaseID, path),
+		body, query)
+	if err != nil {
+		return err
+	}
+	response := struct {
+		Success bool        `json:"success"`
+		Data    interface{} `json:"data"`
+	}{Data: data}
+	return parseResponse(resp, &response)
+}
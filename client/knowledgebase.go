@@ -87,16 +87,19 @@ type ExtractConfig struct {
 	Relations []*GraphRelation `json:"relations,omitempty"`
 }
 
-// GraphNode represents a node in the graph extraction configuration
+// GraphNode represents a node in the graph extraction configuration or a knowledge graph
 type GraphNode struct {
-	Name string `json:"name"`
+	Name       string   `json:"name"`
+	Chunks     []string `json:"chunks,omitempty"`
+	Attributes []string `json:"attributes,omitempty"`
 }
 
-// GraphRelation represents a relation in the graph extraction configuration
+// GraphRelation represents a relation in the graph extraction configuration or a knowledge graph
 type GraphRelation struct {
-	Node1 string `json:"node1"`
-	Node2 string `json:"node2"`
-	Type  string `json:"type"`
+	Node1  string   `json:"node1"`
+	Node2  string   `json:"node2"`
+	Type   string   `json:"type"`
+	Chunks []string `json:"chunks,omitempty"` // Chunks the relation was extracted from
 }
 
 // KnowledgeBaseResponse knowledge base response
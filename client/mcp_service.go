@@ -0,0 +1,251 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The MCP Service related interfaces are used to manage the MCP services agents can call
+// Services can be created, retrieved, updated, deleted, tested and inspected
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// MCP transport types
+const (
+	MCPTransportSSE            = "sse"
+	MCPTransportHTTPStreamable = "http-streamable"
+	MCPTransportStdio          = "stdio"
+)
+
+// MCPService represents an MCP service configuration
+type MCPService struct {
+	ID             string             `json:"id"`
+	TenantID       uint64             `json:"tenant_id"`
+	Name           string             `json:"name"`
+	Description    string             `json:"description"`
+	Enabled        bool               `json:"enabled"`
+	TransportType  string             `json:"transport_type"`
+	URL            *string            `json:"url,omitempty"` // Required for SSE and HTTP streamable transports
+	Headers        map[string]string  `json:"headers"`
+	AuthConfig     *MCPAuthConfig     `json:"auth_config"`
+	AdvancedConfig *MCPAdvancedConfig `json:"advanced_config"`
+	StdioConfig    *MCPStdioConfig    `json:"stdio_config,omitempty"` // Required for stdio transport
+	EnvVars        map[string]string  `json:"env_vars,omitempty"`     // Environment variables for stdio transport
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// MCPAuthConfig represents the authentication configuration of an MCP service
+type MCPAuthConfig struct {
+	APIKey        string            `json:"api_key,omitempty"`
+	Token         string            `json:"token,omitempty"`
+	CustomHeaders map[string]string `json:"custom_headers,omitempty"`
+}
+
+// MCPAdvancedConfig represents the advanced configuration of an MCP service
+type MCPAdvancedConfig struct {
+	Timeout    int `json:"timeout"`     // Timeout in seconds
+	RetryCount int `json:"retry_count"` // Number of retries
+	RetryDelay int `json:"retry_delay"` // Delay between retries in seconds
+}
+
+// MCPStdioConfig represents the stdio transport configuration of an MCP service
+type MCPStdioConfig struct {
+	Command string   `json:"command"` // "uvx" or "npx"
+	Args    []string `json:"args"`
+}
+
+// UpdateMCPServiceRequest updates an MCP service, only the fields that are set are changed
+type UpdateMCPServiceRequest struct {
+	Name           *string            `json:"name,omitempty"`
+	Description    *string            `json:"description,omitempty"`
+	Enabled        *bool              `json:"enabled,omitempty"`
+	TransportType  *string            `json:"transport_type,omitempty"`
+	URL            *string            `json:"url,omitempty"`
+	Headers        map[string]string  `json:"headers,omitempty"`
+	AuthConfig     *MCPAuthConfig     `json:"auth_config,omitempty"`
+	AdvancedConfig *MCPAdvancedConfig `json:"advanced_config,omitempty"`
+	StdioConfig    *MCPStdioConfig    `json:"stdio_config,omitempty"`
+	EnvVars        map[string]string  `json:"env_vars,omitempty"`
+}
+
+// MCPTool represents a tool exposed by an MCP service
+type MCPTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"` // JSON Schema of the tool parameters
+}
+
+// MCPResource represents a resource exposed by an MCP service
+type MCPResource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// MCPPrompt represents a prompt template exposed by an MCP service
+type MCPPrompt struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Arguments   []MCPPromptArgument `json:"arguments,omitempty"`
+}
+
+// MCPPromptArgument represents an argument of an MCP prompt template
+type MCPPromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// MCPTestResult represents the result of testing an MCP service connection
+type MCPTestResult struct {
+	Success   bool          `json:"success"`
+	Message   string        `json:"message,omitempty"`
+	Tools     []MCPTool     `json:"tools,omitempty"`
+	Resources []MCPResource `json:"resources,omitempty"`
+	Prompts   []MCPPrompt   `json:"prompts,omitempty"`
+}
+
+// MCPServiceResponse MCP service response
+type MCPServiceResponse struct {
+	Success bool       `json:"success"`
+	Data    MCPService `json:"data"`
+}
+
+// MCPServiceListResponse MCP service list response
+type MCPServiceListResponse struct {
+	Success bool         `json:"success"`
+	Data    []MCPService `json:"data"`
+}
+
+// CreateMCPService creates an MCP service
+func (c *Client) CreateMCPService(ctx context.Context, service *MCPService) (*MCPService, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/mcp-services", service, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response MCPServiceResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetMCPService gets an MCP service
+func (c *Client) GetMCPService(ctx context.Context, serviceID string) (*MCPService, error) {
+	path := fmt.Sprintf("/api/v1/mcp-services/%s", serviceID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response MCPServiceResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// ListMCPServices lists the MCP services of the current tenant
+func (c *Client) ListMCPServices(ctx context.Context) ([]MCPService, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/mcp-services", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response MCPServiceListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// UpdateMCPService updates the fields of an MCP service that are set in the request
+func (c *Client) UpdateMCPService(ctx context.Context,
+	serviceID string, request *UpdateMCPServiceRequest,
+) (*MCPService, error) {
+	path := fmt.Sprintf("/api/v1/mcp-services/%s", serviceID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response MCPServiceResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// DeleteMCPService deletes an MCP service
+func (c *Client) DeleteMCPService(ctx context.Context, serviceID string) error {
+	path := fmt.Sprintf("/api/v1/mcp-services/%s", serviceID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// TestMCPService connects to an MCP service and lists what it exposes
+// A failed connection is reported in the result rather than as an error
+func (c *Client) TestMCPService(ctx context.Context, serviceID string) (*MCPTestResult, error) {
+	path := fmt.Sprintf("/api/v1/mcp-services/%s/test", serviceID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool          `json:"success"`
+		Data    MCPTestResult `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetMCPServiceTools lists the tools of an MCP service
+func (c *Client) GetMCPServiceTools(ctx context.Context, serviceID string) ([]MCPTool, error) {
+	var tools []MCPTool
+	err := c.getMCPServiceItems(ctx, serviceID, "tools", &tools)
+	return tools, err
+}
+
+// GetMCPServiceResources lists the resources of an MCP service
+func (c *Client) GetMCPServiceResources(ctx context.Context, serviceID string) ([]MCPResource, error) {
+	var resources []MCPResource
+	err := c.getMCPServiceItems(ctx, serviceID, "resources", &resources)
+	return resources, err
+}
+
+// GetMCPServicePrompts lists the prompt templates of an MCP service
+func (c *Client) GetMCPServicePrompts(ctx context.Context, serviceID string) ([]MCPPrompt, error) {
+	var prompts []MCPPrompt
+	err := c.getMCPServiceItems(ctx, serviceID, "prompts", &prompts)
+	return prompts, err
+}
+
+// getMCPServiceItems gets a list exposed by an MCP service into target
+func (c *Client) getMCPServiceItems(ctx context.Context, serviceID string, kind string, target interface{}) error {
+	path := fmt.Sprintf("/api/v1/mcp-services/%s/%s", serviceID, kind)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	response := struct {
+		Success bool        `json:"success"`
+		Data    interface{} `json:"data"`
+	}{Data: target}
+	return parseResponse(resp, &response)
+}
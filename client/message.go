@@ -45,6 +45,8 @@ type Message struct {
 	RequestID           string          `json:"request_id"`
 	Content             string          `json:"content"`
 	Role                string          `json:"role"`
+	AgentID             string          `json:"agent_id,omitempty"`
+	AgentVersion        int             `json:"agent_version,omitempty"` // Version of the agent that answered
 	KnowledgeReferences []*SearchResult `json:"knowledge_references"`
 	AgentSteps          []AgentStep     `json:"agent_steps,omitempty"` // Agent execution steps (only for assistant messages)
 	IsCompleted         bool            `json:"is_completed"`
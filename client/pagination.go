@@ -0,0 +1,91 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The pagination helpers walk through paginated list endpoints page by page
+package client
+
+import (
+	"context"
+	"iter"
+)
+
+// DefaultPageSize is the page size used by the ListAll helpers
+const DefaultPageSize = 100
+
+// PageFetcher fetches one page of a paginated list, returning the items and the total count
+type PageFetcher[T any] func(ctx context.Context, page, pageSize int) ([]T, int64, error)
+
+// IteratePages yields the items of a paginated list one by one, fetching pages as needed.
+// Iteration stops at the first error, which is yielded with the zero value of T.
+func IteratePages[T any](ctx context.Context, pageSize int, fetch PageFetcher[T]) iter.Seq2[T, error] {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return func(yield func(T, error) bool) {
+		var seen int64
+		for page := 1; ; page++ {
+			items, total, err := fetch(ctx, page, pageSize)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			seen += int64(len(items))
+			if len(items) == 0 || seen >= total {
+				return
+			}
+		}
+	}
+}
+
+// CollectPages fetches every page of a paginated list
+func CollectPages[T any](ctx context.Context, pageSize int, fetch PageFetcher[T]) ([]T, error) {
+	var all []T
+	for item, err := range IteratePages(ctx, pageSize, fetch) {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, item)
+	}
+	return all, nil
+}
+
+// ListAllKnowledge lists every knowledge entry of a knowledge base, optionally filtered by tag
+func (c *Client) ListAllKnowledge(ctx context.Context, knowledgeBaseID string, tagID string) ([]Knowledge, error) {
+	return CollectPages(ctx, DefaultPageSize, func(ctx context.Context, page, pageSize int) ([]Knowledge, int64, error) {
+		return c.ListKnowledge(ctx, knowledgeBaseID, page, pageSize, tagID)
+	})
+}
+
+// ListAllSessions lists every session of the current tenant
+func (c *Client) ListAllSessions(ctx context.Context) ([]Session, error) {
+	return CollectPages(ctx, DefaultPageSize, func(ctx context.Context, page, pageSize int) ([]Session, int64, error) {
+		sessions, total, err := c.GetSessionsByTenant(ctx, page, pageSize)
+		return sessions, int64(total), err
+	})
+}
+
+// ListAllTags lists every tag of a knowledge base
+func (c *Client) ListAllTags(ctx context.Context, knowledgeBaseID string) ([]TagWithStats, error) {
+	return CollectPages(ctx, DefaultPageSize, func(ctx context.Context, page, pageSize int) ([]TagWithStats, int64, error) {
+		result, err := c.ListTags(ctx, knowledgeBaseID, page, pageSize, "")
+		if err != nil {
+			return nil, 0, err
+		}
+		return result.Tags, result.Total, nil
+	})
+}
+
+// ListAllFAQEntries lists every FAQ entry of a knowledge base, optionally filtered by tag
+func (c *Client) ListAllFAQEntries(ctx context.Context, knowledgeBaseID string, tagID string) ([]FAQEntry, error) {
+	return CollectPages(ctx, DefaultPageSize, func(ctx context.Context, page, pageSize int) ([]FAQEntry, int64, error) {
+		result, err := c.ListFAQEntries(ctx, knowledgeBaseID, page, pageSize, tagID, "", "", "")
+		if err != nil {
+			return nil, 0, err
+		}
+		return result.Entries, result.Total, nil
+	})
+}
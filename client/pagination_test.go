@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// pagedNumbers serves the numbers 1..total in pages, recording the requested pages
+func pagedNumbers(total int, pages *[]int) PageFetcher[int] {
+	return func(ctx context.Context, page, pageSize int) ([]int, int64, error) {
+		*pages = append(*pages, page)
+		var items []int
+		for n := (page-1)*pageSize + 1; n <= min(page*pageSize, total); n++ {
+			items = append(items, n)
+		}
+		return items, int64(total), nil
+	}
+}
+
+func TestCollectPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		pageSize  int
+		wantPages []int
+	}{
+		{"stops at the last partial page", 5, 2, []int{1, 2, 3}},
+		{"stops at the last full page", 4, 2, []int{1, 2}},
+		{"empty list", 0, 2, []int{1}},
+		{"default page size", 150, 0, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pages []int
+			items, err := CollectPages(context.Background(), tt.pageSize, pagedNumbers(tt.total, &pages))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(items) != tt.total {
+				t.Errorf("got %d items, want %d", len(items), tt.total)
+			}
+			if !slices.Equal(pages, tt.wantPages) {
+				t.Errorf("fetched pages %v, want %v", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestIteratePagesStopsOnEmptyPage(t *testing.T) {
+	// The total claims more items than the server returns, an empty page ends the iteration
+	var pages []int
+	fetch := func(ctx context.Context, page, pageSize int) ([]int, int64, error) {
+		pages = append(pages, page)
+		if page > 1 {
+			return nil, 10, nil
+		}
+		return []int{1, 2}, 10, nil
+	}
+	items, err := CollectPages(context.Background(), 2, fetch)
+	if err != nil || len(items) != 2 || !slices.Equal(pages, []int{1, 2}) {
+		t.Errorf("items %v, pages %v, err %v", items, pages, err)
+	}
+}
+
+func TestIteratePagesBreak(t *testing.T) {
+	var pages []int
+	var seen []int
+	for item, err := range IteratePages(context.Background(), 2, pagedNumbers(10, &pages)) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		seen = append(seen, item)
+		if item == 3 {
+			break
+		}
+	}
+	if !slices.Equal(seen, []int{1, 2, 3}) || !slices.Equal(pages, []int{1, 2}) {
+		t.Errorf("seen %v, pages %v", seen, pages)
+	}
+}
+
+func TestIteratePagesError(t *testing.T) {
+	failure := errors.New("boom")
+	fetch := func(ctx context.Context, page, pageSize int) ([]int, int64, error) {
+		if page == 2 {
+			return nil, 0, failure
+		}
+		return []int{1, 2}, 10, nil
+	}
+	if _, err := CollectPages(context.Background(), 2, fetch); !errors.Is(err, failure) {
+		t.Errorf("err = %v, want %v", err, failure)
+	}
+}
@@ -240,63 +240,41 @@ func (c *Client) KnowledgeQAStream(
 	callback func(*StreamResponse) error,
 ) error {
 	path := fmt.Sprintf("/api/v1/knowledge-chat/%s", sessionID)
-	fmt.Printf("Starting KnowledgeQAStream request, session ID: %s, query: %s\n", sessionID, request.Query)
 
 	resp, err := c.doRequest(ctx, http.MethodPost, path, request, nil)
 	if err != nil {
-		fmt.Printf("Request failed: %v\n", err)
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		err := fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
-		fmt.Printf("Request returned error status: %v\n", err)
-		return err
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
-	fmt.Println("Successfully established SSE connection, processing data stream")
-
 	// Use bufio to read SSE data line by line
 	scanner := bufio.NewScanner(resp.Body)
 	var dataBuffer string
-	var eventType string
-	messageCount := 0
 
 	for scanner.Scan() {
 		line := scanner.Text()
-		fmt.Printf("Received SSE line: %s\n", line)
 
 		// Empty line indicates the end of an event
 		if line == "" {
 			if dataBuffer != "" {
-				fmt.Printf("Processing data: %s, event type: %s\n", dataBuffer, eventType)
 				var streamResponse StreamResponse
 				if err := json.Unmarshal([]byte(dataBuffer), &streamResponse); err != nil {
-					fmt.Printf("Failed to parse SSE data: %v\n", err)
 					return fmt.Errorf("failed to parse SSE data: %w", err)
 				}
 
-				messageCount++
-				fmt.Printf("Parsed message #%d, done status: %v\n", messageCount, streamResponse.Done)
-
 				if err := callback(&streamResponse); err != nil {
-					fmt.Printf("Callback processing failed: %v\n", err)
 					return err
 				}
 				dataBuffer = ""
-				eventType = ""
 			}
 			continue
 		}
 
-		// Process lines with event: prefix
-		if strings.HasPrefix(line, "event:") {
-			eventType = line[6:] // Remove "event:" prefix
-			fmt.Printf("Set event type: %s\n", eventType)
-		}
-
 		// Process lines with data: prefix
 		if strings.HasPrefix(line, "data:") {
 			dataBuffer = line[5:] // Remove "data:" prefix
@@ -304,11 +282,9 @@ func (c *Client) KnowledgeQAStream(
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Printf("Failed to read SSE stream: %v\n", err)
 		return fmt.Errorf("failed to read SSE stream: %w", err)
 	}
 
-	fmt.Printf("KnowledgeQAStream completed, processed %d messages\n", messageCount)
 	return nil
 }
 
@@ -419,29 +395,14 @@ type SearchKnowledgeResponse struct {
 
 // SearchKnowledge performs knowledge base search without LLM summarization
 func (c *Client) SearchKnowledge(ctx context.Context, request *SearchKnowledgeRequest) ([]*SearchResult, error) {
-	fmt.Printf("Starting SearchKnowledge request, knowledge base IDs: %v, knowledge IDs: %v, query: %s\n",
-		request.KnowledgeBaseIDs, request.KnowledgeIDs, request.Query)
-
 	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/knowledge-search", request, nil)
 	if err != nil {
-		fmt.Printf("Request failed: %v\n", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		err := fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
-		fmt.Printf("Request returned error status: %v\n", err)
 		return nil, err
 	}
 
 	var response SearchKnowledgeResponse
 	if err := parseResponse(resp, &response); err != nil {
-		fmt.Printf("Failed to parse response: %v\n", err)
 		return nil, err
 	}
-
-	fmt.Printf("SearchKnowledge completed, found %d results\n", len(response.Data))
 	return response.Data, nil
 }
@@ -0,0 +1,167 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The System related interfaces report server information and back up or restore tenant data
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// SystemInfo represents the server version and the engines it is running with
+type SystemInfo struct {
+	Version             string `json:"version"`
+	CommitID            string `json:"commit_id,omitempty"`
+	BuildTime           string `json:"build_time,omitempty"`
+	GoVersion           string `json:"go_version,omitempty"`
+	KeywordIndexEngine  string `json:"keyword_index_engine,omitempty"`
+	VectorStoreEngine   string `json:"vector_store_engine,omitempty"`
+	GraphDatabaseEngine string `json:"graph_database_engine,omitempty"`
+	MinioEnabled        bool   `json:"minio_enabled,omitempty"`
+}
+
+// BackupOption describes a kind of data that can be exported, with the number of records
+type BackupOption struct {
+	Key   string `json:"key"` // Name of the BackupExportRequest flag, e.g. include_knowledge
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// BackupExportRequest selects the data to export
+type BackupExportRequest struct {
+	IncludeTenants        bool `json:"include_tenants"`
+	IncludeUsers          bool `json:"include_users"`
+	IncludeKnowledgeBases bool `json:"include_knowledge_bases"`
+	IncludeKnowledge      bool `json:"include_knowledge"`
+	IncludeChunks         bool `json:"include_chunks"`
+	IncludeSessions       bool `json:"include_sessions"`
+	IncludeMessages       bool `json:"include_messages"`
+	IncludeModels         bool `json:"include_models"`
+	IncludeCredentials    bool `json:"include_credentials"`
+	IncludeTags           bool `json:"include_tags"`
+	IncludeAgents         bool `json:"include_agents"`
+	IncludeMCPServices    bool `json:"include_mcp_services"`
+}
+
+// BackupImportResult reports the number of imported records of each kind
+type BackupImportResult struct {
+	TenantsImported        int      `json:"tenants_imported"`
+	UsersImported          int      `json:"users_imported"`
+	KnowledgeBasesImported int      `json:"knowledge_bases_imported"`
+	KnowledgeImported      int      `json:"knowledge_imported"`
+	ChunksImported         int      `json:"chunks_imported"`
+	SessionsImported       int      `json:"sessions_imported"`
+	MessagesImported       int      `json:"messages_imported"`
+	ModelsImported         int      `json:"models_imported"`
+	CredentialsImported    int      `json:"credentials_imported"`
+	TagsImported           int      `json:"tags_imported"`
+	AgentsImported         int      `json:"agents_imported"`
+	MCPServicesImported    int      `json:"mcp_services_imported"`
+	Errors                 []string `json:"errors,omitempty"`
+}
+
+// GetSystemInfo gets the server version and engine information
+func (c *Client) GetSystemInfo(ctx context.Context) (*SystemInfo, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/system/info", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Code int        `json:"code"`
+		Msg  string     `json:"msg"`
+		Data SystemInfo `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetBackupOptions lists the kinds of data that can be exported
+func (c *Client) GetBackupOptions(ctx context.Context) ([]BackupOption, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/system/backup/options", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool           `json:"success"`
+		Data    []BackupOption `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// ExportBackup exports the selected data of the current tenant as a zip archive written to w
+func (c *Client) ExportBackup(ctx context.Context, request *BackupExportRequest, w io.Writer) error {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/system/backup/export", request, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	return nil
+}
+
+// ImportBackup imports a zip archive created by ExportBackup
+// With skipExisting, records that already exist are left unchanged
+func (c *Client) ImportBackup(ctx context.Context, zipPath string, skipExisting bool) (*BackupImportResult, error) {
+	file, err := os.Open(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filepath.Base(zipPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to copy file content: %w", err)
+	}
+	if err := writer.WriteField("skip_existing", strconv.FormatBool(skipExisting)); err != nil {
+		return nil, fmt.Errorf("failed to write skip_existing field: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/system/backup/import", body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	c.setCommonHeaders(ctx, req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var response struct {
+		Success bool               `json:"success"`
+		Data    BackupImportResult `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ToolApprovalStatus is the state of a tool approval
+type ToolApprovalStatus string
+
+const (
+	ToolApprovalPending  ToolApprovalStatus = "pending"
+	ToolApprovalApproved ToolApprovalStatus = "approved"
+	ToolApprovalRejected ToolApprovalStatus = "rejected"
+	ToolApprovalExpired  ToolApprovalStatus = "expired"
+)
+
+// ToolApproval is a tool call of an agent waiting for, or decided by, the user
+type ToolApproval struct {
+	ID         string             `json:"id"`
+	SessionID  string             `json:"session_id"`
+	MessageID  string             `json:"message_id"`
+	ToolCallID string             `json:"tool_call_id"`
+	ToolName   string             `json:"tool_name"`
+	Arguments  json.RawMessage    `json:"arguments"`
+	Status     ToolApprovalStatus `json:"status"`
+	Reason     string             `json:"reason,omitempty"`
+	DecidedBy  string             `json:"decided_by,omitempty"`
+	DecidedAt  *time.Time         `json:"decided_at,omitempty"`
+	ExpiresAt  time.Time          `json:"expires_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// ListToolApprovals lists the tool approvals of a session, all of them if status is empty
+func (c *Client) ListToolApprovals(
+	ctx context.Context, sessionID string, status ToolApprovalStatus,
+) ([]ToolApproval, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", string(status))
+	}
+	path := fmt.Sprintf("/api/v1/sessions/%s/tool-approvals", sessionID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool           `json:"success"`
+		Data    []ToolApproval `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DecideToolApproval approves or rejects a tool call an agent is waiting on,
+// the reason of a rejection is returned to the agent as the tool result
+func (c *Client) DecideToolApproval(
+	ctx context.Context, sessionID, approvalID string, approved bool, reason string,
+) (*ToolApproval, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/tool-approvals/%s", sessionID, approvalID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, map[string]interface{}{
+		"approved": approved,
+		"reason":   reason,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    ToolApproval `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
@@ -0,0 +1,94 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The Web Search related interfaces are used to list search providers and configure tenant web search
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// WebSearchConfig represents the tenant web search configuration
+type WebSearchConfig struct {
+	Provider          string   `json:"provider"`           // Search provider ID
+	APIKey            string   `json:"api_key"`            // API key of the provider, if required
+	MaxResults        int      `json:"max_results"`        // Maximum number of results, 1-50
+	IncludeDate       bool     `json:"include_date"`       // Whether to include dates in results
+	CompressionMethod string   `json:"compression_method"` // none, summary, extract or rag
+	Blacklist         []string `json:"blacklist"`          // Blacklist rules
+	// RAG compression settings
+	EmbeddingModelID   string `json:"embedding_model_id,omitempty"`
+	EmbeddingDimension int    `json:"embedding_dimension,omitempty"`
+	RerankModelID      string `json:"rerank_model_id,omitempty"`
+	DocumentFragments  int    `json:"document_fragments,omitempty"`
+	// Multiple provider settings
+	FallbackProviders   []string                               `json:"fallback_providers,omitempty"`
+	ProviderCredentials map[string]WebSearchProviderCredential `json:"provider_credentials,omitempty"`
+}
+
+// WebSearchProviderCredential holds tenant-level credentials of a web search provider
+type WebSearchProviderCredential struct {
+	APIKey   string `json:"api_key,omitempty"`
+	EngineID string `json:"engine_id,omitempty"` // Google Programmable Search engine ID (cx)
+}
+
+// WebSearchProviderInfo describes an available web search provider
+type WebSearchProviderInfo struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Free           bool   `json:"free"`
+	RequiresAPIKey bool   `json:"requires_api_key"`
+	Description    string `json:"description"`
+	APIURL         string `json:"api_url,omitempty"`
+}
+
+// WebSearchConfigResponse web search configuration response
+type WebSearchConfigResponse struct {
+	Success bool             `json:"success"`
+	Data    *WebSearchConfig `json:"data"`
+}
+
+// GetWebSearchProviders lists the web search providers available on the server
+func (c *Client) GetWebSearchProviders(ctx context.Context) ([]WebSearchProviderInfo, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/web-search/providers", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                    `json:"success"`
+		Data    []WebSearchProviderInfo `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetWebSearchConfig gets the web search configuration of the current tenant
+// Returns nil if web search has not been configured
+func (c *Client) GetWebSearchConfig(ctx context.Context) (*WebSearchConfig, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/tenants/kv/web-search-config", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response WebSearchConfigResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// UpdateWebSearchConfig replaces the web search configuration of the current tenant
+func (c *Client) UpdateWebSearchConfig(ctx context.Context, config *WebSearchConfig) (*WebSearchConfig, error) {
+	resp, err := c.doRequest(ctx, http.MethodPut, "/api/v1/tenants/kv/web-search-config", config, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response WebSearchConfigResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -24,6 +24,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -33,9 +34,13 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"go.uber.org/dig"
 
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/container"
+	"github.com/Tencent/WeKnora/internal/handler"
+	"github.com/Tencent/WeKnora/internal/router"
 	"github.com/Tencent/WeKnora/internal/runtime"
 	"github.com/Tencent/WeKnora/internal/tracing"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -49,6 +54,15 @@ func main() {
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds | log.Lshortfile)
 	log.SetOutput(os.Stdout)
 
+	// Select run mode: api, worker or all (default)
+	modeFlag := flag.String("mode", os.Getenv("RUN_MODE"),
+		"run mode: api (HTTP API only), worker (background tasks only) or all; defaults to $RUN_MODE or all")
+	flag.Parse()
+	mode, err := container.ParseRunMode(*modeFlag)
+	if err != nil {
+		log.Fatalf("Invalid run mode: %v", err)
+	}
+
 	// Set Gin mode
 	if os.Getenv("GIN_MODE") == "release" {
 		gin.SetMode(gin.ReleaseMode)
@@ -57,32 +71,36 @@ func main() {
 	}
 
 	// Build dependency injection container
-	c := container.BuildContainer(runtime.GetContainer())
+	c := container.BuildContainer(runtime.GetContainer(), mode)
 
 	// Run application
-	err := c.Invoke(func(
+	err = c.Invoke(func(
 		cfg *config.Config,
-		router *gin.Engine,
 		tracer *tracing.Tracer,
 		resourceCleaner interfaces.ResourceCleaner,
 	) error {
-		// Create context for resource cleanup
 		shutdownTimeout := cfg.Server.ShutdownTimeout
 		if shutdownTimeout == 0 {
 			shutdownTimeout = 30 * time.Second
 		}
-		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), shutdownTimeout)
-		defer cleanupCancel()
 
 		// Register tracer cleanup function to resource cleaner
 		resourceCleaner.RegisterWithName("Tracer", func() error {
-			return tracer.Cleanup(cleanupCtx)
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			return tracer.Cleanup(ctx)
 		})
 
-		// Create HTTP server
-		server := &http.Server{
-			Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-			Handler: router,
+		// Create HTTP server: the API in api/all mode, health checks and metrics in worker mode
+		server, err := newHTTPServer(c, cfg, mode)
+		if err != nil {
+			return err
+		}
+		var worker *asynq.Server
+		if mode.RunsWorker() {
+			if err := c.Invoke(func(s *asynq.Server) { worker = s }); err != nil {
+				return err
+			}
 		}
 
 		ctx, done := context.WithCancel(context.Background())
@@ -90,18 +108,26 @@ func main() {
 		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 		go func() {
 			sig := <-signals
-			log.Printf("Received signal: %v, starting server shutdown...", sig)
-
-			// Create a context with timeout for server shutdown
-			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer shutdownCancel()
+			log.Printf("Received signal: %v, starting %s shutdown...", sig, mode)
 
-			if err := server.Shutdown(shutdownCtx); err != nil {
-				log.Fatalf("Server forced to shutdown: %v", err)
+			// Stop taking new requests first; a worker keeps its health endpoint up while draining
+			if mode.ServesAPI() {
+				shutdownHTTPServer(server)
+			}
+			if worker != nil {
+				// Stops fetching new tasks and waits for in-flight tasks; unfinished ones are requeued
+				log.Println("Draining in-flight tasks...")
+				worker.Shutdown()
+				log.Println("Task worker stopped")
+			}
+			if !mode.ServesAPI() {
+				shutdownHTTPServer(server)
 			}
 
 			// Clean up all registered resources
 			log.Println("Cleaning up resources...")
+			cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cleanupCancel()
 			errs := resourceCleaner.Cleanup(cleanupCtx)
 			if len(errs) > 0 {
 				log.Printf("Errors occurred during resource cleanup: %v", errs)
@@ -112,7 +138,7 @@ func main() {
 		}()
 
 		// Start server
-		log.Printf("Server is running at %s:%d", cfg.Server.Host, cfg.Server.Port)
+		log.Printf("Server is running in %s mode at %s", mode, server.Addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			return fmt.Errorf("failed to start server: %v", err)
 		}
@@ -125,3 +151,41 @@ func main() {
 		log.Fatalf("Failed to run application: %v", err)
 	}
 }
+
+// newHTTPServer creates the HTTP server of the run mode: the API router in api and all modes,
+// health checks and metrics on the worker health port in worker mode
+func newHTTPServer(c *dig.Container, cfg *config.Config, mode container.RunMode) (*http.Server, error) {
+	if mode.ServesAPI() {
+		var server *http.Server
+		err := c.Invoke(func(engine *gin.Engine) {
+			server = &http.Server{
+				Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+				Handler: engine,
+			}
+		})
+		return server, err
+	}
+
+	port := 8081
+	if cfg.Worker != nil && cfg.Worker.HealthPort > 0 {
+		port = cfg.Worker.HealthPort
+	}
+	var server *http.Server
+	err := c.Invoke(func(healthHandler *handler.HealthHandler) {
+		server = &http.Server{
+			Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, port),
+			Handler: router.NewWorkerRouter(healthHandler),
+		}
+	})
+	return server, err
+}
+
+// shutdownHTTPServer stops accepting connections and waits for in-flight requests
+func shutdownHTTPServer(server *http.Server) {
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Fatalf("Server forced to shutdown: %v", err)
+	}
+}
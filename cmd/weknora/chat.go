@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Tencent/WeKnora/client"
+)
+
+// chatOptions are the settings of a chat
+type chatOptions struct {
+	kbIDs     []string
+	agentID   string
+	webSearch bool
+	verbose   bool
+}
+
+func runChat(ctx context.Context, args []string) error {
+	var (
+		conn      clientFlags
+		kbIDs     stringList
+		opts      chatOptions
+		sessionID string
+	)
+	fs := newFlagSet("chat", "[question]", "Chats with knowledge bases, or with an agent when -agent is set.\n"+
+		"Asks a single question if one is given, otherwise reads questions from stdin until EOF or /exit.")
+	conn.register(fs, 0)
+	fs.Var(&kbIDs, "kb", "knowledge base IDs to answer from, repeatable or comma-separated")
+	fs.StringVar(&opts.agentID, "agent", "", "ID of the agent to chat with")
+	fs.StringVar(&sessionID, "session", "", "continue an existing session instead of creating one")
+	fs.BoolVar(&opts.webSearch, "web", false, "enable web search")
+	fs.BoolVar(&opts.verbose, "v", false, "print agent thinking and tool results to stderr")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	opts.kbIDs = kbIDs
+
+	c, err := conn.newClient()
+	if err != nil {
+		return err
+	}
+	if sessionID == "" {
+		session, err := c.CreateSession(ctx, &client.CreateSessionRequest{Title: "weknora CLI"})
+		if err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		sessionID = session.ID
+	}
+
+	if question := strings.TrimSpace(strings.Join(fs.Args(), " ")); question != "" {
+		return ask(ctx, c, sessionID, question, opts)
+	}
+
+	fmt.Fprintf(os.Stderr, "Session %s, type /exit or press Ctrl-D to quit\n", sessionID)
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Fprint(os.Stderr, "\n> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(os.Stderr)
+			return scanner.Err()
+		}
+		question := strings.TrimSpace(scanner.Text())
+		switch question {
+		case "":
+			continue
+		case "/exit", "/quit":
+			return nil
+		}
+		if err := ask(ctx, c, sessionID, question, opts); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+	}
+}
+
+// ask streams the answer to a question to stdout, followed by its references
+func ask(ctx context.Context, c *client.Client, sessionID, question string, opts chatOptions) error {
+	var references []*client.SearchResult
+	if opts.agentID != "" {
+		err := c.AgentQAStreamWithRequest(ctx, sessionID, &client.AgentQARequest{
+			Query:            question,
+			KnowledgeBaseIDs: opts.kbIDs,
+			AgentEnabled:     true,
+			AgentID:          opts.agentID,
+			WebSearchEnabled: opts.webSearch,
+		}, func(resp *client.AgentStreamResponse) error {
+			switch resp.ResponseType {
+			case client.AgentResponseTypeAnswer:
+				fmt.Print(resp.Content)
+			case client.AgentResponseTypeToolCall:
+				fmt.Fprintf(os.Stderr, "[%s]\n", resp.Content)
+			case client.AgentResponseTypeThinking, client.AgentResponseTypeToolResult, client.AgentResponseTypeReflection:
+				if opts.verbose {
+					fmt.Fprint(os.Stderr, resp.Content)
+				}
+			case client.AgentResponseTypeArtifact:
+				fmt.Fprintf(os.Stderr, "[%v %v: %s]\n", resp.Data["type"], resp.Data["artifact_id"], resp.Content)
+			case client.AgentResponseTypeReferences:
+				references = append(references, resp.KnowledgeReferences...)
+			case client.AgentResponseTypeError:
+				return fmt.Errorf("%s", resp.Content)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		err := c.KnowledgeQAStream(ctx, sessionID, &client.KnowledgeQARequest{
+			Query:            question,
+			KnowledgeBaseIDs: opts.kbIDs,
+			WebSearchEnabled: opts.webSearch,
+			DisableTitle:     true,
+		}, func(resp *client.StreamResponse) error {
+			switch resp.ResponseType {
+			case client.ResponseTypeAnswer:
+				fmt.Print(resp.Content)
+			case client.ResponseTypeReferences:
+				references = append(references, resp.KnowledgeReferences...)
+			case client.ResponseTypeError:
+				return fmt.Errorf("%s", resp.Content)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Println()
+
+	if len(references) > 0 {
+		fmt.Println("\nReferences:")
+		for i, ref := range references {
+			fmt.Printf("  [%d] %s: %s\n", i+1, ref.KnowledgeTitle, truncate(ref.Content, 80))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/client"
+)
+
+// metricOrder is the order metrics are printed in
+var metricOrder = []string{
+	"precision", "recall", "ndcg3", "ndcg10", "mrr", "map",
+	"bleu1", "bleu2", "bleu4", "rouge1", "rouge2", "rougel",
+}
+
+// thresholds maps metric names to their minimum values, set with repeated -min name=value flags
+type thresholds map[string]float64
+
+func (t thresholds) String() string {
+	var parts []string
+	for name, value := range t {
+		parts = append(parts, fmt.Sprintf("%s=%g", name, value))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ",")
+}
+
+func (t thresholds) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return fmt.Errorf("expected metric=value, got %q", item)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(metricOrder, name) {
+			return fmt.Errorf("unknown metric %q, expected one of %s", name, strings.Join(metricOrder, ", "))
+		}
+		min, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		t[name] = min
+	}
+	return nil
+}
+
+func runEval(ctx context.Context, args []string) error {
+	var (
+		conn     clientFlags
+		request  client.EvaluationRequest
+		taskID   string
+		interval time.Duration
+		minimums = thresholds{}
+		asJSON   bool
+	)
+	fs := newFlagSet("eval", "", "Starts an evaluation, waits for it to finish and prints its metrics.\n"+
+		"Exits with status 1 if the task fails or a metric is below its -min threshold, for use in CI.")
+	conn.register(fs, time.Minute)
+	fs.StringVar(&request.DatasetID, "dataset", "", "evaluation dataset ID")
+	fs.StringVar(&request.KnowledgeBaseID, "kb", "", "knowledge base to evaluate, a temporary one is created if empty")
+	fs.StringVar(&request.ChatModelID, "chat-model", "", "chat model ID")
+	fs.StringVar(&request.RerankModelID, "rerank-model", "", "rerank model ID")
+	fs.StringVar(&taskID, "task", "", "wait for an existing task instead of starting one")
+	fs.DurationVar(&interval, "interval", 5*time.Second, "polling interval")
+	fs.Var(minimums, "min", "minimum value of a metric, e.g. -min ndcg10=0.6, repeatable")
+	fs.BoolVar(&asJSON, "json", false, "print the result as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return usageError(fs)
+	}
+
+	c, err := conn.newClient()
+	if err != nil {
+		return err
+	}
+	if taskID == "" {
+		detail, err := c.StartEvaluation(ctx, &request)
+		if err != nil {
+			return fmt.Errorf("failed to start evaluation: %w", err)
+		}
+		taskID = detail.Task.ID
+		fmt.Fprintf(os.Stderr, "Started evaluation task %s\n", taskID)
+	}
+
+	detail, err := c.WaitForEvaluation(ctx, taskID, interval, func(d *client.EvaluationDetail) {
+		if d.Task != nil {
+			fmt.Fprintf(os.Stderr, "\r%s %d/%d", d.Task.Status, d.Task.Finished, d.Task.Total)
+		}
+	})
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		if err := printJSON(detail); err != nil {
+			return err
+		}
+	}
+	if detail.Metric == nil {
+		return fmt.Errorf("evaluation task %s finished without metrics", taskID)
+	}
+
+	metrics := detail.Metric.Map()
+	var failed []string
+	for _, name := range metricOrder {
+		status := ""
+		if min, ok := minimums[name]; ok {
+			status = fmt.Sprintf("  (min %.4f) ok", min)
+			if metrics[name] < min {
+				status = fmt.Sprintf("  (min %.4f) FAIL", min)
+				failed = append(failed, name)
+			}
+		}
+		if !asJSON {
+			fmt.Printf("%-10s %.4f%s\n", name, metrics[name], status)
+		}
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "Metrics below threshold: %s\n", strings.Join(failed, ", "))
+		return &exitError{code: 1}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func runKB(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		fmt.Fprint(os.Stderr, "Usage: weknora kb list [flags]\n")
+		return &exitError{code: 2}
+	}
+
+	var (
+		conn   clientFlags
+		asJSON bool
+	)
+	fs := newFlagSet("kb list", "", "Lists the knowledge bases of the tenant.")
+	conn.register(fs, time.Minute)
+	fs.BoolVar(&asJSON, "json", false, "print JSON instead of a table")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+
+	c, err := conn.newClient()
+	if err != nil {
+		return err
+	}
+	kbs, err := c.ListKnowledgeBases(ctx)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		return printJSON(kbs)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tTYPE\tKNOWLEDGE\tCHUNKS\tUPDATED")
+	for _, kb := range kbs {
+		if kb.IsTemporary {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n",
+			kb.ID, kb.Name, kb.Type, kb.KnowledgeCount, kb.ChunkCount, kb.UpdatedAt.Local().Format(time.DateTime))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,173 @@
+// Package main is the weknora command-line tool
+// It uploads directories, lists knowledge bases, searches, chats and runs evaluations
+// against a WeKnora server through the Go client SDK
+//
+// The server address and API key are read from the WEKNORA_URL and WEKNORA_API_KEY
+// environment variables, or from the -url and -api-key flags of each command
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Tencent/WeKnora/client"
+)
+
+const usage = `weknora is a command-line tool for WeKnora
+
+Usage:
+  weknora <command> [flags] [args]
+
+Commands:
+  upload   Upload the files of a directory to a knowledge base
+  kb       Manage knowledge bases (kb list)
+  search   Search knowledge bases without generating an answer
+  chat     Chat with knowledge bases or an agent from the terminal
+  eval     Run an evaluation and check its metrics against thresholds
+
+Environment:
+  WEKNORA_URL       Server address, default http://localhost:8080
+  WEKNORA_API_KEY   Tenant API key (sk-...)
+
+Run "weknora <command> -h" for the flags of a command.
+`
+
+// command is a subcommand of the tool
+type command struct {
+	name string
+	run  func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{name: "upload", run: runUpload},
+	{name: "kb", run: runKB},
+	{name: "search", run: runSearch},
+	{name: "chat", run: runChat},
+	{name: "eval", run: runEval},
+}
+
+// exitError carries the exit code of a command that failed without a message to print
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	name, args := os.Args[1], os.Args[2:]
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(ctx, args)
+		var exitErr *exitError
+		switch {
+		case err == nil:
+			return
+		case errors.As(err, &exitErr):
+			os.Exit(exitErr.code)
+		default:
+			fmt.Fprintf(os.Stderr, "weknora %s: %v\n", name, err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "weknora: unknown command %q\n\n%s", name, usage)
+	os.Exit(2)
+}
+
+// clientFlags are the connection flags shared by all commands
+type clientFlags struct {
+	url     string
+	apiKey  string
+	timeout time.Duration
+}
+
+// register adds the connection flags to a command's flag set
+func (f *clientFlags) register(fs *flag.FlagSet, defaultTimeout time.Duration) {
+	fs.StringVar(&f.url, "url", envOrDefault("WEKNORA_URL", "http://localhost:8080"), "server address")
+	fs.StringVar(&f.apiKey, "api-key", os.Getenv("WEKNORA_API_KEY"), "tenant API key")
+	fs.DurationVar(&f.timeout, "timeout", defaultTimeout, "HTTP timeout of each request, 0 for none")
+}
+
+// newClient creates an SDK client from the connection flags
+func (f *clientFlags) newClient() (*client.Client, error) {
+	if f.apiKey == "" {
+		return nil, errors.New("an API key is required, set WEKNORA_API_KEY or -api-key")
+	}
+	return client.NewClient(strings.TrimRight(f.url, "/"), client.WithToken(f.apiKey), client.WithTimeout(f.timeout)), nil
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// stringList is a repeatable or comma-separated string flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// newFlagSet creates the flag set of a command, printing its usage line on -h
+func newFlagSet(name, argsUsage, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s\n\n%s\n\nFlags:\n",
+			strings.TrimSpace("weknora "+name+" [flags] "+argsUsage), description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the flags of a command, the flag package prints any error and the usage itself
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return &exitError{code: 2}
+	}
+	return nil
+}
+
+// usageError prints the usage of a command whose arguments are invalid
+func usageError(fs *flag.FlagSet) error {
+	fs.Usage()
+	return &exitError{code: 2}
+}
+
+// truncate shortens text to at most n characters, collapsing whitespace
+func truncate(text string, n int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/client"
+)
+
+func runSearch(ctx context.Context, args []string) error {
+	var (
+		conn    clientFlags
+		kbIDs   stringList
+		limit   int
+		keyword bool
+		asJSON  bool
+	)
+	fs := newFlagSet("search", "<query>", "Searches knowledge bases and prints the matching chunks.\n"+
+		"By default runs the hybrid retrieval used by chat; -keyword runs a plain keyword search instead.")
+	conn.register(fs, time.Minute)
+	fs.Var(&kbIDs, "kb", "knowledge base IDs to search, repeatable or comma-separated (required unless -keyword)")
+	fs.IntVar(&limit, "n", 10, "maximum number of results to print")
+	fs.BoolVar(&keyword, "keyword", false, "keyword search across all knowledge bases, or those of -kb")
+	fs.BoolVar(&asJSON, "json", false, "print JSON instead of text")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	query := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if query == "" || (!keyword && len(kbIDs) == 0) {
+		return usageError(fs)
+	}
+
+	c, err := conn.newClient()
+	if err != nil {
+		return err
+	}
+
+	if keyword {
+		results, total, err := c.GlobalSearchKnowledge(ctx, query, 1, limit, kbIDs)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printJSON(results)
+		}
+		for i, result := range results {
+			fmt.Printf("%d. %s / %s  [%s %.3f]\n   %s\n\n", i+1,
+				result.KnowledgeBaseName, result.KnowledgeTitle, result.MatchType, result.Score, truncate(result.Content, 200))
+		}
+		fmt.Printf("%d of %d matches\n", len(results), total)
+		return nil
+	}
+
+	results, err := c.SearchKnowledge(ctx, &client.SearchKnowledgeRequest{Query: query, KnowledgeBaseIDs: kbIDs})
+	if err != nil {
+		return err
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	if asJSON {
+		return printJSON(results)
+	}
+	for i, result := range results {
+		fmt.Printf("%d. %s #%d  [%.3f]\n   %s\n\n", i+1,
+			result.KnowledgeTitle, result.ChunkIndex, result.Score, truncate(result.Content, 200))
+	}
+	fmt.Printf("%d results\n", len(results))
+	return nil
+}
+
+// printJSON prints v as indented JSON
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/client"
+)
+
+// uploadResult is the outcome of uploading one file
+type uploadResult struct {
+	path string
+	err  error
+}
+
+func runUpload(ctx context.Context, args []string) error {
+	var (
+		conn       clientFlags
+		kbID       string
+		workers    int
+		extensions stringList
+		multimodal bool
+		dryRun     bool
+	)
+	fs := newFlagSet("upload", "<dir>", "Uploads the files under a directory to a knowledge base.\n"+
+		"Files the knowledge base already contains are skipped.")
+	conn.register(fs, 10*time.Minute)
+	fs.StringVar(&kbID, "kb", "", "knowledge base ID (required)")
+	fs.IntVar(&workers, "workers", 4, "number of concurrent uploads")
+	fs.Var(&extensions, "ext", "only upload files with these extensions, e.g. -ext pdf,docx")
+	fs.BoolVar(&multimodal, "multimodal", false, "enable multimodal parsing of images in documents")
+	fs.BoolVar(&dryRun, "dry-run", false, "list the files that would be uploaded")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if kbID == "" || fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	files, err := collectFiles(fs.Arg(0), extensions)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Println("No files to upload")
+		return nil
+	}
+	if dryRun {
+		for _, file := range files {
+			fmt.Println(file)
+		}
+		fmt.Printf("%d files would be uploaded\n", len(files))
+		return nil
+	}
+
+	c, err := conn.newClient()
+	if err != nil {
+		return err
+	}
+
+	workers = max(1, min(workers, len(files)))
+	jobs := make(chan string)
+	results := make(chan uploadResult)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				_, err := c.CreateKnowledgeFromFile(ctx, kbID, path, nil, &multimodal, "")
+				results <- uploadResult{path: path, err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, path := range files {
+			select {
+			case jobs <- path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var done, uploaded, skipped, failed int
+	total := len(files)
+	for result := range results {
+		done++
+		switch {
+		case result.err == nil:
+			uploaded++
+			fmt.Printf("[%d/%d] uploaded %s\n", done, total, result.path)
+		case errors.Is(result.err, client.ErrDuplicateFile):
+			skipped++
+			fmt.Printf("[%d/%d] skipped  %s (already uploaded)\n", done, total, result.path)
+		default:
+			failed++
+			fmt.Printf("[%d/%d] failed   %s: %v\n", done, total, result.path, result.err)
+		}
+	}
+
+	fmt.Printf("\n%d uploaded, %d skipped, %d failed", uploaded, skipped, failed)
+	if remaining := total - done; remaining > 0 {
+		fmt.Printf(", %d not attempted", remaining)
+	}
+	fmt.Println()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if failed > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// collectFiles lists the regular files under root, skipping hidden files and directories
+func collectFiles(root string, extensions []string) ([]string, error) {
+	allowed := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		allowed[strings.ToLower(strings.TrimPrefix(ext, "."))] = true
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+		if len(allowed) > 0 && !allowed[ext] {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", root, err)
+	}
+	return files, nil
+}
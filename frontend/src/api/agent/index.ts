@@ -20,6 +20,12 @@ export interface CustomAgentConfig {
   // MCP服务选择模式：all=全部启用的MCP服务, selected=指定服务, none=不使用MCP
   mcp_selection_mode?: 'all' | 'selected' | 'none';
   mcp_services?: string[];          // 选择的MCP服务ID列表
+  // 绑定 MCP 提示词作为系统提示词模板（优先于 system_prompt）
+  mcp_prompt?: {
+    service_id: string;
+    prompt_name: string;
+    arguments?: Record<string, string>;
+  } | null;
 
   // ===== 知识库设置 =====
   // 知识库选择模式：all=全部知识库, selected=指定知识库, none=不使用知识库
@@ -41,11 +41,24 @@ export interface MCPResource {
   mimeType?: string
 }
 
+export interface MCPPromptArgument {
+  name: string
+  description?: string
+  required?: boolean
+}
+
+export interface MCPPrompt {
+  name: string
+  description?: string
+  arguments?: MCPPromptArgument[]
+}
+
 export interface MCPTestResult {
   success: boolean
   message?: string
   tools?: MCPTool[]
   resources?: MCPResource[]
+  prompts?: MCPPrompt[]
 }
 
 // List all MCP services
@@ -101,3 +114,8 @@ export async function getMCPServiceResources(id: string): Promise<MCPResource[]>
   return response.data || []
 }
 
+// Get prompts from an MCP service
+export async function getMCPServicePrompts(id: string): Promise<MCPPrompt[]> {
+  const response: any = await get(`/api/v1/mcp-services/${id}/prompts`)
+  return response.data || []
+}
@@ -1,5 +1,5 @@
 import axios from 'axios'
-import { put } from '@/utils/request'
+import { get, post, put } from '@/utils/request'
 
 export interface ExtractContentRequest {
   platform: string
@@ -7,25 +7,55 @@ export interface ExtractContentRequest {
   kbId: string
 }
 
+export interface ExtractItem {
+  platform: string
+  url: string
+}
+
+export interface BatchExtractContentRequest {
+  kbId: string
+  provider?: string
+  items: ExtractItem[]
+}
+
+export interface ExtractItemProgress {
+  platform: string
+  url: string
+  status: 'pending' | 'processing' | 'completed' | 'failed'
+  provider?: string
+  knowledge_id?: string
+  title?: string
+  error?: string
+}
+
+export interface ExtractProgress {
+  task_id: string
+  knowledge_base_id: string
+  status: 'pending' | 'processing' | 'completed' | 'failed'
+  progress: number
+  total: number
+  processed: number
+  succeeded: number
+  failed: number
+  items: ExtractItemProgress[]
+  message: string
+  error: string
+}
+
 export interface ExtractContentResponse {
   success: boolean
   message: string
-  data?: {
-    content: string
-    knowledgeId: string
-  }
+  data?: ExtractProgress
 }
 
 // API基础URL
 const BASE_URL = import.meta.env.VITE_IS_DOCKER ? "" : "http://localhost:8080";
 
 /**
- * 从自媒体平台提取文案内容
- * 设置 8 分钟超时，因为 Coze Workflow 执行时间较长
+ * 提交单个链接的文案提取任务，返回任务进度
  */
 export async function extractSocialMediaContent(data: ExtractContentRequest): Promise<ExtractContentResponse> {
   const response = await axios.post(`${BASE_URL}/api/v1/social-media/extract`, data, {
-    timeout: 8 * 60 * 1000, // 8 分钟
     headers: {
       'Content-Type': 'application/json'
     }
@@ -33,6 +63,21 @@ export async function extractSocialMediaContent(data: ExtractContentRequest): Pr
   return response.data as ExtractContentResponse
 }
 
+/**
+ * 批量提交文案提取任务
+ */
+export async function batchExtractSocialMediaContent(data: BatchExtractContentRequest): Promise<ExtractContentResponse> {
+  const response: any = await post(`/api/v1/social-media/extract/batch`, data)
+  return response as ExtractContentResponse
+}
+
+/**
+ * 查询文案提取任务进度
+ */
+export async function getSocialMediaExtractProgress(taskId: string): Promise<{ success: boolean; data: ExtractProgress }> {
+  const response: any = await get(`/api/v1/social-media/extract/progress/${taskId}`)
+  return response as { success: boolean; data: ExtractProgress }
+}
 
 /**
  * 更新知识库的阿里云 API Key
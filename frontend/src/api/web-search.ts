@@ -10,10 +10,18 @@ export interface WebSearchProviderConfig {
   api_url?: string
 }
 
+// WebSearchProviderCredential holds the tenant's own credentials for a provider
+export interface WebSearchProviderCredential {
+  api_key?: string
+  engine_id?: string
+}
+
 // WebSearchConfig represents the web search configuration for a tenant
 export interface WebSearchConfig {
   provider: string
   api_key?: string
+  fallback_providers?: string[]
+  provider_credentials?: Record<string, WebSearchProviderCredential>
   max_results: number
   include_date: boolean
   compression_method: string
@@ -486,6 +486,12 @@ export default {
     apiKeyLabel: 'API Key',
     apiKeyDescription: 'Enter the API key for the selected search provider',
     apiKeyPlaceholder: 'Enter API key',
+    fallbackLabel: 'Fallback Providers',
+    fallbackDescription: 'Tried in order when the primary provider fails or is rate limited',
+    fallbackPlaceholder: 'Select fallback providers...',
+    credentialLabel: '{name} Credentials',
+    credentialDescription: 'Leave empty to use the credentials configured on the server',
+    engineIdPlaceholder: 'Enter search engine ID (cx)',
     maxResultsLabel: 'Maximum Results',
     maxResultsDescription: 'Maximum number of results returned per search (1-50)',
     includeDateLabel: 'Include Publish Date',
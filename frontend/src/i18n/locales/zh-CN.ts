@@ -393,6 +393,12 @@ export default {
     apiKeyLabel: "API 密钥",
     apiKeyDescription: "输入所选搜索引擎的 API 密钥",
     apiKeyPlaceholder: "请输入 API 密钥",
+    fallbackLabel: "备用搜索引擎",
+    fallbackDescription: "主搜索引擎出错或被限流时按顺序尝试",
+    fallbackPlaceholder: "选择备用搜索引擎...",
+    credentialLabel: "{name} 凭据",
+    credentialDescription: "留空则使用服务端配置的凭据",
+    engineIdPlaceholder: "请输入搜索引擎 ID（cx）",
     maxResultsLabel: "最大结果数",
     maxResultsDescription: "每次搜索返回的最大结果数量（1-50）",
     includeDateLabel: "包含发布日期",
@@ -1306,13 +1312,14 @@ export default {
     selectPlatform: "选择平台",
     selectPlatformPlaceholder: "请选择社交媒体平台",
     videoUrl: "视频链接",
-    videoUrlPlaceholder: "请输入小红书或抖音的视频链接",
-    urlHint: "支持小红书笔记链接和抖音视频链接",
+    videoUrlPlaceholder: "请输入视频链接，每行一个，支持批量导入",
+    urlHint: "支持小红书笔记链接和抖音视频链接，多个链接请分行填写",
     importing: "正在提取文案...",
     selectPlatformFirst: "请先选择平台",
     enterUrlFirst: "请输入视频链接",
     importSuccess: "文案导入成功",
     importFailed: "文案导入失败",
+    importPartial: "文案导入完成：{succeeded} 条成功，{failed} 条失败",
     apiNotImplemented: "API接口待实现，请稍后提供调用指令",
     platforms: {
       xiaohongshu: "小红书",
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// saveArtifacts stores the artifacts of a tool result against the assistant message and announces them.
+// Sub-agents run without a message of their own and store theirs against the message of the delegating agent.
+// Artifacts that cannot be stored are dropped, and the output tells the model not to reference them.
+func (e *AgentEngine) saveArtifacts(
+	ctx context.Context,
+	tc types.LLMToolCall,
+	result *types.ToolResult,
+	iteration int,
+	sessionID, messageID string,
+) {
+	if messageID == "" {
+		messageID, _ = ctx.Value(types.MessageIDContextKey).(string)
+	}
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+
+	summaries := make([]map[string]interface{}, 0, len(result.Artifacts))
+	for _, artifact := range result.Artifacts {
+		if e.artifacts == nil || sessionID == "" {
+			logger.Warnf(ctx, "[Agent] Artifact %s of tool %s dropped, artifacts are unavailable", artifact.ID, tc.Function.Name)
+			result.Output += "\n注意: " + artifact.ArtifactReference() + " 无法保存，请不要在回答中引用它。\n"
+			continue
+		}
+		artifact.TenantID = tenantID
+		artifact.SessionID = sessionID
+		artifact.MessageID = messageID
+		artifact.ToolCallID = tc.ID
+		if err := e.artifacts.SaveArtifact(ctx, artifact); err != nil {
+			logger.Errorf(ctx, "[Agent] Failed to save artifact %s of tool %s: %v", artifact.ID, tc.Function.Name, err)
+			result.Output += "\n注意: " + artifact.ArtifactReference() + " 保存失败，请不要在回答中引用它。\n"
+			continue
+		}
+
+		summaries = append(summaries, map[string]interface{}{
+			"id":        artifact.ID,
+			"type":      artifact.Type,
+			"title":     artifact.Title,
+			"file_name": artifact.FileName,
+			"row_count": artifact.RowCount,
+		})
+		e.eventBus.Emit(ctx, event.Event{
+			ID:        tc.ID + "-artifact-" + artifact.ID,
+			Type:      event.EventAgentArtifact,
+			SessionID: sessionID,
+			Data: event.AgentArtifactData{
+				ArtifactID: artifact.ID,
+				ToolCallID: tc.ID,
+				Type:       string(artifact.Type),
+				Title:      artifact.Title,
+				FileName:   artifact.FileName,
+				Size:       artifact.Size,
+				RowCount:   artifact.RowCount,
+				Spec:       json.RawMessage(artifact.Spec),
+				Iteration:  iteration,
+			},
+		})
+	}
+
+	if len(summaries) > 0 {
+		if result.Data == nil {
+			result.Data = make(map[string]interface{})
+		}
+		result.Data["artifacts"] = summaries
+	}
+	// 文件内容已保存，不再随工具结果保留在内存中
+	result.Artifacts = nil
+}
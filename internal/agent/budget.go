@@ -0,0 +1,207 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// budgetLimits are the limits of a budget, in the order they are checked
+var budgetLimits = []types.AgentBudgetLimit{
+	types.AgentBudgetTokens, types.AgentBudgetDuration, types.AgentBudgetToolCalls,
+}
+
+// budgetTracker tracks the consumption of an agent run against its budget.
+// A nil tracker tracks nothing and never runs out.
+type budgetTracker struct {
+	budget *types.AgentBudget
+	start  time.Time
+	usage  types.AgentUsage
+	warned bool // the agent has been told to wrap up
+}
+
+// newBudgetTracker starts tracking a run
+func newBudgetTracker(budget *types.AgentBudget) *budgetTracker {
+	return &budgetTracker{budget: budget, start: time.Now()}
+}
+
+// estimateTokens estimates the tokens of a text (rough approximation: 4 bytes ≈ 1 token)
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// addLLMCall counts a model call, estimating its tokens from the messages, tools and response
+func (b *budgetTracker) addLLMCall(
+	messages []chat.Message, tools []chat.Tool, content string, toolCalls []types.LLMToolCall,
+) {
+	if b == nil {
+		return
+	}
+	prompt := 0
+	for _, msg := range messages {
+		prompt += estimateTokens(msg.Role) + estimateTokens(msg.Content)
+		for _, tc := range msg.ToolCalls {
+			prompt += estimateTokens(tc.Function.Name) + estimateTokens(tc.Function.Arguments)
+		}
+	}
+	if len(tools) > 0 {
+		if definitions, err := json.Marshal(tools); err == nil {
+			prompt += estimateTokens(string(definitions))
+		}
+	}
+	completion := estimateTokens(content)
+	for _, tc := range toolCalls {
+		completion += estimateTokens(tc.Function.Name) + estimateTokens(tc.Function.Arguments)
+	}
+
+	b.usage.LLMCalls++
+	b.usage.PromptTokens += prompt
+	b.usage.CompletionTokens += completion
+	b.usage.TotalTokens += prompt + completion
+}
+
+// addToolCall counts an executed tool call, adding the tokens of the sub-agent it delegated to
+func (b *budgetTracker) addToolCall(result *types.ToolResult) {
+	if b == nil {
+		return
+	}
+	b.usage.ToolCalls++
+	if result != nil && result.Usage != nil {
+		b.usage.LLMCalls += result.Usage.LLMCalls
+		b.usage.PromptTokens += result.Usage.PromptTokens
+		b.usage.CompletionTokens += result.Usage.CompletionTokens
+		b.usage.TotalTokens += result.Usage.TotalTokens
+	}
+}
+
+// ratios returns the share of each limit of the budget consumed so far
+func (b *budgetTracker) ratios() map[types.AgentBudgetLimit]float64 {
+	ratios := make(map[types.AgentBudgetLimit]float64)
+	if b == nil || b.budget == nil {
+		return ratios
+	}
+	if b.budget.MaxTokens > 0 {
+		ratios[types.AgentBudgetTokens] = float64(b.usage.TotalTokens) / float64(b.budget.MaxTokens)
+	}
+	if b.budget.MaxDurationSeconds > 0 {
+		ratios[types.AgentBudgetDuration] = float64(time.Since(b.start)) / float64(b.budget.MaxDuration())
+	}
+	if b.budget.MaxToolCalls > 0 {
+		ratios[types.AgentBudgetToolCalls] = float64(b.usage.ToolCalls) / float64(b.budget.MaxToolCalls)
+	}
+	return ratios
+}
+
+// exceeded returns the first limit that is used up, or "" if the run is within its budget
+func (b *budgetTracker) exceeded() types.AgentBudgetLimit {
+	ratios := b.ratios()
+	for _, limit := range budgetLimits {
+		if ratio, ok := ratios[limit]; ok && ratio >= 1 {
+			return limit
+		}
+	}
+	return ""
+}
+
+// withDeadline bounds ctx by the end of the duration budget, so that a slow model call,
+// tool call or approval wait cannot run past it
+func (b *budgetTracker) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b == nil || b.budget == nil || b.budget.MaxDurationSeconds <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, b.start.Add(b.budget.MaxDuration()))
+}
+
+// deadlineHit reports whether ctx, derived from parent by withDeadline, ended because
+// the duration budget ran out rather than because the run itself was stopped
+func deadlineHit(ctx, parent context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil
+}
+
+// markExceeded records the limit that ended the run early
+func (b *budgetTracker) markExceeded(limit types.AgentBudgetLimit) {
+	if b != nil && b.usage.BudgetExceeded == "" {
+		b.usage.BudgetExceeded = limit
+	}
+}
+
+// exceededLimit returns the limit that ended the run early, or "" if none did
+func (b *budgetTracker) exceededLimit() types.AgentBudgetLimit {
+	if b == nil {
+		return ""
+	}
+	return b.usage.BudgetExceeded
+}
+
+// describe formats the consumption of a limit for the model
+func (b *budgetTracker) describe(limit types.AgentBudgetLimit) string {
+	switch limit {
+	case types.AgentBudgetTokens:
+		return fmt.Sprintf("%d of %d tokens", b.usage.TotalTokens, b.budget.MaxTokens)
+	case types.AgentBudgetDuration:
+		return fmt.Sprintf("%ds of %ds", int(time.Since(b.start).Seconds()), b.budget.MaxDurationSeconds)
+	case types.AgentBudgetToolCalls:
+		return fmt.Sprintf("%d of %d tool calls", b.usage.ToolCalls, b.budget.MaxToolCalls)
+	}
+	return string(limit)
+}
+
+// snapshot returns the consumption of the run so far
+func (b *budgetTracker) snapshot(rounds int) *types.AgentUsage {
+	if b == nil {
+		return nil
+	}
+	usage := b.usage
+	usage.Rounds = rounds
+	usage.DurationMs = time.Since(b.start).Milliseconds()
+	usage.Budget = b.budget
+	return &usage
+}
+
+// appendBudgetWarning tells the agent once to wrap up when a limit of its budget is nearly used up
+func (e *AgentEngine) appendBudgetWarning(ctx context.Context, messages []chat.Message, round int) []chat.Message {
+	b := e.budget
+	if b == nil || b.warned {
+		return messages
+	}
+	ratios := b.ratios()
+	nearing := make([]string, 0)
+	for _, limit := range budgetLimits {
+		if ratio, ok := ratios[limit]; ok && ratio >= types.AgentBudgetWarnRatio {
+			nearing = append(nearing, b.describe(limit))
+		}
+	}
+	if len(nearing) == 0 {
+		return messages
+	}
+	b.warned = true
+
+	logger.Infof(ctx, "[Agent][Round-%d] Budget nearly used up: %s", round+1, strings.Join(nearing, ", "))
+	common.PipelineWarn(ctx, "Agent", "budget_warning", map[string]interface{}{
+		"iteration": round,
+		"used":      strings.Join(nearing, ", "),
+	})
+	return append(messages, chat.Message{
+		Role: "user",
+		Content: fmt.Sprintf("[Notice] This task has nearly used up its budget (%s used). "+
+			"Stop exploring: make at most one more essential tool call, then give your final answer "+
+			"with the information you already have.", strings.Join(nearing, ", ")),
+	})
+}
+
+// budgetRefusal is the result of a tool call skipped because the budget of the run is used up
+func budgetRefusal(limit types.AgentBudgetLimit) *types.ToolResult {
+	return &types.ToolResult{
+		Success: false,
+		Error: fmt.Sprintf("The %s budget of this task is used up, the tool was not called. "+
+			"Answer with the information you already have.", strings.ReplaceAll(string(limit), "_", " ")),
+	}
+}
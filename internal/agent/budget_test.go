@@ -0,0 +1,203 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedChat asks for the given tool calls in its first round and answers afterwards
+type scriptedChat struct {
+	toolCalls []types.LLMToolCall
+	calls     int
+}
+
+func (c *scriptedChat) Chat(
+	ctx context.Context, messages []chat.Message, opts *chat.ChatOptions,
+) (*types.ChatResponse, error) {
+	return &types.ChatResponse{Content: "answer", FinishReason: "stop"}, nil
+}
+
+func (c *scriptedChat) ChatStream(
+	ctx context.Context, messages []chat.Message, opts *chat.ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	c.calls++
+	out := make(chan types.StreamResponse, 1)
+	if c.calls == 1 && len(opts.Tools) > 0 {
+		out <- types.StreamResponse{ToolCalls: c.toolCalls, Done: true}
+	} else {
+		out <- types.StreamResponse{Content: "answer", Done: true}
+	}
+	close(out)
+	return out, nil
+}
+
+func (c *scriptedChat) GetModelName() string { return "scripted" }
+
+func (c *scriptedChat) GetModelID() string { return "scripted" }
+
+// probeTool counts its calls and, if blocking, runs until its context is done
+type probeTool struct {
+	blocking bool
+	calls    int
+}
+
+func (t *probeTool) Name() string { return "probe" }
+
+func (t *probeTool) Description() string { return "probe" }
+
+func (t *probeTool) Parameters() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+
+func (t *probeTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	t.calls++
+	if t.blocking {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &types.ToolResult{Success: true, Output: "ok"}, nil
+}
+
+// runWithBudget runs an agent whose model calls the probe tool n times in its first round
+func runWithBudget(t *testing.T, budget *types.AgentBudget, tool *probeTool, n int) *types.AgentState {
+	toolCalls := make([]types.LLMToolCall, 0, n)
+	for i := 0; i < n; i++ {
+		toolCalls = append(toolCalls, types.LLMToolCall{
+			ID:       fmt.Sprintf("call-%d", i),
+			Type:     "function",
+			Function: types.FunctionCall{Name: tool.Name(), Arguments: "{}"},
+		})
+	}
+	registry := tools.NewToolRegistry()
+	registry.RegisterTool(tool)
+	engine := NewAgentEngine(&types.AgentConfig{MaxIterations: 5, Budget: budget},
+		&scriptedChat{toolCalls: toolCalls}, registry, nil, nil, nil, nil, "session", "", nil, nil, nil)
+
+	state, err := engine.Execute(context.Background(), "session", "message", "query", nil, nil)
+	require.NoError(t, err)
+	return state
+}
+
+func TestMergeAgentBudgets(t *testing.T) {
+	tests := []struct {
+		name    string
+		budgets []*types.AgentBudget
+		want    *types.AgentBudget
+	}{
+		{name: "none", budgets: nil, want: nil},
+		{name: "all unset", budgets: []*types.AgentBudget{nil, {}}, want: nil},
+		{
+			name:    "single",
+			budgets: []*types.AgentBudget{nil, {MaxTokens: 1000}},
+			want:    &types.AgentBudget{MaxTokens: 1000},
+		},
+		{
+			name: "tighter limit wins",
+			budgets: []*types.AgentBudget{
+				{MaxTokens: 1000, MaxDurationSeconds: 60, MaxToolCalls: 10},
+				{MaxTokens: 500, MaxDurationSeconds: 120, MaxToolCalls: 20},
+			},
+			want: &types.AgentBudget{MaxTokens: 500, MaxDurationSeconds: 60, MaxToolCalls: 10},
+		},
+		{
+			name: "unlimited does not loosen",
+			budgets: []*types.AgentBudget{
+				{MaxTokens: 1000},
+				{MaxDurationSeconds: 30, MaxToolCalls: 5},
+			},
+			want: &types.AgentBudget{MaxTokens: 1000, MaxDurationSeconds: 30, MaxToolCalls: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, types.MergeAgentBudgets(tt.budgets...))
+		})
+	}
+}
+
+func TestBudgetTracker(t *testing.T) {
+	budget := &types.AgentBudget{MaxTokens: 1000, MaxDurationSeconds: 100, MaxToolCalls: 10}
+	tests := []struct {
+		name     string
+		budget   *types.AgentBudget
+		usage    types.AgentUsage
+		elapsed  time.Duration
+		warn     bool
+		exceeded types.AgentBudgetLimit
+	}{
+		{name: "no budget", budget: nil, usage: types.AgentUsage{TotalTokens: 1 << 20, ToolCalls: 100}},
+		{name: "within budget", budget: budget, usage: types.AgentUsage{TotalTokens: 500, ToolCalls: 5}},
+		{name: "tokens nearly used", budget: budget, usage: types.AgentUsage{TotalTokens: 800}, warn: true},
+		{name: "duration nearly used", budget: budget, elapsed: 85 * time.Second, warn: true},
+		{name: "tool calls nearly used", budget: budget, usage: types.AgentUsage{ToolCalls: 8}, warn: true},
+		{
+			name: "tokens exceeded", budget: budget, usage: types.AgentUsage{TotalTokens: 1000},
+			warn: true, exceeded: types.AgentBudgetTokens,
+		},
+		{
+			name: "duration exceeded", budget: budget, elapsed: 101 * time.Second,
+			warn: true, exceeded: types.AgentBudgetDuration,
+		},
+		{
+			name: "tool calls exceeded", budget: budget, usage: types.AgentUsage{ToolCalls: 10},
+			warn: true, exceeded: types.AgentBudgetToolCalls,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &budgetTracker{budget: tt.budget, start: time.Now().Add(-tt.elapsed), usage: tt.usage}
+			assert.Equal(t, tt.exceeded, tracker.exceeded())
+
+			engine := &AgentEngine{budget: tracker}
+			messages := engine.appendBudgetWarning(context.Background(), nil, 0)
+			if !tt.warn {
+				assert.Empty(t, messages)
+				return
+			}
+			require.Len(t, messages, 1)
+			assert.Contains(t, messages[0].Content, "nearly used up its budget")
+			// The agent is warned only once per run
+			assert.Empty(t, engine.appendBudgetWarning(context.Background(), nil, 1))
+		})
+	}
+}
+
+func TestBudgetRefusesRemainingToolCalls(t *testing.T) {
+	tool := &probeTool{}
+	state := runWithBudget(t, &types.AgentBudget{MaxToolCalls: 2}, tool, 4)
+
+	assert.Equal(t, 2, tool.calls)
+	require.NotEmpty(t, state.RoundSteps)
+	calls := state.RoundSteps[0].ToolCalls
+	require.Len(t, calls, 4)
+	for i, call := range calls {
+		if i < 2 {
+			assert.True(t, call.Result.Success, call.ID)
+		} else {
+			assert.False(t, call.Result.Success, call.ID)
+			assert.Contains(t, call.Result.Error, "tool calls budget of this task is used up", call.ID)
+		}
+	}
+	assert.Equal(t, types.AgentBudgetToolCalls, state.Usage.BudgetExceeded)
+	assert.Equal(t, 2, state.Usage.ToolCalls)
+	assert.Equal(t, "answer", state.FinalAnswer)
+}
+
+func TestBudgetDeadlineCancelsSlowToolCall(t *testing.T) {
+	tool := &probeTool{blocking: true}
+	start := time.Now()
+	state := runWithBudget(t, &types.AgentBudget{MaxDurationSeconds: 1}, tool, 2)
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	// The slow call is cancelled at the deadline and the next one is refused without running
+	assert.Equal(t, 1, tool.calls)
+	assert.Equal(t, types.AgentBudgetDuration, state.Usage.BudgetExceeded)
+	assert.Equal(t, "answer", state.FinalAnswer)
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/Tencent/WeKnora/internal/common"
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/mcp"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -28,11 +30,16 @@ type AgentEngine struct {
 	toolRegistry         *tools.ToolRegistry
 	chatModel            chat.Chat
 	eventBus             *event.EventBus
-	knowledgeBasesInfo   []*KnowledgeBaseInfo      // Detailed knowledge base information for prompt
-	selectedDocs         []*SelectedDocumentInfo   // User-selected documents (via @ mention)
-	contextManager       interfaces.ContextManager // Context manager for writing agent conversation to LLM context
-	sessionID            string                    // Session ID for context management
-	systemPromptTemplate string                    // System prompt template (optional, uses default if empty)
+	knowledgeBasesInfo   []*KnowledgeBaseInfo           // Detailed knowledge base information for prompt
+	selectedDocs         []*SelectedDocumentInfo        // User-selected documents (via @ mention)
+	contextManager       interfaces.ContextManager      // Context manager for writing agent conversation to LLM context
+	sessionID            string                         // Session ID for context management
+	systemPromptTemplate string                         // System prompt template (optional, uses default if empty)
+	queryImages          []string                       // Image attachments (data URIs) of the current user query
+	resourceWatcher      *mcp.ResourceWatcher           // Updates of MCP resources read in this session (optional)
+	toolApprovals        interfaces.ToolApprovalService // Approvals of tool calls the policy requires confirmation for (optional)
+	artifacts            interfaces.ArtifactService     // Storage of the charts and files produced by tools (optional)
+	budget               *budgetTracker                 // Consumption of the current run against config.Budget
 }
 
 // listToolNames returns tool.function names for logging
@@ -55,6 +62,9 @@ func NewAgentEngine(
 	contextManager interfaces.ContextManager,
 	sessionID string,
 	systemPromptTemplate string,
+	resourceWatcher *mcp.ResourceWatcher,
+	toolApprovals interfaces.ToolApprovalService,
+	artifacts interfaces.ArtifactService,
 ) *AgentEngine {
 	if eventBus == nil {
 		eventBus = event.NewEventBus()
@@ -69,6 +79,9 @@ func NewAgentEngine(
 		contextManager:       contextManager,
 		sessionID:            sessionID,
 		systemPromptTemplate: systemPromptTemplate,
+		resourceWatcher:      resourceWatcher,
+		toolApprovals:        toolApprovals,
+		artifacts:            artifacts,
 	}
 }
 
@@ -78,8 +91,11 @@ func (e *AgentEngine) Execute(
 	ctx context.Context,
 	sessionID, messageID, query string,
 	llmContext []chat.Message,
+	images []string,
 ) (*types.AgentState, error) {
 	logger.Infof(ctx, "========== Agent Execution Started ==========")
+	e.queryImages = images
+	e.budget = newBudgetTracker(e.config.Budget)
 	// Ensure tools are cleaned up after execution
 	defer e.toolRegistry.Cleanup(ctx)
 
@@ -91,6 +107,7 @@ func (e *AgentEngine) Execute(
 		"message_id":   messageID,
 		"query":        query,
 		"context_msgs": len(llmContext),
+		"images":       len(images),
 	})
 
 	// Initialize state
@@ -108,6 +125,7 @@ func (e *AgentEngine) Execute(
 		e.selectedDocs,
 		e.systemPromptTemplate,
 	)
+	systemPrompt += FormatUserMemories(e.config.UserMemories)
 	logger.Debugf(ctx, "[Agent] SystemPrompt Length: %d characters", len(systemPrompt))
 	logger.Debugf(ctx, "[Agent] SystemPrompt (stream)\n----\n%s\n----", systemPrompt)
 
@@ -127,6 +145,7 @@ func (e *AgentEngine) Execute(
 	})
 
 	_, err := e.executeLoop(ctx, state, query, messages, tools, sessionID, messageID)
+	metrics.ObserveAgentRun(len(state.RoundSteps))
 	if err != nil {
 		logger.Errorf(ctx, "[Agent] Execution failed: %v", err)
 		e.eventBus.Emit(ctx, event.Event{
@@ -170,7 +189,18 @@ func (e *AgentEngine) executeLoop(
 		"max_iterations": e.config.MaxIterations,
 	})
 	for state.CurrentRound < e.config.MaxIterations {
+		// Stop calling tools once the budget is used up, the answer is generated from what was found so far
+		if limit := e.budget.exceeded(); limit != "" {
+			e.budget.markExceeded(limit)
+			logger.Warnf(ctx, "[Agent][Round-%d] Budget exceeded: %s", state.CurrentRound+1, e.budget.describe(limit))
+			break
+		}
 		roundStart := time.Now()
+		metrics.IncAgentRound()
+		// Let the agent know about MCP resources updated since they were read
+		messages = e.appendResourceUpdates(ctx, messages, state.CurrentRound)
+		// Ask the agent to wrap up when its budget is nearly used up
+		messages = e.appendBudgetWarning(ctx, messages, state.CurrentRound)
 		logger.Infof(ctx, "========== Round %d/%d Started ==========", state.CurrentRound+1, e.config.MaxIterations)
 		logger.Infof(ctx, "[Agent][Round-%d] Message history size: %d messages", state.CurrentRound+1, len(messages))
 		common.PipelineInfo(ctx, "Agent", "round_start", map[string]interface{}{
@@ -188,7 +218,16 @@ func (e *AgentEngine) executeLoop(
 			"round":     state.CurrentRound + 1,
 			"tool_cnt":  len(tools),
 		})
-		response, err := e.streamThinkingToEventBus(ctx, messages, tools, state.CurrentRound, sessionID)
+		thinkCtx, cancelThink := e.budget.withDeadline(ctx)
+		response, err := e.streamThinkingToEventBus(thinkCtx, messages, tools, state.CurrentRound, sessionID)
+		cancelThink()
+		if deadlineHit(thinkCtx, ctx) {
+			// The duration budget ran out during the call, its partial response is dropped
+			e.budget.markExceeded(types.AgentBudgetDuration)
+			logger.Warnf(ctx, "[Agent][Round-%d] Budget exceeded during LLM call: %s",
+				state.CurrentRound+1, e.budget.describe(types.AgentBudgetDuration))
+			break
+		}
 		if err != nil {
 			logger.Errorf(ctx, "[Agent][Round-%d] LLM call failed: %v", state.CurrentRound+1, err)
 			common.PipelineError(ctx, "Agent", "think_failed", map[string]interface{}{
@@ -307,7 +346,28 @@ func (e *AgentEngine) executeLoop(
 					"tool_call_id": tc.ID,
 					"tool_index":   fmt.Sprintf("%d/%d", i+1, len(response.ToolCalls)),
 				})
-				result, err := e.toolRegistry.ExecuteTool(ctx, tc.Function.Name, json.RawMessage(tc.Function.Arguments))
+				// Tools the approval policy denies, or the user rejects, are answered without running
+				var result *types.ToolResult
+				var err error
+				executed := false
+				if limit := e.budget.exceeded(); limit != "" {
+					// Remaining calls of the round are answered without running once the budget is used up
+					e.budget.markExceeded(limit)
+					result = budgetRefusal(limit)
+				} else if refusal := e.authorizeToolCall(ctx, tc, args, state.CurrentRound, sessionID, messageID); refusal != nil {
+					result = refusal
+				} else {
+					executed = true
+					// Time spent waiting for the user is not part of the tool's duration
+					toolCallStartTime = time.Now()
+					toolCtx, cancelTool := e.budget.withDeadline(ctx)
+					toolCtx = context.WithValue(toolCtx, types.ToolCallIDContextKey, tc.ID)
+					if messageID != "" {
+						toolCtx = context.WithValue(toolCtx, types.MessageIDContextKey, messageID)
+					}
+					result, err = e.toolRegistry.ExecuteTool(toolCtx, tc.Function.Name, json.RawMessage(tc.Function.Arguments))
+					cancelTool()
+				}
 				duration := time.Since(toolCallStartTime).Milliseconds()
 				logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool execution completed in %dms",
 					state.CurrentRound+1, i+1, len(response.ToolCalls), duration)
@@ -328,6 +388,13 @@ func (e *AgentEngine) executeLoop(
 						Error:   err.Error(),
 					}
 				}
+				result = toolCall.Result
+				if executed {
+					e.budget.addToolCall(result)
+				}
+				if result != nil && len(result.Artifacts) > 0 {
+					e.saveArtifacts(ctx, tc, result, state.CurrentRound, sessionID, messageID)
+				}
 
 				toolSuccess := toolCall.Result != nil && toolCall.Result.Success
 				pipelineFields := map[string]interface{}{
@@ -418,10 +485,12 @@ func (e *AgentEngine) executeLoop(
 
 				// Optional: Reflection after each tool call (streaming)
 				if e.config.ReflectionEnabled && result != nil {
+					reflectCtx, cancelReflect := e.budget.withDeadline(ctx)
 					reflection, err := e.streamReflectionToEventBus(
-						ctx, tc.ID, tc.Function.Name, result.Output,
+						reflectCtx, tc.ID, tc.Function.Name, result.Output,
 						state.CurrentRound, sessionID,
 					)
+					cancelReflect()
 					if err != nil {
 						logger.Warnf(ctx, "Reflection failed: %v", err)
 					} else if reflection != "" {
@@ -451,11 +520,20 @@ func (e *AgentEngine) executeLoop(
 
 	// If loop finished without final answer, generate one
 	if !state.IsComplete {
-		logger.Info(ctx, "Reached max iterations, generating final answer")
-		common.PipelineWarn(ctx, "Agent", "max_iterations_reached", map[string]interface{}{
-			"iterations": state.CurrentRound,
-			"max":        e.config.MaxIterations,
-		})
+		if limit := e.budget.exceededLimit(); limit != "" {
+			logger.Infof(ctx, "Budget exceeded (%s), generating final answer", limit)
+			common.PipelineWarn(ctx, "Agent", "budget_exceeded", map[string]interface{}{
+				"iterations": state.CurrentRound,
+				"limit":      string(limit),
+				"used":       e.budget.describe(limit),
+			})
+		} else {
+			logger.Info(ctx, "Reached max iterations, generating final answer")
+			common.PipelineWarn(ctx, "Agent", "max_iterations_reached", map[string]interface{}{
+				"iterations": state.CurrentRound,
+				"max":        e.config.MaxIterations,
+			})
+		}
 
 		// Stream final answer generation through EventBus
 		if err := e.streamFinalAnswerToEventBus(ctx, query, state, sessionID); err != nil {
@@ -474,6 +552,9 @@ func (e *AgentEngine) executeLoop(
 	for _, ref := range state.KnowledgeRefs {
 		knowledgeRefsInterface = append(knowledgeRefsInterface, ref)
 	}
+	state.Usage = e.budget.snapshot(len(state.RoundSteps))
+	logger.Infof(ctx, "[Agent] Usage: %d LLM calls, ~%d tokens, %d tool calls, %dms",
+		state.Usage.LLMCalls, state.Usage.TotalTokens, state.Usage.ToolCalls, state.Usage.DurationMs)
 
 	e.eventBus.Emit(ctx, event.Event{
 		ID:        generateEventID("complete"),
@@ -486,6 +567,7 @@ func (e *AgentEngine) executeLoop(
 			TotalSteps:      len(state.RoundSteps),
 			TotalDurationMs: time.Since(startTime).Milliseconds(),
 			MessageID:       messageID, // Include message ID for proper message update
+			Usage:           state.Usage,
 		},
 	})
 
@@ -493,11 +575,43 @@ func (e *AgentEngine) executeLoop(
 	return state, nil
 }
 
+// appendResourceUpdates appends a notice listing the MCP resources updated since last round
+func (e *AgentEngine) appendResourceUpdates(ctx context.Context, messages []chat.Message, round int) []chat.Message {
+	updates := e.resourceWatcher.DrainUpdates()
+	if len(updates) == 0 {
+		return messages
+	}
+
+	var builder strings.Builder
+	builder.WriteString("[Notice] The following MCP resources you read earlier have been updated:\n")
+	uris := make([]string, 0, len(updates))
+	for _, update := range updates {
+		builder.WriteString(fmt.Sprintf("- %s (service: %s, updated at %s)\n",
+			update.URI, update.ServiceName, update.UpdatedAt.Format(time.RFC3339)))
+		uris = append(uris, update.URI)
+	}
+	builder.WriteString("Read them again with the read_resource tool of the service if they are relevant to the task.")
+
+	logger.Infof(ctx, "[Agent][Round-%d] %d MCP resources updated: %v", round+1, len(updates), uris)
+	common.PipelineInfo(ctx, "Agent", "mcp_resources_updated", map[string]interface{}{
+		"iteration": round,
+		"resources": strings.Join(uris, ", "),
+	})
+	return append(messages, chat.Message{
+		Role:    "user",
+		Content: builder.String(),
+	})
+}
+
 // buildToolsForLLM builds the tools list for LLM function calling
 func (e *AgentEngine) buildToolsForLLM() []chat.Tool {
 	functionDefs := e.toolRegistry.GetFunctionDefinitions()
 	tools := make([]chat.Tool, 0, len(functionDefs))
 	for _, def := range functionDefs {
+		// Tools the approval policy denies are not offered to the model at all
+		if e.config.ToolApprovalPolicy.ModeFor(def.Name) == types.ToolApprovalDeny {
+			continue
+		}
 		tools = append(tools, chat.Tool{
 			Type: "function",
 			Function: chat.FunctionDef{
@@ -622,6 +736,7 @@ func (e *AgentEngine) streamLLMToEventBus(
 			emitFunc(&chunk, fullContent)
 		}
 	}
+	e.budget.addLLMCall(messages, opts.Tools, fullContent, toolCalls)
 
 	return fullContent, toolCalls, nil
 }
@@ -784,10 +899,11 @@ func (e *AgentEngine) streamFinalAnswerToEventBus(
 		e.selectedDocs,
 		e.systemPromptTemplate,
 	)
+	systemPrompt += FormatUserMemories(e.config.UserMemories)
 
 	messages := []chat.Message{
 		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: query},
+		{Role: "user", Content: query, Images: e.queryImages},
 	}
 
 	// Add all tool call results as context
@@ -899,6 +1015,7 @@ func (e *AgentEngine) buildMessagesWithLLMContext(
 	messages = append(messages, chat.Message{
 		Role:    "user",
 		Content: currentQuery,
+		Images:  e.queryImages,
 	})
 
 	return messages
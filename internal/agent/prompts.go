@@ -225,6 +225,25 @@ func formatSelectedDocuments(docs []*SelectedDocumentInfo) string {
 	return builder.String()
 }
 
+// FormatUserMemories formats long-term memories about the user for the prompt
+func FormatUserMemories(memories []string) string {
+	if len(memories) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("\n### What You Remember About the User\n")
+	builder.WriteString("The following facts were learned from previous conversations with this user. ")
+	builder.WriteString("Use them to personalize your answer when relevant, do not mention them otherwise, ")
+	builder.WriteString("and prefer what the user says in the current conversation if it conflicts.\n\n")
+	for _, memory := range memories {
+		builder.WriteString(fmt.Sprintf("- %s\n", memory))
+	}
+	builder.WriteString("\n")
+
+	return builder.String()
+}
+
 // renderPromptPlaceholdersWithStatus renders placeholders including web search status
 // Supported placeholders:
 //   - {{knowledge_bases}}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// authorizeToolCall applies the tool approval policy of the agent to a tool call.
+// It returns nil if the tool may run, otherwise the failed result reported to the model instead.
+// For tools that need confirmation it persists a pending approval, announces it on the event bus
+// and blocks until the user decides, the approval expires or ctx is done.
+func (e *AgentEngine) authorizeToolCall(
+	ctx context.Context,
+	tc types.LLMToolCall,
+	args map[string]any,
+	iteration int,
+	sessionID, messageID string,
+) *types.ToolResult {
+	policy := e.config.ToolApprovalPolicy
+	switch policy.ModeFor(tc.Function.Name) {
+	case types.ToolApprovalDeny:
+		logger.Infof(ctx, "[Agent] Tool %s is denied by the approval policy", tc.Function.Name)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Tool %s is not allowed for this agent. Do not call it again.", tc.Function.Name),
+		}
+	case types.ToolApprovalConfirm:
+	default:
+		return nil
+	}
+
+	if e.toolApprovals == nil {
+		logger.Warnf(ctx, "[Agent] Tool %s requires approval but approvals are unavailable", tc.Function.Name)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Tool %s requires the user's approval, which is not available.", tc.Function.Name),
+		}
+	}
+
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	approval := &types.ToolApproval{
+		TenantID:   tenantID,
+		SessionID:  sessionID,
+		MessageID:  messageID,
+		ToolCallID: tc.ID,
+		ToolName:   tc.Function.Name,
+		Arguments:  types.JSON(tc.Function.Arguments),
+	}
+	if err := e.toolApprovals.RequestApproval(ctx, approval, policy.Timeout()); err != nil {
+		logger.Errorf(ctx, "[Agent] Failed to request approval of tool %s: %v", tc.Function.Name, err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to ask the user to approve tool %s.", tc.Function.Name),
+		}
+	}
+	e.emitToolApproval(ctx, tc, args, approval, iteration, sessionID, "-tool-approval")
+
+	// The wait ends with the duration budget of the run, the approval then expires
+	waitCtx, cancel := e.budget.withDeadline(ctx)
+	decided, err := e.toolApprovals.WaitForDecision(waitCtx, tenantID, approval.ID)
+	cancel()
+	if err != nil {
+		logger.Errorf(ctx, "[Agent] Failed to wait for approval %s: %v", approval.ID, err)
+		decided = approval
+		decided.Status = types.ToolApprovalExpired
+		decided.Reason = "The decision of the user could not be read"
+	}
+	e.emitToolApproval(ctx, tc, args, decided, iteration, sessionID, "-tool-approval-result")
+	logger.Infof(ctx, "[Agent] Tool %s approval %s: %s", tc.Function.Name, approval.ID, decided.Status)
+
+	switch decided.Status {
+	case types.ToolApprovalApproved:
+		return nil
+	case types.ToolApprovalRejected:
+		message := fmt.Sprintf("The user rejected the call of tool %s.", tc.Function.Name)
+		if decided.Reason != "" {
+			message += " Reason: " + decided.Reason
+		}
+		return &types.ToolResult{
+			Success: false,
+			Error:   message + " Do not call it again unless the user asks for it.",
+		}
+	default:
+		return &types.ToolResult{
+			Success: false,
+			Error: fmt.Sprintf("The call of tool %s was not approved: %s. It was not executed.",
+				tc.Function.Name, decided.Reason),
+		}
+	}
+}
+
+// emitToolApproval announces an approval request or its decision on the event bus
+func (e *AgentEngine) emitToolApproval(
+	ctx context.Context,
+	tc types.LLMToolCall,
+	args map[string]any,
+	approval *types.ToolApproval,
+	iteration int,
+	sessionID, idSuffix string,
+) {
+	e.eventBus.Emit(ctx, event.Event{
+		ID:        tc.ID + idSuffix,
+		Type:      event.EventAgentToolApproval,
+		SessionID: sessionID,
+		Data: event.AgentToolApprovalData{
+			ApprovalID: approval.ID,
+			ToolCallID: tc.ID,
+			ToolName:   tc.Function.Name,
+			Arguments:  args,
+			Status:     string(approval.Status),
+			Reason:     approval.Reason,
+			ExpiresAt:  approval.ExpiresAt.Unix(),
+			Iteration:  iteration,
+		},
+	})
+}
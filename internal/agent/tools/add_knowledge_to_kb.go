@@ -168,5 +168,10 @@ The knowledge has been processed and will be available for search shortly.`,
 	return &types.ToolResult{
 		Success: true,
 		Output:  output,
+		Data: map[string]interface{}{
+			"knowledge_id":      knowledge.ID,
+			"knowledge_base_id": input.KnowledgeBaseID,
+			"title":             input.Title,
+		},
 	}, nil
 }
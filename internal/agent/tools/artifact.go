@@ -0,0 +1,371 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	// maxChartArtifactRows bounds the data points inlined into a chart specification
+	maxChartArtifactRows = 1000
+	// maxFileArtifactRows bounds the rows written to a CSV or XLSX artifact
+	maxFileArtifactRows = 10000
+
+	vegaLiteSchema = "https://vega.github.io/schema/vega-lite/v5.json"
+)
+
+// ChartOptions describes how a result set is drawn as a chart
+type ChartOptions struct {
+	Mark  string `json:"mark"            jsonschema:"Chart type: bar, line, area, point or arc (pie)"`
+	X     string `json:"x"               jsonschema:"Result column on the x axis, or the slice category of an arc chart"`
+	Y     string `json:"y"               jsonschema:"Numeric result column on the y axis, or the slice size of an arc chart"`
+	Color string `json:"color,omitempty" jsonschema:"Optional result column to split series by"`
+}
+
+// chartMarks are the Vega-Lite marks a chart artifact may use
+var chartMarks = []string{"bar", "line", "area", "point", "arc"}
+
+// vegaFieldType is the Vega-Lite measurement type of a result column
+type vegaFieldType string
+
+const (
+	vegaQuantitative vegaFieldType = "quantitative"
+	vegaTemporal     vegaFieldType = "temporal"
+	vegaNominal      vegaFieldType = "nominal"
+)
+
+// temporalLayouts are the time formats recognized in result values
+var temporalLayouts = []string{
+	"2006-01-02 15:04:05 -0700 MST", // time.Time formatted with %v
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006-01",
+}
+
+// isNullValue reports whether a formatted result value is NULL
+func isNullValue(value string) bool {
+	return value == "" || value == "<nil>"
+}
+
+// inferFieldType infers the Vega-Lite type of a column from its values, ignoring NULLs
+func inferFieldType(column string, rows []map[string]string) vegaFieldType {
+	numeric, temporal, seen := true, true, false
+	for _, row := range rows {
+		value := row[column]
+		if isNullValue(value) {
+			continue
+		}
+		seen = true
+		if numeric {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				numeric = false
+			}
+		}
+		if temporal {
+			if _, ok := parseTemporal(value); !ok {
+				temporal = false
+			}
+		}
+		if !numeric && !temporal {
+			break
+		}
+	}
+	switch {
+	case !seen:
+		return vegaNominal
+	case numeric:
+		return vegaQuantitative
+	case temporal:
+		return vegaTemporal
+	default:
+		return vegaNominal
+	}
+}
+
+// parseTemporal parses a formatted result value as a time
+func parseTemporal(value string) (time.Time, bool) {
+	for _, layout := range temporalLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// typedValue converts a formatted result value to the JSON value of its column type
+func typedValue(value string, fieldType vegaFieldType) interface{} {
+	if isNullValue(value) {
+		return nil
+	}
+	switch fieldType {
+	case vegaQuantitative:
+		f, _ := strconv.ParseFloat(value, 64)
+		return f
+	case vegaTemporal:
+		t, _ := parseTemporal(value)
+		return t.Format(time.RFC3339)
+	default:
+		return value
+	}
+}
+
+// escapeVegaField escapes the characters Vega-Lite treats as nested field access
+func escapeVegaField(field string) string {
+	return strings.NewReplacer(`\`, `\\`, ".", `\.`, "[", `\[`, "]", `\]`).Replace(field)
+}
+
+// BuildChartSpec builds a Vega-Lite specification of a result set with its data inlined.
+// Without x or y, the first column is drawn against the first other numeric column.
+func BuildChartSpec(
+	title string, chart *ChartOptions, columns []string, rows []map[string]string,
+) (json.RawMessage, error) {
+	options := ChartOptions{Mark: "bar"}
+	if chart != nil {
+		options = *chart
+	}
+	options.Mark = strings.ToLower(strings.TrimSpace(options.Mark))
+	if options.Mark == "" {
+		options.Mark = "bar"
+	}
+	if !slices.Contains(chartMarks, options.Mark) {
+		return nil, fmt.Errorf("unsupported chart mark %q, use one of %s", options.Mark, strings.Join(chartMarks, ", "))
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("the query returned no columns to chart")
+	}
+
+	fieldTypes := make(map[string]vegaFieldType, len(columns))
+	for _, column := range columns {
+		fieldTypes[column] = inferFieldType(column, rows)
+	}
+	if options.X == "" {
+		options.X = columns[0]
+	}
+	if options.Y == "" {
+		for _, column := range columns {
+			if column != options.X && fieldTypes[column] == vegaQuantitative {
+				options.Y = column
+				break
+			}
+		}
+	}
+	for _, field := range []string{options.X, options.Y, options.Color} {
+		if field != "" && !slices.Contains(columns, field) {
+			return nil, fmt.Errorf("column %q is not in the query result (columns: %s)",
+				field, strings.Join(columns, ", "))
+		}
+	}
+	if options.Y == "" {
+		return nil, fmt.Errorf("the query result has no numeric column for the y axis")
+	}
+	if options.Mark != "point" && fieldTypes[options.Y] != vegaQuantitative {
+		return nil, fmt.Errorf("column %q must be numeric for a %s chart", options.Y, options.Mark)
+	}
+
+	values := make([]map[string]interface{}, 0, min(len(rows), maxChartArtifactRows))
+	for _, row := range rows[:min(len(rows), maxChartArtifactRows)] {
+		value := make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			value[column] = typedValue(row[column], fieldTypes[column])
+		}
+		values = append(values, value)
+	}
+
+	encodeField := func(column string) map[string]interface{} {
+		encoding := map[string]interface{}{
+			"field": escapeVegaField(column),
+			"type":  fieldTypes[column],
+			"title": column,
+		}
+		// 保持 SQL 中 ORDER BY 的顺序
+		if fieldTypes[column] == vegaNominal {
+			encoding["sort"] = nil
+		}
+		return encoding
+	}
+	encoding := map[string]interface{}{}
+	if options.Mark == "arc" {
+		encoding["theta"] = encodeField(options.Y)
+		encoding["color"] = encodeField(options.X)
+	} else {
+		encoding["x"] = encodeField(options.X)
+		encoding["y"] = encodeField(options.Y)
+		if options.Color != "" {
+			encoding["color"] = encodeField(options.Color)
+		}
+	}
+
+	tooltip := make([]map[string]interface{}, 0, len(columns))
+	for _, column := range columns {
+		tooltip = append(tooltip, map[string]interface{}{
+			"field": escapeVegaField(column),
+			"type":  fieldTypes[column],
+			"title": column,
+		})
+	}
+	encoding["tooltip"] = tooltip
+
+	spec := map[string]interface{}{
+		"$schema":  vegaLiteSchema,
+		"width":    "container",
+		"data":     map[string]interface{}{"values": values},
+		"mark":     map[string]interface{}{"type": options.Mark},
+		"encoding": encoding,
+	}
+	if title != "" {
+		spec["title"] = title
+	}
+	return json.Marshal(spec)
+}
+
+// BuildCSV writes a result set as CSV with a UTF-8 BOM, so that Excel opens it correctly
+func BuildCSV(columns []string, rows []map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xEF, 0xBB, 0xBF})
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			record[i] = row[column]
+			if isNullValue(record[i]) {
+				record[i] = ""
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// BuildXLSX writes a result set as an Excel workbook, with numeric columns stored as numbers
+func BuildXLSX(columns []string, rows []map[string]string) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	writer, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, err
+	}
+	fieldTypes := make([]vegaFieldType, len(columns))
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		fieldTypes[i] = inferFieldType(column, rows)
+		header[i] = column
+	}
+	if err := writer.SetRow("A1", header); err != nil {
+		return nil, err
+	}
+	for r, row := range rows {
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			value := row[column]
+			switch {
+			case isNullValue(value):
+				values[i] = nil
+			case fieldTypes[i] == vegaQuantitative:
+				values[i], _ = strconv.ParseFloat(value, 64)
+			default:
+				values[i] = value
+			}
+		}
+		cell, err := excelize.CoordinatesToCellName(1, r+2)
+		if err != nil {
+			return nil, err
+		}
+		if err := writer.SetRow(cell, values); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// artifactFileName derives a file name from the title of an artifact
+func artifactFileName(title string, ext string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(title))
+	name = strings.Trim(name, "_")
+	if name == "" {
+		name = "query_result"
+	}
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[:100])
+	}
+	return name + "." + ext
+}
+
+// NewResultArtifact builds a chart, CSV or XLSX artifact of a result set.
+// The ID is assigned here so the tool output can tell the model how to reference it.
+// It returns the artifact and whether the rows were truncated to the artifact row limit.
+func NewResultArtifact(
+	artifactType types.ArtifactType, title string, chart *ChartOptions,
+	columns []string, rows []map[string]string,
+) (*types.MessageArtifact, bool, error) {
+	artifact := &types.MessageArtifact{
+		ID:    uuid.New().String(),
+		Type:  artifactType,
+		Title: strings.TrimSpace(title),
+	}
+
+	limit := maxFileArtifactRows
+	if artifactType == types.ArtifactChart {
+		limit = maxChartArtifactRows
+	}
+	truncated := len(rows) > limit
+	rows = rows[:min(len(rows), limit)]
+	artifact.RowCount = len(rows)
+
+	var err error
+	switch artifactType {
+	case types.ArtifactChart:
+		var spec json.RawMessage
+		spec, err = BuildChartSpec(artifact.Title, chart, columns, rows)
+		artifact.Spec = types.JSON(spec)
+	case types.ArtifactCSV:
+		artifact.FileName = artifactFileName(artifact.Title, "csv")
+		artifact.ContentType = "text/csv; charset=utf-8"
+		artifact.Content, err = BuildCSV(columns, rows)
+	case types.ArtifactXLSX:
+		artifact.FileName = artifactFileName(artifact.Title, "xlsx")
+		artifact.ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		artifact.Content, err = BuildXLSX(columns, rows)
+	default:
+		err = fmt.Errorf("unsupported artifact type %q, use chart, csv or xlsx", artifactType)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	artifact.Size = int64(len(artifact.Content))
+	return artifact, truncated, nil
+}
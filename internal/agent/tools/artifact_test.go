@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestNewResultArtifact(t *testing.T) {
+	columns := []string{"month", "sales.total", "region"}
+	rows := []map[string]string{
+		{"month": "2025-01-01 00:00:00 +0000 UTC", "sales.total": "10.5", "region": "east"},
+		{"month": "2025-02-01 00:00:00 +0000 UTC", "sales.total": "<nil>", "region": "west"},
+		{"month": "2025-03-01 00:00:00 +0000 UTC", "sales.total": "7", "region": "east"},
+	}
+
+	artifact, truncated, err := NewResultArtifact(types.ArtifactChart, "Monthly sales",
+		&ChartOptions{Mark: "Line", Color: "region"}, columns, rows)
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	assert.NotEmpty(t, artifact.ID)
+	assert.Equal(t, 3, artifact.RowCount)
+
+	var spec map[string]interface{}
+	require.NoError(t, json.Unmarshal(artifact.Spec, &spec))
+	assert.Equal(t, "Monthly sales", spec["title"])
+	assert.Equal(t, "line", spec["mark"].(map[string]interface{})["type"])
+	encoding := spec["encoding"].(map[string]interface{})
+	assert.Equal(t, "temporal", encoding["x"].(map[string]interface{})["type"])
+	y := encoding["y"].(map[string]interface{})
+	assert.Equal(t, `sales\.total`, y["field"])
+	assert.Equal(t, "quantitative", y["type"])
+	assert.Equal(t, "nominal", encoding["color"].(map[string]interface{})["type"])
+	values := spec["data"].(map[string]interface{})["values"].([]interface{})
+	require.Len(t, values, 3)
+	assert.Equal(t, 10.5, values[0].(map[string]interface{})["sales.total"])
+	assert.Nil(t, values[1].(map[string]interface{})["sales.total"])
+	assert.Equal(t, "2025-01-01T00:00:00Z", values[0].(map[string]interface{})["month"])
+
+	// 饼图使用 theta 与 color 编码
+	artifact, _, err = NewResultArtifact(types.ArtifactChart, "", &ChartOptions{Mark: "arc", X: "region"}, columns, rows)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(artifact.Spec, &spec))
+	encoding = spec["encoding"].(map[string]interface{})
+	assert.Contains(t, encoding, "theta")
+	assert.NotContains(t, encoding, "x")
+
+	for _, chart := range []*ChartOptions{
+		{Mark: "pie"},
+		{Mark: "bar", X: "month", Y: "missing"},
+		{Mark: "bar", X: "month", Y: "region"},
+	} {
+		_, _, err := NewResultArtifact(types.ArtifactChart, "", chart, columns, rows)
+		assert.Error(t, err, chart)
+	}
+
+	artifact, _, err = NewResultArtifact(types.ArtifactCSV, "Sales / 2025", nil, columns, rows)
+	require.NoError(t, err)
+	assert.Equal(t, "Sales___2025.csv", artifact.FileName)
+	assert.Equal(t, int64(len(artifact.Content)), artifact.Size)
+	assert.True(t, bytes.HasPrefix(artifact.Content, []byte{0xEF, 0xBB, 0xBF}))
+	assert.Contains(t, string(artifact.Content), "month,sales.total,region\n")
+	assert.Contains(t, string(artifact.Content), ",,west\n")
+
+	artifact, _, err = NewResultArtifact(types.ArtifactXLSX, "", nil, columns, rows)
+	require.NoError(t, err)
+	assert.Equal(t, "query_result.xlsx", artifact.FileName)
+	f, err := excelize.OpenReader(bytes.NewReader(artifact.Content))
+	require.NoError(t, err)
+	defer f.Close()
+	sheetRows, err := f.GetRows(f.GetSheetName(0))
+	require.NoError(t, err)
+	require.Len(t, sheetRows, 4)
+	assert.Equal(t, columns, sheetRows[0])
+	assert.Equal(t, "10.5", sheetRows[1][1])
+	cellType, err := f.GetCellType(f.GetSheetName(0), "B2")
+	require.NoError(t, err)
+	assert.NotEqual(t, excelize.CellTypeSharedString, cellType)
+}
@@ -14,14 +14,20 @@ import (
 )
 
 var dataAnalysisTool = BaseTool{
-	name:        ToolDataAnalysis,
-	description: "Use this tool when the knowledge is CSV or Excel files. It loads the data into memory and executes SQL for data analysis. If the user's question requires data statistics, convert the question into SQL and execute it.",
-	schema:      utils.GenerateSchema[DataAnalysisInput](),
+	name: ToolDataAnalysis,
+	description: "Use this tool when the knowledge is CSV or Excel files. It loads the data into memory and executes SQL for data analysis. If the user's question requires data statistics, convert the question into SQL and execute it. " +
+		"Set artifact to chart to draw the result as a chart, or to csv/xlsx to offer the result as a download, " +
+		"then place the returned <artifact id=\"...\"/> tag in the final answer where it should appear.",
+	schema: utils.GenerateSchema[DataAnalysisInput](),
 }
 
 type DataAnalysisInput struct {
 	KnowledgeID string `json:"knowledge_id" jsonschema:"id of the knowledge to query"`
 	Sql         string `json:"sql" jsonschema:"SQL to be executed on knowledge"`
+	// Optional structured output of the result set
+	Artifact types.ArtifactType `json:"artifact,omitempty" jsonschema:"Optional: chart to draw the result, csv or xlsx to offer it as a download"`
+	Title    string             `json:"title,omitempty" jsonschema:"Title of the chart or file"`
+	Chart    *ChartOptions      `json:"chart,omitempty" jsonschema:"How to draw the chart, used when artifact is chart"`
 }
 
 type DataAnalysisTool struct {
@@ -119,7 +125,7 @@ func (t *DataAnalysisTool) Execute(ctx context.Context, args json.RawMessage) (*
 
 	logger.Infof(ctx, "[Tool][DataAnalysis] Received SQL query for session %s: %s", t.sessionID, input.Sql)
 	// Execute single query and get results
-	results, err := t.executeSingleQuery(ctx, input.Sql)
+	columns, results, err := t.executeSingleQuery(ctx, input.Sql)
 	if err != nil {
 		return &types.ToolResult{
 			Success: false,
@@ -129,17 +135,47 @@ func (t *DataAnalysisTool) Execute(ctx context.Context, args json.RawMessage) (*
 
 	queryOutput := t.formatQueryResults(results, input.Sql)
 	logger.Infof(ctx, "[Tool][DataAnalysis] Completed execution query, total %d rows for session %s", len(results), t.sessionID)
-	return &types.ToolResult{
+	result := &types.ToolResult{
 		Success: true,
 		Output:  queryOutput,
 		Data: map[string]interface{}{
+			"columns":      columns,
 			"rows":         results,
 			"row_count":    len(results),
 			"query":        input.Sql,
 			"display_type": ToolDataAnalysis,
 			"session_id":   t.sessionID,
 		},
-	}, nil
+	}
+	if input.Artifact != "" {
+		result.Output += t.attachArtifact(ctx, result, input, columns, results)
+	}
+	return result, nil
+}
+
+// attachArtifact builds the requested artifact of the result set and returns the note appended to the output.
+// A failure does not fail the query, the note tells the model what went wrong instead.
+func (t *DataAnalysisTool) attachArtifact(
+	ctx context.Context, result *types.ToolResult, input DataAnalysisInput,
+	columns []string, rows []map[string]string,
+) string {
+	artifact, truncated, err := NewResultArtifact(input.Artifact, input.Title, input.Chart, columns, rows)
+	if err != nil {
+		logger.Warnf(ctx, "[Tool][DataAnalysis] Failed to build %s artifact for session %s: %v",
+			input.Artifact, t.sessionID, err)
+		return fmt.Sprintf("\n=== 产物 ===\n\n未能生成 %s: %v\n", input.Artifact, err)
+	}
+	result.Artifacts = append(result.Artifacts, artifact)
+
+	var note strings.Builder
+	note.WriteString("\n=== 产物 ===\n\n")
+	fmt.Fprintf(&note, "已生成 %s (%d 行)", artifact.Type, artifact.RowCount)
+	if truncated {
+		fmt.Fprintf(&note, "，结果超过上限，仅包含前 %d 行", artifact.RowCount)
+	}
+	fmt.Fprintf(&note, "。在最终回答中需要展示的位置原样写出 %s，客户端会将其渲染为图表或下载链接。\n",
+		artifact.ArtifactReference())
+	return note.String()
 }
 
 // executeSingleQuery executes a single SQL query and returns columns and results
@@ -152,11 +188,11 @@ func (t *DataAnalysisTool) Execute(ctx context.Context, args json.RawMessage) (*
 //   - []string: merged column names (existing + new columns, deduplicated)
 //   - []map[string]string: query results
 //   - error: any error that occurred during execution
-func (t *DataAnalysisTool) executeSingleQuery(ctx context.Context, sqlQuery string) ([]map[string]string, error) {
+func (t *DataAnalysisTool) executeSingleQuery(ctx context.Context, sqlQuery string) ([]string, []map[string]string, error) {
 	rows, err := t.db.QueryContext(ctx, sqlQuery)
 	if err != nil {
 		logger.Errorf(ctx, "[Tool][DataAnalysis] Query execution failed: %v", err)
-		return nil, fmt.Errorf("query execution failed: %w", err)
+		return nil, nil, fmt.Errorf("query execution failed: %w", err)
 	}
 	defer rows.Close()
 
@@ -164,7 +200,7 @@ func (t *DataAnalysisTool) executeSingleQuery(ctx context.Context, sqlQuery stri
 	columns, err := rows.Columns()
 	if err != nil {
 		logger.Errorf(ctx, "[Tool][DataAnalysis] Failed to get columns: %v", err)
-		return nil, fmt.Errorf("failed to get columns: %w", err)
+		return nil, nil, fmt.Errorf("failed to get columns: %w", err)
 	}
 
 	// Process results
@@ -178,7 +214,7 @@ func (t *DataAnalysisTool) executeSingleQuery(ctx context.Context, sqlQuery stri
 
 		if err := rows.Scan(columnPointers...); err != nil {
 			logger.Errorf(ctx, "[Tool][DataAnalysis] Failed to scan row: %v", err)
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
 		rowMap := make(map[string]string)
@@ -196,10 +232,10 @@ func (t *DataAnalysisTool) executeSingleQuery(ctx context.Context, sqlQuery stri
 
 	if err := rows.Err(); err != nil {
 		logger.Errorf(ctx, "[Tool][DataAnalysis] Error iterating rows: %v", err)
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+		return nil, nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return results, nil
+	return columns, results, nil
 }
 
 // formatQueryResults formats query results into JSONL format (one JSON object per line)
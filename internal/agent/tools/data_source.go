@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+type DataSourceSchemaInput struct {
+	DataSourceID string `json:"data_source_id" jsonschema:"ID of the data source to describe"`
+}
+
+type DataSourceQueryInput struct {
+	DataSourceID string `json:"data_source_id" jsonschema:"ID of the data source to query"`
+	SQL          string `json:"sql"            jsonschema:"A single read-only SELECT statement on the allowed tables of the data source"`
+}
+
+// RegisterDataSourceTools registers the schema and query tools of the data sources bound to an agent
+func RegisterDataSourceTools(
+	registry *ToolRegistry,
+	dataSources []*types.DataSource,
+	dataSourceService interfaces.DataSourceService,
+) {
+	if len(dataSources) == 0 {
+		return
+	}
+	registry.RegisterTool(NewDataSourceSchemaTool(dataSources, dataSourceService))
+	registry.RegisterTool(NewDataSourceQueryTool(dataSources, dataSourceService))
+}
+
+// describeDataSources lists the data sources for the tool descriptions
+func describeDataSources(dataSources []*types.DataSource) string {
+	var b strings.Builder
+	b.WriteString("\n\n## Available Data Sources\n")
+	for _, ds := range dataSources {
+		fmt.Fprintf(&b, "- %s (id: %s, type: %s, tables: %s)", ds.Name, ds.ID, ds.Type,
+			strings.Join(ds.AllowedTables, ", "))
+		if ds.Description != "" {
+			b.WriteString(": " + ds.Description)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// dataSourceIndex maps the IDs of the bound data sources to them
+func dataSourceIndex(dataSources []*types.DataSource) map[string]*types.DataSource {
+	index := make(map[string]*types.DataSource, len(dataSources))
+	for _, ds := range dataSources {
+		index[ds.ID] = ds
+	}
+	return index
+}
+
+// DataSourceSchemaTool describes the allowed tables of an external data source
+type DataSourceSchemaTool struct {
+	BaseTool
+	dataSources       map[string]*types.DataSource
+	dataSourceService interfaces.DataSourceService
+}
+
+// NewDataSourceSchemaTool creates a schema tool for the given data sources
+func NewDataSourceSchemaTool(
+	dataSources []*types.DataSource,
+	dataSourceService interfaces.DataSourceService,
+) *DataSourceSchemaTool {
+	return &DataSourceSchemaTool{
+		BaseTool: BaseTool{
+			name: ToolDataSourceSchema,
+			description: "Get the columns and types of the tables an external business database allows to query. " +
+				"Call it before writing SQL for data_source_query." + describeDataSources(dataSources),
+			schema: utils.GenerateSchema[DataSourceSchemaInput](),
+		},
+		dataSources:       dataSourceIndex(dataSources),
+		dataSourceService: dataSourceService,
+	}
+}
+
+// Execute describes the data source
+func (t *DataSourceSchemaTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input DataSourceSchemaInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse args: %v", err),
+		}, err
+	}
+	ds, ok := t.dataSources[input.DataSourceID]
+	if !ok {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Unknown data source: %s", input.DataSourceID),
+		}, nil
+	}
+
+	tables, err := t.dataSourceService.DescribeDataSource(ctx, ds.ID)
+	if err != nil {
+		logger.Errorf(ctx, "[Tool][DataSourceSchema] Failed to describe data source %s: %v", ds.ID, err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to describe data source %s: %v", ds.Name, err),
+		}, nil
+	}
+
+	var output strings.Builder
+	fmt.Fprintf(&output, "=== 数据源 %s (%s) ===\n\n", ds.Name, ds.Type)
+	fmt.Fprintf(&output, "单次查询最多返回 %d 行\n\n", ds.EffectiveRowLimit())
+	for _, table := range tables {
+		fmt.Fprintf(&output, "### %s\n", table.Name)
+		if len(table.Columns) == 0 {
+			output.WriteString("  (表不存在或无可见列)\n\n")
+			continue
+		}
+		for _, column := range table.Columns {
+			nullable := ""
+			if column.Nullable {
+				nullable = ", nullable"
+			}
+			fmt.Fprintf(&output, "- %s (%s%s)\n", column.Name, column.Type, nullable)
+		}
+		output.WriteString("\n")
+	}
+
+	return &types.ToolResult{
+		Success: true,
+		Output:  output.String(),
+		Data: map[string]interface{}{
+			"display_type":   "data_source_schema",
+			"data_source_id": ds.ID,
+			"tables":         tables,
+		},
+	}, nil
+}
+
+// DataSourceQueryTool runs validated read-only queries on an external data source
+type DataSourceQueryTool struct {
+	BaseTool
+	dataSources       map[string]*types.DataSource
+	dataSourceService interfaces.DataSourceService
+}
+
+// NewDataSourceQueryTool creates a query tool for the given data sources
+func NewDataSourceQueryTool(
+	dataSources []*types.DataSource,
+	dataSourceService interfaces.DataSourceService,
+) *DataSourceQueryTool {
+	return &DataSourceQueryTool{
+		BaseTool: BaseTool{
+			name: ToolDataSourceQuery,
+			description: `Run a read-only SQL query on an external business database.
+
+## Rules
+- Only a single SELECT statement on the allowed tables of the data source
+- No CTEs (WITH), UNION, subqueries or functions in FROM; use JOINs and GROUP BY instead
+- Reference schema-qualified tables exactly as listed, e.g. sales.orders
+- Write standard SQL: quote identifiers with double quotes, not backticks, and strings with single quotes
+- Results are capped at the row limit of the data source; aggregate in SQL instead of reading raw rows` +
+				describeDataSources(dataSources),
+			schema: utils.GenerateSchema[DataSourceQueryInput](),
+		},
+		dataSources:       dataSourceIndex(dataSources),
+		dataSourceService: dataSourceService,
+	}
+}
+
+// Execute runs the query
+func (t *DataSourceQueryTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input DataSourceQueryInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse args: %v", err),
+		}, err
+	}
+	ds, ok := t.dataSources[input.DataSourceID]
+	if !ok {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Unknown data source: %s", input.DataSourceID),
+		}, nil
+	}
+	if strings.TrimSpace(input.SQL) == "" {
+		return &types.ToolResult{
+			Success: false,
+			Error:   "Missing or invalid 'sql' parameter",
+		}, nil
+	}
+
+	logger.Infof(ctx, "[Tool][DataSourceQuery] Querying data source %s: %s", ds.ID, input.SQL)
+	result, err := t.dataSourceService.QueryDataSource(ctx, ds.ID, input.SQL)
+	if err != nil {
+		logger.Errorf(ctx, "[Tool][DataSourceQuery] Query failed on data source %s: %v", ds.ID, err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	output := formatQueryResults(result.Columns, result.Rows, result.Query)
+	if result.Truncated {
+		output += fmt.Sprintf("\n注意: 结果超过数据源的行数上限，仅返回前 %d 行。请使用聚合或更精确的条件。\n",
+			result.RowCount)
+	}
+
+	return &types.ToolResult{
+		Success: true,
+		Output:  output,
+		Data: map[string]interface{}{
+			"display_type":   "database_query",
+			"data_source_id": ds.ID,
+			"columns":        result.Columns,
+			"rows":           result.Rows,
+			"row_count":      result.RowCount,
+			"truncated":      result.Truncated,
+			"query":          result.Query,
+		},
+	}, nil
+}
@@ -106,6 +106,11 @@ type SQLSecurityValidator struct {
 	allowedTables    map[string]bool
 	allowedFunctions map[string]bool
 	tenantID         uint64
+	// external validates queries on a tenant's data source: tables may be schema-qualified
+	// and no tenant condition is injected
+	external bool
+	// maxRows caps the LIMIT of the query, 0 keeps it unchanged
+	maxRows int
 }
 
 // NewSQLSecurityValidator creates a new SQL security validator
@@ -173,6 +178,31 @@ func NewSQLSecurityValidator(tenantID uint64) *SQLSecurityValidator {
 	}
 }
 
+// dataSourceFunctions are the functions of MySQL and SQLite allowed on data sources in addition
+// to the PostgreSQL whitelist
+var dataSourceFunctions = []string{
+	"ifnull", "if", "substr", "instr", "group_concat", "date", "datetime", "strftime", "julianday",
+	"date_format", "str_to_date", "year", "month", "day", "hour", "minute", "datediff", "timestampdiff",
+	"char_length", "truncate", "mod", "power", "sqrt",
+}
+
+// NewDataSourceSQLValidator creates a validator for read-only queries on an external data source.
+// Only allowedTables may be queried, written as "table" or "schema.table" the same way the query
+// references them, and the LIMIT of the query is capped at maxRows
+func NewDataSourceSQLValidator(allowedTables []string, maxRows int) *SQLSecurityValidator {
+	v := NewSQLSecurityValidator(0)
+	v.external = true
+	v.maxRows = maxRows
+	v.allowedTables = make(map[string]bool, len(allowedTables))
+	for _, table := range allowedTables {
+		v.allowedTables[strings.ToLower(strings.TrimSpace(table))] = true
+	}
+	for _, fn := range dataSourceFunctions {
+		v.allowedFunctions[fn] = true
+	}
+	return v
+}
+
 // DatabaseQueryInput defines the input parameters for database query tool
 
 // DatabaseQueryTool allows AI to query the database with auto-injected tenant_id for security
@@ -310,7 +340,7 @@ func (t *DatabaseQueryTool) Execute(ctx context.Context, args json.RawMessage) (
 
 	// Format output
 	logger.Debugf(ctx, "[Tool][DatabaseQuery] Formatting query results...")
-	output := t.formatQueryResults(columns, results, securedSQL)
+	output := formatQueryResults(columns, results, securedSQL)
 
 	logger.Infof(ctx, "[Tool][DatabaseQuery] Execute completed successfully: %d rows returned", len(results))
 	return &types.ToolResult{
@@ -368,11 +398,18 @@ func (v *SQLSecurityValidator) ValidateAndSecure(sqlQuery string) (string, error
 		return "", err
 	}
 
+	if v.maxRows > 0 {
+		v.capLimit(selectStmt)
+	}
+
 	// Phase 6: Normalize SQL (removes comments, standardizes format)
 	normalizedSQL, err := pg_query.Deparse(result)
 	if err != nil {
 		return "", fmt.Errorf("failed to normalize SQL: %v", err)
 	}
+	if v.external {
+		return normalizedSQL, nil
+	}
 
 	// Phase 7: Inject tenant_id conditions
 	securedSQL := v.injectTenantConditions(normalizedSQL, tablesInQuery)
@@ -380,6 +417,18 @@ func (v *SQLSecurityValidator) ValidateAndSecure(sqlQuery string) (string, error
 	return securedSQL, nil
 }
 
+// capLimit sets the LIMIT of the query to maxRows unless it already has a smaller constant limit
+func (v *SQLSecurityValidator) capLimit(stmt *pg_query.SelectStmt) {
+	if stmt.LimitCount != nil {
+		if c := stmt.LimitCount.GetAConst(); c != nil && !c.Isnull && c.GetIval() != nil &&
+			c.GetIval().Ival <= int32(v.maxRows) {
+			return
+		}
+	}
+	stmt.LimitCount = pg_query.MakeAConstIntNode(int64(v.maxRows), -1)
+	stmt.LimitOption = pg_query.LimitOption_LIMIT_OPTION_COUNT
+}
+
 // validateInput performs basic input validation
 func (v *SQLSecurityValidator) validateInput(sql string) error {
 	// Check for null bytes
@@ -482,6 +531,22 @@ func (v *SQLSecurityValidator) validateFromItem(node *pg_query.Node, tables map[
 	if rv := node.GetRangeVar(); rv != nil {
 		tableName := strings.ToLower(rv.Relname)
 
+		if v.external {
+			// Data sources allow tables of any schema listed explicitly
+			if rv.Schemaname != "" {
+				tableName = strings.ToLower(rv.Schemaname) + "." + tableName
+			}
+			if !v.allowedTables[tableName] {
+				return fmt.Errorf("table not allowed: %s", tableName)
+			}
+			alias := tableName
+			if rv.Alias != nil && rv.Alias.Aliasname != "" {
+				alias = strings.ToLower(rv.Alias.Aliasname)
+			}
+			tables[tableName] = alias
+			return nil
+		}
+
 		// Check for schema qualification (e.g., pg_catalog.pg_class)
 		if rv.Schemaname != "" {
 			schemaName := strings.ToLower(rv.Schemaname)
@@ -796,7 +861,7 @@ func (v *SQLSecurityValidator) injectTenantConditions(sql string, tablesInQuery
 }
 
 // formatQueryResults formats query results into readable text
-func (t *DatabaseQueryTool) formatQueryResults(
+func formatQueryResults(
 	columns []string,
 	results []map[string]interface{},
 	query string,
@@ -8,6 +8,7 @@ const (
 	ToolKnowledgeSearch     = "knowledge_search"
 	ToolListKnowledgeChunks = "list_knowledge_chunks"
 	ToolQueryKnowledgeGraph = "query_knowledge_graph"
+	ToolGraphGlobalSearch   = "graph_global_search"
 	ToolGetDocumentInfo     = "get_document_info"
 	ToolDatabaseQuery       = "database_query"
 	ToolDataAnalysis        = "data_analysis"
@@ -15,6 +16,9 @@ const (
 	ToolWebSearch           = "web_search"
 	ToolWebFetch            = "web_fetch"
 	ToolAddKnowledgeToKB    = "add_knowledge_to_kb"
+	// Registered when the agent has data sources bound
+	ToolDataSourceSchema = "data_source_schema"
+	ToolDataSourceQuery  = "data_source_query"
 )
 
 // AvailableTool defines a simple tool metadata used by settings APIs.
@@ -34,6 +38,7 @@ func AvailableToolDefinitions() []AvailableTool {
 		{Name: ToolKnowledgeSearch, Label: "语义搜索", Description: "理解问题并查找语义相关内容"},
 		{Name: ToolListKnowledgeChunks, Label: "查看文档分块", Description: "获取文档完整分块内容"},
 		{Name: ToolQueryKnowledgeGraph, Label: "查询知识图谱", Description: "从知识图谱中查询关系"},
+		{Name: ToolGraphGlobalSearch, Label: "图谱全局检索", Description: "基于知识图谱社区摘要回答全局性问题"},
 		{Name: ToolGetDocumentInfo, Label: "获取文档信息", Description: "查看文档元数据"},
 		{Name: ToolDatabaseQuery, Label: "查询数据库", Description: "查询数据库中的信息"},
 		{Name: ToolDataAnalysis, Label: "数据分析", Description: "理解数据文件并进行数据分析"},
@@ -0,0 +1,287 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// SubAgentToolPrefix prefixes the names of tools that delegate a task to another custom agent
+const SubAgentToolPrefix = "agent."
+
+var delegateAgentSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "task": {
+      "type": "string",
+      "description": "The sub-question or task for the agent. It does not see the conversation, so include all context it needs"
+    }
+  },
+  "required": ["task"]
+}`)
+
+// forwardedSubAgentEvents are the streaming events of a sub-agent shown as nested steps of the delegating agent
+var forwardedSubAgentEvents = []event.EventType{
+	event.EventAgentThought,
+	event.EventAgentToolCall,
+	event.EventAgentToolResult,
+	event.EventAgentToolApproval,
+	event.EventAgentReflection,
+	event.EventAgentArtifact,
+}
+
+// DelegateAgentTool delegates a task to another custom agent, which runs with its own
+// tools, knowledge bases and iteration budget and answers with its final answer and references
+type DelegateAgentTool struct {
+	agent     types.SubAgentInfo
+	runner    interfaces.SubAgentRunner
+	eventBus  *event.EventBus
+	path      []string
+	sessionID string
+}
+
+// NewDelegateAgentTool creates a tool delegating to the given agent.
+// path holds the IDs of the delegating agents, the calling agent last
+func NewDelegateAgentTool(
+	agent types.SubAgentInfo,
+	runner interfaces.SubAgentRunner,
+	eventBus *event.EventBus,
+	path []string,
+	sessionID string,
+) *DelegateAgentTool {
+	return &DelegateAgentTool{
+		agent:     agent,
+		runner:    runner,
+		eventBus:  eventBus,
+		path:      path,
+		sessionID: sessionID,
+	}
+}
+
+// RegisterSubAgentTools registers a delegation tool for each sub-agent
+func RegisterSubAgentTools(
+	registry *ToolRegistry,
+	agents []types.SubAgentInfo,
+	runner interfaces.SubAgentRunner,
+	eventBus *event.EventBus,
+	path []string,
+	sessionID string,
+) {
+	for _, agent := range agents {
+		registry.RegisterTool(NewDelegateAgentTool(agent, runner, eventBus, path, sessionID))
+	}
+}
+
+// Name returns the unique name for this tool
+// Format: agent.{agent_id}
+func (t *DelegateAgentTool) Name() string {
+	return SubAgentToolPrefix + sanitizeName(t.agent.ID)
+}
+
+// Description returns the tool description
+func (t *DelegateAgentTool) Description() string {
+	description := fmt.Sprintf("[Agent: %s] Delegate a self-contained sub-task to the agent %q, "+
+		"which works on it independently with its own tools and knowledge bases "+
+		"and returns its final answer with the references it used.", t.agent.Name, t.agent.Name)
+	if t.agent.Description != "" {
+		description += " The agent: " + t.agent.Description
+	}
+	return description
+}
+
+// Parameters returns the JSON Schema for tool parameters
+func (t *DelegateAgentTool) Parameters() json.RawMessage {
+	return delegateAgentSchema
+}
+
+// depth returns the nesting depth of the sub-agent
+func (t *DelegateAgentTool) depth() int {
+	return max(len(t.path), 1)
+}
+
+// Execute runs the sub-agent on the task
+func (t *DelegateAgentTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input struct {
+		Task string `json:"task"`
+	}
+	if err := json.Unmarshal(args, &input); err != nil {
+		logger.Errorf(ctx, "[Tool][DelegateAgent] Failed to parse args: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse args: %v", err),
+		}, err
+	}
+	task := strings.TrimSpace(input.Task)
+	if task == "" {
+		return &types.ToolResult{
+			Success: false,
+			Error:   "task is required",
+		}, nil
+	}
+
+	logger.Infof(ctx, "[Tool][DelegateAgent] Delegating task to agent %s (%s) at depth %d",
+		t.agent.Name, t.agent.ID, t.depth())
+
+	parentToolCallID, _ := ctx.Value(types.ToolCallIDContextKey).(string)
+	childBus := event.NewEventBus()
+	t.forwardEvents(childBus, parentToolCallID)
+
+	state, err := t.runner.RunSubAgent(ctx, t.agent.ID, task, t.path, t.sessionID, childBus)
+	if err != nil {
+		logger.Errorf(ctx, "[Tool][DelegateAgent] Agent %s failed: %v", t.agent.ID, err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Agent %s failed: %v", t.agent.Name, err),
+		}, nil
+	}
+
+	references := CollectSubAgentReferences(state)
+	var output strings.Builder
+	fmt.Fprintf(&output, "=== Answer of agent %s ===\n\n", t.agent.Name)
+	if state.FinalAnswer != "" {
+		output.WriteString(state.FinalAnswer)
+	} else {
+		output.WriteString("(The agent finished without an answer)")
+	}
+	if len(references) > 0 {
+		output.WriteString("\n\n=== References ===\n")
+		for i, ref := range references {
+			fmt.Fprintf(&output, "[%d] %v (knowledge_id: %v, chunk_id: %v)\n",
+				i+1, ref["knowledge_title"], ref["knowledge_id"], ref["chunk_id"])
+		}
+	}
+
+	return &types.ToolResult{
+		Success: true,
+		Output:  output.String(),
+		Data: map[string]interface{}{
+			"display_type":   "sub_agent",
+			"sub_agent_id":   t.agent.ID,
+			"sub_agent_name": t.agent.Name,
+			"rounds":         len(state.RoundSteps),
+			"references":     references,
+		},
+		Steps: state.RoundSteps,
+		Usage: state.Usage,
+	}, nil
+}
+
+// forwardEvents forwards the streaming events of the sub-agent to the event bus of the delegating agent.
+// Event and tool call IDs are prefixed with the delegating tool call so that they stay unique,
+// and the nesting is recorded in the metadata. The final answer of the sub-agent is returned as the
+// tool result, so it is streamed as a thought of the sub-agent rather than as the answer of the conversation.
+func (t *DelegateAgentTool) forwardEvents(childBus *event.EventBus, parentToolCallID string) {
+	nest := func(evt event.Event) event.Event {
+		prefix := func(id string) string {
+			if parentToolCallID == "" {
+				return id
+			}
+			return parentToolCallID + "/" + id
+		}
+		evt.ID = prefix(evt.ID)
+
+		// Events of deeper sub-agents already carry their own nesting
+		metadata := make(map[string]interface{}, len(evt.Metadata)+4)
+		for k, v := range evt.Metadata {
+			metadata[k] = v
+		}
+		if _, nested := metadata[event.MetadataDepth]; !nested {
+			metadata[event.MetadataDepth] = t.depth()
+			metadata[event.MetadataParentToolCallID] = parentToolCallID
+			metadata[event.MetadataAgentID] = t.agent.ID
+			metadata[event.MetadataAgentName] = t.agent.Name
+		}
+		evt.Metadata = metadata
+
+		switch data := evt.Data.(type) {
+		case event.AgentToolCallData:
+			data.ToolCallID = prefix(data.ToolCallID)
+			evt.Data = data
+		case event.AgentToolResultData:
+			data.ToolCallID = prefix(data.ToolCallID)
+			evt.Data = data
+		case event.AgentToolApprovalData:
+			data.ToolCallID = prefix(data.ToolCallID)
+			evt.Data = data
+		case event.AgentReflectionData:
+			data.ToolCallID = prefix(data.ToolCallID)
+			evt.Data = data
+		case event.AgentArtifactData:
+			data.ToolCallID = prefix(data.ToolCallID)
+			evt.Data = data
+		}
+		return evt
+	}
+
+	forward := func(ctx context.Context, evt event.Event) error {
+		return t.eventBus.Emit(ctx, nest(evt))
+	}
+	for _, eventType := range forwardedSubAgentEvents {
+		childBus.On(eventType, forward)
+	}
+	childBus.On(event.EventAgentFinalAnswer, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentFinalAnswerData)
+		if !ok {
+			return nil
+		}
+		evt.Type = event.EventAgentThought
+		evt.Data = event.AgentThoughtData{Content: data.Content, Done: data.Done}
+		return t.eventBus.Emit(ctx, nest(evt))
+	})
+}
+
+// CollectSubAgentReferences collects the knowledge chunks a sub-agent retrieved, deduplicated by chunk ID
+func CollectSubAgentReferences(state *types.AgentState) []map[string]interface{} {
+	references := make([]map[string]interface{}, 0)
+	if state == nil {
+		return references
+	}
+
+	seen := make(map[string]bool)
+	add := func(chunkID string, ref map[string]interface{}) {
+		if chunkID == "" || seen[chunkID] {
+			return
+		}
+		seen[chunkID] = true
+		references = append(references, ref)
+	}
+
+	for _, ref := range state.KnowledgeRefs {
+		if ref == nil {
+			continue
+		}
+		add(ref.ID, map[string]interface{}{
+			"chunk_id":        ref.ID,
+			"content":         ref.Content,
+			"knowledge_id":    ref.KnowledgeID,
+			"knowledge_title": ref.KnowledgeTitle,
+		})
+	}
+	for _, step := range state.RoundSteps {
+		for _, toolCall := range step.ToolCalls {
+			if toolCall.Result == nil || !toolCall.Result.Success {
+				continue
+			}
+			// Search tools report their chunks as results, delegated agents as references
+			for _, key := range []string{"results", "references"} {
+				items, _ := toolCall.Result.Data[key].([]map[string]interface{})
+				for _, item := range items {
+					chunkID, _ := item["chunk_id"].(string)
+					add(chunkID, map[string]interface{}{
+						"chunk_id":        chunkID,
+						"content":         item["content"],
+						"knowledge_id":    item["knowledge_id"],
+						"knowledge_title": item["knowledge_title"],
+					})
+				}
+			}
+		}
+	}
+	return references
+}
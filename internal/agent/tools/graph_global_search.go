@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// maxGraphGlobalSearchResults bounds the community summaries returned by one search
+const maxGraphGlobalSearchResults = 10
+
+var graphGlobalSearchTool = BaseTool{
+	name: ToolGraphGlobalSearch,
+	description: `Search the summaries of the knowledge graph communities to answer questions about a whole knowledge base.
+
+## Core Function
+The entities of a knowledge graph are grouped into communities of closely related entities, each summarized in advance. This tool returns the summaries most relevant to the query.
+
+## When to Use
+✅ **Use for**:
+- Corpus-wide questions: "What are the main topics of these documents?", "Which companies are mentioned and how are they related?"
+- Overviews and comparisons spanning many documents
+
+❌ **Don't use for**:
+- Specific facts or exact wording → use knowledge_search
+- Relationships of a single entity → use query_knowledge_graph
+
+## Parameters
+- **query** (required): The question or topic to summarize.
+- **knowledge_base_ids** (optional): Limit the search to these knowledge bases.
+- **top_k** (optional): Number of summaries to return, 1-10, default 5.
+
+## Notes
+- Communities are built on demand for knowledge bases with graph extraction enabled; no result means they have not been built yet.
+- Cite the listed entities and follow up with knowledge_search for source passages.`,
+	schema: utils.GenerateSchema[GraphGlobalSearchInput](),
+}
+
+// GraphGlobalSearchInput defines the input parameters of the graph global search tool
+type GraphGlobalSearchInput struct {
+	Query            string   `json:"query" jsonschema:"Question or topic to answer from the community summaries"`
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty" jsonschema:"Optional knowledge base IDs to limit the search"`
+	TopK             int      `json:"top_k,omitempty" jsonschema:"Number of summaries to return, 1-10, default 5"`
+}
+
+// GraphGlobalSearchTool searches the community summaries of the knowledge graphs
+type GraphGlobalSearchTool struct {
+	BaseTool
+	communityService interfaces.GraphCommunityService
+	searchTargets    types.SearchTargets
+}
+
+// NewGraphGlobalSearchTool creates a new graph global search tool limited to the knowledge bases of the search targets
+func NewGraphGlobalSearchTool(
+	communityService interfaces.GraphCommunityService,
+	searchTargets types.SearchTargets,
+) *GraphGlobalSearchTool {
+	return &GraphGlobalSearchTool{
+		BaseTool:         graphGlobalSearchTool,
+		communityService: communityService,
+		searchTargets:    searchTargets,
+	}
+}
+
+// Execute searches the community summaries
+func (t *GraphGlobalSearchTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input GraphGlobalSearchInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse args: %v", err),
+		}, err
+	}
+	if strings.TrimSpace(input.Query) == "" {
+		return &types.ToolResult{
+			Success: false,
+			Error:   "query is required",
+		}, fmt.Errorf("invalid query")
+	}
+	if input.TopK <= 0 {
+		input.TopK = 5
+	}
+	input.TopK = min(input.TopK, maxGraphGlobalSearchResults)
+
+	// 只能检索智能体可访问的知识库
+	kbIDs := t.searchTargets.GetAllKnowledgeBaseIDs()
+	if len(input.KnowledgeBaseIDs) > 0 {
+		kbIDs = slices.DeleteFunc(kbIDs, func(id string) bool {
+			return !slices.Contains(input.KnowledgeBaseIDs, id)
+		})
+	}
+	if len(kbIDs) == 0 {
+		return &types.ToolResult{
+			Success: false,
+			Error:   "no accessible knowledge bases to search",
+		}, fmt.Errorf("no search targets available")
+	}
+
+	communities, err := t.communityService.SearchCommunities(ctx, kbIDs, input.Query, input.TopK)
+	if err != nil {
+		logger.Errorf(ctx, "[Tool][GraphGlobalSearch] Failed to search communities: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to search communities: %v", err),
+		}, err
+	}
+	if len(communities) == 0 {
+		return &types.ToolResult{
+			Success: true,
+			Output:  "未找到知识图谱社区摘要，知识库的图谱社区可能尚未构建。请改用 knowledge_search。",
+			Data: map[string]interface{}{
+				"query":              input.Query,
+				"knowledge_base_ids": kbIDs,
+				"results":            []interface{}{},
+			},
+		}, nil
+	}
+
+	var output strings.Builder
+	output.WriteString("=== 知识图谱全局检索 ===\n\n")
+	output.WriteString(fmt.Sprintf("📊 查询: %s\n", input.Query))
+	output.WriteString(fmt.Sprintf("✓ 找到 %d 个相关社区\n\n", len(communities)))
+	results := make([]map[string]interface{}, 0, len(communities))
+	for i, community := range communities {
+		entities := community.Entities[:min(len(community.Entities), 20)]
+		output.WriteString(fmt.Sprintf("社区 #%d: %s\n", i+1, community.Title))
+		output.WriteString(fmt.Sprintf("  📍 相关度: %.2f\n", community.Score))
+		output.WriteString(fmt.Sprintf("  🔗 实体 (%d): %s\n", community.Size, strings.Join(entities, ", ")))
+		output.WriteString(fmt.Sprintf("  📄 摘要: %s\n\n", community.Summary))
+		results = append(results, map[string]interface{}{
+			"community_id":      community.ID,
+			"knowledge_base_id": community.KnowledgeBaseID,
+			"title":             community.Title,
+			"summary":           community.Summary,
+			"entities":          entities,
+			"size":              community.Size,
+			"score":             community.Score,
+		})
+	}
+	output.WriteString("💡 摘要概括了多个文档，需要原文依据时使用 knowledge_search 检索相关实体\n")
+
+	return &types.ToolResult{
+		Success: true,
+		Output:  output.String(),
+		Data: map[string]interface{}{
+			"query":              input.Query,
+			"knowledge_base_ids": kbIDs,
+			"results":            results,
+			"count":              len(results),
+		},
+	}, nil
+}
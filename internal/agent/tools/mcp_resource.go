@@ -0,0 +1,195 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/mcp"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// mcpResourceMaxChars limits the resource text returned to the LLM
+	mcpResourceMaxChars = 20000
+	// mcpResourceListLimit limits the number of resources listed in the tool description
+	mcpResourceListLimit = 20
+)
+
+// MCPResourceInput defines the input parameters for the MCP resource read tool
+type MCPResourceInput struct {
+	URI       string `json:"uri"`
+	Subscribe *bool  `json:"subscribe,omitempty"`
+}
+
+// MCPResourceTool reads resources of an MCP service so the agent can use them as context
+type MCPResourceTool struct {
+	service    *types.MCPService
+	resources  []*types.MCPResource
+	mcpManager *mcp.MCPManager
+	watcher    *mcp.ResourceWatcher
+}
+
+// NewMCPResourceTool creates a new MCP resource read tool
+// resources are the resources listed by the service, used to describe the tool
+// watcher is optional, when set read resources are subscribed for update notifications
+func NewMCPResourceTool(
+	service *types.MCPService,
+	resources []*types.MCPResource,
+	mcpManager *mcp.MCPManager,
+	watcher *mcp.ResourceWatcher,
+) *MCPResourceTool {
+	return &MCPResourceTool{
+		service:    service,
+		resources:  resources,
+		mcpManager: mcpManager,
+		watcher:    watcher,
+	}
+}
+
+// Name returns the unique name for this tool
+// Format: mcp.{service_name}.read_resource
+func (t *MCPResourceTool) Name() string {
+	return fmt.Sprintf("mcp.%s.read_resource", sanitizeName(t.service.Name))
+}
+
+// Description returns the tool description including the known resources
+func (t *MCPResourceTool) Description() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("[MCP Service: %s] Read the content of a resource by its URI.", t.service.Name))
+	if len(t.resources) > 0 {
+		builder.WriteString(" Available resources:")
+		for i, resource := range t.resources {
+			if i >= mcpResourceListLimit {
+				builder.WriteString(fmt.Sprintf("\n- ... and %d more", len(t.resources)-mcpResourceListLimit))
+				break
+			}
+			builder.WriteString(fmt.Sprintf("\n- %s (%s)", resource.URI, resource.Name))
+			if resource.Description != "" {
+				builder.WriteString(": " + resource.Description)
+			}
+		}
+	}
+	return builder.String()
+}
+
+// Parameters returns the JSON Schema for tool parameters
+func (t *MCPResourceTool) Parameters() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"uri": {
+				"type": "string",
+				"description": "URI of the resource to read"
+			},
+			"subscribe": {
+				"type": "boolean",
+				"description": "Whether to be notified when the resource changes during this session (default: true)"
+			}
+		},
+		"required": ["uri"]
+	}`)
+}
+
+// Execute reads the resource and returns its text content
+func (t *MCPResourceTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input MCPResourceInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		logger.Errorf(ctx, "[Tool][MCPResource] Failed to parse args: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse args: %v", err),
+		}, err
+	}
+	input.URI = strings.TrimSpace(input.URI)
+	if input.URI == "" {
+		return &types.ToolResult{
+			Success: false,
+			Error:   "uri is required",
+		}, nil
+	}
+	logger.Infof(ctx, "[Tool][MCPResource] Reading resource %s from service: %s", input.URI, t.service.Name)
+
+	client, err := t.mcpManager.GetOrCreateClient(t.service)
+	if err != nil {
+		logger.Errorf(ctx, "[Tool][MCPResource] Failed to get MCP client: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to connect to MCP service: %v", err),
+		}, nil
+	}
+
+	// For stdio transport, ensure connection is released after use
+	if t.service.TransportType == types.MCPTransportStdio {
+		defer func() {
+			if err := client.Disconnect(); err != nil {
+				logger.Warnf(ctx, "[Tool][MCPResource] Failed to disconnect stdio MCP client: %v", err)
+			}
+		}()
+	}
+
+	result, err := client.ReadResource(ctx, input.URI)
+	if err != nil {
+		logger.Errorf(ctx, "[Tool][MCPResource] Read resource failed: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to read resource: %v", err),
+		}, nil
+	}
+
+	subscribed := false
+	if t.watcher != nil && (input.Subscribe == nil || *input.Subscribe) {
+		if err := t.mcpManager.WatchResource(ctx, t.watcher, t.service, input.URI); err != nil {
+			logger.Debugf(ctx, "[Tool][MCPResource] Resource %s not subscribed: %v", input.URI, err)
+		} else {
+			subscribed = true
+		}
+	}
+
+	output := formatResourceContents(result.Contents)
+	return &types.ToolResult{
+		Success: true,
+		Output:  output,
+		Data: map[string]interface{}{
+			"uri":        input.URI,
+			"service":    t.service.Name,
+			"contents":   len(result.Contents),
+			"subscribed": subscribed,
+		},
+	}, nil
+}
+
+// formatResourceContents converts resource contents into text for the LLM
+func formatResourceContents(contents []mcp.ResourceContent) string {
+	if len(contents) == 0 {
+		return "Resource is empty"
+	}
+
+	var builder strings.Builder
+	for i, content := range contents {
+		if i > 0 {
+			builder.WriteString("\n\n")
+		}
+		if len(contents) > 1 {
+			builder.WriteString(fmt.Sprintf("--- %s ---\n", content.URI))
+		}
+		if content.Text != "" {
+			builder.WriteString(content.Text)
+		} else if content.Blob != "" {
+			mimeType := content.MimeType
+			if mimeType == "" {
+				mimeType = "application/octet-stream"
+			}
+			builder.WriteString(fmt.Sprintf("[Binary content: %s, %d bytes base64]", mimeType, len(content.Blob)))
+		}
+	}
+
+	output := builder.String()
+	if runes := []rune(output); len(runes) > mcpResourceMaxChars {
+		output = string(runes[:mcpResourceMaxChars]) +
+			fmt.Sprintf("\n\n[... truncated, %d characters in total]", len(runes))
+	}
+	return output
+}
@@ -188,11 +188,14 @@ func sanitizeName(name string) string {
 }
 
 // RegisterMCPTools registers MCP tools from given services
+// Services declaring the resources capability also get a read_resource tool,
+// resources read through it are subscribed with the watcher (optional)
 func RegisterMCPTools(
 	ctx context.Context,
 	registry *ToolRegistry,
 	services []*types.MCPService,
 	mcpManager *mcp.MCPManager,
+	watcher *mcp.ResourceWatcher,
 ) error {
 	if len(services) == 0 {
 		return nil
@@ -249,6 +252,19 @@ func RegisterMCPTools(
 			registry.RegisterTool(tool)
 			logger.GetLogger(ctx).Infof("Registered MCP tool: %s from service: %s", tool.Name(), service.Name)
 		}
+
+		// Register resource read tool if the service exposes resources
+		if client.GetServerCapabilities().Resources != nil {
+			listCtx, cancel := context.WithTimeout(ctx, listToolsTimeout)
+			resources, err := client.ListResources(listCtx)
+			cancel()
+			if err != nil {
+				logger.GetLogger(ctx).Warnf("Failed to list resources from MCP service %s: %v", service.Name, err)
+			}
+			tool := NewMCPResourceTool(service, resources, mcpManager, watcher)
+			registry.RegisterTool(tool)
+			logger.GetLogger(ctx).Infof("Registered MCP resource tool: %s (%d resources)", tool.Name(), len(resources))
+		}
 	}
 
 	return nil
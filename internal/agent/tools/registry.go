@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/types"
 )
 
@@ -79,7 +81,13 @@ func (r *ToolRegistry) ExecuteTool(
 		}, err
 	}
 
+	start := time.Now()
 	result, execErr := tool.Execute(ctx, args)
+	status := metrics.Status(execErr)
+	if result != nil && !result.Success {
+		status = metrics.StatusError
+	}
+	metrics.ObserveToolCall(name, status, time.Since(start))
 	fields := map[string]interface{}{
 		"tool": name,
 		"args": args,
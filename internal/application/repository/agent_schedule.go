@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// agentScheduleRepository 智能体定时任务及运行记录仓库实现
+type agentScheduleRepository struct {
+	db *gorm.DB
+}
+
+// NewAgentScheduleRepository 创建智能体定时任务仓库
+func NewAgentScheduleRepository(db *gorm.DB) interfaces.AgentScheduleRepository {
+	return &agentScheduleRepository{db: db}
+}
+
+// CreateSchedule 创建定时任务
+func (r *agentScheduleRepository) CreateSchedule(ctx context.Context, schedule *types.AgentSchedule) error {
+	return r.db.WithContext(ctx).Create(schedule).Error
+}
+
+// GetSchedule 根据ID获取定时任务
+func (r *agentScheduleRepository) GetSchedule(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.AgentSchedule, error) {
+	var schedule types.AgentSchedule
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ListSchedules 获取租户的定时任务列表
+func (r *agentScheduleRepository) ListSchedules(ctx context.Context, tenantID uint64) ([]*types.AgentSchedule, error) {
+	var schedules []*types.AgentSchedule
+	if err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// ListEventSchedules 获取租户启用且配置了触发事件的定时任务
+func (r *agentScheduleRepository) ListEventSchedules(
+	ctx context.Context, tenantID uint64,
+) ([]*types.AgentSchedule, error) {
+	var schedules []*types.AgentSchedule
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND enabled = ? AND jsonb_array_length(trigger_events) > 0", tenantID, true).
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// ListDueSchedules 获取所有租户中已到运行时间的定时任务
+func (r *agentScheduleRepository) ListDueSchedules(
+	ctx context.Context, now time.Time, limit int,
+) ([]*types.AgentSchedule, error) {
+	var schedules []*types.AgentSchedule
+	if err := r.db.WithContext(ctx).
+		Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").Limit(limit).
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// UpdateSchedule 更新定时任务
+func (r *agentScheduleRepository) UpdateSchedule(ctx context.Context, schedule *types.AgentSchedule) error {
+	return r.db.WithContext(ctx).Model(&types.AgentSchedule{}).
+		Where("id = ? AND tenant_id = ?", schedule.ID, schedule.TenantID).
+		Select("agent_id", "name", "description", "prompt", "cron", "timezone", "trigger_events",
+			"trigger_knowledge_base_ids", "output_knowledge_base_id", "notify_webhook", "enabled",
+			"next_run_at", "updated_at").
+		Updates(schedule).Error
+}
+
+// ClaimCronRun 以读取到的下一次运行时间为条件推进运行时间，只有一个 worker 能认领同一次运行
+func (r *agentScheduleRepository) ClaimCronRun(
+	ctx context.Context, schedule *types.AgentSchedule, nextRunAt *time.Time,
+) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&types.AgentSchedule{}).
+		Where("id = ? AND tenant_id = ? AND next_run_at = ?", schedule.ID, schedule.TenantID, schedule.NextRunAt).
+		Updates(map[string]interface{}{"next_run_at": nextRunAt, "last_run_at": now})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateSessionID 记录保存运行结果的会话
+func (r *agentScheduleRepository) UpdateSessionID(ctx context.Context, tenantID uint64, id, sessionID string) error {
+	return r.db.WithContext(ctx).Model(&types.AgentSchedule{}).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Update("session_id", sessionID).Error
+}
+
+// DeleteSchedule 删除定时任务
+func (r *agentScheduleRepository) DeleteSchedule(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).
+		Delete(&types.AgentSchedule{}).Error
+}
+
+// CreateRun 创建运行记录
+func (r *agentScheduleRepository) CreateRun(ctx context.Context, run *types.AgentScheduleRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// GetRun 根据ID获取运行记录
+func (r *agentScheduleRepository) GetRun(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.AgentScheduleRun, error) {
+	var run types.AgentScheduleRun
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetPendingEventRun 获取定时任务尚未开始的事件触发运行
+func (r *agentScheduleRepository) GetPendingEventRun(
+	ctx context.Context, tenantID uint64, scheduleID string,
+) (*types.AgentScheduleRun, error) {
+	var run types.AgentScheduleRun
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND schedule_id = ? AND trigger = ? AND status = ?",
+			tenantID, scheduleID, types.AgentRunTriggerEvent, types.AgentRunPending).
+		Order("created_at DESC").First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// AppendRunEvents 向尚未开始的运行追加触发事件
+// 事件在 SQL 中追加到已有事件之后，并发触发同一运行时不会相互覆盖
+func (r *agentScheduleRepository) AppendRunEvents(
+	ctx context.Context, run *types.AgentScheduleRun, events []types.AgentRunEvent,
+) (bool, error) {
+	data, err := json.Marshal(events)
+	if err != nil {
+		return false, err
+	}
+	var updated types.AgentScheduleRun
+	result := r.db.WithContext(ctx).Model(&updated).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "events"}}}).
+		Where("id = ? AND tenant_id = ? AND status = ?", run.ID, run.TenantID, types.AgentRunPending).
+		Updates(map[string]interface{}{
+			"events":     gorm.Expr("COALESCE(events, '[]'::jsonb) || ?::jsonb", string(data)),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		run.Events = updated.Events
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateRun 更新运行状态及结果
+func (r *agentScheduleRepository) UpdateRun(ctx context.Context, run *types.AgentScheduleRun) error {
+	return r.db.WithContext(ctx).Model(&types.AgentScheduleRun{}).
+		Where("id = ? AND tenant_id = ?", run.ID, run.TenantID).
+		Select("status", "session_id", "message_id", "answer", "output_knowledge_id", "usage", "error",
+			"started_at", "finished_at", "updated_at").
+		Updates(run).Error
+}
+
+// IsRunOutput 判断知识是否由定时任务运行写回
+func (r *agentScheduleRepository) IsRunOutput(ctx context.Context, tenantID uint64, knowledgeID string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&types.AgentScheduleRun{}).
+		Where("tenant_id = ? AND output_knowledge_id = ?", tenantID, knowledgeID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ListRuns 分页获取定时任务的运行记录
+func (r *agentScheduleRepository) ListRuns(
+	ctx context.Context, tenantID uint64, scheduleID string, page *types.Pagination,
+) ([]*types.AgentScheduleRun, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.AgentScheduleRun{}).
+		Where("tenant_id = ? AND schedule_id = ?", tenantID, scheduleID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []*types.AgentScheduleRun
+	if err := query.Order("created_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Find(&runs).Error; err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
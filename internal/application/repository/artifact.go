@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// artifactRepository 消息产物仓库实现
+type artifactRepository struct {
+	db *gorm.DB
+}
+
+// NewArtifactRepository 创建消息产物仓库
+func NewArtifactRepository(db *gorm.DB) interfaces.ArtifactRepository {
+	return &artifactRepository{db: db}
+}
+
+// CreateArtifact 保存产物
+func (r *artifactRepository) CreateArtifact(ctx context.Context, artifact *types.MessageArtifact) error {
+	return r.db.WithContext(ctx).Create(artifact).Error
+}
+
+// GetArtifact 根据ID获取租户的产物，包含文件内容
+func (r *artifactRepository) GetArtifact(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.MessageArtifact, error) {
+	var artifact types.MessageArtifact
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&artifact).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &artifact, nil
+}
+
+// ListMessageArtifacts 获取消息的产物，按创建时间排序，不读取文件内容
+func (r *artifactRepository) ListMessageArtifacts(
+	ctx context.Context, tenantID uint64, messageID string,
+) ([]*types.MessageArtifact, error) {
+	var artifacts []*types.MessageArtifact
+	if err := r.db.WithContext(ctx).Omit("content").
+		Where("tenant_id = ? AND message_id = ?", tenantID, messageID).
+		Order("created_at ASC").Find(&artifacts).Error; err != nil {
+		return nil, err
+	}
+	return artifacts, nil
+}
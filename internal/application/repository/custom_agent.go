@@ -12,6 +12,9 @@ import (
 // ErrCustomAgentNotFound is returned when a custom agent is not found
 var ErrCustomAgentNotFound = errors.New("custom agent not found")
 
+// ErrCustomAgentVersionNotFound is returned when a version of a custom agent is not found
+var ErrCustomAgentVersionNotFound = errors.New("custom agent version not found")
+
 // customAgentRepository implements the CustomAgentRepository interface
 type customAgentRepository struct {
 	db *gorm.DB
@@ -72,3 +75,57 @@ func (r *customAgentRepository) UpdateAgent(ctx context.Context, agent *types.Cu
 func (r *customAgentRepository) DeleteAgent(ctx context.Context, id string, tenantID uint64) error {
 	return r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).Delete(&types.CustomAgent{}).Error
 }
+
+// CreateAgentWithVersion creates an agent together with its first version
+func (r *customAgentRepository) CreateAgentWithVersion(
+	ctx context.Context, agent *types.CustomAgent, version *types.CustomAgentVersion,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(agent).Error; err != nil {
+			return err
+		}
+		return tx.Create(version).Error
+	})
+}
+
+// UpdateAgentWithVersion updates an agent and records the new version of its settings
+func (r *customAgentRepository) UpdateAgentWithVersion(
+	ctx context.Context, agent *types.CustomAgent, version *types.CustomAgentVersion,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(agent).Error; err != nil {
+			return err
+		}
+		return tx.Create(version).Error
+	})
+}
+
+// ListAgentVersions lists the versions of an agent, newest first
+func (r *customAgentRepository) ListAgentVersions(
+	ctx context.Context, agentID string, tenantID uint64,
+) ([]*types.CustomAgentVersion, error) {
+	var versions []*types.CustomAgentVersion
+	if err := r.db.WithContext(ctx).
+		Where("agent_id = ? AND tenant_id = ?", agentID, tenantID).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetAgentVersion gets a version of an agent
+func (r *customAgentRepository) GetAgentVersion(
+	ctx context.Context, agentID string, tenantID uint64, version int,
+) (*types.CustomAgentVersion, error) {
+	var v types.CustomAgentVersion
+	if err := r.db.WithContext(ctx).
+		Where("agent_id = ? AND tenant_id = ? AND version = ?", agentID, tenantID, version).
+		First(&v).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomAgentVersionNotFound
+		}
+		return nil, err
+	}
+	return &v, nil
+}
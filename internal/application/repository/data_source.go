@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// dataSourceRepository 外部数据源仓库实现
+type dataSourceRepository struct {
+	db *gorm.DB
+}
+
+// NewDataSourceRepository 创建外部数据源仓库
+func NewDataSourceRepository(db *gorm.DB) interfaces.DataSourceRepository {
+	return &dataSourceRepository{db: db}
+}
+
+// CreateDataSource 创建数据源
+func (r *dataSourceRepository) CreateDataSource(ctx context.Context, dataSource *types.DataSource) error {
+	return r.db.WithContext(ctx).Create(dataSource).Error
+}
+
+// GetDataSource 根据ID获取数据源
+func (r *dataSourceRepository) GetDataSource(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.DataSource, error) {
+	var dataSource types.DataSource
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&dataSource).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &dataSource, nil
+}
+
+// ListDataSources 获取租户的数据源列表
+func (r *dataSourceRepository) ListDataSources(ctx context.Context, tenantID uint64) ([]*types.DataSource, error) {
+	var dataSources []*types.DataSource
+	if err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").Find(&dataSources).Error; err != nil {
+		return nil, err
+	}
+	return dataSources, nil
+}
+
+// ListDataSourcesByIDs 根据ID列表获取租户的数据源
+func (r *dataSourceRepository) ListDataSourcesByIDs(
+	ctx context.Context, tenantID uint64, ids []string,
+) ([]*types.DataSource, error) {
+	var dataSources []*types.DataSource
+	if len(ids) == 0 {
+		return dataSources, nil
+	}
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND id IN ?", tenantID, ids).
+		Find(&dataSources).Error; err != nil {
+		return nil, err
+	}
+	return dataSources, nil
+}
+
+// UpdateDataSource 更新数据源
+func (r *dataSourceRepository) UpdateDataSource(ctx context.Context, dataSource *types.DataSource) error {
+	return r.db.WithContext(ctx).Model(&types.DataSource{}).
+		Where("id = ? AND tenant_id = ?", dataSource.ID, dataSource.TenantID).
+		Select("name", "description", "type", "connection", "allowed_tables", "row_limit", "enabled", "updated_at").
+		Updates(dataSource).Error
+}
+
+// DeleteDataSource 删除数据源
+func (r *dataSourceRepository) DeleteDataSource(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).
+		Delete(&types.DataSource{}).Error
+}
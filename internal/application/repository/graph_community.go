@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// graphCommunityRepository 知识图谱社区仓库实现
+type graphCommunityRepository struct {
+	db *gorm.DB
+}
+
+// NewGraphCommunityRepository 创建知识图谱社区仓库
+func NewGraphCommunityRepository(db *gorm.DB) interfaces.GraphCommunityRepository {
+	return &graphCommunityRepository{db: db}
+}
+
+// ReplaceCommunities 在一个事务中删除知识库原有社区并写入新社区
+func (r *graphCommunityRepository) ReplaceCommunities(
+	ctx context.Context, kbID string, communities []*types.GraphCommunity,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("knowledge_base_id = ?", kbID).Delete(&types.GraphCommunity{}).Error; err != nil {
+			return err
+		}
+		if len(communities) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(communities, 100).Error
+	})
+}
+
+// ListCommunities 获取知识库的全部社区，实体多的在前
+func (r *graphCommunityRepository) ListCommunities(
+	ctx context.Context, kbIDs []string,
+) ([]*types.GraphCommunity, error) {
+	var communities []*types.GraphCommunity
+	if len(kbIDs) == 0 {
+		return communities, nil
+	}
+	if err := r.db.WithContext(ctx).Where("knowledge_base_id IN ?", kbIDs).
+		Order("size DESC, relation_count DESC, id").Find(&communities).Error; err != nil {
+		return nil, err
+	}
+	return communities, nil
+}
+
+// DeleteCommunities 删除知识库的全部社区
+func (r *graphCommunityRepository) DeleteCommunities(ctx context.Context, kbID string) error {
+	return r.db.WithContext(ctx).Where("knowledge_base_id = ?", kbID).Delete(&types.GraphCommunity{}).Error
+}
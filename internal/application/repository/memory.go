@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// userMemoryRepository 用户长期记忆仓库实现
+type userMemoryRepository struct {
+	db *gorm.DB
+}
+
+// NewUserMemoryRepository 创建用户长期记忆仓库
+func NewUserMemoryRepository(db *gorm.DB) interfaces.UserMemoryRepository {
+	return &userMemoryRepository{db: db}
+}
+
+// CreateMemory 创建记忆
+func (r *userMemoryRepository) CreateMemory(ctx context.Context, memory *types.UserMemory) error {
+	return r.db.WithContext(ctx).Create(memory).Error
+}
+
+// GetMemory 根据ID获取用户的记忆
+func (r *userMemoryRepository) GetMemory(
+	ctx context.Context, tenantID uint64, userID string, id string,
+) (*types.UserMemory, error) {
+	var memory types.UserMemory
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ? AND user_id = ?", id, tenantID, userID).
+		First(&memory).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &memory, nil
+}
+
+// ListMemories 获取用户的全部记忆，最近更新的在前
+func (r *userMemoryRepository) ListMemories(
+	ctx context.Context, tenantID uint64, userID string,
+) ([]*types.UserMemory, error) {
+	var memories []*types.UserMemory
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Order("updated_at DESC").Find(&memories).Error; err != nil {
+		return nil, err
+	}
+	return memories, nil
+}
+
+// UpdateMemory 更新记忆内容及向量
+func (r *userMemoryRepository) UpdateMemory(ctx context.Context, memory *types.UserMemory) error {
+	return r.db.WithContext(ctx).Model(&types.UserMemory{}).
+		Where("id = ? AND tenant_id = ? AND user_id = ?", memory.ID, memory.TenantID, memory.UserID).
+		Select("content", "source_session_id", "embedding", "embedding_model_id", "updated_at").
+		Updates(memory).Error
+}
+
+// UpdateEmbedding 更新记忆向量，不改变更新时间
+func (r *userMemoryRepository) UpdateEmbedding(ctx context.Context, memory *types.UserMemory) error {
+	return r.db.WithContext(ctx).Model(&types.UserMemory{}).
+		Where("id = ? AND tenant_id = ? AND user_id = ?", memory.ID, memory.TenantID, memory.UserID).
+		UpdateColumns(map[string]interface{}{
+			"embedding":          memory.Embedding,
+			"embedding_model_id": memory.EmbeddingModelID,
+		}).Error
+}
+
+// DeleteMemories 删除用户的指定记忆
+func (r *userMemoryRepository) DeleteMemories(
+	ctx context.Context, tenantID uint64, userID string, ids []string,
+) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Where("id IN ? AND tenant_id = ? AND user_id = ?", ids, tenantID, userID).
+		Delete(&types.UserMemory{}).Error
+}
+
+// DeleteAllMemories 删除用户的全部记忆
+func (r *userMemoryRepository) DeleteAllMemories(ctx context.Context, tenantID uint64, userID string) error {
+	return r.db.WithContext(ctx).Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Delete(&types.UserMemory{}).Error
+}
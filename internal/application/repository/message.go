@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"slices"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -23,16 +25,52 @@ func NewMessageRepository(db *gorm.DB) interfaces.MessageRepository {
 	}
 }
 
-// CreateMessage creates a new message
+// activeBranchSQL selects the IDs of the messages on the active branch of a session,
+// walking from the session's active message up through the parent links
+const activeBranchSQL = `WITH RECURSIVE branch AS (
+	SELECT m.id, m.parent_id FROM messages m
+	JOIN sessions s ON s.active_message_id = m.id
+	WHERE s.id = ? AND m.deleted_at IS NULL
+	UNION ALL
+	SELECT m.id, m.parent_id FROM messages m
+	JOIN branch b ON m.id = b.parent_id
+	WHERE m.deleted_at IS NULL
+) SELECT id FROM branch`
+
+// latestDescendantSQL selects the most recently created message in the subtree of a message;
+// a message created later than all others in the subtree has no children, so it is a leaf
+const latestDescendantSQL = `WITH RECURSIVE subtree AS (
+	SELECT id, created_at FROM messages
+	WHERE id = ? AND session_id = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT m.id, m.created_at FROM messages m
+	JOIN subtree t ON m.parent_id = t.id
+	WHERE m.deleted_at IS NULL
+) SELECT id FROM subtree ORDER BY created_at DESC LIMIT 1`
+
+// CreateMessage creates a new message and makes it the last message of the session's active branch
 func (r *messageRepository) CreateMessage(
 	ctx context.Context, message *types.Message,
 ) (*types.Message, error) {
-	if err := r.db.WithContext(ctx).Create(message).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return setActiveMessage(tx, message.SessionID, message.ID)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return message, nil
 }
 
+// setActiveMessage updates the active message of a session
+// The column is written directly since Session.ActiveMessageID is read-only for GORM,
+// so that saving a stale session never moves the active branch back
+func setActiveMessage(tx *gorm.DB, sessionID string, messageID string) error {
+	return tx.Exec("UPDATE sessions SET active_message_id = ? WHERE id = ?", messageID, sessionID).Error
+}
+
 // GetMessage retrieves a message
 func (r *messageRepository) GetMessage(
 	ctx context.Context, sessionID string, messageID string,
@@ -64,7 +102,7 @@ func (r *messageRepository) GetRecentMessagesBySession(
 ) ([]*types.Message, error) {
 	var messages []*types.Message
 	if err := r.db.WithContext(ctx).Where(
-		"session_id = ?", sessionID,
+		"session_id = ? AND id IN (?)", sessionID, r.db.Raw(activeBranchSQL, sessionID),
 	).Order("created_at DESC").Limit(limit).Find(&messages).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -90,7 +128,7 @@ func (r *messageRepository) GetMessagesBySessionBeforeTime(
 ) ([]*types.Message, error) {
 	var messages []*types.Message
 	if err := r.db.WithContext(ctx).Where(
-		"session_id = ? AND created_at < ?", sessionID, beforeTime,
+		"session_id = ? AND created_at < ? AND id IN (?)", sessionID, beforeTime, r.db.Raw(activeBranchSQL, sessionID),
 	).Order("created_at DESC").Limit(limit).Find(&messages).Error; err != nil {
 		return nil, err
 	}
@@ -114,11 +152,74 @@ func (r *messageRepository) UpdateMessage(ctx context.Context, message *types.Me
 	).Updates(message).Error
 }
 
-// DeleteMessage deletes a message
+// DeleteMessage deletes a message, attaching its children to its parent
+// If the message ends the active branch, the active branch moves to its parent
 func (r *messageRepository) DeleteMessage(ctx context.Context, sessionID string, messageID string) error {
-	return r.db.WithContext(ctx).Where(
-		"id = ? AND session_id = ?", messageID, sessionID,
-	).Delete(&types.Message{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var message types.Message
+		if err := tx.Where("id = ? AND session_id = ?", messageID, sessionID).First(&message).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Model(&types.Message{}).Where(
+			"session_id = ? AND parent_id = ?", sessionID, messageID,
+		).UpdateColumn("parent_id", message.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(
+			"UPDATE sessions SET active_message_id = ? WHERE id = ? AND active_message_id = ?",
+			message.ParentID, sessionID, messageID,
+		).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&message).Error
+	})
+}
+
+// GetChildMessageIDs retrieves the IDs of the children of the given parent messages in creation order
+func (r *messageRepository) GetChildMessageIDs(
+	ctx context.Context, sessionID string, parentIDs []string,
+) (map[string][]string, error) {
+	var rows []struct {
+		ID       string
+		ParentID string
+	}
+	if err := r.db.WithContext(ctx).Model(&types.Message{}).Select("id, parent_id").Where(
+		"session_id = ? AND parent_id IN ?", sessionID, parentIDs,
+	).Order("created_at ASC").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	children := make(map[string][]string, len(parentIDs))
+	for _, row := range rows {
+		children[row.ParentID] = append(children[row.ParentID], row.ID)
+	}
+	return children, nil
+}
+
+// ActivateMessage makes the most recent path through a message the active branch of its session
+func (r *messageRepository) ActivateMessage(
+	ctx context.Context, sessionID string, messageID string,
+) (*types.Message, error) {
+	var leaf types.Message
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var leafID string
+		if err := tx.Raw(latestDescendantSQL, messageID, sessionID).Scan(&leafID).Error; err != nil {
+			return err
+		}
+		if leafID == "" {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("id = ?", leafID).First(&leaf).Error; err != nil {
+			return err
+		}
+		return setActiveMessage(tx, sessionID, leafID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &leaf, nil
 }
 
 // GetFirstMessageOfUser retrieves the first message from a user in a session
@@ -151,3 +252,108 @@ func (r *messageRepository) GetMessageByRequestID(
 
 	return &message, nil
 }
+
+// messageKnowledgeBaseSQL matches messages whose turn (the question and its answers share a request ID)
+// cited a document of a knowledge base or @mentioned the knowledge base
+const messageKnowledgeBaseSQL = `EXISTS (
+	SELECT 1 FROM messages t
+	WHERE t.session_id = m.session_id AND t.deleted_at IS NULL
+	AND (t.id = m.id OR (m.request_id <> '' AND t.request_id = m.request_id))
+	AND (
+		EXISTS (
+			SELECT 1 FROM jsonb_array_elements(CASE WHEN jsonb_typeof(t.knowledge_references::jsonb) = 'array'
+				THEN t.knowledge_references::jsonb ELSE '[]'::jsonb END) r
+			JOIN knowledges k ON k.id = r->>'knowledge_id'
+			WHERE k.knowledge_base_id = ?
+		)
+		OR COALESCE(t.mentioned_items::jsonb, '[]'::jsonb) @> ?::jsonb
+	)
+)`
+
+// SearchMessages finds the messages of a tenant's sessions matching the search terms.
+// A message matches when every term appears in its content or in its session title, and at
+// least one in its content; a session whose title alone matches is represented by its first question.
+func (r *messageRepository) SearchMessages(
+	ctx context.Context, tenantID uint64, params *types.MessageSearchParams, page *types.Pagination,
+) ([]*types.MessageSearchHit, int64, error) {
+	query := r.db.WithContext(ctx).Table("messages m").
+		Joins("JOIN sessions s ON s.id = m.session_id AND s.deleted_at IS NULL").
+		Where("s.tenant_id = ? AND m.deleted_at IS NULL", tenantID)
+
+	patterns := make([]interface{}, 0, len(params.Terms))
+	for _, term := range params.Terms {
+		patterns = append(patterns, "%"+escapeLikePattern(term)+"%")
+	}
+	var eachTerm, allInContent, anyInContent, allInTitle []string
+	var eachTermArgs []interface{}
+	for _, pattern := range patterns {
+		eachTerm = append(eachTerm, "(m.content ILIKE ? OR s.title ILIKE ?)")
+		eachTermArgs = append(eachTermArgs, pattern, pattern)
+		allInContent = append(allInContent, "m.content ILIKE ?")
+		anyInContent = append(anyInContent, "m.content ILIKE ?")
+		allInTitle = append(allInTitle, "s.title ILIKE ?")
+	}
+	if len(patterns) > 0 {
+		query = query.Where(strings.Join(eachTerm, " AND "), eachTermArgs...).
+			Where("(("+strings.Join(anyInContent, " OR ")+") OR (m.parent_id = '' AND "+
+				strings.Join(allInTitle, " AND ")+"))", append(slices.Clone(patterns), patterns...)...)
+	}
+
+	if params.Role != "" {
+		query = query.Where("m.role = ?", params.Role)
+	}
+	if params.AgentID != "" {
+		query = query.Where("m.agent_id = ?", params.AgentID)
+	}
+	if params.StartTime != nil {
+		query = query.Where("m.created_at >= ?", *params.StartTime)
+	}
+	if params.EndTime != nil {
+		query = query.Where("m.created_at <= ?", *params.EndTime)
+	}
+	if params.KnowledgeBaseID != "" {
+		// 只按 id 与 type 匹配，忽略提及项中的其他字段
+		mentioned, err := json.Marshal([]map[string]string{{"id": params.KnowledgeBaseID, "type": "kb"}})
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where(messageKnowledgeBaseSQL, params.KnowledgeBaseID, string(mentioned))
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	// 内容包含全部关键词的消息排在前面，其余按时间倒序
+	rank := "0"
+	if len(patterns) > 0 {
+		rank = "CASE WHEN " + strings.Join(allInContent, " AND ") + " THEN 0 ELSE 1 END"
+	}
+	var hits []*types.MessageSearchHit
+	if err := query.Select(
+		"m.id AS message_id, m.session_id, s.title AS session_title, m.request_id, m.role, m.agent_id, "+
+			"m.created_at, m.content, "+rank+" AS content_rank", patterns...,
+	).Order("content_rank ASC, m.created_at DESC").
+		Offset(page.Offset()).Limit(page.Limit()).Scan(&hits).Error; err != nil {
+		return nil, 0, err
+	}
+	return hits, total, nil
+}
+
+// GetActiveBranchMessageIDs retrieves the IDs of the messages on the active branch of a session
+func (r *messageRepository) GetActiveBranchMessageIDs(ctx context.Context, sessionID string) ([]string, error) {
+	var ids []string
+	if err := r.db.WithContext(ctx).Raw(activeBranchSQL, sessionID).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// escapeLikePattern escapes the LIKE wildcards of a search term so it is matched literally
+func escapeLikePattern(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
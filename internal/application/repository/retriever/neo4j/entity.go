@@ -0,0 +1,315 @@
+package neo4j
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// entityReturn groups the nodes of an entity, to be preceded by "WITH name, nodes"
+const entityReturn = `
+	RETURN name, [x IN nodes | {
+		kg: x.kg, chunks: x.chunks, attributes: x.attributes, degree: size([(x)--() | 1])
+	}] AS nodes
+`
+
+// entityPage is a page of entities and the total number of matching entities
+type entityPage struct {
+	entities []*types.GraphEntity
+	total    int64
+}
+
+// ListEntities lists the entities of a knowledge base whose name contains the keyword
+func (n *Neo4jRepository) ListEntities(ctx context.Context, knowledgeBaseID, keyword string,
+	offset, limit int,
+) ([]*types.GraphEntity, int64, error) {
+	if n.driver == nil {
+		return nil, 0, types.ErrGraphStoreDisabled
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	labelExpr := n.Label(types.NameSpace{KnowledgeBase: knowledgeBaseID})
+	match := `MATCH (n:` + labelExpr + `) WHERE $keyword = '' OR toLower(n.name) CONTAINS toLower($keyword)`
+	params := map[string]interface{}{"keyword": keyword, "offset": offset, "limit": limit}
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		countResult, err := tx.Run(ctx, match+` RETURN count(DISTINCT n.name) AS total`, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count entities: %v", err)
+		}
+		record, err := countResult.Single(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count entities: %v", err)
+		}
+		total, _ := record.Values[0].(int64)
+
+		entities, err := n.runEntityQuery(ctx, tx, match+`
+			WITH n.name AS name, collect(n) AS nodes
+			ORDER BY name SKIP $offset LIMIT $limit
+		`+entityReturn, params)
+		if err != nil {
+			return nil, err
+		}
+		return &entityPage{entities: entities, total: total}, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "list entities failed: %v", err)
+		return nil, 0, err
+	}
+	page := result.(*entityPage)
+	return page.entities, page.total, nil
+}
+
+// GetEntity gets an entity of a knowledge base by name
+func (n *Neo4jRepository) GetEntity(ctx context.Context, knowledgeBaseID, name string) (*types.GraphEntity, error) {
+	if n.driver == nil {
+		return nil, types.ErrGraphStoreDisabled
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	labelExpr := n.Label(types.NameSpace{KnowledgeBase: knowledgeBaseID})
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return n.runEntityQuery(ctx, tx, `
+			MATCH (n:`+labelExpr+` {name: $name})
+			WITH n.name AS name, collect(n) AS nodes
+		`+entityReturn, map[string]interface{}{"name": name})
+	})
+	if err != nil {
+		logger.Errorf(ctx, "get entity failed: %v", err)
+		return nil, err
+	}
+	entities := result.([]*types.GraphEntity)
+	if len(entities) == 0 {
+		return nil, nil
+	}
+	return entities[0], nil
+}
+
+// runEntityQuery runs a query ending with entityReturn and collects the entities
+func (n *Neo4jRepository) runEntityQuery(ctx context.Context, tx neo4j.ManagedTransaction,
+	query string, params map[string]interface{},
+) ([]*types.GraphEntity, error) {
+	result, err := tx.Run(ctx, query, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entities: %v", err)
+	}
+	entities := make([]*types.GraphEntity, 0)
+	for result.Next(ctx) {
+		record := result.Record()
+		name, _ := record.Values[0].(string)
+		nodes, _ := record.Values[1].([]interface{})
+
+		merged := &types.GraphNode{Name: name}
+		entity := &types.GraphEntity{Name: name, KnowledgeIDs: []string{}}
+		for _, item := range nodes {
+			props, _ := item.(map[string]interface{})
+			types.MergeGraphNode(merged, &types.GraphNode{
+				Chunks:     propStrings(props["chunks"]),
+				Attributes: propStrings(props["attributes"]),
+			})
+			if kg, _ := props["kg"].(string); kg != "" && !slices.Contains(entity.KnowledgeIDs, kg) {
+				entity.KnowledgeIDs = append(entity.KnowledgeIDs, kg)
+			}
+			degree, _ := props["degree"].(int64)
+			entity.Degree += int(degree)
+		}
+		entity.Chunks = append([]string{}, merged.Chunks...)
+		entity.Attributes = append([]string{}, merged.Attributes...)
+		entities = append(entities, entity)
+	}
+	return entities, result.Err()
+}
+
+// ExpandEntities returns the subgraph within depth hops of the named entities
+func (n *Neo4jRepository) ExpandEntities(ctx context.Context, knowledgeBaseID string, names []string,
+	depth, limit int,
+) (*types.GraphData, error) {
+	if n.driver == nil {
+		return nil, types.ErrGraphStoreDisabled
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	labelExpr := n.Label(types.NameSpace{KnowledgeBase: knowledgeBaseID})
+	// 同名实体在不同文档中是不同的节点，因此按名称逐跳扩展，而不是沿节点路径遍历
+	hopQuery := `
+		MATCH (n:` + labelExpr + `)-[r]-(m:` + labelExpr + `)
+		WHERE n.name IN $names
+		WITH DISTINCT r
+		RETURN startNode(r).name AS source, endNode(r).name AS target, type(r) AS type,
+			coalesce(r.chunks, [c IN coalesce(startNode(r).chunks, []) WHERE c IN coalesce(endNode(r).chunks, [])]) AS chunks
+		LIMIT $limit
+	`
+	nodeQuery := `
+		MATCH (n:` + labelExpr + `)
+		WHERE n.name IN $names
+		RETURN n.name AS name, n.chunks AS chunks, n.attributes AS attributes
+	`
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		seen := make([]string, 0, len(names))
+		for _, name := range names {
+			if !slices.Contains(seen, name) {
+				seen = append(seen, name)
+			}
+		}
+		frontier := seen
+		var relations []*types.GraphRelation
+		for hop := 0; hop < depth && len(frontier) > 0 && len(relations) < limit; hop++ {
+			hopResult, err := tx.Run(ctx, hopQuery, map[string]interface{}{
+				"names": frontier, "limit": limit - len(relations),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand entities: %v", err)
+			}
+			var next []string
+			for hopResult.Next(ctx) {
+				record := hopResult.Record()
+				rel := &types.GraphRelation{Chunks: propStrings(record.Values[3])}
+				rel.Node1, _ = record.Values[0].(string)
+				rel.Node2, _ = record.Values[1].(string)
+				rel.Type, _ = record.Values[2].(string)
+				relations = append(relations, rel)
+				for _, name := range []string{rel.Node1, rel.Node2} {
+					if !slices.Contains(seen, name) {
+						seen = append(seen, name)
+						next = append(next, name)
+					}
+				}
+			}
+			if err := hopResult.Err(); err != nil {
+				return nil, fmt.Errorf("failed to expand entities: %v", err)
+			}
+			relations = types.MergeGraphRelations(relations)
+			frontier = next
+		}
+
+		nodeResult, err := tx.Run(ctx, nodeQuery, map[string]interface{}{"names": seen})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get entities: %v", err)
+		}
+		nodes := make(map[string]*types.GraphNode, len(seen))
+		for nodeResult.Next(ctx) {
+			record := nodeResult.Record()
+			name, _ := record.Values[0].(string)
+			node, ok := nodes[name]
+			if !ok {
+				node = &types.GraphNode{Name: name}
+				nodes[name] = node
+			}
+			types.MergeGraphNode(node, &types.GraphNode{
+				Chunks:     propStrings(record.Values[1]),
+				Attributes: propStrings(record.Values[2]),
+			})
+		}
+		if err := nodeResult.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get entities: %v", err)
+		}
+
+		graph := &types.GraphData{Relation: relations}
+		for _, name := range seen {
+			if node, ok := nodes[name]; ok {
+				graph.Node = append(graph.Node, node)
+			}
+		}
+		return graph, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "expand entities failed: %v", err)
+		return nil, err
+	}
+	return result.(*types.GraphData), nil
+}
+
+// UpdateEntityAttributes replaces the attributes of an entity in all documents
+func (n *Neo4jRepository) UpdateEntityAttributes(ctx context.Context, knowledgeBaseID, name string,
+	attributes []string,
+) error {
+	if n.driver == nil {
+		return types.ErrGraphStoreDisabled
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	labelExpr := n.Label(types.NameSpace{KnowledgeBase: knowledgeBaseID})
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `MATCH (n:` + labelExpr + ` {name: $name}) SET n.attributes = $attributes`
+		if _, err := tx.Run(ctx, query, map[string]interface{}{"name": name, "attributes": attributes}); err != nil {
+			return nil, fmt.Errorf("failed to update entity: %v", err)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "update entity failed: %v", err)
+	}
+	return err
+}
+
+// MergeEntities merges the source entities into the target entity, document by document
+func (n *Neo4jRepository) MergeEntities(ctx context.Context, knowledgeBaseID string, sources []string,
+	target string,
+) error {
+	if n.driver == nil {
+		return types.ErrGraphStoreDisabled
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	labelExpr := n.Label(types.NameSpace{KnowledgeBase: knowledgeBaseID})
+	names := append([]string{target}, sources...)
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		// 每个文档内的同名节点合并为一个，目标实体的节点排在首位以保留其标签
+		mergeQuery := `
+			MATCH (n:` + labelExpr + `)
+			WHERE n.name IN $names
+			WITH n ORDER BY CASE WHEN n.name = $target THEN 0 ELSE 1 END
+			WITH n.kg AS kg, collect(n) AS nodes
+			WITH nodes,
+				apoc.coll.toSet(apoc.coll.flatten([x IN nodes | coalesce(x.chunks, [])])) AS chunks,
+				apoc.coll.toSet(apoc.coll.flatten([x IN nodes | coalesce(x.attributes, [])])) AS attributes
+			CALL apoc.refactor.mergeNodes(nodes, {
+				properties: {name: 'discard', kg: 'discard', ` + "`.*`" + `: 'combine'},
+				mergeRels: true
+			}) YIELD node
+			SET node.name = $target, node.chunks = chunks, node.attributes = attributes
+			RETURN count(node)
+		`
+		params := map[string]interface{}{"names": names, "target": target}
+		if _, err := tx.Run(ctx, mergeQuery, params); err != nil {
+			return nil, fmt.Errorf("failed to merge entities: %v", err)
+		}
+
+		// 合并的实体之间原有的关系成为自环，予以删除；合并后的关系去重其来源分块
+		cleanupQueries := []string{
+			`MATCH (n:` + labelExpr + ` {name: $target})-[r]->(n) DELETE r`,
+			`MATCH (n:` + labelExpr + ` {name: $target})-[r]-()
+			WHERE r.chunks IS NOT NULL
+			SET r.chunks = apoc.coll.toSet(apoc.coll.flatten([r.chunks], true))`,
+		}
+		for _, query := range cleanupQueries {
+			if _, err := tx.Run(ctx, query, params); err != nil {
+				return nil, fmt.Errorf("failed to merge entities: %v", err)
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "merge entities failed: %v", err)
+		return err
+	}
+	logger.Infof(ctx, "merged entities %v into %s", sources, target)
+	return nil
+}
+
+// propStrings converts a list property to strings, nil for a missing property
+func propStrings(value interface{}) []string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	return listI2listS(list)
+}
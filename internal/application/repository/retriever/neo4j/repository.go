@@ -89,6 +89,7 @@ func (n *Neo4jRepository) addGraph(ctx context.Context, namespace types.NameSpac
 			CALL apoc.merge.node(row.source_labels, {name: row.source, kg: row.knowledge_id}, {}, {}) YIELD node as source
 			CALL apoc.merge.node(row.target_labels, {name: row.target, kg: row.knowledge_id}, {}, {}) YIELD node as target
 			CALL apoc.merge.relationship(source, row.type, {}, row.attributes, target) YIELD rel
+			SET rel.chunks = apoc.coll.union(coalesce(rel.chunks, []), coalesce(row.chunks, []))
 			RETURN distinct 'done'
 		`
 		relData := []map[string]interface{}{}
@@ -98,6 +99,7 @@ func (n *Neo4jRepository) addGraph(ctx context.Context, namespace types.NameSpac
 				"target":        rel.Node2,
 				"knowledge_id":  namespace.Knowledge,
 				"type":          rel.Type,
+				"chunks":        rel.Chunks,
 				"source_labels": n.Labels(namespace),
 				"target_labels": n.Labels(namespace),
 			})
@@ -0,0 +1,579 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// searchNodeLimit bounds the entity names matched by a node search
+	searchNodeLimit = 100
+	// searchNodeRelationLimit bounds the relations returned by a node search
+	searchNodeRelationLimit = 500
+)
+
+// jsonbUnion appends the elements of the second JSONB array missing from the first
+const jsonbUnion = `%[1]s || COALESCE((SELECT jsonb_agg(e) FROM jsonb_array_elements(%[2]s) e ` +
+	`WHERE NOT %[1]s @> jsonb_build_array(e)), '[]'::jsonb)`
+
+// expandQuery finds the entities within depth hops of the start names by a recursive query,
+// and returns the relations of the entities reached in fewer than depth hops, nearest first
+const expandQuery = `
+	WITH RECURSIVE reached(name, depth) AS (
+		SELECT unnest(?::text[]), 0
+		UNION
+		SELECT CASE WHEN e.source = r.name THEN e.target ELSE e.source END, r.depth + 1
+		FROM reached r
+		JOIN graph_edges e ON e.knowledge_base_id = ? AND (e.source = r.name OR e.target = r.name)
+		WHERE r.depth < ?
+	), nearest AS (
+		SELECT name, MIN(depth) AS depth FROM reached GROUP BY name
+	)
+	SELECT e.source, e.target, e.type, e.chunks
+	FROM graph_edges e
+	LEFT JOIN nearest s ON s.name = e.source
+	LEFT JOIN nearest t ON t.name = e.target
+	WHERE e.knowledge_base_id = ? AND LEAST(s.depth, t.depth) < ?
+	ORDER BY LEAST(s.depth, t.depth), e.id
+	LIMIT ?
+`
+
+// graphNode is an entity extracted from a document
+type graphNode struct {
+	ID              int64             `gorm:"column:id;primaryKey"`
+	KnowledgeBaseID string            `gorm:"column:knowledge_base_id"`
+	KnowledgeID     string            `gorm:"column:knowledge_id"`
+	Name            string            `gorm:"column:name"`
+	Chunks          types.StringArray `gorm:"column:chunks;type:jsonb"`
+	Attributes      types.StringArray `gorm:"column:attributes;type:jsonb"`
+	CreatedAt       time.Time         `gorm:"column:created_at"`
+	UpdatedAt       time.Time         `gorm:"column:updated_at"`
+}
+
+// TableName specifies the database table name for graphNode
+func (graphNode) TableName() string {
+	return "graph_nodes"
+}
+
+// graphEdge is a relation between two entity names extracted from a document
+type graphEdge struct {
+	ID              int64             `gorm:"column:id;primaryKey"`
+	KnowledgeBaseID string            `gorm:"column:knowledge_base_id"`
+	KnowledgeID     string            `gorm:"column:knowledge_id"`
+	Source          string            `gorm:"column:source"`
+	Target          string            `gorm:"column:target"`
+	Type            string            `gorm:"column:type"`
+	Chunks          types.StringArray `gorm:"column:chunks;type:jsonb"`
+	CreatedAt       time.Time         `gorm:"column:created_at"`
+	UpdatedAt       time.Time         `gorm:"column:updated_at"`
+}
+
+// TableName specifies the database table name for graphEdge
+func (graphEdge) TableName() string {
+	return "graph_edges"
+}
+
+// pgGraphRepository stores knowledge graphs in the graph_nodes and graph_edges tables.
+// Like the Neo4j repository, a node is identified by its name within a document.
+type pgGraphRepository struct {
+	db *gorm.DB
+	// fuzzy is set when pg_trgm is installed, node search then also matches similar names
+	fuzzy bool
+}
+
+// NewPostgresGraphRepository creates a new PostgreSQL graph repository
+func NewPostgresGraphRepository(db *gorm.DB) interfaces.RetrieveGraphRepository {
+	repo := &pgGraphRepository{db: db}
+	var installed int64
+	if err := db.Raw("SELECT COUNT(*) FROM pg_extension WHERE extname = 'pg_trgm'").
+		Scan(&installed).Error; err != nil {
+		logger.Warnf(context.Background(), "[Postgres] Failed to check pg_trgm, fuzzy entity search disabled: %v", err)
+	}
+	repo.fuzzy = installed > 0
+	logger.Infof(context.Background(), "[Postgres] Initializing PostgreSQL graph repository, fuzzy search: %v", repo.fuzzy)
+	return repo
+}
+
+// AddGraph adds the graphs extracted from a document
+func (r *pgGraphRepository) AddGraph(ctx context.Context, namespace types.NameSpace, graphs []*types.GraphData) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, graph := range graphs {
+			if err := addGraph(tx, namespace, graph); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "failed to add graph: %v", err)
+	}
+	return err
+}
+
+// addGraph upserts the nodes and relations of a graph, combining chunks and attributes of existing ones
+func addGraph(tx *gorm.DB, namespace types.NameSpace, graph *types.GraphData) error {
+	// 关系的端点也作为节点写入，与 Neo4j 中 MERGE 端点节点的行为一致
+	nodes := make(map[string]*types.GraphNode)
+	var names []string
+	addNode := func(node *types.GraphNode) {
+		if existing, ok := nodes[node.Name]; ok {
+			types.MergeGraphNode(existing, node)
+			return
+		}
+		nodes[node.Name] = &types.GraphNode{Name: node.Name}
+		types.MergeGraphNode(nodes[node.Name], node)
+		names = append(names, node.Name)
+	}
+	for _, node := range graph.Node {
+		addNode(node)
+	}
+	for _, rel := range graph.Relation {
+		addNode(&types.GraphNode{Name: rel.Node1})
+		addNode(&types.GraphNode{Name: rel.Node2})
+	}
+	rows := make([]*graphNode, 0, len(names))
+	for _, name := range names {
+		rows = append(rows, &graphNode{
+			KnowledgeBaseID: namespace.KnowledgeBase,
+			KnowledgeID:     namespace.Knowledge,
+			Name:            name,
+			Chunks:          nonNil(nodes[name].Chunks),
+			Attributes:      nonNil(nodes[name].Attributes),
+		})
+	}
+	if err := upsertNodes(tx, rows); err != nil {
+		return err
+	}
+
+	edges := make([]*graphEdge, 0, len(graph.Relation))
+	for _, rel := range types.MergeGraphRelations(graph.Relation) {
+		edges = append(edges, &graphEdge{
+			KnowledgeBaseID: namespace.KnowledgeBase,
+			KnowledgeID:     namespace.Knowledge,
+			Source:          rel.Node1,
+			Target:          rel.Node2,
+			Type:            rel.Type,
+			Chunks:          nonNil(rel.Chunks),
+		})
+	}
+	return upsertEdges(tx, edges)
+}
+
+func upsertNodes(tx *gorm.DB, rows []*graphNode) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "knowledge_base_id"}, {Name: "knowledge_id"}, {Name: "name"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "chunks"},
+				Value: gorm.Expr(fmt.Sprintf(jsonbUnion, "graph_nodes.chunks", "excluded.chunks"))},
+			{Column: clause.Column{Name: "attributes"},
+				Value: gorm.Expr(fmt.Sprintf(jsonbUnion, "graph_nodes.attributes", "excluded.attributes"))},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+		},
+	}).Create(rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to create nodes: %v", err)
+	}
+	return nil
+}
+
+func upsertEdges(tx *gorm.DB, rows []*graphEdge) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "knowledge_base_id"}, {Name: "knowledge_id"}, {Name: "source"}, {Name: "target"}, {Name: "type"},
+		},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "chunks"},
+				Value: gorm.Expr(fmt.Sprintf(jsonbUnion, "graph_edges.chunks", "excluded.chunks"))},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+		},
+	}).Create(rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to create relationships: %v", err)
+	}
+	return nil
+}
+
+// DelGraph deletes the graphs of the namespaces, the whole knowledge base when no knowledge is given
+func (r *pgGraphRepository) DelGraph(ctx context.Context, namespaces []types.NameSpace) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, namespace := range namespaces {
+			if err := inNamespace(tx, namespace).Delete(&graphEdge{}).Error; err != nil {
+				return fmt.Errorf("failed to delete relationships: %v", err)
+			}
+			if err := inNamespace(tx, namespace).Delete(&graphNode{}).Error; err != nil {
+				return fmt.Errorf("failed to delete nodes: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// SearchNode returns the relations of the entities whose name contains or, with pg_trgm, resembles a search term
+func (r *pgGraphRepository) SearchNode(ctx context.Context, namespace types.NameSpace,
+	nodes []string,
+) (*types.GraphData, error) {
+	graph := &types.GraphData{}
+	var conditions []string
+	var args []interface{}
+	for _, node := range nodes {
+		if node == "" {
+			continue
+		}
+		conditions = append(conditions, "name ILIKE ?")
+		args = append(args, "%"+escapeLikePattern(node)+"%")
+		if r.fuzzy {
+			conditions = append(conditions, "name % ?")
+			args = append(args, node)
+		}
+	}
+	if len(conditions) == 0 {
+		return graph, nil
+	}
+
+	db := r.db.WithContext(ctx)
+	var names []string
+	if err := inNamespace(db.Model(&graphNode{}), namespace).
+		Where(strings.Join(conditions, " OR "), args...).
+		Distinct("name").Limit(searchNodeLimit).Pluck("name", &names).Error; err != nil {
+		logger.Errorf(ctx, "search node failed: %v", err)
+		return nil, err
+	}
+	if len(names) == 0 {
+		return graph, nil
+	}
+
+	var edges []*graphEdge
+	if err := inNamespace(db, namespace).
+		Where("source IN ? OR target IN ?", names, names).
+		Order("id").Limit(searchNodeRelationLimit).Find(&edges).Error; err != nil {
+		logger.Errorf(ctx, "search node failed: %v", err)
+		return nil, err
+	}
+	graph.Relation = edgesToRelations(edges)
+
+	var endpoints []string
+	for _, rel := range graph.Relation {
+		endpoints = appendMissing(endpoints, rel.Node1, rel.Node2)
+	}
+	var rows []*graphNode
+	if err := inNamespace(db, namespace).Where("name IN ?", endpoints).
+		Order("id").Find(&rows).Error; err != nil {
+		logger.Errorf(ctx, "search node failed: %v", err)
+		return nil, err
+	}
+	graph.Node = mergeNodeRows(endpoints, rows)
+	return graph, nil
+}
+
+// ListEntities lists the entities of a knowledge base whose name contains the keyword
+func (r *pgGraphRepository) ListEntities(ctx context.Context, knowledgeBaseID, keyword string,
+	offset, limit int,
+) ([]*types.GraphEntity, int64, error) {
+	query := r.db.WithContext(ctx).Model(&graphNode{}).Where("knowledge_base_id = ?", knowledgeBaseID)
+	if keyword != "" {
+		query = query.Where("name ILIKE ?", "%"+escapeLikePattern(keyword)+"%")
+	}
+	var total int64
+	if err := query.Session(&gorm.Session{}).Distinct("name").Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count entities: %v", err)
+	}
+	var names []string
+	if err := query.Session(&gorm.Session{}).Group("name").Order("name").Offset(offset).Limit(limit).
+		Pluck("name", &names).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to query entities: %v", err)
+	}
+	entities, err := r.loadEntities(ctx, knowledgeBaseID, names)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+// GetEntity gets an entity of a knowledge base by name
+func (r *pgGraphRepository) GetEntity(ctx context.Context, knowledgeBaseID, name string) (*types.GraphEntity, error) {
+	entities, err := r.loadEntities(ctx, knowledgeBaseID, []string{name})
+	if err != nil || len(entities) == 0 {
+		return nil, err
+	}
+	return entities[0], nil
+}
+
+// loadEntities groups the nodes of the names in all documents, in the order of the names
+func (r *pgGraphRepository) loadEntities(ctx context.Context, knowledgeBaseID string,
+	names []string,
+) ([]*types.GraphEntity, error) {
+	entities := make([]*types.GraphEntity, 0, len(names))
+	if len(names) == 0 {
+		return entities, nil
+	}
+	db := r.db.WithContext(ctx)
+	var rows []*graphNode
+	if err := db.Where("knowledge_base_id = ? AND name IN ?", knowledgeBaseID, names).
+		Order("id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query entities: %v", err)
+	}
+	var degrees []struct {
+		Name   string
+		Degree int
+	}
+	if err := db.Raw(`
+		SELECT name, COUNT(*) AS degree FROM (
+			SELECT source AS name FROM graph_edges WHERE knowledge_base_id = ? AND source IN ?
+			UNION ALL
+			SELECT target AS name FROM graph_edges WHERE knowledge_base_id = ? AND target IN ?
+		) d GROUP BY name
+	`, knowledgeBaseID, names, knowledgeBaseID, names).Scan(&degrees).Error; err != nil {
+		return nil, fmt.Errorf("failed to count relations: %v", err)
+	}
+
+	byName := make(map[string]*types.GraphEntity, len(names))
+	nodes := make(map[string]*types.GraphNode, len(names))
+	for _, row := range rows {
+		entity, ok := byName[row.Name]
+		if !ok {
+			entity = &types.GraphEntity{Name: row.Name, KnowledgeIDs: []string{}}
+			byName[row.Name] = entity
+			nodes[row.Name] = &types.GraphNode{Name: row.Name}
+		}
+		types.MergeGraphNode(nodes[row.Name], &types.GraphNode{Chunks: row.Chunks, Attributes: row.Attributes})
+		entity.KnowledgeIDs = appendMissing(entity.KnowledgeIDs, row.KnowledgeID)
+	}
+	for _, degree := range degrees {
+		if entity, ok := byName[degree.Name]; ok {
+			entity.Degree = degree.Degree
+		}
+	}
+	for _, name := range names {
+		if entity, ok := byName[name]; ok {
+			entity.Chunks = nonNil(nodes[name].Chunks)
+			entity.Attributes = nonNil(nodes[name].Attributes)
+			entities = append(entities, entity)
+		}
+	}
+	return entities, nil
+}
+
+// ExpandEntities returns the subgraph within depth hops of the named entities
+func (r *pgGraphRepository) ExpandEntities(ctx context.Context, knowledgeBaseID string, names []string,
+	depth, limit int,
+) (*types.GraphData, error) {
+	graph := &types.GraphData{}
+	seen := appendMissing([]string{}, names...)
+	if len(seen) == 0 || depth < 1 || limit < 1 {
+		return graph, nil
+	}
+	db := r.db.WithContext(ctx)
+	var edges []*graphEdge
+	if err := db.Raw(expandQuery, pq.Array(seen), knowledgeBaseID, depth, knowledgeBaseID, depth, limit).
+		Scan(&edges).Error; err != nil {
+		logger.Errorf(ctx, "expand entities failed: %v", err)
+		return nil, fmt.Errorf("failed to expand entities: %v", err)
+	}
+	graph.Relation = edgesToRelations(edges)
+	for _, rel := range graph.Relation {
+		seen = appendMissing(seen, rel.Node1, rel.Node2)
+	}
+
+	var rows []*graphNode
+	if err := db.Where("knowledge_base_id = ? AND name IN ?", knowledgeBaseID, seen).
+		Order("id").Find(&rows).Error; err != nil {
+		logger.Errorf(ctx, "expand entities failed: %v", err)
+		return nil, fmt.Errorf("failed to get entities: %v", err)
+	}
+	graph.Node = mergeNodeRows(seen, rows)
+	return graph, nil
+}
+
+// UpdateEntityAttributes replaces the attributes of an entity in all documents
+func (r *pgGraphRepository) UpdateEntityAttributes(ctx context.Context, knowledgeBaseID, name string,
+	attributes []string,
+) error {
+	err := r.db.WithContext(ctx).Model(&graphNode{}).
+		Where("knowledge_base_id = ? AND name = ?", knowledgeBaseID, name).
+		Updates(map[string]interface{}{
+			"attributes": types.StringArray(nonNil(attributes)),
+			"updated_at": time.Now(),
+		}).Error
+	if err != nil {
+		logger.Errorf(ctx, "update entity failed: %v", err)
+		return fmt.Errorf("failed to update entity: %v", err)
+	}
+	return nil
+}
+
+// MergeEntities merges the source entities into the target entity, document by document
+func (r *pgGraphRepository) MergeEntities(ctx context.Context, knowledgeBaseID string, sources []string,
+	target string,
+) error {
+	names := appendMissing([]string{target}, sources...)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 每个文档内的同名节点合并为一行，目标实体的分块和属性排在前面
+		var rows []*graphNode
+		if err := tx.Where("knowledge_base_id = ? AND name IN ?", knowledgeBaseID, names).
+			Order("id").Find(&rows).Error; err != nil {
+			return err
+		}
+		rank := func(row *graphNode) int {
+			if row.Name == target {
+				return 0
+			}
+			return 1
+		}
+		slices.SortStableFunc(rows, func(a, b *graphNode) int { return rank(a) - rank(b) })
+		merged := make(map[string]*types.GraphNode)
+		var knowledgeIDs []string
+		for _, row := range rows {
+			node, ok := merged[row.KnowledgeID]
+			if !ok {
+				node = &types.GraphNode{Name: target}
+				merged[row.KnowledgeID] = node
+				knowledgeIDs = append(knowledgeIDs, row.KnowledgeID)
+			}
+			types.MergeGraphNode(node, &types.GraphNode{Chunks: row.Chunks, Attributes: row.Attributes})
+		}
+		if err := tx.Where("knowledge_base_id = ? AND name IN ?", knowledgeBaseID, names).
+			Delete(&graphNode{}).Error; err != nil {
+			return err
+		}
+		nodeRows := make([]*graphNode, 0, len(knowledgeIDs))
+		for _, knowledgeID := range knowledgeIDs {
+			nodeRows = append(nodeRows, &graphNode{
+				KnowledgeBaseID: knowledgeBaseID,
+				KnowledgeID:     knowledgeID,
+				Name:            target,
+				Chunks:          nonNil(merged[knowledgeID].Chunks),
+				Attributes:      nonNil(merged[knowledgeID].Attributes),
+			})
+		}
+		if err := upsertNodes(tx, nodeRows); err != nil {
+			return err
+		}
+
+		// 关系端点改为目标实体；合并的实体之间原有的关系成为自环，予以删除
+		var edges []*graphEdge
+		if err := tx.Where("knowledge_base_id = ? AND (source IN ? OR target IN ?)", knowledgeBaseID, names, names).
+			Order("id").Find(&edges).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("knowledge_base_id = ? AND (source IN ? OR target IN ?)", knowledgeBaseID, names, names).
+			Delete(&graphEdge{}).Error; err != nil {
+			return err
+		}
+		rename := func(name string) string {
+			if slices.Contains(names, name) {
+				return target
+			}
+			return name
+		}
+		type edgeKey struct{ knowledgeID, source, target, relationType string }
+		index := make(map[edgeKey]*graphEdge)
+		var edgeRows []*graphEdge
+		for _, edge := range edges {
+			source, dest := rename(edge.Source), rename(edge.Target)
+			if source == dest {
+				continue
+			}
+			key := edgeKey{edge.KnowledgeID, source, dest, edge.Type}
+			if existing, ok := index[key]; ok {
+				existing.Chunks = appendMissing(existing.Chunks, edge.Chunks...)
+				continue
+			}
+			index[key] = &graphEdge{
+				KnowledgeBaseID: knowledgeBaseID,
+				KnowledgeID:     edge.KnowledgeID,
+				Source:          source,
+				Target:          dest,
+				Type:            edge.Type,
+				Chunks:          appendMissing(types.StringArray{}, edge.Chunks...),
+			}
+			edgeRows = append(edgeRows, index[key])
+		}
+		return upsertEdges(tx, edgeRows)
+	})
+	if err != nil {
+		logger.Errorf(ctx, "merge entities failed: %v", err)
+		return fmt.Errorf("failed to merge entities: %v", err)
+	}
+	logger.Infof(ctx, "merged entities %v into %s", sources, target)
+	return nil
+}
+
+// inNamespace scopes a query to a knowledge base, and to a knowledge if it is given
+func inNamespace(db *gorm.DB, namespace types.NameSpace) *gorm.DB {
+	db = db.Where("knowledge_base_id = ?", namespace.KnowledgeBase)
+	if namespace.Knowledge != "" {
+		db = db.Where("knowledge_id = ?", namespace.Knowledge)
+	}
+	return db
+}
+
+// edgesToRelations converts edges to relations, merging those of the same endpoints and type in different documents
+func edgesToRelations(edges []*graphEdge) []*types.GraphRelation {
+	relations := make([]*types.GraphRelation, 0, len(edges))
+	for _, edge := range edges {
+		relations = append(relations, &types.GraphRelation{
+			Node1:  edge.Source,
+			Node2:  edge.Target,
+			Type:   edge.Type,
+			Chunks: edge.Chunks,
+		})
+	}
+	return types.MergeGraphRelations(relations)
+}
+
+// mergeNodeRows groups the node rows of each name, in the order of the names
+func mergeNodeRows(names []string, rows []*graphNode) []*types.GraphNode {
+	byName := make(map[string]*types.GraphNode, len(names))
+	for _, row := range rows {
+		node, ok := byName[row.Name]
+		if !ok {
+			node = &types.GraphNode{Name: row.Name}
+			byName[row.Name] = node
+		}
+		types.MergeGraphNode(node, &types.GraphNode{Chunks: row.Chunks, Attributes: row.Attributes})
+	}
+	nodes := make([]*types.GraphNode, 0, len(byName))
+	for _, name := range names {
+		if node, ok := byName[name]; ok {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func appendMissing[S ~[]string](list S, values ...string) S {
+	for _, value := range values {
+		if !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+func nonNil(list []string) types.StringArray {
+	if list == nil {
+		return types.StringArray{}
+	}
+	return list
+}
+
+// escapeLikePattern escapes the LIKE wildcards of a search term so it is matched literally
+func escapeLikePattern(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
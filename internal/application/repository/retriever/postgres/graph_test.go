@@ -0,0 +1,259 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// recordedQuery is a statement received by a fakeConn
+type recordedQuery struct {
+	sql  string
+	args []driver.Value
+}
+
+// fakeResult answers the queries containing match
+type fakeResult struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+}
+
+// fakeConn records the statements it receives and answers queries with the first matching result
+type fakeConn struct {
+	results []fakeResult
+	queries []recordedQuery
+}
+
+func (c *fakeConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+
+func (c *fakeConn) Driver() driver.Driver { return nil }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *fakeConn) Commit() error { return nil }
+
+func (c *fakeConn) Rollback() error { return nil }
+
+func (c *fakeConn) ExecContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Result, error) {
+	c.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Rows, error) {
+	c.record(query, args)
+	for _, result := range c.results {
+		if strings.Contains(query, result.match) {
+			return &fakeRows{columns: result.columns, rows: result.rows}, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+func (c *fakeConn) record(query string, args []driver.NamedValue) {
+	values := make([]driver.Value, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+	c.queries = append(c.queries, recordedQuery{sql: query, args: values})
+}
+
+// find returns the recorded statements containing match
+func (c *fakeConn) find(match string) []recordedQuery {
+	var found []recordedQuery
+	for _, query := range c.queries {
+		if strings.Contains(query.sql, match) {
+			found = append(found, query)
+		}
+	}
+	return found
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// newFakeGraphRepository creates a repository on the PostgreSQL dialect whose statements go to a fakeConn
+func newFakeGraphRepository(t *testing.T, fuzzy bool, results ...fakeResult) (*pgGraphRepository, *fakeConn) {
+	conn := &fakeConn{results: results}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(conn)}), &gorm.Config{})
+	require.NoError(t, err)
+	return &pgGraphRepository{db: db, fuzzy: fuzzy}, conn
+}
+
+var (
+	edgeColumns = []string{"source", "target", "type", "chunks"}
+	nodeColumns = []string{"knowledge_id", "name", "chunks", "attributes"}
+)
+
+func TestExpandEntitiesBindsNamesAsArray(t *testing.T) {
+	repo, conn := newFakeGraphRepository(t, false,
+		fakeResult{match: "WITH RECURSIVE", columns: edgeColumns, rows: [][]driver.Value{
+			{"a", "c", "rel", []byte(`["c1"]`)},
+		}},
+		fakeResult{match: "graph_nodes", columns: nodeColumns, rows: [][]driver.Value{
+			{"k", "a", []byte(`["c1"]`), []byte(`[]`)},
+			{"k", "c", []byte(`["c1"]`), []byte(`["attr"]`)},
+		}},
+	)
+
+	graph, err := repo.ExpandEntities(context.Background(), "kb", []string{"a", "b", "a"}, 2, 10)
+	require.NoError(t, err)
+
+	expand := conn.find("WITH RECURSIVE")
+	require.Len(t, expand, 1)
+	// 起点名称作为单个数组参数绑定，而不是展开为行值
+	assert.Contains(t, expand[0].sql, "SELECT unnest($1::text[]), 0")
+	assert.Equal(t, []driver.Value{`{"a","b"}`, "kb", int64(2), "kb", int64(2), int64(10)}, expand[0].args)
+
+	nodes := conn.find("graph_nodes")
+	require.Len(t, nodes, 1)
+	assert.Contains(t, nodes[0].sql, "name IN ($2,$3,$4)")
+	assert.Equal(t, []driver.Value{"kb", "a", "b", "c"}, nodes[0].args)
+
+	require.Len(t, graph.Relation, 1)
+	assert.Equal(t, "c", graph.Relation[0].Node2)
+	require.Len(t, graph.Node, 2)
+	assert.Equal(t, []string{"attr"}, graph.Node[1].Attributes)
+}
+
+func TestSearchNode(t *testing.T) {
+	tests := []struct {
+		name       string
+		fuzzy      bool
+		condition  string
+		searchArgs []driver.Value
+	}{
+		{
+			name:       "substring",
+			condition:  "(name ILIKE $2 OR name ILIKE $3)",
+			searchArgs: []driver.Value{"kb", `%50\%\_off%`, "%b%"},
+		},
+		{
+			name:       "fuzzy",
+			fuzzy:      true,
+			condition:  "(name ILIKE $2 OR name % $3 OR name ILIKE $4 OR name % $5)",
+			searchArgs: []driver.Value{"kb", `%50\%\_off%`, "50%_off", "%b%", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, conn := newFakeGraphRepository(t, tt.fuzzy,
+				fakeResult{match: "DISTINCT", columns: []string{"name"}, rows: [][]driver.Value{{"b"}}},
+				fakeResult{match: "graph_edges", columns: edgeColumns, rows: [][]driver.Value{
+					{"a", "b", "rel", []byte(`["c1"]`)},
+				}},
+				fakeResult{match: "graph_nodes", columns: nodeColumns, rows: [][]driver.Value{
+					{"k", "b", []byte(`["c1"]`), []byte(`[]`)},
+				}},
+			)
+
+			graph, err := repo.SearchNode(context.Background(), types.NameSpace{KnowledgeBase: "kb"},
+				[]string{"50%_off", "", "b"})
+			require.NoError(t, err)
+			require.Len(t, conn.queries, 3)
+
+			search := conn.queries[0]
+			assert.Contains(t, search.sql, "SELECT DISTINCT")
+			assert.Contains(t, search.sql, tt.condition)
+			assert.Equal(t, append(tt.searchArgs, int64(searchNodeLimit)), search.args)
+
+			relations := conn.queries[1]
+			assert.Contains(t, relations.sql, "(source IN ($2) OR target IN ($3))")
+			assert.Equal(t, []driver.Value{"kb", "b", "b", int64(searchNodeRelationLimit)}, relations.args)
+
+			nodes := conn.queries[2]
+			assert.Contains(t, nodes.sql, "name IN ($2,$3)")
+			assert.Equal(t, []driver.Value{"kb", "a", "b"}, nodes.args)
+
+			require.Len(t, graph.Relation, 1)
+			require.Len(t, graph.Node, 1)
+			assert.Equal(t, "b", graph.Node[0].Name)
+		})
+	}
+}
+
+func TestSearchNodeWithoutTerms(t *testing.T) {
+	repo, conn := newFakeGraphRepository(t, true)
+	graph, err := repo.SearchNode(context.Background(), types.NameSpace{KnowledgeBase: "kb"}, []string{""})
+	require.NoError(t, err)
+	assert.Empty(t, graph.Node)
+	assert.Empty(t, conn.queries)
+}
+
+func TestMergeEntities(t *testing.T) {
+	repo, conn := newFakeGraphRepository(t, false,
+		fakeResult{match: `SELECT * FROM "graph_nodes"`, columns: nodeColumns, rows: [][]driver.Value{
+			{"k1", "alias", []byte(`["c2"]`), []byte(`["a2"]`)},
+			{"k1", "target", []byte(`["c1"]`), []byte(`["a1"]`)},
+			{"k2", "alias", []byte(`["c3"]`), []byte(`[]`)},
+		}},
+		fakeResult{match: `SELECT * FROM "graph_edges"`, columns: append([]string{"knowledge_id"}, edgeColumns...),
+			rows: [][]driver.Value{
+				{"k1", "alias", "target", "same", []byte(`["c1"]`)},
+				{"k1", "alias", "other", "rel", []byte(`["c2"]`)},
+				{"k1", "target", "other", "rel", []byte(`["c1"]`)},
+			}},
+	)
+
+	require.NoError(t, repo.MergeEntities(context.Background(), "kb", []string{"alias"}, "target"))
+
+	names := []driver.Value{"kb", "target", "alias"}
+	nodeDeletes := conn.find(`DELETE FROM "graph_nodes"`)
+	require.Len(t, nodeDeletes, 1)
+	assert.Contains(t, nodeDeletes[0].sql, "knowledge_base_id = $1 AND name IN ($2,$3)")
+	assert.Equal(t, names, nodeDeletes[0].args)
+	edgeDeletes := conn.find(`DELETE FROM "graph_edges"`)
+	require.Len(t, edgeDeletes, 1)
+	assert.Contains(t, edgeDeletes[0].sql, "knowledge_base_id = $1 AND (source IN ($2,$3) OR target IN ($4,$5))")
+	assert.Equal(t, append(names, "target", "alias"), edgeDeletes[0].args)
+
+	// 每个文档合并为一行目标实体，目标实体的分块和属性在前
+	nodes := conn.find(`INSERT INTO "graph_nodes"`)
+	require.Len(t, nodes, 1)
+	assert.Contains(t, nodes[0].sql, "VALUES ($1,$2,$3,$4,$5,$6,$7),($8,$9,$10,$11,$12,$13,$14) "+
+		`ON CONFLICT ("knowledge_base_id","knowledge_id","name") DO UPDATE SET`)
+	require.Len(t, nodes[0].args, 14)
+	assert.Equal(t, []driver.Value{"kb", "k1", "target", []byte(`["c1","c2"]`), []byte(`["a1","a2"]`)},
+		nodes[0].args[:5])
+	assert.Equal(t, []driver.Value{"kb", "k2", "target", []byte(`["c3"]`), []byte(`[]`)}, nodes[0].args[7:12])
+
+	// 合并后成为自环的关系被删除，相同的关系合并分块
+	edges := conn.find(`INSERT INTO "graph_edges"`)
+	require.Len(t, edges, 1)
+	assert.Contains(t, edges[0].sql, "VALUES ($1,$2,$3,$4,$5,$6,$7,$8) "+
+		`ON CONFLICT ("knowledge_base_id","knowledge_id","source","target","type") DO UPDATE SET`)
+	require.Len(t, edges[0].args, 8)
+	assert.Equal(t, []driver.Value{"kb", "k1", "target", "other", "rel", []byte(`["c2","c1"]`)}, edges[0].args[:6])
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// sessionShareRepository 会话分享链接仓库实现
+type sessionShareRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionShareRepository 创建会话分享链接仓库
+func NewSessionShareRepository(db *gorm.DB) interfaces.SessionShareRepository {
+	return &sessionShareRepository{db: db}
+}
+
+// CreateShare 创建分享链接
+func (r *sessionShareRepository) CreateShare(ctx context.Context, share *types.SessionShare) error {
+	return r.db.WithContext(ctx).Create(share).Error
+}
+
+// GetShareByToken 根据令牌获取分享链接，不限定租户
+func (r *sessionShareRepository) GetShareByToken(ctx context.Context, token string) (*types.SessionShare, error) {
+	var share types.SessionShare
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&share).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &share, nil
+}
+
+// ListShares 获取会话未过期的分享链接，不加载快照
+func (r *sessionShareRepository) ListShares(
+	ctx context.Context, tenantID uint64, sessionID string,
+) ([]*types.SessionShare, error) {
+	var shares []*types.SessionShare
+	if err := r.db.WithContext(ctx).Omit("snapshot").
+		Where("tenant_id = ? AND session_id = ? AND expires_at > ?", tenantID, sessionID, time.Now()).
+		Order("created_at DESC").Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// DeleteShare 撤销分享链接，返回链接是否存在
+func (r *sessionShareRepository) DeleteShare(
+	ctx context.Context, tenantID uint64, sessionID string, id string,
+) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ? AND session_id = ?", id, tenantID, sessionID).
+		Delete(&types.SessionShare{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// toolApprovalRepository 工具调用审批仓库实现
+type toolApprovalRepository struct {
+	db *gorm.DB
+}
+
+// NewToolApprovalRepository 创建工具调用审批仓库
+func NewToolApprovalRepository(db *gorm.DB) interfaces.ToolApprovalRepository {
+	return &toolApprovalRepository{db: db}
+}
+
+// CreateApproval 创建审批
+func (r *toolApprovalRepository) CreateApproval(ctx context.Context, approval *types.ToolApproval) error {
+	return r.db.WithContext(ctx).Create(approval).Error
+}
+
+// GetApproval 根据ID获取租户的审批
+func (r *toolApprovalRepository) GetApproval(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.ToolApproval, error) {
+	var approval types.ToolApproval
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&approval).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// ListApprovals 获取会话的审批，按创建时间排序，status 为空时返回全部
+func (r *toolApprovalRepository) ListApprovals(
+	ctx context.Context, tenantID uint64, sessionID string, status types.ToolApprovalStatus,
+) ([]*types.ToolApproval, error) {
+	query := r.db.WithContext(ctx).Where("tenant_id = ? AND session_id = ?", tenantID, sessionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var approvals []*types.ToolApproval
+	if err := query.Order("created_at ASC").Find(&approvals).Error; err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+// ResolveApproval 仅当审批仍待处理时写入决定，避免并发的决定或过期互相覆盖
+func (r *toolApprovalRepository) ResolveApproval(ctx context.Context, approval *types.ToolApproval) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&types.ToolApproval{}).
+		Where("id = ? AND tenant_id = ? AND status = ?", approval.ID, approval.TenantID, types.ToolApprovalPending).
+		Updates(map[string]interface{}{
+			"status":     approval.Status,
+			"reason":     approval.Reason,
+			"decided_by": approval.DecidedBy,
+			"decided_at": approval.DecidedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// webhookRepository Webhook 订阅及投递记录仓库实现
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository 创建 Webhook 仓库
+func NewWebhookRepository(db *gorm.DB) interfaces.WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// CreateSubscription 创建订阅
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *types.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(subscription).Error
+}
+
+// GetSubscription 根据ID获取订阅
+func (r *webhookRepository) GetSubscription(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.WebhookSubscription, error) {
+	var subscription types.WebhookSubscription
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// ListSubscriptions 获取租户的订阅列表
+func (r *webhookRepository) ListSubscriptions(
+	ctx context.Context, tenantID uint64,
+) ([]*types.WebhookSubscription, error) {
+	var subscriptions []*types.WebhookSubscription
+	if err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// UpdateSubscription 更新订阅
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, subscription *types.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Model(&types.WebhookSubscription{}).
+		Where("id = ? AND tenant_id = ?", subscription.ID, subscription.TenantID).
+		Select("name", "description", "url", "events", "enabled", "updated_at").
+		Updates(subscription).Error
+}
+
+// DeleteSubscription 删除订阅
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).
+		Delete(&types.WebhookSubscription{}).Error
+}
+
+// CreateDelivery 创建投递记录
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+// GetDelivery 根据ID获取投递记录
+func (r *webhookRepository) GetDelivery(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.WebhookDelivery, error) {
+	var delivery types.WebhookDelivery
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// UpdateDelivery 更新投递结果
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Model(&types.WebhookDelivery{}).
+		Where("id = ? AND tenant_id = ?", delivery.ID, delivery.TenantID).
+		Select("status", "attempts", "response_status", "response_body", "error",
+			"duration_ms", "delivered_at", "updated_at").
+		Updates(delivery).Error
+}
+
+// ListDeliveries 分页获取订阅的投递记录
+func (r *webhookRepository) ListDeliveries(
+	ctx context.Context, tenantID uint64, subscriptionID string, page *types.Pagination,
+) ([]*types.WebhookDelivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.WebhookDelivery{}).
+		Where("tenant_id = ? AND subscription_id = ?", tenantID, subscriptionID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []*types.WebhookDelivery
+	if err := query.Order("created_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
@@ -0,0 +1,399 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/hibiken/asynq"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	defaultAgentRunTimeout   = 30 * time.Minute
+	defaultAgentTriggerDelay = time.Minute
+	// agentScheduleDispatchBatch bounds the due schedules enqueued by one dispatch, the rest follow a minute later
+	agentScheduleDispatchBatch = 100
+)
+
+// agentScheduleParser parses standard 5-field cron expressions and descriptors such as @daily
+var agentScheduleParser = cron.NewParser(
+	cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// agentScheduleService implements the AgentScheduleService interface
+type agentScheduleService struct {
+	repo               interfaces.AgentScheduleRepository
+	customAgentService interfaces.CustomAgentService
+	kbRepo             interfaces.KnowledgeBaseRepository
+	task               *asynq.Client
+	runTimeout         time.Duration
+	triggerDelay       time.Duration
+}
+
+// NewAgentScheduleService creates a new agent schedule service
+func NewAgentScheduleService(
+	repo interfaces.AgentScheduleRepository,
+	customAgentService interfaces.CustomAgentService,
+	kbRepo interfaces.KnowledgeBaseRepository,
+	task *asynq.Client,
+	cfg *config.Config,
+) interfaces.AgentScheduleService {
+	runTimeout, triggerDelay := agentScheduleTimings(cfg)
+	return &agentScheduleService{
+		repo:               repo,
+		customAgentService: customAgentService,
+		kbRepo:             kbRepo,
+		task:               task,
+		runTimeout:         runTimeout,
+		triggerDelay:       triggerDelay,
+	}
+}
+
+// agentScheduleTimings returns the configured run timeout and event trigger delay
+func agentScheduleTimings(cfg *config.Config) (time.Duration, time.Duration) {
+	runTimeout, triggerDelay := defaultAgentRunTimeout, defaultAgentTriggerDelay
+	if cfg.AgentSchedule != nil {
+		if cfg.AgentSchedule.RunTimeout > 0 {
+			runTimeout = time.Duration(cfg.AgentSchedule.RunTimeout) * time.Second
+		}
+		if cfg.AgentSchedule.TriggerDelay > 0 {
+			triggerDelay = time.Duration(cfg.AgentSchedule.TriggerDelay) * time.Second
+		}
+	}
+	return runTimeout, triggerDelay
+}
+
+// CreateSchedule creates a schedule and computes its next cron run
+func (s *agentScheduleService) CreateSchedule(ctx context.Context, schedule *types.AgentSchedule) error {
+	if err := s.validateSchedule(ctx, schedule); err != nil {
+		return err
+	}
+	next, err := nextAgentScheduleRun(schedule, time.Now())
+	if err != nil {
+		return werrors.NewBadRequestError(err.Error())
+	}
+	schedule.ID = ""
+	schedule.TenantID = ctx.Value(types.TenantIDContextKey).(uint64)
+	schedule.SessionID = ""
+	schedule.NextRunAt = next
+	schedule.LastRunAt = nil
+
+	if err := s.repo.CreateSchedule(ctx, schedule); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"name": schedule.Name})
+		return err
+	}
+	logger.Infof(ctx, "Agent schedule created: %s, agent: %s, cron: %q, events: %v",
+		schedule.ID, schedule.AgentID, schedule.Cron, schedule.TriggerEvents)
+	return nil
+}
+
+// GetSchedule gets a schedule of the current tenant
+func (s *agentScheduleService) GetSchedule(ctx context.Context, id string) (*types.AgentSchedule, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	schedule, err := s.repo.GetSchedule(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, werrors.NewNotFoundError("Agent schedule not found")
+	}
+	return schedule, nil
+}
+
+// ListSchedules lists the schedules of the current tenant
+func (s *agentScheduleService) ListSchedules(ctx context.Context) ([]*types.AgentSchedule, error) {
+	return s.repo.ListSchedules(ctx, ctx.Value(types.TenantIDContextKey).(uint64))
+}
+
+// UpdateSchedule updates a schedule and recomputes its next cron run
+func (s *agentScheduleService) UpdateSchedule(
+	ctx context.Context, schedule *types.AgentSchedule,
+) (*types.AgentSchedule, error) {
+	existing, err := s.GetSchedule(ctx, schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	existing.AgentID = schedule.AgentID
+	existing.Name = schedule.Name
+	existing.Description = schedule.Description
+	existing.Prompt = schedule.Prompt
+	existing.Cron = schedule.Cron
+	existing.Timezone = schedule.Timezone
+	existing.TriggerEvents = schedule.TriggerEvents
+	existing.TriggerKnowledgeBaseIDs = schedule.TriggerKnowledgeBaseIDs
+	existing.OutputKnowledgeBaseID = schedule.OutputKnowledgeBaseID
+	existing.NotifyWebhook = schedule.NotifyWebhook
+	existing.Enabled = schedule.Enabled
+	if existing.NextRunAt, err = nextAgentScheduleRun(existing, time.Now()); err != nil {
+		return nil, werrors.NewBadRequestError(err.Error())
+	}
+	existing.UpdatedAt = time.Now()
+	if err := s.repo.UpdateSchedule(ctx, existing); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"schedule_id": existing.ID})
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteSchedule deletes a schedule of the current tenant, its session and runs are kept
+func (s *agentScheduleService) DeleteSchedule(ctx context.Context, id string) error {
+	if _, err := s.GetSchedule(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteSchedule(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id)
+}
+
+// ListRuns lists the runs of a schedule, newest first
+func (s *agentScheduleService) ListRuns(
+	ctx context.Context, scheduleID string, page *types.Pagination,
+) (*types.PageResult, error) {
+	if _, err := s.GetSchedule(ctx, scheduleID); err != nil {
+		return nil, err
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	runs, total, err := s.repo.ListRuns(ctx, tenantID, scheduleID, page)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, page, runs), nil
+}
+
+// GetRun gets a run of a schedule
+func (s *agentScheduleService) GetRun(ctx context.Context, scheduleID, runID string) (*types.AgentScheduleRun, error) {
+	run, err := s.repo.GetRun(ctx, ctx.Value(types.TenantIDContextKey).(uint64), runID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil || run.ScheduleID != scheduleID {
+		return nil, werrors.NewNotFoundError("Agent schedule run not found")
+	}
+	return run, nil
+}
+
+// RunNow enqueues a run of a schedule immediately, also if the schedule is disabled
+func (s *agentScheduleService) RunNow(ctx context.Context, id string) (*types.AgentScheduleRun, error) {
+	schedule, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueueRun(ctx, schedule, types.AgentRunTriggerManual, nil, 0)
+}
+
+// TriggerKnowledgeEvent enqueues the runs of the schedules triggered by a knowledge lifecycle event.
+// Events arriving while a triggered run waits to start are added to that run instead of starting another one.
+func (s *agentScheduleService) TriggerKnowledgeEvent(
+	ctx context.Context, eventType types.WebhookEventType, knowledge *types.Knowledge,
+) {
+	schedules, err := s.repo.ListEventSchedules(ctx, knowledge.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list agent schedules for event %s: %v", eventType, err)
+		return
+	}
+	if len(schedules) == 0 {
+		return
+	}
+	// 定时任务写回的知识不再触发定时任务，避免相互触发形成循环
+	isOutput, err := s.repo.IsRunOutput(ctx, knowledge.TenantID, knowledge.ID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to check the origin of knowledge %s: %v", knowledge.ID, err)
+		return
+	}
+	if isOutput {
+		return
+	}
+
+	runEvent := types.AgentRunEvent{
+		Type:            eventType,
+		KnowledgeID:     knowledge.ID,
+		KnowledgeBaseID: knowledge.KnowledgeBaseID,
+		Title:           knowledge.Title,
+		OccurredAt:      time.Now(),
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, knowledge.TenantID)
+	for _, schedule := range schedules {
+		if !schedule.TriggeredBy(eventType, knowledge.KnowledgeBaseID) {
+			continue
+		}
+		pending, err := s.repo.GetPendingEventRun(ctx, knowledge.TenantID, schedule.ID)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to get pending run of agent schedule %s: %v", schedule.ID, err)
+			continue
+		}
+		if pending != nil {
+			appended, err := s.repo.AppendRunEvents(ctx, pending, []types.AgentRunEvent{runEvent})
+			if err != nil {
+				logger.Errorf(ctx, "Failed to add event to agent schedule run %s: %v", pending.ID, err)
+				continue
+			}
+			if appended {
+				logger.Infof(ctx, "Event %s of knowledge %s added to pending run %s of agent schedule %s",
+					eventType, knowledge.ID, pending.ID, schedule.ID)
+				continue
+			}
+		}
+		if _, err := s.enqueueRun(ctx, schedule, types.AgentRunTriggerEvent,
+			[]types.AgentRunEvent{runEvent}, s.triggerDelay); err != nil {
+			logger.Errorf(ctx, "Failed to trigger agent schedule %s: %v", schedule.ID, err)
+		}
+	}
+}
+
+// ProcessDispatch handles the periodic task enqueueing the runs of due cron schedules of all tenants.
+// Every worker enqueues the dispatch, each due run is claimed by one of them.
+func (s *agentScheduleService) ProcessDispatch(ctx context.Context, t *asynq.Task) error {
+	now := time.Now()
+	schedules, err := s.repo.ListDueSchedules(ctx, now, agentScheduleDispatchBatch)
+	if err != nil {
+		return fmt.Errorf("failed to list due agent schedules: %w", err)
+	}
+	for _, schedule := range schedules {
+		next, err := nextAgentScheduleRun(schedule, now)
+		if err != nil {
+			// 表达式已无法解析（如时区数据缺失），停止 cron 运行直到修改
+			logger.Errorf(ctx, "Failed to compute next run of agent schedule %s, stopping its cron runs: %v",
+				schedule.ID, err)
+			next = nil
+		}
+		claimed, err := s.repo.ClaimCronRun(ctx, schedule, next)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to claim run of agent schedule %s: %v", schedule.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		tenantCtx := context.WithValue(ctx, types.TenantIDContextKey, schedule.TenantID)
+		if _, err := s.enqueueRun(tenantCtx, schedule, types.AgentRunTriggerCron, nil, 0); err != nil {
+			logger.Errorf(ctx, "Failed to enqueue cron run of agent schedule %s: %v", schedule.ID, err)
+		}
+	}
+	return nil
+}
+
+// enqueueRun records a pending run of a schedule and enqueues it to start after the delay
+func (s *agentScheduleService) enqueueRun(
+	ctx context.Context,
+	schedule *types.AgentSchedule,
+	trigger types.AgentRunTrigger,
+	events []types.AgentRunEvent,
+	delay time.Duration,
+) (*types.AgentScheduleRun, error) {
+	run := &types.AgentScheduleRun{
+		TenantID:   schedule.TenantID,
+		ScheduleID: schedule.ID,
+		Trigger:    trigger,
+		Events:     events,
+		Status:     types.AgentRunPending,
+		SessionID:  schedule.SessionID,
+	}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	payload, _ := json.Marshal(types.AgentScheduledRunPayload{TenantID: schedule.TenantID, RunID: run.ID})
+	// 运行失败不重试，仅在 worker 中断时重新执行
+	task := asynq.NewTask(types.TypeAgentScheduledRun, payload, asynq.Queue("default"),
+		asynq.MaxRetry(1), asynq.Timeout(s.runTimeout+time.Minute), asynq.ProcessIn(delay))
+	if _, err := s.task.Enqueue(task); err != nil {
+		now := time.Now()
+		run.Status = types.AgentRunFailed
+		run.Error = fmt.Sprintf("failed to enqueue run: %v", err)
+		run.FinishedAt = &now
+		run.UpdatedAt = now
+		_ = s.repo.UpdateRun(ctx, run)
+		return nil, err
+	}
+	logger.Infof(ctx, "Agent schedule %s run %s queued (%s), starts in %s", schedule.ID, run.ID, trigger, delay)
+	return run, nil
+}
+
+// validateSchedule checks a schedule of the current tenant before it is saved
+func (s *agentScheduleService) validateSchedule(ctx context.Context, schedule *types.AgentSchedule) error {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	schedule.Prompt = strings.TrimSpace(schedule.Prompt)
+	schedule.Cron = strings.TrimSpace(schedule.Cron)
+	if schedule.Name == "" {
+		return werrors.NewBadRequestError("Schedule name is required")
+	}
+	if schedule.Prompt == "" {
+		return werrors.NewBadRequestError("Schedule prompt is required")
+	}
+	if schedule.Cron != "" {
+		if _, err := agentScheduleParser.Parse(schedule.Cron); err != nil {
+			return werrors.NewBadRequestError(fmt.Sprintf("Invalid cron expression: %v", err))
+		}
+		if strings.HasPrefix(schedule.Cron, "TZ=") || strings.HasPrefix(schedule.Cron, "CRON_TZ=") {
+			return werrors.NewBadRequestError("Set the time zone with the timezone field")
+		}
+	}
+	if schedule.Timezone != "" {
+		if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+			return werrors.NewBadRequestError("Unknown time zone: " + schedule.Timezone)
+		}
+	}
+	for _, eventType := range schedule.TriggerEvents {
+		if !types.IsValidAgentScheduleTriggerEvent(eventType) {
+			return werrors.NewBadRequestError("Unsupported trigger event: " + string(eventType))
+		}
+	}
+
+	agent, err := s.customAgentService.GetAgentByID(ctx, schedule.AgentID)
+	if err != nil || agent == nil {
+		return werrors.NewBadRequestError("Agent not found: " + schedule.AgentID)
+	}
+	if !agent.IsAgentMode() {
+		return werrors.NewBadRequestError("Only agents running in agent mode can be scheduled")
+	}
+
+	if schedule.OutputKnowledgeBaseID != "" {
+		kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, schedule.OutputKnowledgeBaseID)
+		if err != nil || kb == nil || kb.TenantID != ctx.Value(types.TenantIDContextKey).(uint64) {
+			return werrors.NewBadRequestError("Output knowledge base not found: " + schedule.OutputKnowledgeBaseID)
+		}
+		if kb.Type == types.KnowledgeBaseTypeFAQ {
+			return werrors.NewBadRequestError("Results cannot be written to a FAQ knowledge base")
+		}
+	}
+	return nil
+}
+
+// agentScheduleLocation returns the time zone of the cron expression of a schedule
+func agentScheduleLocation(schedule *types.AgentSchedule) (*time.Location, error) {
+	if schedule.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(schedule.Timezone)
+}
+
+// nextAgentScheduleRun computes the next cron run of a schedule after the time,
+// nil if the schedule is disabled or has no cron expression
+func nextAgentScheduleRun(schedule *types.AgentSchedule, after time.Time) (*time.Time, error) {
+	if !schedule.Enabled || schedule.Cron == "" {
+		return nil, nil
+	}
+	location, err := agentScheduleLocation(schedule)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := agentScheduleParser.Parse(schedule.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression: %w", err)
+	}
+	next := expr.Next(after.In(location))
+	if next.IsZero() {
+		return nil, fmt.Errorf("cron expression %q never runs", schedule.Cron)
+	}
+	return &next, nil
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// maxAgentRunPromptEvents bounds the trigger events listed in the prompt of a run
+const maxAgentRunPromptEvents = 50
+
+// scheduledAgentRunner runs custom agents headlessly for agent schedules,
+// storing each run as a question and answer in the session of the schedule
+type scheduledAgentRunner struct {
+	repo               interfaces.AgentScheduleRepository
+	tenantRepo         interfaces.TenantRepository
+	sessionService     interfaces.SessionService
+	messageService     interfaces.MessageService
+	customAgentService interfaces.CustomAgentService
+	knowledgeService   interfaces.KnowledgeService
+	webhookService     interfaces.WebhookService
+	timeout            time.Duration
+}
+
+// NewScheduledAgentRunner creates the handler of scheduled agent run tasks
+func NewScheduledAgentRunner(
+	repo interfaces.AgentScheduleRepository,
+	tenantRepo interfaces.TenantRepository,
+	sessionService interfaces.SessionService,
+	messageService interfaces.MessageService,
+	customAgentService interfaces.CustomAgentService,
+	knowledgeService interfaces.KnowledgeService,
+	webhookService interfaces.WebhookService,
+	cfg *config.Config,
+) interfaces.TaskHandler {
+	timeout, _ := agentScheduleTimings(cfg)
+	return &scheduledAgentRunner{
+		repo:               repo,
+		tenantRepo:         tenantRepo,
+		sessionService:     sessionService,
+		messageService:     messageService,
+		customAgentService: customAgentService,
+		knowledgeService:   knowledgeService,
+		webhookService:     webhookService,
+		timeout:            timeout,
+	}
+}
+
+// Handle runs the agent of a schedule on its prompt and records the result
+func (r *scheduledAgentRunner) Handle(ctx context.Context, t *asynq.Task) error {
+	var payload types.AgentScheduledRunPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "failed to unmarshal scheduled agent run payload: %v", err)
+		return nil
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+
+	run, err := r.repo.GetRun(ctx, payload.TenantID, payload.RunID)
+	if err != nil {
+		return fmt.Errorf("failed to get agent schedule run: %w", err)
+	}
+	// 运行中的记录说明上次执行被 worker 中断，重新执行
+	if run == nil || (run.Status != types.AgentRunPending && run.Status != types.AgentRunRunning) {
+		return nil
+	}
+
+	tenantInfo, err := r.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to get tenant info: %w", err)
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	schedule, err := r.repo.GetSchedule(ctx, payload.TenantID, run.ScheduleID)
+	if err != nil {
+		return fmt.Errorf("failed to get agent schedule: %w", err)
+	}
+	if schedule == nil {
+		r.finish(ctx, nil, run, errors.New("the schedule was deleted"))
+		return nil
+	}
+
+	now := time.Now()
+	run.Status = types.AgentRunRunning
+	run.StartedAt = &now
+	run.UpdatedAt = now
+	if err := r.repo.UpdateRun(ctx, run); err != nil {
+		return fmt.Errorf("failed to start agent schedule run: %w", err)
+	}
+	// 事件可能在读取后、开始运行前合并进来
+	if started, err := r.repo.GetRun(ctx, payload.TenantID, run.ID); err == nil && started != nil {
+		run.Events = started.Events
+	}
+
+	logger.Infof(ctx, "Running agent schedule %s (%s), run %s, agent %s",
+		schedule.ID, run.Trigger, run.ID, schedule.AgentID)
+	r.finish(ctx, schedule, run, r.execute(ctx, schedule, run))
+	return nil
+}
+
+// execute runs the agent in the session of the schedule and fills in the answer of the run
+func (r *scheduledAgentRunner) execute(
+	ctx context.Context, schedule *types.AgentSchedule, run *types.AgentScheduleRun,
+) error {
+	customAgent, err := r.customAgentService.GetAgentByID(ctx, schedule.AgentID)
+	if err != nil || customAgent == nil {
+		return fmt.Errorf("agent %s is unavailable: %v", schedule.AgentID, err)
+	}
+	if !customAgent.IsAgentMode() {
+		return fmt.Errorf("agent %s no longer runs in agent mode", schedule.AgentID)
+	}
+
+	session, err := r.ensureSession(ctx, schedule)
+	if err != nil {
+		return err
+	}
+	run.SessionID = session.ID
+
+	userMessage, err := r.messageService.CreateMessage(ctx, &types.Message{
+		SessionID:   session.ID,
+		ParentID:    session.ActiveMessageID,
+		Role:        "user",
+		AgentID:     customAgent.ID,
+		Content:     agentRunPrompt(schedule, run),
+		RequestID:   run.ID,
+		CreatedAt:   time.Now(),
+		IsCompleted: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save the prompt: %w", err)
+	}
+	assistantMessage, err := r.messageService.CreateMessage(ctx, &types.Message{
+		SessionID:    session.ID,
+		ParentID:     userMessage.ID,
+		Role:         "assistant",
+		AgentID:      customAgent.ID,
+		AgentVersion: customAgent.Version,
+		RequestID:    run.ID,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save the answer: %w", err)
+	}
+	run.MessageID = assistantMessage.ID
+
+	// 无人值守运行：收集事件中的回答、步骤及引用，写入回答消息
+	var (
+		mu       sync.Mutex
+		answer   strings.Builder
+		complete *event.AgentCompleteData
+		runErr   string
+	)
+	eventBus := event.NewEventBus()
+	eventBus.On(event.EventAgentFinalAnswer, func(ctx context.Context, evt event.Event) error {
+		if data, ok := evt.Data.(event.AgentFinalAnswerData); ok {
+			mu.Lock()
+			answer.WriteString(data.Content)
+			mu.Unlock()
+		}
+		return nil
+	})
+	eventBus.On(event.EventAgentComplete, func(ctx context.Context, evt event.Event) error {
+		if data, ok := evt.Data.(event.AgentCompleteData); ok && data.MessageID == assistantMessage.ID {
+			mu.Lock()
+			complete = &data
+			mu.Unlock()
+		}
+		return nil
+	})
+	eventBus.On(event.EventError, func(ctx context.Context, evt event.Event) error {
+		if data, ok := evt.Data.(event.ErrorData); ok {
+			mu.Lock()
+			runErr = data.Error
+			mu.Unlock()
+		}
+		return nil
+	})
+
+	runCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	err = r.sessionService.AgentQA(runCtx, session, userMessage.Content, nil, assistantMessage.ID, "",
+		eventBus, customAgent, nil, nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assistantMessage.Content = answer.String()
+	if complete != nil {
+		if assistantMessage.Content == "" {
+			assistantMessage.Content = complete.FinalAnswer
+		}
+		if steps, ok := complete.AgentSteps.([]types.AgentStep); ok {
+			assistantMessage.AgentSteps = steps
+		}
+		for _, ref := range complete.KnowledgeRefs {
+			if sr, ok := ref.(*types.SearchResult); ok {
+				assistantMessage.KnowledgeReferences = append(assistantMessage.KnowledgeReferences, sr)
+			}
+		}
+		run.Usage, _ = complete.Usage.(*types.AgentUsage)
+	}
+	assistantMessage.IsCompleted = true
+	assistantMessage.UpdatedAt = time.Now()
+	if err := r.messageService.UpdateMessage(context.WithoutCancel(ctx), assistantMessage); err != nil {
+		logger.Errorf(ctx, "Failed to save the answer of agent schedule run %s: %v", run.ID, err)
+	}
+	run.Answer = assistantMessage.Content
+
+	switch {
+	case err != nil:
+		return err
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("the run did not finish within %s", r.timeout)
+	case runErr != "":
+		return errors.New(runErr)
+	case complete == nil:
+		return errors.New("the agent stopped without completing")
+	}
+
+	if schedule.OutputKnowledgeBaseID != "" && strings.TrimSpace(run.Answer) != "" {
+		return r.writeBack(ctx, schedule, run)
+	}
+	return nil
+}
+
+// ensureSession returns the session collecting the runs of a schedule, creating it on the first run
+// or when it was deleted
+func (r *scheduledAgentRunner) ensureSession(ctx context.Context, schedule *types.AgentSchedule) (*types.Session, error) {
+	if schedule.SessionID != "" {
+		session, err := r.sessionService.GetSession(ctx, schedule.SessionID)
+		if err == nil {
+			return session, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get the session of the schedule: %w", err)
+		}
+	}
+
+	session, err := r.sessionService.CreateSession(ctx, &types.Session{
+		TenantID:    schedule.TenantID,
+		Title:       schedule.Name,
+		Description: "Runs of agent schedule " + schedule.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the session of the schedule: %w", err)
+	}
+	if err := r.repo.UpdateSessionID(ctx, schedule.TenantID, schedule.ID, session.ID); err != nil {
+		logger.Errorf(ctx, "Failed to save the session of agent schedule %s: %v", schedule.ID, err)
+	}
+	schedule.SessionID = session.ID
+	return session, nil
+}
+
+// writeBack adds the answer of a run to the output knowledge base of the schedule with add_knowledge_to_kb
+func (r *scheduledAgentRunner) writeBack(
+	ctx context.Context, schedule *types.AgentSchedule, run *types.AgentScheduleRun,
+) error {
+	location, err := agentScheduleLocation(schedule)
+	if err != nil {
+		location = time.UTC
+	}
+	args, _ := json.Marshal(tools.AddKnowledgeToKBInput{
+		KnowledgeBaseID: schedule.OutputKnowledgeBaseID,
+		Title:           fmt.Sprintf("%s %s", schedule.Name, run.StartedAt.In(location).Format("2006-01-02 15:04")),
+		Content:         run.Answer,
+	})
+	result, err := tools.NewAddKnowledgeToKBTool(r.knowledgeService).Execute(ctx, args)
+	if err != nil || result == nil || !result.Success {
+		if result != nil && result.Error != "" {
+			return fmt.Errorf("failed to write the answer back: %s", result.Error)
+		}
+		return fmt.Errorf("failed to write the answer back: %v", err)
+	}
+	run.OutputKnowledgeID, _ = result.Data["knowledge_id"].(string)
+	// 立即保存写回的知识，使其解析完成事件被识别为运行输出
+	if err := r.repo.UpdateRun(ctx, run); err != nil {
+		logger.Errorf(ctx, "Failed to save the output of agent schedule run %s: %v", run.ID, err)
+	}
+	return nil
+}
+
+// finish records the outcome of a run and delivers it to webhooks if the schedule asks for it
+func (r *scheduledAgentRunner) finish(
+	ctx context.Context, schedule *types.AgentSchedule, run *types.AgentScheduleRun, runErr error,
+) {
+	ctx = context.WithoutCancel(ctx)
+	now := time.Now()
+	run.Status = types.AgentRunSucceeded
+	run.Error = ""
+	if runErr != nil {
+		run.Status = types.AgentRunFailed
+		run.Error = runErr.Error()
+		logger.Warnf(ctx, "Agent schedule run %s failed: %v", run.ID, runErr)
+	} else {
+		logger.Infof(ctx, "Agent schedule run %s succeeded, answer length: %d", run.ID, len(run.Answer))
+	}
+	run.FinishedAt = &now
+	run.UpdatedAt = now
+	if err := r.repo.UpdateRun(ctx, run); err != nil {
+		logger.Errorf(ctx, "Failed to save agent schedule run %s: %v", run.ID, err)
+	}
+
+	if schedule == nil || !schedule.NotifyWebhook {
+		return
+	}
+	r.webhookService.Publish(ctx, run.TenantID, types.WebhookEventAgentRunFinished, map[string]interface{}{
+		"schedule_id":         schedule.ID,
+		"schedule_name":       schedule.Name,
+		"agent_id":            schedule.AgentID,
+		"run_id":              run.ID,
+		"trigger":             run.Trigger,
+		"events":              run.Events,
+		"status":              run.Status,
+		"session_id":          run.SessionID,
+		"message_id":          run.MessageID,
+		"answer":              run.Answer,
+		"output_knowledge_id": run.OutputKnowledgeID,
+		"usage":               run.Usage,
+		"error":               run.Error,
+	})
+}
+
+// agentRunPrompt returns the prompt of a run: the prompt of the schedule,
+// followed by the knowledge events that triggered it
+func agentRunPrompt(schedule *types.AgentSchedule, run *types.AgentScheduleRun) string {
+	if len(run.Events) == 0 {
+		return schedule.Prompt
+	}
+	var b strings.Builder
+	b.WriteString(schedule.Prompt)
+	b.WriteString("\n\nThis task was triggered by the following knowledge events:\n")
+	for i, e := range run.Events {
+		if i == maxAgentRunPromptEvents {
+			fmt.Fprintf(&b, "- ... and %d more events\n", len(run.Events)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- %s: %q (knowledge ID: %s, knowledge base ID: %s)\n",
+			e.Type, e.Title, e.KnowledgeID, e.KnowledgeBaseID)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextAgentScheduleRunUsesTimezone(t *testing.T) {
+	schedule := &types.AgentSchedule{Cron: "0 9 * * 1", Timezone: "Asia/Shanghai", Enabled: true}
+	// 2025-08-11 is a Monday, 09:00 in Shanghai is 01:00 UTC
+	after := time.Date(2025, 8, 11, 2, 0, 0, 0, time.UTC)
+
+	next, err := nextAgentScheduleRun(schedule, after)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.True(t, next.Equal(time.Date(2025, 8, 18, 1, 0, 0, 0, time.UTC)), "got %s", next)
+
+	schedule.Enabled = false
+	next, err = nextAgentScheduleRun(schedule, after)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+
+	next, err = nextAgentScheduleRun(&types.AgentSchedule{Enabled: true}, after)
+	require.NoError(t, err)
+	assert.Nil(t, next, "event-only schedules have no cron run")
+}
+
+func TestAgentScheduleTriggeredBy(t *testing.T) {
+	schedule := &types.AgentSchedule{
+		Enabled:                 true,
+		TriggerEvents:           []types.WebhookEventType{types.WebhookEventKnowledgeParsed},
+		TriggerKnowledgeBaseIDs: []string{"kb-in", "kb-out"},
+		OutputKnowledgeBaseID:   "kb-out",
+	}
+
+	assert.True(t, schedule.TriggeredBy(types.WebhookEventKnowledgeParsed, "kb-in"))
+	assert.False(t, schedule.TriggeredBy(types.WebhookEventKnowledgeDeleted, "kb-in"))
+	assert.False(t, schedule.TriggeredBy(types.WebhookEventKnowledgeParsed, "kb-other"))
+	assert.False(t, schedule.TriggeredBy(types.WebhookEventKnowledgeParsed, "kb-out"),
+		"knowledge of the output knowledge base must not trigger its own schedule")
+
+	schedule.Enabled = false
+	assert.False(t, schedule.TriggeredBy(types.WebhookEventKnowledgeParsed, "kb-in"))
+}
+
+func TestAgentRunPromptListsEvents(t *testing.T) {
+	schedule := &types.AgentSchedule{Prompt: "Summarize the new documents"}
+	assert.Equal(t, schedule.Prompt, agentRunPrompt(schedule, &types.AgentScheduleRun{}))
+
+	run := &types.AgentScheduleRun{}
+	for i := 0; i < maxAgentRunPromptEvents+3; i++ {
+		run.Events = append(run.Events, types.AgentRunEvent{
+			Type:            types.WebhookEventKnowledgeParsed,
+			KnowledgeID:     fmt.Sprintf("k%d", i),
+			KnowledgeBaseID: "kb",
+			Title:           fmt.Sprintf("doc %d", i),
+		})
+	}
+	prompt := agentRunPrompt(schedule, run)
+	assert.True(t, strings.HasPrefix(prompt, schedule.Prompt))
+	assert.Contains(t, prompt, `"doc 0"`)
+	assert.NotContains(t, prompt, fmt.Sprintf(`"doc %d"`, maxAgentRunPromptEvents))
+	assert.True(t, strings.HasSuffix(prompt, "and 3 more events"))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/Tencent/WeKnora/internal/agent"
 	"github.com/Tencent/WeKnora/internal/agent/tools"
@@ -35,6 +36,10 @@ type agentService struct {
 	chunkService          interfaces.ChunkService
 	duckdb                *sql.DB
 	webSearchStateService interfaces.WebSearchStateService
+	toolApprovalService   interfaces.ToolApprovalService
+	dataSourceService     interfaces.DataSourceService
+	artifactService       interfaces.ArtifactService
+	communityService      interfaces.GraphCommunityService
 }
 
 // NewAgentService creates a new agent service
@@ -51,6 +56,10 @@ func NewAgentService(
 	webSearchService interfaces.WebSearchService,
 	duckdb *sql.DB,
 	webSearchStateService interfaces.WebSearchStateService,
+	toolApprovalService interfaces.ToolApprovalService,
+	dataSourceService interfaces.DataSourceService,
+	artifactService interfaces.ArtifactService,
+	communityService interfaces.GraphCommunityService,
 ) interfaces.AgentService {
 	return &agentService{
 		cfg:                   cfg,
@@ -65,6 +74,10 @@ func NewAgentService(
 		webSearchService:      webSearchService,
 		duckdb:                duckdb,
 		webSearchStateService: webSearchStateService,
+		toolApprovalService:   toolApprovalService,
+		dataSourceService:     dataSourceService,
+		artifactService:       artifactService,
+		communityService:      communityService,
 	}
 }
 
@@ -77,6 +90,7 @@ func (s *agentService) CreateAgentEngine(
 	eventBus *event.EventBus,
 	contextManager interfaces.ContextManager,
 	sessionID string,
+	subAgentRunner interfaces.SubAgentRunner,
 ) (interfaces.AgentEngine, error) {
 	logger.Infof(ctx, "Creating agent engine with custom EventBus")
 
@@ -105,6 +119,11 @@ func (s *agentService) CreateAgentEngine(
 	if tid, ok := ctx.Value(types.TenantIDContextKey).(uint64); ok {
 		tenantID = tid
 	}
+	// Resources read by the agent are watched per session so later turns see their updates
+	var resourceWatcher *mcp.ResourceWatcher
+	if s.mcpManager != nil && sessionID != "" {
+		resourceWatcher = s.mcpManager.ResourceWatcher(sessionID)
+	}
 	if tenantID > 0 && s.mcpServiceService != nil && s.mcpManager != nil {
 		// Check MCP selection mode from agent config
 		mcpMode := config.MCPSelectionMode
@@ -146,7 +165,7 @@ func (s *agentService) CreateAgentEngine(
 
 				// Register MCP tools
 				if len(enabledServices) > 0 {
-					if err := tools.RegisterMCPTools(ctx, toolRegistry, enabledServices, s.mcpManager); err != nil {
+					if err := tools.RegisterMCPTools(ctx, toolRegistry, enabledServices, s.mcpManager, resourceWatcher); err != nil {
 						logger.Warnf(ctx, "Failed to register MCP tools: %v", err)
 					} else {
 						logger.Infof(ctx, "Registered MCP tools from %d enabled services", len(enabledServices))
@@ -156,6 +175,19 @@ func (s *agentService) CreateAgentEngine(
 		}
 	}
 
+	// Register the tools querying the external data sources bound to the agent
+	if len(config.DataSources) > 0 && s.dataSourceService != nil {
+		s.registerDataSourceTools(ctx, toolRegistry, config.DataSources)
+	}
+
+	// Register the custom agents this agent can delegate sub-tasks to
+	if len(config.SubAgents) > 0 && subAgentRunner != nil {
+		tools.RegisterSubAgentTools(toolRegistry, config.SubAgents, subAgentRunner, eventBus,
+			config.DelegationPath, sessionID)
+		logger.Infof(ctx, "Registered %d sub-agent tools at delegation depth %d",
+			len(config.SubAgents), config.DelegationDepth())
+	}
+
 	// Get knowledge base detailed information for prompt
 	kbInfos, err := s.getKnowledgeBaseInfos(ctx, config.KnowledgeBases)
 	if err != nil {
@@ -183,6 +215,15 @@ func (s *agentService) CreateAgentEngine(
 	if config.UseCustomSystemPrompt {
 		systemPromptTemplate = config.ResolveSystemPrompt(config.WebSearchEnabled)
 	}
+	// An MCP prompt bound to the agent takes priority over the configured system prompt
+	if config.MCPPrompt != nil && config.MCPPrompt.ServiceID != "" && config.MCPPrompt.PromptName != "" {
+		if prompt, err := s.renderMCPPrompt(ctx, tenantID, config.MCPPrompt); err != nil {
+			logger.Warnf(ctx, "Failed to render MCP prompt %s, using configured system prompt: %v",
+				config.MCPPrompt.PromptName, err)
+		} else if prompt != "" {
+			systemPromptTemplate = prompt
+		}
+	}
 
 	// Create engine with provided EventBus and contextManager
 	engine := agent.NewAgentEngine(
@@ -195,11 +236,74 @@ func (s *agentService) CreateAgentEngine(
 		contextManager,
 		sessionID,
 		systemPromptTemplate,
+		resourceWatcher,
+		s.toolApprovalService,
+		s.artifactService,
 	)
 
 	return engine, nil
 }
 
+// registerDataSourceTools registers the schema and query tools for the enabled data sources of the agent
+func (s *agentService) registerDataSourceTools(ctx context.Context, registry *tools.ToolRegistry, ids []string) {
+	dataSources, err := s.dataSourceService.ListDataSourcesByIDs(ctx, ids)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list data sources: %v", err)
+		return
+	}
+	enabled := make([]*types.DataSource, 0, len(dataSources))
+	for _, ds := range dataSources {
+		if ds.Enabled {
+			enabled = append(enabled, ds)
+		}
+	}
+	tools.RegisterDataSourceTools(registry, enabled, s.dataSourceService)
+	logger.Infof(ctx, "Registered data source tools for %d of %d data sources", len(enabled), len(ids))
+}
+
+// renderMCPPrompt fetches the bound MCP prompt and joins its text messages into a system prompt template
+func (s *agentService) renderMCPPrompt(
+	ctx context.Context,
+	tenantID uint64,
+	binding *types.MCPPromptBinding,
+) (string, error) {
+	if tenantID == 0 || s.mcpServiceService == nil || s.mcpManager == nil {
+		return "", fmt.Errorf("MCP services are not available")
+	}
+
+	services, err := s.mcpServiceService.ListMCPServicesByIDs(ctx, tenantID, []string{binding.ServiceID})
+	if err != nil {
+		return "", fmt.Errorf("failed to get MCP service: %w", err)
+	}
+	if len(services) == 0 || services[0] == nil || !services[0].Enabled {
+		return "", fmt.Errorf("MCP service %s not found or disabled", binding.ServiceID)
+	}
+	service := services[0]
+
+	client, err := s.mcpManager.GetOrCreateClient(service)
+	if err != nil {
+		return "", err
+	}
+	if service.TransportType == types.MCPTransportStdio {
+		defer client.Disconnect()
+	}
+
+	result, err := client.GetPrompt(ctx, binding.PromptName, binding.Arguments)
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, 0, len(result.Messages))
+	for _, message := range result.Messages {
+		if message.Content.Text != "" {
+			parts = append(parts, message.Content.Text)
+		}
+	}
+	logger.Infof(ctx, "Using MCP prompt %s from service %s as system prompt (%d messages)",
+		binding.PromptName, service.Name, len(parts))
+	return strings.Join(parts, "\n\n"), nil
+}
+
 // registerTools registers tools based on the agent configuration
 func (s *agentService) registerTools(
 	ctx context.Context,
@@ -229,6 +333,7 @@ func (s *agentService) registerTools(
 			tools.ToolGrepChunks:          true,
 			tools.ToolListKnowledgeChunks: true,
 			tools.ToolQueryKnowledgeGraph: true,
+			tools.ToolGraphGlobalSearch:   true,
 			tools.ToolGetDocumentInfo:     true,
 			tools.ToolDatabaseQuery:       true,
 			tools.ToolDataAnalysis:        true,
@@ -282,6 +387,8 @@ func (s *agentService) registerTools(
 			toolToRegister = tools.NewListKnowledgeChunksTool(s.knowledgeService, s.chunkService)
 		case tools.ToolQueryKnowledgeGraph:
 			toolToRegister = tools.NewQueryKnowledgeGraphTool(s.knowledgeBaseService)
+		case tools.ToolGraphGlobalSearch:
+			toolToRegister = tools.NewGraphGlobalSearchTool(s.communityService, config.SearchTargets)
 		case tools.ToolGetDocumentInfo:
 			toolToRegister = tools.NewGetDocumentInfoTool(s.knowledgeService, s.chunkService)
 		case tools.ToolDatabaseQuery:
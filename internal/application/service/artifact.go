@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// artifactService implements the ArtifactService interface
+type artifactService struct {
+	repo        interfaces.ArtifactRepository
+	sessionRepo interfaces.SessionRepository
+}
+
+// NewArtifactService creates a new message artifact service
+func NewArtifactService(
+	repo interfaces.ArtifactRepository,
+	sessionRepo interfaces.SessionRepository,
+) interfaces.ArtifactService {
+	return &artifactService{repo: repo, sessionRepo: sessionRepo}
+}
+
+// SaveArtifact persists an artifact produced by a tool call
+func (s *artifactService) SaveArtifact(ctx context.Context, artifact *types.MessageArtifact) error {
+	if !types.IsValidArtifactType(artifact.Type) {
+		return fmt.Errorf("invalid artifact type %q", artifact.Type)
+	}
+	if artifact.TenantID == 0 {
+		artifact.TenantID, _ = ctx.Value(types.TenantIDContextKey).(uint64)
+	}
+	artifact.Size = int64(len(artifact.Content))
+	artifact.CreatedAt = time.Now()
+	if err := s.repo.CreateArtifact(ctx, artifact); err != nil {
+		return fmt.Errorf("failed to save artifact: %w", err)
+	}
+	logger.Infof(ctx, "Saved %s artifact %s of message %s", artifact.Type, artifact.ID, artifact.MessageID)
+	return nil
+}
+
+// ListMessageArtifacts lists the artifacts of a message of a session of the current tenant
+func (s *artifactService) ListMessageArtifacts(
+	ctx context.Context, sessionID string, messageID string,
+) ([]*types.MessageArtifact, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if _, err := s.sessionRepo.Get(ctx, tenantID, sessionID); err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, werrors.NewNotFoundError("Session not found")
+		}
+		return nil, err
+	}
+
+	artifacts, err := s.repo.ListMessageArtifacts(ctx, tenantID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*types.MessageArtifact, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		if artifact.SessionID == sessionID {
+			result = append(result, artifact)
+		}
+	}
+	return result, nil
+}
+
+// GetArtifact gets an artifact of a session of the current tenant
+func (s *artifactService) GetArtifact(
+	ctx context.Context, sessionID string, id string,
+) (*types.MessageArtifact, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	artifact, err := s.repo.GetArtifact(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if artifact == nil || artifact.SessionID != sessionID {
+		return nil, werrors.NewNotFoundError("Artifact not found")
+	}
+	return artifact, nil
+}
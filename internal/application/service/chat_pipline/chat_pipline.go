@@ -2,7 +2,9 @@ package chatpipline
 
 import (
 	"context"
+	"time"
 
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/types"
 )
 
@@ -72,7 +74,17 @@ func (e *EventManager) Trigger(ctx context.Context,
 	eventType types.EventType, chatManage *types.ChatManage,
 ) *PluginError {
 	if handler, ok := e.handlers[eventType]; ok {
-		return handler(ctx, eventType, chatManage)
+		start := time.Now()
+		err := handler(ctx, eventType, chatManage)
+		result := metrics.StatusSuccess
+		if err != nil {
+			result = metrics.StatusError
+			if err.ErrorType != "" {
+				result = err.ErrorType
+			}
+		}
+		metrics.ObservePipelineStage(string(eventType), result, time.Since(start))
+		return err
 	}
 	return nil
 }
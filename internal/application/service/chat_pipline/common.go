@@ -67,8 +67,12 @@ func prepareMessagesWithHistory(chatManage *types.ChatManage) []chat.Message {
 		chatMessages = append(chatMessages, chat.Message{Role: "assistant", Content: history.Answer})
 	}
 
-	// Add current user message
-	chatMessages = append(chatMessages, chat.Message{Role: "user", Content: chatManage.UserContent})
+	// Add current user message (with image attachments if any)
+	chatMessages = append(chatMessages, chat.Message{
+		Role:    "user",
+		Content: chatManage.UserContent,
+		Images:  chatManage.Images,
+	})
 
 	return chatMessages
 }
@@ -0,0 +1,94 @@
+package chatpipline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// maxCommunitySearchResults bounds the community summaries put into the context, they are much longer than chunks
+const maxCommunitySearchResults = 10
+
+// PluginSearchCommunity answers corpus-wide questions from the summaries of the graph communities
+// of the knowledge bases instead of their chunks
+type PluginSearchCommunity struct {
+	communityService interfaces.GraphCommunityService
+}
+
+// NewPluginSearchCommunity creates a new community search plugin
+func NewPluginSearchCommunity(
+	eventManager *EventManager,
+	communityService interfaces.GraphCommunityService,
+) *PluginSearchCommunity {
+	res := &PluginSearchCommunity{communityService: communityService}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the list of event types this plugin responds to
+func (p *PluginSearchCommunity) ActivationEvents() []types.EventType {
+	return []types.EventType{types.COMMUNITY_SEARCH}
+}
+
+// OnEvent puts the community summaries most relevant to the query into the merge result
+func (p *PluginSearchCommunity) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	kbIDs := chatManage.SearchTargets.GetAllKnowledgeBaseIDs()
+	topK := chatManage.RerankTopK
+	if topK <= 0 || topK > maxCommunitySearchResults {
+		topK = maxCommunitySearchResults
+	}
+	pipelineInfo(ctx, "SearchCommunity", "input", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"kb_cnt":     len(kbIDs),
+		"top_k":      topK,
+	})
+
+	communities, err := p.communityService.SearchCommunities(ctx, kbIDs, chatManage.RewriteQuery, topK)
+	if err != nil {
+		pipelineError(ctx, "SearchCommunity", "search", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return ErrSearch.WithError(err)
+	}
+	if len(communities) == 0 {
+		pipelineWarn(ctx, "SearchCommunity", "empty", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"reason":     "no communities, rebuild the graph communities of the knowledge bases",
+		})
+		return ErrSearchNothing
+	}
+
+	results := make([]*types.SearchResult, 0, len(communities))
+	for i, community := range communities {
+		results = append(results, community2SearchResult(community, i))
+	}
+	chatManage.SearchResult = results
+	chatManage.MergeResult = results
+	pipelineInfo(ctx, "SearchCommunity", "output", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"result_cnt": len(results),
+	})
+	return next()
+}
+
+// community2SearchResult converts a community to a search result referenced by the answer
+func community2SearchResult(community *types.GraphCommunity, seq int) *types.SearchResult {
+	content := fmt.Sprintf("%s\n%s\n实体: %s", community.Title, community.Summary,
+		strings.Join(community.Entities[:min(len(community.Entities), 20)], ", "))
+	return &types.SearchResult{
+		ID:             community.ID,
+		Content:        content,
+		KnowledgeTitle: community.Title,
+		Seq:            seq,
+		Score:          community.Score,
+		MatchType:      types.MatchTypeGraph,
+		ChunkType:      types.ChunkTypeGraphCommunity,
+		Metadata:       map[string]string{"knowledge_base_id": community.KnowledgeBaseID},
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -16,10 +17,11 @@ import (
 
 // Custom agent related errors
 var (
-	ErrAgentNotFound       = errors.New("agent not found")
-	ErrCannotModifyBuiltin = errors.New("cannot modify built-in agent basic info")
-	ErrCannotDeleteBuiltin = errors.New("cannot delete built-in agent")
-	ErrAgentNameRequired   = errors.New("agent name is required")
+	ErrAgentNotFound        = errors.New("agent not found")
+	ErrCannotModifyBuiltin  = errors.New("cannot modify built-in agent basic info")
+	ErrCannotDeleteBuiltin  = errors.New("cannot delete built-in agent")
+	ErrAgentNameRequired    = errors.New("agent name is required")
+	ErrAgentVersionNotFound = errors.New("agent version not found")
 )
 
 // customAgentService implements the CustomAgentService interface
@@ -38,6 +40,18 @@ func NewCustomAgentService(repo interfaces.CustomAgentRepository, cfg *config.Co
 
 // CreateAgent creates a new custom agent
 func (s *customAgentService) CreateAgent(ctx context.Context, agent *types.CustomAgent) (*types.CustomAgent, error) {
+	return s.createAgent(ctx, agent, types.AgentVersionNoteCreated)
+}
+
+// ImportAgent creates an agent from an export whose references were resolved
+func (s *customAgentService) ImportAgent(ctx context.Context, agent *types.CustomAgent, note string) (*types.CustomAgent, error) {
+	// Imported agents always get a new ID
+	agent.ID = ""
+	return s.createAgent(ctx, agent, note)
+}
+
+// createAgent creates a custom agent, recording its settings as version 1
+func (s *customAgentService) createAgent(ctx context.Context, agent *types.CustomAgent, note string) (*types.CustomAgent, error) {
 	// Validate required fields
 	if strings.TrimSpace(agent.Name) == "" {
 		return nil, ErrAgentNameRequired
@@ -73,7 +87,10 @@ func (s *customAgentService) CreateAgent(ctx context.Context, agent *types.Custo
 	logger.Infof(ctx, "Creating custom agent, ID: %s, tenant ID: %d, name: %s, agent_mode: %s",
 		agent.ID, agent.TenantID, agent.Name, agent.Config.AgentMode)
 
-	if err := s.repo.CreateAgent(ctx, agent); err != nil {
+	agent.Version = 0
+	version := agent.NewVersion(note, contextUserID(ctx))
+	agent.Version = version.Version
+	if err := s.repo.CreateAgentWithVersion(ctx, agent, version); err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"agent_id":  agent.ID,
 			"tenant_id": agent.TenantID,
@@ -199,6 +216,11 @@ func (s *customAgentService) ListAgents(ctx context.Context) ([]*types.CustomAge
 
 // UpdateAgent updates an agent's information
 func (s *customAgentService) UpdateAgent(ctx context.Context, agent *types.CustomAgent) (*types.CustomAgent, error) {
+	return s.updateAgent(ctx, agent, types.AgentVersionNoteUpdated)
+}
+
+// updateAgent updates an agent, recording a new version with the note if its settings changed
+func (s *customAgentService) updateAgent(ctx context.Context, agent *types.CustomAgent, note string) (*types.CustomAgent, error) {
 	if agent.ID == "" {
 		logger.Error(ctx, "Agent ID is empty")
 		return nil, errors.New("agent ID cannot be empty")
@@ -212,7 +234,7 @@ func (s *customAgentService) UpdateAgent(ctx context.Context, agent *types.Custo
 
 	// Handle built-in agents specially using registry
 	if types.IsBuiltinAgentID(agent.ID) {
-		return s.updateBuiltinAgent(ctx, agent, tenantID)
+		return s.updateBuiltinAgent(ctx, agent, tenantID, note)
 	}
 
 	// Get existing agent
@@ -235,6 +257,7 @@ func (s *customAgentService) UpdateAgent(ctx context.Context, agent *types.Custo
 	}
 
 	// Update fields
+	previous := *existingAgent
 	existingAgent.Name = agent.Name
 	existingAgent.Description = agent.Description
 	existingAgent.Avatar = agent.Avatar
@@ -246,7 +269,7 @@ func (s *customAgentService) UpdateAgent(ctx context.Context, agent *types.Custo
 
 	logger.Infof(ctx, "Updating custom agent, ID: %s, name: %s", agent.ID, agent.Name)
 
-	if err := s.repo.UpdateAgent(ctx, existingAgent); err != nil {
+	if err := s.saveAgent(ctx, existingAgent, &previous, note); err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"agent_id": agent.ID,
 		})
@@ -258,7 +281,7 @@ func (s *customAgentService) UpdateAgent(ctx context.Context, agent *types.Custo
 }
 
 // updateBuiltinAgent updates a built-in agent's configuration (but not basic info)
-func (s *customAgentService) updateBuiltinAgent(ctx context.Context, agent *types.CustomAgent, tenantID uint64) (*types.CustomAgent, error) {
+func (s *customAgentService) updateBuiltinAgent(ctx context.Context, agent *types.CustomAgent, tenantID uint64, note string) (*types.CustomAgent, error) {
 	// Get the default built-in agent from registry
 	defaultAgent := types.GetBuiltinAgent(agent.ID, tenantID)
 	if defaultAgent == nil {
@@ -273,13 +296,14 @@ func (s *customAgentService) updateBuiltinAgent(ctx context.Context, agent *type
 
 	if existingAgent != nil {
 		// Update existing record - only update config, keep basic info unchanged
+		previous := *existingAgent
 		existingAgent.Config = agent.Config
 		existingAgent.UpdatedAt = time.Now()
 		existingAgent.EnsureDefaults()
 
 		logger.Infof(ctx, "Updating built-in agent config, ID: %s", agent.ID)
 
-		if err := s.repo.UpdateAgent(ctx, existingAgent); err != nil {
+		if err := s.saveAgent(ctx, existingAgent, &previous, note); err != nil {
 			logger.ErrorWithFields(ctx, err, map[string]interface{}{
 				"agent_id": agent.ID,
 			})
@@ -306,7 +330,9 @@ func (s *customAgentService) updateBuiltinAgent(ctx context.Context, agent *type
 
 	logger.Infof(ctx, "Creating built-in agent config record, ID: %s, tenant ID: %d", agent.ID, tenantID)
 
-	if err := s.repo.CreateAgent(ctx, newAgent); err != nil {
+	version := newAgent.NewVersion(note, contextUserID(ctx))
+	newAgent.Version = version.Version
+	if err := s.repo.CreateAgentWithVersion(ctx, newAgent, version); err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"agent_id":  agent.ID,
 			"tenant_id": tenantID,
@@ -458,7 +484,9 @@ func (s *customAgentService) CopyAgent(ctx context.Context, id string) (*types.C
 
 	logger.Infof(ctx, "Copying agent, source ID: %s, new ID: %s", id, newAgent.ID)
 
-	if err := s.repo.CreateAgent(ctx, newAgent); err != nil {
+	version := newAgent.NewVersion(types.AgentVersionNoteCopied, contextUserID(ctx))
+	newAgent.Version = version.Version
+	if err := s.repo.CreateAgentWithVersion(ctx, newAgent, version); err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"source_agent_id": id,
 			"new_agent_id":    newAgent.ID,
@@ -469,3 +497,80 @@ func (s *customAgentService) CopyAgent(ctx context.Context, id string) (*types.C
 	logger.Infof(ctx, "Agent copied successfully, source ID: %s, new ID: %s", id, newAgent.ID)
 	return newAgent, nil
 }
+
+// saveAgent saves an updated agent, recording a new version if its settings differ from the previous ones
+func (s *customAgentService) saveAgent(ctx context.Context, agent, previous *types.CustomAgent, note string) error {
+	if previous.Version > 0 && agent.SameSettings(previous) {
+		return s.repo.UpdateAgent(ctx, agent)
+	}
+	version := agent.NewVersion(note, contextUserID(ctx))
+	agent.Version = version.Version
+	if err := s.repo.UpdateAgentWithVersion(ctx, agent, version); err != nil {
+		agent.Version = previous.Version
+		return err
+	}
+	logger.Infof(ctx, "Recorded version %d of agent %s: %s", version.Version, agent.ID, note)
+	return nil
+}
+
+// ListAgentVersions lists the versions of an agent's settings, newest first
+func (s *customAgentService) ListAgentVersions(ctx context.Context, id string) ([]*types.CustomAgentVersion, error) {
+	agent, err := s.GetAgentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListAgentVersions(ctx, agent.ID, agent.TenantID)
+}
+
+// GetAgentVersion gets a version of an agent's settings
+func (s *customAgentService) GetAgentVersion(ctx context.Context, id string, version int) (*types.CustomAgentVersion, error) {
+	tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64)
+	if !ok {
+		return nil, ErrInvalidTenantID
+	}
+	v, err := s.repo.GetAgentVersion(ctx, id, tenantID, version)
+	if err != nil {
+		if errors.Is(err, repository.ErrCustomAgentVersionNotFound) {
+			return nil, ErrAgentVersionNotFound
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+// DiffAgentVersions compares two versions of an agent's settings, version 0 compares against empty settings
+func (s *customAgentService) DiffAgentVersions(ctx context.Context, id string, from, to int) (*types.AgentVersionDiff, error) {
+	target, err := s.GetAgentVersion(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+	base := &types.CustomAgentVersion{AgentID: id}
+	if from > 0 {
+		if base, err = s.GetAgentVersion(ctx, id, from); err != nil {
+			return nil, err
+		}
+	}
+	return types.DiffAgentVersions(base, target)
+}
+
+// RollbackAgent restores the settings of a previous version, recording them as a new version
+func (s *customAgentService) RollbackAgent(ctx context.Context, id string, version int) (*types.CustomAgent, error) {
+	target, err := s.GetAgentVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Rolling back agent %s to version %d", id, version)
+	return s.updateAgent(ctx, &types.CustomAgent{
+		ID:          id,
+		Name:        target.Name,
+		Description: target.Description,
+		Avatar:      target.Avatar,
+		Config:      target.Config,
+	}, fmt.Sprintf("rolled back to version %d", version))
+}
+
+// contextUserID returns the ID of the user making the request, empty for API key requests
+func contextUserID(ctx context.Context) string {
+	userID, _ := ctx.Value(types.UserIDContextKey).(string)
+	return userID
+}
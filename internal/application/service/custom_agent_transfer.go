@@ -0,0 +1,387 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// agentTransferService implements the AgentTransferService interface
+type agentTransferService struct {
+	agentService      interfaces.CustomAgentService
+	modelService      interfaces.ModelService
+	kbService         interfaces.KnowledgeBaseService
+	mcpService        interfaces.MCPServiceService
+	dataSourceService interfaces.DataSourceService
+}
+
+// NewAgentTransferService creates a new agent transfer service
+func NewAgentTransferService(
+	agentService interfaces.CustomAgentService,
+	modelService interfaces.ModelService,
+	kbService interfaces.KnowledgeBaseService,
+	mcpService interfaces.MCPServiceService,
+	dataSourceService interfaces.DataSourceService,
+) interfaces.AgentTransferService {
+	return &agentTransferService{
+		agentService:      agentService,
+		modelService:      modelService,
+		kbService:         kbService,
+		mcpService:        mcpService,
+		dataSourceService: dataSourceService,
+	}
+}
+
+// agentResource is a resource of the current tenant an agent config can refer to
+type agentResource struct {
+	ID   string
+	Name string
+	Type string
+}
+
+// ExportAgent exports the current settings of an agent together with the names of the resources it references
+func (s *agentTransferService) ExportAgent(ctx context.Context, id string) (*types.AgentExport, error) {
+	agent, err := s.agentService.GetAgentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	references := agentConfigReferences(&agent.Config)
+	resources, err := s.loadResources(ctx, references)
+	if err != nil {
+		return nil, err
+	}
+	for i := range references {
+		for _, resource := range resources[references[i].Kind] {
+			if resource.ID == references[i].ID {
+				references[i].Name = resource.Name
+				break
+			}
+		}
+	}
+	logger.Infof(ctx, "Exporting agent %s version %d with %d references", agent.ID, agent.Version, len(references))
+	return &types.AgentExport{
+		FormatVersion: types.AgentExportFormatVersion,
+		ExportedAt:    time.Now(),
+		AgentVersion:  agent.Version,
+		Name:          agent.Name,
+		Description:   agent.Description,
+		Avatar:        agent.Avatar,
+		Config:        agent.Config,
+		References:    references,
+	}, nil
+}
+
+// PlanImport resolves the references of an export in the current tenant without importing it
+func (s *agentTransferService) PlanImport(ctx context.Context, export *types.AgentExport,
+	mappings []types.AgentReferenceMapping,
+) (*types.AgentImportPlan, error) {
+	// 以配置中实际使用的 ID 为准，名称取自导出文件的引用列表
+	references := agentConfigReferences(&export.Config)
+	for i := range references {
+		for _, exported := range export.References {
+			if exported.Kind == references[i].Kind && exported.ID == references[i].ID {
+				references[i].Name = exported.Name
+				break
+			}
+		}
+	}
+	resources, err := s.loadResources(ctx, references)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &types.AgentImportPlan{
+		Name:        export.Name,
+		Description: export.Description,
+		AgentMode:   export.Config.AgentMode,
+		References:  make([]types.AgentReferenceResolution, 0, len(references)),
+	}
+	for _, ref := range references {
+		targetID := ""
+		for _, mapping := range mappings {
+			if mapping.Kind == ref.Kind && mapping.ID == ref.ID {
+				targetID = mapping.TargetID
+				break
+			}
+		}
+		resolution, err := resolveAgentReference(ref, resources[ref.Kind], targetID)
+		if err != nil {
+			return nil, err
+		}
+		if !resolution.Resolved() {
+			plan.Unresolved++
+		}
+		plan.References = append(plan.References, resolution)
+	}
+	return plan, nil
+}
+
+// ImportAgent creates an agent from an export
+func (s *agentTransferService) ImportAgent(ctx context.Context, export *types.AgentExport,
+	mappings []types.AgentReferenceMapping, name string, skipUnresolved bool,
+) (*types.CustomAgent, error) {
+	plan, err := s.PlanImport(ctx, export, mappings)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(plan.References))
+	var unresolved []string
+	for _, resolution := range plan.References {
+		if resolution.Resolved() {
+			targets[agentReferenceKey(resolution.Kind, resolution.ID)] = resolution.TargetID
+			continue
+		}
+		unresolved = append(unresolved, fmt.Sprintf("%s %s (%s, %s)",
+			resolution.Kind, resolution.ID, resolution.Name, resolution.Status))
+	}
+	if len(unresolved) > 0 && !skipUnresolved {
+		return nil, werrors.NewBadRequestError("Unresolved agent references, map them or skip them").
+			WithDetails(strings.Join(unresolved, "; "))
+	}
+
+	config := export.Config
+	rewriteAgentConfigReferences(&config, func(kind types.AgentReferenceKind, id string) string {
+		return targets[agentReferenceKey(kind, id)]
+	})
+	if err := config.ToolApprovalPolicy.Validate(); err != nil {
+		return nil, werrors.NewBadRequestError(err.Error())
+	}
+	if err := config.Budget.Validate(); err != nil {
+		return nil, werrors.NewBadRequestError(err.Error())
+	}
+	if err := config.ValidateSubAgents(""); err != nil {
+		return nil, werrors.NewBadRequestError(err.Error())
+	}
+
+	agent := &types.CustomAgent{
+		Name:        export.Name,
+		Description: export.Description,
+		Avatar:      export.Avatar,
+		Config:      config,
+	}
+	if name != "" {
+		agent.Name = name
+	}
+	note := fmt.Sprintf("imported from %q version %d", export.Name, export.AgentVersion)
+	if len(unresolved) > 0 {
+		note += fmt.Sprintf(", %d unresolved references removed", len(unresolved))
+	}
+	imported, err := s.agentService.ImportAgent(ctx, agent, note)
+	if err != nil {
+		if errors.Is(err, ErrAgentNameRequired) {
+			return nil, werrors.NewBadRequestError(err.Error())
+		}
+		return nil, err
+	}
+	return imported, nil
+}
+
+// loadResources lists the resources of the current tenant of the kinds used by the references
+func (s *agentTransferService) loadResources(ctx context.Context,
+	references []types.AgentReference,
+) (map[types.AgentReferenceKind][]agentResource, error) {
+	resources := make(map[types.AgentReferenceKind][]agentResource)
+	for _, ref := range references {
+		if _, loaded := resources[ref.Kind]; loaded {
+			continue
+		}
+		list, err := s.listResources(ctx, ref.Kind)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s resources: %w", ref.Kind, err)
+		}
+		resources[ref.Kind] = list
+	}
+	return resources, nil
+}
+
+func (s *agentTransferService) listResources(ctx context.Context,
+	kind types.AgentReferenceKind,
+) ([]agentResource, error) {
+	list := []agentResource{}
+	switch kind {
+	case types.AgentReferenceModel:
+		models, err := s.modelService.ListModels(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, model := range models {
+			list = append(list, agentResource{ID: model.ID, Name: model.Name, Type: string(model.Type)})
+		}
+	case types.AgentReferenceKnowledgeBase:
+		kbs, err := s.kbService.ListKnowledgeBases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, kb := range kbs {
+			list = append(list, agentResource{ID: kb.ID, Name: kb.Name})
+		}
+	case types.AgentReferenceMCPService:
+		tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64)
+		if !ok {
+			return nil, ErrInvalidTenantID
+		}
+		services, err := s.mcpService.ListMCPServices(ctx, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range services {
+			list = append(list, agentResource{ID: service.ID, Name: service.Name})
+		}
+	case types.AgentReferenceSubAgent:
+		agents, err := s.agentService.ListAgents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, agent := range agents {
+			list = append(list, agentResource{ID: agent.ID, Name: agent.Name})
+		}
+	case types.AgentReferenceDataSource:
+		dataSources, err := s.dataSourceService.ListDataSources(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, dataSource := range dataSources {
+			list = append(list, agentResource{ID: dataSource.ID, Name: dataSource.Name})
+		}
+	}
+	return list, nil
+}
+
+// resolveAgentReference resolves a reference to a resource of the current tenant: by the mapped target if given,
+// otherwise by the same ID, otherwise by the only resource with the same name. Models must also have the same type.
+func resolveAgentReference(ref types.AgentReference, resources []agentResource,
+	targetID string,
+) (types.AgentReferenceResolution, error) {
+	resolution := types.AgentReferenceResolution{AgentReference: ref}
+	if targetID != "" {
+		for _, resource := range resources {
+			if resource.ID == targetID && (ref.Type == "" || resource.Type == ref.Type) {
+				resolution.Status = types.AgentReferenceMapped
+				resolution.TargetID = targetID
+				return resolution, nil
+			}
+		}
+		return resolution, werrors.NewBadRequestError(
+			fmt.Sprintf("Mapping target %s of %s %s not found", targetID, ref.Kind, ref.ID))
+	}
+
+	var named []types.AgentReferenceCandidate
+	for _, resource := range resources {
+		if ref.Type != "" && resource.Type != ref.Type {
+			continue
+		}
+		if resource.ID == ref.ID {
+			resolution.Status = types.AgentReferenceMatched
+			resolution.TargetID = resource.ID
+			return resolution, nil
+		}
+		if ref.Name != "" && resource.Name == ref.Name {
+			named = append(named, types.AgentReferenceCandidate{ID: resource.ID, Name: resource.Name})
+		}
+	}
+	switch len(named) {
+	case 0:
+		resolution.Status = types.AgentReferenceMissing
+	case 1:
+		resolution.Status = types.AgentReferenceMatched
+		resolution.TargetID = named[0].ID
+	default:
+		resolution.Status = types.AgentReferenceAmbiguous
+		resolution.Candidates = named
+	}
+	return resolution, nil
+}
+
+// agentConfigReferences lists the resources an agent config refers to, built-in sub-agents excluded
+func agentConfigReferences(config *types.CustomAgentConfig) []types.AgentReference {
+	references := []types.AgentReference{}
+	add := func(kind types.AgentReferenceKind, id string, modelType types.ModelType) {
+		if id == "" {
+			return
+		}
+		for _, ref := range references {
+			if ref.Kind == kind && ref.ID == id {
+				return
+			}
+		}
+		references = append(references, types.AgentReference{Kind: kind, ID: id, Type: string(modelType)})
+	}
+	add(types.AgentReferenceModel, config.ModelID, types.ModelTypeKnowledgeQA)
+	add(types.AgentReferenceModel, config.RerankModelID, types.ModelTypeRerank)
+	add(types.AgentReferenceModel, config.MemoryEmbeddingModelID, types.ModelTypeEmbedding)
+	for _, id := range config.KnowledgeBases {
+		add(types.AgentReferenceKnowledgeBase, id, "")
+	}
+	for _, id := range config.MCPServices {
+		add(types.AgentReferenceMCPService, id, "")
+	}
+	if config.MCPPrompt != nil {
+		add(types.AgentReferenceMCPService, config.MCPPrompt.ServiceID, "")
+	}
+	for _, id := range config.SubAgents {
+		if !types.IsBuiltinAgentID(id) {
+			add(types.AgentReferenceSubAgent, id, "")
+		}
+	}
+	for _, id := range config.DataSources {
+		add(types.AgentReferenceDataSource, id, "")
+	}
+	return references
+}
+
+// rewriteAgentConfigReferences replaces the IDs referenced by a config with the resolved ones.
+// Unresolved IDs are removed, and an MCP prompt whose service is unresolved is dropped.
+func rewriteAgentConfigReferences(config *types.CustomAgentConfig,
+	resolve func(kind types.AgentReferenceKind, id string) string,
+) {
+	rewriteList := func(kind types.AgentReferenceKind, ids []string) []string {
+		if ids == nil {
+			return nil
+		}
+		rewritten := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if kind == types.AgentReferenceSubAgent && types.IsBuiltinAgentID(id) {
+				rewritten = append(rewritten, id)
+				continue
+			}
+			if target := resolve(kind, id); target != "" && !slices.Contains(rewritten, target) {
+				rewritten = append(rewritten, target)
+			}
+		}
+		return rewritten
+	}
+	rewriteID := func(kind types.AgentReferenceKind, id string) string {
+		if id == "" {
+			return ""
+		}
+		return resolve(kind, id)
+	}
+
+	config.ModelID = rewriteID(types.AgentReferenceModel, config.ModelID)
+	config.RerankModelID = rewriteID(types.AgentReferenceModel, config.RerankModelID)
+	config.MemoryEmbeddingModelID = rewriteID(types.AgentReferenceModel, config.MemoryEmbeddingModelID)
+	config.KnowledgeBases = rewriteList(types.AgentReferenceKnowledgeBase, config.KnowledgeBases)
+	config.MCPServices = rewriteList(types.AgentReferenceMCPService, config.MCPServices)
+	if config.MCPPrompt != nil {
+		prompt := *config.MCPPrompt
+		prompt.ServiceID = rewriteID(types.AgentReferenceMCPService, prompt.ServiceID)
+		config.MCPPrompt = &prompt
+		if prompt.ServiceID == "" {
+			config.MCPPrompt = nil
+		}
+	}
+	config.SubAgents = rewriteList(types.AgentReferenceSubAgent, config.SubAgents)
+	config.DataSources = rewriteList(types.AgentReferenceDataSource, config.DataSources)
+}
+
+func agentReferenceKey(kind types.AgentReferenceKind, id string) string {
+	return string(kind) + "/" + id
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveAgentReference(t *testing.T) {
+	models := []agentResource{
+		{ID: "m1", Name: "qwen-plus", Type: string(types.ModelTypeKnowledgeQA)},
+		{ID: "m2", Name: "qwen-plus", Type: string(types.ModelTypeRerank)},
+		{ID: "m3", Name: "bge", Type: string(types.ModelTypeRerank)},
+	}
+	ref := types.AgentReference{Kind: types.AgentReferenceModel, ID: "other", Name: "qwen-plus",
+		Type: string(types.ModelTypeKnowledgeQA)}
+
+	resolution, err := resolveAgentReference(ref, models, "")
+	require.NoError(t, err)
+	assert.Equal(t, types.AgentReferenceMatched, resolution.Status)
+	assert.Equal(t, "m1", resolution.TargetID, "only the model of the same type matches by name")
+
+	_, err = resolveAgentReference(ref, models, "m3")
+	assert.Error(t, err, "a model of another type cannot be the mapping target")
+
+	kbs := []agentResource{{ID: "kb1", Name: "docs"}, {ID: "kb2", Name: "docs"}}
+	kbRef := types.AgentReference{Kind: types.AgentReferenceKnowledgeBase, ID: "kb9", Name: "docs"}
+	resolution, err = resolveAgentReference(kbRef, kbs, "")
+	require.NoError(t, err)
+	assert.Equal(t, types.AgentReferenceAmbiguous, resolution.Status)
+	assert.Len(t, resolution.Candidates, 2)
+	assert.False(t, resolution.Resolved())
+
+	kbRef.ID = "kb2"
+	resolution, err = resolveAgentReference(kbRef, kbs, "")
+	require.NoError(t, err)
+	assert.Equal(t, "kb2", resolution.TargetID, "the same ID wins over name matches")
+
+	resolution, err = resolveAgentReference(kbRef, kbs, "kb1")
+	require.NoError(t, err)
+	assert.Equal(t, types.AgentReferenceMapped, resolution.Status)
+	assert.Equal(t, "kb1", resolution.TargetID)
+
+	resolution, err = resolveAgentReference(types.AgentReference{Kind: types.AgentReferenceKnowledgeBase, ID: "x",
+		Name: "missing"}, kbs, "")
+	require.NoError(t, err)
+	assert.Equal(t, types.AgentReferenceMissing, resolution.Status)
+}
+
+func TestRewriteAgentConfigReferences(t *testing.T) {
+	config := types.CustomAgentConfig{
+		ModelID:        "m1",
+		RerankModelID:  "m2",
+		KnowledgeBases: []string{"kb1", "kb2"},
+		MCPServices:    []string{"mcp1"},
+		MCPPrompt:      &types.MCPPromptBinding{ServiceID: "mcp1", PromptName: "review"},
+		SubAgents:      []string{types.BuiltinQuickAnswerID, "a1"},
+	}
+	references := agentConfigReferences(&config)
+	assert.Len(t, references, 6, "duplicates and built-in sub-agents are not listed")
+
+	targets := map[string]string{
+		agentReferenceKey(types.AgentReferenceModel, "m1"):          "M1",
+		agentReferenceKey(types.AgentReferenceKnowledgeBase, "kb2"): "KB2",
+	}
+	original := config
+	rewriteAgentConfigReferences(&config, func(kind types.AgentReferenceKind, id string) string {
+		return targets[agentReferenceKey(kind, id)]
+	})
+
+	assert.Equal(t, "M1", config.ModelID)
+	assert.Empty(t, config.RerankModelID)
+	assert.Equal(t, []string{"KB2"}, config.KnowledgeBases)
+	assert.Empty(t, config.MCPServices)
+	assert.Nil(t, config.MCPPrompt)
+	assert.Equal(t, []string{types.BuiltinQuickAnswerID}, config.SubAgents)
+	assert.Equal(t, []string{"kb1", "kb2"}, original.KnowledgeBases, "the exported config is not modified")
+	assert.Equal(t, "mcp1", original.MCPPrompt.ServiceID)
+}
+
+func TestAgentExportRoundTrip(t *testing.T) {
+	export := &types.AgentExport{
+		FormatVersion: types.AgentExportFormatVersion,
+		AgentVersion:  3,
+		Name:          "reviewer",
+		Config: types.CustomAgentConfig{
+			AgentMode:      types.AgentModeSmartReasoning,
+			KnowledgeBases: []string{"kb1"},
+			Budget:         &types.AgentBudget{MaxToolCalls: 5},
+		},
+		References: []types.AgentReference{{Kind: types.AgentReferenceKnowledgeBase, ID: "kb1", Name: "docs"}},
+	}
+	for _, format := range []string{"yaml", "json"} {
+		data, err := types.EncodeAgentExport(export, format)
+		require.NoError(t, err, format)
+		decoded, err := types.DecodeAgentExport(data)
+		require.NoError(t, err, format)
+		assert.Equal(t, export.Name, decoded.Name, format)
+		assert.Equal(t, 3, decoded.AgentVersion, format)
+		assert.Equal(t, export.Config.KnowledgeBases, decoded.Config.KnowledgeBases, format)
+		require.NotNil(t, decoded.Config.Budget, format)
+		assert.Equal(t, 5, decoded.Config.Budget.MaxToolCalls, format)
+		assert.Equal(t, export.References, decoded.References, format)
+	}
+
+	_, err := types.DecodeAgentExport([]byte("format_version: 99\nname: x\n"))
+	assert.Error(t, err)
+}
+
+func TestDiffAgentVersions(t *testing.T) {
+	agent := &types.CustomAgent{ID: "a1", Name: "reviewer", Version: 1, Config: types.CustomAgentConfig{
+		Temperature:    0.7,
+		KnowledgeBases: []string{},
+	}}
+	updated := *agent
+	updated.Config.KnowledgeBases = nil
+	assert.True(t, agent.SameSettings(&updated), "empty and unset lists are the same setting")
+
+	updated.Config.Temperature = 0.3
+	updated.Config.KnowledgeBases = []string{"kb1"}
+	assert.False(t, agent.SameSettings(&updated))
+
+	from := agent.NewVersion(types.AgentVersionNoteCreated, "")
+	updated.Version = from.Version
+	to := updated.NewVersion(types.AgentVersionNoteUpdated, "")
+	diff, err := types.DiffAgentVersions(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, 2, diff.FromVersion)
+	assert.Equal(t, 3, diff.ToVersion)
+	require.Len(t, diff.Changes, 2)
+	assert.Equal(t, "config.knowledge_bases", diff.Changes[0].Path)
+	assert.Nil(t, diff.Changes[0].From)
+	assert.Equal(t, "config.temperature", diff.Changes[1].Path)
+	assert.Equal(t, 0.7, diff.Changes[1].From)
+	assert.Equal(t, 0.3, diff.Changes[1].To)
+}
@@ -0,0 +1,527 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	defaultDataSourceQueryTimeout = 30 * time.Second
+	defaultDataSourceMaxOpenConns = 5
+	dataSourceDialTimeout         = 10 * time.Second
+)
+
+// dataSourceTablePattern matches the entries of table allow-lists: table or schema.table
+var dataSourceTablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// dataSourcePool is the connection pool of a data source, reopened when the data source changes
+type dataSourcePool struct {
+	db        *sql.DB
+	updatedAt time.Time
+}
+
+// dataSourceService implements the DataSourceService interface
+type dataSourceService struct {
+	repo         interfaces.DataSourceRepository
+	sqliteDir    string
+	queryTimeout time.Duration
+	maxOpenConns int
+	// allowedNetworks 允许连接的内网地址段，其余回环、内网及链路本地地址均被拒绝
+	allowedNetworks []*net.IPNet
+	dialer          *net.Dialer
+
+	mu    sync.Mutex
+	pools map[string]*dataSourcePool
+}
+
+// NewDataSourceService creates a new data source service
+func NewDataSourceService(repo interfaces.DataSourceRepository, cfg *config.Config) interfaces.DataSourceService {
+	s := &dataSourceService{
+		repo:         repo,
+		queryTimeout: defaultDataSourceQueryTimeout,
+		maxOpenConns: defaultDataSourceMaxOpenConns,
+		pools:        make(map[string]*dataSourcePool),
+	}
+	if cfg.DataSource != nil {
+		s.sqliteDir = cfg.DataSource.SQLiteDir
+		if cfg.DataSource.QueryTimeout > 0 {
+			s.queryTimeout = time.Duration(cfg.DataSource.QueryTimeout) * time.Second
+		}
+		if cfg.DataSource.MaxOpenConns > 0 {
+			s.maxOpenConns = cfg.DataSource.MaxOpenConns
+		}
+		for _, network := range cfg.DataSource.AllowedPrivateNetworks {
+			ipNet, err := parseNetwork(network)
+			if err != nil {
+				logger.Warnf(context.Background(), "Ignoring invalid data source allowed network %q: %v", network, err)
+				continue
+			}
+			s.allowedNetworks = append(s.allowedNetworks, ipNet)
+		}
+	}
+	s.dialer = &net.Dialer{Timeout: dataSourceDialTimeout, Control: s.denyPrivateNetwork}
+	return s
+}
+
+// parseNetwork parses a CIDR or a single IP address into a network
+func parseNetwork(network string) (*net.IPNet, error) {
+	network = strings.TrimSpace(network)
+	if !strings.Contains(network, "/") {
+		ip := net.ParseIP(network)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address")
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(network)
+	return ipNet, err
+}
+
+// denyPrivateNetwork rejects connections to loopback, private and link-local addresses
+// outside of the configured allowed networks
+func (s *dataSourceService) denyPrivateNetwork(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("data source address %s is not allowed", host)
+	}
+	if !utils.IsPrivateIP(ip) {
+		return nil
+	}
+	for _, allowed := range s.allowedNetworks {
+		if allowed.Contains(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("data source address %s is not allowed: add it to data_source.allowed_private_networks "+
+		"to connect to private networks", host)
+}
+
+// CreateDataSource creates a data source
+func (s *dataSourceService) CreateDataSource(ctx context.Context, dataSource *types.DataSource) error {
+	dataSource.ID = ""
+	dataSource.TenantID = ctx.Value(types.TenantIDContextKey).(uint64)
+	if err := s.validateDataSource(dataSource); err != nil {
+		return err
+	}
+
+	if err := s.repo.CreateDataSource(ctx, dataSource); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"name": dataSource.Name})
+		return err
+	}
+	logger.Infof(ctx, "Data source created: %s, type: %s, tables: %v",
+		dataSource.ID, dataSource.Type, dataSource.AllowedTables)
+	return nil
+}
+
+// GetDataSource gets a data source of the current tenant
+func (s *dataSourceService) GetDataSource(ctx context.Context, id string) (*types.DataSource, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	dataSource, err := s.repo.GetDataSource(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if dataSource == nil {
+		return nil, werrors.NewNotFoundError("Data source not found")
+	}
+	return dataSource, nil
+}
+
+// ListDataSources lists the data sources of the current tenant
+func (s *dataSourceService) ListDataSources(ctx context.Context) ([]*types.DataSource, error) {
+	return s.repo.ListDataSources(ctx, ctx.Value(types.TenantIDContextKey).(uint64))
+}
+
+// ListDataSourcesByIDs lists the data sources of the current tenant with the given IDs
+func (s *dataSourceService) ListDataSourcesByIDs(ctx context.Context, ids []string) ([]*types.DataSource, error) {
+	return s.repo.ListDataSourcesByIDs(ctx, ctx.Value(types.TenantIDContextKey).(uint64), ids)
+}
+
+// UpdateDataSource updates a data source, an empty password keeps the current one
+func (s *dataSourceService) UpdateDataSource(
+	ctx context.Context, dataSource *types.DataSource,
+) (*types.DataSource, error) {
+	existing, err := s.GetDataSource(ctx, dataSource.ID)
+	if err != nil {
+		return nil, err
+	}
+	if dataSource.Connection.Password == "" {
+		dataSource.Connection.Password = existing.Connection.Password
+	}
+	dataSource.TenantID = existing.TenantID
+	if err := s.validateDataSource(dataSource); err != nil {
+		return nil, err
+	}
+
+	existing.Name = dataSource.Name
+	existing.Description = dataSource.Description
+	existing.Type = dataSource.Type
+	existing.Connection = dataSource.Connection
+	existing.AllowedTables = dataSource.AllowedTables
+	existing.RowLimit = dataSource.RowLimit
+	existing.Enabled = dataSource.Enabled
+	existing.UpdatedAt = time.Now()
+	if err := s.repo.UpdateDataSource(ctx, existing); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"data_source_id": existing.ID})
+		return nil, err
+	}
+	s.closePool(existing.ID)
+	return existing, nil
+}
+
+// DeleteDataSource deletes a data source of the current tenant
+func (s *dataSourceService) DeleteDataSource(ctx context.Context, id string) error {
+	if _, err := s.GetDataSource(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteDataSource(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id); err != nil {
+		return err
+	}
+	s.closePool(id)
+	return nil
+}
+
+// DescribeDataSource connects to a data source and describes the columns of its allowed tables.
+// Tables that do not exist are returned without columns
+func (s *dataSourceService) DescribeDataSource(ctx context.Context, id string) ([]*types.DataSourceTable, error) {
+	dataSource, err := s.GetDataSource(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	db, err := s.getPool(dataSource)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var query string
+	switch dataSource.Type {
+	case types.DataSourcePostgres:
+		query = `SELECT column_name, data_type, is_nullable = 'YES' FROM information_schema.columns
+			WHERE table_schema = COALESCE($1, current_schema()) AND table_name = $2 ORDER BY ordinal_position`
+	case types.DataSourceMySQL:
+		query = `SELECT column_name, column_type, is_nullable = 'YES' FROM information_schema.columns
+			WHERE table_schema = COALESCE(?, DATABASE()) AND table_name = ? ORDER BY ordinal_position`
+	case types.DataSourceSQLite:
+		query = `SELECT name, type, "notnull" = 0 FROM pragma_table_info(?2, COALESCE(?1, 'main'))`
+	}
+
+	tables := make([]*types.DataSourceTable, 0, len(dataSource.AllowedTables))
+	for _, name := range dataSource.AllowedTables {
+		var schema sql.NullString
+		table := name
+		if i := strings.Index(name, "."); i > 0 {
+			schema = sql.NullString{String: name[:i], Valid: true}
+			table = name[i+1:]
+		}
+
+		rows, err := db.QueryContext(ctx, query, schema, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe table %s: %w", name, err)
+		}
+		described := &types.DataSourceTable{Name: name, Columns: make([]*types.DataSourceColumn, 0)}
+		for rows.Next() {
+			var column types.DataSourceColumn
+			if err := rows.Scan(&column.Name, &column.Type, &column.Nullable); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to describe table %s: %w", name, err)
+			}
+			described.Columns = append(described.Columns, &column)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe table %s: %w", name, err)
+		}
+		tables = append(tables, described)
+	}
+	return tables, nil
+}
+
+// QueryDataSource validates and runs a read-only SELECT on a data source
+func (s *dataSourceService) QueryDataSource(
+	ctx context.Context, id string, query string,
+) (*types.DataSourceQueryResult, error) {
+	dataSource, err := s.GetDataSource(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !dataSource.Enabled {
+		return nil, werrors.NewBadRequestError("Data source is disabled")
+	}
+
+	// 多取一行用于判断结果是否被截断
+	rowLimit := dataSource.EffectiveRowLimit()
+	validated, err := tools.NewDataSourceSQLValidator(dataSource.AllowedTables, rowLimit+1).
+		ValidateAndSecure(query)
+	if err != nil {
+		return nil, werrors.NewBadRequestError(fmt.Sprintf("SQL validation failed: %v", err))
+	}
+
+	db, err := s.getPool(dataSource)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to data source: %w", err)
+	}
+	// 查询只读，始终回滚
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, validated)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &types.DataSourceQueryResult{
+		Columns: columns,
+		Rows:    make([]map[string]interface{}, 0),
+		Query:   validated,
+	}
+	for rows.Next() {
+		if len(result.Rows) == rowLimit {
+			result.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[column] = string(b)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	result.RowCount = len(result.Rows)
+
+	logger.Infof(ctx, "Queried data source %s: %d rows, truncated: %v", dataSource.ID, result.RowCount, result.Truncated)
+	return result, nil
+}
+
+// validateDataSource checks a data source and normalizes its allowed tables
+func (s *dataSourceService) validateDataSource(dataSource *types.DataSource) error {
+	if strings.TrimSpace(dataSource.Name) == "" {
+		return werrors.NewBadRequestError("Data source name is required")
+	}
+	if !types.IsValidDataSourceType(dataSource.Type) {
+		return werrors.NewBadRequestError("Unsupported data source type: " + string(dataSource.Type))
+	}
+	if dataSource.RowLimit < 0 || dataSource.RowLimit > types.MaxDataSourceRowLimit {
+		return werrors.NewBadRequestError(
+			fmt.Sprintf("Row limit must be between 0 and %d", types.MaxDataSourceRowLimit))
+	}
+
+	if len(dataSource.AllowedTables) == 0 {
+		return werrors.NewBadRequestError("At least one allowed table is required")
+	}
+	tables := make([]string, 0, len(dataSource.AllowedTables))
+	seen := make(map[string]bool, len(dataSource.AllowedTables))
+	for _, table := range dataSource.AllowedTables {
+		table = strings.ToLower(strings.TrimSpace(table))
+		if !dataSourceTablePattern.MatchString(table) {
+			return werrors.NewBadRequestError("Invalid table name: " + table)
+		}
+		if !seen[table] {
+			seen[table] = true
+			tables = append(tables, table)
+		}
+	}
+	dataSource.AllowedTables = tables
+
+	conn := dataSource.Connection
+	if dataSource.Type == types.DataSourceSQLite {
+		_, err := s.sqlitePath(dataSource.TenantID, conn.Path)
+		return err
+	}
+	if conn.Host == "" || conn.Database == "" {
+		return werrors.NewBadRequestError("Host and database are required")
+	}
+	if conn.Port < 0 || conn.Port > 65535 {
+		return werrors.NewBadRequestError("Invalid port")
+	}
+	return nil
+}
+
+// sqlitePath resolves the file of a SQLite data source inside the tenant's directory <sqlite_dir>/<tenant_id>,
+// following symlinks so that they cannot lead to files of other tenants
+func (s *dataSourceService) sqlitePath(tenantID uint64, path string) (string, error) {
+	if s.sqliteDir == "" {
+		return "", werrors.NewBadRequestError("SQLite data sources are not enabled")
+	}
+	path = filepath.Clean(strings.TrimSpace(path))
+	if path == "." || filepath.IsAbs(path) || !isLocalPath(path) || strings.ContainsAny(path, "?#") {
+		return "", werrors.NewBadRequestError("SQLite path must be a file name relative to the SQLite directory")
+	}
+	tenantDir, err := filepath.EvalSymlinks(filepath.Join(s.sqliteDir, strconv.FormatUint(tenantID, 10)))
+	if err != nil {
+		return "", werrors.NewBadRequestError("SQLite directory of the tenant does not exist")
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(tenantDir, path))
+	if err != nil {
+		return "", werrors.NewBadRequestError("SQLite file not found: " + path)
+	}
+	rel, err := filepath.Rel(tenantDir, resolved)
+	if err != nil || !isLocalPath(rel) || strings.ContainsAny(resolved, "?#") {
+		return "", werrors.NewBadRequestError("SQLite path must stay inside the SQLite directory of the tenant")
+	}
+	return resolved, nil
+}
+
+// isLocalPath reports whether a cleaned relative path stays inside its base directory
+func isLocalPath(path string) bool {
+	return path != ".." && !strings.HasPrefix(path, ".."+string(filepath.Separator))
+}
+
+// openDataSource opens a read-only connection pool to a data source,
+// PostgreSQL and MySQL connections go through the private network guard
+func (s *dataSourceService) openDataSource(dataSource *types.DataSource) (*sql.DB, error) {
+	conn := dataSource.Connection
+	switch dataSource.Type {
+	case types.DataSourcePostgres:
+		port := conn.Port
+		if port == 0 {
+			port = 5432
+		}
+		query := url.Values{}
+		query.Set("default_transaction_read_only", "on")
+		query.Set("statement_timeout", strconv.FormatInt(s.queryTimeout.Milliseconds(), 10))
+		query.Set("connect_timeout", strconv.Itoa(int(dataSourceDialTimeout.Seconds())))
+		if conn.SSLMode != "" {
+			query.Set("sslmode", conn.SSLMode)
+		}
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(conn.Username, conn.Password),
+			Host:     net.JoinHostPort(conn.Host, strconv.Itoa(port)),
+			Path:     "/" + conn.Database,
+			RawQuery: query.Encode(),
+		}
+		connConfig, err := pgx.ParseConfig(u.String())
+		if err != nil {
+			return nil, werrors.NewBadRequestError("Invalid PostgreSQL connection: " + err.Error())
+		}
+		connConfig.DialFunc = s.dialer.DialContext
+		return stdlib.OpenDB(*connConfig), nil
+	case types.DataSourceMySQL:
+		port := conn.Port
+		if port == 0 {
+			port = 3306
+		}
+		mysqlCfg := mysql.NewConfig()
+		mysqlCfg.User = conn.Username
+		mysqlCfg.Passwd = conn.Password
+		mysqlCfg.Net = "tcp"
+		mysqlCfg.Addr = net.JoinHostPort(conn.Host, strconv.Itoa(port))
+		mysqlCfg.DBName = conn.Database
+		mysqlCfg.Timeout = dataSourceDialTimeout
+		mysqlCfg.DialFunc = s.dialer.DialContext
+		// 查询经 PostgreSQL 解析器校验后执行，使 MySQL 按标准 SQL 解析标识符和字符串
+		mysqlCfg.Params = map[string]string{
+			"sql_mode": "CONCAT(@@sql_mode, ',ANSI_QUOTES,NO_BACKSLASH_ESCAPES')",
+		}
+		connector, err := mysql.NewConnector(mysqlCfg)
+		if err != nil {
+			return nil, werrors.NewBadRequestError("Invalid MySQL connection: " + err.Error())
+		}
+		return sql.OpenDB(connector), nil
+	case types.DataSourceSQLite:
+		path, err := s.sqlitePath(dataSource.TenantID, conn.Path)
+		if err != nil {
+			return nil, err
+		}
+		db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro&_query_only=1")
+		if err != nil {
+			return nil, fmt.Errorf("failed to open data source: %w", err)
+		}
+		return db, nil
+	}
+	return nil, werrors.NewBadRequestError("Unsupported data source type: " + string(dataSource.Type))
+}
+
+// getPool returns the connection pool of a data source, opening it on first use
+func (s *dataSourceService) getPool(dataSource *types.DataSource) (*sql.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pool, ok := s.pools[dataSource.ID]; ok {
+		if pool.updatedAt.Equal(dataSource.UpdatedAt) {
+			return pool.db, nil
+		}
+		// 数据源已被其他实例更新
+		pool.db.Close()
+		delete(s.pools, dataSource.ID)
+	}
+
+	db, err := s.openDataSource(dataSource)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(s.maxOpenConns)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+	s.pools[dataSource.ID] = &dataSourcePool{db: db, updatedAt: dataSource.UpdatedAt}
+	return db, nil
+}
+
+// closePool closes the connection pool of a data source
+func (s *dataSourceService) closePool(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pool, ok := s.pools[id]; ok {
+		pool.db.Close()
+		delete(s.pools, id)
+	}
+}
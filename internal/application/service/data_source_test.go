@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryDataSourceRepository keeps data sources in memory
+type memoryDataSourceRepository struct {
+	dataSources map[string]types.DataSource
+}
+
+func (r *memoryDataSourceRepository) CreateDataSource(ctx context.Context, dataSource *types.DataSource) error {
+	if dataSource.ID == "" {
+		dataSource.ID = "ds-" + dataSource.Name
+	}
+	dataSource.UpdatedAt = time.Now()
+	r.dataSources[dataSource.ID] = *dataSource
+	return nil
+}
+
+func (r *memoryDataSourceRepository) GetDataSource(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.DataSource, error) {
+	dataSource, ok := r.dataSources[id]
+	if !ok || dataSource.TenantID != tenantID {
+		return nil, nil
+	}
+	return &dataSource, nil
+}
+
+func (r *memoryDataSourceRepository) ListDataSources(
+	ctx context.Context, tenantID uint64,
+) ([]*types.DataSource, error) {
+	return nil, nil
+}
+
+func (r *memoryDataSourceRepository) ListDataSourcesByIDs(
+	ctx context.Context, tenantID uint64, ids []string,
+) ([]*types.DataSource, error) {
+	return nil, nil
+}
+
+func (r *memoryDataSourceRepository) UpdateDataSource(ctx context.Context, dataSource *types.DataSource) error {
+	r.dataSources[dataSource.ID] = *dataSource
+	return nil
+}
+
+func (r *memoryDataSourceRepository) DeleteDataSource(ctx context.Context, tenantID uint64, id string) error {
+	delete(r.dataSources, id)
+	return nil
+}
+
+func TestQuerySQLiteDataSource(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "1"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "2"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2", "other.db"), nil, 0o644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "2", "other.db"), filepath.Join(dir, "1", "link.db")))
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "1", "sales.db"))
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE orders (id INTEGER PRIMARY KEY, region TEXT NOT NULL, amount REAL);
+		CREATE TABLE customers (id INTEGER PRIMARY KEY, email TEXT);
+		INSERT INTO orders (region, amount) VALUES ('east', 10), ('west', 5), ('east', 2.5);`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := &memoryDataSourceRepository{dataSources: make(map[string]types.DataSource)}
+	s := NewDataSourceService(repo, &config.Config{DataSource: &config.DataSourceConfig{SQLiteDir: dir}})
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+
+	// 只能引用本租户目录下的文件，符号链接也不能指向其他租户的文件
+	var appErr *werrors.AppError
+	for _, path := range []string{"../1/sales.db", "../2/other.db", "link.db", "missing.db"} {
+		err = s.CreateDataSource(ctx, &types.DataSource{
+			Name: "escape", Type: types.DataSourceSQLite, AllowedTables: []string{"orders"},
+			Connection: types.DataSourceConnection{Path: path},
+		})
+		if assert.ErrorAs(t, err, &appErr, path) {
+			assert.Equal(t, werrors.ErrBadRequest, appErr.Code, path)
+		}
+	}
+	otherTenantCtx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(2))
+	err = s.CreateDataSource(otherTenantCtx, &types.DataSource{
+		Name: "sales", Type: types.DataSourceSQLite, AllowedTables: []string{"orders"},
+		Connection: types.DataSourceConnection{Path: "sales.db"},
+	})
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, werrors.ErrBadRequest, appErr.Code)
+	}
+
+	dataSource := &types.DataSource{
+		Name: "sales", Type: types.DataSourceSQLite, AllowedTables: []string{" Orders "}, RowLimit: 2, Enabled: true,
+		Connection: types.DataSourceConnection{Path: "sales.db"},
+	}
+	require.NoError(t, s.CreateDataSource(ctx, dataSource))
+	assert.Equal(t, []string{"orders"}, dataSource.AllowedTables)
+
+	tables, err := s.DescribeDataSource(ctx, dataSource.ID)
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+	require.Len(t, tables[0].Columns, 3)
+	assert.Equal(t, "region", tables[0].Columns[1].Name)
+	assert.False(t, tables[0].Columns[1].Nullable)
+
+	result, err := s.QueryDataSource(ctx, dataSource.ID,
+		"SELECT region, SUM(amount) AS total FROM orders GROUP BY region ORDER BY total DESC")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"region", "total"}, result.Columns)
+	require.Equal(t, 2, result.RowCount)
+	assert.Equal(t, "east", result.Rows[0]["region"])
+	assert.False(t, result.Truncated)
+
+	// 超过行数上限的结果被截断
+	result, err = s.QueryDataSource(ctx, dataSource.ID, "SELECT id FROM orders LIMIT 100")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.RowCount)
+	assert.True(t, result.Truncated)
+
+	for _, query := range []string{
+		"SELECT email FROM customers",
+		"DELETE FROM orders",
+		"SELECT id FROM orders; DROP TABLE orders",
+		"SELECT id FROM orders WHERE id IN (SELECT id FROM customers)",
+		"SELECT load_extension('evil') FROM orders",
+	} {
+		_, err := s.QueryDataSource(ctx, dataSource.ID, query)
+		if assert.ErrorAs(t, err, &appErr, query) {
+			assert.Equal(t, werrors.ErrBadRequest, appErr.Code, query)
+		}
+	}
+}
+
+func TestDataSourceDialGuard(t *testing.T) {
+	s := NewDataSourceService(nil, &config.Config{DataSource: &config.DataSourceConfig{
+		AllowedPrivateNetworks: []string{"10.1.0.0/16", "192.168.1.20", "invalid"},
+	}}).(*dataSourceService)
+
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"8.8.8.8:5432", true},
+		{"127.0.0.1:5432", false},
+		{"[::1]:3306", false},
+		{"169.254.169.254:80", false},
+		{"0.0.0.0:5432", false},
+		{"10.0.0.5:5432", false},
+		{"10.1.2.3:5432", true},
+		{"192.168.1.20:3306", true},
+		{"192.168.1.21:3306", false},
+	}
+	for _, tt := range tests {
+		err := s.denyPrivateNetwork("tcp", tt.address, nil)
+		if tt.allowed {
+			assert.NoError(t, err, tt.address)
+		} else {
+			assert.Error(t, err, tt.address)
+		}
+	}
+}
@@ -32,6 +32,7 @@ type EvaluationService struct {
 	knowledgeService     interfaces.KnowledgeService     // Service for knowledge operations
 	sessionService       interfaces.SessionService       // Service for chat sessions
 	modelService         interfaces.ModelService         // Service for model operations
+	webhookService       interfaces.WebhookService       // Service for webhook notifications
 
 	evaluationMemoryStorage *evaluationMemoryStorage // In-memory storage for evaluation tasks
 }
@@ -43,6 +44,7 @@ func NewEvaluationService(
 	knowledgeService interfaces.KnowledgeService,
 	sessionService interfaces.SessionService,
 	modelService interfaces.ModelService,
+	webhookService interfaces.WebhookService,
 ) interfaces.EvaluationService {
 	evaluationMemoryStorage := newEvaluationMemoryStorage()
 	return &EvaluationService{
@@ -52,6 +54,7 @@ func NewEvaluationService(
 		knowledgeService:        knowledgeService,
 		sessionService:          sessionService,
 		modelService:            modelService,
+		webhookService:          webhookService,
 		evaluationMemoryStorage: evaluationMemoryStorage,
 	}
 }
@@ -304,6 +307,11 @@ func (e *EvaluationService) Evaluation(ctx context.Context,
 		// Create new context with logger for background task
 		newCtx := logger.CloneContext(ctx)
 		logger.Infof(newCtx, "Background evaluation started for task ID: %s", taskID)
+		// Notify webhook subscribers once the task finished, successfully or not
+		defer func() {
+			e.webhookService.Publish(newCtx, detail.Task.TenantID, types.WebhookEventEvaluationFinished,
+				map[string]interface{}{"task": detail.Task, "metric": detail.Metric})
+		}()
 
 		// Update task status to running
 		detail.Task.Status = types.EvaluationStatueRunning
@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/Tencent/WeKnora/internal/agent/tools"
@@ -84,8 +83,8 @@ func NewChunkExtractTask(
 	chunkID string,
 	modelID string,
 ) error {
-	if strings.ToLower(os.Getenv("NEO4J_ENABLE")) != "true" {
-		logger.Warn(ctx, "NEO4J is not enabled, skip chunk extract task")
+	if !types.IsGraphEnabled() {
+		logger.Warn(ctx, "Graph store is not enabled, skip chunk extract task")
 		return nil
 	}
 	payload, err := json.Marshal(types.ExtractChunkPayload{
@@ -223,6 +222,9 @@ func (s *ChunkExtractService) Handle(ctx context.Context, t *asynq.Task) error {
 	for _, node := range graph.Node {
 		node.Chunks = []string{chunk.ID}
 	}
+	for _, rel := range graph.Relation {
+		rel.Chunks = []string{chunk.ID}
+	}
 	if err = s.graphEngine.AddGraph(ctx,
 		types.NameSpace{KnowledgeBase: chunk.KnowledgeBaseID, Knowledge: chunk.KnowledgeID},
 		[]*types.GraphData{graph},
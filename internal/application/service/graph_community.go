@@ -0,0 +1,565 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/common"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	// maxCommunityEntities bounds the entities of a knowledge base communities are built from
+	maxCommunityEntities    = 5000
+	communityEntityPageSize = 500
+	// communityExpandBatch is the number of entities whose relations are loaded at once
+	communityExpandBatch = 200
+	// communityRelationLimit bounds the relations loaded for one batch of entities
+	communityRelationLimit = 5000
+	// minCommunitySize is the number of entities below which a community is not summarized
+	minCommunitySize = 3
+	maxCommunities   = 50
+	// maxCommunityPromptEntities and maxCommunityPromptRelations bound the graph described to the summary model
+	maxCommunityPromptEntities  = 60
+	maxCommunityPromptRelations = 100
+	defaultCommunitySearchTopK  = 5
+)
+
+// defaultCommunitySummaryPrompt instructs the model to summarize a community of entities
+const defaultCommunitySummaryPrompt = `You summarize a community of closely related entities of a knowledge graph extracted from a document collection.
+
+Read the entities and the relations between them, then write:
+- title: a short name for the topic of the community, at most 10 words.
+- summary: one or two paragraphs describing the main entities, how they relate and the key facts they convey, so that broad questions about the collection can be answered from the summary without the source documents.
+Use only the information given, and write in the language of the entities.
+
+Respond with a JSON object only, e.g. {"title": "...", "summary": "..."}.`
+
+// graphCommunityService implements the GraphCommunityService interface
+type graphCommunityService struct {
+	kbService    interfaces.KnowledgeBaseService
+	graphRepo    interfaces.RetrieveGraphRepository
+	repo         interfaces.GraphCommunityRepository
+	modelService interfaces.ModelService
+	task         *asynq.Client
+}
+
+// NewGraphCommunityService creates a new graph community service
+func NewGraphCommunityService(
+	kbService interfaces.KnowledgeBaseService,
+	graphRepo interfaces.RetrieveGraphRepository,
+	repo interfaces.GraphCommunityRepository,
+	modelService interfaces.ModelService,
+	task *asynq.Client,
+) interfaces.GraphCommunityService {
+	return &graphCommunityService{
+		kbService:    kbService,
+		graphRepo:    graphRepo,
+		repo:         repo,
+		modelService: modelService,
+		task:         task,
+	}
+}
+
+// RebuildCommunities schedules the rebuild of the communities of a knowledge base
+func (s *graphCommunityService) RebuildCommunities(ctx context.Context, kbID string,
+	resolveEntities bool,
+) (*types.GraphCommunityRebuild, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	if !types.IsGraphEnabled() || kb.ExtractConfig == nil || !kb.ExtractConfig.Enabled {
+		return nil, werrors.NewBadRequestError("Knowledge graph extraction is not enabled for this knowledge base")
+	}
+	if kb.SummaryModelID == "" {
+		return nil, werrors.NewBadRequestError("A summary model is required to summarize graph communities")
+	}
+
+	payload := types.GraphCommunityPayload{
+		TenantID:        kb.TenantID,
+		KnowledgeBaseID: kbID,
+		ResolveEntities: resolveEntities,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	// 同一知识库同时只有一个重建任务
+	task := asynq.NewTask(types.TypeGraphCommunity, payloadBytes, asynq.Queue("low"), asynq.MaxRetry(1),
+		asynq.Timeout(time.Hour), asynq.TaskID("graph-community-"+kbID))
+	info, err := s.task.Enqueue(task)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil, werrors.NewConflictError("A community rebuild is already in progress for this knowledge base")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue graph community task: %w", err)
+	}
+	logger.Infof(ctx, "Graph community rebuild scheduled for knowledge base %s, resolve entities: %v",
+		kbID, resolveEntities)
+	return &types.GraphCommunityRebuild{
+		TaskID:          info.ID,
+		KnowledgeBaseID: kbID,
+		ResolveEntities: resolveEntities,
+	}, nil
+}
+
+// ListCommunities lists the communities of a knowledge base
+func (s *graphCommunityService) ListCommunities(ctx context.Context, kbID string) ([]*types.GraphCommunity, error) {
+	if _, err := s.getKnowledgeBase(ctx, kbID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListCommunities(ctx, []string{kbID})
+}
+
+// SearchCommunities ranks the communities of the knowledge bases by the similarity of their summaries
+// to the query. Communities without an embedding rank last, largest first.
+func (s *graphCommunityService) SearchCommunities(ctx context.Context, kbIDs []string, query string,
+	topK int,
+) ([]*types.GraphCommunity, error) {
+	if topK <= 0 {
+		topK = defaultCommunitySearchTopK
+	}
+	communities, err := s.repo.ListCommunities(ctx, kbIDs)
+	if err != nil || len(communities) == 0 {
+		return communities, err
+	}
+
+	// 各知识库的向量模型可能不同，按模型分别生成查询向量
+	queryEmbeddings := make(map[string][]float32)
+	for _, community := range communities {
+		if community.EmbeddingModelID == "" || len(community.Embedding) == 0 {
+			continue
+		}
+		queryEmbedding, ok := queryEmbeddings[community.EmbeddingModelID]
+		if !ok {
+			queryEmbedding = s.embedQuery(ctx, community.EmbeddingModelID, query)
+			queryEmbeddings[community.EmbeddingModelID] = queryEmbedding
+		}
+		community.Score = cosineSimilarity(community.Embedding, queryEmbedding)
+	}
+	sort.SliceStable(communities, func(i, j int) bool { return communities[i].Score > communities[j].Score })
+	return communities[:min(len(communities), topK)], nil
+}
+
+// embedQuery embeds the query with a model, nil if it fails
+func (s *graphCommunityService) embedQuery(ctx context.Context, modelID, query string) []float32 {
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, modelID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get embedding model %s for community search: %v", modelID, err)
+		return nil
+	}
+	embedding, err := embedder.Embed(ctx, query)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to embed query for community search: %v", err)
+		return nil
+	}
+	return embedding
+}
+
+// ProcessRebuild handles graph community tasks: it merges duplicate entities if requested, detects the
+// communities of the graph, summarizes them with the summary model of the knowledge base and replaces
+// the stored communities
+func (s *graphCommunityService) ProcessRebuild(ctx context.Context, t *asynq.Task) error {
+	var payload types.GraphCommunityPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "failed to unmarshal graph community payload: %v", err)
+		return nil
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, payload.KnowledgeBaseID)
+	if err != nil || kb == nil {
+		logger.Infof(ctx, "Knowledge base %s not found, skipping graph community rebuild", payload.KnowledgeBaseID)
+		return nil
+	}
+	chatModel, err := s.modelService.GetChatModel(ctx, kb.SummaryModelID)
+	if err != nil {
+		return fmt.Errorf("failed to get chat model: %w", err)
+	}
+
+	if payload.ResolveEntities {
+		entities, err := s.loadEntities(ctx, kb.ID)
+		if err != nil {
+			return skipDisabledGraph(ctx, err)
+		}
+		merged, err := s.resolveEntities(ctx, kb, chatModel, entities)
+		if err != nil {
+			return err
+		}
+		logger.Infof(ctx, "Merged %d duplicate entities in knowledge base %s", merged, kb.ID)
+	}
+
+	entities, err := s.loadEntities(ctx, kb.ID)
+	if err != nil {
+		return skipDisabledGraph(ctx, err)
+	}
+	relations, err := s.loadRelations(ctx, kb.ID, entities)
+	if err != nil {
+		return skipDisabledGraph(ctx, err)
+	}
+
+	var communities []*types.GraphCommunity
+	// 社区按实体数从多到少排列，只摘要较大的社区
+	for _, members := range detectCommunities(relations) {
+		if len(members) < minCommunitySize || len(communities) >= maxCommunities {
+			break
+		}
+		community, err := s.summarizeCommunity(ctx, chatModel, entities, members, relations)
+		if err != nil {
+			return err
+		}
+		if community == nil {
+			continue
+		}
+		community.TenantID = kb.TenantID
+		community.KnowledgeBaseID = kb.ID
+		communities = append(communities, community)
+	}
+	s.embedCommunities(ctx, kb.EmbeddingModelID, communities)
+
+	if err := s.repo.ReplaceCommunities(ctx, kb.ID, communities); err != nil {
+		return fmt.Errorf("failed to save graph communities: %w", err)
+	}
+	logger.Infof(ctx, "Rebuilt %d graph communities from %d entities and %d relations of knowledge base %s",
+		len(communities), len(entities), len(relations), kb.ID)
+	return nil
+}
+
+// skipDisabledGraph ends a task without retrying when no graph store is configured
+func skipDisabledGraph(ctx context.Context, err error) error {
+	if errors.Is(err, types.ErrGraphStoreDisabled) {
+		logger.Warnf(ctx, "Knowledge graph storage is not enabled, skipping graph community rebuild")
+		return nil
+	}
+	return err
+}
+
+// loadEntities loads the entities of a knowledge base by name, at most maxCommunityEntities
+func (s *graphCommunityService) loadEntities(ctx context.Context, kbID string) (map[string]*types.GraphEntity, error) {
+	entities := make(map[string]*types.GraphEntity)
+	for offset := 0; offset < maxCommunityEntities; offset += communityEntityPageSize {
+		page, _, err := s.graphRepo.ListEntities(ctx, kbID, "", offset, communityEntityPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, entity := range page {
+			entities[entity.Name] = entity
+		}
+		if len(page) < communityEntityPageSize {
+			break
+		}
+	}
+	return entities, nil
+}
+
+// loadRelations loads the relations between the entities, merging those of the same endpoints and type
+func (s *graphCommunityService) loadRelations(ctx context.Context, kbID string,
+	entities map[string]*types.GraphEntity,
+) ([]*types.GraphRelation, error) {
+	names := make([]string, 0, len(entities))
+	for name := range entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var relations []*types.GraphRelation
+	for start := 0; start < len(names); start += communityExpandBatch {
+		batch := names[start:min(start+communityExpandBatch, len(names))]
+		graph, err := s.graphRepo.ExpandEntities(ctx, kbID, batch, 1, communityRelationLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, rel := range graph.Relation {
+			if rel.Node1 == rel.Node2 || entities[rel.Node1] == nil || entities[rel.Node2] == nil {
+				continue
+			}
+			relations = append(relations, rel)
+		}
+	}
+	return types.MergeGraphRelations(relations), nil
+}
+
+// summarizeCommunity asks the chat model for the title and summary of a community, nil if the response
+// cannot be used
+func (s *graphCommunityService) summarizeCommunity(ctx context.Context, chatModel chat.Chat,
+	entities map[string]*types.GraphEntity, members []string, relations []*types.GraphRelation,
+) (*types.GraphCommunity, error) {
+	inCommunity := make(map[string]bool, len(members))
+	for _, name := range members {
+		inCommunity[name] = true
+	}
+
+	var described strings.Builder
+	described.WriteString("Entities:\n")
+	for _, name := range members[:min(len(members), maxCommunityPromptEntities)] {
+		described.WriteString("- " + name)
+		if entity := entities[name]; entity != nil && len(entity.Attributes) > 0 {
+			described.WriteString(": " + strings.Join(entity.Attributes[:min(len(entity.Attributes), 3)], "; "))
+		}
+		described.WriteString("\n")
+	}
+	described.WriteString("\nRelations:\n")
+	relationCount := 0
+	for _, rel := range relations {
+		if !inCommunity[rel.Node1] || !inCommunity[rel.Node2] {
+			continue
+		}
+		if relationCount < maxCommunityPromptRelations {
+			described.WriteString(fmt.Sprintf("- %s -[%s]-> %s\n", rel.Node1, rel.Type, rel.Node2))
+		}
+		relationCount++
+	}
+
+	thinking := false
+	resp, err := chatModel.Chat(ctx, []chat.Message{
+		{Role: "system", Content: defaultCommunitySummaryPrompt},
+		{Role: "user", Content: described.String()},
+	}, &chat.ChatOptions{Temperature: DefaultLLMTemperature, Thinking: &thinking})
+	if err != nil {
+		return nil, fmt.Errorf("community summary failed: %w", err)
+	}
+
+	var summary struct {
+		Title   string `json:"title"`
+		Summary string `json:"summary"`
+	}
+	if err := common.ParseLLMJsonResponse(resp.Content, &summary); err != nil {
+		logger.Warnf(ctx, "Failed to parse community summary response: %v, content: %s", err, resp.Content)
+		return nil, nil
+	}
+	summary.Title = strings.TrimSpace(summary.Title)
+	summary.Summary = strings.TrimSpace(summary.Summary)
+	if summary.Summary == "" {
+		return nil, nil
+	}
+	if summary.Title == "" {
+		summary.Title = strings.Join(members[:min(len(members), 3)], ", ")
+	}
+	if runes := []rune(summary.Title); len(runes) > 100 {
+		summary.Title = string(runes[:100])
+	}
+	return &types.GraphCommunity{
+		Title:         summary.Title,
+		Summary:       summary.Summary,
+		Entities:      members,
+		Size:          len(members),
+		RelationCount: relationCount,
+	}, nil
+}
+
+// embedCommunities generates the embeddings global search ranks communities by.
+// Communities are still stored without embeddings if the knowledge base has no embedding model or it fails.
+func (s *graphCommunityService) embedCommunities(ctx context.Context, modelID string,
+	communities []*types.GraphCommunity,
+) {
+	if modelID == "" || len(communities) == 0 {
+		return
+	}
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, modelID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get embedding model for graph communities: %v", err)
+		return
+	}
+	texts := make([]string, len(communities))
+	for i, community := range communities {
+		texts[i] = community.Title + "\n" + community.Summary
+	}
+	embeddings, err := embedder.BatchEmbedWithPool(ctx, embedder, texts)
+	if err != nil || len(embeddings) != len(communities) {
+		logger.Warnf(ctx, "Failed to embed graph communities: %v", err)
+		return
+	}
+	for i, community := range communities {
+		community.Embedding = embeddings[i]
+		community.EmbeddingModelID = modelID
+	}
+}
+
+// getKnowledgeBase gets a knowledge base of the current tenant
+func (s *graphCommunityService) getKnowledgeBase(ctx context.Context, kbID string) (*types.KnowledgeBase, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		return nil, werrors.NewNotFoundError("knowledge base not found")
+	}
+	return kb, nil
+}
+
+// detectCommunities groups the entities of the relations into communities by Louvain modularity
+// optimization over the undirected graph, weighted by the number of relation types between two entities.
+// Communities are returned largest first, the entities of a community most connected first.
+func detectCommunities(relations []*types.GraphRelation) [][]string {
+	index := make(map[string]int)
+	var names []string
+	nodeOf := func(name string) int {
+		if i, ok := index[name]; ok {
+			return i
+		}
+		index[name] = len(names)
+		names = append(names, name)
+		return len(names) - 1
+	}
+	seen := make(map[[3]string]bool)
+	var adjacency []map[int]float64
+	for _, rel := range relations {
+		if rel.Node1 == rel.Node2 {
+			continue
+		}
+		ends := [2]string{rel.Node1, rel.Node2}
+		if ends[0] > ends[1] {
+			ends[0], ends[1] = ends[1], ends[0]
+		}
+		key := [3]string{ends[0], ends[1], rel.Type}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		i, j := nodeOf(ends[0]), nodeOf(ends[1])
+		for len(adjacency) < len(names) {
+			adjacency = append(adjacency, make(map[int]float64))
+		}
+		adjacency[i][j]++
+		adjacency[j][i]++
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	degree := make([]float64, len(names))
+	for i, neighbours := range adjacency {
+		for _, weight := range neighbours {
+			degree[i] += weight
+		}
+	}
+
+	// membership 记录每个实体所属的社区，每轮局部移动后将社区聚合为节点继续优化
+	membership := make([]int, len(names))
+	for i := range membership {
+		membership[i] = i
+	}
+	graph := adjacency
+	for level := 0; level < 10; level++ {
+		community, moved := louvainLocalMoving(graph)
+		if !moved {
+			break
+		}
+		count := renumberCommunities(community)
+		for i := range membership {
+			membership[i] = community[membership[i]]
+		}
+		aggregated := make([]map[int]float64, count)
+		for i := range aggregated {
+			aggregated[i] = make(map[int]float64)
+		}
+		for i, neighbours := range graph {
+			for j, weight := range neighbours {
+				aggregated[community[i]][community[j]] += weight
+			}
+		}
+		graph = aggregated
+	}
+
+	groups := make(map[int][]string)
+	for i, name := range names {
+		groups[membership[i]] = append(groups[membership[i]], name)
+	}
+	communities := make([][]string, 0, len(groups))
+	for _, members := range groups {
+		sort.Slice(members, func(a, b int) bool {
+			da, db := degree[index[members[a]]], degree[index[members[b]]]
+			if da != db {
+				return da > db
+			}
+			return members[a] < members[b]
+		})
+		communities = append(communities, members)
+	}
+	sort.Slice(communities, func(a, b int) bool {
+		if len(communities[a]) != len(communities[b]) {
+			return len(communities[a]) > len(communities[b])
+		}
+		return communities[a][0] < communities[b][0]
+	})
+	return communities
+}
+
+// louvainLocalMoving moves each node into the neighbouring community with the largest modularity gain
+// until no move improves modularity, and reports whether any node moved.
+// A self loop of a node holds the weight inside the community the node was aggregated from.
+func louvainLocalMoving(graph []map[int]float64) ([]int, bool) {
+	community := make([]int, len(graph))
+	degree := make([]float64, len(graph))
+	total := make([]float64, len(graph))
+	var totalWeight float64
+	for i, neighbours := range graph {
+		community[i] = i
+		for _, weight := range neighbours {
+			degree[i] += weight
+		}
+		total[i] = degree[i]
+		totalWeight += degree[i]
+	}
+	if totalWeight == 0 {
+		return community, false
+	}
+
+	moved := false
+	for pass := 0; pass < 100; pass++ {
+		changed := false
+		for i, neighbours := range graph {
+			links := make(map[int]float64)
+			for j, weight := range neighbours {
+				if j != i {
+					links[community[j]] += weight
+				}
+			}
+			current := community[i]
+			total[current] -= degree[i]
+			// 按社区编号遍历，保证结果稳定
+			candidates := make([]int, 0, len(links))
+			for c := range links {
+				candidates = append(candidates, c)
+			}
+			sort.Ints(candidates)
+			best, bestGain := current, links[current]-total[current]*degree[i]/totalWeight
+			for _, c := range candidates {
+				if gain := links[c] - total[c]*degree[i]/totalWeight; gain > bestGain+1e-12 {
+					best, bestGain = c, gain
+				}
+			}
+			total[best] += degree[i]
+			community[i] = best
+			if best != current {
+				changed, moved = true, true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return community, moved
+}
+
+// renumberCommunities renumbers the communities from 0 in order of first appearance and returns their count
+func renumberCommunities(community []int) int {
+	numbers := make(map[int]int)
+	for i, c := range community {
+		n, ok := numbers[c]
+		if !ok {
+			n = len(numbers)
+			numbers[c] = n
+		}
+		community[i] = n
+	}
+	return len(numbers)
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectCommunities(t *testing.T) {
+	relation := func(node1, node2, relationType string) *types.GraphRelation {
+		return &types.GraphRelation{Node1: node1, Node2: node2, Type: relationType}
+	}
+	communities := detectCommunities([]*types.GraphRelation{
+		// 两个三角形由一条关系相连
+		relation("Docker", "containerd", "uses"),
+		relation("Docker", "runc", "uses"),
+		relation("containerd", "runc", "uses"),
+		relation("Kubernetes", "etcd", "stores state in"),
+		relation("Kubernetes", "kubelet", "runs"),
+		relation("kubelet", "etcd", "watches"),
+		relation("kubelet", "containerd", "calls"),
+		// 重复关系及自环不影响结果
+		relation("runc", "Docker", "uses"),
+		relation("Docker", "Docker", "extends"),
+		relation("Redis", "Sentinel", "monitored by"),
+	})
+
+	require.Len(t, communities, 3)
+	assert.ElementsMatch(t, []string{"Docker", "containerd", "runc"}, communities[0])
+	assert.ElementsMatch(t, []string{"Kubernetes", "etcd", "kubelet"}, communities[1])
+	assert.Equal(t, []string{"Redis", "Sentinel"}, communities[2])
+	assert.Equal(t, "containerd", communities[0][0], "the most connected entity comes first")
+	assert.Equal(t, "kubelet", communities[1][0])
+
+	assert.Empty(t, detectCommunities(nil))
+}
+
+func TestNormalizeEntityName(t *testing.T) {
+	assert.Equal(t, "openai", normalizeEntityName("OpenAI"))
+	assert.Equal(t, "openai", normalizeEntityName(" open AI "))
+	assert.Equal(t, "openai", normalizeEntityName("ＯｐｅｎＡＩ"))
+	assert.Equal(t, "openaiinc.", normalizeEntityName("OpenAI Inc."))
+	// 仅符号不同的名称可能是不同实体，不直接合并
+	assert.Equal(t, "open-ai", normalizeEntityName("Open-AI"))
+	assert.Equal(t, "c", normalizeEntityName("C"))
+	assert.Equal(t, "c++", normalizeEntityName("C++"))
+	assert.Equal(t, "c++", normalizeEntityName("Ｃ＋＋"))
+	assert.Equal(t, "c#", normalizeEntityName("c #"))
+
+	assert.Equal(t, "openai", entityNameStem("OpenAI Inc."))
+	assert.Equal(t, "openai", entityNameStem("Open AI, Limited"))
+	assert.Equal(t, "腾讯", entityNameStem("腾讯有限公司"))
+	assert.Equal(t, "company", entityNameStem("Company"), "a name is never stripped to nothing")
+	assert.Equal(t, "disco", entityNameStem("Disco"), "suffix words only match whole words")
+	assert.Equal(t, "c", entityNameStem("C++"))
+}
+
+func TestEntityMergeCandidates(t *testing.T) {
+	names := []string{"OpenAI", "OpenAI Inc.", "GPT-4", "Microsoft", "Microsoft Corporation", "Bing"}
+	candidates := entityMergeCandidates(names, nil, 0.9)
+	assert.Equal(t, [][]string{{"OpenAI", "OpenAI Inc."}, {"Microsoft", "Microsoft Corporation"}}, candidates)
+
+	// 仅符号不同的名称交由模型确认
+	candidates = entityMergeCandidates([]string{"C", "C++", "C#", "Open-AI", "OpenAI"}, nil, 0.9)
+	assert.Equal(t, [][]string{{"C", "C++", "C#"}, {"Open-AI", "OpenAI"}}, candidates)
+
+	embeddings := [][]float32{{1, 0}, {1, 0}, {0, 1}, {0.6, 0.8}, {0.6, 0.8}, {0.59, 0.81}}
+	candidates = entityMergeCandidates(names, embeddings, 0.99)
+	assert.Equal(t, [][]string{
+		{"OpenAI", "OpenAI Inc."},
+		{"Microsoft", "Microsoft Corporation", "Bing"},
+	}, candidates)
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// entityResolutionThreshold is the similarity of name embeddings from which two entities may be duplicates
+	entityResolutionThreshold = 0.9
+	// maxResolutionEntities bounds the entities compared pairwise, the most connected are compared
+	maxResolutionEntities = 2000
+	// maxResolutionGroupSize skips candidate groups too broad to be the same entity
+	maxResolutionGroupSize = 8
+	// resolutionGroupsPerCall is the number of candidate groups confirmed by one model call
+	resolutionGroupsPerCall = 20
+)
+
+// Legal form suffixes ignored when looking for duplicate entities. Chinese names have no word
+// boundaries, their suffixes are trimmed from the end of the name, longest first.
+var (
+	entityNameSuffixWords = []string{
+		"inc", "incorporated", "corp", "corporation", "co", "company", "ltd", "limited", "llc", "plc", "gmbh",
+	}
+	entityNameSuffixesCJK = []string{"股份有限公司", "有限责任公司", "有限公司", "公司", "集团"}
+)
+
+// defaultEntityResolutionPrompt instructs the model to confirm duplicate entities
+const defaultEntityResolutionPrompt = `You clean up a knowledge graph extracted from documents. Each candidate group lists entity names that may refer to the same real-world entity, with some of their attributes.
+
+For each group, decide which names refer to exactly the same entity, e.g. "OpenAI" and "OpenAI Inc.". Do NOT merge names of related but different entities, such as a company and its product, a person and their organization, different versions of a product, or names whose symbols change their meaning like "C", "C++" and "C#".
+
+Respond with a JSON array only, one item per set of names to merge:
+[{"group": 1, "names": ["OpenAI", "OpenAI Inc."], "canonical": "OpenAI"}]
+"canonical" is the clearest and most common of the names. Respond with [] if nothing should be merged.`
+
+// resolveEntities merges the entities of a knowledge base that refer to the same real-world entity and
+// returns the number of entities merged away. Names differing only in case, width or spacing are merged
+// directly; names with the same stem or similar embeddings, e.g. "C" and "C++", are merged once confirmed
+// by the chat model.
+func (s *graphCommunityService) resolveEntities(ctx context.Context, kb *types.KnowledgeBase,
+	chatModel chat.Chat, entities map[string]*types.GraphEntity,
+) (int, error) {
+	names := make([]string, 0, len(entities))
+	for name := range entities {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if entities[names[i]].Degree != entities[names[j]].Degree {
+			return entities[names[i]].Degree > entities[names[j]].Degree
+		}
+		return names[i] < names[j]
+	})
+
+	merged := 0
+	normalized := make(map[string][]string)
+	var keys []string
+	for _, name := range names {
+		key := normalizeEntityName(name)
+		if _, ok := normalized[key]; !ok {
+			keys = append(keys, key)
+		}
+		normalized[key] = append(normalized[key], name)
+	}
+	// 每组保留关系最多的名称，其余名称直接合并
+	remaining := make([]string, 0, len(keys))
+	for _, key := range keys {
+		group := normalized[key]
+		remaining = append(remaining, group[0])
+		if len(group) == 1 || key == "" {
+			continue
+		}
+		if err := s.mergeEntities(ctx, kb.ID, entities, group[1:], group[0]); err != nil {
+			return merged, err
+		}
+		merged += len(group) - 1
+	}
+
+	remaining = remaining[:min(len(remaining), maxResolutionEntities)]
+	embeddings := s.embedEntityNames(ctx, kb.EmbeddingModelID, remaining)
+	candidates := entityMergeCandidates(remaining, embeddings, entityResolutionThreshold)
+	logger.Infof(ctx, "Found %d candidate groups of duplicate entities in knowledge base %s", len(candidates), kb.ID)
+
+	for start := 0; start < len(candidates); start += resolutionGroupsPerCall {
+		groups := candidates[start:min(start+resolutionGroupsPerCall, len(candidates))]
+		decisions, err := confirmEntityMerges(ctx, chatModel, groups, entities)
+		if err != nil {
+			return merged, err
+		}
+		for _, decision := range decisions {
+			if err := s.mergeEntities(ctx, kb.ID, entities, decision.sources, decision.target); err != nil {
+				return merged, err
+			}
+			merged += len(decision.sources)
+		}
+	}
+	return merged, nil
+}
+
+// mergeEntities merges the source entities into the target in the graph store and in the loaded entities
+func (s *graphCommunityService) mergeEntities(ctx context.Context, kbID string,
+	entities map[string]*types.GraphEntity, sources []string, target string,
+) error {
+	if err := s.graphRepo.MergeEntities(ctx, kbID, sources, target); err != nil {
+		return fmt.Errorf("failed to merge entities into %s: %w", target, err)
+	}
+	for _, source := range sources {
+		if entity := entities[source]; entity != nil && entities[target] != nil {
+			entities[target].Degree += entity.Degree
+		}
+		delete(entities, source)
+	}
+	logger.Infof(ctx, "Merged entities %v into %s in knowledge base %s", sources, target, kbID)
+	return nil
+}
+
+// embedEntityNames embeds the entity names, nil if the knowledge base has no embedding model or it fails
+func (s *graphCommunityService) embedEntityNames(ctx context.Context, modelID string, names []string) [][]float32 {
+	if modelID == "" || len(names) < 2 {
+		return nil
+	}
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, modelID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get embedding model for entity resolution: %v", err)
+		return nil
+	}
+	embeddings, err := embedder.BatchEmbedWithPool(ctx, embedder, names)
+	if err != nil || len(embeddings) != len(names) {
+		logger.Warnf(ctx, "Failed to embed entity names, only comparing names: %v", err)
+		return nil
+	}
+	return embeddings
+}
+
+// entityMerge is a merge of duplicate entities confirmed by the chat model
+type entityMerge struct {
+	sources []string
+	target  string
+}
+
+// confirmEntityMerges asks the chat model which names of the candidate groups are the same entity
+func confirmEntityMerges(ctx context.Context, chatModel chat.Chat, groups [][]string,
+	entities map[string]*types.GraphEntity,
+) ([]entityMerge, error) {
+	var described strings.Builder
+	for i, group := range groups {
+		described.WriteString(fmt.Sprintf("Group %d:\n", i+1))
+		for _, name := range group {
+			described.WriteString("- " + name)
+			if entity := entities[name]; entity != nil && len(entity.Attributes) > 0 {
+				described.WriteString(": " + strings.Join(entity.Attributes[:min(len(entity.Attributes), 3)], "; "))
+			}
+			described.WriteString("\n")
+		}
+	}
+
+	thinking := false
+	resp, err := chatModel.Chat(ctx, []chat.Message{
+		{Role: "system", Content: defaultEntityResolutionPrompt},
+		{Role: "user", Content: described.String()},
+	}, &chat.ChatOptions{Temperature: DefaultLLMTemperature, Thinking: &thinking})
+	if err != nil {
+		return nil, fmt.Errorf("entity resolution failed: %w", err)
+	}
+	var decisions []struct {
+		Group     int      `json:"group"`
+		Names     []string `json:"names"`
+		Canonical string   `json:"canonical"`
+	}
+	if err := common.ParseLLMJsonResponse(resp.Content, &decisions); err != nil {
+		logger.Warnf(ctx, "Failed to parse entity resolution response: %v, content: %s", err, resp.Content)
+		return nil, nil
+	}
+
+	// 只接受候选组内仍存在的名称，同一名称只合并一次
+	used := make(map[string]bool)
+	var merges []entityMerge
+	for _, decision := range decisions {
+		if decision.Group < 1 || decision.Group > len(groups) {
+			continue
+		}
+		var names []string
+		for _, name := range decision.Names {
+			if entities[name] != nil && !used[name] && slices.Contains(groups[decision.Group-1], name) {
+				names = append(names, name)
+				used[name] = true
+			}
+		}
+		if len(names) < 2 {
+			continue
+		}
+		target := names[0]
+		if slices.Contains(names, decision.Canonical) {
+			target = decision.Canonical
+		}
+		merge := entityMerge{target: target}
+		for _, name := range names {
+			if name != target {
+				merge.sources = append(merge.sources, name)
+			}
+		}
+		merges = append(merges, merge)
+	}
+	return merges, nil
+}
+
+// entityMergeCandidates groups the names that may refer to the same entity: names of the same stem
+// and names whose embeddings reach the threshold. Only groups of 2 to maxResolutionGroupSize names are returned.
+func entityMergeCandidates(names []string, embeddings [][]float32, threshold float64) [][]string {
+	parent := make([]int, len(names))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		parent[find(i)] = find(j)
+	}
+
+	stems := make(map[string]int)
+	for i, name := range names {
+		stem := entityNameStem(name)
+		if stem == "" {
+			continue
+		}
+		if j, ok := stems[stem]; ok {
+			union(i, j)
+		} else {
+			stems[stem] = i
+		}
+	}
+	if len(embeddings) == len(names) {
+		for i := range names {
+			for j := i + 1; j < len(names); j++ {
+				if cosineSimilarity(embeddings[i], embeddings[j]) >= threshold {
+					union(i, j)
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]string)
+	var roots []int
+	for i, name := range names {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], name)
+	}
+	var candidates [][]string
+	for _, root := range roots {
+		if group := groups[root]; len(group) >= 2 && len(group) <= maxResolutionGroupSize {
+			candidates = append(candidates, group)
+		}
+	}
+	return candidates
+}
+
+// foldEntityName folds full-width characters and case of a name
+func foldEntityName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 0xFF01 && r <= 0xFF5E:
+			r -= 0xFEE0
+		case r == 0x3000:
+			r = ' '
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// entityNameTokens folds a name and splits it into words of letters and digits
+func entityNameTokens(name string) []string {
+	return strings.FieldsFunc(foldEntityName(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// normalizeEntityName returns the key under which names differing only in case, width or spacing are
+// the same entity, e.g. "OpenAI", "openai" and "Open AI". Symbols are kept: "C", "C++" and "C#" differ.
+func normalizeEntityName(name string) string {
+	return strings.Join(strings.Fields(foldEntityName(name)), "")
+}
+
+// entityNameStem returns the normalized name without legal form suffixes, e.g. "openai" for "OpenAI Inc."
+func entityNameStem(name string) string {
+	tokens := entityNameTokens(name)
+	for len(tokens) > 1 && slices.Contains(entityNameSuffixWords, tokens[len(tokens)-1]) {
+		tokens = tokens[:len(tokens)-1]
+	}
+	stem := strings.Join(tokens, "")
+	for _, suffix := range entityNameSuffixesCJK {
+		if trimmed := strings.TrimSuffix(stem, suffix); trimmed != stem && trimmed != "" {
+			return trimmed
+		}
+	}
+	return stem
+}
@@ -70,6 +70,9 @@ type knowledgeService struct {
 	task            *asynq.Client
 	graphEngine     interfaces.RetrieveGraphRepository
 	redisClient     *redis.Client
+	limiter         interfaces.TenantLimiter
+	webhookService  interfaces.WebhookService
+	scheduleService interfaces.AgentScheduleService
 }
 
 const (
@@ -95,6 +98,9 @@ func NewKnowledgeService(
 	graphEngine interfaces.RetrieveGraphRepository,
 	retrieveEngine interfaces.RetrieveEngineRegistry,
 	redisClient *redis.Client,
+	limiter interfaces.TenantLimiter,
+	webhookService interfaces.WebhookService,
+	scheduleService interfaces.AgentScheduleService,
 ) (interfaces.KnowledgeService, error) {
 	return &knowledgeService{
 		config:          config,
@@ -112,6 +118,9 @@ func NewKnowledgeService(
 		graphEngine:     graphEngine,
 		retrieveEngine:  retrieveEngine,
 		redisClient:     redisClient,
+		limiter:         limiter,
+		webhookService:  webhookService,
+		scheduleService: scheduleService,
 	}, nil
 }
 
@@ -334,7 +343,8 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		return knowledge, nil
 	}
 
-	task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes, asynq.Queue("default"))
+	task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes, asynq.Queue("default"),
+		asynq.Retention(documentProcessTaskRetention))
 	info, err := s.task.Enqueue(task)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to enqueue document process task: %v", err)
@@ -470,7 +480,8 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 		return knowledge, nil
 	}
 
-	task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes, asynq.Queue("default"))
+	task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes, asynq.Queue("default"),
+		asynq.Retention(documentProcessTaskRetention))
 	info, err := s.task.Enqueue(task)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to enqueue URL process task: %v", err)
@@ -486,14 +497,22 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 func (s *knowledgeService) CreateKnowledgeFromPassage(ctx context.Context,
 	kbID string, passage []string,
 ) (*types.Knowledge, error) {
-	return s.createKnowledgeFromPassageInternal(ctx, kbID, passage, false)
+	return s.createKnowledgeFromPassageInternal(ctx, kbID, "", passage, false)
 }
 
 // CreateKnowledgeFromPassageSync creates a knowledge entry from text passages and waits for indexing to complete.
 func (s *knowledgeService) CreateKnowledgeFromPassageSync(ctx context.Context,
 	kbID string, passage []string,
 ) (*types.Knowledge, error) {
-	return s.createKnowledgeFromPassageInternal(ctx, kbID, passage, true)
+	return s.createKnowledgeFromPassageInternal(ctx, kbID, "", passage, true)
+}
+
+// CreateKnowledgeFromPassageSyncWithID creates a knowledge entry with the given ID from text passages
+// and waits for indexing to complete.
+func (s *knowledgeService) CreateKnowledgeFromPassageSyncWithID(ctx context.Context,
+	kbID, knowledgeID string, passage []string,
+) (*types.Knowledge, error) {
+	return s.createKnowledgeFromPassageInternal(ctx, kbID, knowledgeID, passage, true)
 }
 
 // CreateKnowledgeFromManual creates or saves manual Markdown knowledge content.
@@ -584,7 +603,7 @@ func (s *knowledgeService) CreateKnowledgeFromManual(ctx context.Context,
 // createKnowledgeFromPassageInternal consolidates the common logic for creating knowledge from passages.
 // When syncMode is true, chunk processing is performed synchronously; otherwise, it's processed asynchronously.
 func (s *knowledgeService) createKnowledgeFromPassageInternal(ctx context.Context,
-	kbID string, passage []string, syncMode bool,
+	kbID, knowledgeID string, passage []string, syncMode bool,
 ) (*types.Knowledge, error) {
 	if syncMode {
 		logger.Info(ctx, "Start creating knowledge from passage (sync)")
@@ -618,8 +637,11 @@ func (s *knowledgeService) createKnowledgeFromPassageInternal(ctx context.Contex
 	} else {
 		logger.Info(ctx, "Creating knowledge record")
 	}
+	if knowledgeID == "" {
+		knowledgeID = uuid.New().String()
+	}
 	knowledge := &types.Knowledge{
-		ID:               uuid.New().String(),
+		ID:               knowledgeID,
 		TenantID:         ctx.Value(types.TenantIDContextKey).(uint64),
 		KnowledgeBaseID:  kbID,
 		Type:             "passage",
@@ -674,7 +696,8 @@ func (s *knowledgeService) createKnowledgeFromPassageInternal(ctx context.Contex
 			return knowledge, nil
 		}
 
-		task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes, asynq.Queue("default"))
+		task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes, asynq.Queue("default"),
+			asynq.Retention(documentProcessTaskRetention))
 		info, err := s.task.Enqueue(task)
 		if err != nil {
 			logger.Errorf(ctx, "Failed to enqueue passage process task: %v", err)
@@ -805,7 +828,11 @@ func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error
 		return err
 	}
 	// Delete the knowledge entry itself from the database
-	return s.repo.DeleteKnowledge(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id)
+	if err := s.repo.DeleteKnowledge(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id); err != nil {
+		return err
+	}
+	s.publishKnowledgeDeleted(ctx, knowledge)
+	return nil
 }
 
 // DeleteKnowledgeList deletes a knowledge entry and all related resources
@@ -917,7 +944,25 @@ func (s *knowledgeService) DeleteKnowledgeList(ctx context.Context, ids []string
 		return err
 	}
 	// 5. Delete the knowledge entry itself from the database
-	return s.repo.DeleteKnowledgeList(ctx, tenantInfo.ID, ids)
+	if err := s.repo.DeleteKnowledgeList(ctx, tenantInfo.ID, ids); err != nil {
+		return err
+	}
+	for _, knowledge := range knowledgeList {
+		s.publishKnowledgeDeleted(ctx, knowledge)
+	}
+	return nil
+}
+
+// publishKnowledgeDeleted notifies webhook subscribers that a knowledge was deleted
+func (s *knowledgeService) publishKnowledgeDeleted(ctx context.Context, knowledge *types.Knowledge) {
+	s.webhookService.Publish(ctx, knowledge.TenantID, types.WebhookEventKnowledgeDeleted, map[string]interface{}{
+		"knowledge_id":      knowledge.ID,
+		"knowledge_base_id": knowledge.KnowledgeBaseID,
+		"type":              knowledge.Type,
+		"title":             knowledge.Title,
+		"file_name":         knowledge.FileName,
+	})
+	s.scheduleService.TriggerKnowledgeEvent(ctx, types.WebhookEventKnowledgeDeleted, knowledge)
 }
 
 func (s *knowledgeService) cloneKnowledge(
@@ -1019,6 +1064,29 @@ type ProcessChunksOptions struct {
 }
 
 // processChunks processes chunks and creates embeddings for knowledge content
+// publishParseResult publishes the parse result of a knowledge once parsing reached a terminal state
+func (s *knowledgeService) publishParseResult(ctx context.Context, knowledge *types.Knowledge) {
+	var eventType types.WebhookEventType
+	switch knowledge.ParseStatus {
+	case types.ParseStatusCompleted:
+		eventType = types.WebhookEventKnowledgeParsed
+	case types.ParseStatusFailed:
+		eventType = types.WebhookEventKnowledgeFailed
+	default:
+		return
+	}
+	s.webhookService.Publish(ctx, knowledge.TenantID, eventType, map[string]interface{}{
+		"knowledge_id":      knowledge.ID,
+		"knowledge_base_id": knowledge.KnowledgeBaseID,
+		"type":              knowledge.Type,
+		"title":             knowledge.Title,
+		"file_name":         knowledge.FileName,
+		"parse_status":      knowledge.ParseStatus,
+		"error_message":     knowledge.ErrorMessage,
+	})
+	s.scheduleService.TriggerKnowledgeEvent(ctx, eventType, knowledge)
+}
+
 func (s *knowledgeService) processChunks(ctx context.Context,
 	kb *types.KnowledgeBase, knowledge *types.Knowledge, chunks []*proto.Chunk,
 	opts ...ProcessChunksOptions,
@@ -1031,6 +1099,8 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 
 	ctx, span := tracing.ContextWithSpan(ctx, "knowledgeService.processChunks")
 	defer span.End()
+	// 解析结束（完成或失败）后通知 webhook 订阅方
+	defer s.publishParseResult(ctx, knowledge)
 	span.SetAttributes(
 		attribute.Int("tenant_id", int(knowledge.TenantID)),
 		attribute.String("knowledge_base_id", knowledge.KnowledgeBaseID),
@@ -4447,7 +4517,15 @@ func (s *knowledgeService) updateFAQImportProgressStatus(
 		}
 	}
 
-	return s.saveFAQImportProgress(ctx, existingProgress)
+	if err := s.saveFAQImportProgress(ctx, existingProgress); err != nil {
+		return err
+	}
+	if status == types.FAQImportStatusCompleted || status == types.FAQImportStatusFailed {
+		if tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64); ok {
+			s.webhookService.Publish(ctx, tenantID, types.WebhookEventFAQImportFinished, existingProgress)
+		}
+	}
+	return nil
 }
 
 // getRunningFAQImportTaskID checks if there's a running FAQ import task for the given KB
@@ -4946,6 +5024,7 @@ func (s *knowledgeService) triggerManualProcessing(ctx context.Context,
 			knowledge.ErrorMessage = cfgErr.Error()
 			knowledge.UpdatedAt = time.Now()
 			s.repo.UpdateKnowledge(ctx, knowledge)
+			s.publishParseResult(ctx, knowledge)
 			return
 		}
 		if cfg == nil {
@@ -4987,6 +5066,7 @@ func (s *knowledgeService) triggerManualProcessing(ctx context.Context,
 		knowledge.ErrorMessage = err.Error()
 		knowledge.UpdatedAt = time.Now()
 		s.repo.UpdateKnowledge(ctx, knowledge)
+		s.publishParseResult(ctx, knowledge)
 		return
 	}
 
@@ -5173,6 +5253,14 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		logger.Warnf(ctx, "Unexpected parse status: %s for knowledge: %s", knowledge.ParseStatus, payload.KnowledgeID)
 	}
 
+	// processChunks 会自行通知解析结果，这里只通知进入分块处理前的失败；被限流延后的任务不通知
+	chunksProcessed, throttled := false, false
+	defer func() {
+		if !chunksProcessed && !throttled {
+			s.publishParseResult(ctx, knowledge)
+		}
+	}()
+
 	// 获取知识库信息
 	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, payload.KnowledgeBaseID)
 	if err != nil {
@@ -5184,13 +5272,6 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		return nil
 	}
 
-	knowledge.ParseStatus = "processing"
-	knowledge.UpdatedAt = time.Now()
-	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
-		logger.Errorf(ctx, "failed to update knowledge status to processing: %v", err)
-		return nil
-	}
-
 	// 构建VLM配置（如果需要）
 	var vlmConfig *proto.VLMConfig
 	if payload.EnableMultimodel {
@@ -5216,6 +5297,23 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		return nil
 	}
 
+	// 多模态解析由 docreader 调用 VLM，租户当前分钟的 VLM 额度用尽时延后任务
+	// 在更新为 processing 之前检查，延后的文档保持原状态
+	useVLM := vlmConfig != nil && len(payload.Passages) == 0
+	if useVLM && s.limiter.Remaining(ctx, payload.TenantID, types.RateLimitVLM) == 0 {
+		logger.Infof(ctx, "Tenant %d reached its VLM call limit, deferring document %s",
+			payload.TenantID, payload.KnowledgeID)
+		throttled = true
+		return types.ErrTaskThrottled
+	}
+
+	knowledge.ParseStatus = "processing"
+	knowledge.UpdatedAt = time.Now()
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		logger.Errorf(ctx, "failed to update knowledge status to processing: %v", err)
+		return nil
+	}
+
 	// 处理不同类型的导入：文件、URL、文本段落
 	var chunks []*proto.Chunk
 	if payload.URL != "" {
@@ -5273,6 +5371,7 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 			chunks = append(chunks, chunk)
 		}
 		// 直接处理chunks，不需要调用docReader
+		chunksProcessed = true
 		s.processChunks(ctx, kb, knowledge, chunks)
 		return nil
 	} else {
@@ -5343,7 +5442,17 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		chunks = fileResp.Chunks
 	}
 
+	if useVLM {
+		// 每张图片的描述生成计为一次 VLM 调用
+		imageCount := 0
+		for _, chunk := range chunks {
+			imageCount += len(chunk.Images)
+		}
+		s.limiter.Record(ctx, payload.TenantID, types.RateLimitVLM, imageCount)
+	}
+
 	// 处理chunks（这会更新状态为completed）
+	chunksProcessed = true
 	s.processChunks(ctx, kb, knowledge, chunks, ProcessChunksOptions{
 		EnableQuestionGeneration: payload.EnableQuestionGeneration,
 		QuestionCount:            payload.QuestionCount,
@@ -5974,7 +6083,15 @@ func (s *knowledgeService) saveKBCloneProgress(ctx context.Context, progress *ty
 	if err != nil {
 		return fmt.Errorf("failed to marshal progress: %w", err)
 	}
-	return s.redisClient.Set(ctx, key, data, kbCloneProgressTTL).Err()
+	if err := s.redisClient.Set(ctx, key, data, kbCloneProgressTTL).Err(); err != nil {
+		return err
+	}
+	if progress.Status == types.KBCloneStatusCompleted || progress.Status == types.KBCloneStatusFailed {
+		if tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64); ok {
+			s.webhookService.Publish(ctx, tenantID, types.WebhookEventKBCloneFinished, progress)
+		}
+	}
+	return nil
 }
 
 // SaveKBCloneProgress saves the KB clone progress to Redis (public method for handler use)
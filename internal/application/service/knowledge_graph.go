@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	defaultGraphNeighbourhoodDepth = 1
+	maxGraphNeighbourhoodDepth     = 3
+	// graphNeighbourhoodLimit bounds the relations returned for a neighbourhood
+	graphNeighbourhoodLimit = 300
+	defaultGraphPathDepth   = 4
+	maxGraphPathDepth       = 6
+	// graphPathHopLimit bounds the relations followed from one hop of a path search
+	graphPathHopLimit = 2000
+	// maxGraphSourceChunks bounds the source chunks returned for an entity or relation
+	maxGraphSourceChunks  = 100
+	maxGraphMergeEntities = 50
+)
+
+// knowledgeGraphService implements the KnowledgeGraphService interface
+type knowledgeGraphService struct {
+	kbService interfaces.KnowledgeBaseService
+	graphRepo interfaces.RetrieveGraphRepository
+	chunkRepo interfaces.ChunkRepository
+}
+
+// NewKnowledgeGraphService creates a new knowledge graph service
+func NewKnowledgeGraphService(
+	kbService interfaces.KnowledgeBaseService,
+	graphRepo interfaces.RetrieveGraphRepository,
+	chunkRepo interfaces.ChunkRepository,
+) interfaces.KnowledgeGraphService {
+	return &knowledgeGraphService{
+		kbService: kbService,
+		graphRepo: graphRepo,
+		chunkRepo: chunkRepo,
+	}
+}
+
+// ListEntities lists the entities of a knowledge base whose name contains the keyword
+func (s *knowledgeGraphService) ListEntities(ctx context.Context, kbID, keyword string,
+	page *types.Pagination,
+) (*types.PageResult, error) {
+	if err := s.checkKnowledgeBase(ctx, kbID); err != nil {
+		return nil, err
+	}
+	entities, total, err := s.graphRepo.ListEntities(ctx, kbID, strings.TrimSpace(keyword),
+		page.Offset(), page.Limit())
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return types.NewPageResult(total, page, entities), nil
+}
+
+// GetEntity gets an entity of a knowledge base by name
+func (s *knowledgeGraphService) GetEntity(ctx context.Context, kbID, name string) (*types.GraphEntity, error) {
+	if err := s.checkKnowledgeBase(ctx, kbID); err != nil {
+		return nil, err
+	}
+	return s.getEntity(ctx, kbID, name)
+}
+
+func (s *knowledgeGraphService) getEntity(ctx context.Context, kbID, name string) (*types.GraphEntity, error) {
+	entity, err := s.graphRepo.GetEntity(ctx, kbID, name)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	if entity == nil {
+		return nil, werrors.NewNotFoundError("Entity not found: " + name)
+	}
+	return entity, nil
+}
+
+// GetNeighbourhood returns the entities within depth hops of an entity
+func (s *knowledgeGraphService) GetNeighbourhood(ctx context.Context, kbID, name string,
+	depth int,
+) (*types.GraphData, error) {
+	if depth == 0 {
+		depth = defaultGraphNeighbourhoodDepth
+	}
+	if depth < 1 || depth > maxGraphNeighbourhoodDepth {
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("depth must be between 1 and %d", maxGraphNeighbourhoodDepth))
+	}
+	if _, err := s.GetEntity(ctx, kbID, name); err != nil {
+		return nil, err
+	}
+	graph, err := s.graphRepo.ExpandEntities(ctx, kbID, []string{name}, depth, graphNeighbourhoodLimit)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return graph, nil
+}
+
+// FindPath finds a shortest path between two entities by breadth-first search over the entity names
+func (s *knowledgeGraphService) FindPath(ctx context.Context, kbID, from, to string,
+	maxDepth int,
+) (*types.GraphPath, error) {
+	if maxDepth == 0 {
+		maxDepth = defaultGraphPathDepth
+	}
+	if maxDepth < 1 || maxDepth > maxGraphPathDepth {
+		return nil, werrors.NewBadRequestError(fmt.Sprintf("max_depth must be between 1 and %d", maxGraphPathDepth))
+	}
+	if _, err := s.GetEntity(ctx, kbID, from); err != nil {
+		return nil, err
+	}
+	if _, err := s.getEntity(ctx, kbID, to); err != nil {
+		return nil, err
+	}
+
+	// previous 记录每个已访问实体在最短路径上的前一跳
+	type hop struct {
+		from     string
+		relation *types.GraphRelation
+	}
+	previous := map[string]*hop{from: nil}
+	frontier := []string{from}
+	for depth := 0; depth < maxDepth && len(frontier) > 0; depth++ {
+		if _, found := previous[to]; found {
+			break
+		}
+		graph, err := s.graphRepo.ExpandEntities(ctx, kbID, frontier, 1, graphPathHopLimit)
+		if err != nil {
+			return nil, graphError(err)
+		}
+		inFrontier := make(map[string]bool, len(frontier))
+		for _, name := range frontier {
+			inFrontier[name] = true
+		}
+		var next []string
+		for _, rel := range graph.Relation {
+			for _, ends := range [][2]string{{rel.Node1, rel.Node2}, {rel.Node2, rel.Node1}} {
+				if _, visited := previous[ends[1]]; visited || !inFrontier[ends[0]] {
+					continue
+				}
+				previous[ends[1]] = &hop{from: ends[0], relation: rel}
+				next = append(next, ends[1])
+			}
+		}
+		frontier = next
+	}
+	if _, found := previous[to]; !found {
+		return nil, werrors.NewNotFoundError(
+			fmt.Sprintf("No path of at most %d hops between %s and %s", maxDepth, from, to))
+	}
+
+	path := &types.GraphPath{Node: []string{to}, Relation: []*types.GraphRelation{}}
+	for step := previous[to]; step != nil; step = previous[step.from] {
+		path.Node = append([]string{step.from}, path.Node...)
+		path.Relation = append([]*types.GraphRelation{step.relation}, path.Relation...)
+	}
+	return path, nil
+}
+
+// ListEntityChunks lists the chunks an entity was extracted from
+func (s *knowledgeGraphService) ListEntityChunks(ctx context.Context, kbID, name string) ([]*types.Chunk, error) {
+	entity, err := s.GetEntity(ctx, kbID, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadChunks(ctx, kbID, entity.Chunks)
+}
+
+// ListRelationChunks lists the chunks the relations between two entities were extracted from
+func (s *knowledgeGraphService) ListRelationChunks(ctx context.Context, kbID, source, target,
+	relationType string,
+) ([]*types.Chunk, error) {
+	if _, err := s.GetEntity(ctx, kbID, source); err != nil {
+		return nil, err
+	}
+	graph, err := s.graphRepo.ExpandEntities(ctx, kbID, []string{source}, 1, graphPathHopLimit)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	var chunkIDs []string
+	found := false
+	for _, rel := range graph.Relation {
+		connects := (rel.Node1 == source && rel.Node2 == target) || (rel.Node1 == target && rel.Node2 == source)
+		if !connects || (relationType != "" && rel.Type != relationType) {
+			continue
+		}
+		found = true
+		for _, id := range rel.Chunks {
+			if !slices.Contains(chunkIDs, id) {
+				chunkIDs = append(chunkIDs, id)
+			}
+		}
+	}
+	if !found {
+		return nil, werrors.NewNotFoundError(fmt.Sprintf("No relation between %s and %s", source, target))
+	}
+	return s.loadChunks(ctx, kbID, chunkIDs)
+}
+
+// UpdateEntity corrects the name or attributes of an entity
+func (s *knowledgeGraphService) UpdateEntity(ctx context.Context, kbID, name string,
+	update *types.GraphEntityUpdate,
+) (*types.GraphEntity, error) {
+	if update.Name != nil && strings.TrimSpace(*update.Name) == "" {
+		return nil, werrors.NewBadRequestError("Entity name cannot be empty")
+	}
+	if _, err := s.GetEntity(ctx, kbID, name); err != nil {
+		return nil, err
+	}
+	if update.Attributes != nil {
+		if err := s.graphRepo.UpdateEntityAttributes(ctx, kbID, name, *update.Attributes); err != nil {
+			return nil, graphError(err)
+		}
+	}
+	if update.Name != nil {
+		if newName := strings.TrimSpace(*update.Name); newName != name {
+			// 重命名为已有实体时两者合并
+			if err := s.graphRepo.MergeEntities(ctx, kbID, []string{name}, newName); err != nil {
+				return nil, graphError(err)
+			}
+			logger.Infof(ctx, "Renamed entity %s to %s in knowledge base %s", name, newName, kbID)
+			name = newName
+		}
+	}
+	return s.getEntity(ctx, kbID, name)
+}
+
+// MergeEntities merges entities into the target entity
+func (s *knowledgeGraphService) MergeEntities(ctx context.Context, kbID string, sources []string,
+	target string,
+) (*types.GraphEntity, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil, werrors.NewBadRequestError("Target entity name cannot be empty")
+	}
+	var names []string
+	for _, source := range sources {
+		if source != target && !slices.Contains(names, source) {
+			names = append(names, source)
+		}
+	}
+	if len(names) == 0 {
+		return nil, werrors.NewBadRequestError("At least one entity other than the target must be merged")
+	}
+	if len(names) > maxGraphMergeEntities {
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("At most %d entities can be merged at once", maxGraphMergeEntities))
+	}
+	if err := s.checkKnowledgeBase(ctx, kbID); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if _, err := s.getEntity(ctx, kbID, name); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.graphRepo.MergeEntities(ctx, kbID, names, target); err != nil {
+		return nil, graphError(err)
+	}
+	logger.Infof(ctx, "Merged %d entities into %s in knowledge base %s", len(names), target, kbID)
+	return s.getEntity(ctx, kbID, target)
+}
+
+// checkKnowledgeBase checks that the knowledge base belongs to the current tenant
+func (s *knowledgeGraphService) checkKnowledgeBase(ctx context.Context, kbID string) error {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		return werrors.NewNotFoundError("knowledge base not found")
+	}
+	return nil
+}
+
+// loadChunks loads the chunks of the knowledge base with the IDs, in the order of the IDs
+func (s *knowledgeGraphService) loadChunks(ctx context.Context, kbID string, ids []string) ([]*types.Chunk, error) {
+	if len(ids) > maxGraphSourceChunks {
+		ids = ids[:maxGraphSourceChunks]
+	}
+	if len(ids) == 0 {
+		return []*types.Chunk{}, nil
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	chunks, err := s.chunkRepo.ListChunksByID(ctx, tenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*types.Chunk, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.KnowledgeBaseID == kbID {
+			byID[chunk.ID] = chunk
+		}
+	}
+	// 已删除的分块不再返回
+	ordered := make([]*types.Chunk, 0, len(byID))
+	for _, id := range ids {
+		if chunk, ok := byID[id]; ok {
+			ordered = append(ordered, chunk)
+		}
+	}
+	return ordered, nil
+}
+
+// graphError maps repository errors to application errors
+func graphError(err error) error {
+	if errors.Is(err, types.ErrGraphStoreDisabled) {
+		return werrors.NewBadRequestError("Knowledge graph storage is not enabled")
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryGraphRepository keeps the relations of one knowledge base in memory
+type memoryGraphRepository struct {
+	interfaces.RetrieveGraphRepository
+	relations []*types.GraphRelation
+	merged    []string
+}
+
+func (r *memoryGraphRepository) GetEntity(ctx context.Context, kbID, name string) (*types.GraphEntity, error) {
+	for _, rel := range r.relations {
+		if rel.Node1 == name || rel.Node2 == name {
+			return &types.GraphEntity{Name: name}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryGraphRepository) ExpandEntities(ctx context.Context, kbID string, names []string,
+	depth, limit int,
+) (*types.GraphData, error) {
+	graph := &types.GraphData{}
+	for _, rel := range r.relations {
+		if slices.Contains(names, rel.Node1) || slices.Contains(names, rel.Node2) {
+			graph.Relation = append(graph.Relation, rel)
+		}
+	}
+	return graph, nil
+}
+
+func (r *memoryGraphRepository) MergeEntities(ctx context.Context, kbID string, sources []string,
+	target string,
+) error {
+	r.merged = append(sources, target)
+	return nil
+}
+
+// tenantKnowledgeBaseService owns the knowledge base "kb" of tenant 1
+type tenantKnowledgeBaseService struct {
+	interfaces.KnowledgeBaseService
+}
+
+func (s *tenantKnowledgeBaseService) GetKnowledgeBaseByID(ctx context.Context, id string) (*types.KnowledgeBase, error) {
+	return &types.KnowledgeBase{ID: id, TenantID: 1}, nil
+}
+
+func newTestKnowledgeGraphService(relations ...*types.GraphRelation) (*knowledgeGraphService, *memoryGraphRepository) {
+	repo := &memoryGraphRepository{relations: relations}
+	return &knowledgeGraphService{kbService: &tenantKnowledgeBaseService{}, graphRepo: repo}, repo
+}
+
+func TestFindGraphPath(t *testing.T) {
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	service, _ := newTestKnowledgeGraphService(
+		&types.GraphRelation{Node1: "Docker", Node2: "containerd", Type: "uses"},
+		&types.GraphRelation{Node1: "Kubernetes", Node2: "containerd", Type: "uses"},
+		&types.GraphRelation{Node1: "Kubernetes", Node2: "etcd", Type: "stores state in"},
+		&types.GraphRelation{Node1: "Docker", Node2: "Kubernetes", Type: "runs on"},
+		&types.GraphRelation{Node1: "Redis", Node2: "Sentinel", Type: "monitored by"},
+	)
+
+	path, err := service.FindPath(ctx, "kb", "Docker", "etcd", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Docker", "Kubernetes", "etcd"}, path.Node)
+	require.Equal(t, 2, path.Length())
+	assert.Equal(t, "runs on", path.Relation[0].Type)
+	assert.Equal(t, "stores state in", path.Relation[1].Type)
+
+	_, err = service.FindPath(ctx, "kb", "Docker", "etcd", 1)
+	appErr, ok := werrors.IsAppError(err)
+	require.True(t, ok)
+	assert.Equal(t, werrors.ErrNotFound, appErr.Code, "etcd is two hops away")
+
+	_, err = service.FindPath(ctx, "kb", "Docker", "Redis", 0)
+	assert.Error(t, err, "entities of different components are not connected")
+
+	_, err = service.FindPath(ctx, "kb", "Docker", "Unknown", 0)
+	assert.Error(t, err)
+}
+
+func TestMergeGraphEntitiesValidation(t *testing.T) {
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	service, repo := newTestKnowledgeGraphService(
+		&types.GraphRelation{Node1: "OpenAI", Node2: "GPT-4", Type: "develops"},
+		&types.GraphRelation{Node1: "OpenAI Inc.", Node2: "ChatGPT", Type: "develops"},
+	)
+
+	_, err := service.MergeEntities(ctx, "kb", []string{"OpenAI"}, "OpenAI")
+	assert.Error(t, err, "merging an entity into itself does nothing")
+
+	_, err = service.MergeEntities(ctx, "kb", []string{"OpenAI Inc.", "openai"}, "OpenAI")
+	assert.Error(t, err, "unknown source entities are rejected")
+	assert.Nil(t, repo.merged)
+
+	entity, err := service.MergeEntities(ctx, "kb", []string{"OpenAI Inc.", "OpenAI", "OpenAI Inc."}, " OpenAI ")
+	require.NoError(t, err)
+	assert.Equal(t, "OpenAI", entity.Name)
+	assert.Equal(t, []string{"OpenAI Inc.", "OpenAI"}, repo.merged)
+}
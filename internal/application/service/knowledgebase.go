@@ -30,6 +30,7 @@ type knowledgeBaseService struct {
 	tenantRepo     interfaces.TenantRepository
 	fileSvc        interfaces.FileService
 	graphEngine    interfaces.RetrieveGraphRepository
+	communityRepo  interfaces.GraphCommunityRepository
 	asynqClient    *asynq.Client
 }
 
@@ -42,6 +43,7 @@ func NewKnowledgeBaseService(repo interfaces.KnowledgeBaseRepository,
 	tenantRepo interfaces.TenantRepository,
 	fileSvc interfaces.FileService,
 	graphEngine interfaces.RetrieveGraphRepository,
+	communityRepo interfaces.GraphCommunityRepository,
 	asynqClient *asynq.Client,
 ) interfaces.KnowledgeBaseService {
 	return &knowledgeBaseService{
@@ -53,6 +55,7 @@ func NewKnowledgeBaseService(repo interfaces.KnowledgeBaseRepository,
 		tenantRepo:     tenantRepo,
 		fileSvc:        fileSvc,
 		graphEngine:    graphEngine,
+		communityRepo:  communityRepo,
 		asynqClient:    asynqClient,
 	}
 }
@@ -376,6 +379,9 @@ func (s *knowledgeBaseService) ProcessKBDelete(ctx context.Context, t *asynq.Tas
 				logger.Warnf(ctx, "Failed to delete knowledge graph: %v", err)
 			}
 		}
+		if err := s.communityRepo.DeleteCommunities(ctx, kbID); err != nil {
+			logger.Warnf(ctx, "Failed to delete knowledge graph communities: %v", err)
+		}
 
 		// Delete all knowledge entries from database
 		logger.Infof(ctx, "Deleting knowledge entries from database")
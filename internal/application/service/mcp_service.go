@@ -334,6 +334,16 @@ func (s *mcpServiceService) TestMCPService(
 		resources = []*types.MCPResource{}
 	}
 
+	// List prompts (only when the server declares the prompts capability)
+	prompts := []*types.MCPPrompt{}
+	if initResult.Capabilities.Prompts != nil {
+		prompts, err = client.ListPrompts(testCtx)
+		if err != nil {
+			logger.GetLogger(ctx).Warnf("Failed to list prompts: %v", err)
+			prompts = []*types.MCPPrompt{}
+		}
+	}
+
 	return &types.MCPTestResult{
 		Success: true,
 		Message: fmt.Sprintf(
@@ -343,6 +353,7 @@ func (s *mcpServiceService) TestMCPService(
 		),
 		Tools:     tools,
 		Resources: resources,
+		Prompts:   prompts,
 	}, nil
 }
 
@@ -406,6 +417,44 @@ func (s *mcpServiceService) GetMCPServiceResources(
 	return resources, nil
 }
 
+// GetMCPServicePrompts retrieves the list of prompts from an MCP service
+func (s *mcpServiceService) GetMCPServicePrompts(
+	ctx context.Context,
+	tenantID uint64,
+	id string,
+) ([]*types.MCPPrompt, error) {
+	// Get service
+	service, err := s.mcpServiceRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MCP service: %w", err)
+	}
+	if service == nil {
+		return nil, fmt.Errorf("MCP service not found")
+	}
+
+	// Get or create client
+	client, err := s.mcpManager.GetOrCreateClient(service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MCP client: %w", err)
+	}
+	if service.TransportType == types.MCPTransportStdio {
+		defer client.Disconnect()
+	}
+
+	// Servers without the prompts capability have no prompts to offer
+	if client.GetServerCapabilities().Prompts == nil {
+		return []*types.MCPPrompt{}, nil
+	}
+
+	// List prompts
+	prompts, err := client.ListPrompts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompts: %w", err)
+	}
+
+	return prompts, nil
+}
+
 // equalStringSlices compares two string slices for equality
 func equalStringSlices(a, b []string) bool {
 	if len(a) != len(b) {
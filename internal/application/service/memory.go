@@ -0,0 +1,529 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	defaultMemoryExtractDelay    = 10 * time.Minute
+	defaultMemoryExtractRounds   = 10
+	defaultMemoryRecallTopK      = 5
+	defaultMemoryRecallThreshold = 0.3
+	defaultMemoryMaxPerUser      = 200
+	// memoryMaxLength bounds the length of a single memory
+	memoryMaxLength = 500
+	// memoryMergeThreshold is the similarity above which an extracted fact replaces an existing memory
+	memoryMergeThreshold = 0.92
+)
+
+// defaultMemoryExtractPrompt instructs the model to extract durable facts about the user
+const defaultMemoryExtractPrompt = `You maintain a long-term memory of durable facts about a user, collected from their conversations with an assistant.
+
+Read the conversation and the memories already known, then list facts worth remembering for future conversations:
+- Stable information about the user: role, background, projects, goals, preferences, constraints and habits.
+- Explicit requests about how the assistant should answer them (language, format, level of detail).
+- Do NOT record one-off questions, the assistant's answers, temporary tasks, or anything sensitive such as passwords, keys or contact details.
+- If a fact updates or contradicts a known memory, write the updated fact in full.
+- Do NOT repeat memories that are already known and unchanged.
+- Each fact is one short self-contained sentence about the user, written in the language of the conversation.
+
+Respond with a JSON array of strings only, e.g. ["The user is a backend engineer working on a payment system."]. Respond with [] if there is nothing new.`
+
+// userMemoryService implements the UserMemoryService interface
+type userMemoryService struct {
+	repo            interfaces.UserMemoryRepository
+	sessionRepo     interfaces.SessionRepository
+	messageRepo     interfaces.MessageRepository
+	modelService    interfaces.ModelService
+	task            *asynq.Client
+	extractDelay    time.Duration
+	extractRounds   int
+	recallTopK      int
+	recallThreshold float64
+	maxPerUser      int
+	extractPrompt   string
+}
+
+// NewUserMemoryService creates a new user memory service
+func NewUserMemoryService(
+	repo interfaces.UserMemoryRepository,
+	sessionRepo interfaces.SessionRepository,
+	messageRepo interfaces.MessageRepository,
+	modelService interfaces.ModelService,
+	task *asynq.Client,
+	cfg *config.Config,
+) interfaces.UserMemoryService {
+	s := &userMemoryService{
+		repo:            repo,
+		sessionRepo:     sessionRepo,
+		messageRepo:     messageRepo,
+		modelService:    modelService,
+		task:            task,
+		extractDelay:    defaultMemoryExtractDelay,
+		extractRounds:   defaultMemoryExtractRounds,
+		recallTopK:      defaultMemoryRecallTopK,
+		recallThreshold: defaultMemoryRecallThreshold,
+		maxPerUser:      defaultMemoryMaxPerUser,
+		extractPrompt:   defaultMemoryExtractPrompt,
+	}
+	if cfg.Memory != nil {
+		if cfg.Memory.ExtractDelay > 0 {
+			s.extractDelay = time.Duration(cfg.Memory.ExtractDelay) * time.Second
+		}
+		if cfg.Memory.ExtractRounds > 0 {
+			s.extractRounds = cfg.Memory.ExtractRounds
+		}
+		if cfg.Memory.RecallTopK > 0 {
+			s.recallTopK = cfg.Memory.RecallTopK
+		}
+		if cfg.Memory.RecallThreshold > 0 {
+			s.recallThreshold = cfg.Memory.RecallThreshold
+		}
+		if cfg.Memory.MaxPerUser > 0 {
+			s.maxPerUser = cfg.Memory.MaxPerUser
+		}
+		if cfg.Memory.ExtractPrompt != "" {
+			s.extractPrompt = cfg.Memory.ExtractPrompt
+		}
+	}
+	return s
+}
+
+// memoryOwner returns the tenant and user of the request, memories are only available to logged-in users
+func memoryOwner(ctx context.Context) (uint64, string, error) {
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	userID, _ := ctx.Value(types.UserIDContextKey).(string)
+	if userID == "" {
+		return 0, "", werrors.NewForbiddenError("Memories are only available to logged-in users")
+	}
+	return tenantID, userID, nil
+}
+
+// normalizeMemoryContent trims a memory and checks its length
+func normalizeMemoryContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", werrors.NewBadRequestError("Memory content is required")
+	}
+	if len([]rune(content)) > memoryMaxLength {
+		return "", werrors.NewBadRequestError(fmt.Sprintf("Memory content must not exceed %d characters", memoryMaxLength))
+	}
+	return content, nil
+}
+
+// ListMemories lists the memories of the current user
+func (s *userMemoryService) ListMemories(ctx context.Context) ([]*types.UserMemory, error) {
+	tenantID, userID, err := memoryOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListMemories(ctx, tenantID, userID)
+}
+
+// CreateMemory adds a memory for the current user, its embedding is generated on the next recall
+func (s *userMemoryService) CreateMemory(ctx context.Context, content string) (*types.UserMemory, error) {
+	tenantID, userID, err := memoryOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if content, err = normalizeMemoryContent(content); err != nil {
+		return nil, err
+	}
+
+	memory := &types.UserMemory{TenantID: tenantID, UserID: userID, Content: content}
+	if err := s.repo.CreateMemory(ctx, memory); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"user_id": userID})
+		return nil, err
+	}
+	s.prune(ctx, tenantID, userID)
+	logger.Infof(ctx, "Memory %s created for user %s", memory.ID, userID)
+	return memory, nil
+}
+
+// UpdateMemory changes the content of a memory of the current user
+func (s *userMemoryService) UpdateMemory(ctx context.Context, id string, content string) (*types.UserMemory, error) {
+	tenantID, userID, err := memoryOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if content, err = normalizeMemoryContent(content); err != nil {
+		return nil, err
+	}
+
+	memory, err := s.repo.GetMemory(ctx, tenantID, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if memory == nil {
+		return nil, werrors.NewNotFoundError("Memory not found")
+	}
+	memory.Content = content
+	// 内容变化后向量失效，下次召回时重新生成
+	memory.Embedding = nil
+	memory.EmbeddingModelID = ""
+	if err := s.repo.UpdateMemory(ctx, memory); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"memory_id": id})
+		return nil, err
+	}
+	return memory, nil
+}
+
+// DeleteMemory deletes a memory of the current user
+func (s *userMemoryService) DeleteMemory(ctx context.Context, id string) error {
+	tenantID, userID, err := memoryOwner(ctx)
+	if err != nil {
+		return err
+	}
+	memory, err := s.repo.GetMemory(ctx, tenantID, userID, id)
+	if err != nil {
+		return err
+	}
+	if memory == nil {
+		return werrors.NewNotFoundError("Memory not found")
+	}
+	return s.repo.DeleteMemories(ctx, tenantID, userID, []string{id})
+}
+
+// ClearMemories deletes all memories of the current user
+func (s *userMemoryService) ClearMemories(ctx context.Context) error {
+	tenantID, userID, err := memoryOwner(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Clearing memories of user %s", userID)
+	return s.repo.DeleteAllMemories(ctx, tenantID, userID)
+}
+
+// RecallMemories returns the memories of a user most relevant to a query.
+// Without an embedding model the most recently updated memories are returned.
+func (s *userMemoryService) RecallMemories(
+	ctx context.Context, userID string, query string, embeddingModelID string,
+) ([]*types.UserMemory, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	memories, err := s.repo.ListMemories(ctx, tenantID, userID)
+	if err != nil || len(memories) == 0 {
+		return nil, err
+	}
+	if embeddingModelID == "" {
+		return memories[:min(len(memories), s.recallTopK)], nil
+	}
+
+	if err := s.ensureEmbeddings(ctx, memories, embeddingModelID); err != nil {
+		return nil, err
+	}
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, embeddingModelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embedding model: %w", err)
+	}
+	queryEmbedding, err := embedder.Embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	return rankMemories(memories, queryEmbedding, s.recallThreshold, s.recallTopK), nil
+}
+
+// ensureEmbeddings generates the embeddings of memories that are missing or come from another model
+func (s *userMemoryService) ensureEmbeddings(
+	ctx context.Context, memories []*types.UserMemory, embeddingModelID string,
+) error {
+	var stale []*types.UserMemory
+	var contents []string
+	for _, memory := range memories {
+		if memory.EmbeddingModelID != embeddingModelID || len(memory.Embedding) == 0 {
+			stale = append(stale, memory)
+			contents = append(contents, memory.Content)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, embeddingModelID)
+	if err != nil {
+		return fmt.Errorf("failed to get embedding model: %w", err)
+	}
+	embeddings, err := embedder.BatchEmbed(ctx, contents)
+	if err != nil {
+		return fmt.Errorf("failed to embed memories: %w", err)
+	}
+	if len(embeddings) != len(stale) {
+		return fmt.Errorf("embedding model returned %d embeddings for %d memories", len(embeddings), len(stale))
+	}
+	for i, memory := range stale {
+		memory.Embedding = embeddings[i]
+		memory.EmbeddingModelID = embeddingModelID
+		if err := s.repo.UpdateEmbedding(ctx, memory); err != nil {
+			logger.Warnf(ctx, "Failed to save embedding of memory %s: %v", memory.ID, err)
+		}
+	}
+	logger.Infof(ctx, "Generated embeddings for %d memories with model %s", len(stale), embeddingModelID)
+	return nil
+}
+
+// rankMemories returns the topK memories whose similarity to the query reaches the threshold, most similar first
+func rankMemories(
+	memories []*types.UserMemory, queryEmbedding []float32, threshold float64, topK int,
+) []*types.UserMemory {
+	type scoredMemory struct {
+		memory *types.UserMemory
+		score  float64
+	}
+	var scored []scoredMemory
+	for _, memory := range memories {
+		if score := cosineSimilarity(memory.Embedding, queryEmbedding); score >= threshold {
+			scored = append(scored, scoredMemory{memory: memory, score: score})
+		}
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+
+	result := make([]*types.UserMemory, 0, min(len(scored), topK))
+	for _, item := range scored[:min(len(scored), topK)] {
+		result = append(result, item.memory)
+	}
+	return result
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, 0 if they are not comparable
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// ScheduleExtraction extracts memories from a session once it has been idle for a while.
+// A later question in the session schedules a new extraction, the earlier one is then skipped.
+func (s *userMemoryService) ScheduleExtraction(ctx context.Context, payload *types.MemoryExtractPayload) {
+	if payload.UserID == "" || payload.ModelID == "" {
+		return
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to marshal memory extraction payload: %v", err)
+		return
+	}
+	task := asynq.NewTask(types.TypeMemoryExtract, payloadBytes,
+		asynq.Queue("low"), asynq.MaxRetry(3), asynq.ProcessIn(s.extractDelay))
+	if _, err := s.task.Enqueue(task); err != nil {
+		logger.Errorf(ctx, "Failed to enqueue memory extraction for session %s: %v", payload.SessionID, err)
+		return
+	}
+	logger.Infof(ctx, "Memory extraction scheduled for session %s in %s", payload.SessionID, s.extractDelay)
+}
+
+// ProcessExtraction handles memory extraction tasks
+func (s *userMemoryService) ProcessExtraction(ctx context.Context, t *asynq.Task) error {
+	var payload types.MemoryExtractPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "failed to unmarshal memory extraction payload: %v", err)
+		return nil
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	ctx = context.WithValue(ctx, types.UserIDContextKey, payload.UserID)
+
+	session, err := s.sessionRepo.Get(ctx, payload.TenantID, payload.SessionID)
+	if err != nil || session == nil {
+		logger.Infof(ctx, "Session %s not found, skipping memory extraction", payload.SessionID)
+		return nil
+	}
+	if session.ActiveMessageID != payload.MessageID {
+		// 会话在此之后仍有新的对话，由更晚的任务提取
+		logger.Infof(ctx, "Session %s continued, skipping memory extraction", payload.SessionID)
+		return nil
+	}
+
+	messages, err := s.messageRepo.GetRecentMessagesBySession(ctx, payload.SessionID, s.extractRounds*2)
+	if err != nil {
+		return fmt.Errorf("failed to get session messages: %w", err)
+	}
+	transcript := formatMemoryTranscript(messages)
+	if transcript == "" {
+		return nil
+	}
+
+	existing, err := s.repo.ListMemories(ctx, payload.TenantID, payload.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to list memories: %w", err)
+	}
+	facts, err := s.extractFacts(ctx, payload.ModelID, transcript, existing)
+	if err != nil {
+		return err
+	}
+	if len(facts) == 0 {
+		logger.Infof(ctx, "No new memories extracted from session %s", payload.SessionID)
+		return nil
+	}
+
+	created, updated, err := s.saveFacts(ctx, &payload, facts, existing)
+	if err != nil {
+		return err
+	}
+	s.prune(ctx, payload.TenantID, payload.UserID)
+	logger.Infof(ctx, "Memories extracted from session %s: %d created, %d updated", payload.SessionID, created, updated)
+	return nil
+}
+
+// extractFacts asks the chat model for new facts about the user in a transcript
+func (s *userMemoryService) extractFacts(
+	ctx context.Context, modelID string, transcript string, existing []*types.UserMemory,
+) ([]string, error) {
+	chatModel, err := s.modelService.GetChatModel(ctx, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat model: %w", err)
+	}
+
+	var known strings.Builder
+	for _, memory := range existing {
+		known.WriteString("- " + memory.Content + "\n")
+	}
+	if known.Len() == 0 {
+		known.WriteString("(none)\n")
+	}
+
+	thinking := false
+	resp, err := chatModel.Chat(ctx, []chat.Message{
+		{Role: "system", Content: s.extractPrompt},
+		{Role: "user", Content: "Known memories:\n" + known.String() + "\nConversation:\n" + transcript},
+	}, &chat.ChatOptions{Temperature: DefaultLLMTemperature, Thinking: &thinking})
+	if err != nil {
+		return nil, fmt.Errorf("memory extraction failed: %w", err)
+	}
+
+	var facts []string
+	if err := common.ParseLLMJsonResponse(resp.Content, &facts); err != nil {
+		logger.Warnf(ctx, "Failed to parse memory extraction response: %v, content: %s", err, resp.Content)
+		return nil, nil
+	}
+
+	// 去除空白、过长及与已有记忆完全相同的条目
+	seen := make(map[string]bool, len(existing))
+	for _, memory := range existing {
+		seen[memory.Content] = true
+	}
+	result := make([]string, 0, len(facts))
+	for _, fact := range facts {
+		fact = strings.TrimSpace(fact)
+		if fact == "" || seen[fact] || len([]rune(fact)) > memoryMaxLength {
+			continue
+		}
+		seen[fact] = true
+		result = append(result, fact)
+	}
+	return result, nil
+}
+
+// saveFacts stores extracted facts. With an embedding model, a fact nearly identical to an
+// existing memory replaces it instead of being added.
+func (s *userMemoryService) saveFacts(
+	ctx context.Context, payload *types.MemoryExtractPayload, facts []string, existing []*types.UserMemory,
+) (int, int, error) {
+	var embeddings [][]float32
+	if payload.EmbeddingModelID != "" {
+		if err := s.ensureEmbeddings(ctx, existing, payload.EmbeddingModelID); err != nil {
+			logger.Warnf(ctx, "Failed to embed existing memories, skipping merge: %v", err)
+		} else if embedder, err := s.modelService.GetEmbeddingModel(ctx, payload.EmbeddingModelID); err != nil {
+			logger.Warnf(ctx, "Failed to get embedding model, skipping merge: %v", err)
+		} else if embeddings, err = embedder.BatchEmbed(ctx, facts); err != nil || len(embeddings) != len(facts) {
+			logger.Warnf(ctx, "Failed to embed extracted memories, skipping merge: %v", err)
+			embeddings = nil
+		}
+	}
+
+	created, updated := 0, 0
+	for i, fact := range facts {
+		memory := &types.UserMemory{
+			TenantID:        payload.TenantID,
+			UserID:          payload.UserID,
+			Content:         fact,
+			SourceSessionID: payload.SessionID,
+		}
+		if embeddings != nil {
+			memory.Embedding = embeddings[i]
+			memory.EmbeddingModelID = payload.EmbeddingModelID
+			if match := mostSimilarMemory(existing, embeddings[i]); match != nil {
+				memory.ID = match.ID
+				if err := s.repo.UpdateMemory(ctx, memory); err != nil {
+					return created, updated, fmt.Errorf("failed to update memory: %w", err)
+				}
+				updated++
+				continue
+			}
+		}
+		if err := s.repo.CreateMemory(ctx, memory); err != nil {
+			return created, updated, fmt.Errorf("failed to create memory: %w", err)
+		}
+		created++
+	}
+	return created, updated, nil
+}
+
+// mostSimilarMemory returns the memory an extracted fact restates, nil if there is none
+func mostSimilarMemory(memories []*types.UserMemory, embedding []float32) *types.UserMemory {
+	var best *types.UserMemory
+	bestScore := memoryMergeThreshold
+	for _, memory := range memories {
+		if score := cosineSimilarity(memory.Embedding, embedding); score >= bestScore {
+			best, bestScore = memory, score
+		}
+	}
+	return best
+}
+
+// prune deletes the least recently updated memories of a user over the limit
+func (s *userMemoryService) prune(ctx context.Context, tenantID uint64, userID string) {
+	memories, err := s.repo.ListMemories(ctx, tenantID, userID)
+	if err != nil || len(memories) <= s.maxPerUser {
+		return
+	}
+	ids := make([]string, 0, len(memories)-s.maxPerUser)
+	for _, memory := range memories[s.maxPerUser:] {
+		ids = append(ids, memory.ID)
+	}
+	if err := s.repo.DeleteMemories(ctx, tenantID, userID, ids); err != nil {
+		logger.Warnf(ctx, "Failed to prune memories of user %s: %v", userID, err)
+		return
+	}
+	logger.Infof(ctx, "Pruned %d memories of user %s", len(ids), userID)
+}
+
+// formatMemoryTranscript renders the completed turns of a conversation for memory extraction
+func formatMemoryTranscript(messages []*types.Message) string {
+	var builder strings.Builder
+	for _, message := range messages {
+		content := strings.TrimSpace(message.Content)
+		if content == "" {
+			continue
+		}
+		switch message.Role {
+		case "user":
+			builder.WriteString("User: " + content + "\n\n")
+		case "assistant":
+			builder.WriteString("Assistant: " + content + "\n\n")
+		}
+	}
+	return builder.String()
+}
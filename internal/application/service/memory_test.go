@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankMemoriesAppliesThresholdAndTopK(t *testing.T) {
+	memories := []*types.UserMemory{
+		{ID: "orthogonal", Embedding: types.MemoryEmbedding{0, 1}},
+		{ID: "close", Embedding: types.MemoryEmbedding{1, 0.2}},
+		{ID: "exact", Embedding: types.MemoryEmbedding{2, 0}},
+		{ID: "unembedded"},
+		{ID: "other-model", Embedding: types.MemoryEmbedding{1, 0, 0}},
+	}
+
+	ranked := rankMemories(memories, []float32{1, 0}, 0.3, 5)
+	if assert.Len(t, ranked, 2) {
+		assert.Equal(t, "exact", ranked[0].ID)
+		assert.Equal(t, "close", ranked[1].ID)
+	}
+
+	ranked = rankMemories(memories, []float32{1, 0}, 0.3, 1)
+	if assert.Len(t, ranked, 1) {
+		assert.Equal(t, "exact", ranked[0].ID)
+	}
+}
+
+func TestMostSimilarMemoryRequiresNearDuplicate(t *testing.T) {
+	memories := []*types.UserMemory{
+		{ID: "related", Embedding: types.MemoryEmbedding{1, 1}},
+		{ID: "same", Embedding: types.MemoryEmbedding{1, 0.05}},
+	}
+
+	match := mostSimilarMemory(memories, []float32{1, 0})
+	if assert.NotNil(t, match) {
+		assert.Equal(t, "same", match.ID)
+	}
+	assert.Nil(t, mostSimilarMemory(memories[:1], []float32{1, 0}))
+}
+
+func TestFormatMemoryTranscriptSkipsEmptyMessages(t *testing.T) {
+	transcript := formatMemoryTranscript([]*types.Message{
+		{Role: "user", Content: " I mostly write Go. "},
+		{Role: "assistant", Content: ""},
+		{Role: "assistant", Content: "Noted."},
+	})
+	assert.Equal(t, "User: I mostly write Go.\n\nAssistant: Noted.\n\n", transcript)
+}
@@ -285,3 +285,62 @@ func (s *messageService) DeleteMessage(ctx context.Context, sessionID string, me
 	logger.Info(ctx, "Message deleted successfully")
 	return nil
 }
+
+// GetChildMessageIDs retrieves the IDs of the children of the given parent messages
+// This is used to tell clients which messages have alternative versions to switch between
+// Parameters:
+//   - ctx: Context containing tenant information
+//   - sessionID: The ID of the session containing the messages
+//   - parentIDs: IDs of the parent messages, an empty ID stands for the first message level
+//
+// Returns the child message IDs in creation order keyed by parent ID, or an error if retrieval fails
+func (s *messageService) GetChildMessageIDs(ctx context.Context,
+	sessionID string, parentIDs []string,
+) (map[string][]string, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if _, err := s.sessionRepo.Get(ctx, tenantID, sessionID); err != nil {
+		logger.Errorf(ctx, "Failed to get session: %v", err)
+		return nil, err
+	}
+
+	children, err := s.messageRepo.GetChildMessageIDs(ctx, sessionID, parentIDs)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+		})
+		return nil, err
+	}
+	return children, nil
+}
+
+// ActivateMessage switches the active branch of a session to the most recent path through a message
+// Subsequent questions and history loading follow the new branch
+// Parameters:
+//   - ctx: Context containing tenant information
+//   - sessionID: The ID of the session containing the message
+//   - messageID: The ID of the message to switch to
+//
+// Returns the last message of the new active branch or an error if switching fails
+func (s *messageService) ActivateMessage(ctx context.Context,
+	sessionID string, messageID string,
+) (*types.Message, error) {
+	logger.Infof(ctx, "Activating message, session ID: %s, message ID: %s", sessionID, messageID)
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if _, err := s.sessionRepo.Get(ctx, tenantID, sessionID); err != nil {
+		logger.Errorf(ctx, "Failed to get session: %v", err)
+		return nil, err
+	}
+
+	leaf, err := s.messageRepo.ActivateMessage(ctx, sessionID, messageID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": messageID,
+		})
+		return nil, err
+	}
+
+	logger.Infof(ctx, "Active branch switched, session ID: %s, active message ID: %s", sessionID, leaf.ID)
+	return leaf, nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// maxSearchTerms bounds the terms of a search query
+	maxSearchTerms = 8
+	// snippetContextRunes is the number of characters kept before the first match, twice as many are kept after it
+	snippetContextRunes = 60
+)
+
+// messageSearchService implements the MessageSearchService interface
+type messageSearchService struct {
+	messageRepo interfaces.MessageRepository
+}
+
+// NewMessageSearchService creates a new chat history search service
+func NewMessageSearchService(messageRepo interfaces.MessageRepository) interfaces.MessageSearchService {
+	return &messageSearchService{messageRepo: messageRepo}
+}
+
+// SearchMessages searches the messages and session titles of the current tenant
+func (s *messageSearchService) SearchMessages(
+	ctx context.Context, params *types.MessageSearchParams, page *types.Pagination,
+) (*types.PageResult, error) {
+	params.Terms = parseSearchTerms(params.Query)
+	if len(params.Terms) == 0 {
+		return nil, werrors.NewBadRequestError("query is required")
+	}
+	if params.Role != "" && params.Role != "user" && params.Role != "assistant" {
+		return nil, werrors.NewBadRequestError("role must be user or assistant")
+	}
+	if params.StartTime != nil && params.EndTime != nil && params.EndTime.Before(*params.StartTime) {
+		return nil, werrors.NewBadRequestError("end_time must not be before start_time")
+	}
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	hits, total, err := s.messageRepo.SearchMessages(ctx, tenantID, params, page)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"tenant_id": tenantID})
+		return nil, err
+	}
+
+	activeBranches := make(map[string]map[string]bool)
+	for _, hit := range hits {
+		active, ok := activeBranches[hit.SessionID]
+		if !ok {
+			active = make(map[string]bool)
+			ids, err := s.messageRepo.GetActiveBranchMessageIDs(ctx, hit.SessionID)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				active[id] = true
+			}
+			activeBranches[hit.SessionID] = active
+		}
+		hit.OnActiveBranch = active[hit.MessageID]
+		hit.Snippet = highlightSnippet(hit.Content, params.Terms, snippetContextRunes)
+		hit.TitleSnippet = highlightSnippet(hit.SessionTitle, params.Terms, 0)
+		// 消息按 created_at < before_time 加载，加一微秒使该消息成为加载结果的最后一条
+		hit.LoadBeforeTime = hit.CreatedAt.Add(time.Microsecond).Format(time.RFC3339Nano)
+	}
+	if hits == nil {
+		hits = []*types.MessageSearchHit{}
+	}
+
+	logger.Infof(ctx, "Searched chat history of tenant %d, %d terms, %d hits", tenantID, len(params.Terms), total)
+	return types.NewPageResult(total, page, hits), nil
+}
+
+// parseSearchTerms splits a query on whitespace, dropping duplicate terms (case-insensitive)
+func parseSearchTerms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, term := range strings.Fields(query) {
+		key := strings.ToLower(term)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, term)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// highlightSnippet returns an HTML-escaped excerpt of text with the terms wrapped in <mark>.
+// With a positive contextRunes, the excerpt is cut to the characters around the first match;
+// otherwise the whole text is kept.
+func highlightSnippet(text string, terms []string, contextRunes int) string {
+	runes := []rune(text)
+	lower := lowerRunes(text)
+
+	// 标记命中的字符
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		needle := lowerRunes(term)
+		if len(needle) == 0 {
+			continue
+		}
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if !runesHavePrefix(lower[i:], needle) {
+				continue
+			}
+			for j := i; j < i+len(needle); j++ {
+				marked[j] = true
+			}
+			if first == -1 || i < first {
+				first = i
+			}
+		}
+	}
+
+	start, end := 0, len(runes)
+	if contextRunes > 0 {
+		if first == -1 {
+			first = 0
+		}
+		start = max(first-contextRunes, 0)
+		end = min(first+contextRunes*2, len(runes))
+	}
+
+	var builder strings.Builder
+	if start > 0 {
+		builder.WriteString("…")
+	}
+	inMark := false
+	for i := start; i < end; i++ {
+		if marked[i] != inMark {
+			if marked[i] {
+				builder.WriteString("<mark>")
+			} else {
+				builder.WriteString("</mark>")
+			}
+			inMark = marked[i]
+		}
+		builder.WriteString(html.EscapeString(string(runes[i])))
+	}
+	if inMark {
+		builder.WriteString("</mark>")
+	}
+	if end < len(runes) {
+		builder.WriteString("…")
+	}
+	return builder.String()
+}
+
+// runesHavePrefix reports whether s begins with prefix
+func runesHavePrefix(s, prefix []rune) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for i, r := range prefix {
+		if s[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// lowerRunes lowercases text character by character, so indexes match those of []rune(text)
+func lowerRunes(text string) []rune {
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
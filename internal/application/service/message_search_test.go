@@ -0,0 +1,74 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSearchTerms(t *testing.T) {
+	terms := parseSearchTerms("  VPN  certificate vpn\tlast ")
+	want := []string{"VPN", "certificate", "last"}
+	if strings.Join(terms, ",") != strings.Join(want, ",") {
+		t.Fatalf("parseSearchTerms() = %v, want %v", terms, want)
+	}
+	if terms := parseSearchTerms("   "); len(terms) != 0 {
+		t.Fatalf("parseSearchTerms() of a blank query = %v, want none", terms)
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		terms        []string
+		contextRunes int
+		want         string
+	}{
+		{
+			name:  "case-insensitive matches are marked",
+			text:  "Renew the VPN certificate",
+			terms: []string{"vpn", "Certificate"},
+			want:  "Renew the <mark>VPN</mark> <mark>certificate</mark>",
+		},
+		{
+			name:  "overlapping matches are merged",
+			text:  "abcdef",
+			terms: []string{"bcd", "cde"},
+			want:  "a<mark>bcde</mark>f",
+		},
+		{
+			name:  "content is escaped",
+			text:  "<script>vpn</script>",
+			terms: []string{"vpn"},
+			want:  "&lt;script&gt;<mark>vpn</mark>&lt;/script&gt;",
+		},
+		{
+			name:         "long text is cut around the first match",
+			text:         "0123456789VPN0123456789",
+			terms:        []string{"vpn"},
+			contextRunes: 3,
+			want:         "…789<mark>VPN</mark>012…",
+		},
+		{
+			name:         "multibyte text keeps whole characters",
+			text:         "上个月关于证书的对话",
+			terms:        []string{"证书"},
+			contextRunes: 2,
+			want:         "…关于<mark>证书</mark>的对…",
+		},
+		{
+			name:         "text without a match starts from the beginning",
+			text:         "abcdefgh",
+			terms:        []string{"xyz"},
+			contextRunes: 2,
+			want:         "abcd…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightSnippet(tt.text, tt.terms, tt.contextRunes); got != tt.want {
+				t.Errorf("highlightSnippet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
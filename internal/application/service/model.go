@@ -20,13 +20,19 @@ var ErrModelNotFound = errors.New("model not found")
 type modelService struct {
 	repo          interfaces.ModelRepository
 	ollamaService *ollama.OllamaService
+	limiter       interfaces.TenantLimiter
 }
 
 // NewModelService creates a new model service instance
-func NewModelService(repo interfaces.ModelRepository, ollamaService *ollama.OllamaService) interfaces.ModelService {
+func NewModelService(
+	repo interfaces.ModelRepository,
+	ollamaService *ollama.OllamaService,
+	limiter interfaces.TenantLimiter,
+) interfaces.ModelService {
 	return &modelService{
 		repo:          repo,
 		ollamaService: ollamaService,
+		limiter:       limiter,
 	}
 }
 
@@ -287,7 +293,8 @@ func (s *modelService) GetEmbeddingModel(ctx context.Context, modelId string) (e
 	}
 
 	logger.Info(ctx, "Embedding model initialized successfully")
-	return embedder, nil
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	return withEmbeddingRateLimit(embedder, s.limiter, tenantID), nil
 }
 
 // GetRerankModel retrieves and initializes a reranking model instance
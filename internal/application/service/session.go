@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/Tencent/WeKnora/internal/agent/tools"
@@ -42,6 +43,10 @@ type sessionService struct {
 	knowledgeService     interfaces.KnowledgeService      // Service for knowledge operations
 	chunkService         interfaces.ChunkService          // Service for chunk operations
 	webSearchStateRepo   interfaces.WebSearchStateService // Service for web search state
+	fileService          interfaces.FileService           // Service for reading image attachments
+	limiter              interfaces.TenantLimiter         // Per-tenant model call limits
+	memoryService        interfaces.UserMemoryService     // Long-term user memories for agents
+	customAgentService   interfaces.CustomAgentService    // Custom agents that agents delegate sub-tasks to
 }
 
 // NewSessionService creates a new session service instance with all required dependencies
@@ -57,6 +62,10 @@ func NewSessionService(cfg *config.Config,
 	agentService interfaces.AgentService,
 	sessionStorage llmcontext.ContextStorage,
 	webSearchStateRepo interfaces.WebSearchStateService,
+	fileService interfaces.FileService,
+	limiter interfaces.TenantLimiter,
+	memoryService interfaces.UserMemoryService,
+	customAgentService interfaces.CustomAgentService,
 ) interfaces.SessionService {
 	return &sessionService{
 		cfg:                  cfg,
@@ -71,6 +80,10 @@ func NewSessionService(cfg *config.Config,
 		agentService:         agentService,
 		sessionStorage:       sessionStorage,
 		webSearchStateRepo:   webSearchStateRepo,
+		fileService:          fileService,
+		limiter:              limiter,
+		memoryService:        memoryService,
+		customAgentService:   customAgentService,
 	}
 }
 
@@ -395,6 +408,7 @@ func (s *sessionService) KnowledgeQA(
 	ctx context.Context,
 	session *types.Session,
 	query string,
+	images types.MessageImages,
 	knowledgeBaseIDs []string,
 	knowledgeIDs []string,
 	assistantMessageID string,
@@ -557,6 +571,14 @@ func (s *sessionService) KnowledgeQA(
 		logger.Warnf(ctx, "Failed to build search targets: %v", err)
 	}
 
+	// Load image attachments, caption them through the KB's VLM and use the captions for retrieval
+	imageDataURIs := s.loadImageDataURIs(ctx, images)
+	if len(imageDataURIs) > 0 {
+		s.captionImages(ctx, images, imageDataURIs, searchTargets)
+		query = appendImageCaptions(query, images)
+		logger.Infof(ctx, "Query augmented with %d image attachment(s)", len(images))
+	}
+
 	// Create chat management object with session settings
 	logger.Infof(
 		ctx,
@@ -597,6 +619,7 @@ func (s *sessionService) KnowledgeQA(
 		FAQPriorityEnabled:       faqPriorityEnabled,
 		FAQDirectAnswerThreshold: faqDirectAnswerThreshold,
 		FAQScoreBoost:            faqScoreBoost,
+		Images:                   s.imagesForChatModel(ctx, chatModelID, imageDataURIs),
 	}
 
 	// Determine pipeline based on knowledge bases availability and web search setting
@@ -623,6 +646,11 @@ func (s *sessionService) KnowledgeQA(
 			logger.Info(ctx, "Knowledge bases selected, using rag_stream pipeline")
 		}
 		pipeline = types.Pipline["rag_stream"]
+		if customAgent != nil && customAgent.Config.KnowledgeSearchMode == types.KnowledgeSearchModeGlobal &&
+			len(searchTargets) > 0 {
+			logger.Info(ctx, "Global knowledge search mode, using rag_global_stream pipeline")
+			pipeline = types.Pipline["rag_global_stream"]
+		}
 	}
 
 	// Start knowledge QA event processing
@@ -1061,6 +1089,7 @@ func (s *sessionService) AgentQA(
 	ctx context.Context,
 	session *types.Session,
 	query string,
+	images types.MessageImages,
 	assistantMessageID string,
 	summaryModelID string,
 	eventBus *event.EventBus,
@@ -1081,8 +1110,6 @@ func (s *sessionService) AgentQA(
 	// Build effective agent configuration by merging session and tenant configs
 	// All config now comes from customAgent parameter
 
-	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
-
 	// customAgent is required for AgentQA
 	if customAgent == nil {
 		logger.Warnf(ctx, "Custom agent not provided for session: %s", sessionID)
@@ -1092,6 +1119,138 @@ func (s *sessionService) AgentQA(
 	// Ensure defaults are set
 	customAgent.EnsureDefaults()
 
+	agentConfig := s.buildAgentConfig(ctx, customAgent, knowledgeBaseIDs, knowledgeIDs)
+	searchTargets := agentConfig.SearchTargets
+	// The agent answering the user is the root of the delegation path of its sub-agents
+	agentConfig.DelegationPath = []string{customAgent.ID}
+	agentConfig.SubAgents = s.resolveSubAgents(ctx, customAgent, agentConfig.DelegationPath)
+
+	// Recall long-term memories about the user, only for logged-in users of agents with memory enabled
+	userID, _ := ctx.Value(types.UserIDContextKey).(string)
+	memoryEnabled := customAgent.Config.MemoryEnabled && userID != ""
+	if memoryEnabled {
+		memories, err := s.memoryService.RecallMemories(ctx, userID, query, customAgent.Config.MemoryEmbeddingModelID)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to recall memories of user %s: %v", userID, err)
+		}
+		for _, memory := range memories {
+			agentConfig.UserMemories = append(agentConfig.UserMemories, memory.Content)
+		}
+		logger.Infof(ctx, "Recalled %d memories for user %s", len(agentConfig.UserMemories), userID)
+	}
+
+	// Load image attachments; captions let the agent search knowledge with the image content,
+	// the images themselves are only attached for vision-capable chat models
+	imageDataURIs := s.loadImageDataURIs(ctx, images)
+	if len(imageDataURIs) > 0 {
+		s.captionImages(ctx, images, imageDataURIs, searchTargets)
+		query = appendImageCaptions(query, images)
+		logger.Infof(ctx, "Agent query augmented with %d image attachment(s)", len(images))
+	}
+
+	// Get summary model: prioritize request's summaryModelID, then custom agent config
+	effectiveModelID := summaryModelID
+	if effectiveModelID == "" {
+		effectiveModelID = customAgent.Config.ModelID
+	}
+	if summaryModelID != "" {
+		logger.Infof(ctx, "Using request's summary model override: %s", effectiveModelID)
+	}
+	summaryModel, rerankModel, err := s.loadAgentModels(ctx, customAgent, agentConfig, effectiveModelID)
+	if err != nil {
+		return err
+	}
+
+	// Get or create contextManager for this session
+	contextManager := s.getContextManagerForSession(ctx, session, summaryModel)
+
+	// Set system prompt for the current agent in context manager
+	// This ensures the context uses the correct system prompt when switching agents
+	systemPrompt := agentConfig.ResolveSystemPrompt(agentConfig.WebSearchEnabled)
+	if systemPrompt != "" {
+		if err := contextManager.SetSystemPrompt(ctx, sessionID, systemPrompt); err != nil {
+			logger.Warnf(ctx, "Failed to set system prompt in context manager: %v", err)
+		} else {
+			logger.Infof(ctx, "System prompt updated in context manager for agent")
+		}
+	}
+
+	// Get LLM context from context manager
+	llmContext, err := s.getContextForSession(ctx, contextManager, sessionID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get LLM context: %v, continuing without history", err)
+		llmContext = []chat.Message{}
+	}
+	logger.Infof(ctx, "Loaded %d messages from LLM context manager", len(llmContext))
+
+	// Apply multi-turn configuration for Agent mode
+	// Note: In Agent mode, context is managed by contextManager with compression strategies,
+	// so we don't apply HistoryTurns limit here. HistoryTurns is used in normal (KnowledgeQA) mode.
+	if !agentConfig.MultiTurnEnabled {
+		// Multi-turn disabled, clear history
+		logger.Infof(ctx, "Multi-turn disabled for this agent, clearing history context")
+		llmContext = []chat.Message{}
+	}
+
+	// Create agent engine with EventBus and ContextManager
+	logger.Info(ctx, "Creating agent engine")
+	engine, err := s.agentService.CreateAgentEngine(
+		ctx,
+		agentConfig,
+		summaryModel,
+		rerankModel,
+		eventBus,
+		contextManager,
+		session.ID,
+		s,
+	)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to create agent engine: %v", err)
+		return err
+	}
+
+	// Execute agent with streaming (asynchronously)
+	// Events will be emitted to EventBus and handled by the Handler layer
+	logger.Info(ctx, "Executing agent with streaming")
+	queryImages := s.imagesForChatModel(ctx, effectiveModelID, imageDataURIs)
+	if _, err := engine.Execute(ctx, sessionID, assistantMessageID, query, llmContext, queryImages); err != nil {
+		logger.Errorf(ctx, "Agent execution failed: %v", err)
+		// Emit error event to the EventBus used by this agent
+		eventBus.Emit(ctx, event.Event{
+			Type:      event.EventError,
+			SessionID: sessionID,
+			Data: event.ErrorData{
+				Error:     err.Error(),
+				Stage:     "agent_execution",
+				SessionID: sessionID,
+			},
+		})
+	}
+
+	if memoryEnabled {
+		s.memoryService.ScheduleExtraction(ctx, &types.MemoryExtractPayload{
+			TenantID:         tenantID,
+			UserID:           userID,
+			SessionID:        sessionID,
+			MessageID:        assistantMessageID,
+			ModelID:          effectiveModelID,
+			EmbeddingModelID: customAgent.Config.MemoryEmbeddingModelID,
+		})
+	}
+	// Return empty - events will be handled by Handler via EventBus subscription
+	return nil
+}
+
+// buildAgentConfig builds the runtime configuration of a custom agent.
+// Knowledge bases given with the request (@ mentions) take priority over the ones configured for the agent
+func (s *sessionService) buildAgentConfig(
+	ctx context.Context,
+	customAgent *types.CustomAgent,
+	knowledgeBaseIDs []string,
+	knowledgeIDs []string,
+) *types.AgentConfig {
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+
 	// Create runtime AgentConfig from customAgent
 	// Note: tenantInfo.AgentConfig is deprecated, all config comes from customAgent now
 	agentConfig := &types.AgentConfig{
@@ -1104,7 +1263,16 @@ func (s *sessionService) AgentQA(
 		HistoryTurns:        customAgent.Config.HistoryTurns,
 		MCPSelectionMode:    customAgent.Config.MCPSelectionMode,
 		MCPServices:         customAgent.Config.MCPServices,
+		MCPPrompt:           customAgent.Config.MCPPrompt,
+		ToolApprovalPolicy:  customAgent.Config.ToolApprovalPolicy,
+		DataSources:         customAgent.Config.DataSources,
 	}
+	// The budget of the tenant caps the budget of the agent
+	var tenantBudget *types.AgentBudget
+	if tenantInfo.RateLimits != nil {
+		tenantBudget = tenantInfo.RateLimits.AgentBudget
+	}
+	agentConfig.Budget = types.MergeAgentBudgets(customAgent.Config.Budget, tenantBudget)
 
 	// Resolve knowledge bases: request-level @ mentions take priority over agent config
 	if len(knowledgeBaseIDs) > 0 || len(knowledgeIDs) > 0 {
@@ -1146,8 +1314,6 @@ func (s *sessionService) AgentQA(
 		}
 	}
 
-	logger.Infof(ctx, "Merged agent config from tenant %d and session %s", tenantInfo.ID, sessionID)
-
 	// Log knowledge bases if present
 	if len(agentConfig.KnowledgeBases) > 0 {
 		logger.Infof(ctx, "Agent configured with %d knowledge base(s): %v",
@@ -1165,27 +1331,27 @@ func (s *sessionService) AgentQA(
 	}
 	agentConfig.SearchTargets = searchTargets
 	logger.Infof(ctx, "Agent search targets built: %d targets", len(searchTargets))
+	return agentConfig
+}
 
-	// Get summary model: prioritize request's summaryModelID, then custom agent config
+// loadAgentModels loads the chat model of a custom agent, and its rerank model when it has knowledge to search
+func (s *sessionService) loadAgentModels(
+	ctx context.Context,
+	customAgent *types.CustomAgent,
+	agentConfig *types.AgentConfig,
+	modelID string,
+) (chat.Chat, rerank.Reranker, error) {
 	// Note: tenantInfo.ConversationConfig is deprecated, all config comes from customAgent now
-	effectiveModelID := summaryModelID
-	if effectiveModelID == "" {
-		effectiveModelID = customAgent.Config.ModelID
-	}
-	if effectiveModelID == "" {
+	if modelID == "" {
 		logger.Warnf(ctx, "No summary model configured for custom agent %s", customAgent.ID)
-		return errors.New("summary model (model_id) is not configured in custom agent settings")
-	}
-	if summaryModelID != "" {
-		logger.Infof(ctx, "Using request's summary model override: %s", effectiveModelID)
-	} else {
-		logger.Infof(ctx, "Using custom agent's model_id: %s", effectiveModelID)
+		return nil, nil, errors.New("summary model (model_id) is not configured in custom agent settings")
 	}
+	logger.Infof(ctx, "Using chat model %s for custom agent %s", modelID, customAgent.ID)
 
-	summaryModel, err := s.modelService.GetChatModel(ctx, effectiveModelID)
+	summaryModel, err := s.modelService.GetChatModel(ctx, modelID)
 	if err != nil {
 		logger.Warnf(ctx, "Failed to get chat model: %v", err)
-		return fmt.Errorf("failed to get chat model: %w", err)
+		return nil, nil, fmt.Errorf("failed to get chat model: %w", err)
 	}
 
 	// Get rerank model from custom agent config (only required when knowledge bases are configured)
@@ -1195,83 +1361,97 @@ func (s *sessionService) AgentQA(
 		rerankModelID := customAgent.Config.RerankModelID
 		if rerankModelID == "" {
 			logger.Warnf(ctx, "No rerank model configured for custom agent %s, but knowledge bases are specified", customAgent.ID)
-			return errors.New("rerank model (rerank_model_id) is not configured in custom agent settings")
+			return nil, nil, errors.New("rerank model (rerank_model_id) is not configured in custom agent settings")
 		}
 
 		rerankModel, err = s.modelService.GetRerankModel(ctx, rerankModelID)
 		if err != nil {
 			logger.Warnf(ctx, "Failed to get rerank model: %v", err)
-			return fmt.Errorf("failed to get rerank model: %w", err)
+			return nil, nil, fmt.Errorf("failed to get rerank model: %w", err)
 		}
 	} else {
 		logger.Infof(ctx, "No knowledge bases configured, skipping rerank model initialization")
 	}
 
-	// Get or create contextManager for this session
-	contextManager := s.getContextManagerForSession(ctx, session, summaryModel)
+	return summaryModel, rerankModel, nil
+}
 
-	// Set system prompt for the current agent in context manager
-	// This ensures the context uses the correct system prompt when switching agents
-	systemPrompt := agentConfig.ResolveSystemPrompt(agentConfig.WebSearchEnabled)
-	if systemPrompt != "" {
-		if err := contextManager.SetSystemPrompt(ctx, sessionID, systemPrompt); err != nil {
-			logger.Warnf(ctx, "Failed to set system prompt in context manager: %v", err)
-		} else {
-			logger.Infof(ctx, "System prompt updated in context manager for agent")
+// resolveSubAgents returns the custom agents the agent can delegate to.
+// path holds the IDs of the delegating agents and the agent itself; agents already on it are skipped
+// to avoid delegation cycles, and none are returned once the maximum delegation depth is reached
+func (s *sessionService) resolveSubAgents(
+	ctx context.Context,
+	customAgent *types.CustomAgent,
+	path []string,
+) []types.SubAgentInfo {
+	if len(customAgent.Config.SubAgents) == 0 || s.customAgentService == nil {
+		return nil
+	}
+	if len(path) > types.MaxAgentDelegationDepth {
+		logger.Infof(ctx, "Agent %s is at the maximum delegation depth, sub-agents are not available", customAgent.ID)
+		return nil
+	}
+
+	subAgents := make([]types.SubAgentInfo, 0, len(customAgent.Config.SubAgents))
+	for _, agentID := range customAgent.Config.SubAgents {
+		if slices.Contains(path, agentID) {
+			logger.Warnf(ctx, "Skip sub-agent %s of agent %s: delegation cycle", agentID, customAgent.ID)
+			continue
 		}
+		subAgent, err := s.customAgentService.GetAgentByID(ctx, agentID)
+		if err != nil || subAgent == nil {
+			logger.Warnf(ctx, "Skip sub-agent %s of agent %s: %v", agentID, customAgent.ID, err)
+			continue
+		}
+		if !subAgent.IsAgentMode() {
+			logger.Warnf(ctx, "Skip sub-agent %s of agent %s: not an agent mode agent", agentID, customAgent.ID)
+			continue
+		}
+		subAgents = append(subAgents, types.SubAgentInfo{
+			ID:          subAgent.ID,
+			Name:        subAgent.Name,
+			Description: subAgent.Description,
+		})
 	}
+	return subAgents
+}
 
-	// Get LLM context from context manager
-	llmContext, err := s.getContextForSession(ctx, contextManager, sessionID)
+// RunSubAgent runs a custom agent on a task delegated by another agent of the session.
+// The sub-agent starts without conversation history and does not write to the session context
+func (s *sessionService) RunSubAgent(
+	ctx context.Context,
+	agentID, task string,
+	path []string,
+	sessionID string,
+	eventBus *event.EventBus,
+) (*types.AgentState, error) {
+	customAgent, err := s.customAgentService.GetAgentByID(ctx, agentID)
 	if err != nil {
-		logger.Warnf(ctx, "Failed to get LLM context: %v, continuing without history", err)
-		llmContext = []chat.Message{}
+		return nil, fmt.Errorf("failed to get agent %s: %w", agentID, err)
+	}
+	customAgent.EnsureDefaults()
+	if !customAgent.IsAgentMode() {
+		return nil, fmt.Errorf("agent %s does not run in agent mode", agentID)
 	}
-	logger.Infof(ctx, "Loaded %d messages from LLM context manager", len(llmContext))
 
-	// Apply multi-turn configuration for Agent mode
-	// Note: In Agent mode, context is managed by contextManager with compression strategies,
-	// so we don't apply HistoryTurns limit here. HistoryTurns is used in normal (KnowledgeQA) mode.
-	if !agentConfig.MultiTurnEnabled {
-		// Multi-turn disabled, clear history
-		logger.Infof(ctx, "Multi-turn disabled for this agent, clearing history context")
-		llmContext = []chat.Message{}
+	agentConfig := s.buildAgentConfig(ctx, customAgent, nil, nil)
+	agentConfig.DelegationPath = append(slices.Clone(path), customAgent.ID)
+	agentConfig.SubAgents = s.resolveSubAgents(ctx, customAgent, agentConfig.DelegationPath)
+
+	summaryModel, rerankModel, err := s.loadAgentModels(ctx, customAgent, agentConfig, customAgent.Config.ModelID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create agent engine with EventBus and ContextManager
-	logger.Info(ctx, "Creating agent engine")
 	engine, err := s.agentService.CreateAgentEngine(
-		ctx,
-		agentConfig,
-		summaryModel,
-		rerankModel,
-		eventBus,
-		contextManager,
-		session.ID,
+		ctx, agentConfig, summaryModel, rerankModel, eventBus, nil, sessionID, s,
 	)
 	if err != nil {
-		logger.Errorf(ctx, "Failed to create agent engine: %v", err)
-		return err
+		return nil, fmt.Errorf("failed to create agent engine: %w", err)
 	}
 
-	// Execute agent with streaming (asynchronously)
-	// Events will be emitted to EventBus and handled by the Handler layer
-	logger.Info(ctx, "Executing agent with streaming")
-	if _, err := engine.Execute(ctx, sessionID, assistantMessageID, query, llmContext); err != nil {
-		logger.Errorf(ctx, "Agent execution failed: %v", err)
-		// Emit error event to the EventBus used by this agent
-		eventBus.Emit(ctx, event.Event{
-			Type:      event.EventError,
-			SessionID: sessionID,
-			Data: event.ErrorData{
-				Error:     err.Error(),
-				Stage:     "agent_execution",
-				SessionID: sessionID,
-			},
-		})
-	}
-	// Return empty - events will be handled by Handler via EventBus subscription
-	return nil
+	logger.Infof(ctx, "Running sub-agent %s at delegation depth %d", agentID, agentConfig.DelegationDepth())
+	return engine.Execute(ctx, sessionID, "", task, nil, nil)
 }
 
 // getContextManagerForSession creates a context manager for the session based on configuration
@@ -1330,6 +1510,55 @@ func (s *sessionService) ClearContext(ctx context.Context, sessionID string) err
 	return s.sessionStorage.Delete(ctx, sessionID)
 }
 
+// RebuildContext replaces the LLM context of a session with the question-answer pairs
+// on its active branch, so that agent mode follows the branch after editing,
+// regenerating or switching messages. Tool calls of earlier turns are not restored
+func (s *sessionService) RebuildContext(ctx context.Context, sessionID string) error {
+	maxRounds := s.cfg.Conversation.MaxRounds
+	messages, err := s.messageRepo.GetRecentMessagesBySession(ctx, sessionID, maxRounds*2+10)
+	if err != nil {
+		return fmt.Errorf("failed to load active branch: %w", err)
+	}
+
+	// Pair questions and answers by request ID, unanswered questions are skipped
+	type round struct{ query, answer string }
+	rounds := make([]*round, 0, len(messages)/2)
+	byRequestID := make(map[string]*round)
+	for _, message := range messages {
+		r, ok := byRequestID[message.RequestID]
+		if !ok {
+			r = &round{}
+			byRequestID[message.RequestID] = r
+			rounds = append(rounds, r)
+		}
+		if message.Role == "user" {
+			r.query = message.Content
+		} else {
+			r.answer = message.Content
+		}
+	}
+
+	history := make([]chat.Message, 0, len(rounds)*2)
+	for _, r := range rounds {
+		if r.query == "" || r.answer == "" {
+			continue
+		}
+		history = append(history,
+			chat.Message{Role: "user", Content: r.query},
+			chat.Message{Role: "assistant", Content: r.answer},
+		)
+	}
+	if len(history) > maxRounds*2 {
+		history = history[len(history)-maxRounds*2:]
+	}
+
+	logger.Infof(ctx, "Rebuilding context for session %s from %d messages on the active branch", sessionID, len(history))
+	if len(history) == 0 {
+		return s.sessionStorage.Delete(ctx, sessionID)
+	}
+	return s.sessionStorage.Save(ctx, sessionID, history)
+}
+
 // handleFallbackResponse handles fallback response based on strategy
 func (s *sessionService) handleFallbackResponse(ctx context.Context, chatManage *types.ChatManage) {
 	if chatManage.FallbackStrategy == types.FallbackStrategyModel {
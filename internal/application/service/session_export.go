@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// exportFileExtension returns the file extension of an export format
+func exportFileExtension(format types.SessionExportFormat) string {
+	switch format {
+	case types.SessionExportMarkdown:
+		return "md"
+	case types.SessionExportHTML:
+		return "html"
+	default:
+		return "json"
+	}
+}
+
+// transcriptRoleName returns the display name of a message role
+func transcriptRoleName(role string) string {
+	switch role {
+	case "user":
+		return "User"
+	case "assistant":
+		return "Assistant"
+	default:
+		return role
+	}
+}
+
+// transcriptTitle returns the title of a transcript, with a placeholder for untitled sessions
+func transcriptTitle(transcript *types.SessionTranscript) string {
+	if transcript.Title != "" {
+		return transcript.Title
+	}
+	return "Untitled conversation"
+}
+
+// renderTranscriptJSON renders a transcript as indented JSON
+func renderTranscriptJSON(transcript *types.SessionTranscript) ([]byte, error) {
+	return json.MarshalIndent(transcript, "", "  ")
+}
+
+// renderTranscriptMarkdown renders a transcript as Markdown
+func renderTranscriptMarkdown(transcript *types.SessionTranscript) string {
+	var builder strings.Builder
+	builder.WriteString("# " + transcriptTitle(transcript) + "\n\n")
+	if transcript.Description != "" {
+		builder.WriteString(transcript.Description + "\n\n")
+	}
+	builder.WriteString(fmt.Sprintf("> Created %s, exported %s\n\n",
+		transcript.CreatedAt.Format(time.RFC3339), transcript.ExportedAt.Format(time.RFC3339)))
+
+	for _, message := range transcript.Messages {
+		builder.WriteString("---\n\n")
+		builder.WriteString(fmt.Sprintf("### %s · %s\n\n",
+			transcriptRoleName(message.Role), message.CreatedAt.Format("2006-01-02 15:04:05")))
+
+		if len(message.MentionedItems) > 0 {
+			names := make([]string, 0, len(message.MentionedItems))
+			for _, item := range message.MentionedItems {
+				names = append(names, "@"+item.Name)
+			}
+			builder.WriteString("Mentioned: " + strings.Join(names, ", ") + "\n\n")
+		}
+		if len(message.Images) > 0 {
+			builder.WriteString("Images: " + strings.Join(message.Images, ", ") + "\n\n")
+		}
+
+		if len(message.AgentSteps) > 0 {
+			builder.WriteString("<details>\n<summary>Agent steps</summary>\n\n")
+			for _, step := range message.AgentSteps {
+				builder.WriteString(fmt.Sprintf("**Step %d**\n\n", step.Iteration+1))
+				if step.Thought != "" {
+					builder.WriteString(step.Thought + "\n\n")
+				}
+				for _, toolCall := range step.ToolCalls {
+					builder.WriteString(fmt.Sprintf("- Tool `%s`", toolCall.Name))
+					if args := formatToolArgs(toolCall.Args); args != "" {
+						builder.WriteString(" `" + args + "`")
+					}
+					builder.WriteString("\n")
+					if toolCall.Result != nil && toolCall.Result.Output != "" {
+						builder.WriteString("\n```\n" + toolCall.Result.Output + "\n```\n")
+					}
+				}
+				builder.WriteString("\n")
+			}
+			builder.WriteString("</details>\n\n")
+		}
+
+		builder.WriteString(message.Content + "\n\n")
+
+		if len(message.References) > 0 {
+			builder.WriteString("**References**\n\n")
+			for _, ref := range message.References {
+				builder.WriteString(fmt.Sprintf("%d. %s (chunk %d)\n", ref.Index, ref.KnowledgeTitle, ref.ChunkIndex))
+				if ref.Content != "" {
+					builder.WriteString("   > " + strings.ReplaceAll(ref.Content, "\n", "\n   > ") + "\n")
+				}
+			}
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
+
+// formatToolArgs renders tool call arguments as compact JSON
+func formatToolArgs(args map[string]interface{}) string {
+	if len(args) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// transcriptHTMLTemplate renders a transcript as a standalone read-only page.
+// Message content is shown as escaped plain text, so shared pages never run content as HTML.
+var transcriptHTMLTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"role":  transcriptRoleName,
+	"time":  func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"args":  formatToolArgs,
+	"title": transcriptTitle,
+	"step":  func(iteration int) int { return iteration + 1 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{title .}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; max-width: 860px; margin: 0 auto; padding: 24px; color: #1f2328; }
+header { border-bottom: 1px solid #d0d7de; margin-bottom: 16px; }
+.meta { color: #656d76; font-size: 13px; }
+.message { border: 1px solid #d0d7de; border-radius: 8px; padding: 12px 16px; margin: 12px 0; }
+.message.user { background: #f6f8fa; }
+.role { font-weight: 600; margin-bottom: 8px; }
+.content { white-space: pre-wrap; word-wrap: break-word; line-height: 1.6; }
+.references { font-size: 14px; margin-top: 12px; }
+.references blockquote { color: #656d76; white-space: pre-wrap; margin: 4px 0 8px 16px; }
+details { font-size: 14px; margin-bottom: 8px; }
+pre { background: #f6f8fa; padding: 8px; overflow-x: auto; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{title .}}</h1>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+<p class="meta">Created {{time .CreatedAt}} · Exported {{time .ExportedAt}}</p>
+</header>
+{{range .Messages}}
+<section class="message {{.Role}}">
+<div class="role">{{role .Role}} <span class="meta">{{time .CreatedAt}}</span></div>
+{{if .MentionedItems}}<p class="meta">Mentioned: {{range $i, $item := .MentionedItems}}{{if $i}}, {{end}}@{{$item.Name}}{{end}}</p>{{end}}
+{{if .Images}}<p class="meta">Images: {{range $i, $name := .Images}}{{if $i}}, {{end}}{{$name}}{{end}}</p>{{end}}
+{{if .AgentSteps}}<details><summary>Agent steps</summary>
+{{range .AgentSteps}}<p><strong>Step {{step .Iteration}}</strong></p>
+{{if .Thought}}<div class="content">{{.Thought}}</div>{{end}}
+<ul>{{range .ToolCalls}}<li>Tool <code>{{.Name}}</code> <code>{{args .Args}}</code>{{if .Result}}{{if .Result.Output}}<pre>{{.Result.Output}}</pre>{{end}}{{end}}</li>{{end}}</ul>
+{{end}}</details>{{end}}
+<div class="content">{{.Content}}</div>
+{{if .References}}<div class="references"><strong>References</strong><ol>
+{{range .References}}<li>{{.KnowledgeTitle}} <span class="meta">(chunk {{.ChunkIndex}})</span>{{if .Content}}<blockquote>{{.Content}}</blockquote>{{end}}</li>
+{{end}}</ol></div>{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
+
+// renderTranscriptHTML renders a transcript as a standalone HTML page
+func renderTranscriptHTML(transcript *types.SessionTranscript) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := transcriptHTMLTemplate.Execute(&buf, transcript); err != nil {
+		return nil, fmt.Errorf("failed to render transcript: %w", err)
+	}
+	return buf.Bytes(), nil
+}
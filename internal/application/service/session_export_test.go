@@ -0,0 +1,89 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTranscriptMessages() []*types.Message {
+	return []*types.Message{
+		{
+			ID:             "q1",
+			Role:           "user",
+			Content:        "What is <b>our</b> refund policy?",
+			MentionedItems: types.MentionedItems{{ID: "kb-1", Name: "Policies", Type: "kb"}},
+		},
+		{
+			ID:      "a1",
+			Role:    "assistant",
+			Content: "Refunds are accepted within 30 days [1].",
+			KnowledgeReferences: types.References{
+				{ID: "chunk-1", KnowledgeID: "doc-1", KnowledgeTitle: "old.pdf", ChunkIndex: 3, Content: "secret chunk text"},
+				{ID: "chunk-2", KnowledgeID: "doc-2", KnowledgeFilename: "faq.md", Content: "more chunk text"},
+			},
+			AgentSteps: types.AgentSteps{{
+				Iteration: 0,
+				Thought:   "Search the policies",
+				ToolCalls: []types.ToolCall{{
+					Name:   "knowledge_search",
+					Args:   map[string]interface{}{"query": "refund"},
+					Result: &types.ToolResult{Success: true, Output: "secret chunk text"},
+				}},
+			}},
+		},
+	}
+}
+
+func TestNewSessionTranscriptResolvesTitlesAndHidesChunkContent(t *testing.T) {
+	messages := testTranscriptMessages()
+	session := &types.Session{ID: "s1", Title: "Refunds"}
+
+	transcript := newSessionTranscript(session, messages, map[string]string{"doc-1": "Refund Policy"}, false)
+	require.Len(t, transcript.Messages, 2)
+	assert.False(t, transcript.IncludesChunkContent)
+
+	refs := transcript.Messages[1].References
+	require.Len(t, refs, 2)
+	assert.Equal(t, 1, refs[0].Index)
+	assert.Equal(t, "Refund Policy", refs[0].KnowledgeTitle)
+	assert.Equal(t, "faq.md", refs[1].KnowledgeTitle)
+	assert.Empty(t, refs[0].Content)
+
+	steps := transcript.Messages[1].AgentSteps
+	require.Len(t, steps, 1)
+	assert.Equal(t, "knowledge_search", steps[0].ToolCalls[0].Name)
+	assert.Empty(t, steps[0].ToolCalls[0].Result.Output)
+	// The stored message is left untouched
+	assert.Equal(t, "secret chunk text", messages[1].AgentSteps[0].ToolCalls[0].Result.Output)
+
+	for _, render := range []func() string{
+		func() string { return renderTranscriptMarkdown(transcript) },
+		func() string { data, _ := renderTranscriptHTML(transcript); return string(data) },
+	} {
+		assert.NotContains(t, render(), "secret chunk text")
+	}
+}
+
+func TestNewSessionTranscriptIncludesChunkContentWhenAllowed(t *testing.T) {
+	transcript := newSessionTranscript(&types.Session{ID: "s1"}, testTranscriptMessages(), nil, true)
+
+	assert.Equal(t, "secret chunk text", transcript.Messages[1].References[0].Content)
+	assert.Equal(t, "old.pdf", transcript.Messages[1].References[0].KnowledgeTitle)
+	assert.Contains(t, renderTranscriptMarkdown(transcript), "> secret chunk text")
+}
+
+func TestRenderTranscriptHTMLEscapesContent(t *testing.T) {
+	transcript := newSessionTranscript(&types.Session{ID: "s1", Title: "<script>x</script>"},
+		testTranscriptMessages(), nil, false)
+
+	data, err := renderTranscriptHTML(transcript)
+	require.NoError(t, err)
+	page := string(data)
+	assert.False(t, strings.Contains(page, "<script>"))
+	assert.Contains(t, page, "&lt;b&gt;our&lt;/b&gt;")
+	assert.Contains(t, page, "@Policies")
+}
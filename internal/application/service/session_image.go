@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// maxChatImageBytes limits the size of a single image attachment loaded for the chat model
+const maxChatImageBytes = 10 << 20
+
+// imageCaptionPrompt is the prompt used to OCR/caption image attachments through the KB's VLM
+const imageCaptionPrompt = `请详细描述这张图片的内容，用于后续的知识库检索：
+1. 如果图片中包含文字（如报错信息、代码、表格、界面文字），请完整准确地提取出来
+2. 简要说明图片的主体内容和场景
+直接输出描述内容，不要添加额外的解释。`
+
+// loadImageDataURIs reads image attachments from file storage and encodes them as data URIs
+// The result is aligned with images; an image that fails to load gets an empty entry
+// so that the conversation can still continue
+func (s *sessionService) loadImageDataURIs(ctx context.Context, images types.MessageImages) []string {
+	if len(images) == 0 {
+		return nil
+	}
+	dataURIs := make([]string, len(images))
+	for i, image := range images {
+		data, err := s.readImage(ctx, image.FilePath)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to load image attachment %s: %v", image.FilePath, err)
+			continue
+		}
+		dataURIs[i] = chat.BuildImageDataURI(image.MimeType, data)
+	}
+	return dataURIs
+}
+
+// compactImages drops the images that failed to load
+func compactImages(dataURIs []string) []string {
+	result := make([]string, 0, len(dataURIs))
+	for _, uri := range dataURIs {
+		if uri != "" {
+			result = append(result, uri)
+		}
+	}
+	return result
+}
+
+// imagesForChatModel returns the images to attach to the request of the chat model. Text-only models
+// reject image inputs, they only get the captions appended to the query.
+func (s *sessionService) imagesForChatModel(ctx context.Context, modelID string, dataURIs []string) []string {
+	images := compactImages(dataURIs)
+	if len(images) == 0 {
+		return nil
+	}
+	model, err := s.modelService.GetModelByID(ctx, modelID)
+	if err != nil || model == nil {
+		logger.Warnf(ctx, "Failed to get chat model %s, not attaching images: %v", modelID, err)
+		return nil
+	}
+	if !model.SupportsVision() {
+		logger.Infof(ctx, "Chat model %s is not vision-capable, only passing image captions", modelID)
+		return nil
+	}
+	return images
+}
+
+// readImage reads the content of an image attachment with a size limit
+func (s *sessionService) readImage(ctx context.Context, filePath string) ([]byte, error) {
+	reader, err := s.fileService.GetFile(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxChatImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > maxChatImageBytes {
+		return nil, fmt.Errorf("image exceeds %d bytes", maxChatImageBytes)
+	}
+	return data, nil
+}
+
+// captionImages generates OCR/caption text for image attachments using the VLM of the first
+// knowledge base (among the search targets) that has multimodal enabled.
+// Captions are written back into images in place; it's a no-op when no VLM is configured.
+func (s *sessionService) captionImages(
+	ctx context.Context,
+	images types.MessageImages,
+	dataURIs []string,
+	searchTargets types.SearchTargets,
+) {
+	if len(images) == 0 || len(images) != len(dataURIs) {
+		return
+	}
+
+	vlm := s.getVLMChatModel(ctx, searchTargets)
+	if vlm == nil {
+		return
+	}
+
+	for i := range images {
+		if images[i].Caption != "" || dataURIs[i] == "" {
+			continue
+		}
+		if tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64); ok {
+			if err := s.limiter.Wait(ctx, tenantID, types.RateLimitVLM, 1); err != nil {
+				logger.Warnf(ctx, "Stopped captioning images while waiting for VLM limit: %v", err)
+				return
+			}
+		}
+		resp, err := vlm.Chat(ctx, []chat.Message{
+			{Role: "user", Content: imageCaptionPrompt, Images: []string{dataURIs[i]}},
+		}, &chat.ChatOptions{Temperature: 0.1})
+		if err != nil {
+			logger.Warnf(ctx, "Failed to caption image %s: %v", images[i].FileName, err)
+			continue
+		}
+		images[i].Caption = strings.TrimSpace(resp.Content)
+		logger.Infof(ctx, "Generated caption for image %s, length: %d", images[i].FileName, len(images[i].Caption))
+	}
+}
+
+// getVLMChatModel returns a chat model for the VLM configured on the searched knowledge bases
+func (s *sessionService) getVLMChatModel(ctx context.Context, searchTargets types.SearchTargets) chat.Chat {
+	seen := make(map[string]bool)
+	for _, target := range searchTargets {
+		if target == nil || target.KnowledgeBaseID == "" || seen[target.KnowledgeBaseID] {
+			continue
+		}
+		seen[target.KnowledgeBaseID] = true
+
+		kb, err := s.knowledgeBaseService.GetKnowledgeBaseByID(ctx, target.KnowledgeBaseID)
+		if err != nil || kb == nil || !kb.VLMConfig.IsEnabled() {
+			continue
+		}
+
+		// 新版本：使用模型管理中的 VLM 模型
+		if kb.VLMConfig.Enabled && kb.VLMConfig.ModelID != "" {
+			vlm, err := s.modelService.GetChatModel(ctx, kb.VLMConfig.ModelID)
+			if err != nil {
+				logger.Warnf(ctx, "Failed to get VLM model %s: %v", kb.VLMConfig.ModelID, err)
+				continue
+			}
+			return vlm
+		}
+
+		// 兼容老版本：直接使用 ModelName 和 BaseURL
+		source := types.ModelSourceRemote
+		if kb.VLMConfig.InterfaceType == "ollama" {
+			source = types.ModelSourceLocal
+		}
+		vlm, err := chat.NewChat(&chat.ChatConfig{
+			Source:    source,
+			BaseURL:   kb.VLMConfig.BaseURL,
+			ModelName: kb.VLMConfig.ModelName,
+			APIKey:    kb.VLMConfig.APIKey,
+		})
+		if err != nil {
+			logger.Warnf(ctx, "Failed to create legacy VLM model %s: %v", kb.VLMConfig.ModelName, err)
+			continue
+		}
+		return vlm
+	}
+	return nil
+}
+
+// appendImageCaptions appends image captions to the query so that retrieval can match image content
+func appendImageCaptions(query string, images types.MessageImages) string {
+	var builder strings.Builder
+	for i, image := range images {
+		if image.Caption == "" {
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("\n[图片%d] %s", i+1, image.Caption))
+	}
+	if builder.Len() == 0 {
+		return query
+	}
+	return query + "\n\n用户上传的图片内容：" + builder.String()
+}
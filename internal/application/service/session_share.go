@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultShareExpiryDays = 7
+	maxShareExpiryDays     = 90
+	// maxTranscriptMessages bounds the messages of an exported conversation
+	maxTranscriptMessages = 5000
+	// SharedSessionPath is the path prefix of the public read-only page of a share link
+	SharedSessionPath = "/api/v1/shared/"
+)
+
+// sessionShareService implements the SessionShareService interface
+type sessionShareService struct {
+	repo          interfaces.SessionShareRepository
+	sessionRepo   interfaces.SessionRepository
+	messageRepo   interfaces.MessageRepository
+	knowledgeRepo interfaces.KnowledgeRepository
+}
+
+// NewSessionShareService creates a new session export and share service
+func NewSessionShareService(
+	repo interfaces.SessionShareRepository,
+	sessionRepo interfaces.SessionRepository,
+	messageRepo interfaces.MessageRepository,
+	knowledgeRepo interfaces.KnowledgeRepository,
+) interfaces.SessionShareService {
+	return &sessionShareService{
+		repo:          repo,
+		sessionRepo:   sessionRepo,
+		messageRepo:   messageRepo,
+		knowledgeRepo: knowledgeRepo,
+	}
+}
+
+// getSession gets a session of the current tenant
+func (s *sessionShareService) getSession(ctx context.Context, sessionID string) (*types.Session, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	session, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, werrors.NewNotFoundError("Session not found")
+		}
+		return nil, err
+	}
+	return session, nil
+}
+
+// ExportSession renders the active branch of a session of the current tenant
+func (s *sessionShareService) ExportSession(
+	ctx context.Context, sessionID string, format types.SessionExportFormat, includeChunkContent bool,
+) ([]byte, string, string, error) {
+	if !types.IsValidSessionExportFormat(format) {
+		return nil, "", "", werrors.NewBadRequestError("Unsupported export format: " + string(format))
+	}
+	session, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	transcript, err := s.buildTranscript(ctx, session, includeChunkContent)
+	if err != nil {
+		return nil, "", "", err
+	}
+	data, contentType, err := s.RenderTranscript(transcript, format)
+	if err != nil {
+		return nil, "", "", err
+	}
+	logger.Infof(ctx, "Session %s exported as %s, %d messages", sessionID, format, len(transcript.Messages))
+	return data, contentType, fmt.Sprintf("conversation-%s.%s", session.ID, exportFileExtension(format)), nil
+}
+
+// CreateShare snapshots a session and creates a share link
+func (s *sessionShareService) CreateShare(
+	ctx context.Context, sessionID string, includeChunkContent bool, expiresInDays int,
+) (*types.SessionShare, error) {
+	if expiresInDays == 0 {
+		expiresInDays = defaultShareExpiryDays
+	}
+	if expiresInDays < 0 || expiresInDays > maxShareExpiryDays {
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("expires_in_days must be between 1 and %d", maxShareExpiryDays))
+	}
+	session, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	transcript, err := s.buildTranscript(ctx, session, includeChunkContent)
+	if err != nil {
+		return nil, err
+	}
+	token, err := generateShareToken()
+	if err != nil {
+		return nil, err
+	}
+
+	share := &types.SessionShare{
+		TenantID:            session.TenantID,
+		SessionID:           session.ID,
+		Token:               token,
+		IncludeChunkContent: includeChunkContent,
+		Snapshot:            *transcript,
+		ExpiresAt:           time.Now().AddDate(0, 0, expiresInDays),
+	}
+	if userID, ok := ctx.Value(types.UserIDContextKey).(string); ok {
+		share.CreatedBy = userID
+	}
+	if err := s.repo.CreateShare(ctx, share); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"session_id": sessionID})
+		return nil, err
+	}
+	share.URL = SharedSessionPath + share.Token
+	logger.Infof(ctx, "Share link %s created for session %s, expires at %s",
+		share.ID, sessionID, share.ExpiresAt.Format(time.RFC3339))
+	return share, nil
+}
+
+// ListShares lists the active share links of a session
+func (s *sessionShareService) ListShares(ctx context.Context, sessionID string) ([]*types.SessionShare, error) {
+	session, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := s.repo.ListShares(ctx, session.TenantID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, share := range shares {
+		share.URL = SharedSessionPath + share.Token
+	}
+	return shares, nil
+}
+
+// RevokeShare revokes a share link of a session
+func (s *sessionShareService) RevokeShare(ctx context.Context, sessionID string, shareID string) error {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	found, err := s.repo.DeleteShare(ctx, tenantID, sessionID, shareID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return werrors.NewNotFoundError("Share link not found")
+	}
+	logger.Infof(ctx, "Share link %s of session %s revoked", shareID, sessionID)
+	return nil
+}
+
+// GetSharedTranscript returns the snapshot behind a share link.
+// Expired links, revoked links and links of deleted sessions are reported as not found.
+func (s *sessionShareService) GetSharedTranscript(ctx context.Context, token string) (*types.SessionTranscript, error) {
+	notFound := werrors.NewNotFoundError("Share link not found or expired")
+	if token == "" {
+		return nil, notFound
+	}
+	share, err := s.repo.GetShareByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if share == nil || share.IsExpired() {
+		return nil, notFound
+	}
+	if _, err := s.sessionRepo.Get(ctx, share.TenantID, share.SessionID); err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound
+		}
+		return nil, err
+	}
+	return &share.Snapshot, nil
+}
+
+// RenderTranscript renders a transcript in an export format
+func (s *sessionShareService) RenderTranscript(
+	transcript *types.SessionTranscript, format types.SessionExportFormat,
+) ([]byte, string, error) {
+	switch format {
+	case types.SessionExportMarkdown:
+		return []byte(renderTranscriptMarkdown(transcript)), "text/markdown; charset=utf-8", nil
+	case types.SessionExportHTML:
+		data, err := renderTranscriptHTML(transcript)
+		return data, "text/html; charset=utf-8", err
+	case types.SessionExportJSON:
+		data, err := renderTranscriptJSON(transcript)
+		return data, "application/json; charset=utf-8", err
+	default:
+		return nil, "", werrors.NewBadRequestError("Unsupported export format: " + string(format))
+	}
+}
+
+// buildTranscript copies the active branch of a session, resolving citations to document titles
+func (s *sessionShareService) buildTranscript(
+	ctx context.Context, session *types.Session, includeChunkContent bool,
+) (*types.SessionTranscript, error) {
+	messages, err := s.messageRepo.GetRecentMessagesBySession(ctx, session.ID, maxTranscriptMessages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session messages: %w", err)
+	}
+
+	return newSessionTranscript(session, messages, s.knowledgeTitles(ctx, session.TenantID, messages),
+		includeChunkContent), nil
+}
+
+// knowledgeTitles looks up the current titles of the documents cited by messages
+func (s *sessionShareService) knowledgeTitles(
+	ctx context.Context, tenantID uint64, messages []*types.Message,
+) map[string]string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, message := range messages {
+		for _, ref := range message.KnowledgeReferences {
+			if ref != nil && ref.KnowledgeID != "" && !seen[ref.KnowledgeID] {
+				seen[ref.KnowledgeID] = true
+				ids = append(ids, ref.KnowledgeID)
+			}
+		}
+	}
+	titles := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return titles
+	}
+
+	knowledgeList, err := s.knowledgeRepo.GetKnowledgeBatch(ctx, tenantID, ids)
+	if err != nil {
+		// 查询失败时退回到引用中保存的标题
+		logger.Warnf(ctx, "Failed to resolve titles of cited documents: %v", err)
+		return titles
+	}
+	for _, knowledge := range knowledgeList {
+		if knowledge.Title != "" {
+			titles[knowledge.ID] = knowledge.Title
+		} else {
+			titles[knowledge.ID] = knowledge.FileName
+		}
+	}
+	return titles
+}
+
+// newSessionTranscript builds a transcript from the messages of a session.
+// Without includeChunkContent, the cited chunk text and the tool outputs (which may
+// quote chunks) are left out, only the citation metadata is kept.
+func newSessionTranscript(
+	session *types.Session, messages []*types.Message, titles map[string]string, includeChunkContent bool,
+) *types.SessionTranscript {
+	transcript := &types.SessionTranscript{
+		SessionID:            session.ID,
+		Title:                session.Title,
+		Description:          session.Description,
+		CreatedAt:            session.CreatedAt,
+		ExportedAt:           time.Now(),
+		IncludesChunkContent: includeChunkContent,
+		Messages:             make([]types.TranscriptMessage, 0, len(messages)),
+	}
+
+	for _, message := range messages {
+		item := types.TranscriptMessage{
+			ID:             message.ID,
+			Role:           message.Role,
+			Content:        message.Content,
+			CreatedAt:      message.CreatedAt,
+			MentionedItems: message.MentionedItems,
+		}
+		for _, image := range message.Images {
+			item.Images = append(item.Images, image.FileName)
+		}
+		for _, ref := range message.KnowledgeReferences {
+			if ref == nil {
+				continue
+			}
+			title := titles[ref.KnowledgeID]
+			if title == "" {
+				title = ref.KnowledgeTitle
+			}
+			if title == "" {
+				title = ref.KnowledgeFilename
+			}
+			reference := types.TranscriptReference{
+				Index:          len(item.References) + 1,
+				KnowledgeID:    ref.KnowledgeID,
+				KnowledgeTitle: title,
+				ChunkID:        ref.ID,
+				ChunkIndex:     ref.ChunkIndex,
+				Score:          ref.Score,
+			}
+			if includeChunkContent {
+				reference.Content = ref.Content
+			}
+			item.References = append(item.References, reference)
+		}
+		if includeChunkContent {
+			item.AgentSteps = message.AgentSteps
+		} else {
+			item.AgentSteps = withoutToolOutputs(message.AgentSteps)
+		}
+		transcript.Messages = append(transcript.Messages, item)
+	}
+	return transcript
+}
+
+// withoutToolOutputs copies agent steps, dropping the outputs of the tool calls
+func withoutToolOutputs(steps types.AgentSteps) types.AgentSteps {
+	if len(steps) == 0 {
+		return nil
+	}
+	result := make(types.AgentSteps, 0, len(steps))
+	for _, step := range steps {
+		toolCalls := make([]types.ToolCall, 0, len(step.ToolCalls))
+		for _, toolCall := range step.ToolCalls {
+			if toolCall.Result != nil {
+				toolCall.Result = &types.ToolResult{Success: toolCall.Result.Success, Error: toolCall.Result.Error}
+			}
+			toolCall.Reflection = ""
+			toolCalls = append(toolCalls, toolCall)
+		}
+		step.ToolCalls = toolCalls
+		result = append(result, step)
+	}
+	return result
+}
+
+// generateShareToken generates the random token of a share link
+func generateShareToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
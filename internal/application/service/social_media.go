@@ -0,0 +1,476 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/application/service/social_media"
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	socialMediaProgressKeyPrefix = "social_media_extract_progress:"
+	socialMediaProgressTTL       = 24 * time.Hour
+	defaultSocialMediaTimeout    = 300 // 秒
+	defaultSocialMediaBatchSize  = 50
+)
+
+// socialMediaService imports transcripts of social media videos into knowledge bases
+type socialMediaService struct {
+	extractors       []interfaces.SocialMediaExtractor
+	timeout          time.Duration
+	maxBatchSize     int
+	kbService        interfaces.KnowledgeBaseService
+	knowledgeService interfaces.KnowledgeService
+	knowledgeRepo    interfaces.KnowledgeRepository
+	tenantRepo       interfaces.TenantRepository
+	task             *asynq.Client
+	redisClient      *redis.Client
+	extractorInfos   []types.SocialMediaExtractorInfo
+}
+
+// NewSocialMediaService creates a new social media service
+func NewSocialMediaService(
+	cfg *config.Config,
+	kbService interfaces.KnowledgeBaseService,
+	knowledgeService interfaces.KnowledgeService,
+	knowledgeRepo interfaces.KnowledgeRepository,
+	tenantRepo interfaces.TenantRepository,
+	task *asynq.Client,
+	redisClient *redis.Client,
+) (interfaces.SocialMediaService, error) {
+	s := &socialMediaService{
+		timeout:          defaultSocialMediaTimeout * time.Second,
+		maxBatchSize:     defaultSocialMediaBatchSize,
+		kbService:        kbService,
+		knowledgeService: knowledgeService,
+		knowledgeRepo:    knowledgeRepo,
+		tenantRepo:       tenantRepo,
+		task:             task,
+		redisClient:      redisClient,
+	}
+
+	extractorConfigs := []config.SocialMediaExtractorConfig{{ID: "coze", Name: "Coze Workflow"}}
+	if cfg.SocialMedia != nil {
+		extractorConfigs = cfg.SocialMedia.Extractors
+		if cfg.SocialMedia.Timeout > 0 {
+			s.timeout = time.Duration(cfg.SocialMedia.Timeout) * time.Second
+		}
+		if cfg.SocialMedia.MaxBatchSize > 0 {
+			s.maxBatchSize = cfg.SocialMedia.MaxBatchSize
+		}
+	}
+
+	for _, extractorConfig := range extractorConfigs {
+		extractor, err := social_media.NewExtractor(extractorConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize social media extractor %s: %v", extractorConfig.ID, err)
+		}
+		s.extractors = append(s.extractors, extractor)
+		s.extractorInfos = append(s.extractorInfos, types.SocialMediaExtractorInfo{
+			ID:        extractorConfig.ID,
+			Name:      extractorConfig.Name,
+			Platforms: extractorConfig.Platforms,
+		})
+		logger.Infof(context.Background(), "Initialized social media extractor: %s", extractorConfig.ID)
+	}
+	return s, nil
+}
+
+// ListExtractors lists the configured extractors
+func (s *socialMediaService) ListExtractors(ctx context.Context) []types.SocialMediaExtractorInfo {
+	return s.extractorInfos
+}
+
+// SubmitExtraction validates the request, enqueues the extraction task and saves the initial progress
+func (s *socialMediaService) SubmitExtraction(ctx context.Context,
+	kbID string, provider string, items []types.SocialMediaExtractItem,
+) (*types.SocialMediaExtractProgress, error) {
+	if len(items) == 0 {
+		return nil, werrors.NewBadRequestError("至少需要提交一个链接")
+	}
+	if len(items) > s.maxBatchSize {
+		return nil, werrors.NewBadRequestError(fmt.Sprintf("单次最多提交 %d 个链接", s.maxBatchSize))
+	}
+	if provider != "" && s.getExtractor(provider) == nil {
+		return nil, werrors.NewBadRequestError(fmt.Sprintf("未知的提取器: %s", provider))
+	}
+
+	cleanItems := make([]types.SocialMediaExtractItem, 0, len(items))
+	for i, item := range items {
+		platform := strings.ToLower(strings.TrimSpace(item.Platform))
+		rawURL := strings.TrimSpace(item.URL)
+		if platform == "" {
+			return nil, werrors.NewBadRequestError(fmt.Sprintf("第 %d 个链接未指定平台", i+1))
+		}
+		if !secutils.IsValidURL(rawURL) {
+			return nil, werrors.NewBadRequestError(fmt.Sprintf("第 %d 个链接无效", i+1))
+		}
+		if !s.hasExtractorFor(platform, provider) {
+			return nil, werrors.NewBadRequestError(fmt.Sprintf("没有可用于平台 %s 的提取器", platform))
+		}
+		cleanItems = append(cleanItems, types.SocialMediaExtractItem{Platform: platform, URL: rawURL})
+	}
+
+	// 验证知识库是否存在
+	if _, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID); err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge base %s: %v", secutils.SanitizeForLog(kbID), err)
+		return nil, werrors.NewNotFoundError("知识库不存在")
+	}
+
+	payload := types.SocialMediaExtractPayload{
+		TenantID:        ctx.Value(types.TenantIDContextKey).(uint64),
+		TaskID:          uuid.New().String(),
+		KnowledgeBaseID: kbID,
+		Provider:        provider,
+		Items:           cleanItems,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal social media extract payload: %w", err)
+	}
+
+	// Save initial progress before enqueuing so that the worker always finds it
+	progress := newSocialMediaProgress(&payload)
+	if err := s.saveProgress(ctx, progress); err != nil {
+		return nil, fmt.Errorf("failed to save social media extract progress: %w", err)
+	}
+
+	// 每个链接的提取时间较长，按链接数放宽任务超时
+	taskTimeout := time.Duration(len(cleanItems))*(s.timeout+time.Minute) + 5*time.Minute
+	task := asynq.NewTask(types.TypeSocialMediaExtract, payloadBytes,
+		asynq.Queue("default"), asynq.MaxRetry(3), asynq.Timeout(taskTimeout))
+	info, err := s.task.Enqueue(task)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to enqueue social media extract task: %v", err)
+		return nil, fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	logger.Infof(ctx, "Social media extract task enqueued: %s, asynq task ID: %s, kb: %s, items: %d",
+		payload.TaskID, info.ID, secutils.SanitizeForLog(kbID), len(cleanItems))
+	return progress, nil
+}
+
+// GetExtractionProgress retrieves the progress of an extraction task
+func (s *socialMediaService) GetExtractionProgress(
+	ctx context.Context,
+	taskID string,
+) (*types.SocialMediaExtractProgress, error) {
+	data, err := s.redisClient.Get(ctx, socialMediaProgressKeyPrefix+taskID).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, werrors.NewNotFoundError("Social media extract task not found")
+		}
+		return nil, fmt.Errorf("failed to get progress from Redis: %w", err)
+	}
+
+	var progress types.SocialMediaExtractProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal progress: %w", err)
+	}
+
+	// 不允许跨租户查看任务进度
+	if tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64); ok {
+		if progress.TenantID != 0 && progress.TenantID != tenantID {
+			return nil, werrors.NewNotFoundError("Social media extract task not found")
+		}
+	}
+	return &progress, nil
+}
+
+// ProcessSocialMediaExtract handles Asynq social media extraction tasks
+// Items are processed sequentially; items already finished by a previous attempt are skipped on retry
+func (s *socialMediaService) ProcessSocialMediaExtract(ctx context.Context, t *asynq.Task) error {
+	var payload types.SocialMediaExtractPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal social media extract payload: %w", err)
+	}
+
+	// Add tenant ID to context
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+
+	// Get tenant info and add to context
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant info: %v", err)
+		return fmt.Errorf("failed to get tenant info: %w", err)
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	retryCount, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	isLastRetry := retryCount >= maxRetry
+
+	logger.Infof(ctx, "Processing social media extract task: %s, kb: %s, items: %d, retry: %d/%d",
+		payload.TaskID, payload.KnowledgeBaseID, len(payload.Items), retryCount, maxRetry)
+
+	progress, err := s.GetExtractionProgress(ctx, payload.TaskID)
+	if err != nil || len(progress.Items) != len(payload.Items) {
+		progress = newSocialMediaProgress(&payload)
+	}
+	progress.Status = types.SocialMediaStatusProcessing
+	progress.Message = "Extracting content..."
+	s.updateProgress(ctx, progress)
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, payload.KnowledgeBaseID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge base: %v", err)
+		if isLastRetry {
+			progress.Status = types.SocialMediaStatusFailed
+			progress.Error = err.Error()
+			progress.Message = "Knowledge base not found"
+			s.updateProgress(ctx, progress)
+		}
+		return err
+	}
+
+	for i := range progress.Items {
+		item := &progress.Items[i]
+		if item.Status == types.SocialMediaStatusCompleted || item.Status == types.SocialMediaStatusFailed {
+			continue
+		}
+		item.Status = types.SocialMediaStatusProcessing
+		s.updateProgress(ctx, progress)
+
+		if err := s.processItem(ctx, kb, payload.TaskID, i, payload.Provider, item); err != nil {
+			logger.Warnf(ctx, "Failed to extract %s content from %s: %v",
+				item.Platform, secutils.SanitizeForLog(item.URL), err)
+			item.Status = types.SocialMediaStatusFailed
+			item.Error = err.Error()
+		} else {
+			item.Status = types.SocialMediaStatusCompleted
+			item.Error = ""
+		}
+		s.updateProgress(ctx, progress)
+	}
+
+	progress.Status = types.SocialMediaStatusCompleted
+	progress.Message = fmt.Sprintf("Extraction finished: %d succeeded, %d failed", progress.Succeeded, progress.Failed)
+	if progress.Succeeded == 0 {
+		progress.Status = types.SocialMediaStatusFailed
+		progress.Error = "all items failed"
+	}
+	s.updateProgress(ctx, progress)
+
+	logger.Infof(ctx, "Social media extract task %s finished: %d succeeded, %d failed",
+		payload.TaskID, progress.Succeeded, progress.Failed)
+	return nil
+}
+
+// processItem extracts a single URL and stores the result as knowledge
+func (s *socialMediaService) processItem(ctx context.Context,
+	kb *types.KnowledgeBase, taskID string, index int, provider string, item *types.SocialMediaItemProgress,
+) error {
+	// 知识 ID 由任务和条目序号确定，任务重试时能找到上次创建的知识，避免重复创建
+	knowledgeID := socialMediaKnowledgeID(taskID, index)
+	existing, err := s.knowledgeRepo.GetKnowledgeByID(ctx, kb.TenantID, knowledgeID)
+	switch {
+	case err == nil && existing.ParseStatus == types.ParseStatusCompleted && existing.Source != "":
+		// 上次已完成，只是未能记录条目状态
+		item.KnowledgeID = existing.ID
+		item.Title = existing.Title
+		item.Provider = existing.GetMetadata()["provider"]
+		return nil
+	case err == nil:
+		// 上次在处理中途中断，删除后重新创建
+		logger.Infof(ctx, "Deleting knowledge %s left by an interrupted extraction", knowledgeID)
+		if err := s.knowledgeService.DeleteKnowledge(ctx, knowledgeID); err != nil {
+			return fmt.Errorf("delete interrupted knowledge failed: %w", err)
+		}
+	case !errors.Is(err, repository.ErrKnowledgeNotFound):
+		return fmt.Errorf("get knowledge failed: %w", err)
+	}
+
+	req := &types.SocialMediaExtractRequest{
+		Platform:     item.Platform,
+		URL:          item.URL,
+		AliyunAPIKey: kb.StorageConfig.AliyunAPIKey,
+	}
+
+	content, extractorName, err := s.extract(ctx, req, provider)
+	if err != nil {
+		return err
+	}
+	item.Provider = extractorName
+
+	knowledge, err := s.knowledgeService.CreateKnowledgeFromPassageSyncWithID(
+		ctx, kb.ID, knowledgeID, []string{content.Content})
+	if err != nil {
+		return fmt.Errorf("create knowledge failed: %w", err)
+	}
+	item.KnowledgeID = knowledge.ID
+
+	title := strings.TrimSpace(content.Title)
+	if title == "" {
+		title = fmt.Sprintf("%s_%s", item.Platform, time.Now().Format("20060102_150405"))
+	}
+	if safeTitle, ok := secutils.ValidateInput(title); ok && safeTitle != "" {
+		title = safeTitle
+	} else {
+		title = fmt.Sprintf("%s_%s", item.Platform, time.Now().Format("20060102_150405"))
+	}
+	item.Title = title
+
+	originalURL := content.OriginalURL
+	if originalURL == "" {
+		originalURL = item.URL
+	}
+	metadata := &types.SocialMediaMetadata{
+		Platform:    item.Platform,
+		Provider:    extractorName,
+		Author:      content.Author,
+		PublishTime: content.PublishTime,
+		OriginalURL: originalURL,
+		ExtractedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	metadataJSON, err := metadata.ToJSON()
+	if err != nil {
+		return fmt.Errorf("marshal metadata failed: %w", err)
+	}
+
+	// 逐列更新，避免覆盖文档处理流程写入的解析状态；source 最后写入，重试时据此判断条目已完成
+	columns := []struct {
+		name  string
+		value interface{}
+	}{
+		{"title", title},
+		{"metadata", metadataJSON},
+		{"source", fmt.Sprintf("%s:%s", item.Platform, item.URL)},
+	}
+	for _, column := range columns {
+		if err := s.knowledgeRepo.UpdateKnowledgeColumn(ctx, knowledge.ID, column.name, column.value); err != nil {
+			logger.Warnf(ctx, "Failed to update knowledge %s column %s: %v", knowledge.ID, column.name, err)
+		}
+	}
+	return nil
+}
+
+// socialMediaKnowledgeID returns the ID of the knowledge created for an item of an extraction task
+func socialMediaKnowledgeID(taskID string, index int) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte("social-media:"+taskID+":"+strconv.Itoa(index))).String()
+}
+
+// extract runs the extractors supporting the platform in order until one succeeds
+// When provider is set only that extractor is used
+func (s *socialMediaService) extract(ctx context.Context,
+	req *types.SocialMediaExtractRequest, provider string,
+) (*types.SocialMediaContent, string, error) {
+	var errs []string
+	for _, extractor := range s.extractors {
+		if provider != "" && extractor.Name() != provider {
+			continue
+		}
+		if !extractor.Supports(req.Platform) {
+			continue
+		}
+
+		extractCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		content, err := extractor.Extract(extractCtx, req)
+		cancel()
+		if err == nil && content != nil && strings.TrimSpace(content.Content) != "" {
+			return content, extractor.Name(), nil
+		}
+		if err == nil {
+			err = fmt.Errorf("empty content")
+		}
+		logger.Warnf(ctx, "Extractor %s failed for %s: %v", extractor.Name(), secutils.SanitizeForLog(req.URL), err)
+		errs = append(errs, fmt.Sprintf("%s: %v", extractor.Name(), err))
+	}
+	if len(errs) == 0 {
+		return nil, "", fmt.Errorf("no extractor available for platform %s", req.Platform)
+	}
+	return nil, "", fmt.Errorf("extract failed: %s", strings.Join(errs, "; "))
+}
+
+// getExtractor returns the extractor with the given ID
+func (s *socialMediaService) getExtractor(name string) interfaces.SocialMediaExtractor {
+	for _, extractor := range s.extractors {
+		if extractor.Name() == name {
+			return extractor
+		}
+	}
+	return nil
+}
+
+// hasExtractorFor reports whether some extractor (or the requested one) supports the platform
+func (s *socialMediaService) hasExtractorFor(platform, provider string) bool {
+	for _, extractor := range s.extractors {
+		if provider != "" && extractor.Name() != provider {
+			continue
+		}
+		if extractor.Supports(platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// newSocialMediaProgress builds the initial progress of an extraction task
+func newSocialMediaProgress(payload *types.SocialMediaExtractPayload) *types.SocialMediaExtractProgress {
+	now := time.Now().Unix()
+	progress := &types.SocialMediaExtractProgress{
+		TaskID:          payload.TaskID,
+		TenantID:        payload.TenantID,
+		KnowledgeBaseID: payload.KnowledgeBaseID,
+		Status:          types.SocialMediaStatusPending,
+		Total:           len(payload.Items),
+		Items:           make([]types.SocialMediaItemProgress, 0, len(payload.Items)),
+		Message:         "Task queued, waiting to start...",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	for _, item := range payload.Items {
+		progress.Items = append(progress.Items, types.SocialMediaItemProgress{
+			Platform: item.Platform,
+			URL:      item.URL,
+			Status:   types.SocialMediaStatusPending,
+		})
+	}
+	return progress
+}
+
+// updateProgress recomputes the counters and saves the progress, logging failures
+func (s *socialMediaService) updateProgress(ctx context.Context, progress *types.SocialMediaExtractProgress) {
+	progress.Processed, progress.Succeeded, progress.Failed = 0, 0, 0
+	for _, item := range progress.Items {
+		switch item.Status {
+		case types.SocialMediaStatusCompleted:
+			progress.Succeeded++
+		case types.SocialMediaStatusFailed:
+			progress.Failed++
+		default:
+			continue
+		}
+		progress.Processed++
+	}
+	if progress.Total > 0 {
+		progress.Progress = progress.Processed * 100 / progress.Total
+	}
+	if err := s.saveProgress(ctx, progress); err != nil {
+		logger.Errorf(ctx, "Failed to update social media extract progress: %v", err)
+	}
+}
+
+// saveProgress saves the extraction progress to Redis
+func (s *socialMediaService) saveProgress(ctx context.Context, progress *types.SocialMediaExtractProgress) error {
+	progress.UpdatedAt = time.Now().Unix()
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+	return s.redisClient.Set(ctx, socialMediaProgressKeyPrefix+progress.TaskID, data, socialMediaProgressTTL).Err()
+}
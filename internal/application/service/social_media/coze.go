@@ -0,0 +1,202 @@
+package social_media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	defaultCozeAPIURL     = "https://api.coze.cn/v1/workflow/stream_run"
+	defaultCozeWorkflowID = "7596601391050588212"
+	// cozeMaxLineSize is the maximum size of a single SSE line in the workflow stream
+	cozeMaxLineSize = 4 << 20
+)
+
+// CozeExtractor extracts video transcripts through a Coze workflow
+type CozeExtractor struct {
+	platformFilter
+	apiURL     string
+	token      string
+	workflowID string
+	client     *http.Client
+}
+
+// cozeWorkflowRequest Coze workflow 请求
+type cozeWorkflowRequest struct {
+	WorkflowID string                 `json:"workflow_id"`
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+// cozeStreamResponse Coze 流式响应
+type cozeStreamResponse struct {
+	Content      string `json:"content"`
+	ContentType  string `json:"content_type"`
+	NodeType     string `json:"node_type"`
+	NodeID       string `json:"node_id"`
+	NodeTitle    string `json:"node_title"`
+	NodeIsFinish bool   `json:"node_is_finish"`
+}
+
+// cozeStructuredOutput is the optional structured output of the workflow End node
+type cozeStructuredOutput struct {
+	Title       string `json:"title"`
+	Content     string `json:"content"`
+	Output      string `json:"output"`
+	Author      string `json:"author"`
+	PublishTime string `json:"publish_time"`
+}
+
+// NewCozeExtractor creates a new Coze workflow extractor
+func NewCozeExtractor(cfg config.SocialMediaExtractorConfig) (interfaces.SocialMediaExtractor, error) {
+	apiURL := configValue(cfg.APIURL)
+	if apiURL == "" {
+		apiURL = defaultCozeAPIURL
+	}
+	workflowID := configValue(cfg.WorkflowID)
+	if workflowID == "" {
+		workflowID = defaultCozeWorkflowID
+	}
+	return &CozeExtractor{
+		platformFilter: cfg.Platforms,
+		apiURL:         apiURL,
+		token:          configValue(cfg.APIKey),
+		workflowID:     workflowID,
+		// Coze Workflow 执行时间较长，超时由任务上下文控制
+		client: &http.Client{Timeout: 10 * time.Minute},
+	}, nil
+}
+
+// Name returns the extractor ID
+func (e *CozeExtractor) Name() string {
+	return "coze"
+}
+
+// Extract calls the Coze workflow and returns the extracted transcript
+func (e *CozeExtractor) Extract(
+	ctx context.Context,
+	req *types.SocialMediaExtractRequest,
+) (*types.SocialMediaContent, error) {
+	if e.token == "" {
+		return nil, fmt.Errorf("coze api token is not configured")
+	}
+	if req.AliyunAPIKey == "" {
+		return nil, fmt.Errorf("aliyun api key is not configured for the knowledge base")
+	}
+
+	reqJSON, err := json.Marshal(cozeWorkflowRequest{
+		WorkflowID: e.workflowID,
+		Parameters: map[string]interface{}{
+			"video_url": req.URL,
+			"aliy_api":  req.AliyunAPIKey,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request failed: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.apiURL, bytes.NewReader(reqJSON))
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+e.token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("coze API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	output, err := parseCozeStream(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parse response failed: %w", err)
+	}
+	return parseCozeOutput(output, req.URL), nil
+}
+
+// parseCozeStream parses the SSE stream of a workflow run, preferring the content of the End node
+func parseCozeStream(body io.Reader) (string, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), cozeMaxLineSize)
+	var finalContent string
+	var lastError string
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		jsonData := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+
+		// 尝试解析为错误响应
+		var errorResp struct {
+			ErrorMessage string `json:"error_message"`
+			ErrorCode    int    `json:"error_code"`
+		}
+		if err := json.Unmarshal([]byte(jsonData), &errorResp); err == nil && errorResp.ErrorMessage != "" {
+			lastError = errorResp.ErrorMessage
+			continue
+		}
+
+		var streamResp cozeStreamResponse
+		if err := json.Unmarshal([]byte(jsonData), &streamResp); err != nil || streamResp.Content == "" {
+			continue
+		}
+		// 优先使用 End 节点的内容，否则暂存第一个非空节点的内容
+		if streamResp.NodeType == "End" || finalContent == "" {
+			finalContent = streamResp.Content
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("read stream failed: %w", err)
+	}
+	if lastError != "" {
+		return "", fmt.Errorf("workflow execution failed: %s", lastError)
+	}
+	if finalContent == "" {
+		return "", fmt.Errorf("no content found in response")
+	}
+	return finalContent, nil
+}
+
+// parseCozeOutput converts the workflow output to extracted content
+// The End node may return plain text or a JSON object carrying title/author/publish_time
+func parseCozeOutput(output string, originalURL string) *types.SocialMediaContent {
+	result := &types.SocialMediaContent{OriginalURL: originalURL}
+
+	var structured cozeStructuredOutput
+	trimmed := strings.TrimSpace(output)
+	if strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &structured) == nil {
+		content := structured.Content
+		if content == "" {
+			content = structured.Output
+		}
+		if content != "" {
+			result.Title = strings.TrimSpace(structured.Title)
+			result.Content = content
+			result.Author = strings.TrimSpace(structured.Author)
+			result.PublishTime = normalizePublishTime(structured.PublishTime)
+			return result
+		}
+	}
+
+	result.Content = output
+	return result
+}
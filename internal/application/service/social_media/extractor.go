@@ -0,0 +1,104 @@
+package social_media
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// maxFetchRedirects bounds the redirects followed when fetching user-supplied URLs
+const maxFetchRedirects = 10
+
+// NewExtractor creates an extractor from its configuration
+func NewExtractor(cfg config.SocialMediaExtractorConfig) (interfaces.SocialMediaExtractor, error) {
+	switch cfg.ID {
+	case "coze":
+		return NewCozeExtractor(cfg)
+	case "subtitle":
+		return NewSubtitleExtractor(cfg)
+	case "stt":
+		return NewSTTExtractor(cfg)
+	default:
+		return nil, fmt.Errorf("unknown social media extractor: %s", cfg.ID)
+	}
+}
+
+// platformFilter implements Supports for extractors restricted to a set of platforms
+type platformFilter []string
+
+// Supports reports whether the platform is in the list; an empty list supports all platforms
+func (f platformFilter) Supports(platform string) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, p := range f {
+		if strings.EqualFold(p, platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// newGuardedHTTPClient creates a client for user-supplied URLs: it only fetches http(s) URLs and
+// refuses to connect to loopback, private and link-local addresses, redirect targets included
+func newGuardedHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, Control: utils.DenyPrivateNetwork}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return errors.New("stopped after too many redirects")
+			}
+			return checkFetchURL(req.URL)
+		},
+	}
+}
+
+// checkFetchURL rejects URLs that are not absolute http(s) URLs
+func checkFetchURL(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("unsupported url %q: only http and https urls are allowed", u.Redacted())
+	}
+	return nil
+}
+
+// configValue returns the configured value, treating unresolved ${ENV} references as empty
+func configValue(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") {
+		return ""
+	}
+	return value
+}
+
+// normalizePublishTime converts common platform time formats to RFC3339, keeping the raw value otherwise
+func normalizePublishTime(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	layouts := []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return value
+}
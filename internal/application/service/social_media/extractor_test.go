@@ -0,0 +1,138 @@
+package social_media
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func TestParseSubtitle(t *testing.T) {
+	vtt := "WEBVTT\nKind: captions\n\nNOTE generated\nfor test\n\n1\n00:00:01.000 --> 00:00:02.000\n<c>大家好</c>\n\n2\n00:00:02.000 --> 00:00:03.000\n大家好\n今天讲讲<i>知识库</i>\n"
+	got := parseSubtitle(vtt)
+	want := "大家好\n今天讲讲知识库"
+	if got != want {
+		t.Fatalf("unexpected subtitle text: %q", got)
+	}
+}
+
+func TestParseCozeStream(t *testing.T) {
+	stream := strings.Join([]string{
+		"event: Message",
+		`data: {"content":"partial","node_type":"LLM"}`,
+		"",
+		"event: Message",
+		`data: {"content":"{\"title\":\"标题\",\"content\":\"正文\",\"author\":\"作者\",\"publish_time\":\"2025-01-02\"}","node_type":"End"}`,
+		"",
+	}, "\n")
+	output, err := parseCozeStream(strings.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := parseCozeOutput(output, "https://example.com/v/1")
+	if content.Title != "标题" || content.Content != "正文" || content.Author != "作者" {
+		t.Fatalf("unexpected structured output: %+v", content)
+	}
+	if content.PublishTime != "2025-01-02T00:00:00Z" {
+		t.Fatalf("unexpected publish time: %s", content.PublishTime)
+	}
+
+	if _, err := parseCozeStream(strings.NewReader(`data: {"error_message":"boom","error_code":1}`)); err == nil {
+		t.Fatalf("expected workflow error")
+	}
+}
+
+func TestSubtitleExtractorFromPage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/video", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head>
+<title>fallback</title>
+<meta property="og:title" content="视频标题">
+<meta name="author" content="UP主">
+<meta property="article:published_time" content="2025-03-04T05:06:07Z">
+<link rel="canonical" href="https://example.com/canonical">
+</head><body><video>
+<track kind="subtitles" srclang="en" src="/subs/en.vtt">
+<track kind="subtitles" srclang="zh-CN" src="subs/zh.vtt">
+</video></body></html>`))
+	})
+	mux.HandleFunc("/subs/zh.vtt", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("WEBVTT\n\n00:00:01.000 --> 00:00:02.000\n中文字幕\n"))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	extractor, err := NewSubtitleExtractor(config.SocialMediaExtractorConfig{ID: "subtitle"})
+	if err != nil {
+		t.Fatalf("failed to build extractor: %v", err)
+	}
+	// 测试服务器监听在回环地址上
+	extractor.(*SubtitleExtractor).client = ts.Client()
+	content, err := extractor.Extract(context.Background(), &types.SocialMediaExtractRequest{
+		Platform: "bilibili",
+		URL:      ts.URL + "/video",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content.Content != "中文字幕" {
+		t.Fatalf("expected chinese track, got %q", content.Content)
+	}
+	if content.Title != "视频标题" || content.Author != "UP主" ||
+		content.PublishTime != "2025-03-04T05:06:07Z" || content.OriginalURL != "https://example.com/canonical" {
+		t.Fatalf("unexpected metadata: %+v", content)
+	}
+}
+
+func TestExtractorsRejectPrivateNetwork(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte("WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nsecret\n"))
+	}))
+	defer ts.Close()
+
+	subtitle, err := NewSubtitleExtractor(config.SocialMediaExtractorConfig{ID: "subtitle"})
+	if err != nil {
+		t.Fatalf("failed to build extractor: %v", err)
+	}
+	stt, err := NewSTTExtractor(config.SocialMediaExtractorConfig{ID: "stt", APIURL: ts.URL})
+	if err != nil {
+		t.Fatalf("failed to build extractor: %v", err)
+	}
+	for _, extractor := range []interfaces.SocialMediaExtractor{subtitle, stt} {
+		for _, rawURL := range []string{ts.URL + "/video.vtt", "file:///etc/passwd", "ftp://example.com/a.mp4"} {
+			_, err := extractor.Extract(context.Background(), &types.SocialMediaExtractRequest{URL: rawURL})
+			if err == nil {
+				t.Fatalf("%s: expected %s to be rejected", extractor.Name(), rawURL)
+			}
+		}
+	}
+	if n := requests.Load(); n != 0 {
+		t.Fatalf("expected no request to reach the private server, got %d", n)
+	}
+}
+
+func TestPlatformFilter(t *testing.T) {
+	extractor, _ := NewCozeExtractor(config.SocialMediaExtractorConfig{
+		ID:        "coze",
+		Platforms: []string{"douyin"},
+		APIKey:    "${COZE_API_TOKEN}",
+	})
+	if !extractor.Supports("Douyin") || extractor.Supports("bilibili") {
+		t.Fatalf("unexpected platform support")
+	}
+	if extractor.(*CozeExtractor).token != "" {
+		t.Fatalf("unresolved env reference should be treated as empty")
+	}
+	if _, err := NewExtractor(config.SocialMediaExtractorConfig{ID: "unknown"}); err == nil {
+		t.Fatalf("expected unknown extractor error")
+	}
+}
@@ -0,0 +1,179 @@
+package social_media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// maxSTTMediaSize limits the size of media downloaded for transcription
+	maxSTTMediaSize = 200 << 20
+	defaultSTTModel = "whisper-1"
+)
+
+// STTExtractor downloads the media file and transcribes it with a local speech-to-text service
+// The service must expose an OpenAI compatible /audio/transcriptions endpoint
+type STTExtractor struct {
+	platformFilter
+	apiURL string
+	apiKey string
+	model  string
+	client *http.Client
+	// mediaClient downloads the user-supplied media, it cannot reach private networks
+	mediaClient *http.Client
+}
+
+// NewSTTExtractor creates a new speech-to-text extractor
+func NewSTTExtractor(cfg config.SocialMediaExtractorConfig) (interfaces.SocialMediaExtractor, error) {
+	model := configValue(cfg.Model)
+	if model == "" {
+		model = defaultSTTModel
+	}
+	return &STTExtractor{
+		platformFilter: cfg.Platforms,
+		apiURL:         strings.TrimRight(configValue(cfg.APIURL), "/"),
+		apiKey:         configValue(cfg.APIKey),
+		model:          model,
+		client: &http.Client{
+			Timeout: 30 * time.Minute,
+		},
+		mediaClient: newGuardedHTTPClient(30 * time.Minute),
+	}, nil
+}
+
+// Name returns the extractor ID
+func (e *STTExtractor) Name() string {
+	return "stt"
+}
+
+// Extract downloads the media and returns its transcription
+func (e *STTExtractor) Extract(
+	ctx context.Context,
+	req *types.SocialMediaExtractRequest,
+) (*types.SocialMediaContent, error) {
+	if e.apiURL == "" {
+		return nil, fmt.Errorf("speech-to-text api url is not configured")
+	}
+
+	media, err := e.download(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := e.transcribe(ctx, mediaFileName(req.URL), media)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("transcription is empty")
+	}
+	return &types.SocialMediaContent{
+		Content:     text,
+		OriginalURL: req.URL,
+	}, nil
+}
+
+// download fetches the media file; only direct audio/video links are supported
+func (e *STTExtractor) download(ctx context.Context, rawURL string) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	if err := checkFetchURL(httpReq.URL); err != nil {
+		return nil, err
+	}
+	resp, err := e.mediaClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("download media failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download media failed with status %d", resp.StatusCode)
+	}
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	if strings.HasPrefix(contentType, "text/html") {
+		return nil, fmt.Errorf("url is a web page, a direct audio/video link is required")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSTTMediaSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read media failed: %w", err)
+	}
+	if len(data) > maxSTTMediaSize {
+		return nil, fmt.Errorf("media exceeds %dMB", maxSTTMediaSize>>20)
+	}
+	return data, nil
+}
+
+// transcribe sends the media to the transcription endpoint
+func (e *STTExtractor) transcribe(ctx context.Context, fileName string, media []byte) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return "", fmt.Errorf("create form file failed: %w", err)
+	}
+	if _, err := part.Write(media); err != nil {
+		return "", fmt.Errorf("write form file failed: %w", err)
+	}
+	_ = writer.WriteField("model", e.model)
+	_ = writer.WriteField("response_format", "json")
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("close multipart writer failed: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.apiURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", fmt.Errorf("create request failed: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+	if e.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read transcription response failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("transcription API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("parse transcription response failed: %w", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
+
+// mediaFileName derives the upload file name from the media URL
+func mediaFileName(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		if name := path.Base(u.Path); name != "" && name != "/" && name != "." && path.Ext(name) != "" {
+			return name
+		}
+	}
+	return "media.mp4"
+}
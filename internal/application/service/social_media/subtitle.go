@@ -0,0 +1,236 @@
+package social_media
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// maxSubtitlePageSize limits the size of pages and subtitle files fetched by the subtitle extractor
+const maxSubtitlePageSize = 10 << 20
+
+// subtitleTagPattern matches inline markup in subtitle cues, e.g. <c>, <i>, <00:00:01.000>, {\an8}
+var subtitleTagPattern = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`)
+
+// SubtitleExtractor fetches subtitle/transcript tracks published with a video
+// It accepts either a direct SRT/VTT link or a page exposing <track> subtitle elements
+type SubtitleExtractor struct {
+	platformFilter
+	client *http.Client
+}
+
+// NewSubtitleExtractor creates a new subtitle extractor
+func NewSubtitleExtractor(cfg config.SocialMediaExtractorConfig) (interfaces.SocialMediaExtractor, error) {
+	return &SubtitleExtractor{
+		platformFilter: cfg.Platforms,
+		client:         newGuardedHTTPClient(60 * time.Second),
+	}, nil
+}
+
+// Name returns the extractor ID
+func (e *SubtitleExtractor) Name() string {
+	return "subtitle"
+}
+
+// Extract fetches the subtitle track of the video and converts it to plain text
+func (e *SubtitleExtractor) Extract(
+	ctx context.Context,
+	req *types.SocialMediaExtractRequest,
+) (*types.SocialMediaContent, error) {
+	body, contentType, err := e.fetch(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.SocialMediaContent{OriginalURL: req.URL}
+	if isSubtitleFile(req.URL, contentType) {
+		result.Content = parseSubtitle(string(body))
+	} else {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+		if err != nil {
+			return nil, fmt.Errorf("parse page failed: %w", err)
+		}
+		fillPageMetadata(doc, result)
+
+		trackURL, ok := findSubtitleTrack(doc, req.URL)
+		if !ok {
+			return nil, fmt.Errorf("no subtitle track found on page")
+		}
+		trackBody, _, err := e.fetch(ctx, trackURL)
+		if err != nil {
+			return nil, fmt.Errorf("fetch subtitle track failed: %w", err)
+		}
+		result.Content = parseSubtitle(string(trackBody))
+	}
+
+	if strings.TrimSpace(result.Content) == "" {
+		return nil, fmt.Errorf("subtitle track is empty")
+	}
+	return result, nil
+}
+
+// fetch downloads a page or subtitle file with a size limit
+func (e *SubtitleExtractor) fetch(ctx context.Context, rawURL string) ([]byte, string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("create request failed: %w", err)
+	}
+	if err := checkFetchURL(httpReq.URL); err != nil {
+		return nil, "", err
+	}
+	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (compatible; WeKnora/1.0)")
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return nil, "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSubtitlePageSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("read response failed: %w", err)
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// isSubtitleFile reports whether the URL/content type denotes a subtitle file rather than a web page
+func isSubtitleFile(rawURL, contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if strings.Contains(contentType, "text/vtt") || strings.Contains(contentType, "subrip") {
+		return true
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		switch strings.ToLower(path.Ext(u.Path)) {
+		case ".vtt", ".srt":
+			return true
+		}
+	}
+	return false
+}
+
+// findSubtitleTrack returns the absolute URL of the best subtitle track on the page
+// Chinese tracks are preferred, then the default track, then the first one
+func findSubtitleTrack(doc *goquery.Document, pageURL string) (string, bool) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", false
+	}
+
+	var first, preferred string
+	doc.Find("track[src]").Each(func(_ int, s *goquery.Selection) {
+		kind := strings.ToLower(s.AttrOr("kind", "subtitles"))
+		if kind != "subtitles" && kind != "captions" {
+			return
+		}
+		ref, err := url.Parse(strings.TrimSpace(s.AttrOr("src", "")))
+		if err != nil {
+			return
+		}
+		trackURL := base.ResolveReference(ref).String()
+		if first == "" {
+			first = trackURL
+		}
+		lang := strings.ToLower(s.AttrOr("srclang", ""))
+		if preferred == "" && (strings.HasPrefix(lang, "zh") || s.Is("[default]")) {
+			preferred = trackURL
+		}
+	})
+	if preferred != "" {
+		return preferred, true
+	}
+	return first, first != ""
+}
+
+// fillPageMetadata reads title, author, publish time and canonical URL from page meta tags
+func fillPageMetadata(doc *goquery.Document, result *types.SocialMediaContent) {
+	meta := func(selectors ...string) string {
+		for _, selector := range selectors {
+			if value := strings.TrimSpace(doc.Find(selector).First().AttrOr("content", "")); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	result.Title = meta(`meta[property="og:title"]`, `meta[name="twitter:title"]`)
+	if result.Title == "" {
+		result.Title = strings.TrimSpace(doc.Find("title").First().Text())
+	}
+	result.Author = meta(`meta[name="author"]`, `meta[property="article:author"]`, `meta[property="og:video:director"]`)
+	result.PublishTime = normalizePublishTime(meta(
+		`meta[property="article:published_time"]`,
+		`meta[property="og:video:release_date"]`,
+		`meta[itemprop="uploadDate"]`,
+		`meta[itemprop="datePublished"]`,
+	))
+	if canonical := strings.TrimSpace(doc.Find(`link[rel="canonical"]`).First().AttrOr("href", "")); canonical != "" {
+		result.OriginalURL = canonical
+	} else if ogURL := meta(`meta[property="og:url"]`); ogURL != "" {
+		result.OriginalURL = ogURL
+	}
+}
+
+// parseSubtitle converts SRT/WebVTT content to plain text, dropping cue numbers, timings and markup
+func parseSubtitle(content string) string {
+	content = strings.ReplaceAll(strings.TrimPrefix(content, "\ufeff"), "\r\n", "\n")
+
+	var lines []string
+	var last string
+	skipBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			skipBlock = false
+			continue
+		}
+		if skipBlock {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "WEBVTT"),
+			strings.HasPrefix(line, "NOTE"),
+			strings.HasPrefix(line, "STYLE"),
+			strings.HasPrefix(line, "REGION"):
+			// 跳过头部及注释/样式块
+			skipBlock = true
+			continue
+		case strings.Contains(line, "-->"):
+			continue
+		case isCueNumber(line):
+			continue
+		}
+
+		text := strings.TrimSpace(subtitleTagPattern.ReplaceAllString(line, ""))
+		// 滚动字幕中相邻行经常重复
+		if text == "" || text == last {
+			continue
+		}
+		lines = append(lines, text)
+		last = text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isCueNumber reports whether the line is an SRT cue sequence number
+func isCueNumber(line string) bool {
+	for _, r := range line {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticExtractor returns the same content for every URL
+type staticExtractor struct{}
+
+func (staticExtractor) Name() string { return "static" }
+
+func (staticExtractor) Supports(platform string) bool { return true }
+
+func (staticExtractor) Extract(
+	ctx context.Context, req *types.SocialMediaExtractRequest,
+) (*types.SocialMediaContent, error) {
+	return &types.SocialMediaContent{Title: "视频标题", Content: "文案内容"}, nil
+}
+
+// memoryKnowledgeRepository keeps knowledge in memory
+type memoryKnowledgeRepository struct {
+	interfaces.KnowledgeRepository
+	knowledge map[string]*types.Knowledge
+}
+
+// memoryKnowledgeService creates and deletes the knowledge of a memoryKnowledgeRepository
+type memoryKnowledgeService struct {
+	interfaces.KnowledgeService
+	repo    *memoryKnowledgeRepository
+	created int
+	deleted int
+}
+
+func (m *memoryKnowledgeService) CreateKnowledgeFromPassageSyncWithID(
+	ctx context.Context, kbID, knowledgeID string, passage []string,
+) (*types.Knowledge, error) {
+	m.created++
+	m.repo.knowledge[knowledgeID] = &types.Knowledge{
+		ID: knowledgeID, TenantID: 1, KnowledgeBaseID: kbID, ParseStatus: types.ParseStatusCompleted,
+	}
+	return m.repo.knowledge[knowledgeID], nil
+}
+
+func (m *memoryKnowledgeService) DeleteKnowledge(ctx context.Context, id string) error {
+	m.deleted++
+	delete(m.repo.knowledge, id)
+	return nil
+}
+
+func (m *memoryKnowledgeRepository) GetKnowledgeByID(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.Knowledge, error) {
+	knowledge, ok := m.knowledge[id]
+	if !ok || knowledge.TenantID != tenantID {
+		return nil, repository.ErrKnowledgeNotFound
+	}
+	copied := *knowledge
+	return &copied, nil
+}
+
+func (m *memoryKnowledgeRepository) UpdateKnowledgeColumn(
+	ctx context.Context, id string, column string, value interface{},
+) error {
+	switch column {
+	case "title":
+		m.knowledge[id].Title = value.(string)
+	case "source":
+		m.knowledge[id].Source = value.(string)
+	case "metadata":
+		m.knowledge[id].Metadata = value.(types.JSON)
+	}
+	return nil
+}
+
+func TestProcessSocialMediaItemIsIdempotent(t *testing.T) {
+	repo := &memoryKnowledgeRepository{knowledge: make(map[string]*types.Knowledge)}
+	store := &memoryKnowledgeService{repo: repo}
+	s := &socialMediaService{
+		extractors:       []interfaces.SocialMediaExtractor{staticExtractor{}},
+		timeout:          time.Minute,
+		knowledgeService: store,
+		knowledgeRepo:    repo,
+	}
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	kb := &types.KnowledgeBase{ID: "kb", TenantID: 1}
+	newItem := func() *types.SocialMediaItemProgress {
+		return &types.SocialMediaItemProgress{Platform: "bilibili", URL: "https://example.com/video"}
+	}
+
+	item := newItem()
+	require.NoError(t, s.processItem(ctx, kb, "task", 0, "", item))
+	assert.Equal(t, socialMediaKnowledgeID("task", 0), item.KnowledgeID)
+	assert.Equal(t, "bilibili:https://example.com/video", repo.knowledge[item.KnowledgeID].Source)
+
+	// 重试时条目状态未能保存：复用已创建的知识
+	retried := newItem()
+	require.NoError(t, s.processItem(ctx, kb, "task", 0, "", retried))
+	assert.Equal(t, item.KnowledgeID, retried.KnowledgeID)
+	assert.Equal(t, "视频标题", retried.Title)
+	assert.Equal(t, "static", retried.Provider)
+	assert.Equal(t, 1, store.created)
+
+	// 上次处理中途中断：删除残留的知识后重新创建
+	repo.knowledge[item.KnowledgeID].ParseStatus = "processing"
+	repo.knowledge[item.KnowledgeID].Source = ""
+	require.NoError(t, s.processItem(ctx, kb, "task", 0, "", newItem()))
+	assert.Equal(t, 1, store.deleted)
+	assert.Equal(t, 2, store.created)
+	assert.Len(t, repo.knowledge, 1)
+
+	// 其他条目使用不同的知识
+	other := newItem()
+	require.NoError(t, s.processItem(ctx, kb, "task", 1, "", other))
+	assert.NotEqual(t, item.KnowledgeID, other.KnowledgeID)
+	assert.Len(t, repo.knowledge, 2)
+}
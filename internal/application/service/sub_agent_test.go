@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubCustomAgentService serves custom agents from a map
+type stubCustomAgentService struct {
+	interfaces.CustomAgentService
+	agents map[string]*types.CustomAgent
+}
+
+func (s *stubCustomAgentService) GetAgentByID(ctx context.Context, id string) (*types.CustomAgent, error) {
+	if agent, ok := s.agents[id]; ok {
+		return agent, nil
+	}
+	return nil, errors.New("agent not found")
+}
+
+// stubSubAgentRunner plays a sub-agent that searches once and answers
+type stubSubAgentRunner struct{}
+
+func (stubSubAgentRunner) RunSubAgent(
+	ctx context.Context, agentID, task string, path []string, sessionID string, eventBus *event.EventBus,
+) (*types.AgentState, error) {
+	eventBus.Emit(ctx, event.Event{
+		ID:   "call_a-tool-call",
+		Type: event.EventAgentToolCall,
+		Data: event.AgentToolCallData{ToolCallID: "call_a", ToolName: tools.ToolKnowledgeSearch},
+	})
+	eventBus.Emit(ctx, event.Event{
+		ID:   "answer",
+		Type: event.EventAgentFinalAnswer,
+		Data: event.AgentFinalAnswerData{Content: "42", Done: true},
+	})
+	results := []map[string]interface{}{
+		{"chunk_id": "c1", "knowledge_id": "k1", "knowledge_title": "Report", "content": "…"},
+		{"chunk_id": "c1", "knowledge_id": "k1", "knowledge_title": "Report", "content": "…"},
+	}
+	return &types.AgentState{
+		FinalAnswer: "42",
+		RoundSteps: []types.AgentStep{{ToolCalls: []types.ToolCall{{
+			ID:     "call_a",
+			Name:   tools.ToolKnowledgeSearch,
+			Result: &types.ToolResult{Success: true, Data: map[string]interface{}{"results": results}},
+		}}}},
+	}, nil
+}
+
+func TestResolveSubAgents(t *testing.T) {
+	agentMode := types.CustomAgentConfig{AgentMode: types.AgentModeSmartReasoning}
+	s := &sessionService{customAgentService: &stubCustomAgentService{agents: map[string]*types.CustomAgent{
+		"coordinator": {ID: "coordinator", Config: agentMode},
+		"analyst":     {ID: "analyst", Name: "Analyst", Description: "Analyzes data", Config: agentMode},
+		"quick":       {ID: "quick", Config: types.CustomAgentConfig{AgentMode: types.AgentModeQuickAnswer}},
+	}}}
+	coordinator := &types.CustomAgent{ID: "coordinator", Config: types.CustomAgentConfig{
+		AgentMode: types.AgentModeSmartReasoning,
+		SubAgents: []string{"analyst", "quick", "missing", "coordinator"},
+	}}
+	ctx := context.Background()
+
+	subAgents := s.resolveSubAgents(ctx, coordinator, []string{"coordinator"})
+	assert.Equal(t, []types.SubAgentInfo{{ID: "analyst", Name: "Analyst", Description: "Analyzes data"}}, subAgents)
+
+	// 委派链已达到最大深度
+	path := []string{"root", "middle", "coordinator"}
+	require.Len(t, path, types.MaxAgentDelegationDepth+1)
+	assert.Empty(t, s.resolveSubAgents(ctx, coordinator, path))
+}
+
+func TestDelegateAgentToolNestsEvents(t *testing.T) {
+	parentBus := event.NewEventBus()
+	var forwarded []event.Event
+	for _, eventType := range []event.EventType{event.EventAgentToolCall, event.EventAgentThought} {
+		parentBus.On(eventType, func(ctx context.Context, evt event.Event) error {
+			forwarded = append(forwarded, evt)
+			return nil
+		})
+	}
+	parentBus.On(event.EventAgentFinalAnswer, func(ctx context.Context, evt event.Event) error {
+		t.Error("the answer of the sub-agent must not become the answer of the conversation")
+		return nil
+	})
+
+	tool := tools.NewDelegateAgentTool(types.SubAgentInfo{ID: "builtin-data-analyst", Name: "Analyst"},
+		stubSubAgentRunner{}, parentBus, []string{"coordinator"}, "s1")
+	assert.Equal(t, "agent.builtin_data_analyst", tool.Name())
+
+	ctx := context.WithValue(context.Background(), types.ToolCallIDContextKey, "call_1")
+	result, err := tool.Execute(ctx, json.RawMessage(`{"task":"What is the answer?"}`))
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Contains(t, result.Output, "42")
+	assert.Len(t, result.Steps, 1)
+	assert.Len(t, result.Data["references"], 1)
+
+	require.Len(t, forwarded, 2)
+	assert.Equal(t, "call_1/call_a-tool-call", forwarded[0].ID)
+	assert.Equal(t, "call_1/call_a", forwarded[0].Data.(event.AgentToolCallData).ToolCallID)
+	assert.Equal(t, 1, forwarded[0].Metadata[event.MetadataDepth])
+	assert.Equal(t, "call_1", forwarded[0].Metadata[event.MetadataParentToolCallID])
+	assert.Equal(t, event.EventAgentThought, forwarded[1].Type)
+	assert.Equal(t, "builtin-data-analyst", forwarded[1].Metadata[event.MetadataAgentID])
+}
@@ -0,0 +1,490 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	// documentProcessTaskRetention keeps finished document parsing tasks in the queue,
+	// so failed parses can be retried with their original payload
+	documentProcessTaskRetention = 24 * time.Hour
+	// taskScanPageSize is the page size used when scanning the queues
+	taskScanPageSize = 100
+	// taskScanLimit bounds the number of tasks scanned per queue and state
+	taskScanLimit = 5000
+)
+
+// taskListStates are the task states listed by default
+var taskListStates = []types.TaskState{
+	types.TaskStatePending,
+	types.TaskStateActive,
+	types.TaskStateScheduled,
+	types.TaskStateRetry,
+	types.TaskStateArchived,
+	types.TaskStateCompleted,
+}
+
+// taskAdminService exposes the asynq queues to tenants, restricted to their own tasks
+type taskAdminService struct {
+	inspector     *asynq.Inspector
+	client        *asynq.Client
+	kbService     interfaces.KnowledgeBaseService
+	knowledgeRepo interfaces.KnowledgeRepository
+}
+
+// NewTaskAdminService creates a new task administration service
+func NewTaskAdminService(
+	inspector *asynq.Inspector,
+	client *asynq.Client,
+	kbService interfaces.KnowledgeBaseService,
+	knowledgeRepo interfaces.KnowledgeRepository,
+) interfaces.TaskAdminService {
+	return &taskAdminService{
+		inspector:     inspector,
+		client:        client,
+		kbService:     kbService,
+		knowledgeRepo: knowledgeRepo,
+	}
+}
+
+// ListTasks lists the tasks of the current tenant matching the filter
+func (s *taskAdminService) ListTasks(
+	ctx context.Context, filter *types.TaskListFilter, page *types.Pagination,
+) (*types.PageResult, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if filter == nil {
+		filter = &types.TaskListFilter{}
+	}
+	queues, err := s.queues(filter.Queue)
+	if err != nil {
+		return nil, err
+	}
+	states := taskListStates
+	if filter.State != "" {
+		if !isValidTaskState(filter.State) {
+			return nil, werrors.NewBadRequestError("invalid task state: " + string(filter.State))
+		}
+		states = []types.TaskState{filter.State}
+	}
+
+	tasks := make([]*types.TaskInfo, 0)
+	for _, queue := range queues {
+		for _, state := range states {
+			err := s.scanTasks(queue, state, func(info *asynq.TaskInfo, payload map[string]interface{}) bool {
+				if !payloadMatches(payload, tenantID, filter.KnowledgeBaseID) {
+					return true
+				}
+				if filter.Type != "" && info.Type != filter.Type {
+					return true
+				}
+				tasks = append(tasks, toTaskInfo(info, payload))
+				return true
+			})
+			if err != nil {
+				logger.Errorf(ctx, "Failed to list %s tasks of queue %s: %v", state, queue, err)
+				return nil, err
+			}
+		}
+	}
+
+	// 最近失败或待执行的任务排在前面
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return taskSortTime(tasks[i]).After(taskSortTime(tasks[j]))
+	})
+
+	total := len(tasks)
+	start := (page.GetPage() - 1) * page.GetPageSize()
+	if start > total {
+		start = total
+	}
+	end := start + page.GetPageSize()
+	if end > total {
+		end = total
+	}
+	return types.NewPageResult(int64(total), page, tasks[start:end]), nil
+}
+
+// GetTask gets a task of the current tenant
+func (s *taskAdminService) GetTask(ctx context.Context, queue, taskID string) (*types.TaskInfo, error) {
+	info, payload, err := s.getTenantTask(ctx, queue, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return toTaskInfo(info, payload), nil
+}
+
+// RetryTask runs a failed, scheduled or archived task immediately
+// Completed tasks are enqueued again with the same payload
+func (s *taskAdminService) RetryTask(ctx context.Context, queue, taskID string) error {
+	info, _, err := s.getTenantTask(ctx, queue, taskID)
+	if err != nil {
+		return err
+	}
+
+	switch info.State {
+	case asynq.TaskStateScheduled, asynq.TaskStateRetry, asynq.TaskStateArchived:
+		if err := s.inspector.RunTask(info.Queue, info.ID); err != nil {
+			logger.Errorf(ctx, "Failed to run task %s: %v", info.ID, err)
+			return err
+		}
+	case asynq.TaskStateCompleted:
+		if _, err := s.requeue(info); err != nil {
+			logger.Errorf(ctx, "Failed to re-enqueue task %s: %v", info.ID, err)
+			return err
+		}
+	default:
+		return werrors.NewBadRequestError("task is " + info.State.String() + " and cannot be retried")
+	}
+	logger.Infof(ctx, "Task retried: queue=%s, id=%s, type=%s", info.Queue, info.ID, info.Type)
+	return nil
+}
+
+// CancelTask cancels a running task or deletes a task waiting in the queue
+func (s *taskAdminService) CancelTask(ctx context.Context, queue, taskID string) error {
+	info, _, err := s.getTenantTask(ctx, queue, taskID)
+	if err != nil {
+		return err
+	}
+
+	switch info.State {
+	case asynq.TaskStateActive:
+		err = s.inspector.CancelProcessing(info.ID)
+	case asynq.TaskStateCompleted:
+		return werrors.NewBadRequestError("task is already completed")
+	default:
+		err = s.inspector.DeleteTask(info.Queue, info.ID)
+	}
+	if err != nil {
+		logger.Errorf(ctx, "Failed to cancel task %s: %v", info.ID, err)
+		return err
+	}
+	logger.Infof(ctx, "Task cancelled: queue=%s, id=%s, type=%s, state=%s",
+		info.Queue, info.ID, info.Type, info.State.String())
+	return nil
+}
+
+// RetryFailedDocuments re-runs the failed document parsing tasks of a knowledge base
+// Tasks waiting for retry or archived are run immediately; completed tasks whose knowledge
+// is marked as failed are enqueued again with their original payload
+func (s *taskAdminService) RetryFailedDocuments(ctx context.Context, kbID string) (*types.TaskBulkRetryResult, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		return nil, werrors.NewNotFoundError("knowledge base not found")
+	}
+
+	result := &types.TaskBulkRetryResult{KnowledgeIDs: make([]string, 0)}
+	retried := make(map[string]bool)
+	for _, queue := range types.TaskQueues {
+		for _, state := range []types.TaskState{
+			types.TaskStateRetry, types.TaskStateArchived, types.TaskStateCompleted,
+		} {
+			err := s.scanTasks(queue, state, func(info *asynq.TaskInfo, payload map[string]interface{}) bool {
+				if info.Type != types.TypeDocumentProcess || !payloadMatches(payload, tenantID, kbID) {
+					return true
+				}
+				knowledgeID, _ := payload["knowledge_id"].(string)
+				if knowledgeID == "" || retried[knowledgeID] {
+					return true
+				}
+				knowledge, err := s.knowledgeRepo.GetKnowledgeByID(ctx, tenantID, knowledgeID)
+				if err != nil || knowledge == nil {
+					return true
+				}
+				// 已完成的任务只重试解析失败的知识
+				if state == types.TaskStateCompleted && knowledge.ParseStatus != types.ParseStatusFailed {
+					return true
+				}
+
+				if state == types.TaskStateCompleted {
+					_, err = s.requeue(info)
+				} else {
+					err = s.inspector.RunTask(info.Queue, info.ID)
+				}
+				if err != nil {
+					logger.Warnf(ctx, "Failed to retry document task %s of knowledge %s: %v", info.ID, knowledgeID, err)
+					return true
+				}
+				retried[knowledgeID] = true
+
+				if knowledge.ParseStatus == types.ParseStatusFailed {
+					knowledge.ParseStatus = types.ParseStatusPending
+					knowledge.ErrorMessage = ""
+					knowledge.UpdatedAt = time.Now()
+					if err := s.knowledgeRepo.UpdateKnowledge(ctx, knowledge); err != nil {
+						logger.Warnf(ctx, "Failed to reset parse status of knowledge %s: %v", knowledgeID, err)
+					}
+				}
+				result.Retried++
+				result.KnowledgeIDs = append(result.KnowledgeIDs, knowledgeID)
+				return true
+			})
+			if err != nil {
+				logger.Errorf(ctx, "Failed to scan %s tasks of queue %s: %v", state, queue, err)
+				return nil, err
+			}
+		}
+	}
+
+	logger.Infof(ctx, "Retried %d failed document tasks of knowledge base %s", result.Retried, secutils.SanitizeForLog(kbID))
+	return result, nil
+}
+
+// ListQueues lists the task queues and their statistics
+func (s *taskAdminService) ListQueues(ctx context.Context) ([]*types.TaskQueueInfo, error) {
+	queues := make([]*types.TaskQueueInfo, 0, len(types.TaskQueues))
+	for _, queue := range types.TaskQueues {
+		info, err := s.inspector.GetQueueInfo(queue)
+		if err != nil {
+			if errors.Is(err, asynq.ErrQueueNotFound) {
+				queues = append(queues, &types.TaskQueueInfo{Queue: queue})
+				continue
+			}
+			logger.Errorf(ctx, "Failed to get info of queue %s: %v", queue, err)
+			return nil, err
+		}
+		queues = append(queues, &types.TaskQueueInfo{
+			Queue:     queue,
+			Paused:    info.Paused,
+			Pending:   info.Pending,
+			Active:    info.Active,
+			Scheduled: info.Scheduled,
+			Retry:     info.Retry,
+			Archived:  info.Archived,
+			Completed: info.Completed,
+			Processed: info.Processed,
+			Failed:    info.Failed,
+		})
+	}
+	return queues, nil
+}
+
+// PauseQueue pauses the processing of a queue
+func (s *taskAdminService) PauseQueue(ctx context.Context, queue string) error {
+	if !isKnownQueue(queue) {
+		return werrors.NewNotFoundError("queue not found: " + queue)
+	}
+	if err := s.inspector.PauseQueue(queue); err != nil {
+		logger.Errorf(ctx, "Failed to pause queue %s: %v", queue, err)
+		return err
+	}
+	logger.Infof(ctx, "Queue paused: %s", queue)
+	return nil
+}
+
+// ResumeQueue resumes the processing of a paused queue
+func (s *taskAdminService) ResumeQueue(ctx context.Context, queue string) error {
+	if !isKnownQueue(queue) {
+		return werrors.NewNotFoundError("queue not found: " + queue)
+	}
+	if err := s.inspector.UnpauseQueue(queue); err != nil {
+		logger.Errorf(ctx, "Failed to resume queue %s: %v", queue, err)
+		return err
+	}
+	logger.Infof(ctx, "Queue resumed: %s", queue)
+	return nil
+}
+
+// queues returns the queues to scan, validating the requested one
+func (s *taskAdminService) queues(queue string) ([]string, error) {
+	if queue == "" {
+		return types.TaskQueues, nil
+	}
+	if !isKnownQueue(queue) {
+		return nil, werrors.NewBadRequestError("unknown queue: " + queue)
+	}
+	return []string{queue}, nil
+}
+
+// getTenantTask gets a task and checks it belongs to the current tenant
+func (s *taskAdminService) getTenantTask(
+	ctx context.Context, queue, taskID string,
+) (*asynq.TaskInfo, map[string]interface{}, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if !isKnownQueue(queue) {
+		return nil, nil, werrors.NewNotFoundError("task not found")
+	}
+	info, err := s.inspector.GetTaskInfo(queue, taskID)
+	if err != nil {
+		if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+			return nil, nil, werrors.NewNotFoundError("task not found")
+		}
+		logger.Errorf(ctx, "Failed to get task %s: %v", secutils.SanitizeForLog(taskID), err)
+		return nil, nil, err
+	}
+	payload := decodeTaskPayload(info.Payload)
+	if !payloadMatches(payload, tenantID, "") {
+		return nil, nil, werrors.NewNotFoundError("task not found")
+	}
+	return info, payload, nil
+}
+
+// scanTasks iterates the tasks of a queue in the given state until fn returns false
+func (s *taskAdminService) scanTasks(
+	queue string, state types.TaskState,
+	fn func(info *asynq.TaskInfo, payload map[string]interface{}) bool,
+) error {
+	for page, scanned := 1, 0; scanned < taskScanLimit; page++ {
+		opts := []asynq.ListOption{asynq.Page(page), asynq.PageSize(taskScanPageSize)}
+		var (
+			infos []*asynq.TaskInfo
+			err   error
+		)
+		switch state {
+		case types.TaskStatePending:
+			infos, err = s.inspector.ListPendingTasks(queue, opts...)
+		case types.TaskStateActive:
+			infos, err = s.inspector.ListActiveTasks(queue, opts...)
+		case types.TaskStateScheduled:
+			infos, err = s.inspector.ListScheduledTasks(queue, opts...)
+		case types.TaskStateRetry:
+			infos, err = s.inspector.ListRetryTasks(queue, opts...)
+		case types.TaskStateArchived:
+			infos, err = s.inspector.ListArchivedTasks(queue, opts...)
+		case types.TaskStateCompleted:
+			infos, err = s.inspector.ListCompletedTasks(queue, opts...)
+		}
+		if err != nil {
+			if errors.Is(err, asynq.ErrQueueNotFound) {
+				return nil
+			}
+			return err
+		}
+		for _, info := range infos {
+			if !fn(info, decodeTaskPayload(info.Payload)) {
+				return nil
+			}
+		}
+		scanned += len(infos)
+		if len(infos) < taskScanPageSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// requeue enqueues a copy of a finished task
+func (s *taskAdminService) requeue(info *asynq.TaskInfo) (*asynq.TaskInfo, error) {
+	opts := []asynq.Option{asynq.Queue(info.Queue), asynq.MaxRetry(info.MaxRetry)}
+	if info.Timeout > 0 {
+		opts = append(opts, asynq.Timeout(info.Timeout))
+	}
+	if info.Retention > 0 {
+		opts = append(opts, asynq.Retention(info.Retention))
+	}
+	return s.client.Enqueue(asynq.NewTask(info.Type, info.Payload, opts...))
+}
+
+// decodeTaskPayload decodes the JSON payload of a task, numbers are kept as json.Number
+func decodeTaskPayload(data []byte) map[string]interface{} {
+	payload := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return map[string]interface{}{}
+	}
+	return payload
+}
+
+// payloadMatches checks the tenant (and optionally the knowledge base) of a task payload
+func payloadMatches(payload map[string]interface{}, tenantID uint64, kbID string) bool {
+	number, ok := payload["tenant_id"].(json.Number)
+	if !ok || number.String() != jsonUint(tenantID) {
+		return false
+	}
+	if kbID == "" {
+		return true
+	}
+	for _, key := range []string{"knowledge_base_id", "kb_id", "source_id"} {
+		if value, _ := payload[key].(string); value == kbID {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonUint(value uint64) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// summarizePayload keeps the scalar fields of a payload and replaces lists by their length
+func summarizePayload(payload map[string]interface{}) map[string]interface{} {
+	summary := make(map[string]interface{}, len(payload))
+	for key, value := range payload {
+		switch v := value.(type) {
+		case []interface{}:
+			summary[key+"_count"] = len(v)
+		case map[string]interface{}:
+			// 嵌套对象不返回
+		default:
+			summary[key] = v
+		}
+	}
+	return summary
+}
+
+func toTaskInfo(info *asynq.TaskInfo, payload map[string]interface{}) *types.TaskInfo {
+	task := &types.TaskInfo{
+		ID:        info.ID,
+		Queue:     info.Queue,
+		Type:      info.Type,
+		State:     types.TaskState(info.State.String()),
+		Payload:   summarizePayload(payload),
+		MaxRetry:  info.MaxRetry,
+		Retried:   info.Retried,
+		LastError: info.LastErr,
+	}
+	if !info.LastFailedAt.IsZero() {
+		task.LastFailedAt = &info.LastFailedAt
+	}
+	if !info.NextProcessAt.IsZero() {
+		task.NextProcessAt = &info.NextProcessAt
+	}
+	if !info.CompletedAt.IsZero() {
+		task.CompletedAt = &info.CompletedAt
+	}
+	return task
+}
+
+func taskSortTime(task *types.TaskInfo) time.Time {
+	switch {
+	case task.LastFailedAt != nil:
+		return *task.LastFailedAt
+	case task.CompletedAt != nil:
+		return *task.CompletedAt
+	case task.NextProcessAt != nil:
+		return *task.NextProcessAt
+	}
+	return time.Time{}
+}
+
+func isKnownQueue(queue string) bool {
+	for _, known := range types.TaskQueues {
+		if known == queue {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidTaskState(state types.TaskState) bool {
+	for _, known := range taskListStates {
+		if known == state {
+			return true
+		}
+	}
+	return false
+}
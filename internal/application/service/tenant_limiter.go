@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// tenantLimitsCacheTTL bounds how long updated tenant limits take to apply on other instances
+	tenantLimitsCacheTTL = time.Minute
+	// rateLimitWindow is the fixed window of the model call limits
+	rateLimitWindow = time.Minute
+)
+
+// acquireTaskSlotScript drops expired slots, then adds the task to the tenant's
+// running set unless the set is full. Scores are slot expiry times in unix ms.
+// KEYS[1]: running set; ARGV: now, limit, task ID, expires at, key TTL (ms)
+var acquireTaskSlotScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if redis.call('ZSCORE', KEYS[1], ARGV[3]) then
+  redis.call('ZADD', KEYS[1], ARGV[4], ARGV[3])
+  return 1
+end
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[2]) then
+  return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[4], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+// consumeRateScript adds n calls to the current window unless that exceeds the limit.
+// An empty window always accepts, so a batch larger than the limit cannot block forever.
+// KEYS[1]: window counter; ARGV: n, limit, window TTL (ms)
+var consumeRateScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if current > 0 and current + tonumber(ARGV[1]) > tonumber(ARGV[2]) then
+  return 0
+end
+redis.call('INCRBY', KEYS[1], ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+type cachedTenantLimits struct {
+	limits    *types.EffectiveRateLimits
+	expiresAt time.Time
+}
+
+// tenantLimiter implements the TenantLimiter interface on top of Redis so that
+// the limits are shared by all server and worker instances
+type tenantLimiter struct {
+	redisClient *redis.Client
+	tenantRepo  interfaces.TenantRepository
+	config      *config.Config
+	cache       sync.Map // tenantID -> *cachedTenantLimits
+}
+
+// NewTenantLimiter creates a new tenant limiter
+func NewTenantLimiter(
+	redisClient *redis.Client,
+	tenantRepo interfaces.TenantRepository,
+	config *config.Config,
+) interfaces.TenantLimiter {
+	return &tenantLimiter{
+		redisClient: redisClient,
+		tenantRepo:  tenantRepo,
+		config:      config,
+	}
+}
+
+// Limits returns the tenant's overrides merged with the configured defaults
+func (l *tenantLimiter) Limits(ctx context.Context, tenantID uint64) *types.EffectiveRateLimits {
+	if cached, ok := l.cache.Load(tenantID); ok {
+		entry := cached.(*cachedTenantLimits)
+		if time.Now().Before(entry.expiresAt) {
+			return entry.limits
+		}
+	}
+
+	limits := &types.EffectiveRateLimits{}
+	if cfg := l.config.TaskScheduling; cfg != nil {
+		limits.MaxConcurrentTasks = cfg.MaxConcurrentTasks
+		limits.EmbeddingCallsPerMinute = cfg.EmbeddingCallsPerMinute
+		limits.VLMCallsPerMinute = cfg.VLMCallsPerMinute
+	}
+	tenant, err := l.tenantRepo.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		// 获取失败时使用默认限制，且不缓存，避免长时间沿用错误结果
+		logger.Warnf(ctx, "Failed to load rate limits of tenant %d, using defaults: %v", tenantID, err)
+		return limits
+	}
+	if overrides := tenant.RateLimits; overrides != nil {
+		if overrides.MaxConcurrentTasks != nil {
+			limits.MaxConcurrentTasks = *overrides.MaxConcurrentTasks
+		}
+		if overrides.EmbeddingCallsPerMinute != nil {
+			limits.EmbeddingCallsPerMinute = *overrides.EmbeddingCallsPerMinute
+		}
+		if overrides.VLMCallsPerMinute != nil {
+			limits.VLMCallsPerMinute = *overrides.VLMCallsPerMinute
+		}
+	}
+	l.cache.Store(tenantID, &cachedTenantLimits{limits: limits, expiresAt: time.Now().Add(tenantLimitsCacheTTL)})
+	return limits
+}
+
+// InvalidateLimits drops the cached limits of a tenant
+func (l *tenantLimiter) InvalidateLimits(tenantID uint64) {
+	l.cache.Delete(tenantID)
+}
+
+// Usage returns the running tasks and the model calls of the current minute of a tenant
+func (l *tenantLimiter) Usage(ctx context.Context, tenantID uint64) (*types.TenantRateLimitUsage, error) {
+	key := taskSlotsKey(tenantID)
+	if err := l.redisClient.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprint(time.Now().UnixMilli())).Err(); err != nil {
+		return nil, err
+	}
+	running, err := l.redisClient.ZCard(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	embeddingCalls, err := l.windowCount(ctx, tenantID, types.RateLimitEmbedding)
+	if err != nil {
+		return nil, err
+	}
+	vlmCalls, err := l.windowCount(ctx, tenantID, types.RateLimitVLM)
+	if err != nil {
+		return nil, err
+	}
+	return &types.TenantRateLimitUsage{
+		RunningTasks:   int(running),
+		EmbeddingCalls: embeddingCalls,
+		VLMCalls:       vlmCalls,
+	}, nil
+}
+
+// AcquireTaskSlot reserves a concurrency slot for a task
+func (l *tenantLimiter) AcquireTaskSlot(
+	ctx context.Context, tenantID uint64, taskID string, expiresAt time.Time,
+) (bool, error) {
+	limit := l.Limits(ctx, tenantID).MaxConcurrentTasks
+	if limit <= 0 {
+		return true, nil
+	}
+	now := time.Now()
+	keyTTL := expiresAt.Sub(now)
+	if keyTTL < time.Minute {
+		keyTTL = time.Minute
+	}
+	acquired, err := acquireTaskSlotScript.Run(ctx, l.redisClient, []string{taskSlotsKey(tenantID)},
+		now.UnixMilli(), limit, taskID, expiresAt.UnixMilli(), keyTTL.Milliseconds(),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return acquired == 1, nil
+}
+
+// ReleaseTaskSlot releases the concurrency slot held by a task
+func (l *tenantLimiter) ReleaseTaskSlot(ctx context.Context, tenantID uint64, taskID string) {
+	// 任务上下文可能已取消，使用独立的上下文释放
+	if err := l.redisClient.ZRem(context.WithoutCancel(ctx), taskSlotsKey(tenantID), taskID).Err(); err != nil {
+		logger.Warnf(ctx, "Failed to release task slot of tenant %d, task %s: %v", tenantID, taskID, err)
+	}
+}
+
+// Wait blocks until the tenant may make n calls of the given kind and records them
+func (l *tenantLimiter) Wait(ctx context.Context, tenantID uint64, kind types.RateLimitKind, n int) error {
+	limit := l.limitOf(ctx, tenantID, kind)
+	if limit <= 0 || n <= 0 {
+		return nil
+	}
+	logged := false
+	for {
+		now := time.Now()
+		ok, err := consumeRateScript.Run(ctx, l.redisClient, []string{rateWindowKey(tenantID, kind, now)},
+			n, limit, (2 * rateLimitWindow).Milliseconds(),
+		).Int()
+		if err != nil {
+			// Redis 不可用时放行，限流不应阻断业务
+			logger.Warnf(ctx, "Failed to check %s rate limit of tenant %d: %v", kind, tenantID, err)
+			return nil
+		}
+		if ok == 1 {
+			return nil
+		}
+		if !logged {
+			logger.Infof(ctx, "Tenant %d reached %s limit of %d calls per minute, waiting", tenantID, kind, limit)
+			logged = true
+		}
+		// 等到下一个时间窗口，加入随机抖动避免同时唤醒
+		wait := now.Truncate(rateLimitWindow).Add(rateLimitWindow).Sub(now) +
+			time.Duration(rand.Int63n(int64(time.Second)))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Remaining returns the calls of the given kind left in the current minute
+func (l *tenantLimiter) Remaining(ctx context.Context, tenantID uint64, kind types.RateLimitKind) int {
+	limit := l.limitOf(ctx, tenantID, kind)
+	if limit <= 0 {
+		return -1
+	}
+	used, err := l.windowCount(ctx, tenantID, kind)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to read %s rate limit usage of tenant %d: %v", kind, tenantID, err)
+		return -1
+	}
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
+
+// Record counts n calls of the given kind that were made outside of Wait
+func (l *tenantLimiter) Record(ctx context.Context, tenantID uint64, kind types.RateLimitKind, n int) {
+	if n <= 0 {
+		return
+	}
+	key := rateWindowKey(tenantID, kind, time.Now())
+	pipe := l.redisClient.TxPipeline()
+	pipe.IncrBy(ctx, key, int64(n))
+	pipe.PExpire(ctx, key, 2*rateLimitWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warnf(ctx, "Failed to record %d %s calls of tenant %d: %v", n, kind, tenantID, err)
+	}
+}
+
+func (l *tenantLimiter) limitOf(ctx context.Context, tenantID uint64, kind types.RateLimitKind) int {
+	limits := l.Limits(ctx, tenantID)
+	switch kind {
+	case types.RateLimitEmbedding:
+		return limits.EmbeddingCallsPerMinute
+	case types.RateLimitVLM:
+		return limits.VLMCallsPerMinute
+	default:
+		return 0
+	}
+}
+
+func (l *tenantLimiter) windowCount(ctx context.Context, tenantID uint64, kind types.RateLimitKind) (int, error) {
+	count, err := l.redisClient.Get(ctx, rateWindowKey(tenantID, kind, time.Now())).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+// rateLimitedEmbedder waits for the tenant's embedding budget before every ingestion call.
+// Calls outside ingestion tasks, such as query embeddings of retrieval, are not limited.
+type rateLimitedEmbedder struct {
+	embedding.Embedder
+	limiter  interfaces.TenantLimiter
+	tenantID uint64
+}
+
+// withEmbeddingRateLimit binds the embedder to the embedding limit of a tenant
+func withEmbeddingRateLimit(embedder embedding.Embedder, limiter interfaces.TenantLimiter, tenantID uint64) embedding.Embedder {
+	if limiter == nil || tenantID == 0 {
+		return embedder
+	}
+	return &rateLimitedEmbedder{Embedder: embedder, limiter: limiter, tenantID: tenantID}
+}
+
+// Embed converts text to vector once the tenant is within its limit
+func (e *rateLimitedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := e.wait(ctx); err != nil {
+		return nil, err
+	}
+	return e.Embedder.Embed(ctx, text)
+}
+
+// BatchEmbed converts multiple texts to vectors once the tenant is within its limit
+func (e *rateLimitedEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := e.wait(ctx); err != nil {
+		return nil, err
+	}
+	return e.Embedder.BatchEmbed(ctx, texts)
+}
+
+// wait blocks until the tenant is within its embedding limit, only for ingestion calls
+func (e *rateLimitedEmbedder) wait(ctx context.Context) error {
+	if ingestion, _ := ctx.Value(types.IngestionContextKey).(bool); !ingestion {
+		return nil
+	}
+	return e.limiter.Wait(ctx, e.tenantID, types.RateLimitEmbedding, 1)
+}
+
+func taskSlotsKey(tenantID uint64) string {
+	return fmt.Sprintf("tenant_tasks:%d", tenantID)
+}
+
+func rateWindowKey(tenantID uint64, kind types.RateLimitKind, now time.Time) string {
+	return fmt.Sprintf("tenant_rate:%d:%s:%d", tenantID, kind, now.Unix()/int64(rateLimitWindow/time.Second))
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingLimiter counts the calls waiting for a limit
+type countingLimiter struct {
+	interfaces.TenantLimiter
+	waits int
+}
+
+func (l *countingLimiter) Wait(context.Context, uint64, types.RateLimitKind, int) error {
+	l.waits++
+	return nil
+}
+
+type fixedEmbedder struct {
+	embedding.Embedder
+}
+
+func (fixedEmbedder) Embed(context.Context, string) ([]float32, error) {
+	return []float32{1}, nil
+}
+
+func (fixedEmbedder) BatchEmbed(_ context.Context, texts []string) ([][]float32, error) {
+	return make([][]float32, len(texts)), nil
+}
+
+func TestRateLimitedEmbedderOnlyLimitsIngestion(t *testing.T) {
+	limiter := &countingLimiter{}
+	embedder := withEmbeddingRateLimit(fixedEmbedder{}, limiter, 1)
+
+	// 检索与对话中的调用不等待限流
+	_, err := embedder.Embed(context.Background(), "query")
+	require.NoError(t, err)
+	assert.Equal(t, 0, limiter.waits)
+
+	ingestion := context.WithValue(context.Background(), types.IngestionContextKey, true)
+	_, err = embedder.Embed(ingestion, "chunk")
+	require.NoError(t, err)
+	_, err = embedder.BatchEmbed(ingestion, []string{"chunk 1", "chunk 2"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, limiter.waits)
+}
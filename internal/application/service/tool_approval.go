@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+const (
+	// toolApprovalPollInterval is how often a waiting agent re-reads its approval,
+	// so that decisions made through another server instance are seen
+	toolApprovalPollInterval = time.Second
+	// toolApprovalReasonMaxLength bounds the reason given with a decision
+	toolApprovalReasonMaxLength = 1000
+)
+
+// toolApprovalService implements the ToolApprovalService interface
+type toolApprovalService struct {
+	repo        interfaces.ToolApprovalRepository
+	sessionRepo interfaces.SessionRepository
+
+	// 本实例上等待决定的智能体，决定后立即唤醒而无需等待下一次轮询
+	mu      sync.Mutex
+	waiters map[string]chan struct{}
+}
+
+// NewToolApprovalService creates a new tool approval service
+func NewToolApprovalService(
+	repo interfaces.ToolApprovalRepository,
+	sessionRepo interfaces.SessionRepository,
+) interfaces.ToolApprovalService {
+	return &toolApprovalService{
+		repo:        repo,
+		sessionRepo: sessionRepo,
+		waiters:     make(map[string]chan struct{}),
+	}
+}
+
+// RequestApproval persists a pending approval of a tool call
+func (s *toolApprovalService) RequestApproval(
+	ctx context.Context, approval *types.ToolApproval, timeout time.Duration,
+) error {
+	now := time.Now()
+	approval.Status = types.ToolApprovalPending
+	approval.CreatedAt = now
+	approval.ExpiresAt = now.Add(timeout)
+	if err := s.repo.CreateApproval(ctx, approval); err != nil {
+		return fmt.Errorf("failed to save tool approval: %w", err)
+	}
+	logger.Infof(ctx, "Tool call %s of session %s is waiting for approval %s",
+		approval.ToolName, approval.SessionID, approval.ID)
+	return nil
+}
+
+// WaitForDecision blocks until the approval is decided or expires, or ctx is done
+func (s *toolApprovalService) WaitForDecision(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.ToolApproval, error) {
+	notify := s.subscribe(id)
+	defer s.unsubscribe(id)
+
+	ticker := time.NewTicker(toolApprovalPollInterval)
+	defer ticker.Stop()
+
+	for {
+		approval, err := s.repo.GetApproval(ctx, tenantID, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return s.expire(context.WithoutCancel(ctx), tenantID, id,
+					"The agent was stopped before the user made a decision")
+			}
+			return nil, err
+		}
+		if approval == nil {
+			return nil, werrors.NewNotFoundError("Tool approval not found")
+		}
+		if approval.Status != types.ToolApprovalPending {
+			return approval, nil
+		}
+
+		remaining := time.Until(approval.ExpiresAt)
+		if remaining <= 0 {
+			return s.expire(ctx, tenantID, id, "The user did not make a decision in time")
+		}
+		timer := time.NewTimer(remaining)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return s.expire(context.WithoutCancel(ctx), tenantID, id,
+				"The agent was stopped before the user made a decision")
+		case <-notify:
+		case <-ticker.C:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// ListApprovals lists the approvals of a session of the current tenant
+func (s *toolApprovalService) ListApprovals(
+	ctx context.Context, sessionID string, status types.ToolApprovalStatus,
+) ([]*types.ToolApproval, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if _, err := s.sessionRepo.Get(ctx, tenantID, sessionID); err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, werrors.NewNotFoundError("Session not found")
+		}
+		return nil, err
+	}
+
+	approvals, err := s.repo.ListApprovals(ctx, tenantID, sessionID, status)
+	if err != nil {
+		return nil, err
+	}
+	// 智能体可能已退出（如服务重启），过期的待处理审批在此补记为过期
+	result := make([]*types.ToolApproval, 0, len(approvals))
+	for _, approval := range approvals {
+		if approval.Status == types.ToolApprovalPending && time.Now().After(approval.ExpiresAt) {
+			expired, err := s.expire(ctx, tenantID, approval.ID, "The user did not make a decision in time")
+			if err != nil {
+				logger.Warnf(ctx, "Failed to expire tool approval %s: %v", approval.ID, err)
+			} else {
+				approval = expired
+			}
+		}
+		if status == "" || approval.Status == status {
+			result = append(result, approval)
+		}
+	}
+	return result, nil
+}
+
+// DecideApproval approves or rejects a pending approval of a session of the current tenant
+func (s *toolApprovalService) DecideApproval(
+	ctx context.Context, sessionID string, id string, approved bool, reason string,
+) (*types.ToolApproval, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > toolApprovalReasonMaxLength {
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("Reason must be at most %d characters", toolApprovalReasonMaxLength))
+	}
+
+	approval, err := s.repo.GetApproval(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if approval == nil || approval.SessionID != sessionID {
+		return nil, werrors.NewNotFoundError("Tool approval not found")
+	}
+	if approval.Status == types.ToolApprovalPending && time.Now().After(approval.ExpiresAt) {
+		if approval, err = s.expire(ctx, tenantID, id, "The user did not make a decision in time"); err != nil {
+			return nil, err
+		}
+	}
+	if approval.Status != types.ToolApprovalPending {
+		return nil, werrors.NewConflictError("Tool approval is already " + string(approval.Status))
+	}
+
+	now := time.Now()
+	approval.Status = types.ToolApprovalRejected
+	if approved {
+		approval.Status = types.ToolApprovalApproved
+	}
+	approval.Reason = reason
+	approval.DecidedBy, _ = ctx.Value(types.UserIDContextKey).(string)
+	approval.DecidedAt = &now
+	ok, err := s.repo.ResolveApproval(ctx, approval)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, werrors.NewConflictError("Tool approval has already been decided")
+	}
+	s.notify(id)
+
+	logger.Infof(ctx, "Tool approval %s of session %s %s", id, sessionID, approval.Status)
+	return approval, nil
+}
+
+// expire marks an approval still pending as expired and returns its final state,
+// which is the decision of the user if one was made in the meantime
+func (s *toolApprovalService) expire(
+	ctx context.Context, tenantID uint64, id string, reason string,
+) (*types.ToolApproval, error) {
+	now := time.Now()
+	if _, err := s.repo.ResolveApproval(ctx, &types.ToolApproval{
+		ID:        id,
+		TenantID:  tenantID,
+		Status:    types.ToolApprovalExpired,
+		Reason:    reason,
+		DecidedAt: &now,
+	}); err != nil {
+		return nil, err
+	}
+	approval, err := s.repo.GetApproval(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if approval == nil {
+		return nil, werrors.NewNotFoundError("Tool approval not found")
+	}
+	return approval, nil
+}
+
+func (s *toolApprovalService) subscribe(id string) <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	s.waiters[id] = ch
+	return ch
+}
+
+func (s *toolApprovalService) unsubscribe(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.waiters, id)
+}
+
+func (s *toolApprovalService) notify(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ch, ok := s.waiters[id]; ok {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
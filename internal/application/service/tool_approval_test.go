@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryToolApprovalRepository keeps tool approvals in memory
+type memoryToolApprovalRepository struct {
+	mu        sync.Mutex
+	approvals map[string]types.ToolApproval
+}
+
+func (r *memoryToolApprovalRepository) CreateApproval(ctx context.Context, approval *types.ToolApproval) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.approvals[approval.ID] = *approval
+	return nil
+}
+
+func (r *memoryToolApprovalRepository) GetApproval(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.ToolApproval, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	approval, ok := r.approvals[id]
+	if !ok || approval.TenantID != tenantID {
+		return nil, nil
+	}
+	return &approval, nil
+}
+
+func (r *memoryToolApprovalRepository) ListApprovals(
+	ctx context.Context, tenantID uint64, sessionID string, status types.ToolApprovalStatus,
+) ([]*types.ToolApproval, error) {
+	return nil, nil
+}
+
+func (r *memoryToolApprovalRepository) ResolveApproval(ctx context.Context, approval *types.ToolApproval) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.approvals[approval.ID]
+	if !ok || stored.TenantID != approval.TenantID || stored.Status != types.ToolApprovalPending {
+		return false, nil
+	}
+	stored.Status, stored.Reason = approval.Status, approval.Reason
+	stored.DecidedBy, stored.DecidedAt = approval.DecidedBy, approval.DecidedAt
+	r.approvals[approval.ID] = stored
+	return true, nil
+}
+
+func newTestToolApprovalService() *toolApprovalService {
+	repo := &memoryToolApprovalRepository{approvals: make(map[string]types.ToolApproval)}
+	return NewToolApprovalService(repo, nil).(*toolApprovalService)
+}
+
+func TestToolApprovalPolicyModeFor(t *testing.T) {
+	policy := &types.ToolApprovalPolicy{
+		Default: types.ToolApprovalAuto,
+		Tools: map[string]types.ToolApprovalMode{
+			"add_knowledge_to_kb": types.ToolApprovalConfirm,
+			"mcp.*":               types.ToolApprovalConfirm,
+			"mcp.github.*":        types.ToolApprovalDeny,
+			"mcp.github.search":   types.ToolApprovalAuto,
+		},
+	}
+
+	assert.Equal(t, types.ToolApprovalConfirm, policy.ModeFor("add_knowledge_to_kb"))
+	assert.Equal(t, types.ToolApprovalConfirm, policy.ModeFor("mcp.jira.create_issue"))
+	assert.Equal(t, types.ToolApprovalDeny, policy.ModeFor("mcp.github.delete_repo"))
+	assert.Equal(t, types.ToolApprovalAuto, policy.ModeFor("mcp.github.search"))
+	assert.Equal(t, types.ToolApprovalAuto, policy.ModeFor("knowledge_search"))
+
+	var none *types.ToolApprovalPolicy
+	assert.Equal(t, types.ToolApprovalAuto, none.ModeFor("add_knowledge_to_kb"))
+	assert.Equal(t, types.DefaultToolApprovalTimeout, none.Timeout())
+	assert.NoError(t, none.Validate())
+
+	assert.Error(t, (&types.ToolApprovalPolicy{Default: "ask"}).Validate())
+	assert.Error(t, (&types.ToolApprovalPolicy{TimeoutSeconds: 7200}).Validate())
+}
+
+func TestToolApprovalDecisionWakesWaiter(t *testing.T) {
+	s := newTestToolApprovalService()
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+
+	approval := &types.ToolApproval{ID: "a1", TenantID: 1, SessionID: "s1", ToolName: "add_knowledge_to_kb"}
+	require.NoError(t, s.RequestApproval(ctx, approval, time.Minute))
+
+	done := make(chan *types.ToolApproval)
+	go func() {
+		decided, err := s.WaitForDecision(ctx, 1, "a1")
+		assert.NoError(t, err)
+		done <- decided
+	}()
+
+	// 等待的智能体订阅后再作出决定
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.waiters["a1"] != nil
+	}, time.Second, 10*time.Millisecond)
+
+	_, err := s.DecideApproval(ctx, "other-session", "a1", false, "")
+	var appErr *werrors.AppError
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, werrors.ErrNotFound, appErr.Code)
+	}
+
+	decided, err := s.DecideApproval(ctx, "s1", "a1", false, "  wrong knowledge base ")
+	require.NoError(t, err)
+	assert.Equal(t, types.ToolApprovalRejected, decided.Status)
+
+	select {
+	case decided := <-done:
+		assert.Equal(t, types.ToolApprovalRejected, decided.Status)
+		assert.Equal(t, "wrong knowledge base", decided.Reason)
+	case <-time.After(toolApprovalPollInterval / 2):
+		t.Fatal("waiter was not woken by the decision")
+	}
+
+	_, err = s.DecideApproval(ctx, "s1", "a1", true, "")
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, werrors.ErrConflict, appErr.Code)
+	}
+}
+
+func TestToolApprovalExpiresWhenAgentStops(t *testing.T) {
+	s := newTestToolApprovalService()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	approval := &types.ToolApproval{ID: "a2", TenantID: 1, SessionID: "s1", ToolName: "mcp.jira.create_issue"}
+	require.NoError(t, s.RequestApproval(ctx, approval, time.Minute))
+
+	cancel()
+	decided, err := s.WaitForDecision(ctx, 1, "a2")
+	require.NoError(t, err)
+	assert.Equal(t, types.ToolApprovalExpired, decided.Status)
+	assert.NotEmpty(t, decided.Reason)
+}
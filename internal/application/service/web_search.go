@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/application/service/web_search"
@@ -17,8 +19,13 @@ import (
 
 // WebSearchService provides web search functionality
 type WebSearchService struct {
-	providers map[string]interfaces.WebSearchProvider
-	config    *config.WebSearchConfig
+	providers       map[string]interfaces.WebSearchProvider
+	providerConfigs map[string]config.WebSearchProviderConfig
+	config          *config.WebSearchConfig
+
+	// cooldowns records rate-limited providers (keyed by provider ID and API key) until they may be retried
+	cooldownMu sync.Mutex
+	cooldowns  map[string]time.Time
 }
 
 // CompressWithRAG performs RAG-based compression using a temporary, hidden knowledge base.
@@ -243,6 +250,7 @@ func stripMarker(content string) string {
 }
 
 // Search performs web search using the specified provider
+// When the provider errors or is rate limited, the fallback providers are tried in order
 // This method implements the interface expected by PluginSearch
 func (s *WebSearchService) Search(
 	ctx context.Context,
@@ -253,36 +261,126 @@ func (s *WebSearchService) Search(
 		return nil, fmt.Errorf("web search config is required")
 	}
 
-	provider, ok := s.providers[config.Provider]
-	if !ok {
-		return nil, fmt.Errorf("web search provider %s is not available", config.Provider)
-	}
-
-	// Set timeout
+	// Set timeout for each provider attempt
 	timeout := time.Duration(s.config.Timeout) * time.Second
 	if timeout == 0 {
 		timeout = 10 * time.Second
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+	var errs []error
+	for _, providerID := range s.providerChain(config) {
+		provider, err := s.providerFor(providerID, config)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		cooldownKey := providerID + "|" + config.ProviderCredential(providerID).APIKey
+		if until, limited := s.cooldownUntil(cooldownKey); limited {
+			logger.Infof(ctx, "Web search provider %s is rate limited until %s, skipping",
+				providerID, until.Format(time.RFC3339))
+			errs = append(errs, fmt.Errorf("%s: %w", providerID, web_search.ErrRateLimited))
+			continue
+		}
 
-	// Perform search
-	results, err := provider.Search(ctx, query, config.MaxResults, config.IncludeDate)
-	if err != nil {
-		return nil, fmt.Errorf("web search failed: %w", err)
+		searchCtx, cancel := context.WithTimeout(ctx, timeout)
+		results, err := provider.Search(searchCtx, query, config.MaxResults, config.IncludeDate)
+		cancel()
+		if err != nil {
+			var rateLimitErr *web_search.RateLimitError
+			if errors.As(err, &rateLimitErr) {
+				s.setCooldown(cooldownKey, rateLimitErr.RetryAfter)
+			}
+			logger.Warnf(ctx, "Web search provider %s failed, trying next provider: %v", providerID, err)
+			errs = append(errs, err)
+			continue
+		}
+
+		results = web_search.NormalizeResults(results, providerID, config.MaxResults, config.IncludeDate)
+
+		// Apply blacklist filtering
+		results = s.filterBlacklist(results, config.Blacklist)
+		if len(errs) > 0 {
+			logger.Infof(ctx, "Web search fell back to provider %s after %d failures", providerID, len(errs))
+		}
+
+		// Compression is handled later in the integration layer
+		return results, nil
 	}
 
-	// Apply blacklist filtering
-	results = s.filterBlacklist(results, config.Blacklist)
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("web search provider %s is not available", config.Provider)
+	}
+	return nil, fmt.Errorf("web search failed: %w", errors.Join(errs...))
+}
 
-	// Apply compression if needed
-	if config.CompressionMethod != "none" && config.CompressionMethod != "" {
-		// Compression will be handled later in the integration layer
-		// For now, we just return the results
+// providerChain returns the primary provider followed by the fallback providers, without duplicates
+func (s *WebSearchService) providerChain(config *types.WebSearchConfig) []string {
+	fallbacks := config.FallbackProviders
+	if len(fallbacks) == 0 {
+		fallbacks = s.config.Default.FallbackProviders
+	}
+	chain := make([]string, 0, len(fallbacks)+1)
+	seen := make(map[string]bool, len(fallbacks)+1)
+	for _, id := range append([]string{config.Provider}, fallbacks...) {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		chain = append(chain, id)
+	}
+	return chain
+}
+
+// providerFor returns the provider to use for the tenant config
+// Tenant credentials create a dedicated provider instance, otherwise the shared one is used
+func (s *WebSearchService) providerFor(
+	providerID string,
+	config *types.WebSearchConfig,
+) (interfaces.WebSearchProvider, error) {
+	providerConfig, ok := s.providerConfigs[providerID]
+	if !ok {
+		return nil, fmt.Errorf("web search provider %s is not available", providerID)
+	}
+
+	credential := config.ProviderCredential(providerID)
+	if credential.APIKey == "" && credential.EngineID == "" {
+		provider, ok := s.providers[providerID]
+		if !ok {
+			return nil, fmt.Errorf("web search provider %s is not available", providerID)
+		}
+		return provider, nil
 	}
 
-	return results, nil
+	if credential.APIKey != "" {
+		providerConfig.APIKey = credential.APIKey
+	}
+	if credential.EngineID != "" {
+		providerConfig.EngineID = credential.EngineID
+	}
+	return web_search.NewProvider(providerConfig)
+}
+
+// cooldownUntil reports whether the provider is still rate limited
+func (s *WebSearchService) cooldownUntil(key string) (time.Time, bool) {
+	s.cooldownMu.Lock()
+	defer s.cooldownMu.Unlock()
+	until, ok := s.cooldowns[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	if time.Now().After(until) {
+		delete(s.cooldowns, key)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// setCooldown marks the provider as rate limited for the given duration
+func (s *WebSearchService) setCooldown(key string, duration time.Duration) {
+	s.cooldownMu.Lock()
+	defer s.cooldownMu.Unlock()
+	s.cooldowns[key] = time.Now().Add(duration)
 }
 
 // NewWebSearchService creates a new web search service
@@ -292,25 +390,22 @@ func NewWebSearchService(cfg *config.Config) (interfaces.WebSearchService, error
 	}
 
 	service := &WebSearchService{
-		providers: make(map[string]interfaces.WebSearchProvider),
-		config:    cfg.WebSearch,
+		providers:       make(map[string]interfaces.WebSearchProvider),
+		providerConfigs: make(map[string]config.WebSearchProviderConfig),
+		config:          cfg.WebSearch,
+		cooldowns:       make(map[string]time.Time),
 	}
 
 	// Initialize providers based on config
 	for _, providerConfig := range cfg.WebSearch.Providers {
-		var provider interfaces.WebSearchProvider
-		var err error
-
-		switch providerConfig.ID {
-		case "duckduckgo":
-			provider, err = web_search.NewDuckDuckGoProvider(providerConfig)
-		default:
-			return nil, fmt.Errorf("unknown web search provider: %s", providerConfig.ID)
-		}
+		provider, err := web_search.NewProvider(providerConfig)
 		if err != nil {
-			return nil, fmt.Errorf("failed to initialize provider %s: %v", providerConfig.ID, err)
+			// A misconfigured optional provider (e.g. missing api_url) must not prevent startup
+			logger.Warnf(context.Background(), "Skip web search provider %s: %v", providerConfig.ID, err)
+			continue
 		}
 		service.providers[providerConfig.ID] = provider
+		service.providerConfigs[providerConfig.ID] = providerConfig
 		logger.Infof(context.Background(), "Initialized web search provider: %s", providerConfig.ID)
 	}
 
@@ -0,0 +1,98 @@
+package web_search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const defaultBingAPIURL = "https://api.bing.microsoft.com/v7.0/search"
+
+// BingProvider implements web search using the Bing Web Search API
+type BingProvider struct {
+	name   string
+	apiURL string
+	apiKey string
+	client *http.Client
+}
+
+// NewBingProvider creates a new Bing provider
+func NewBingProvider(cfg config.WebSearchProviderConfig) (interfaces.WebSearchProvider, error) {
+	apiURL := cfg.APIURL
+	if apiURL == "" {
+		apiURL = defaultBingAPIURL
+	}
+	return &BingProvider{
+		name:   providerName(cfg, ProviderTypeBing),
+		apiURL: apiURL,
+		apiKey: cfg.APIKey,
+		client: newHTTPClient(),
+	}, nil
+}
+
+// Name returns the provider name
+func (p *BingProvider) Name() string {
+	return p.name
+}
+
+type bingResponse struct {
+	WebPages struct {
+		Value []struct {
+			Name            string `json:"name"`
+			URL             string `json:"url"`
+			Snippet         string `json:"snippet"`
+			DatePublished   string `json:"datePublished"`
+			DateLastCrawled string `json:"dateLastCrawled"`
+		} `json:"value"`
+	} `json:"webPages"`
+}
+
+// Search performs a web search using the Bing Web Search API
+func (p *BingProvider) Search(
+	ctx context.Context,
+	query string,
+	maxResults int,
+	includeDate bool,
+) ([]*types.WebSearchResult, error) {
+	if err := requireAPIKey(p.name, p.apiKey); err != nil {
+		return nil, err
+	}
+	if maxResults <= 0 {
+		maxResults = 5
+	}
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("count", strconv.Itoa(maxResults))
+	params.Set("textDecorations", "false")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Ocp-Apim-Subscription-Key", p.apiKey)
+
+	var resp bingResponse
+	if err := doJSON(p.client, req, p.name, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]*types.WebSearchResult, 0, len(resp.WebPages.Value))
+	for _, item := range resp.WebPages.Value {
+		published := parsePublishedAt(item.DatePublished)
+		if published == nil {
+			published = parsePublishedAt(item.DateLastCrawled)
+		}
+		results = append(results, &types.WebSearchResult{
+			Title:       item.Name,
+			URL:         item.URL,
+			Snippet:     item.Snippet,
+			PublishedAt: published,
+		})
+	}
+	return NormalizeResults(results, p.name, maxResults, includeDate), nil
+}
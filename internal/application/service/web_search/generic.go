@@ -0,0 +1,165 @@
+package web_search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// GenericProvider implements web search for any JSON-over-HTTP search API
+// The request is built from templates and results are mapped with dotted field paths
+type GenericProvider struct {
+	name    string
+	apiURL  string
+	apiKey  string
+	mapping config.GenericWebSearchConfig
+	client  *http.Client
+}
+
+// NewGenericProvider creates a new generic JSON HTTP provider
+func NewGenericProvider(cfg config.WebSearchProviderConfig) (interfaces.WebSearchProvider, error) {
+	if strings.TrimSpace(cfg.APIURL) == "" {
+		return nil, fmt.Errorf("api_url is required for generic web search provider")
+	}
+	if cfg.Generic == nil || cfg.Generic.URLField == "" {
+		return nil, fmt.Errorf("generic.url_field is required for generic web search provider")
+	}
+	mapping := *cfg.Generic
+	if mapping.Method == "" {
+		mapping.Method = http.MethodGet
+	}
+	mapping.Method = strings.ToUpper(mapping.Method)
+	if mapping.TitleField == "" {
+		mapping.TitleField = "title"
+	}
+	return &GenericProvider{
+		name:    providerName(cfg, ProviderTypeGeneric),
+		apiURL:  cfg.APIURL,
+		apiKey:  cfg.APIKey,
+		mapping: mapping,
+		client:  newHTTPClient(),
+	}, nil
+}
+
+// Name returns the provider name
+func (p *GenericProvider) Name() string {
+	return p.name
+}
+
+// Search performs a web search by calling the configured HTTP API
+func (p *GenericProvider) Search(
+	ctx context.Context,
+	query string,
+	maxResults int,
+	includeDate bool,
+) ([]*types.WebSearchResult, error) {
+	if maxResults <= 0 {
+		maxResults = 5
+	}
+	count := strconv.Itoa(maxResults)
+
+	// URL 中的占位符需要 URL 编码，请求体中的占位符需要 JSON 转义
+	reqURL := strings.NewReplacer(
+		"{query}", url.QueryEscape(query),
+		"{max_results}", count,
+		"{api_key}", url.QueryEscape(p.apiKey),
+	).Replace(p.apiURL)
+
+	var body io.Reader
+	if p.mapping.Method != http.MethodGet && p.mapping.Body != "" {
+		body = strings.NewReader(strings.NewReplacer(
+			"{query}", jsonEscape(query),
+			"{max_results}", count,
+			"{api_key}", jsonEscape(p.apiKey),
+		).Replace(p.mapping.Body))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, p.mapping.Method, reqURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	headerReplacer := strings.NewReplacer("{api_key}", p.apiKey)
+	for key, value := range p.mapping.Headers {
+		req.Header.Set(key, headerReplacer.Replace(value))
+	}
+
+	var resp interface{}
+	if err := doJSON(p.client, req, p.name, &resp); err != nil {
+		return nil, err
+	}
+
+	items, ok := lookupPath(resp, p.mapping.ResultsPath).([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s response has no result array at %q", p.name, p.mapping.ResultsPath)
+	}
+
+	results := make([]*types.WebSearchResult, 0, len(items))
+	for _, item := range items {
+		results = append(results, &types.WebSearchResult{
+			Title:       lookupString(item, p.mapping.TitleField),
+			URL:         lookupString(item, p.mapping.URLField),
+			Snippet:     lookupString(item, p.mapping.SnippetField),
+			Content:     lookupString(item, p.mapping.ContentField),
+			PublishedAt: parsePublishedAt(lookupString(item, p.mapping.DateField)),
+		})
+	}
+	return NormalizeResults(results, p.name, maxResults, includeDate), nil
+}
+
+// lookupPath resolves a dotted path (e.g. "data.items" or "results.0.url") in decoded JSON
+// An empty path returns the value itself
+func lookupPath(value interface{}, path string) interface{} {
+	if path == "" {
+		return value
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			value = node[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil
+			}
+			value = node[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// lookupString resolves a dotted path and formats scalar values as string
+func lookupString(value interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+	switch v := lookupPath(value, path).(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+// jsonEscape escapes a string for use inside a JSON string literal
+func jsonEscape(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b[1 : len(b)-1])
+}
@@ -0,0 +1,105 @@
+package web_search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	defaultGoogleAPIURL = "https://www.googleapis.com/customsearch/v1"
+	// googleMaxResults is the maximum number of results per request of the Custom Search JSON API
+	googleMaxResults = 10
+)
+
+// GoogleProvider implements web search using Google Programmable Search (Custom Search JSON API)
+type GoogleProvider struct {
+	name     string
+	apiURL   string
+	apiKey   string
+	engineID string
+	client   *http.Client
+}
+
+// NewGoogleProvider creates a new Google Programmable Search provider
+func NewGoogleProvider(cfg config.WebSearchProviderConfig) (interfaces.WebSearchProvider, error) {
+	apiURL := cfg.APIURL
+	if apiURL == "" {
+		apiURL = defaultGoogleAPIURL
+	}
+	return &GoogleProvider{
+		name:     providerName(cfg, ProviderTypeGoogle),
+		apiURL:   apiURL,
+		apiKey:   cfg.APIKey,
+		engineID: cfg.EngineID,
+		client:   newHTTPClient(),
+	}, nil
+}
+
+// Name returns the provider name
+func (p *GoogleProvider) Name() string {
+	return p.name
+}
+
+type googleResponse struct {
+	Items []struct {
+		Title   string `json:"title"`
+		Link    string `json:"link"`
+		Snippet string `json:"snippet"`
+		Pagemap struct {
+			Metatags []map[string]string `json:"metatags"`
+		} `json:"pagemap"`
+	} `json:"items"`
+}
+
+// Search performs a web search using the Custom Search JSON API
+func (p *GoogleProvider) Search(
+	ctx context.Context,
+	query string,
+	maxResults int,
+	includeDate bool,
+) ([]*types.WebSearchResult, error) {
+	if err := requireAPIKey(p.name, p.apiKey); err != nil {
+		return nil, err
+	}
+	if p.engineID == "" {
+		return nil, fmt.Errorf("web search provider %s requires an engine ID (cx)", p.name)
+	}
+	if maxResults <= 0 {
+		maxResults = 5
+	}
+	params := url.Values{}
+	params.Set("key", p.apiKey)
+	params.Set("cx", p.engineID)
+	params.Set("q", query)
+	params.Set("num", strconv.Itoa(min(maxResults, googleMaxResults)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var resp googleResponse
+	if err := doJSON(p.client, req, p.name, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]*types.WebSearchResult, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		result := &types.WebSearchResult{
+			Title:   item.Title,
+			URL:     item.Link,
+			Snippet: item.Snippet,
+		}
+		if len(item.Pagemap.Metatags) > 0 {
+			result.PublishedAt = parsePublishedAt(item.Pagemap.Metatags[0]["article:published_time"])
+		}
+		results = append(results, result)
+	}
+	return NormalizeResults(results, p.name, maxResults, includeDate), nil
+}
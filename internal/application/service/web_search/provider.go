@@ -0,0 +1,221 @@
+package web_search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// Provider types supported by NewProvider
+const (
+	ProviderTypeDuckDuckGo = "duckduckgo"
+	ProviderTypeSearxNG    = "searxng"
+	ProviderTypeBing       = "bing"
+	ProviderTypeGoogle     = "google"
+	ProviderTypeTavily     = "tavily"
+	ProviderTypeGeneric    = "generic"
+)
+
+// defaultRateLimitCooldown is used when a rate-limited response carries no Retry-After header
+const defaultRateLimitCooldown = time.Minute
+
+// ErrRateLimited is returned (wrapped in *RateLimitError) when a provider rejects a request with HTTP 429
+var ErrRateLimited = errors.New("web search provider rate limited")
+
+// RateLimitError carries how long the provider asked us to wait
+type RateLimitError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: %v (retry after %s)", e.Provider, ErrRateLimited, e.RetryAfter)
+}
+
+// Unwrap makes errors.Is(err, ErrRateLimited) work
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// NewProvider creates a web search provider from its configuration
+// The implementation is selected by cfg.Type, falling back to cfg.ID
+func NewProvider(cfg config.WebSearchProviderConfig) (interfaces.WebSearchProvider, error) {
+	cfg.APIURL = configValue(cfg.APIURL)
+	cfg.APIKey = configValue(cfg.APIKey)
+	cfg.EngineID = configValue(cfg.EngineID)
+
+	providerType := cfg.Type
+	if providerType == "" {
+		providerType = cfg.ID
+	}
+
+	switch providerType {
+	case ProviderTypeDuckDuckGo:
+		return NewDuckDuckGoProvider(cfg)
+	case ProviderTypeSearxNG:
+		return NewSearxNGProvider(cfg)
+	case ProviderTypeBing:
+		return NewBingProvider(cfg)
+	case ProviderTypeGoogle:
+		return NewGoogleProvider(cfg)
+	case ProviderTypeTavily:
+		return NewTavilyProvider(cfg)
+	case ProviderTypeGeneric:
+		return NewGenericProvider(cfg)
+	default:
+		return nil, fmt.Errorf("unknown web search provider type: %s", providerType)
+	}
+}
+
+// configValue treats unresolved environment placeholders (e.g. "${BING_SEARCH_API_KEY}") as empty
+func configValue(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") {
+		return ""
+	}
+	return value
+}
+
+// newHTTPClient returns the HTTP client shared by API based providers
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+	}
+}
+
+// doJSON sends the request and decodes a JSON response into out
+// HTTP 429 is reported as *RateLimitError so callers can fall back to another provider
+func doJSON(client *http.Client, req *http.Request, provider string, out interface{}) error {
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &RateLimitError{Provider: provider, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned status %d: %s", provider, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", provider, err)
+	}
+	return nil
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultRateLimitCooldown
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+	return defaultRateLimitCooldown
+}
+
+// parsePublishedAt parses the date formats returned by the supported search APIs
+func parsePublishedAt(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	layouts := []string{
+		time.RFC3339Nano,
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04:05.0000000",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+		time.RFC1123Z,
+		time.RFC1123,
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]+>`)
+
+// NormalizeResults cleans provider results into a consistent shape:
+// trims text, strips HTML tags from snippets, drops results without URL,
+// removes duplicate URLs, sets the source and limits the result count
+func NormalizeResults(
+	results []*types.WebSearchResult,
+	source string,
+	maxResults int,
+	includeDate bool,
+) []*types.WebSearchResult {
+	normalized := make([]*types.WebSearchResult, 0, len(results))
+	seen := make(map[string]bool, len(results))
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		result.URL = strings.TrimSpace(result.URL)
+		if result.URL == "" || seen[result.URL] {
+			continue
+		}
+		seen[result.URL] = true
+
+		result.Title = strings.TrimSpace(htmlTagPattern.ReplaceAllString(result.Title, ""))
+		result.Snippet = strings.TrimSpace(htmlTagPattern.ReplaceAllString(result.Snippet, ""))
+		result.Content = strings.TrimSpace(result.Content)
+		if result.Title == "" {
+			result.Title = result.URL
+		}
+		if result.Source == "" {
+			result.Source = source
+		}
+		if !includeDate {
+			result.PublishedAt = nil
+		}
+		normalized = append(normalized, result)
+		if maxResults > 0 && len(normalized) >= maxResults {
+			break
+		}
+	}
+	return normalized
+}
+
+// requireAPIKey validates that an API key is configured for providers that need one
+// Checked at search time since the key may come from the tenant configuration
+func requireAPIKey(name, apiKey string) error {
+	if strings.TrimSpace(apiKey) == "" {
+		return fmt.Errorf("web search provider %s requires an API key", name)
+	}
+	return nil
+}
+
+// providerName returns the configured provider ID, defaulting to the provider type
+func providerName(cfg config.WebSearchProviderConfig, providerType string) string {
+	if cfg.ID != "" {
+		return cfg.ID
+	}
+	return providerType
+}
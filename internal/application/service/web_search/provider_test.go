@@ -0,0 +1,171 @@
+package web_search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestNewProvider_Types(t *testing.T) {
+	cases := []struct {
+		cfg     config.WebSearchProviderConfig
+		wantErr bool
+	}{
+		{cfg: config.WebSearchProviderConfig{ID: "duckduckgo"}},
+		{cfg: config.WebSearchProviderConfig{ID: "searxng", APIURL: "http://searxng:8080"}},
+		{cfg: config.WebSearchProviderConfig{ID: "searxng", APIURL: "${SEARXNG_URL}"}, wantErr: true},
+		{cfg: config.WebSearchProviderConfig{ID: "bing"}},
+		{cfg: config.WebSearchProviderConfig{ID: "my-google", Type: "google"}},
+		{cfg: config.WebSearchProviderConfig{ID: "tavily"}},
+		{cfg: config.WebSearchProviderConfig{ID: "custom", Type: "generic", APIURL: "http://x"}, wantErr: true},
+		{cfg: config.WebSearchProviderConfig{ID: "unknown"}, wantErr: true},
+	}
+	for _, c := range cases {
+		p, err := NewProvider(c.cfg)
+		if c.wantErr {
+			if err == nil {
+				t.Fatalf("expected error for %+v", c.cfg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error for %+v: %v", c.cfg, err)
+		}
+		if p.Name() != c.cfg.ID {
+			t.Fatalf("expected provider name %s, got %s", c.cfg.ID, p.Name())
+		}
+	}
+}
+
+func TestSearxNGProvider(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("format") != "json" || r.URL.Query().Get("q") != "weknora" {
+			t.Fatalf("unexpected request: %s", r.URL.String())
+		}
+		_, _ = w.Write([]byte(`{"results":[
+			{"title":"One","url":"https://example.com/1","content":"<b>first</b> result","publishedDate":"2025-01-02T03:04:05"},
+			{"title":"Dup","url":"https://example.com/1","content":"duplicate"},
+			{"title":"Two","url":"https://example.com/2","content":"second"}
+		]}`))
+	}))
+	defer ts.Close()
+
+	p, err := NewProvider(config.WebSearchProviderConfig{ID: "searxng", APIURL: ts.URL + "/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results, err := p.Search(context.Background(), "weknora", 5, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results after dedupe, got %d", len(results))
+	}
+	if results[0].Snippet != "first result" || results[0].Source != "searxng" || results[0].PublishedAt == nil {
+		t.Fatalf("unexpected first result: %+v", results[0])
+	}
+}
+
+func TestBingProvider(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Ocp-Apim-Subscription-Key") != "bing-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"webPages":{"value":[
+			{"name":"Bing One","url":"https://example.com/b1","snippet":"snippet","datePublished":"2025-01-02T03:04:05.0000000"}
+		]}}`))
+	}))
+	defer ts.Close()
+
+	p, _ := NewProvider(config.WebSearchProviderConfig{ID: "bing", APIURL: ts.URL})
+	if _, err := p.Search(context.Background(), "q", 5, true); err == nil {
+		t.Fatalf("expected error without API key")
+	}
+
+	p, _ = NewProvider(config.WebSearchProviderConfig{ID: "bing", APIURL: ts.URL, APIKey: "bing-key"})
+	results, err := p.Search(context.Background(), "q", 5, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "Bing One" || results[0].PublishedAt != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestTavilyProvider_RateLimited(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	p, _ := NewProvider(config.WebSearchProviderConfig{ID: "tavily", APIURL: ts.URL, APIKey: "key"})
+	_, err := p.Search(context.Background(), "q", 5, false)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter != 30*time.Second {
+		t.Fatalf("expected retry after 30s, got %v", err)
+	}
+}
+
+func TestGenericProvider(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") != "hello world" || r.URL.Query().Get("n") != "3" {
+			t.Fatalf("unexpected query: %s", r.URL.RawQuery)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Fatalf("unexpected auth header: %s", r.Header.Get("Authorization"))
+		}
+		_, _ = w.Write([]byte(`{"data":{"items":[
+			{"title":"G1","link":"https://example.com/g1","summary":"s1","meta":{"date":"2025-02-03"}},
+			{"title":"G2","summary":"no link"}
+		]}}`))
+	}))
+	defer ts.Close()
+
+	p, err := NewProvider(config.WebSearchProviderConfig{
+		ID:     "custom",
+		Type:   "generic",
+		APIURL: ts.URL + "/api?q={query}&n={max_results}",
+		APIKey: "secret",
+		Generic: &config.GenericWebSearchConfig{
+			Headers:      map[string]string{"Authorization": "Bearer {api_key}"},
+			ResultsPath:  "data.items",
+			URLField:     "link",
+			SnippetField: "summary",
+			DateField:    "meta.date",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results, err := p.Search(context.Background(), "hello world", 3, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "G1" || results[0].Snippet != "s1" || results[0].PublishedAt == nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestNormalizeResults_Limit(t *testing.T) {
+	results := []*types.WebSearchResult{
+		{URL: " https://a "}, nil, {URL: ""}, {URL: "https://b", Title: "B"}, {URL: "https://c"},
+	}
+	normalized := NormalizeResults(results, "src", 2, false)
+	if len(normalized) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(normalized))
+	}
+	if normalized[0].URL != "https://a" || normalized[0].Title != "https://a" || normalized[0].Source != "src" {
+		t.Fatalf("unexpected first result: %+v", normalized[0])
+	}
+}
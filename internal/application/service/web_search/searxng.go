@@ -0,0 +1,84 @@
+package web_search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// SearxNGProvider implements web search using a self-hosted SearxNG instance (JSON output format)
+type SearxNGProvider struct {
+	name    string
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewSearxNGProvider creates a new SearxNG provider, api_url is the instance base URL
+func NewSearxNGProvider(cfg config.WebSearchProviderConfig) (interfaces.WebSearchProvider, error) {
+	if strings.TrimSpace(cfg.APIURL) == "" {
+		return nil, fmt.Errorf("api_url is required for SearxNG provider")
+	}
+	return &SearxNGProvider{
+		name:    providerName(cfg, ProviderTypeSearxNG),
+		baseURL: strings.TrimRight(cfg.APIURL, "/"),
+		apiKey:  cfg.APIKey,
+		client:  newHTTPClient(),
+	}, nil
+}
+
+// Name returns the provider name
+func (p *SearxNGProvider) Name() string {
+	return p.name
+}
+
+type searxngResponse struct {
+	Results []struct {
+		Title         string `json:"title"`
+		URL           string `json:"url"`
+		Content       string `json:"content"`
+		PublishedDate string `json:"publishedDate"`
+	} `json:"results"`
+}
+
+// Search performs a web search using the SearxNG search API
+func (p *SearxNGProvider) Search(
+	ctx context.Context,
+	query string,
+	maxResults int,
+	includeDate bool,
+) ([]*types.WebSearchResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "json")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// 实例开启鉴权时（如反向代理）使用 Bearer 令牌
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	var resp searxngResponse
+	if err := doJSON(p.client, req, p.name, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]*types.WebSearchResult, 0, len(resp.Results))
+	for _, item := range resp.Results {
+		results = append(results, &types.WebSearchResult{
+			Title:       item.Title,
+			URL:         item.URL,
+			Snippet:     item.Content,
+			PublishedAt: parsePublishedAt(item.PublishedDate),
+		})
+	}
+	return NormalizeResults(results, p.name, maxResults, includeDate), nil
+}
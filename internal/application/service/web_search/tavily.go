@@ -0,0 +1,99 @@
+package web_search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const defaultTavilyAPIURL = "https://api.tavily.com/search"
+
+// TavilyProvider implements web search using the Tavily Search API
+type TavilyProvider struct {
+	name   string
+	apiURL string
+	apiKey string
+	client *http.Client
+}
+
+// NewTavilyProvider creates a new Tavily provider
+func NewTavilyProvider(cfg config.WebSearchProviderConfig) (interfaces.WebSearchProvider, error) {
+	apiURL := cfg.APIURL
+	if apiURL == "" {
+		apiURL = defaultTavilyAPIURL
+	}
+	return &TavilyProvider{
+		name:   providerName(cfg, ProviderTypeTavily),
+		apiURL: apiURL,
+		apiKey: cfg.APIKey,
+		client: newHTTPClient(),
+	}, nil
+}
+
+// Name returns the provider name
+func (p *TavilyProvider) Name() string {
+	return p.name
+}
+
+type tavilyResponse struct {
+	Results []struct {
+		Title         string `json:"title"`
+		URL           string `json:"url"`
+		Content       string `json:"content"`
+		RawContent    string `json:"raw_content"`
+		PublishedDate string `json:"published_date"`
+	} `json:"results"`
+}
+
+// Search performs a web search using the Tavily Search API
+func (p *TavilyProvider) Search(
+	ctx context.Context,
+	query string,
+	maxResults int,
+	includeDate bool,
+) ([]*types.WebSearchResult, error) {
+	if err := requireAPIKey(p.name, p.apiKey); err != nil {
+		return nil, err
+	}
+	if maxResults <= 0 {
+		maxResults = 5
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"query":          query,
+		"max_results":    maxResults,
+		"search_depth":   "basic",
+		"include_answer": false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	var resp tavilyResponse
+	if err := doJSON(p.client, req, p.name, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]*types.WebSearchResult, 0, len(resp.Results))
+	for _, item := range resp.Results {
+		results = append(results, &types.WebSearchResult{
+			Title:       item.Title,
+			URL:         item.URL,
+			Snippet:     item.Content,
+			Content:     item.RawContent,
+			PublishedAt: parsePublishedAt(item.PublishedDate),
+		})
+	}
+	return NormalizeResults(results, p.name, maxResults, includeDate), nil
+}
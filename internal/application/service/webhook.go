@@ -0,0 +1,404 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	defaultWebhookTimeout  = 10 * time.Second
+	defaultWebhookMaxRetry = 8
+	// webhookResponseBodyLimit bounds the response body kept in the delivery log
+	webhookResponseBodyLimit = 2048
+)
+
+// Webhook request headers
+const (
+	WebhookHeaderEvent     = "X-WeKnora-Event"
+	WebhookHeaderDelivery  = "X-WeKnora-Delivery"
+	WebhookHeaderTimestamp = "X-WeKnora-Timestamp"
+	WebhookHeaderSignature = "X-WeKnora-Signature"
+)
+
+// webhookService implements the WebhookService interface
+type webhookService struct {
+	repo       interfaces.WebhookRepository
+	task       *asynq.Client
+	httpClient *http.Client
+	timeout    time.Duration
+	maxRetry   int
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(
+	repo interfaces.WebhookRepository,
+	task *asynq.Client,
+	cfg *config.Config,
+) interfaces.WebhookService {
+	timeout := defaultWebhookTimeout
+	maxRetry := defaultWebhookMaxRetry
+	allowPrivate := false
+	if cfg.Webhook != nil {
+		if cfg.Webhook.Timeout > 0 {
+			timeout = time.Duration(cfg.Webhook.Timeout) * time.Second
+		}
+		if cfg.Webhook.MaxRetry > 0 {
+			maxRetry = cfg.Webhook.MaxRetry
+		}
+		allowPrivate = cfg.Webhook.AllowPrivateNetwork
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		// 在建立连接时校验解析后的地址，避免通过 DNS 解析绕过内网限制
+		dialer.Control = utils.DenyPrivateNetwork
+	}
+	return &webhookService{
+		repo: repo,
+		task: task,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// 不跟随重定向，投递结果以订阅地址的响应为准
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		timeout:  timeout,
+		maxRetry: maxRetry,
+	}
+}
+
+// CreateSubscription creates a subscription and generates its signing secret
+func (s *webhookService) CreateSubscription(ctx context.Context, subscription *types.WebhookSubscription) error {
+	if err := validateWebhookSubscription(subscription); err != nil {
+		return err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return err
+	}
+	subscription.ID = ""
+	subscription.TenantID = ctx.Value(types.TenantIDContextKey).(uint64)
+	subscription.Secret = secret
+
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"name": subscription.Name})
+		return err
+	}
+	logger.Infof(ctx, "Webhook subscription created: %s, events: %v", subscription.ID, subscription.Events)
+	return nil
+}
+
+// GetSubscription gets a subscription of the current tenant
+func (s *webhookService) GetSubscription(ctx context.Context, id string) (*types.WebhookSubscription, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	subscription, err := s.repo.GetSubscription(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, werrors.NewNotFoundError("Webhook subscription not found")
+	}
+	return subscription, nil
+}
+
+// ListSubscriptions lists the subscriptions of the current tenant
+func (s *webhookService) ListSubscriptions(ctx context.Context) ([]*types.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx, ctx.Value(types.TenantIDContextKey).(uint64))
+}
+
+// UpdateSubscription updates the name, URL, events and enabled state of a subscription
+func (s *webhookService) UpdateSubscription(
+	ctx context.Context, subscription *types.WebhookSubscription,
+) (*types.WebhookSubscription, error) {
+	existing, err := s.GetSubscription(ctx, subscription.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWebhookSubscription(subscription); err != nil {
+		return nil, err
+	}
+
+	existing.Name = subscription.Name
+	existing.Description = subscription.Description
+	existing.URL = subscription.URL
+	existing.Events = subscription.Events
+	existing.Enabled = subscription.Enabled
+	existing.UpdatedAt = time.Now()
+	if err := s.repo.UpdateSubscription(ctx, existing); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"subscription_id": existing.ID})
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteSubscription deletes a subscription of the current tenant
+func (s *webhookService) DeleteSubscription(ctx context.Context, id string) error {
+	if _, err := s.GetSubscription(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id)
+}
+
+// ListDeliveries lists the delivery log of a subscription, newest first
+func (s *webhookService) ListDeliveries(
+	ctx context.Context, subscriptionID string, page *types.Pagination,
+) (*types.PageResult, error) {
+	if _, err := s.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	deliveries, total, err := s.repo.ListDeliveries(ctx, tenantID, subscriptionID, page)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, page, deliveries), nil
+}
+
+// TestSubscription sends a webhook.test event to a subscription synchronously
+func (s *webhookService) TestSubscription(ctx context.Context, id string) (*types.WebhookDelivery, error) {
+	subscription, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := s.createDelivery(ctx, subscription, types.WebhookEventTest, map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"message":         "This is a test event from WeKnora",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.send(ctx, subscription, delivery)
+	if delivery.Status != types.WebhookDeliverySuccess {
+		// 测试事件不重试
+		delivery.Status = types.WebhookDeliveryFailed
+	}
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		logger.Errorf(ctx, "Failed to save webhook test delivery %s: %v", delivery.ID, err)
+	}
+	return delivery, nil
+}
+
+// Publish delivers an event of a tenant to its subscriptions in the background.
+// Failures are logged only, publishing never fails the operation that raised the event.
+func (s *webhookService) Publish(
+	ctx context.Context, tenantID uint64, eventType types.WebhookEventType, data interface{},
+) {
+	subscriptions, err := s.repo.ListSubscriptions(ctx, tenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list webhook subscriptions for event %s: %v", eventType, err)
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Enabled || !subscription.Subscribes(eventType) {
+			continue
+		}
+		delivery, err := s.createDelivery(ctx, subscription, eventType, data)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to create webhook delivery for subscription %s: %v", subscription.ID, err)
+			continue
+		}
+
+		payload, _ := json.Marshal(types.WebhookDeliveryPayload{TenantID: tenantID, DeliveryID: delivery.ID})
+		task := asynq.NewTask(types.TypeWebhookDelivery, payload,
+			asynq.Queue("default"), asynq.MaxRetry(s.maxRetry), asynq.Timeout(s.timeout+time.Minute))
+		if _, err := s.task.Enqueue(task); err != nil {
+			logger.Errorf(ctx, "Failed to enqueue webhook delivery %s: %v", delivery.ID, err)
+			delivery.Status = types.WebhookDeliveryFailed
+			delivery.Error = err.Error()
+			delivery.UpdatedAt = time.Now()
+			_ = s.repo.UpdateDelivery(ctx, delivery)
+			continue
+		}
+		logger.Infof(ctx, "Webhook event %s queued for subscription %s, delivery: %s",
+			eventType, subscription.ID, delivery.ID)
+	}
+}
+
+// ProcessDelivery handles webhook delivery tasks
+func (s *webhookService) ProcessDelivery(ctx context.Context, t *asynq.Task) error {
+	var payload types.WebhookDeliveryPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "failed to unmarshal webhook delivery payload: %v", err)
+		return nil
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+
+	delivery, err := s.repo.GetDelivery(ctx, payload.TenantID, payload.DeliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	if delivery == nil || delivery.Status != types.WebhookDeliveryPending {
+		return nil
+	}
+
+	subscription, err := s.repo.GetSubscription(ctx, payload.TenantID, delivery.SubscriptionID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	if subscription == nil || !subscription.Enabled {
+		delivery.Status = types.WebhookDeliveryFailed
+		delivery.Error = "subscription was deleted or disabled"
+		delivery.UpdatedAt = time.Now()
+		return s.repo.UpdateDelivery(ctx, delivery)
+	}
+
+	s.send(ctx, subscription, delivery)
+
+	retryCount, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	if delivery.Status != types.WebhookDeliverySuccess && retryCount >= maxRetry {
+		delivery.Status = types.WebhookDeliveryFailed
+	}
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		logger.Errorf(ctx, "Failed to save webhook delivery %s: %v", delivery.ID, err)
+	}
+
+	if delivery.Status == types.WebhookDeliveryPending {
+		// 返回错误由 asynq 按指数退避重试
+		return fmt.Errorf("webhook delivery %s failed: %s", delivery.ID, delivery.Error)
+	}
+	return nil
+}
+
+// createDelivery records a pending delivery of an event to a subscription
+func (s *webhookService) createDelivery(
+	ctx context.Context,
+	subscription *types.WebhookSubscription,
+	eventType types.WebhookEventType,
+	data interface{},
+) (*types.WebhookDelivery, error) {
+	event := types.WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		TenantID:  subscription.TenantID,
+		CreatedAt: time.Now(),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	delivery := &types.WebhookDelivery{
+		TenantID:       subscription.TenantID,
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		EventType:      eventType,
+		Payload:        types.JSON(body),
+		Status:         types.WebhookDeliveryPending,
+	}
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// send posts the delivery payload to the subscription URL and records the attempt on the delivery
+func (s *webhookService) send(
+	ctx context.Context, subscription *types.WebhookSubscription, delivery *types.WebhookDelivery,
+) {
+	delivery.Attempts++
+	delivery.UpdatedAt = time.Now()
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "WeKnora-Webhook/1.0")
+	req.Header.Set(WebhookHeaderEvent, string(delivery.EventType))
+	req.Header.Set(WebhookHeaderDelivery, delivery.ID)
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := s.httpClient.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		logger.Warnf(ctx, "Webhook delivery %s to subscription %s failed: %v", delivery.ID, subscription.ID, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseBodyLimit))
+	delivery.ResponseStatus = resp.StatusCode
+	delivery.ResponseBody = string(respBody)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		delivery.Error = fmt.Sprintf("unexpected response status %d", resp.StatusCode)
+		logger.Warnf(ctx, "Webhook delivery %s to subscription %s returned %d",
+			delivery.ID, subscription.ID, resp.StatusCode)
+		return
+	}
+
+	now := time.Now()
+	delivery.Status = types.WebhookDeliverySuccess
+	delivery.DeliveredAt = &now
+}
+
+// SignWebhookPayload returns the signature header value of a payload:
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func validateWebhookSubscription(subscription *types.WebhookSubscription) error {
+	if subscription.Name == "" {
+		return werrors.NewBadRequestError("Webhook name is required")
+	}
+	u, err := url.Parse(subscription.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return werrors.NewBadRequestError("Webhook URL must be an absolute http(s) URL")
+	}
+	if len(subscription.Events) == 0 {
+		return werrors.NewBadRequestError("At least one event is required")
+	}
+	for _, eventType := range subscription.Events {
+		if !types.IsValidWebhookEventType(eventType) {
+			return werrors.NewBadRequestError("Unknown webhook event: " + string(eventType))
+		}
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSendSignsPayload(t *testing.T) {
+	const secret = "whsec_test"
+	var gotSignature, gotTimestamp, gotEvent string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(WebhookHeaderSignature)
+		gotTimestamp = r.Header.Get(WebhookHeaderTimestamp)
+		gotEvent = r.Header.Get(WebhookHeaderEvent)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	svc := NewWebhookService(nil, nil, &config.Config{
+		Webhook: &config.WebhookConfig{AllowPrivateNetwork: true},
+	}).(*webhookService)
+	subscription := &types.WebhookSubscription{ID: "sub", URL: server.URL, Secret: secret}
+	delivery := &types.WebhookDelivery{
+		ID:        "delivery",
+		EventType: types.WebhookEventKnowledgeParsed,
+		Payload:   types.JSON(`{"type":"knowledge.parsed"}`),
+		Status:    types.WebhookDeliveryPending,
+	}
+
+	svc.send(context.Background(), subscription, delivery)
+
+	require.Equal(t, types.WebhookDeliverySuccess, delivery.Status, delivery.Error)
+	assert.Equal(t, http.StatusNoContent, delivery.ResponseStatus)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, string(types.WebhookEventKnowledgeParsed), gotEvent)
+	assert.Equal(t, SignWebhookPayload(secret, gotTimestamp, gotBody), gotSignature)
+}
+
+func TestWebhookSendRejectsPrivateNetwork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	svc := NewWebhookService(nil, nil, &config.Config{}).(*webhookService)
+	delivery := &types.WebhookDelivery{ID: "delivery", Payload: types.JSON(`{}`), Status: types.WebhookDeliveryPending}
+
+	svc.send(context.Background(), &types.WebhookSubscription{URL: server.URL}, delivery)
+
+	assert.Equal(t, types.WebhookDeliveryPending, delivery.Status)
+	assert.Contains(t, delivery.Error, "private network")
+}
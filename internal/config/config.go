@@ -26,7 +26,15 @@ type Config struct {
 	StreamManager   *StreamManagerConfig   `yaml:"stream_manager"   json:"stream_manager"`
 	ExtractManager  *ExtractManagerConfig  `yaml:"extract"          json:"extract"`
 	WebSearch       *WebSearchConfig       `yaml:"web_search"       json:"web_search"`
+	SocialMedia     *SocialMediaConfig     `yaml:"social_media"     json:"social_media"`
+	MCPServer       *MCPServerConfig       `yaml:"mcp_server"       json:"mcp_server"`
 	PromptTemplates *PromptTemplatesConfig `yaml:"prompt_templates" json:"prompt_templates"`
+	TaskScheduling  *TaskSchedulingConfig  `yaml:"task_scheduling"  json:"task_scheduling"`
+	Webhook         *WebhookConfig         `yaml:"webhook"          json:"webhook"`
+	Worker          *WorkerConfig          `yaml:"worker"           json:"worker"`
+	Memory          *MemoryConfig          `yaml:"memory"           json:"memory"`
+	DataSource      *DataSourceConfig      `yaml:"data_source"      json:"data_source"`
+	AgentSchedule   *AgentScheduleConfig   `yaml:"agent_schedule"   json:"agent_schedule"`
 }
 
 type DocReaderConfig struct {
@@ -295,6 +303,126 @@ type WebSearchProviderConfig struct {
 	RequiresAPIKey bool   `yaml:"requires_api_key"      json:"requires_api_key"`
 	Description    string `yaml:"description,omitempty" json:"description,omitempty"`
 	APIURL         string `yaml:"api_url,omitempty"     json:"api_url,omitempty"`
+	// Type 提供商实现类型（duckduckgo, searxng, bing, google, tavily, generic），为空时使用 ID
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// APIKey 全局 API 密钥，租户在搜索配置中填写的密钥优先
+	APIKey string `yaml:"api_key,omitempty" json:"-"`
+	// EngineID Google Programmable Search 的搜索引擎 ID（cx）
+	EngineID string `yaml:"engine_id,omitempty" json:"-"`
+	// Generic 通用 JSON HTTP 提供商的请求与结果映射配置
+	Generic *GenericWebSearchConfig `yaml:"generic,omitempty" json:"-"`
+}
+
+// GenericWebSearchConfig describes how to call a JSON-over-HTTP search API and map its results
+// {query}、{max_results} 和 {api_key} 占位符会在 URL、请求头和请求体中被替换
+type GenericWebSearchConfig struct {
+	Method       string            `yaml:"method"        json:"method"` // GET or POST
+	Headers      map[string]string `yaml:"headers"       json:"headers"`
+	Body         string            `yaml:"body"          json:"body"`         // POST 请求体模板
+	ResultsPath  string            `yaml:"results_path"  json:"results_path"` // 结果数组的路径，如 data.items
+	TitleField   string            `yaml:"title_field"   json:"title_field"`
+	URLField     string            `yaml:"url_field"     json:"url_field"`
+	SnippetField string            `yaml:"snippet_field" json:"snippet_field"`
+	ContentField string            `yaml:"content_field" json:"content_field"`
+	DateField    string            `yaml:"date_field"    json:"date_field"`
+}
+
+// MCPServerConfig 内置 MCP Server 配置
+type MCPServerConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// BasePath MCP 端点路径，Streamable HTTP 使用 {base_path}，SSE 使用 {base_path}/sse 和 {base_path}/message
+	BasePath string `yaml:"base_path" json:"base_path"`
+	// MaxResults 检索类工具返回的最大结果数
+	MaxResults int `yaml:"max_results" json:"max_results"`
+}
+
+// TaskSchedulingConfig 后台任务的租户公平调度及模型调用限流配置
+type TaskSchedulingConfig struct {
+	// MaxConcurrentTasks 单个租户同时执行的入库类任务上限，0 表示不限制
+	MaxConcurrentTasks int `yaml:"max_concurrent_tasks" json:"max_concurrent_tasks"`
+	// ThrottleDelay 任务因租户并发已满被延后时的重新调度间隔（秒）
+	ThrottleDelay int `yaml:"throttle_delay" json:"throttle_delay"`
+	// FairTaskTypes 参与租户公平调度的任务类型
+	FairTaskTypes []string `yaml:"fair_task_types" json:"fair_task_types"`
+	// EmbeddingCallsPerMinute 单个租户每分钟 Embedding 调用次数上限，0 表示不限制
+	EmbeddingCallsPerMinute int `yaml:"embedding_calls_per_minute" json:"embedding_calls_per_minute"`
+	// VLMCallsPerMinute 单个租户每分钟 VLM 调用次数上限，0 表示不限制
+	VLMCallsPerMinute int `yaml:"vlm_calls_per_minute" json:"vlm_calls_per_minute"`
+}
+
+// WebhookConfig 出站 Webhook 投递配置
+type WebhookConfig struct {
+	// Timeout 单次投递的请求超时（秒）
+	Timeout int `yaml:"timeout" json:"timeout"`
+	// MaxRetry 投递失败后的最大重试次数，重试间隔按指数退避
+	MaxRetry int `yaml:"max_retry" json:"max_retry"`
+	// AllowPrivateNetwork 是否允许投递到内网、回环等私有地址
+	AllowPrivateNetwork bool `yaml:"allow_private_network" json:"allow_private_network"`
+}
+
+// WorkerConfig 异步任务 worker 配置
+type WorkerConfig struct {
+	// HealthPort worker 模式下健康检查及 Prometheus 指标的 HTTP 端口
+	HealthPort int `yaml:"health_port" json:"health_port"`
+	// Concurrency 同时执行的任务数，0 表示使用 CPU 核数
+	Concurrency int `yaml:"concurrency" json:"concurrency"`
+	// ShutdownTimeout 退出时等待执行中任务完成的最长时间（秒），超时未完成的任务会重新入队
+	ShutdownTimeout int `yaml:"shutdown_timeout" json:"shutdown_timeout"`
+}
+
+// MemoryConfig 智能体长期记忆配置
+type MemoryConfig struct {
+	// ExtractDelay 会话空闲多久后提取记忆（秒）
+	ExtractDelay int `yaml:"extract_delay" json:"extract_delay"`
+	// ExtractRounds 提取时读取的最近对话轮数
+	ExtractRounds int `yaml:"extract_rounds" json:"extract_rounds"`
+	// RecallTopK 每次提问召回的记忆条数
+	RecallTopK int `yaml:"recall_top_k" json:"recall_top_k"`
+	// RecallThreshold 召回的最低相似度
+	RecallThreshold float64 `yaml:"recall_threshold" json:"recall_threshold"`
+	// MaxPerUser 每个用户保留的记忆上限，超出时删除最久未更新的记忆
+	MaxPerUser int `yaml:"max_per_user" json:"max_per_user"`
+	// ExtractPrompt 提取记忆的提示词，为空时使用内置提示词
+	ExtractPrompt string `yaml:"extract_prompt" json:"extract_prompt"`
+}
+
+// DataSourceConfig 智能体外部数据源配置
+type DataSourceConfig struct {
+	// SQLiteDir SQLite 数据源文件所在目录，每个租户只能引用其 <sqlite_dir>/<tenant_id> 子目录下的文件，为空时不允许 SQLite 数据源
+	SQLiteDir string `yaml:"sqlite_dir" json:"sqlite_dir"`
+	// QueryTimeout 单次查询的超时（秒）
+	QueryTimeout int `yaml:"query_timeout" json:"query_timeout"`
+	// MaxOpenConns 每个数据源的最大连接数
+	MaxOpenConns int `yaml:"max_open_conns" json:"max_open_conns"`
+	// AllowedPrivateNetworks 允许连接的内网地址段（CIDR 或单个 IP），默认禁止连接回环、内网及链路本地地址
+	AllowedPrivateNetworks []string `yaml:"allowed_private_networks" json:"allowed_private_networks"`
+}
+
+// AgentScheduleConfig 智能体定时及触发运行配置
+type AgentScheduleConfig struct {
+	// RunTimeout 单次运行的最长时间（秒），超时的运行记为失败
+	RunTimeout int `yaml:"run_timeout" json:"run_timeout"`
+	// TriggerDelay 事件触发后等待多久开始运行（秒），期间的后续事件合并为同一次运行
+	TriggerDelay int `yaml:"trigger_delay" json:"trigger_delay"`
+}
+
+// SocialMediaConfig 自媒体文案提取配置
+type SocialMediaConfig struct {
+	// Extractors 按顺序尝试的提取器列表，前一个失败时自动回退到下一个
+	Extractors   []SocialMediaExtractorConfig `yaml:"extractors"     json:"extractors"`
+	Timeout      int                          `yaml:"timeout"        json:"timeout"`        // 单个链接提取超时（秒）
+	MaxBatchSize int                          `yaml:"max_batch_size" json:"max_batch_size"` // 单次批量提交的最大链接数
+}
+
+// SocialMediaExtractorConfig represents configuration for a social media content extractor
+type SocialMediaExtractorConfig struct {
+	ID         string   `yaml:"id"                    json:"id"` // coze, subtitle, stt
+	Name       string   `yaml:"name"                  json:"name"`
+	Platforms  []string `yaml:"platforms,omitempty"   json:"platforms,omitempty"` // 为空表示支持所有平台
+	APIURL     string   `yaml:"api_url,omitempty"     json:"api_url,omitempty"`
+	APIKey     string   `yaml:"api_key,omitempty"     json:"-"`
+	WorkflowID string   `yaml:"workflow_id,omitempty" json:"workflow_id,omitempty"`
+	Model      string   `yaml:"model,omitempty"       json:"model,omitempty"`
 }
 
 // WebSearchDefaultConfig represents the default web search configuration
@@ -304,4 +432,6 @@ type WebSearchDefaultConfig struct {
 	IncludeDate       bool     `yaml:"include_date"       json:"include_date"`
 	CompressionMethod string   `yaml:"compression_method" json:"compression_method"`
 	Blacklist         []string `yaml:"blacklist"          json:"blacklist"`
+	// FallbackProviders 租户未配置备用搜索引擎时使用的回退顺序
+	FallbackProviders []string `yaml:"fallback_providers" json:"fallback_providers"`
 }
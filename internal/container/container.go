@@ -45,6 +45,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/handler/session"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/mcp"
+	"github.com/Tencent/WeKnora/internal/mcpserver"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
 	"github.com/Tencent/WeKnora/internal/router"
@@ -59,12 +60,14 @@ import (
 // Creates a fully configured application container with proper dependency resolution
 // Parameters:
 //   - container: Base dig container to add dependencies to
+//   - mode: Run mode, API-only components are skipped in worker mode and the asynq worker is
+//     only started in worker and all modes
 //
 // Returns:
 //   - Configured container with all application dependencies registered
-func BuildContainer(container *dig.Container) *dig.Container {
+func BuildContainer(container *dig.Container, mode RunMode) *dig.Container {
 	ctx := context.Background()
-	logger.Debugf(ctx, "[Container] Starting container initialization...")
+	logger.Debugf(ctx, "[Container] Starting container initialization, run mode: %s", mode)
 
 	// Register resource cleaner for proper cleanup of resources
 	must(container.Provide(NewResourceCleaner, dig.As(new(interfaces.ResourceCleaner))))
@@ -109,9 +112,17 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(repository.NewCredentialRepository))
 	must(container.Provide(repository.NewUserRepository))
 	must(container.Provide(repository.NewAuthTokenRepository))
-	must(container.Provide(neo4jRepo.NewNeo4jRepository))
+	must(container.Provide(initGraphRepository))
 	must(container.Provide(repository.NewMCPServiceRepository))
 	must(container.Provide(repository.NewCustomAgentRepository))
+	must(container.Provide(repository.NewWebhookRepository))
+	must(container.Provide(repository.NewAgentScheduleRepository))
+	must(container.Provide(repository.NewUserMemoryRepository))
+	must(container.Provide(repository.NewSessionShareRepository))
+	must(container.Provide(repository.NewToolApprovalRepository))
+	must(container.Provide(repository.NewDataSourceRepository))
+	must(container.Provide(repository.NewArtifactRepository))
+	must(container.Provide(repository.NewGraphCommunityRepository))
 	must(container.Provide(service.NewWebSearchStateService))
 
 	// MCP manager for managing MCP client connections
@@ -121,12 +132,20 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	// Business service layer
 	logger.Debugf(ctx, "[Container] Registering business services...")
 	must(container.Provide(service.NewTenantService))
+	must(container.Provide(service.NewWebhookService))
 	must(container.Provide(service.NewKnowledgeBaseService))
+	must(container.Provide(service.NewAgentScheduleService))
 	must(container.Provide(service.NewKnowledgeService))
 	must(container.Provide(service.NewChunkService))
 	must(container.Provide(service.NewKnowledgeTagService))
 	must(container.Provide(embedding.NewBatchEmbedder))
+	must(container.Provide(service.NewTenantLimiter))
 	must(container.Provide(service.NewModelService))
+	must(container.Provide(service.NewUserMemoryService))
+	must(container.Provide(service.NewSessionShareService))
+	must(container.Provide(service.NewToolApprovalService))
+	must(container.Provide(service.NewDataSourceService))
+	must(container.Provide(service.NewArtifactService))
 	must(container.Provide(service.NewCredentialService))
 	must(container.Provide(service.NewDatasetService))
 	must(container.Provide(service.NewEvaluationService))
@@ -135,14 +154,21 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	// Extract services - register individual extracters with names
 	must(container.Provide(service.NewChunkExtractService, dig.Name("chunkExtracter")))
 	must(container.Provide(service.NewDataTableSummaryService, dig.Name("dataTableSummary")))
+	must(container.Provide(service.NewScheduledAgentRunner, dig.Name("scheduledAgentRunner")))
 
 	must(container.Provide(service.NewMessageService))
+	must(container.Provide(service.NewMessageSearchService))
 	must(container.Provide(service.NewMCPServiceService))
 	must(container.Provide(service.NewCustomAgentService))
+	must(container.Provide(service.NewAgentTransferService))
+	must(container.Provide(service.NewKnowledgeGraphService))
+	must(container.Provide(service.NewGraphCommunityService))
 
 	// Web search service (needed by AgentService)
 	logger.Debugf(ctx, "[Container] Registering web search service...")
 	must(container.Provide(service.NewWebSearchService))
+	must(container.Provide(service.NewSocialMediaService))
+	must(container.Provide(service.NewTaskAdminService))
 
 	// Agent service layer (requires event bus, web search service)
 	// SessionService is passed as parameter to CreateAgentEngine method when creating AgentService
@@ -157,9 +183,13 @@ func BuildContainer(container *dig.Container) *dig.Container {
 
 	logger.Debugf(ctx, "[Container] Registering asynq client and server...")
 	must(container.Provide(router.NewAsyncqClient))
-	must(container.Provide(router.NewAsynqServer))
+	must(container.Provide(router.NewAsynqInspector))
+	if mode.RunsWorker() {
+		must(container.Provide(router.NewAsynqServer))
+	}
 
 	// Chat pipeline components for processing chat requests
+	// Shared by the API and by workers, whose scheduled agent runs go through the session service
 	logger.Debugf(ctx, "[Container] Registering chat pipeline plugins...")
 	must(container.Provide(chatpipline.NewEventManager))
 	must(container.Invoke(chatpipline.NewPluginTracing))
@@ -177,8 +207,32 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Invoke(chatpipline.NewPluginExtractEntity))
 	must(container.Invoke(chatpipline.NewPluginSearchEntity))
 	must(container.Invoke(chatpipline.NewPluginSearchParallel))
+	must(container.Invoke(chatpipline.NewPluginSearchCommunity))
 	logger.Debugf(ctx, "[Container] Chat pipeline plugins registered")
 
+	// Health checks are served in every mode, workers expose them on their own port
+	must(container.Provide(handler.NewHealthHandler))
+
+	if mode.ServesAPI() {
+		registerAPIComponents(ctx, container)
+	}
+
+	if mode.RunsWorker() {
+		logger.Debugf(ctx, "[Container] Starting asynq server...")
+		must(container.Invoke(router.RunAsynqServer))
+		must(container.Invoke(router.RunAgentScheduleDispatcher))
+	}
+
+	logger.Infof(ctx, "[Container] Container initialization completed successfully")
+	return container
+}
+
+// registerAPIComponents registers the components only needed to serve the HTTP API:
+// the built-in MCP server, the HTTP handlers and the router
+func registerAPIComponents(ctx context.Context, container *dig.Container) {
+	// Built-in MCP server exposing knowledge bases to external MCP clients
+	must(container.Provide(mcpserver.NewServer))
+
 	// HTTP handlers layer
 	logger.Debugf(ctx, "[Container] Registering HTTP handlers...")
 	must(container.Provide(handler.NewTenantHandler))
@@ -196,21 +250,25 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(handler.NewInitializationHandler))
 	must(container.Provide(handler.NewAuthHandler))
 	must(container.Provide(handler.NewSystemHandler))
-	must(container.Provide(handler.NewHealthHandler))
 	must(container.Provide(handler.NewMCPServiceHandler))
 	must(container.Provide(handler.NewWebSearchHandler))
 	must(container.Provide(handler.NewCustomAgentHandler))
 	must(container.Provide(handler.NewSocialMediaHandler))
+	must(container.Provide(handler.NewTaskHandler))
+	must(container.Provide(handler.NewWebhookHandler))
+	must(container.Provide(handler.NewAgentScheduleHandler))
+	must(container.Provide(handler.NewMemoryHandler))
+	must(container.Provide(handler.NewSessionShareHandler))
+	must(container.Provide(handler.NewToolApprovalHandler))
+	must(container.Provide(handler.NewDataSourceHandler))
+	must(container.Provide(handler.NewArtifactHandler))
+	must(container.Provide(handler.NewKnowledgeGraphHandler))
 	must(container.Provide(handler.NewBackupHandler))
 	logger.Debugf(ctx, "[Container] HTTP handlers registered")
 
 	// Router configuration
-	logger.Debugf(ctx, "[Container] Registering router and starting asynq server...")
+	logger.Debugf(ctx, "[Container] Registering router...")
 	must(container.Provide(router.NewRouter))
-	must(container.Invoke(router.RunAsynqServer))
-
-	logger.Infof(ctx, "[Container] Container initialization completed successfully")
-	return container
 }
 
 // must is a helper function for error handling
@@ -663,7 +721,7 @@ func initOllamaService() (*ollama.OllamaService, error) {
 
 func initNeo4jClient() (neo4j.Driver, error) {
 	ctx := context.Background()
-	if strings.ToLower(os.Getenv("NEO4J_ENABLE")) != "true" {
+	if types.GetGraphDriver() != types.GraphDriverNeo4j {
 		logger.Debugf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
 		return nil, nil
 	}
@@ -702,6 +760,25 @@ func initNeo4jClient() (neo4j.Driver, error) {
 	return nil, fmt.Errorf("failed to connect to Neo4j after %d attempts: %w", maxRetries, err)
 }
 
+// initGraphRepository creates the knowledge graph store selected by GRAPH_DRIVER
+// Parameters:
+//   - driver: Neo4j driver, nil unless Neo4j is selected
+//   - db: Database connection, used by the PostgreSQL store
+//
+// Returns:
+//   - Graph repository; the Neo4j repository without a driver when knowledge graphs are disabled
+//   - Error if the driver is not supported
+func initGraphRepository(driver neo4j.Driver, db *gorm.DB) (interfaces.RetrieveGraphRepository, error) {
+	switch graphDriver := types.GetGraphDriver(); graphDriver {
+	case types.GraphDriverPostgres:
+		return postgresRepo.NewPostgresGraphRepository(db), nil
+	case types.GraphDriverNeo4j, "":
+		return neo4jRepo.NewNeo4jRepository(driver), nil
+	default:
+		return nil, fmt.Errorf("unsupported graph driver: %s", graphDriver)
+	}
+}
+
 func NewDuckDB() (*sql.DB, error) {
 	sqlDB, err := sql.Open("duckdb", ":memory:")
 	if err != nil {
@@ -0,0 +1,21 @@
+package container
+
+import (
+	"testing"
+
+	"go.uber.org/dig"
+)
+
+// TestBuildContainer resolves the dependency graph of every run mode without running any constructor
+func TestBuildContainer(t *testing.T) {
+	for _, mode := range []RunMode{RunModeAPI, RunModeWorker, RunModeAll} {
+		t.Run(string(mode), func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("build container in %s mode: %v", mode, r)
+				}
+			}()
+			BuildContainer(dig.New(dig.DryRun(true)), mode)
+		})
+	}
+}
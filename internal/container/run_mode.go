@@ -0,0 +1,40 @@
+package container
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RunMode selects which parts of the application a process runs
+type RunMode string
+
+const (
+	// RunModeAPI serves the HTTP API only, background tasks are enqueued for workers
+	RunModeAPI RunMode = "api"
+	// RunModeWorker processes background tasks only, serving health checks and metrics over HTTP
+	RunModeWorker RunMode = "worker"
+	// RunModeAll serves the HTTP API and processes background tasks in the same process
+	RunModeAll RunMode = "all"
+)
+
+// ParseRunMode parses a run mode, an empty value means RunModeAll
+func ParseRunMode(value string) (RunMode, error) {
+	switch mode := RunMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return RunModeAll, nil
+	case RunModeAPI, RunModeWorker, RunModeAll:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown run mode %q, expected api, worker or all", value)
+	}
+}
+
+// ServesAPI reports whether the process serves the HTTP API
+func (m RunMode) ServesAPI() bool {
+	return m == RunModeAPI || m == RunModeAll
+}
+
+// RunsWorker reports whether the process processes background tasks
+func (m RunMode) RunsWorker() bool {
+	return m == RunModeWorker || m == RunModeAll
+}
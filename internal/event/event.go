@@ -47,12 +47,14 @@ const (
 	EventAgentComplete EventType = "agent.complete" // Agent 完成
 
 	// Agent streaming events (for real-time feedback)
-	EventAgentThought     EventType = "thought"      // Agent 思考过程
-	EventAgentToolCall    EventType = "tool_call"    // 工具调用通知
-	EventAgentToolResult  EventType = "tool_result"  // 工具结果
-	EventAgentReflection  EventType = "reflection"   // Agent 反思
-	EventAgentReferences  EventType = "references"   // 知识引用
-	EventAgentFinalAnswer EventType = "final_answer" // 最终答案
+	EventAgentThought      EventType = "thought"       // Agent 思考过程
+	EventAgentToolCall     EventType = "tool_call"     // 工具调用通知
+	EventAgentToolResult   EventType = "tool_result"   // 工具结果
+	EventAgentToolApproval EventType = "tool_approval" // 工具调用等待审批及审批结果
+	EventAgentReflection   EventType = "reflection"    // Agent 反思
+	EventAgentReferences   EventType = "references"    // 知识引用
+	EventAgentArtifact     EventType = "artifact"      // 工具生成的图表或文件
+	EventAgentFinalAnswer  EventType = "final_answer"  // 最终答案
 
 	// Error events
 	EventError EventType = "error" // 错误事件
@@ -1,5 +1,7 @@
 package event
 
+import "encoding/json"
+
 // EventData contains common event data structures for different stages
 
 // QueryData represents query-related event data
@@ -142,6 +144,7 @@ type AgentCompleteData struct {
 	TotalDurationMs int64                  `json:"total_duration_ms"`
 	MessageID       string                 `json:"message_id,omitempty"` // Assistant message ID
 	RequestID       string                 `json:"request_id,omitempty"`
+	Usage           interface{}            `json:"usage,omitempty"` // *types.AgentUsage - tokens, time and tool calls consumed
 	Extra           map[string]interface{} `json:"extra,omitempty"`
 }
 
@@ -175,6 +178,31 @@ type AgentToolResultData struct {
 	Data       map[string]interface{} `json:"data,omitempty"` // Structured data from tool result (e.g., display_type, formatted results)
 }
 
+// AgentToolApprovalData represents a tool call waiting for the user's approval, or its decision
+type AgentToolApprovalData struct {
+	ApprovalID string         `json:"approval_id"`
+	ToolCallID string         `json:"tool_call_id"`
+	ToolName   string         `json:"tool_name"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+	Status     string         `json:"status"` // pending, approved, rejected or expired
+	Reason     string         `json:"reason,omitempty"`
+	ExpiresAt  int64          `json:"expires_at"` // Unix seconds
+	Iteration  int            `json:"iteration"`
+}
+
+// AgentArtifactData represents a chart or file a tool call attached to the assistant message
+type AgentArtifactData struct {
+	ArtifactID string          `json:"artifact_id"`
+	ToolCallID string          `json:"tool_call_id"`
+	Type       string          `json:"type"` // chart, csv or xlsx
+	Title      string          `json:"title,omitempty"`
+	FileName   string          `json:"file_name,omitempty"`
+	Size       int64           `json:"size,omitempty"`
+	RowCount   int             `json:"row_count"`
+	Spec       json.RawMessage `json:"spec,omitempty"` // Vega-Lite specification of a chart
+	Iteration  int             `json:"iteration"`
+}
+
 // AgentReferencesData represents knowledge references data
 type AgentReferencesData struct {
 	References interface{} `json:"references"` // []*types.SearchResult
@@ -195,6 +223,15 @@ type AgentReflectionData struct {
 	Done       bool   `json:"done"` // Whether streaming is complete
 }
 
+// Metadata keys of events a sub-agent emits while running a task delegated by another agent.
+// The events are forwarded to the event bus of the delegating agent with these keys set.
+const (
+	MetadataDepth            = "depth"               // Nesting depth of the sub-agent, 1 for agents called by the top-level agent
+	MetadataParentToolCallID = "parent_tool_call_id" // ID of the tool call that delegated the task
+	MetadataAgentID          = "agent_id"            // ID of the sub-agent
+	MetadataAgentName        = "agent_name"          // Name of the sub-agent
+)
+
 // SessionTitleData represents session title update data
 type SessionTitleData struct {
 	SessionID string `json:"session_id"`
@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AgentScheduleHandler 智能体定时任务处理器
+type AgentScheduleHandler struct {
+	scheduleService interfaces.AgentScheduleService
+}
+
+// NewAgentScheduleHandler 创建智能体定时任务处理器
+func NewAgentScheduleHandler(scheduleService interfaces.AgentScheduleService) *AgentScheduleHandler {
+	return &AgentScheduleHandler{scheduleService: scheduleService}
+}
+
+// AgentScheduleRequest 创建/更新智能体定时任务的请求
+type AgentScheduleRequest struct {
+	AgentID                 string                   `json:"agent_id"                   binding:"required"`
+	Name                    string                   `json:"name"                       binding:"required"`
+	Description             string                   `json:"description"`
+	Prompt                  string                   `json:"prompt"                     binding:"required"`
+	Cron                    string                   `json:"cron"`
+	Timezone                string                   `json:"timezone"`
+	TriggerEvents           []types.WebhookEventType `json:"trigger_events"`
+	TriggerKnowledgeBaseIDs []string                 `json:"trigger_knowledge_base_ids"`
+	OutputKnowledgeBaseID   string                   `json:"output_knowledge_base_id"`
+	NotifyWebhook           bool                     `json:"notify_webhook"`
+	Enabled                 *bool                    `json:"enabled"` // 默认启用
+}
+
+func (r *AgentScheduleRequest) toSchedule() *types.AgentSchedule {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return &types.AgentSchedule{
+		AgentID:                 r.AgentID,
+		Name:                    r.Name,
+		Description:             r.Description,
+		Prompt:                  r.Prompt,
+		Cron:                    r.Cron,
+		Timezone:                r.Timezone,
+		TriggerEvents:           r.TriggerEvents,
+		TriggerKnowledgeBaseIDs: r.TriggerKnowledgeBaseIDs,
+		OutputKnowledgeBaseID:   r.OutputKnowledgeBaseID,
+		NotifyWebhook:           r.NotifyWebhook,
+		Enabled:                 enabled,
+	}
+}
+
+// CreateAgentSchedule godoc
+// @Summary      创建智能体定时任务
+// @Description  创建按 cron 表达式或知识事件运行智能体的定时任务，两者均未配置时只能手动运行
+// @Tags         智能体定时任务
+// @Accept       json
+// @Produce      json
+// @Param        request  body      AgentScheduleRequest    true  "定时任务信息"
+// @Success      201      {object}  map[string]interface{}  "创建的定时任务"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agent-schedules [post]
+func (h *AgentScheduleHandler) CreateAgentSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req AgentScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	schedule := req.toSchedule()
+	if err := h.scheduleService.CreateSchedule(ctx, schedule); err != nil {
+		h.handleError(c, err, "创建智能体定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// ListAgentSchedules godoc
+// @Summary      获取智能体定时任务列表
+// @Description  获取当前租户的智能体定时任务
+// @Tags         智能体定时任务
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "定时任务列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agent-schedules [get]
+func (h *AgentScheduleHandler) ListAgentSchedules(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	schedules, err := h.scheduleService.ListSchedules(ctx)
+	if err != nil {
+		h.handleError(c, err, "获取智能体定时任务列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedules,
+	})
+}
+
+// GetAgentSchedule godoc
+// @Summary      获取智能体定时任务详情
+// @Description  获取智能体定时任务详情，包含下一次运行时间及保存运行结果的会话
+// @Tags         智能体定时任务
+// @Produce      json
+// @Param        id   path      string  true  "定时任务ID"
+// @Success      200  {object}  map[string]interface{}  "定时任务详情"
+// @Failure      404  {object}  errors.AppError         "定时任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agent-schedules/{id} [get]
+func (h *AgentScheduleHandler) GetAgentSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	schedule, err := h.scheduleService.GetSchedule(ctx, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err, "获取智能体定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// UpdateAgentSchedule godoc
+// @Summary      更新智能体定时任务
+// @Description  更新定时任务的配置，修改 cron 或时区后重新计算下一次运行时间
+// @Tags         智能体定时任务
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "定时任务ID"
+// @Param        request  body      AgentScheduleRequest    true  "定时任务信息"
+// @Success      200      {object}  map[string]interface{}  "更新后的定时任务"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "定时任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agent-schedules/{id} [put]
+func (h *AgentScheduleHandler) UpdateAgentSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req AgentScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	schedule := req.toSchedule()
+	schedule.ID = c.Param("id")
+	updated, err := h.scheduleService.UpdateSchedule(ctx, schedule)
+	if err != nil {
+		h.handleError(c, err, "更新智能体定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+	})
+}
+
+// DeleteAgentSchedule godoc
+// @Summary      删除智能体定时任务
+// @Description  删除智能体定时任务，保存运行结果的会话及运行记录保留
+// @Tags         智能体定时任务
+// @Produce      json
+// @Param        id   path      string  true  "定时任务ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "定时任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agent-schedules/{id} [delete]
+func (h *AgentScheduleHandler) DeleteAgentSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.scheduleService.DeleteSchedule(ctx, c.Param("id")); err != nil {
+		h.handleError(c, err, "删除智能体定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "删除成功",
+	})
+}
+
+// RunAgentSchedule godoc
+// @Summary      立即运行智能体定时任务
+// @Description  立即排队运行一次定时任务（停用的任务也可运行），返回待执行的运行记录
+// @Tags         智能体定时任务
+// @Produce      json
+// @Param        id   path      string  true  "定时任务ID"
+// @Success      202  {object}  map[string]interface{}  "运行记录"
+// @Failure      404  {object}  errors.AppError         "定时任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agent-schedules/{id}/run [post]
+func (h *AgentScheduleHandler) RunAgentSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	run, err := h.scheduleService.RunNow(ctx, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err, "运行智能体定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// ListAgentScheduleRuns godoc
+// @Summary      获取智能体定时任务运行记录
+// @Description  分页获取定时任务的运行记录（按时间倒序），包含触发方式、触发事件、状态、回答及资源消耗
+// @Tags         智能体定时任务
+// @Produce      json
+// @Param        id         path      string  true   "定时任务ID"
+// @Param        page       query     int     false  "页码"
+// @Param        page_size  query     int     false  "每页数量"
+// @Success      200        {object}  map[string]interface{}  "运行记录"
+// @Failure      404        {object}  errors.AppError         "定时任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agent-schedules/{id}/runs [get]
+func (h *AgentScheduleHandler) ListAgentScheduleRuns(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if pagination.Page < 1 {
+		pagination.Page = 1
+	}
+	if pagination.PageSize < 1 {
+		pagination.PageSize = 20
+	}
+
+	result, err := h.scheduleService.ListRuns(ctx, c.Param("id"), &pagination)
+	if err != nil {
+		h.handleError(c, err, "获取智能体定时任务运行记录失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetAgentScheduleRun godoc
+// @Summary      获取智能体定时任务运行详情
+// @Description  获取一次运行的详情
+// @Tags         智能体定时任务
+// @Produce      json
+// @Param        id      path      string  true  "定时任务ID"
+// @Param        run_id  path      string  true  "运行ID"
+// @Success      200     {object}  map[string]interface{}  "运行详情"
+// @Failure      404     {object}  errors.AppError         "运行记录不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agent-schedules/{id}/runs/{run_id} [get]
+func (h *AgentScheduleHandler) GetAgentScheduleRun(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	run, err := h.scheduleService.GetRun(ctx, c.Param("id"), c.Param("run_id"))
+	if err != nil {
+		h.handleError(c, err, "获取智能体定时任务运行详情失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+func (h *AgentScheduleHandler) handleError(c *gin.Context, err error, message string) {
+	ctx := c.Request.Context()
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(ctx, err, map[string]interface{}{"path": secutils.SanitizeForLog(c.FullPath())})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ArtifactHandler 消息产物（图表、文件）处理器
+type ArtifactHandler struct {
+	artifactService interfaces.ArtifactService
+}
+
+// NewArtifactHandler 创建消息产物处理器
+func NewArtifactHandler(artifactService interfaces.ArtifactService) *ArtifactHandler {
+	return &ArtifactHandler{artifactService: artifactService}
+}
+
+// ListMessageArtifacts godoc
+// @Summary      获取消息的产物列表
+// @Description  获取智能体工具为回答生成的图表和文件，回答中的 <artifact id="..."/> 标签引用这些产物。不返回文件内容
+// @Tags         问答
+// @Produce      json
+// @Param        session_id  path      string  true  "会话ID"
+// @Param        message_id  path      string  true  "消息ID"
+// @Success      200         {object}  map[string]interface{}  "产物列表"
+// @Failure      404         {object}  errors.AppError         "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/messages/{message_id}/artifacts [get]
+func (h *ArtifactHandler) ListMessageArtifacts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	artifacts, err := h.artifactService.ListMessageArtifacts(ctx,
+		secutils.SanitizeForLog(c.Param("session_id")), secutils.SanitizeForLog(c.Param("message_id")))
+	if err != nil {
+		h.handleError(c, err, "获取消息产物失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    artifacts,
+	})
+}
+
+// GetArtifact godoc
+// @Summary      获取产物详情
+// @Description  获取产物详情，图表类型包含 Vega-Lite 定义，文件类型通过下载接口获取内容
+// @Tags         问答
+// @Produce      json
+// @Param        session_id   path      string  true  "会话ID"
+// @Param        artifact_id  path      string  true  "产物ID"
+// @Success      200          {object}  map[string]interface{}  "产物详情"
+// @Failure      404          {object}  errors.AppError         "产物不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/artifacts/{artifact_id} [get]
+func (h *ArtifactHandler) GetArtifact(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	artifact, err := h.artifactService.GetArtifact(ctx,
+		secutils.SanitizeForLog(c.Param("session_id")), secutils.SanitizeForLog(c.Param("artifact_id")))
+	if err != nil {
+		h.handleError(c, err, "获取产物失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    artifact,
+	})
+}
+
+// DownloadArtifact godoc
+// @Summary      下载产物
+// @Description  下载 CSV 或 XLSX 产物；图表产物返回其 Vega-Lite 定义（JSON 文件）
+// @Tags         问答
+// @Produce      octet-stream
+// @Param        session_id   path      string  true  "会话ID"
+// @Param        artifact_id  path      string  true  "产物ID"
+// @Success      200          {file}    file             "产物文件"
+// @Failure      404          {object}  errors.AppError  "产物不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/artifacts/{artifact_id}/download [get]
+func (h *ArtifactHandler) DownloadArtifact(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	artifact, err := h.artifactService.GetArtifact(ctx,
+		secutils.SanitizeForLog(c.Param("session_id")), secutils.SanitizeForLog(c.Param("artifact_id")))
+	if err != nil {
+		h.handleError(c, err, "下载产物失败")
+		return
+	}
+
+	data, contentType, fileName := artifact.Content, artifact.ContentType, artifact.FileName
+	if artifact.Type == types.ArtifactChart {
+		data, contentType, fileName = []byte(artifact.Spec), "application/json", artifact.ID+".vl.json"
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func (h *ArtifactHandler) handleError(c *gin.Context, err error, message string) {
+	ctx := c.Request.Context()
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(ctx, err, map[string]interface{}{"path": secutils.SanitizeForLog(c.FullPath())})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
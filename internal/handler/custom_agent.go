@@ -14,13 +14,18 @@ import (
 
 // CustomAgentHandler defines the HTTP handler for custom agent operations
 type CustomAgentHandler struct {
-	service interfaces.CustomAgentService
+	service         interfaces.CustomAgentService
+	transferService interfaces.AgentTransferService
 }
 
 // NewCustomAgentHandler creates a new custom agent handler instance
-func NewCustomAgentHandler(service interfaces.CustomAgentService) *CustomAgentHandler {
+func NewCustomAgentHandler(
+	service interfaces.CustomAgentService,
+	transferService interfaces.AgentTransferService,
+) *CustomAgentHandler {
 	return &CustomAgentHandler{
-		service: service,
+		service:         service,
+		transferService: transferService,
 	}
 }
 
@@ -64,6 +69,18 @@ func (h *CustomAgentHandler) CreateAgent(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if err := req.Config.ToolApprovalPolicy.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if err := req.Config.Budget.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if err := req.Config.ValidateSubAgents(""); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	// Build agent object
 	agent := &types.CustomAgent{
@@ -201,6 +218,18 @@ func (h *CustomAgentHandler) UpdateAgent(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if err := req.Config.ToolApprovalPolicy.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if err := req.Config.Budget.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if err := req.Config.ValidateSubAgents(id); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	// Build agent object
 	agent := &types.CustomAgent{
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Tencent/WeKnora/internal/application/service"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AgentImportRequest defines the request body for previewing or importing an agent export
+type AgentImportRequest struct {
+	// YAML or JSON export document
+	Content  string                        `json:"content" binding:"required"`
+	Mappings []types.AgentReferenceMapping `json:"mappings"`
+	// Name of the imported agent, defaults to the exported name
+	Name string `json:"name"`
+	// Remove unresolved references instead of failing the import
+	SkipUnresolved bool `json:"skip_unresolved"`
+}
+
+// ExportAgent godoc
+// @Summary      导出智能体
+// @Description  将智能体配置导出为 YAML 或 JSON 文件，引用的模型、知识库、MCP 服务等附带名称以便导入时匹配
+// @Tags         智能体
+// @Produce      octet-stream
+// @Param        id      path      string  true   "智能体ID"
+// @Param        format  query     string  false  "导出格式：yaml（默认）或 json"
+// @Success      200     {file}    file    "导出文件"
+// @Failure      400     {object}  errors.AppError  "请求参数错误"
+// @Failure      404     {object}  errors.AppError  "智能体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/{id}/export [get]
+func (h *CustomAgentHandler) ExportAgent(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+	format := c.DefaultQuery("format", "yaml")
+	if format != "yaml" && format != "json" {
+		c.Error(errors.NewBadRequestError("format must be yaml or json"))
+		return
+	}
+
+	export, err := h.transferService.ExportAgent(ctx, id)
+	if err != nil {
+		h.handleVersionError(c, err, "Failed to export agent")
+		return
+	}
+	data, err := types.EncodeAgentExport(export, format)
+	if err != nil {
+		h.handleVersionError(c, err, "Failed to export agent")
+		return
+	}
+
+	contentType := "application/x-yaml"
+	if format == "json" {
+		contentType = "application/json"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"agent-%s.%s\"", id, format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// PreviewAgentImport godoc
+// @Summary      预览智能体导入
+// @Description  解析导出文件，返回每个引用在当前租户中的匹配结果，不创建智能体
+// @Tags         智能体
+// @Accept       json
+// @Produce      json
+// @Param        request  body      AgentImportRequest      true  "导出文件内容及引用映射"
+// @Success      200      {object}  map[string]interface{}  "导入计划"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/import/preview [post]
+func (h *CustomAgentHandler) PreviewAgentImport(c *gin.Context) {
+	ctx := c.Request.Context()
+	req, export, ok := h.bindAgentImport(c)
+	if !ok {
+		return
+	}
+	plan, err := h.transferService.PlanImport(ctx, export, req.Mappings)
+	if err != nil {
+		h.handleVersionError(c, err, "Failed to preview agent import")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
+// ImportAgent godoc
+// @Summary      导入智能体
+// @Description  从导出文件创建智能体，引用按映射、相同ID或唯一同名资源解析
+// @Tags         智能体
+// @Accept       json
+// @Produce      json
+// @Param        request  body      AgentImportRequest      true  "导出文件内容及引用映射"
+// @Success      201      {object}  map[string]interface{}  "导入的智能体"
+// @Failure      400      {object}  errors.AppError         "请求参数错误或存在未解析的引用"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/import [post]
+func (h *CustomAgentHandler) ImportAgent(c *gin.Context) {
+	ctx := c.Request.Context()
+	req, export, ok := h.bindAgentImport(c)
+	if !ok {
+		return
+	}
+	agent, err := h.transferService.ImportAgent(ctx, export, req.Mappings, req.Name, req.SkipUnresolved)
+	if err != nil {
+		h.handleVersionError(c, err, "Failed to import agent")
+		return
+	}
+	logger.Infof(ctx, "Custom agent imported successfully, ID: %s, name: %s",
+		secutils.SanitizeForLog(agent.ID), secutils.SanitizeForLog(agent.Name))
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    agent,
+	})
+}
+
+// ListAgentVersions godoc
+// @Summary      获取智能体版本历史
+// @Description  按版本号倒序返回智能体的全部配置版本
+// @Tags         智能体
+// @Produce      json
+// @Param        id   path      string  true  "智能体ID"
+// @Success      200  {object}  map[string]interface{}  "版本列表"
+// @Failure      404  {object}  errors.AppError         "智能体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/{id}/versions [get]
+func (h *CustomAgentHandler) ListAgentVersions(c *gin.Context) {
+	ctx := c.Request.Context()
+	versions, err := h.service.ListAgentVersions(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		h.handleVersionError(c, err, "Failed to list agent versions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    versions,
+	})
+}
+
+// GetAgentVersion godoc
+// @Summary      获取智能体版本详情
+// @Description  返回智能体某一版本的完整配置
+// @Tags         智能体
+// @Produce      json
+// @Param        id       path      string  true  "智能体ID"
+// @Param        version  path      int     true  "版本号"
+// @Success      200      {object}  map[string]interface{}  "版本详情"
+// @Failure      404      {object}  errors.AppError         "版本不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/{id}/versions/{version} [get]
+func (h *CustomAgentHandler) GetAgentVersion(c *gin.Context) {
+	ctx := c.Request.Context()
+	version, ok := parseAgentVersionParam(c)
+	if !ok {
+		return
+	}
+	v, err := h.service.GetAgentVersion(ctx, secutils.SanitizeForLog(c.Param("id")), version)
+	if err != nil {
+		h.handleVersionError(c, err, "Failed to get agent version")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    v,
+	})
+}
+
+// DiffAgentVersions godoc
+// @Summary      比较智能体版本
+// @Description  列出从 from 版本到该版本之间变化的配置项，from 默认为上一个版本
+// @Tags         智能体
+// @Produce      json
+// @Param        id       path      string  true   "智能体ID"
+// @Param        version  path      int     true   "版本号"
+// @Param        from     query     int     false  "比较的起始版本号"
+// @Success      200      {object}  map[string]interface{}  "配置差异"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "版本不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/{id}/versions/{version}/diff [get]
+func (h *CustomAgentHandler) DiffAgentVersions(c *gin.Context) {
+	ctx := c.Request.Context()
+	version, ok := parseAgentVersionParam(c)
+	if !ok {
+		return
+	}
+	from := version - 1
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			c.Error(errors.NewBadRequestError("from must be a version number"))
+			return
+		}
+		from = parsed
+	}
+	diff, err := h.service.DiffAgentVersions(ctx, secutils.SanitizeForLog(c.Param("id")), from, version)
+	if err != nil {
+		h.handleVersionError(c, err, "Failed to diff agent versions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    diff,
+	})
+}
+
+// RollbackAgent godoc
+// @Summary      回滚智能体
+// @Description  恢复某一版本的配置，并记录为新的版本
+// @Tags         智能体
+// @Produce      json
+// @Param        id       path      string  true  "智能体ID"
+// @Param        version  path      int     true  "版本号"
+// @Success      200      {object}  map[string]interface{}  "回滚后的智能体"
+// @Failure      404      {object}  errors.AppError         "版本不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/{id}/versions/{version}/rollback [post]
+func (h *CustomAgentHandler) RollbackAgent(c *gin.Context) {
+	ctx := c.Request.Context()
+	version, ok := parseAgentVersionParam(c)
+	if !ok {
+		return
+	}
+	id := secutils.SanitizeForLog(c.Param("id"))
+	agent, err := h.service.RollbackAgent(ctx, id, version)
+	if err != nil {
+		h.handleVersionError(c, err, "Failed to roll back agent")
+		return
+	}
+	logger.Infof(ctx, "Custom agent %s rolled back to version %d, now version %d", id, version, agent.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    agent,
+	})
+}
+
+// bindAgentImport parses an import request and its export document
+func (h *CustomAgentHandler) bindAgentImport(c *gin.Context) (*AgentImportRequest, *types.AgentExport, bool) {
+	var req AgentImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return nil, nil, false
+	}
+	export, err := types.DecodeAgentExport([]byte(req.Content))
+	if err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return nil, nil, false
+	}
+	return &req, export, true
+}
+
+func parseAgentVersionParam(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.Error(errors.NewBadRequestError("version must be a positive number"))
+		return 0, false
+	}
+	return version, true
+}
+
+func (h *CustomAgentHandler) handleVersionError(c *gin.Context, err error, message string) {
+	ctx := c.Request.Context()
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	switch err {
+	case service.ErrAgentNotFound:
+		c.Error(errors.NewNotFoundError("Agent not found"))
+		return
+	case service.ErrAgentVersionNotFound:
+		c.Error(errors.NewNotFoundError("Agent version not found"))
+		return
+	case service.ErrCannotModifyBuiltin, service.ErrAgentNameRequired:
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	logger.ErrorWithFields(ctx, err, map[string]interface{}{"path": secutils.SanitizeForLog(c.FullPath())})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// DataSourceHandler 外部数据源管理处理器
+type DataSourceHandler struct {
+	dataSourceService interfaces.DataSourceService
+}
+
+// NewDataSourceHandler 创建外部数据源管理处理器
+func NewDataSourceHandler(dataSourceService interfaces.DataSourceService) *DataSourceHandler {
+	return &DataSourceHandler{dataSourceService: dataSourceService}
+}
+
+// DataSourceRequest 创建/更新外部数据源的请求
+type DataSourceRequest struct {
+	Name        string                     `json:"name"           binding:"required"`
+	Description string                     `json:"description"`
+	Type        types.DataSourceType       `json:"type"           binding:"required"`
+	Connection  types.DataSourceConnection `json:"connection"`
+	// 允许智能体查询的表，可带 schema 前缀，如 sales.orders
+	AllowedTables []string `json:"allowed_tables" binding:"required"`
+	// 单次查询返回的最大行数，0 表示使用默认值
+	RowLimit int   `json:"row_limit"`
+	Enabled  *bool `json:"enabled"` // 默认启用
+}
+
+func (r *DataSourceRequest) toDataSource() *types.DataSource {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return &types.DataSource{
+		Name:          r.Name,
+		Description:   r.Description,
+		Type:          r.Type,
+		Connection:    r.Connection,
+		AllowedTables: r.AllowedTables,
+		RowLimit:      r.RowLimit,
+		Enabled:       enabled,
+	}
+}
+
+// CreateDataSource godoc
+// @Summary      创建外部数据源
+// @Description  注册只读的 PostgreSQL、MySQL 或 SQLite 数据源，智能体只能查询允许列表中的表
+// @Tags         数据源
+// @Accept       json
+// @Produce      json
+// @Param        request  body      DataSourceRequest       true  "数据源信息"
+// @Success      201      {object}  map[string]interface{}  "创建的数据源（不含密码）"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /data-sources [post]
+func (h *DataSourceHandler) CreateDataSource(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req DataSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	dataSource := req.toDataSource()
+	if err := h.dataSourceService.CreateDataSource(ctx, dataSource); err != nil {
+		h.handleError(c, err, "创建数据源失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    dataSource.HideSecret(),
+	})
+}
+
+// ListDataSources godoc
+// @Summary      获取外部数据源列表
+// @Description  获取当前租户的外部数据源，不返回连接密码
+// @Tags         数据源
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "数据源列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /data-sources [get]
+func (h *DataSourceHandler) ListDataSources(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	dataSources, err := h.dataSourceService.ListDataSources(ctx)
+	if err != nil {
+		h.handleError(c, err, "获取数据源列表失败")
+		return
+	}
+	data := make([]*types.DataSource, 0, len(dataSources))
+	for _, dataSource := range dataSources {
+		data = append(data, dataSource.HideSecret())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// GetDataSource godoc
+// @Summary      获取外部数据源详情
+// @Description  获取外部数据源详情，不返回连接密码
+// @Tags         数据源
+// @Produce      json
+// @Param        id   path      string  true  "数据源ID"
+// @Success      200  {object}  map[string]interface{}  "数据源详情"
+// @Failure      404  {object}  errors.AppError         "数据源不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /data-sources/{id} [get]
+func (h *DataSourceHandler) GetDataSource(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	dataSource, err := h.dataSourceService.GetDataSource(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		h.handleError(c, err, "获取数据源失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dataSource.HideSecret(),
+	})
+}
+
+// UpdateDataSource godoc
+// @Summary      更新外部数据源
+// @Description  更新数据源的连接、允许查询的表、行数上限及启用状态，密码为空时保留原密码
+// @Tags         数据源
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "数据源ID"
+// @Param        request  body      DataSourceRequest       true  "数据源信息"
+// @Success      200      {object}  map[string]interface{}  "更新后的数据源"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "数据源不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /data-sources/{id} [put]
+func (h *DataSourceHandler) UpdateDataSource(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req DataSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	dataSource := req.toDataSource()
+	dataSource.ID = secutils.SanitizeForLog(c.Param("id"))
+	updated, err := h.dataSourceService.UpdateDataSource(ctx, dataSource)
+	if err != nil {
+		h.handleError(c, err, "更新数据源失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated.HideSecret(),
+	})
+}
+
+// DeleteDataSource godoc
+// @Summary      删除外部数据源
+// @Description  删除外部数据源，绑定该数据源的智能体不再能查询它
+// @Tags         数据源
+// @Produce      json
+// @Param        id   path      string  true  "数据源ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "数据源不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /data-sources/{id} [delete]
+func (h *DataSourceHandler) DeleteDataSource(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.dataSourceService.DeleteDataSource(ctx, secutils.SanitizeForLog(c.Param("id"))); err != nil {
+		h.handleError(c, err, "删除数据源失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "删除成功",
+	})
+}
+
+// GetDataSourceSchema godoc
+// @Summary      获取外部数据源的表结构
+// @Description  连接数据源并返回允许查询的表的列信息，可用于测试连接。不存在的表返回空列
+// @Tags         数据源
+// @Produce      json
+// @Param        id   path      string  true  "数据源ID"
+// @Success      200  {object}  map[string]interface{}  "表结构"
+// @Failure      404  {object}  errors.AppError         "数据源不存在"
+// @Failure      500  {object}  errors.AppError         "连接数据源失败"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /data-sources/{id}/schema [get]
+func (h *DataSourceHandler) GetDataSourceSchema(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tables, err := h.dataSourceService.DescribeDataSource(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		h.handleError(c, err, "获取数据源表结构失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tables,
+	})
+}
+
+func (h *DataSourceHandler) handleError(c *gin.Context, err error, message string) {
+	ctx := c.Request.Context()
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(ctx, err, map[string]interface{}{"path": secutils.SanitizeForLog(c.FullPath())})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
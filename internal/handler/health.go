@@ -3,7 +3,6 @@ package handler
 import (
 	"context"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -87,7 +86,7 @@ func (h *HealthHandler) HealthCheck(c *gin.Context) {
 	}
 
 	// 检查 Neo4j（如果启用）
-	if h.neo4jDriver != nil && os.Getenv("NEO4J_ENABLE") == "true" {
+	if h.neo4jDriver != nil {
 		neo4jHealth := h.checkNeo4j(ctx)
 		components["neo4j"] = neo4jHealth
 		if neo4jHealth.Status == HealthStatusUnhealthy {
@@ -0,0 +1,410 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// KnowledgeGraphHandler 知识图谱浏览与查询处理器
+type KnowledgeGraphHandler struct {
+	graphService     interfaces.KnowledgeGraphService
+	communityService interfaces.GraphCommunityService
+}
+
+// NewKnowledgeGraphHandler 创建知识图谱处理器
+func NewKnowledgeGraphHandler(
+	graphService interfaces.KnowledgeGraphService,
+	communityService interfaces.GraphCommunityService,
+) *KnowledgeGraphHandler {
+	return &KnowledgeGraphHandler{graphService: graphService, communityService: communityService}
+}
+
+// MergeEntitiesRequest 合并实体的请求
+type MergeEntitiesRequest struct {
+	Sources []string `json:"sources" binding:"required,min=1"`
+	Target  string   `json:"target"  binding:"required"`
+}
+
+// RebuildCommunitiesRequest 重建图谱社区的请求
+type RebuildCommunitiesRequest struct {
+	// 重建前先合并重复实体
+	ResolveEntities bool `json:"resolve_entities"`
+}
+
+// ListGraphEntities godoc
+// @Summary      获取知识图谱实体列表
+// @Description  按名称分页列出知识库图谱中的实体，可按关键词过滤（不区分大小写）
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id         path      string  true   "知识库ID"
+// @Param        keyword    query     string  false  "名称关键词"
+// @Param        page       query     int     false  "页码"
+// @Param        page_size  query     int     false  "每页数量"
+// @Success      200        {object}  map[string]interface{}  "实体列表"
+// @Failure      404        {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entities [get]
+func (h *KnowledgeGraphHandler) ListGraphEntities(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if pagination.Page < 1 {
+		pagination.Page = 1
+	}
+	if pagination.PageSize < 1 {
+		pagination.PageSize = 20
+	}
+
+	result, err := h.graphService.ListEntities(ctx, c.Param("id"), c.Query("keyword"), &pagination)
+	if err != nil {
+		h.handleError(c, err, "获取知识图谱实体列表失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetGraphEntity godoc
+// @Summary      获取知识图谱实体详情
+// @Description  获取实体的属性、来源分块、来源文档及关系数
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id    path      string  true  "知识库ID"
+// @Param        name  query     string  true  "实体名称"
+// @Success      200   {object}  map[string]interface{}  "实体详情"
+// @Failure      404   {object}  errors.AppError         "实体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entity [get]
+func (h *KnowledgeGraphHandler) GetGraphEntity(c *gin.Context) {
+	ctx := c.Request.Context()
+	name, ok := requiredQuery(c, "name")
+	if !ok {
+		return
+	}
+	entity, err := h.graphService.GetEntity(ctx, c.Param("id"), name)
+	if err != nil {
+		h.handleError(c, err, "获取知识图谱实体失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entity,
+	})
+}
+
+// UpdateGraphEntity godoc
+// @Summary      修正知识图谱实体
+// @Description  修改实体名称或替换其属性；改为已有实体的名称时两者合并
+// @Tags         知识图谱
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                   true  "知识库ID"
+// @Param        name     query     string                   true  "实体名称"
+// @Param        request  body      types.GraphEntityUpdate  true  "修改内容"
+// @Success      200      {object}  map[string]interface{}   "修改后的实体"
+// @Failure      400      {object}  errors.AppError          "请求参数错误"
+// @Failure      404      {object}  errors.AppError          "实体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entity [put]
+func (h *KnowledgeGraphHandler) UpdateGraphEntity(c *gin.Context) {
+	ctx := c.Request.Context()
+	name, ok := requiredQuery(c, "name")
+	if !ok {
+		return
+	}
+	var update types.GraphEntityUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	entity, err := h.graphService.UpdateEntity(ctx, c.Param("id"), name, &update)
+	if err != nil {
+		h.handleError(c, err, "修正知识图谱实体失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entity,
+	})
+}
+
+// MergeGraphEntities godoc
+// @Summary      合并知识图谱实体
+// @Description  将多个实体合并到目标实体，合并来源分块、属性及关系；目标实体不存在时自动创建
+// @Tags         知识图谱
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "知识库ID"
+// @Param        request  body      MergeEntitiesRequest    true  "合并的实体及目标实体"
+// @Success      200      {object}  map[string]interface{}  "合并后的实体"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "实体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entities/merge [post]
+func (h *KnowledgeGraphHandler) MergeGraphEntities(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req MergeEntitiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	entity, err := h.graphService.MergeEntities(ctx, c.Param("id"), req.Sources, req.Target)
+	if err != nil {
+		h.handleError(c, err, "合并知识图谱实体失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entity,
+	})
+}
+
+// GetGraphNeighbourhood godoc
+// @Summary      获取实体邻域
+// @Description  返回与实体相距不超过 depth 跳的实体及其间的关系
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id     path      string  true   "知识库ID"
+// @Param        name   query     string  true   "实体名称"
+// @Param        depth  query     int     false  "跳数，1-3，默认 1"
+// @Success      200    {object}  map[string]interface{}  "邻域子图"
+// @Failure      404    {object}  errors.AppError         "实体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/neighbourhood [get]
+func (h *KnowledgeGraphHandler) GetGraphNeighbourhood(c *gin.Context) {
+	ctx := c.Request.Context()
+	name, ok := requiredQuery(c, "name")
+	if !ok {
+		return
+	}
+	depth, ok := intQuery(c, "depth")
+	if !ok {
+		return
+	}
+	graph, err := h.graphService.GetNeighbourhood(ctx, c.Param("id"), name, depth)
+	if err != nil {
+		h.handleError(c, err, "获取实体邻域失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    graph,
+	})
+}
+
+// FindGraphPath godoc
+// @Summary      查找实体间最短路径
+// @Description  查找两个实体之间不超过 max_depth 跳的最短路径
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id         path      string  true   "知识库ID"
+// @Param        from       query     string  true   "起点实体名称"
+// @Param        to         query     string  true   "终点实体名称"
+// @Param        max_depth  query     int     false  "最大跳数，1-6，默认 4"
+// @Success      200        {object}  map[string]interface{}  "路径"
+// @Failure      404        {object}  errors.AppError         "实体不存在或没有路径"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/path [get]
+func (h *KnowledgeGraphHandler) FindGraphPath(c *gin.Context) {
+	ctx := c.Request.Context()
+	from, ok := requiredQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := requiredQuery(c, "to")
+	if !ok {
+		return
+	}
+	maxDepth, ok := intQuery(c, "max_depth")
+	if !ok {
+		return
+	}
+	path, err := h.graphService.FindPath(ctx, c.Param("id"), from, to, maxDepth)
+	if err != nil {
+		h.handleError(c, err, "查找实体路径失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"length":   path.Length(),
+			"node":     path.Node,
+			"relation": path.Relation,
+		},
+	})
+}
+
+// ListGraphEntityChunks godoc
+// @Summary      获取实体来源分块
+// @Description  列出抽取出该实体的分块（最多 100 个）
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id    path      string  true  "知识库ID"
+// @Param        name  query     string  true  "实体名称"
+// @Success      200   {object}  map[string]interface{}  "分块列表"
+// @Failure      404   {object}  errors.AppError         "实体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/entity/chunks [get]
+func (h *KnowledgeGraphHandler) ListGraphEntityChunks(c *gin.Context) {
+	ctx := c.Request.Context()
+	name, ok := requiredQuery(c, "name")
+	if !ok {
+		return
+	}
+	chunks, err := h.graphService.ListEntityChunks(ctx, c.Param("id"), name)
+	if err != nil {
+		h.handleError(c, err, "获取实体来源分块失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    chunks,
+	})
+}
+
+// ListGraphRelationChunks godoc
+// @Summary      获取关系来源分块
+// @Description  列出抽取出两个实体间关系的分块（最多 100 个），可按关系类型过滤
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id      path      string  true   "知识库ID"
+// @Param        source  query     string  true   "实体名称"
+// @Param        target  query     string  true   "另一实体名称"
+// @Param        type    query     string  false  "关系类型"
+// @Success      200     {object}  map[string]interface{}  "分块列表"
+// @Failure      404     {object}  errors.AppError         "实体或关系不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/relation/chunks [get]
+func (h *KnowledgeGraphHandler) ListGraphRelationChunks(c *gin.Context) {
+	ctx := c.Request.Context()
+	source, ok := requiredQuery(c, "source")
+	if !ok {
+		return
+	}
+	target, ok := requiredQuery(c, "target")
+	if !ok {
+		return
+	}
+	chunks, err := h.graphService.ListRelationChunks(ctx, c.Param("id"), source, target, c.Query("type"))
+	if err != nil {
+		h.handleError(c, err, "获取关系来源分块失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    chunks,
+	})
+}
+
+// ListGraphCommunities godoc
+// @Summary      获取知识图谱社区列表
+// @Description  列出知识库图谱的社区及其标题、摘要和实体，实体多的在前
+// @Tags         知识图谱
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "社区列表"
+// @Failure      404  {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/communities [get]
+func (h *KnowledgeGraphHandler) ListGraphCommunities(c *gin.Context) {
+	ctx := c.Request.Context()
+	communities, err := h.communityService.ListCommunities(ctx, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err, "获取知识图谱社区列表失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    communities,
+	})
+}
+
+// RebuildGraphCommunities godoc
+// @Summary      重建知识图谱社区
+// @Description  排队重建知识库的图谱社区：可选先合并重复实体，再进行社区发现并由摘要模型生成社区摘要，完成后替换原有社区
+// @Tags         知识图谱
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                     true   "知识库ID"
+// @Param        request  body      RebuildCommunitiesRequest  false  "重建选项"
+// @Success      202      {object}  map[string]interface{}     "重建任务"
+// @Failure      400      {object}  errors.AppError            "未启用图谱抽取或未配置摘要模型"
+// @Failure      409      {object}  errors.AppError            "重建任务进行中"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/graph/communities/rebuild [post]
+func (h *KnowledgeGraphHandler) RebuildGraphCommunities(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req RebuildCommunitiesRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+			return
+		}
+	}
+	rebuild, err := h.communityService.RebuildCommunities(ctx, c.Param("id"), req.ResolveEntities)
+	if err != nil {
+		h.handleError(c, err, "重建知识图谱社区失败")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    rebuild,
+	})
+}
+
+func (h *KnowledgeGraphHandler) handleError(c *gin.Context, err error, message string) {
+	ctx := c.Request.Context()
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(ctx, err, map[string]interface{}{"path": secutils.SanitizeForLog(c.FullPath())})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
+
+// requiredQuery returns a query parameter, failing the request if it is empty
+func requiredQuery(c *gin.Context, key string) (string, bool) {
+	value := c.Query(key)
+	if value == "" {
+		c.Error(errors.NewBadRequestError(key + " is required"))
+		return "", false
+	}
+	return value, true
+}
+
+// intQuery returns an integer query parameter, 0 if absent
+func intQuery(c *gin.Context, key string) (int, bool) {
+	raw := c.Query(key)
+	if raw == "" {
+		return 0, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		c.Error(errors.NewBadRequestError(key + " must be a number"))
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// MemoryHandler 用户长期记忆管理处理器
+type MemoryHandler struct {
+	memoryService interfaces.UserMemoryService
+}
+
+// NewMemoryHandler 创建用户长期记忆管理处理器
+func NewMemoryHandler(memoryService interfaces.UserMemoryService) *MemoryHandler {
+	return &MemoryHandler{memoryService: memoryService}
+}
+
+// MemoryRequest 创建/更新记忆的请求
+type MemoryRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// ListMemories godoc
+// @Summary      获取长期记忆
+// @Description  获取智能体记住的当前用户的长期记忆，按更新时间倒序，仅支持登录用户
+// @Tags         记忆
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "记忆列表"
+// @Failure      403  {object}  errors.AppError         "未登录用户"
+// @Security     Bearer
+// @Router       /memories [get]
+func (h *MemoryHandler) ListMemories(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	memories, err := h.memoryService.ListMemories(ctx)
+	if err != nil {
+		h.handleError(c, err, "获取记忆失败")
+		return
+	}
+	if memories == nil {
+		memories = []*types.UserMemory{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    memories,
+	})
+}
+
+// CreateMemory godoc
+// @Summary      添加长期记忆
+// @Description  为当前用户手动添加一条记忆
+// @Tags         记忆
+// @Accept       json
+// @Produce      json
+// @Param        request  body      MemoryRequest           true  "记忆内容"
+// @Success      201      {object}  map[string]interface{}  "创建的记忆"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      403      {object}  errors.AppError         "未登录用户"
+// @Security     Bearer
+// @Router       /memories [post]
+func (h *MemoryHandler) CreateMemory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req MemoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	memory, err := h.memoryService.CreateMemory(ctx, req.Content)
+	if err != nil {
+		h.handleError(c, err, "添加记忆失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    memory,
+	})
+}
+
+// UpdateMemory godoc
+// @Summary      修改长期记忆
+// @Description  修改当前用户的一条记忆
+// @Tags         记忆
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "记忆ID"
+// @Param        request  body      MemoryRequest           true  "记忆内容"
+// @Success      200      {object}  map[string]interface{}  "修改后的记忆"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "记忆不存在"
+// @Security     Bearer
+// @Router       /memories/{id} [put]
+func (h *MemoryHandler) UpdateMemory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req MemoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	memory, err := h.memoryService.UpdateMemory(ctx, secutils.SanitizeForLog(c.Param("id")), req.Content)
+	if err != nil {
+		h.handleError(c, err, "修改记忆失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    memory,
+	})
+}
+
+// DeleteMemory godoc
+// @Summary      删除长期记忆
+// @Description  删除当前用户的一条记忆
+// @Tags         记忆
+// @Produce      json
+// @Param        id   path      string  true  "记忆ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "记忆不存在"
+// @Security     Bearer
+// @Router       /memories/{id} [delete]
+func (h *MemoryHandler) DeleteMemory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.memoryService.DeleteMemory(ctx, secutils.SanitizeForLog(c.Param("id"))); err != nil {
+		h.handleError(c, err, "删除记忆失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "删除成功",
+	})
+}
+
+// ClearMemories godoc
+// @Summary      清空长期记忆
+// @Description  删除当前用户的全部记忆
+// @Tags         记忆
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "清空成功"
+// @Failure      403  {object}  errors.AppError         "未登录用户"
+// @Security     Bearer
+// @Router       /memories [delete]
+func (h *MemoryHandler) ClearMemories(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.memoryService.ClearMemories(ctx); err != nil {
+		h.handleError(c, err, "清空记忆失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "清空成功",
+	})
+}
+
+func (h *MemoryHandler) handleError(c *gin.Context, err error, message string) {
+	ctx := c.Request.Context()
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(ctx, err, map[string]interface{}{"path": secutils.SanitizeForLog(c.FullPath())})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
@@ -1,14 +1,19 @@
 package handler
 
 import (
+	"context"
+	stderrors "errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
 )
@@ -16,17 +21,27 @@ import (
 // MessageHandler handles HTTP requests related to messages within chat sessions
 // It provides endpoints for loading and managing message history
 type MessageHandler struct {
-	MessageService interfaces.MessageService // Service that implements message business logic
+	MessageService       interfaces.MessageService       // Service that implements message business logic
+	WebhookService       interfaces.WebhookService       // Service that notifies webhook subscribers of low ratings
+	MessageSearchService interfaces.MessageSearchService // Service that searches the chat history
 }
 
 // NewMessageHandler creates a new message handler instance with the required service
 // Parameters:
 //   - messageService: Service that implements message business logic
+//   - webhookService: Service that notifies webhook subscribers of low ratings
+//   - messageSearchService: Service that searches the chat history
 //
 // Returns a pointer to a new MessageHandler
-func NewMessageHandler(messageService interfaces.MessageService) *MessageHandler {
+func NewMessageHandler(
+	messageService interfaces.MessageService,
+	webhookService interfaces.WebhookService,
+	messageSearchService interfaces.MessageSearchService,
+) *MessageHandler {
 	return &MessageHandler{
-		MessageService: messageService,
+		MessageService:       messageService,
+		WebhookService:       webhookService,
+		MessageSearchService: messageSearchService,
 	}
 }
 
@@ -74,6 +89,8 @@ func (h *MessageHandler) LoadMessages(c *gin.Context) {
 			return
 		}
 
+		h.fillSiblingIDs(ctx, sessionID, messages)
+
 		logger.Infof(
 			ctx,
 			"Successfully retrieved recent messages, session ID: %s, message count: %d",
@@ -108,6 +125,8 @@ func (h *MessageHandler) LoadMessages(c *gin.Context) {
 		return
 	}
 
+	h.fillSiblingIDs(ctx, sessionID, messages)
+
 	logger.Infof(
 		ctx,
 		"Successfully retrieved messages before time, session ID: %s, message count: %d",
@@ -119,6 +138,29 @@ func (h *MessageHandler) LoadMessages(c *gin.Context) {
 	})
 }
 
+// fillSiblingIDs marks messages that have alternative versions (edited questions or
+// regenerated answers) with the IDs of all versions, so clients can switch between branches
+func (h *MessageHandler) fillSiblingIDs(ctx context.Context, sessionID string, messages []*types.Message) {
+	if len(messages) == 0 {
+		return
+	}
+	parentIDs := make([]string, 0, len(messages))
+	for _, message := range messages {
+		parentIDs = append(parentIDs, message.ParentID)
+	}
+	children, err := h.MessageService.GetChildMessageIDs(ctx, sessionID, parentIDs)
+	if err != nil {
+		// Sibling information is optional, the messages themselves are still returned
+		logger.Warnf(ctx, "Failed to get sibling messages, session ID: %s, error: %v", sessionID, err)
+		return
+	}
+	for _, message := range messages {
+		if siblings := children[message.ParentID]; len(siblings) > 1 {
+			message.SiblingIDs = siblings
+		}
+	}
+}
+
 // DeleteMessage godoc
 // @Summary      删除消息
 // @Description  从会话中删除指定消息
@@ -156,3 +198,140 @@ func (h *MessageHandler) DeleteMessage(c *gin.Context) {
 		"message": "Message deleted successfully",
 	})
 }
+
+// RateMessageRequest is the request body of rating an assistant message
+type RateMessageRequest struct {
+	Rating  int    `json:"rating"  binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// lowRatedExcerptLength limits the answer excerpt sent with the message.low_rated event
+const lowRatedExcerptLength = 500
+
+// RateMessage godoc
+// @Summary      评价回答
+// @Description  为助手回答打分（1-5），评分不高于 2 时触发 message.low_rated webhook 事件
+// @Tags         消息
+// @Accept       json
+// @Produce      json
+// @Param        session_id  path      string              true  "会话ID"
+// @Param        id          path      string              true  "消息ID"
+// @Param        request     body      RateMessageRequest  true  "评分"
+// @Success      200         {object}  map[string]interface{}  "评价后的消息"
+// @Failure      400         {object}  errors.AppError         "请求参数错误"
+// @Failure      404         {object}  errors.AppError         "消息不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /messages/{session_id}/{id}/rating [put]
+func (h *MessageHandler) RateMessage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	messageID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req RateMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if req.Rating < types.MinMessageRating || req.Rating > types.MaxMessageRating {
+		c.Error(errors.NewBadRequestError(
+			fmt.Sprintf("rating must be between %d and %d", types.MinMessageRating, types.MaxMessageRating),
+		))
+		return
+	}
+
+	message, err := h.MessageService.GetMessage(ctx, sessionID, messageID)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(errors.NewNotFoundError("Message not found"))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	if message.Role != "assistant" {
+		c.Error(errors.NewBadRequestError("only assistant messages can be rated"))
+		return
+	}
+
+	message.Rating = &req.Rating
+	message.RatingComment = req.Comment
+	if err := h.MessageService.UpdateMessage(ctx, message); err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	logger.Infof(ctx, "Message rated, session ID: %s, message ID: %s, rating: %d", sessionID, messageID, req.Rating)
+
+	if req.Rating <= types.LowMessageRating {
+		excerpt := []rune(message.Content)
+		if len(excerpt) > lowRatedExcerptLength {
+			excerpt = excerpt[:lowRatedExcerptLength]
+		}
+		h.WebhookService.Publish(ctx, ctx.Value(types.TenantIDContextKey).(uint64),
+			types.WebhookEventMessageLowRated, map[string]interface{}{
+				"session_id": message.SessionID,
+				"message_id": message.ID,
+				"request_id": message.RequestID,
+				"rating":     req.Rating,
+				"comment":    req.Comment,
+				"content":    string(excerpt),
+			})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    message,
+	})
+}
+
+// SearchMessages godoc
+// @Summary      搜索聊天记录
+// @Description  按关键词搜索当前租户的消息内容及会话标题，支持按时间、角色、智能体及使用的知识库筛选，返回高亮摘要及定位到消息的参数
+// @Tags         消息
+// @Produce      json
+// @Param        query              query     string  true   "关键词，多个关键词以空格分隔，需全部命中"
+// @Param        role               query     string  false  "消息角色：user 或 assistant"
+// @Param        agent_id           query     string  false  "回答问题的智能体ID"
+// @Param        knowledge_base_id  query     string  false  "引用或@提及的知识库ID"
+// @Param        start_time         query     string  false  "起始时间（RFC3339格式）"
+// @Param        end_time           query     string  false  "结束时间（RFC3339格式）"
+// @Param        page               query     int     false  "页码"
+// @Param        page_size          query     int     false  "每页数量"
+// @Success      200                {object}  map[string]interface{}  "搜索结果"
+// @Failure      400                {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /messages/search [get]
+func (h *MessageHandler) SearchMessages(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var page types.Pagination
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.Error(errors.NewBadRequestError("分页参数不合法").WithDetails(err.Error()))
+		return
+	}
+	var params types.MessageSearchParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.Error(errors.NewBadRequestError("搜索参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	result, err := h.MessageSearchService.SearchMessages(ctx, &params, &page)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
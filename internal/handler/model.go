@@ -51,6 +51,7 @@ func hideSensitiveInfo(model *types.Model) *types.Model {
 			// Keep other parameters like embedding dimensions
 			EmbeddingParameters: model.Parameters.EmbeddingParameters,
 			ParameterSize:       model.Parameters.ParameterSize,
+			SupportsVision:      model.Parameters.SupportsVision,
 		},
 		IsBuiltin: model.IsBuiltin,
 		Status:    model.Status,
@@ -342,6 +343,9 @@ func (h *ModelHandler) UpdateModel(c *gin.Context) {
 	if req.Parameters.ParameterSize != "" {
 		model.Parameters.ParameterSize = req.Parameters.ParameterSize
 	}
+	if req.Parameters.SupportsVision != nil {
+		model.Parameters.SupportsVision = req.Parameters.SupportsVision
+	}
 	// Update embedding parameters if provided
 	if req.Parameters.EmbeddingParameters.Dimension > 0 {
 		model.Parameters.EmbeddingParameters.Dimension = req.Parameters.EmbeddingParameters.Dimension
@@ -60,6 +60,8 @@ func (h *AgentStreamHandler) Subscribe() {
 	h.eventBus.On(event.EventAgentThought, h.handleThought)
 	h.eventBus.On(event.EventAgentToolCall, h.handleToolCall)
 	h.eventBus.On(event.EventAgentToolResult, h.handleToolResult)
+	h.eventBus.On(event.EventAgentToolApproval, h.handleToolApproval)
+	h.eventBus.On(event.EventAgentArtifact, h.handleArtifact)
 	h.eventBus.On(event.EventAgentReferences, h.handleReferences)
 	h.eventBus.On(event.EventAgentFinalAnswer, h.handleFinalAnswer)
 	h.eventBus.On(event.EventAgentReflection, h.handleReflection)
@@ -68,6 +70,25 @@ func (h *AgentStreamHandler) Subscribe() {
 	h.eventBus.On(event.EventAgentComplete, h.handleComplete)
 }
 
+// withNesting adds the nesting of events forwarded from sub-agents (depth, delegating tool call and agent)
+// to the stream event data, so that the frontend can show them as nested steps
+func withNesting(evt event.Event, data map[string]interface{}) map[string]interface{} {
+	if _, nested := evt.Metadata[event.MetadataDepth]; !nested {
+		return data
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	for _, key := range []string{
+		event.MetadataDepth, event.MetadataParentToolCallID, event.MetadataAgentID, event.MetadataAgentName,
+	} {
+		if value, ok := evt.Metadata[key]; ok {
+			data[key] = value
+		}
+	}
+	return data
+}
+
 // handleThought handles agent thought events
 func (h *AgentStreamHandler) handleThought(ctx context.Context, evt event.Event) error {
 	data, ok := evt.Data.(event.AgentThoughtData)
@@ -108,7 +129,7 @@ func (h *AgentStreamHandler) handleThought(ctx context.Context, evt event.Event)
 		Content:   data.Content, // Just this chunk
 		Done:      data.Done,
 		Timestamp: time.Now(),
-		Data:      metadata,
+		Data:      withNesting(evt, metadata),
 	}); err != nil {
 		logger.GetLogger(h.ctx).Error("Append thought event to stream failed", "error", err)
 	}
@@ -141,7 +162,7 @@ func (h *AgentStreamHandler) handleToolCall(ctx context.Context, evt event.Event
 		Content:   fmt.Sprintf("Calling tool: %s", data.ToolName),
 		Done:      false,
 		Timestamp: time.Now(),
-		Data:      metadata,
+		Data:      withNesting(evt, metadata),
 	}); err != nil {
 		logger.GetLogger(h.ctx).Error("Append tool call event to stream failed", "error", err)
 	}
@@ -202,7 +223,7 @@ func (h *AgentStreamHandler) handleToolResult(ctx context.Context, evt event.Eve
 		Content:   content,
 		Done:      false,
 		Timestamp: time.Now(),
-		Data:      metadata,
+		Data:      withNesting(evt, metadata),
 	}); err != nil {
 		logger.GetLogger(h.ctx).Error("Append tool result event to stream failed", "error", err)
 	}
@@ -210,6 +231,81 @@ func (h *AgentStreamHandler) handleToolResult(ctx context.Context, evt event.Eve
 	return nil
 }
 
+// handleToolApproval handles tool approval request and decision events
+// They are kept in the stream so that a reconnecting client (ContinueStream) still sees a pending approval
+func (h *AgentStreamHandler) handleToolApproval(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentToolApprovalData)
+	if !ok {
+		return nil
+	}
+
+	content := fmt.Sprintf("Tool %s is waiting for approval", data.ToolName)
+	if data.Status != string(types.ToolApprovalPending) {
+		content = fmt.Sprintf("Tool %s %s", data.ToolName, data.Status)
+	}
+
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeToolApproval,
+		Content:   content,
+		Done:      data.Status != string(types.ToolApprovalPending),
+		Timestamp: time.Now(),
+		Data: withNesting(evt, map[string]interface{}{
+			"approval_id":  data.ApprovalID,
+			"tool_call_id": data.ToolCallID,
+			"tool_name":    data.ToolName,
+			"arguments":    data.Arguments,
+			"status":       data.Status,
+			"reason":       data.Reason,
+			"expires_at":   data.ExpiresAt,
+		}),
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append tool approval event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
+// handleArtifact handles events of charts and files attached to the assistant message
+func (h *AgentStreamHandler) handleArtifact(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentArtifactData)
+	if !ok {
+		return nil
+	}
+
+	content := data.Title
+	if content == "" {
+		content = data.FileName
+	}
+	payload := map[string]interface{}{
+		"artifact_id":  data.ArtifactID,
+		"tool_call_id": data.ToolCallID,
+		"type":         data.Type,
+		"title":        data.Title,
+		"row_count":    data.RowCount,
+	}
+	if len(data.Spec) > 0 {
+		payload["spec"] = data.Spec
+	}
+	if data.FileName != "" {
+		payload["file_name"] = data.FileName
+		payload["size"] = data.Size
+	}
+
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeArtifact,
+		Content:   content,
+		Done:      true,
+		Timestamp: time.Now(),
+		Data:      withNesting(evt, payload),
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append artifact event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
 // handleReferences handles knowledge references events
 func (h *AgentStreamHandler) handleReferences(ctx context.Context, evt event.Event) error {
 	data, ok := evt.Data.(event.AgentReferencesData)
@@ -338,6 +434,7 @@ func (h *AgentStreamHandler) handleReflection(ctx context.Context, evt event.Eve
 		Content:   data.Content, // Just this chunk
 		Done:      data.Done,
 		Timestamp: time.Now(),
+		Data:      withNesting(evt, nil),
 	}); err != nil {
 		logger.GetLogger(h.ctx).Error("Append reflection event to stream failed", "error", err)
 	}
@@ -445,6 +542,7 @@ func (h *AgentStreamHandler) handleComplete(ctx context.Context, evt event.Event
 		Data: map[string]interface{}{
 			"total_steps":       data.TotalSteps,
 			"total_duration_ms": data.TotalDurationMs,
+			"usage":             data.Usage,
 		},
 	}); err != nil {
 		logger.GetLogger(h.ctx).Errorf("Append complete event to stream failed: %v", err)
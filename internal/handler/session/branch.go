@@ -0,0 +1,179 @@
+package session
+
+import (
+	stderrors "errors"
+	"io"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// getBranchMessage gets the message a branch operation starts from and checks its role
+func (h *Handler) getBranchMessage(c *gin.Context, sessionID, messageID, role string) (*types.Message, error) {
+	ctx := c.Request.Context()
+	message, err := h.messageService.GetMessage(ctx, sessionID, messageID)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewNotFoundError("Message not found")
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		return nil, errors.NewInternalServerError(err.Error())
+	}
+	if role != "" && message.Role != role {
+		return nil, errors.NewBadRequestError("message must be a " + role + " message")
+	}
+	return message, nil
+}
+
+// EditMessage godoc
+// @Summary      编辑问题并重新提问
+// @Description  以新的问题替换指定的用户消息并重新回答，原问题及其回答作为另一分支保留，支持SSE流式响应
+// @Tags         问答
+// @Accept       json
+// @Produce      text/event-stream
+// @Param        session_id  path      string                   true  "会话ID"
+// @Param        message_id  path      string                   true  "要编辑的用户消息ID"
+// @Param        request     body      CreateKnowledgeQARequest true  "问答请求"
+// @Success      200         {object}  map[string]interface{}   "问答结果（SSE流）"
+// @Failure      400         {object}  errors.AppError          "请求参数错误"
+// @Failure      404         {object}  errors.AppError          "消息不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/messages/{message_id}/edit [post]
+func (h *Handler) EditMessage(c *gin.Context) {
+	reqCtx, request, err := h.parseQARequest(c, "EditMessage")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	original, err := h.getBranchMessage(c, reqCtx.sessionID, secutils.SanitizeForLog(c.Param("message_id")), "user")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	logger.Infof(reqCtx.ctx, "Editing message %s, new branch from parent %s", original.ID, original.ParentID)
+
+	// The edited question becomes a sibling of the original one
+	reqCtx.parentMessageID = original.ParentID
+	reqCtx.branched = true
+	h.executeQA(reqCtx, request.AgentEnabled)
+}
+
+// RegenerateMessage godoc
+// @Summary      重新生成回答
+// @Description  针对同一问题重新生成回答，可指定其他智能体或模型，原回答作为另一分支保留，支持SSE流式响应
+// @Tags         问答
+// @Accept       json
+// @Produce      text/event-stream
+// @Param        session_id  path      string                    true   "会话ID"
+// @Param        message_id  path      string                    true   "要重新生成的助手消息ID（或其对应的用户消息ID）"
+// @Param        request     body      RegenerateMessageRequest  false  "重新生成请求"
+// @Success      200         {object}  map[string]interface{}    "问答结果（SSE流）"
+// @Failure      400         {object}  errors.AppError           "请求参数错误"
+// @Failure      404         {object}  errors.AppError           "消息不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/messages/{message_id}/regenerate [post]
+func (h *Handler) RegenerateMessage(c *gin.Context) {
+	ctx := logger.CloneContext(c.Request.Context())
+
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	if sessionID == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	// The request body is optional
+	var request RegenerateMessageRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !stderrors.Is(err, io.EOF) {
+		logger.Error(ctx, "Failed to parse request data", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	// Resolve the question to answer again
+	userMessage, err := h.getBranchMessage(c, sessionID, secutils.SanitizeForLog(c.Param("message_id")), "")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if userMessage.Role != "user" {
+		if userMessage.ParentID == "" {
+			c.Error(errors.NewBadRequestError("message has no question to regenerate an answer for"))
+			return
+		}
+		if userMessage, err = h.getBranchMessage(c, sessionID, userMessage.ParentID, "user"); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	logger.Infof(ctx, "Regenerating answer for message %s, summary model: %s, agent: %s",
+		userMessage.ID, secutils.SanitizeForLog(request.SummaryModelID), secutils.SanitizeForLog(request.AgentID))
+
+	reqCtx, err := h.newQARequestContext(ctx, c, sessionID, &CreateKnowledgeQARequest{
+		Query:            userMessage.Content,
+		KnowledgeBaseIDs: request.KnowledgeBaseIDs,
+		KnowledgeIds:     request.KnowledgeIds,
+		AgentEnabled:     request.AgentEnabled,
+		AgentID:          request.AgentID,
+		WebSearchEnabled: request.WebSearchEnabled,
+		SummaryModelID:   request.SummaryModelID,
+	}, userMessage.Images)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// The new answer becomes a sibling of the previous answers, sharing the question's request ID
+	// so that history loading pairs them
+	reqCtx.userMessage = userMessage
+	reqCtx.mentionedItems = userMessage.MentionedItems
+	reqCtx.assistantMessage.RequestID = userMessage.RequestID
+	reqCtx.branched = true
+	h.executeQA(reqCtx, request.AgentEnabled)
+}
+
+// ActivateMessage godoc
+// @Summary      切换分支
+// @Description  将会话的当前分支切换到经过指定消息的最近一条路径，之后的提问与历史加载均沿该分支进行
+// @Tags         会话
+// @Produce      json
+// @Param        session_id  path      string  true  "会话ID"
+// @Param        message_id  path      string  true  "消息ID"
+// @Success      200         {object}  map[string]interface{}  "切换后分支的最后一条消息"
+// @Failure      404         {object}  errors.AppError         "消息不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/messages/{message_id}/activate [post]
+func (h *Handler) ActivateMessage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	messageID := secutils.SanitizeForLog(c.Param("message_id"))
+
+	leaf, err := h.messageService.ActivateMessage(ctx, sessionID, messageID)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(errors.NewNotFoundError("Message not found"))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	if err := h.sessionService.RebuildContext(ctx, sessionID); err != nil {
+		logger.Warnf(ctx, "Failed to rebuild context for session %s: %v", sessionID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    leaf,
+	})
+}
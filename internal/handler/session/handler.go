@@ -20,6 +20,7 @@ type Handler struct {
 	config               *config.Config                  // Application configuration
 	knowledgebaseService interfaces.KnowledgeBaseService // Service for managing knowledge bases
 	customAgentService   interfaces.CustomAgentService   // Service for managing custom agents
+	fileService          interfaces.FileService          // Service for storing image attachments
 }
 
 // NewHandler creates a new instance of Handler with all necessary dependencies
@@ -30,6 +31,7 @@ func NewHandler(
 	config *config.Config,
 	knowledgebaseService interfaces.KnowledgeBaseService,
 	customAgentService interfaces.CustomAgentService,
+	fileService interfaces.FileService,
 ) *Handler {
 	return &Handler{
 		sessionService:       sessionService,
@@ -38,6 +40,7 @@ func NewHandler(
 		config:               config,
 		knowledgebaseService: knowledgebaseService,
 		customAgentService:   customAgentService,
+		fileService:          fileService,
 	}
 }
 
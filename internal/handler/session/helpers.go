@@ -121,18 +121,38 @@ func createAgentQueryEvent(sessionID, assistantMessageID string) interfaces.Stre
 	}
 }
 
-// createUserMessage creates a user message
-func (h *Handler) createUserMessage(ctx context.Context, sessionID, query, requestID string, mentionedItems types.MentionedItems) error {
-	_, err := h.messageService.CreateMessage(ctx, &types.Message{
+// createUserMessage creates a user message as a child of parentID
+// agentID is the custom agent the question is asked to, empty if none was selected
+func (h *Handler) createUserMessage(ctx context.Context, sessionID, query, requestID, parentID, agentID string,
+	mentionedItems types.MentionedItems, images types.MessageImages,
+) (*types.Message, error) {
+	return h.messageService.CreateMessage(ctx, &types.Message{
 		SessionID:      sessionID,
+		ParentID:       parentID,
 		Role:           "user",
+		AgentID:        agentID,
 		Content:        query,
 		RequestID:      requestID,
 		CreatedAt:      time.Now(),
 		IsCompleted:    true,
 		MentionedItems: mentionedItems,
+		Images:         images,
 	})
-	return err
+}
+
+// saveImageCaptions persists captions generated during QA back to the user message
+func (h *Handler) saveImageCaptions(ctx context.Context, userMessage *types.Message) {
+	if userMessage == nil {
+		return
+	}
+	for _, image := range userMessage.Images {
+		if image.Caption != "" {
+			if err := h.messageService.UpdateMessage(ctx, userMessage); err != nil {
+				logger.Warnf(ctx, "Failed to save image captions for message %s: %v", userMessage.ID, err)
+			}
+			return
+		}
+	}
 }
 
 // createAssistantMessage creates an assistant message
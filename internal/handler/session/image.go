@@ -0,0 +1,185 @@
+package session
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxImageAttachments is the maximum number of images attached to a single QA request
+	maxImageAttachments = 5
+	// maxImageUploadSize is the maximum size of an uploaded image attachment (10MB)
+	maxImageUploadSize = 10 << 20
+)
+
+// allowedImageExtensions lists the image types accepted as chat attachments
+var allowedImageExtensions = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".bmp":  "image/bmp",
+}
+
+// isSessionImagePath checks that a storage path was produced by uploading an image to the given session
+// All file services store objects under ".../{tenantID}/{sessionID}/..."
+func isSessionImagePath(filePath string, tenantID uint64, sessionID string) bool {
+	if filePath == "" || strings.Contains(filePath, "..") {
+		return false
+	}
+	return strings.Contains("/"+filePath, fmt.Sprintf("/%d/%s/", tenantID, sessionID))
+}
+
+// convertImageAttachments validates image attachments of a QA request and converts them to types.MessageImages
+func convertImageAttachments(items []ImageAttachmentRequest, tenantID uint64, sessionID string) (types.MessageImages, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	if len(items) > maxImageAttachments {
+		return nil, fmt.Errorf("at most %d images can be attached", maxImageAttachments)
+	}
+	result := make(types.MessageImages, 0, len(items))
+	for _, item := range items {
+		if !isSessionImagePath(item.FilePath, tenantID, sessionID) {
+			return nil, fmt.Errorf("invalid image file path: %s", secutils.SanitizeForLog(item.FilePath))
+		}
+		mimeType := item.MimeType
+		if mimeType == "" {
+			mimeType = allowedImageExtensions[strings.ToLower(filepath.Ext(item.FilePath))]
+		}
+		result = append(result, types.MessageImage{
+			FilePath: item.FilePath,
+			FileName: secutils.SanitizeForLog(item.FileName),
+			MimeType: mimeType,
+		})
+	}
+	return result, nil
+}
+
+// UploadImage godoc
+// @Summary      上传对话图片
+// @Description  上传图片附件（如报错截图），返回的 file_path 可在问答请求的 images 字段中使用
+// @Tags         会话
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        session_id  path      string  true  "会话ID"
+// @Param        file        formData  file    true  "图片文件"
+// @Success      200         {object}  map[string]interface{}  "图片信息"
+// @Failure      400         {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/images [post]
+func (h *Handler) UploadImage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	if sessionID == "" {
+		logger.Error(ctx, "Session ID is empty")
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	// Make sure the session exists and belongs to the current tenant
+	if _, err := h.sessionService.GetSession(ctx, sessionID); err != nil {
+		logger.Errorf(ctx, "Failed to get session, session ID: %s, error: %v", sessionID, err)
+		c.Error(errors.NewNotFoundError("Session not found"))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		logger.Error(ctx, "Image upload failed", err)
+		c.Error(errors.NewBadRequestError("Image upload failed").WithDetails(err.Error()))
+		return
+	}
+	if file.Size > maxImageUploadSize {
+		c.Error(errors.NewBadRequestError(fmt.Sprintf("Image size cannot exceed %dMB", maxImageUploadSize>>20)))
+		return
+	}
+	mimeType, ok := allowedImageExtensions[strings.ToLower(filepath.Ext(file.Filename))]
+	if !ok {
+		c.Error(errors.NewBadRequestError("Unsupported image type"))
+		return
+	}
+
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+	filePath, err := h.fileService.SaveFile(ctx, file, tenantID, sessionID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError("Failed to save image").WithDetails(err.Error()))
+		return
+	}
+
+	logger.Infof(ctx, "Image attachment uploaded, session ID: %s, file: %s",
+		sessionID, secutils.SanitizeForLog(file.Filename))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": types.MessageImage{
+			FilePath: filePath,
+			FileName: secutils.SanitizeForLog(file.Filename),
+			MimeType: mimeType,
+		},
+	})
+}
+
+// GetImage godoc
+// @Summary      获取对话图片
+// @Description  获取会话中上传的图片附件内容
+// @Tags         会话
+// @Produce      octet-stream
+// @Param        session_id  path      string  true  "会话ID"
+// @Param        file_path   query     string  true  "图片存储路径"
+// @Success      200         {file}    binary  "图片内容"
+// @Failure      400         {object}  errors.AppError  "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/images [get]
+func (h *Handler) GetImage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	filePath := c.Query("file_path")
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+	if !isSessionImagePath(filePath, tenantID, sessionID) {
+		c.Error(errors.NewBadRequestError("Invalid image file path"))
+		return
+	}
+
+	if _, err := h.sessionService.GetSession(ctx, sessionID); err != nil {
+		logger.Errorf(ctx, "Failed to get session, session ID: %s, error: %v", sessionID, err)
+		c.Error(errors.NewNotFoundError("Session not found"))
+		return
+	}
+
+	file, err := h.fileService.GetFile(ctx, filePath)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewNotFoundError("Image not found"))
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(filePath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Stream(func(w io.Writer) bool {
+		if _, err := io.Copy(w, file); err != nil {
+			logger.Errorf(ctx, "Failed to send image: %v", err)
+		}
+		return false
+	})
+}
@@ -31,6 +31,14 @@ type qaRequestContext struct {
 	summaryModelID   string
 	webSearchEnabled bool
 	mentionedItems   types.MentionedItems
+	images           types.MessageImages
+	// parentMessageID is the parent of the new user message, the session's active message by default
+	parentMessageID string
+	// userMessage is an existing user message to answer again instead of creating a new one
+	userMessage *types.Message
+	// branched marks requests that leave the previous active branch (edit, regenerate),
+	// the agent context is then rebuilt from the new branch
+	branched bool
 }
 
 // parseQARequest parses and validates a QA request, returns the request context
@@ -58,17 +66,36 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 		return nil, nil, errors.NewBadRequestError("Query content cannot be empty")
 	}
 
+	// Validate image attachments
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+	images, err := convertImageAttachments(request.Images, tenantID, sessionID)
+	if err != nil {
+		logger.Errorf(ctx, "Invalid image attachments: %v", err)
+		return nil, nil, errors.NewBadRequestError(err.Error())
+	}
+
 	// Log request details
 	if requestJSON, err := json.Marshal(request); err == nil {
 		logger.Infof(ctx, "[%s] Request: session_id=%s, request=%s",
 			logPrefix, sessionID, secutils.SanitizeForLog(string(requestJSON)))
 	}
 
+	reqCtx, err := h.newQARequestContext(ctx, c, sessionID, &request, images)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reqCtx, &request, nil
+}
+
+// newQARequestContext loads the session and custom agent of a QA request and builds its context
+func (h *Handler) newQARequestContext(ctx context.Context, c *gin.Context, sessionID string,
+	request *CreateKnowledgeQARequest, images types.MessageImages,
+) (*qaRequestContext, error) {
 	// Get session
 	session, err := h.sessionService.GetSession(ctx, sessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session, session ID: %s, error: %v", sessionID, err)
-		return nil, nil, errors.NewNotFoundError("Session not found")
+		return nil, errors.NewNotFoundError("Session not found")
 	}
 
 	// Get custom agent if agent_id is provided
@@ -106,9 +133,15 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 		summaryModelID:   secutils.SanitizeForLog(request.SummaryModelID),
 		webSearchEnabled: request.WebSearchEnabled,
 		mentionedItems:   convertMentionedItems(request.MentionedItems),
+		images:           images,
+		parentMessageID:  session.ActiveMessageID,
+	}
+	if customAgent != nil {
+		reqCtx.assistantMessage.AgentID = customAgent.ID
+		reqCtx.assistantMessage.AgentVersion = customAgent.Version
 	}
 
-	return reqCtx, &request, nil
+	return reqCtx, nil
 }
 
 // sseStreamContext holds the context for SSE streaming
@@ -281,9 +314,14 @@ func (h *Handler) AgentQA(c *gin.Context) {
 		return
 	}
 
+	h.executeQA(reqCtx, request.AgentEnabled)
+}
+
+// executeQA runs a request in agent mode or normal mode
+func (h *Handler) executeQA(reqCtx *qaRequestContext, agentEnabled bool) {
 	// Determine if agent mode should be enabled
 	// Priority: customAgent.IsAgentMode() > request.AgentEnabled
-	agentModeEnabled := request.AgentEnabled
+	agentModeEnabled := agentEnabled
 	if reqCtx.customAgent != nil {
 		agentModeEnabled = reqCtx.customAgent.IsAgentMode()
 		logger.Infof(reqCtx.ctx, "Agent mode determined by custom agent: %v (config.agent_mode=%s)",
@@ -300,19 +338,47 @@ func (h *Handler) AgentQA(c *gin.Context) {
 	}
 }
 
-// executeNormalModeQA executes the normal (KnowledgeQA) mode
-func (h *Handler) executeNormalModeQA(reqCtx *qaRequestContext, generateTitle bool) {
+// createQAMessages creates the user and assistant messages of a QA request
+// The assistant message is linked to the user message as its parent
+func (h *Handler) createQAMessages(reqCtx *qaRequestContext) (*types.Message, error) {
 	ctx := reqCtx.ctx
-	sessionID := reqCtx.sessionID
 
-	// Create user message
-	if err := h.createUserMessage(ctx, sessionID, reqCtx.query, reqCtx.requestID, reqCtx.mentionedItems); err != nil {
-		reqCtx.c.Error(errors.NewInternalServerError(err.Error()))
-		return
+	// Create user message, unless an existing question is answered again
+	userMessage := reqCtx.userMessage
+	if userMessage == nil {
+		var err error
+		userMessage, err = h.createUserMessage(ctx, reqCtx.sessionID, reqCtx.query, reqCtx.requestID,
+			reqCtx.parentMessageID, reqCtx.assistantMessage.AgentID, reqCtx.mentionedItems, reqCtx.images)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Create assistant message
-	if _, err := h.createAssistantMessage(ctx, reqCtx.assistantMessage); err != nil {
+	reqCtx.assistantMessage.ParentID = userMessage.ID
+	assistantMessage, err := h.createAssistantMessage(ctx, reqCtx.assistantMessage)
+	if err != nil {
+		return nil, err
+	}
+	reqCtx.assistantMessage = assistantMessage
+
+	// The agent context still holds the previous branch
+	if reqCtx.branched {
+		if err := h.sessionService.RebuildContext(ctx, reqCtx.sessionID); err != nil {
+			logger.Warnf(ctx, "Failed to rebuild context for session %s: %v", reqCtx.sessionID, err)
+		}
+	}
+	return userMessage, nil
+}
+
+// executeNormalModeQA executes the normal (KnowledgeQA) mode
+func (h *Handler) executeNormalModeQA(reqCtx *qaRequestContext, generateTitle bool) {
+	ctx := reqCtx.ctx
+	sessionID := reqCtx.sessionID
+
+	// Create user and assistant messages
+	userMessage, err := h.createQAMessages(reqCtx)
+	if err != nil {
 		reqCtx.c.Error(errors.NewInternalServerError(err.Error()))
 		return
 	}
@@ -353,10 +419,12 @@ func (h *Handler) executeNormalModeQA(reqCtx *qaRequestContext, generateTitle bo
 			}
 		}()
 
+		// Captions generated for the images are written back into userMessage.Images
 		err := h.sessionService.KnowledgeQA(
 			streamCtx.asyncCtx,
 			reqCtx.session,
 			reqCtx.query,
+			userMessage.Images,
 			reqCtx.knowledgeBaseIDs,
 			reqCtx.knowledgeIDs,
 			reqCtx.assistantMessage.ID,
@@ -365,6 +433,7 @@ func (h *Handler) executeNormalModeQA(reqCtx *qaRequestContext, generateTitle bo
 			streamCtx.eventBus,
 			reqCtx.customAgent,
 		)
+		h.saveImageCaptions(streamCtx.asyncCtx, userMessage)
 		if err != nil {
 			logger.ErrorWithFields(streamCtx.asyncCtx, err, nil)
 			streamCtx.eventBus.Emit(streamCtx.asyncCtx, event.Event{
@@ -405,19 +474,12 @@ func (h *Handler) executeAgentModeQA(reqCtx *qaRequestContext) {
 		return
 	}
 
-	// Create user message
-	if err := h.createUserMessage(ctx, sessionID, reqCtx.query, reqCtx.requestID, reqCtx.mentionedItems); err != nil {
-		reqCtx.c.Error(errors.NewInternalServerError(err.Error()))
-		return
-	}
-
-	// Create assistant message
-	assistantMessagePtr, err := h.createAssistantMessage(ctx, reqCtx.assistantMessage)
+	// Create user and assistant messages
+	userMessage, err := h.createQAMessages(reqCtx)
 	if err != nil {
 		reqCtx.c.Error(errors.NewInternalServerError(err.Error()))
 		return
 	}
-	reqCtx.assistantMessage = assistantMessagePtr
 
 	logger.Infof(ctx, "Calling agent QA service, session ID: %s", sessionID)
 
@@ -438,10 +500,12 @@ func (h *Handler) executeAgentModeQA(reqCtx *qaRequestContext) {
 			logger.Infof(streamCtx.asyncCtx, "Agent QA service completed for session: %s", sessionID)
 		}()
 
+		// Captions generated for the images are written back into userMessage.Images
 		err := h.sessionService.AgentQA(
 			streamCtx.asyncCtx,
 			reqCtx.session,
 			reqCtx.query,
+			userMessage.Images,
 			reqCtx.assistantMessage.ID,
 			reqCtx.summaryModelID,
 			streamCtx.eventBus,
@@ -449,6 +513,7 @@ func (h *Handler) executeAgentModeQA(reqCtx *qaRequestContext) {
 			reqCtx.knowledgeBaseIDs,
 			reqCtx.knowledgeIDs,
 		)
+		h.saveImageCaptions(streamCtx.asyncCtx, userMessage)
 		if err != nil {
 			logger.ErrorWithFields(streamCtx.asyncCtx, err, nil)
 			streamCtx.eventBus.Emit(streamCtx.asyncCtx, event.Event{
@@ -327,6 +327,8 @@ func (h *Handler) handleAgentEventsForSSE(
 
 	lastOffset := 0
 	log := logger.GetLogger(ctx)
+	// 智能体等待用户审批工具调用时不产生事件，此时不按空闲超时关闭
+	awaitingApproval := false
 
 	log.Infof("Starting pull-based SSE streaming for session=%s, message=%s, maxTimeout=%v",
 		sessionID, assistantMessageID, SSEMaxTimeout)
@@ -341,6 +343,10 @@ func (h *Handler) handleAgentEventsForSSE(
 			return
 
 		case <-idleTimer.C:
+			if awaitingApproval {
+				idleTimer.Reset(SSEIdleTimeout)
+				continue
+			}
 			// 空闲超时，长时间没有新事件
 			log.Warnf("SSE stream idle timeout for session=%s, message=%s, closing",
 				sessionID, assistantMessageID)
@@ -415,6 +421,10 @@ func (h *Handler) handleAgentEventsForSSE(
 					streamCompleted = true
 				}
 
+				if evt.Type == types.ResponseTypeToolApproval {
+					awaitingApproval = !evt.Done
+				}
+
 				// Check for title event
 				if evt.Type == types.ResponseTypeSessionTitle {
 					titleReceived = true
@@ -27,17 +27,26 @@ type MentionedItemRequest struct {
 	KBType string `json:"kb_type"` // "document" or "faq" (only for kb type)
 }
 
+// ImageAttachmentRequest represents an image attached to a QA request
+// The image must be uploaded first via POST /sessions/{session_id}/images
+type ImageAttachmentRequest struct {
+	FilePath string `json:"file_path" binding:"required"` // Storage path returned by the upload API
+	FileName string `json:"file_name"`                    // Original file name
+	MimeType string `json:"mime_type"`                    // MIME type, e.g. image/png
+}
+
 // CreateKnowledgeQARequest defines the request structure for knowledge QA
 type CreateKnowledgeQARequest struct {
-	Query            string                 `json:"query"              binding:"required"` // Query text for knowledge base search
-	KnowledgeBaseIDs []string               `json:"knowledge_base_ids"`                    // Selected knowledge base ID for this request
-	KnowledgeIds     []string               `json:"knowledge_ids"`                         // Selected knowledge ID for this request
-	AgentEnabled     bool                   `json:"agent_enabled"`                         // Whether agent mode is enabled for this request
-	AgentID          string                 `json:"agent_id"`                              // Selected custom agent ID for this request
-	WebSearchEnabled bool                   `json:"web_search_enabled"`                    // Whether web search is enabled for this request
-	SummaryModelID   string                 `json:"summary_model_id"`                      // Optional summary model ID for this request (overrides session default)
-	MentionedItems   []MentionedItemRequest `json:"mentioned_items"`                       // @mentioned knowledge bases and files
-	DisableTitle     bool                   `json:"disable_title"`                         // Whether to disable auto title generation
+	Query            string                   `json:"query"              binding:"required"` // Query text for knowledge base search
+	KnowledgeBaseIDs []string                 `json:"knowledge_base_ids"`                    // Selected knowledge base ID for this request
+	KnowledgeIds     []string                 `json:"knowledge_ids"`                         // Selected knowledge ID for this request
+	AgentEnabled     bool                     `json:"agent_enabled"`                         // Whether agent mode is enabled for this request
+	AgentID          string                   `json:"agent_id"`                              // Selected custom agent ID for this request
+	WebSearchEnabled bool                     `json:"web_search_enabled"`                    // Whether web search is enabled for this request
+	SummaryModelID   string                   `json:"summary_model_id"`                      // Optional summary model ID for this request (overrides session default)
+	MentionedItems   []MentionedItemRequest   `json:"mentioned_items"`                       // @mentioned knowledge bases and files
+	Images           []ImageAttachmentRequest `json:"images"`                                // Image attachments (e.g. screenshots)
+	DisableTitle     bool                     `json:"disable_title"`                         // Whether to disable auto title generation
 }
 
 // SearchKnowledgeRequest defines the request structure for searching knowledge without LLM summarization
@@ -52,3 +61,14 @@ type SearchKnowledgeRequest struct {
 type StopSessionRequest struct {
 	MessageID string `json:"message_id" binding:"required"`
 }
+
+// RegenerateMessageRequest defines the request structure for regenerating an answer
+// The query, mentioned items and images of the original question are reused
+type RegenerateMessageRequest struct {
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids"` // Selected knowledge base ID for this request
+	KnowledgeIds     []string `json:"knowledge_ids"`      // Selected knowledge ID for this request
+	AgentEnabled     bool     `json:"agent_enabled"`      // Whether agent mode is enabled for this request
+	AgentID          string   `json:"agent_id"`           // Custom agent to regenerate the answer with
+	WebSearchEnabled bool     `json:"web_search_enabled"` // Whether web search is enabled for this request
+	SummaryModelID   string   `json:"summary_model_id"`   // Model to regenerate the answer with (overrides session default)
+}
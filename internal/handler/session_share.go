@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// sharedPageCSP forbids scripts and external resources on the public read-only page
+const sharedPageCSP = "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'"
+
+// SessionShareHandler 会话导出及分享链接处理器
+type SessionShareHandler struct {
+	shareService interfaces.SessionShareService
+}
+
+// NewSessionShareHandler 创建会话导出及分享链接处理器
+func NewSessionShareHandler(shareService interfaces.SessionShareService) *SessionShareHandler {
+	return &SessionShareHandler{shareService: shareService}
+}
+
+// CreateSessionShareRequest 创建分享链接的请求
+type CreateSessionShareRequest struct {
+	// 是否在快照中包含引用分块的原文及工具调用的输出，默认不包含
+	IncludeChunkContent bool `json:"include_chunk_content"`
+	// 有效天数，默认 7 天，最长 90 天
+	ExpiresInDays int `json:"expires_in_days"`
+}
+
+// ExportSession godoc
+// @Summary      导出会话
+// @Description  将会话当前分支的对话（含引用、智能体步骤及@提及）导出为 Markdown、HTML 或 JSON 文件
+// @Tags         会话
+// @Produce      octet-stream
+// @Param        session_id             path      string  true   "会话ID"
+// @Param        format                 query     string  false  "导出格式：markdown（默认）、html、json"
+// @Param        include_chunk_content  query     bool    false  "是否包含引用分块原文及工具输出，默认 true"
+// @Success      200                    {file}    file    "导出文件"
+// @Failure      400                    {object}  errors.AppError  "请求参数错误"
+// @Failure      404                    {object}  errors.AppError  "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/export [get]
+func (h *SessionShareHandler) ExportSession(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	format := types.SessionExportFormat(c.DefaultQuery("format", string(types.SessionExportMarkdown)))
+	includeChunkContent, err := strconv.ParseBool(c.DefaultQuery("include_chunk_content", "true"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("include_chunk_content must be a boolean"))
+		return
+	}
+
+	data, contentType, fileName, err := h.shareService.ExportSession(
+		ctx, secutils.SanitizeForLog(c.Param("session_id")), format, includeChunkContent)
+	if err != nil {
+		h.handleError(c, err, "导出会话失败")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// CreateSessionShare godoc
+// @Summary      创建分享链接
+// @Description  保存会话当前分支的快照并创建只读分享链接，持有链接即可无需登录查看，链接到期或撤销后失效
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        session_id  path      string                     true   "会话ID"
+// @Param        request     body      CreateSessionShareRequest  false  "分享设置"
+// @Success      201         {object}  map[string]interface{}     "创建的分享链接"
+// @Failure      400         {object}  errors.AppError            "请求参数错误"
+// @Failure      404         {object}  errors.AppError            "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/shares [post]
+func (h *SessionShareHandler) CreateSessionShare(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateSessionShareRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(errors.NewBadRequestError(err.Error()))
+			return
+		}
+	}
+
+	share, err := h.shareService.CreateShare(
+		ctx, secutils.SanitizeForLog(c.Param("session_id")), req.IncludeChunkContent, req.ExpiresInDays)
+	if err != nil {
+		h.handleError(c, err, "创建分享链接失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    share,
+	})
+}
+
+// ListSessionShares godoc
+// @Summary      获取分享链接列表
+// @Description  获取会话未过期的分享链接
+// @Tags         会话
+// @Produce      json
+// @Param        session_id  path      string  true  "会话ID"
+// @Success      200         {object}  map[string]interface{}  "分享链接列表"
+// @Failure      404         {object}  errors.AppError         "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/shares [get]
+func (h *SessionShareHandler) ListSessionShares(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	shares, err := h.shareService.ListShares(ctx, secutils.SanitizeForLog(c.Param("session_id")))
+	if err != nil {
+		h.handleError(c, err, "获取分享链接失败")
+		return
+	}
+	if shares == nil {
+		shares = []*types.SessionShare{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    shares,
+	})
+}
+
+// RevokeSessionShare godoc
+// @Summary      撤销分享链接
+// @Description  撤销会话的分享链接，撤销后链接立即失效
+// @Tags         会话
+// @Produce      json
+// @Param        session_id  path      string  true  "会话ID"
+// @Param        share_id    path      string  true  "分享链接ID"
+// @Success      200         {object}  map[string]interface{}  "撤销成功"
+// @Failure      404         {object}  errors.AppError         "分享链接不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/shares/{share_id} [delete]
+func (h *SessionShareHandler) RevokeSessionShare(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.shareService.RevokeShare(ctx,
+		secutils.SanitizeForLog(c.Param("session_id")), secutils.SanitizeForLog(c.Param("share_id"))); err != nil {
+		h.handleError(c, err, "撤销分享链接失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "撤销成功",
+	})
+}
+
+// GetSharedSession godoc
+// @Summary      查看分享的会话
+// @Description  通过分享链接查看会话快照，无需登录。默认返回只读 HTML 页面，也可指定 markdown 或 json 格式
+// @Tags         会话
+// @Produce      html
+// @Param        token   path      string  true   "分享令牌"
+// @Param        format  query     string  false  "返回格式：html（默认）、markdown、json"
+// @Success      200     {string}  string  "会话快照"
+// @Failure      404     {object}  errors.AppError  "链接不存在、已过期或已撤销"
+// @Router       /shared/{token} [get]
+func (h *SessionShareHandler) GetSharedSession(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	format := types.SessionExportFormat(c.DefaultQuery("format", string(types.SessionExportHTML)))
+	if !types.IsValidSessionExportFormat(format) {
+		c.Error(errors.NewBadRequestError("Unsupported format: " + secutils.SanitizeForLog(string(format))))
+		return
+	}
+
+	transcript, err := h.shareService.GetSharedTranscript(ctx, c.Param("token"))
+	if err != nil {
+		h.handleError(c, err, "获取分享的会话失败")
+		return
+	}
+	if format == types.SessionExportJSON {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    transcript,
+		})
+		return
+	}
+
+	data, contentType, err := h.shareService.RenderTranscript(transcript, format)
+	if err != nil {
+		h.handleError(c, err, "获取分享的会话失败")
+		return
+	}
+	c.Header("Content-Security-Policy", sharedPageCSP)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func (h *SessionShareHandler) handleError(c *gin.Context, err error, message string) {
+	ctx := c.Request.Context()
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(ctx, err, map[string]interface{}{"path": secutils.SanitizeForLog(c.FullPath())})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/gin-gonic/gin"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -65,7 +66,7 @@ func (h *SystemHandler) GetSystemInfo(c *gin.Context) {
 	// Get vector store engine from config or RETRIEVE_DRIVER
 	vectorStoreEngine := h.getVectorStoreEngine()
 
-	// Get graph database engine from NEO4J_ENABLE
+	// Get graph database engine from GRAPH_DRIVER or NEO4J_ENABLE
 	graphDatabaseEngine := h.getGraphDatabaseEngine()
 
 	// Get MinIO enabled status
@@ -144,6 +145,9 @@ func (h *SystemHandler) getVectorStoreEngine() string {
 
 // getGraphDatabaseEngine returns the graph database engine name
 func (h *SystemHandler) getGraphDatabaseEngine() string {
+	if types.GetGraphDriver() == types.GraphDriverPostgres {
+		return "PostgreSQL"
+	}
 	if h.neo4jDriver == nil {
 		return "未启用"
 	}
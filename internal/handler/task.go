@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// TaskHandler 后台任务管理处理器
+type TaskHandler struct {
+	taskService interfaces.TaskAdminService
+	userService interfaces.UserService
+	config      *config.Config
+}
+
+// NewTaskHandler 创建后台任务管理处理器
+func NewTaskHandler(
+	taskService interfaces.TaskAdminService,
+	userService interfaces.UserService,
+	config *config.Config,
+) *TaskHandler {
+	return &TaskHandler{
+		taskService: taskService,
+		userService: userService,
+		config:      config,
+	}
+}
+
+// ListTasks godoc
+// @Summary      获取后台任务列表
+// @Description  获取当前租户的后台任务（文档解析、FAQ导入、知识库复制/删除等），可按队列、状态、类型、知识库过滤
+// @Tags         任务管理
+// @Produce      json
+// @Param        queue              query     string  false  "队列（critical/default/low）"
+// @Param        state              query     string  false  "状态（pending/active/scheduled/retry/archived/completed）"
+// @Param        type               query     string  false  "任务类型，如 document:process"
+// @Param        knowledge_base_id  query     string  false  "知识库ID"
+// @Param        page               query     int     false  "页码"
+// @Param        page_size          query     int     false  "每页数量"
+// @Success      200                {object}  map[string]interface{}  "任务列表"
+// @Failure      400                {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tasks [get]
+func (h *TaskHandler) ListTasks(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var filter types.TaskListFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if pagination.Page < 1 {
+		pagination.Page = 1
+	}
+	if pagination.PageSize < 1 {
+		pagination.PageSize = 20
+	}
+
+	result, err := h.taskService.ListTasks(ctx, &filter, &pagination)
+	if err != nil {
+		h.handleError(c, err, "获取任务列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetTask godoc
+// @Summary      获取后台任务详情
+// @Description  获取任务的参数摘要、重试次数及最近一次错误
+// @Tags         任务管理
+// @Produce      json
+// @Param        queue  path      string  true  "队列"
+// @Param        id     path      string  true  "任务ID"
+// @Success      200    {object}  map[string]interface{}  "任务详情"
+// @Failure      404    {object}  errors.AppError         "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tasks/{queue}/{id} [get]
+func (h *TaskHandler) GetTask(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	task, err := h.taskService.GetTask(ctx, c.Param("queue"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err, "获取任务详情失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    task,
+	})
+}
+
+// RetryTask godoc
+// @Summary      重试后台任务
+// @Description  立即执行等待重试、已归档或定时的任务；已完成的任务会以相同参数重新入队
+// @Tags         任务管理
+// @Produce      json
+// @Param        queue  path      string  true  "队列"
+// @Param        id     path      string  true  "任务ID"
+// @Success      200    {object}  map[string]interface{}  "任务已重试"
+// @Failure      400    {object}  errors.AppError         "任务状态不允许重试"
+// @Failure      404    {object}  errors.AppError         "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tasks/{queue}/{id}/retry [post]
+func (h *TaskHandler) RetryTask(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.taskService.RetryTask(ctx, c.Param("queue"), c.Param("id")); err != nil {
+		h.handleError(c, err, "重试任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "任务已重试",
+	})
+}
+
+// CancelTask godoc
+// @Summary      取消后台任务
+// @Description  取消正在执行的任务，或从队列中删除等待执行的任务
+// @Tags         任务管理
+// @Produce      json
+// @Param        queue  path      string  true  "队列"
+// @Param        id     path      string  true  "任务ID"
+// @Success      200    {object}  map[string]interface{}  "任务已取消"
+// @Failure      400    {object}  errors.AppError         "任务已完成"
+// @Failure      404    {object}  errors.AppError         "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tasks/{queue}/{id}/cancel [post]
+func (h *TaskHandler) CancelTask(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.taskService.CancelTask(ctx, c.Param("queue"), c.Param("id")); err != nil {
+		h.handleError(c, err, "取消任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "任务已取消",
+	})
+}
+
+// RetryFailedDocuments godoc
+// @Summary      批量重试知识库中解析失败的文档
+// @Description  重新执行知识库下失败的文档解析任务，并将对应知识的解析状态重置为待处理
+// @Tags         任务管理
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "重试结果"
+// @Failure      404  {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tasks/knowledge-bases/{id}/retry-failed [post]
+func (h *TaskHandler) RetryFailedDocuments(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	kbID := c.Param("id")
+	if kbID == "" {
+		c.Error(errors.NewBadRequestError("Knowledge base ID is required"))
+		return
+	}
+
+	result, err := h.taskService.RetryFailedDocuments(ctx, kbID)
+	if err != nil {
+		h.handleError(c, err, "重试失败文档失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ListQueues godoc
+// @Summary      获取任务队列状态
+// @Description  获取各任务队列的任务数量及暂停状态（队列为全局共享，需要跨租户访问权限）
+// @Tags         任务管理
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "队列列表"
+// @Failure      403  {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Router       /tasks/queues [get]
+func (h *TaskHandler) ListQueues(c *gin.Context) {
+	ctx := c.Request.Context()
+	if !h.requireQueueAdmin(c) {
+		return
+	}
+
+	queues, err := h.taskService.ListQueues(ctx)
+	if err != nil {
+		h.handleError(c, err, "获取队列状态失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    queues,
+	})
+}
+
+// PauseQueue godoc
+// @Summary      暂停任务队列
+// @Description  暂停队列中任务的处理，已在执行的任务不受影响（需要跨租户访问权限）
+// @Tags         任务管理
+// @Produce      json
+// @Param        queue  path      string  true  "队列"
+// @Success      200    {object}  map[string]interface{}  "队列已暂停"
+// @Failure      403    {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Router       /tasks/queues/{queue}/pause [post]
+func (h *TaskHandler) PauseQueue(c *gin.Context) {
+	ctx := c.Request.Context()
+	if !h.requireQueueAdmin(c) {
+		return
+	}
+
+	if err := h.taskService.PauseQueue(ctx, c.Param("queue")); err != nil {
+		h.handleError(c, err, "暂停队列失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "队列已暂停",
+	})
+}
+
+// ResumeQueue godoc
+// @Summary      恢复任务队列
+// @Description  恢复已暂停队列的任务处理（需要跨租户访问权限）
+// @Tags         任务管理
+// @Produce      json
+// @Param        queue  path      string  true  "队列"
+// @Success      200    {object}  map[string]interface{}  "队列已恢复"
+// @Failure      403    {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Router       /tasks/queues/{queue}/resume [post]
+func (h *TaskHandler) ResumeQueue(c *gin.Context) {
+	ctx := c.Request.Context()
+	if !h.requireQueueAdmin(c) {
+		return
+	}
+
+	if err := h.taskService.ResumeQueue(ctx, c.Param("queue")); err != nil {
+		h.handleError(c, err, "恢复队列失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "队列已恢复",
+	})
+}
+
+// requireQueueAdmin checks the current user may manage the queues shared by all tenants
+func (h *TaskHandler) requireQueueAdmin(c *gin.Context) bool {
+	ctx := c.Request.Context()
+
+	user, err := h.userService.GetCurrentUser(ctx)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get current user: %v", err)
+		c.Error(errors.NewUnauthorizedError("Failed to get user information").WithDetails(err.Error()))
+		return false
+	}
+	if h.config == nil || h.config.Tenant == nil || !h.config.Tenant.EnableCrossTenantAccess {
+		c.Error(errors.NewForbiddenError("Cross-tenant access is disabled"))
+		return false
+	}
+	if !user.CanAccessAllTenants {
+		logger.Warnf(ctx, "User %s attempted to manage task queues without permission", user.ID)
+		c.Error(errors.NewForbiddenError("Insufficient permissions to manage task queues"))
+		return false
+	}
+	return true
+}
+
+func (h *TaskHandler) handleError(c *gin.Context, err error, message string) {
+	ctx := c.Request.Context()
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(ctx, err, map[string]interface{}{"path": secutils.SanitizeForLog(c.FullPath())})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -22,6 +24,8 @@ import (
 type TenantHandler struct {
 	service     interfaces.TenantService
 	userService interfaces.UserService
+	kbService   interfaces.KnowledgeBaseService
+	limiter     interfaces.TenantLimiter
 	config      *config.Config
 }
 
@@ -29,13 +33,23 @@ type TenantHandler struct {
 // Parameters:
 //   - service: An implementation of the TenantService interface for business logic
 //   - userService: An implementation of the UserService interface for user operations
+//   - kbService: Knowledge base service used to validate the knowledge bases published over MCP
+//   - limiter: Per-tenant task concurrency and model call limiter
 //   - config: Application configuration
 //
 // Returns a pointer to the newly created TenantHandler
-func NewTenantHandler(service interfaces.TenantService, userService interfaces.UserService, config *config.Config) *TenantHandler {
+func NewTenantHandler(
+	service interfaces.TenantService,
+	userService interfaces.UserService,
+	kbService interfaces.KnowledgeBaseService,
+	limiter interfaces.TenantLimiter,
+	config *config.Config,
+) *TenantHandler {
 	return &TenantHandler{
 		service:     service,
 		userService: userService,
+		kbService:   kbService,
+		limiter:     limiter,
 		config:      config,
 	}
 }
@@ -167,6 +181,10 @@ func (h *TenantHandler) UpdateTenant(c *gin.Context) {
 	logger.Infof(ctx, "Updating tenant, ID: %d, Name: %s", id, secutils.SanitizeForLog(tenantData.Name))
 
 	tenantData.ID = id
+	// 限流配置只能由管理员通过 /tenants/{id}/rate-limits 修改
+	tenantData.RateLimits = nil
+	// MCP 知识库范围只能通过 /tenants/kv/mcp-server-config 修改
+	tenantData.MCPServerConfig = nil
 	updatedTenant, err := h.service.UpdateTenant(ctx, &tenantData)
 	if err != nil {
 		// Check if this is an application-specific error
@@ -572,7 +590,7 @@ func (h *TenantHandler) updateTenantAgentConfigInternal(c *gin.Context) {
 
 // GetTenantKV godoc
 // @Summary      获取租户KV配置
-// @Description  获取租户级别的KV配置（支持agent-config、web-search-config、conversation-config）
+// @Description  获取租户级别的KV配置（支持agent-config、web-search-config、conversation-config、mcp-server-config）
 // @Tags         租户管理
 // @Accept       json
 // @Produce      json
@@ -599,6 +617,9 @@ func (h *TenantHandler) GetTenantKV(c *gin.Context) {
 	case "prompt-templates":
 		h.GetPromptTemplates(c)
 		return
+	case "mcp-server-config":
+		h.GetTenantMCPServerConfig(c)
+		return
 	default:
 		logger.Info(ctx, "KV key not supported", "key", key)
 		c.Error(errors.NewBadRequestError("unsupported key"))
@@ -608,7 +629,7 @@ func (h *TenantHandler) GetTenantKV(c *gin.Context) {
 
 // UpdateTenantKV godoc
 // @Summary      更新租户KV配置
-// @Description  更新租户级别的KV配置（支持agent-config、web-search-config、conversation-config）
+// @Description  更新租户级别的KV配置（支持agent-config、web-search-config、conversation-config、mcp-server-config）
 // @Tags         租户管理
 // @Accept       json
 // @Produce      json
@@ -633,6 +654,9 @@ func (h *TenantHandler) UpdateTenantKV(c *gin.Context) {
 	case "conversation-config":
 		h.updateTenantConversationInternal(c)
 		return
+	case "mcp-server-config":
+		h.updateTenantMCPServerConfigInternal(c)
+		return
 	default:
 		logger.Info(ctx, "KV key not supported", "key", key)
 		c.Error(errors.NewBadRequestError("unsupported key"))
@@ -657,6 +681,24 @@ func (h *TenantHandler) updateTenantWebSearchConfigInternal(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("max_results must be between 1 and 50"))
 		return
 	}
+	if h.config.WebSearch != nil {
+		knownProviders := make(map[string]bool, len(h.config.WebSearch.Providers))
+		for _, provider := range h.config.WebSearch.Providers {
+			knownProviders[provider.ID] = true
+		}
+		for _, providerID := range cfg.FallbackProviders {
+			if !knownProviders[providerID] {
+				c.Error(errors.NewBadRequestError("unknown fallback provider: " + providerID))
+				return
+			}
+		}
+		for providerID := range cfg.ProviderCredentials {
+			if !knownProviders[providerID] {
+				c.Error(errors.NewBadRequestError("unknown provider in credentials: " + providerID))
+				return
+			}
+		}
+	}
 
 	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 	if tenant == nil {
@@ -713,6 +755,89 @@ func (h *TenantHandler) GetTenantWebSearchConfig(c *gin.Context) {
 	})
 }
 
+// GetTenantMCPServerConfig godoc
+// @Summary      获取租户 MCP Server 配置
+// @Description  获取通过内置 MCP Server 对外提供的知识库
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "MCP Server 配置"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/kv/mcp-server-config [get]
+func (h *TenantHandler) GetTenantMCPServerConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	cfg := tenant.MCPServerConfig
+	if cfg == nil {
+		cfg = &types.TenantMCPServerConfig{KnowledgeBaseIDs: []string{}}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    cfg,
+	})
+}
+
+// updateTenantMCPServerConfigInternal updates the knowledge bases the tenant publishes over MCP
+func (h *TenantHandler) updateTenantMCPServerConfigInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var cfg types.TenantMCPServerConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	// 只能发布本租户的知识库
+	kbIDs := make([]string, 0, len(cfg.KnowledgeBaseIDs))
+	for _, kbID := range cfg.KnowledgeBaseIDs {
+		if kbID = strings.TrimSpace(kbID); kbID == "" || slices.Contains(kbIDs, kbID) {
+			continue
+		}
+		kb, err := h.kbService.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil || kb == nil || kb.TenantID != tenant.ID || kb.IsTemporary {
+			c.Error(errors.NewBadRequestError("knowledge base not found: " + secutils.SanitizeForLog(kbID)))
+			return
+		}
+		kbIDs = append(kbIDs, kbID)
+	}
+	cfg.KnowledgeBaseIDs = kbIDs
+
+	tenant.MCPServerConfig = &cfg
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			logger.Error(ctx, "Failed to update tenant: application error", appErr)
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update tenant MCP server config").WithDetails(err.Error()))
+		}
+		return
+	}
+	logger.Infof(ctx, "Tenant %d publishes %d knowledge base(s) over MCP", tenant.ID, len(kbIDs))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.MCPServerConfig,
+		"message": "MCP server configuration updated successfully",
+	})
+}
+
 func (h *TenantHandler) buildDefaultConversationConfig() *types.ConversationConfig {
 	return &types.ConversationConfig{
 		Prompt:               h.config.Conversation.Summary.Prompt,
@@ -947,3 +1072,152 @@ func (h *TenantHandler) UpdateBrandConfig(c *gin.Context) {
 		"data":    updatedTenant,
 	})
 }
+
+// GetTenantRateLimits godoc
+// @Summary      获取租户限流配置
+// @Description  获取租户的任务并发及模型调用限流配置、实际生效的限制和当前用量
+// @Tags         租户管理
+// @Produce      json
+// @Param        id   path      int  true  "租户ID"
+// @Success      200  {object}  map[string]interface{}  "限流配置"
+// @Failure      403  {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/{id}/rate-limits [get]
+func (h *TenantHandler) GetTenantRateLimits(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Errorf(ctx, "Invalid tenant ID: %s", secutils.SanitizeForLog(c.Param("id")))
+		c.Error(errors.NewBadRequestError("Invalid tenant ID"))
+		return
+	}
+	// 查看其他租户的限流配置需要跨租户访问权限
+	if currentTenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64); currentTenantID != id &&
+		!h.requireCrossTenantAdmin(c) {
+		return
+	}
+
+	tenant, err := h.service.GetTenantByID(ctx, id)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to retrieve tenant").WithDetails(err.Error()))
+		}
+		return
+	}
+
+	usage, err := h.limiter.Usage(ctx, id)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get rate limit usage of tenant %d: %v", id, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"rate_limits": tenant.RateLimits,
+			"effective":   h.limiter.Limits(ctx, id),
+			"usage":       usage,
+		},
+	})
+}
+
+// UpdateTenantRateLimits godoc
+// @Summary      更新租户限流配置
+// @Description  设置租户的任务并发及每分钟 Embedding/VLM 调用上限，未设置的字段使用系统默认值，0 表示不限制（需要跨租户访问权限）
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                     true  "租户ID"
+// @Param        request  body      types.TenantRateLimits  true  "限流配置"
+// @Success      200      {object}  map[string]interface{}  "更新后的租户"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      403      {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Router       /tenants/{id}/rate-limits [put]
+func (h *TenantHandler) UpdateTenantRateLimits(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Errorf(ctx, "Invalid tenant ID: %s", secutils.SanitizeForLog(c.Param("id")))
+		c.Error(errors.NewBadRequestError("Invalid tenant ID"))
+		return
+	}
+	if !h.requireCrossTenantAdmin(c) {
+		return
+	}
+
+	var rateLimits types.TenantRateLimits
+	if err := c.ShouldBindJSON(&rateLimits); err != nil {
+		c.Error(errors.NewValidationError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	for _, limit := range []*int{
+		rateLimits.MaxConcurrentTasks, rateLimits.EmbeddingCallsPerMinute, rateLimits.VLMCallsPerMinute,
+	} {
+		if limit != nil && *limit < 0 {
+			c.Error(errors.NewBadRequestError("Rate limits must not be negative"))
+			return
+		}
+	}
+	if err := rateLimits.AgentBudget.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	tenant, err := h.service.GetTenantByID(ctx, id)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to retrieve tenant").WithDetails(err.Error()))
+		}
+		return
+	}
+
+	tenant.RateLimits = &rateLimits
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update tenant rate limits").WithDetails(err.Error()))
+		}
+		return
+	}
+	h.limiter.InvalidateLimits(id)
+
+	logger.Infof(ctx, "Rate limits updated for tenant ID: %d", id)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant,
+	})
+}
+
+// requireCrossTenantAdmin checks the current user may manage other tenants
+func (h *TenantHandler) requireCrossTenantAdmin(c *gin.Context) bool {
+	ctx := c.Request.Context()
+
+	user, err := h.userService.GetCurrentUser(ctx)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get current user: %v", err)
+		c.Error(errors.NewUnauthorizedError("Failed to get user information").WithDetails(err.Error()))
+		return false
+	}
+	if h.config == nil || h.config.Tenant == nil || !h.config.Tenant.EnableCrossTenantAccess {
+		c.Error(errors.NewForbiddenError("Cross-tenant access is disabled"))
+		return false
+	}
+	if !user.CanAccessAllTenants {
+		logger.Warnf(ctx, "User %s attempted to manage tenant rate limits without permission", user.ID)
+		c.Error(errors.NewForbiddenError("Insufficient permissions to manage tenant rate limits"))
+		return false
+	}
+	return true
+}
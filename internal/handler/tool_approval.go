@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ToolApprovalHandler 智能体工具调用审批处理器
+type ToolApprovalHandler struct {
+	approvalService interfaces.ToolApprovalService
+}
+
+// NewToolApprovalHandler 创建智能体工具调用审批处理器
+func NewToolApprovalHandler(approvalService interfaces.ToolApprovalService) *ToolApprovalHandler {
+	return &ToolApprovalHandler{approvalService: approvalService}
+}
+
+// DecideToolApprovalRequest 审批工具调用的请求
+type DecideToolApprovalRequest struct {
+	// true 批准执行，false 拒绝
+	Approved *bool `json:"approved" binding:"required"`
+	// 拒绝原因，会作为工具结果返回给智能体
+	Reason string `json:"reason"`
+}
+
+// ListToolApprovals godoc
+// @Summary      获取工具调用审批列表
+// @Description  获取会话中智能体请求审批的工具调用，断线重连后可据此恢复待审批的请求
+// @Tags         问答
+// @Produce      json
+// @Param        session_id  path      string  true   "会话ID"
+// @Param        status      query     string  false  "按状态过滤：pending、approved、rejected、expired"
+// @Success      200         {object}  map[string]interface{}  "审批列表"
+// @Failure      400         {object}  errors.AppError         "请求参数错误"
+// @Failure      404         {object}  errors.AppError         "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/tool-approvals [get]
+func (h *ToolApprovalHandler) ListToolApprovals(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	status := types.ToolApprovalStatus(c.Query("status"))
+	switch status {
+	case "", types.ToolApprovalPending, types.ToolApprovalApproved, types.ToolApprovalRejected, types.ToolApprovalExpired:
+	default:
+		c.Error(errors.NewBadRequestError("Invalid status: " + secutils.SanitizeForLog(string(status))))
+		return
+	}
+
+	approvals, err := h.approvalService.ListApprovals(ctx, secutils.SanitizeForLog(c.Param("session_id")), status)
+	if err != nil {
+		h.handleError(c, err, "获取工具调用审批失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    approvals,
+	})
+}
+
+// DecideToolApproval godoc
+// @Summary      审批工具调用
+// @Description  批准或拒绝智能体等待中的工具调用。批准后智能体继续执行该工具，拒绝时拒绝原因作为工具结果返回给智能体
+// @Tags         问答
+// @Accept       json
+// @Produce      json
+// @Param        session_id   path      string                     true  "会话ID"
+// @Param        approval_id  path      string                     true  "审批ID"
+// @Param        request      body      DecideToolApprovalRequest  true  "审批决定"
+// @Success      200          {object}  map[string]interface{}     "审批结果"
+// @Failure      400          {object}  errors.AppError            "请求参数错误"
+// @Failure      404          {object}  errors.AppError            "审批不存在"
+// @Failure      409          {object}  errors.AppError            "审批已处理或已过期"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/tool-approvals/{approval_id} [post]
+func (h *ToolApprovalHandler) DecideToolApproval(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req DecideToolApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	approval, err := h.approvalService.DecideApproval(ctx,
+		secutils.SanitizeForLog(c.Param("session_id")), secutils.SanitizeForLog(c.Param("approval_id")),
+		*req.Approved, req.Reason)
+	if err != nil {
+		h.handleError(c, err, "审批工具调用失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    approval,
+	})
+}
+
+func (h *ToolApprovalHandler) handleError(c *gin.Context, err error, message string) {
+	ctx := c.Request.Context()
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(ctx, err, map[string]interface{}{"path": secutils.SanitizeForLog(c.FullPath())})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
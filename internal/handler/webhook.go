@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler webhook 订阅管理处理器
+type WebhookHandler struct {
+	webhookService interfaces.WebhookService
+}
+
+// NewWebhookHandler 创建 webhook 订阅管理处理器
+func NewWebhookHandler(webhookService interfaces.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// WebhookSubscriptionRequest 创建/更新 webhook 订阅的请求
+type WebhookSubscriptionRequest struct {
+	Name        string                   `json:"name"        binding:"required"`
+	Description string                   `json:"description"`
+	URL         string                   `json:"url"         binding:"required"`
+	Events      []types.WebhookEventType `json:"events"      binding:"required"`
+	Enabled     *bool                    `json:"enabled"` // 默认启用
+}
+
+func (r *WebhookSubscriptionRequest) toSubscription() *types.WebhookSubscription {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return &types.WebhookSubscription{
+		Name:        r.Name,
+		Description: r.Description,
+		URL:         r.URL,
+		Events:      r.Events,
+		Enabled:     enabled,
+	}
+}
+
+// ListEventTypes godoc
+// @Summary      获取可订阅的 webhook 事件
+// @Description  获取 webhook 订阅可选的事件类型列表
+// @Tags         Webhook
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "事件类型列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/events [get]
+func (h *WebhookHandler) ListEventTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    types.WebhookEventTypes,
+	})
+}
+
+// CreateWebhook godoc
+// @Summary      创建 webhook 订阅
+// @Description  创建 webhook 订阅并生成签名密钥，密钥仅在创建时返回一次
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        request  body      WebhookSubscriptionRequest  true  "订阅信息"
+// @Success      201      {object}  map[string]interface{}      "创建的订阅（含签名密钥）"
+// @Failure      400      {object}  errors.AppError             "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	subscription := req.toSubscription()
+	if err := h.webhookService.CreateSubscription(ctx, subscription); err != nil {
+		h.handleError(c, err, "创建 webhook 订阅失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    subscription,
+	})
+}
+
+// ListWebhooks godoc
+// @Summary      获取 webhook 订阅列表
+// @Description  获取当前租户的 webhook 订阅，不返回签名密钥
+// @Tags         Webhook
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "订阅列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	subscriptions, err := h.webhookService.ListSubscriptions(ctx)
+	if err != nil {
+		h.handleError(c, err, "获取 webhook 订阅列表失败")
+		return
+	}
+	data := make([]*types.WebhookSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		data = append(data, subscription.HideSecret())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// GetWebhook godoc
+// @Summary      获取 webhook 订阅详情
+// @Description  获取 webhook 订阅详情，不返回签名密钥
+// @Tags         Webhook
+// @Produce      json
+// @Param        id   path      string  true  "订阅ID"
+// @Success      200  {object}  map[string]interface{}  "订阅详情"
+// @Failure      404  {object}  errors.AppError         "订阅不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	subscription, err := h.webhookService.GetSubscription(ctx, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err, "获取 webhook 订阅失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    subscription.HideSecret(),
+	})
+}
+
+// UpdateWebhook godoc
+// @Summary      更新 webhook 订阅
+// @Description  更新订阅的名称、地址、事件及启用状态，签名密钥不变
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                      true  "订阅ID"
+// @Param        request  body      WebhookSubscriptionRequest  true  "订阅信息"
+// @Success      200      {object}  map[string]interface{}      "更新后的订阅"
+// @Failure      400      {object}  errors.AppError             "请求参数错误"
+// @Failure      404      {object}  errors.AppError             "订阅不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	subscription := req.toSubscription()
+	subscription.ID = c.Param("id")
+	updated, err := h.webhookService.UpdateSubscription(ctx, subscription)
+	if err != nil {
+		h.handleError(c, err, "更新 webhook 订阅失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated.HideSecret(),
+	})
+}
+
+// DeleteWebhook godoc
+// @Summary      删除 webhook 订阅
+// @Description  删除 webhook 订阅，尚未送达的事件不再发送
+// @Tags         Webhook
+// @Produce      json
+// @Param        id   path      string  true  "订阅ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "订阅不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.webhookService.DeleteSubscription(ctx, c.Param("id")); err != nil {
+		h.handleError(c, err, "删除 webhook 订阅失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "删除成功",
+	})
+}
+
+// ListWebhookDeliveries godoc
+// @Summary      获取 webhook 投递记录
+// @Description  分页获取订阅的事件投递记录（按时间倒序），包含响应状态码、错误及尝试次数
+// @Tags         Webhook
+// @Produce      json
+// @Param        id         path      string  true   "订阅ID"
+// @Param        page       query     int     false  "页码"
+// @Param        page_size  query     int     false  "每页数量"
+// @Success      200        {object}  map[string]interface{}  "投递记录"
+// @Failure      404        {object}  errors.AppError         "订阅不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if pagination.Page < 1 {
+		pagination.Page = 1
+	}
+	if pagination.PageSize < 1 {
+		pagination.PageSize = 20
+	}
+
+	result, err := h.webhookService.ListDeliveries(ctx, c.Param("id"), &pagination)
+	if err != nil {
+		h.handleError(c, err, "获取 webhook 投递记录失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// TestWebhook godoc
+// @Summary      测试 webhook 订阅
+// @Description  向订阅地址同步发送一次 webhook.test 事件并返回投递结果，测试事件不会重试
+// @Tags         Webhook
+// @Produce      json
+// @Param        id   path      string  true  "订阅ID"
+// @Success      200  {object}  map[string]interface{}  "投递结果"
+// @Failure      404  {object}  errors.AppError         "订阅不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id}/test [post]
+func (h *WebhookHandler) TestWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	delivery, err := h.webhookService.TestSubscription(ctx, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err, "测试 webhook 订阅失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    delivery,
+	})
+}
+
+func (h *WebhookHandler) handleError(c *gin.Context, err error, message string) {
+	ctx := c.Request.Context()
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(ctx, err, map[string]interface{}{"path": secutils.SanitizeForLog(c.FullPath())})
+	c.Error(errors.NewInternalServerError(message).WithDetails(err.Error()))
+}
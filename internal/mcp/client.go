@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -38,6 +39,24 @@ type MCPClient interface {
 	// ReadResource reads a resource from the MCP service
 	ReadResource(ctx context.Context, uri string) (*ReadResourceResult, error)
 
+	// ListPrompts retrieves the list of available prompts from the MCP service
+	ListPrompts(ctx context.Context) ([]*types.MCPPrompt, error)
+
+	// GetPrompt renders a prompt with the given arguments
+	GetPrompt(ctx context.Context, name string, args map[string]string) (*GetPromptResult, error)
+
+	// SubscribeResource subscribes to updates of a resource
+	SubscribeResource(ctx context.Context, uri string) error
+
+	// UnsubscribeResource cancels a resource subscription
+	UnsubscribeResource(ctx context.Context, uri string) error
+
+	// OnResourceUpdated registers a handler called when a subscribed resource is updated
+	OnResourceUpdated(handler func(uri string))
+
+	// GetServerCapabilities returns the capabilities declared by the server during initialization
+	GetServerCapabilities() ServerCapabilities
+
 	// IsConnected returns true if the client is connected
 	IsConnected() bool
 
@@ -52,10 +71,14 @@ type ClientConfig struct {
 
 // mcpGoClient wraps mark3labs/mcp-go client to implement our MCPClient interface
 type mcpGoClient struct {
-	service     *types.MCPService
-	client      *client.Client
-	connected   bool
-	initialized bool
+	service      *types.MCPService
+	client       *client.Client
+	connected    bool
+	initialized  bool
+	capabilities ServerCapabilities
+
+	updateHandlersMu sync.RWMutex
+	updateHandlers   []func(uri string)
 }
 
 // NewMCPClient creates a new MCP client based on the transport type
@@ -153,10 +176,30 @@ func NewMCPClient(config *ClientConfig) (MCPClient, error) {
 		return nil, ErrUnsupportedTransport
 	}
 
-	return &mcpGoClient{
+	c := &mcpGoClient{
 		service: config.Service,
 		client:  mcpClient,
-	}, nil
+	}
+	mcpClient.OnNotification(c.handleNotification)
+	return c, nil
+}
+
+// handleNotification dispatches server notifications
+func (c *mcpGoClient) handleNotification(notification mcp.JSONRPCNotification) {
+	if notification.Method != mcp.MethodNotificationResourceUpdated {
+		return
+	}
+	uri, _ := notification.Params.AdditionalFields["uri"].(string)
+	if uri == "" {
+		return
+	}
+
+	c.updateHandlersMu.RLock()
+	handlers := append([]func(string){}, c.updateHandlers...)
+	c.updateHandlersMu.RUnlock()
+	for _, handler := range handlers {
+		handler(uri)
+	}
 }
 
 // Connect establishes connection to the MCP service
@@ -220,9 +263,11 @@ func (c *mcpGoClient) Initialize(ctx context.Context) (*InitializeResult, error)
 	}
 
 	c.initialized = true
+	c.capabilities = convertCapabilities(result.Capabilities)
 
 	return &InitializeResult{
 		ProtocolVersion: result.ProtocolVersion,
+		Capabilities:    c.capabilities,
 		ServerInfo: ServerInfo{
 			Name:    result.ServerInfo.Name,
 			Version: result.ServerInfo.Version,
@@ -363,6 +408,155 @@ func (c *mcpGoClient) ReadResource(ctx context.Context, uri string) (*ReadResour
 	}, nil
 }
 
+// ListPrompts retrieves the list of available prompts
+func (c *mcpGoClient) ListPrompts(ctx context.Context) ([]*types.MCPPrompt, error) {
+	if !c.initialized {
+		return nil, ErrNotConnected
+	}
+
+	result, err := c.client.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompts: %w", err)
+	}
+
+	// Convert to our types
+	prompts := make([]*types.MCPPrompt, len(result.Prompts))
+	for i, prompt := range result.Prompts {
+		args := make([]*types.MCPPromptArgument, len(prompt.Arguments))
+		for j, arg := range prompt.Arguments {
+			args[j] = &types.MCPPromptArgument{
+				Name:        arg.Name,
+				Description: arg.Description,
+				Required:    arg.Required,
+			}
+		}
+		prompts[i] = &types.MCPPrompt{
+			Name:        prompt.Name,
+			Description: prompt.Description,
+			Arguments:   args,
+		}
+	}
+
+	return prompts, nil
+}
+
+// GetPrompt renders a prompt with the given arguments
+func (c *mcpGoClient) GetPrompt(ctx context.Context, name string, args map[string]string) (*GetPromptResult, error) {
+	if !c.initialized {
+		return nil, ErrNotConnected
+	}
+
+	req := mcp.GetPromptRequest{
+		Params: mcp.GetPromptParams{
+			Name:      name,
+			Arguments: args,
+		},
+	}
+
+	result, err := c.client.GetPrompt(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt: %w", err)
+	}
+
+	// Convert to our types
+	messages := make([]PromptMessage, 0, len(result.Messages))
+	for _, message := range result.Messages {
+		item := ContentItem{Type: "unknown"}
+		if textContent, ok := mcp.AsTextContent(message.Content); ok {
+			item = ContentItem{Type: "text", Text: textContent.Text}
+		} else if imageContent, ok := mcp.AsImageContent(message.Content); ok {
+			item = ContentItem{Type: "image", Data: imageContent.Data, MimeType: imageContent.MIMEType}
+		} else if resource, ok := mcp.AsEmbeddedResource(message.Content); ok {
+			item = ContentItem{Type: "resource"}
+			if textResource, ok := mcp.AsTextResourceContents(resource.Resource); ok {
+				item.Text = textResource.Text
+				item.MimeType = textResource.MIMEType
+			}
+		}
+		messages = append(messages, PromptMessage{
+			Role:    string(message.Role),
+			Content: item,
+		})
+	}
+
+	return &GetPromptResult{
+		Description: result.Description,
+		Messages:    messages,
+	}, nil
+}
+
+// SubscribeResource subscribes to updates of a resource
+func (c *mcpGoClient) SubscribeResource(ctx context.Context, uri string) error {
+	if !c.initialized {
+		return ErrNotConnected
+	}
+	if c.capabilities.Resources == nil || !c.capabilities.Resources.Subscribe {
+		return ErrSubscriptionNotSupported
+	}
+
+	req := mcp.SubscribeRequest{
+		Params: mcp.SubscribeParams{
+			URI: uri,
+		},
+	}
+	if err := c.client.Subscribe(ctx, req); err != nil {
+		return fmt.Errorf("failed to subscribe resource: %w", err)
+	}
+	return nil
+}
+
+// UnsubscribeResource cancels a resource subscription
+func (c *mcpGoClient) UnsubscribeResource(ctx context.Context, uri string) error {
+	if !c.initialized {
+		return ErrNotConnected
+	}
+
+	req := mcp.UnsubscribeRequest{
+		Params: mcp.UnsubscribeParams{
+			URI: uri,
+		},
+	}
+	if err := c.client.Unsubscribe(ctx, req); err != nil {
+		return fmt.Errorf("failed to unsubscribe resource: %w", err)
+	}
+	return nil
+}
+
+// OnResourceUpdated registers a handler called when a subscribed resource is updated
+func (c *mcpGoClient) OnResourceUpdated(handler func(uri string)) {
+	c.updateHandlersMu.Lock()
+	defer c.updateHandlersMu.Unlock()
+	c.updateHandlers = append(c.updateHandlers, handler)
+}
+
+// GetServerCapabilities returns the capabilities declared by the server
+func (c *mcpGoClient) GetServerCapabilities() ServerCapabilities {
+	return c.capabilities
+}
+
+// convertCapabilities converts mcp-go server capabilities to our types
+func convertCapabilities(caps mcp.ServerCapabilities) ServerCapabilities {
+	result := ServerCapabilities{
+		Experimental: caps.Experimental,
+	}
+	if caps.Logging != nil {
+		result.Logging = map[string]interface{}{}
+	}
+	if caps.Tools != nil {
+		result.Tools = &ToolsCapability{ListChanged: caps.Tools.ListChanged}
+	}
+	if caps.Resources != nil {
+		result.Resources = &ResourcesCapability{
+			Subscribe:   caps.Resources.Subscribe,
+			ListChanged: caps.Resources.ListChanged,
+		}
+	}
+	if caps.Prompts != nil {
+		result.Prompts = &PromptsCapability{ListChanged: caps.Prompts.ListChanged}
+	}
+	return result
+}
+
 // IsConnected returns true if the client is connected
 func (c *mcpGoClient) IsConnected() bool {
 	return c.connected
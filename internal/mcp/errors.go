@@ -21,6 +21,9 @@ var (
 	// ErrResourceNotFound is returned when requested resource is not found
 	ErrResourceNotFound = errors.New("resource not found")
 
+	// ErrSubscriptionNotSupported is returned when the server does not support resource subscriptions
+	ErrSubscriptionNotSupported = errors.New("resource subscription not supported")
+
 	// ErrInvalidResponse is returned when server response is invalid
 	ErrInvalidResponse = errors.New("invalid response from server")
 
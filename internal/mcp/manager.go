@@ -16,6 +16,10 @@ type MCPManager struct {
 	clientsMu sync.RWMutex
 	ctx       context.Context
 	cancel    context.CancelFunc
+
+	watchers      map[string]*ResourceWatcher // sessionID -> watcher
+	subscriptions map[string]int              // serviceID|uri -> number of watchers
+	watchersMu    sync.Mutex
 }
 
 // NewMCPManager creates a new MCP manager
@@ -23,9 +27,11 @@ func NewMCPManager() *MCPManager {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	manager := &MCPManager{
-		clients: make(map[string]MCPClient),
-		ctx:     ctx,
-		cancel:  cancel,
+		clients:       make(map[string]MCPClient),
+		ctx:           ctx,
+		cancel:        cancel,
+		watchers:      make(map[string]*ResourceWatcher),
+		subscriptions: make(map[string]int),
 	}
 
 	// Start cleanup goroutine
@@ -88,6 +94,13 @@ func (m *MCPManager) GetOrCreateClient(service *types.MCPService) (MCPClient, er
 		return nil, err
 	}
 
+	// Subscriptions of a previous connection are lost on the server side
+	m.resetSubscriptions(service.ID)
+	serviceID := service.ID
+	client.OnResourceUpdated(func(uri string) {
+		m.dispatchResourceUpdate(serviceID, uri)
+	})
+
 	// Store client (only for non-stdio transports)
 	m.clients[service.ID] = client
 
@@ -205,6 +218,7 @@ func (m *MCPManager) cleanupIdleConnections() {
 			return
 		case <-ticker.C:
 			m.removeDisconnectedClients()
+			m.removeIdleWatchers()
 		}
 	}
 }
@@ -0,0 +1,256 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// watcherIdleTimeout is how long a session watcher is kept without activity
+const watcherIdleTimeout = time.Hour
+
+// ResourceUpdate describes a resources/updated notification received from an MCP service
+type ResourceUpdate struct {
+	ServiceID   string    `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	URI         string    `json:"uri"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ResourceWatcher collects updates of the MCP resources read within a session
+// The agent engine drains pending updates before each round so it can re-read changed resources
+type ResourceWatcher struct {
+	sessionID string
+
+	mu         sync.Mutex
+	subscribed map[string]*types.MCPService // key: serviceID + "|" + uri
+	pending    map[string]ResourceUpdate    // key: serviceID + "|" + uri
+	lastActive time.Time
+}
+
+func newResourceWatcher(sessionID string) *ResourceWatcher {
+	return &ResourceWatcher{
+		sessionID:  sessionID,
+		subscribed: make(map[string]*types.MCPService),
+		pending:    make(map[string]ResourceUpdate),
+		lastActive: time.Now(),
+	}
+}
+
+// SessionID returns the session the watcher belongs to
+func (w *ResourceWatcher) SessionID() string {
+	return w.sessionID
+}
+
+// DrainUpdates returns and clears the pending resource updates
+func (w *ResourceWatcher) DrainUpdates() []ResourceUpdate {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastActive = time.Now()
+	if len(w.pending) == 0 {
+		return nil
+	}
+	updates := make([]ResourceUpdate, 0, len(w.pending))
+	for _, update := range w.pending {
+		updates = append(updates, update)
+	}
+	w.pending = make(map[string]ResourceUpdate)
+	return updates
+}
+
+// notify records an update if the watcher subscribed to the resource (or a parent of it)
+func (w *ResourceWatcher) notify(serviceID, uri string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for key, service := range w.subscribed {
+		if service.ID != serviceID || !resourceCovers(strings.TrimPrefix(key, serviceID+"|"), uri) {
+			continue
+		}
+		w.pending[serviceID+"|"+uri] = ResourceUpdate{
+			ServiceID:   serviceID,
+			ServiceName: service.Name,
+			URI:         uri,
+			UpdatedAt:   time.Now(),
+		}
+		return true
+	}
+	return false
+}
+
+// resourceCovers reports whether a subscription to the subscribed URI covers an update of uri:
+// the same resource, or a resource below it in the path hierarchy ("file:///a" covers "file:///a/b"
+// but not "file:///ab")
+func resourceCovers(subscribed, uri string) bool {
+	if uri == subscribed {
+		return true
+	}
+	if !strings.HasPrefix(uri, subscribed) {
+		return false
+	}
+	return strings.HasSuffix(subscribed, "/") || uri[len(subscribed)] == '/'
+}
+
+func subscriptionKey(serviceID, uri string) string {
+	return serviceID + "|" + uri
+}
+
+// ResourceWatcher returns the resource watcher of a session, creating it if needed
+func (m *MCPManager) ResourceWatcher(sessionID string) *ResourceWatcher {
+	m.watchersMu.Lock()
+	defer m.watchersMu.Unlock()
+	watcher, ok := m.watchers[sessionID]
+	if !ok {
+		watcher = newResourceWatcher(sessionID)
+		m.watchers[sessionID] = watcher
+	}
+	return watcher
+}
+
+// WatchResource subscribes the watcher to updates of a resource
+// Stdio services are not cached, so their subscriptions cannot outlive a tool call and are skipped
+func (m *MCPManager) WatchResource(ctx context.Context, watcher *ResourceWatcher, service *types.MCPService, uri string) error {
+	if watcher == nil || service.TransportType == types.MCPTransportStdio {
+		return ErrSubscriptionNotSupported
+	}
+	key := subscriptionKey(service.ID, uri)
+
+	watcher.mu.Lock()
+	_, exists := watcher.subscribed[key]
+	watcher.lastActive = time.Now()
+	watcher.mu.Unlock()
+	if exists {
+		return nil
+	}
+
+	// Only the first watcher of a resource sends resources/subscribe
+	m.watchersMu.Lock()
+	refs := m.subscriptions[key]
+	m.watchersMu.Unlock()
+	if refs == 0 {
+		client, err := m.GetOrCreateClient(service)
+		if err != nil {
+			return err
+		}
+		if err := client.SubscribeResource(ctx, uri); err != nil {
+			return err
+		}
+	}
+
+	m.watchersMu.Lock()
+	m.subscriptions[key]++
+	m.watchersMu.Unlock()
+
+	watcher.mu.Lock()
+	watcher.subscribed[key] = service
+	watcher.mu.Unlock()
+	logger.Infof(ctx, "MCP resource subscribed: service=%s, uri=%s, session=%s", service.Name, uri, watcher.sessionID)
+	return nil
+}
+
+// ReleaseWatcher removes the watcher of a session and cancels subscriptions no longer used
+func (m *MCPManager) ReleaseWatcher(sessionID string) {
+	m.watchersMu.Lock()
+	watcher, ok := m.watchers[sessionID]
+	delete(m.watchers, sessionID)
+	m.watchersMu.Unlock()
+	if !ok {
+		return
+	}
+
+	watcher.mu.Lock()
+	subscribed := watcher.subscribed
+	watcher.subscribed = make(map[string]*types.MCPService)
+	watcher.mu.Unlock()
+
+	for key, service := range subscribed {
+		m.watchersMu.Lock()
+		m.subscriptions[key]--
+		remaining := m.subscriptions[key]
+		if remaining <= 0 {
+			delete(m.subscriptions, key)
+		}
+		m.watchersMu.Unlock()
+		if remaining > 0 {
+			continue
+		}
+
+		client, exists := m.GetClient(service.ID)
+		if !exists || !client.IsConnected() {
+			continue
+		}
+		uri := strings.TrimPrefix(key, service.ID+"|")
+		ctx, cancel := context.WithTimeout(m.ctx, 10*time.Second)
+		if err := client.UnsubscribeResource(ctx, uri); err != nil {
+			logger.GetLogger(m.ctx).Warnf("Failed to unsubscribe MCP resource %s: %v", uri, err)
+		}
+		cancel()
+	}
+}
+
+// dispatchResourceUpdate forwards a resources/updated notification to the watchers
+func (m *MCPManager) dispatchResourceUpdate(serviceID, uri string) {
+	m.watchersMu.Lock()
+	watchers := make([]*ResourceWatcher, 0, len(m.watchers))
+	for _, watcher := range m.watchers {
+		watchers = append(watchers, watcher)
+	}
+	m.watchersMu.Unlock()
+
+	for _, watcher := range watchers {
+		if watcher.notify(serviceID, uri) {
+			logger.GetLogger(m.ctx).Infof("MCP resource updated: service=%s, uri=%s, session=%s",
+				serviceID, uri, watcher.sessionID)
+		}
+	}
+}
+
+// removeIdleWatchers releases watchers of sessions without recent activity
+func (m *MCPManager) removeIdleWatchers() {
+	m.watchersMu.Lock()
+	idle := make([]string, 0)
+	for sessionID, watcher := range m.watchers {
+		watcher.mu.Lock()
+		if time.Since(watcher.lastActive) > watcherIdleTimeout {
+			idle = append(idle, sessionID)
+		}
+		watcher.mu.Unlock()
+	}
+	m.watchersMu.Unlock()
+
+	for _, sessionID := range idle {
+		m.ReleaseWatcher(sessionID)
+	}
+}
+
+// resetSubscriptions forgets the subscriptions of a service, watchers will subscribe again on next read
+func (m *MCPManager) resetSubscriptions(serviceID string) {
+	m.watchersMu.Lock()
+	prefix := serviceID + "|"
+	for key := range m.subscriptions {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.subscriptions, key)
+		}
+	}
+	watchers := make([]*ResourceWatcher, 0, len(m.watchers))
+	for _, watcher := range m.watchers {
+		watchers = append(watchers, watcher)
+	}
+	m.watchersMu.Unlock()
+
+	for _, watcher := range watchers {
+		watcher.mu.Lock()
+		for key := range watcher.subscribed {
+			if strings.HasPrefix(key, prefix) {
+				delete(watcher.subscribed, key)
+			}
+		}
+		watcher.mu.Unlock()
+	}
+}
@@ -0,0 +1,36 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestResourceWatcherNotify(t *testing.T) {
+	service := &types.MCPService{ID: "svc", Name: "files"}
+	tests := []struct {
+		subscribed string
+		updated    string
+		notified   bool
+	}{
+		{"file:///a", "file:///a", true},
+		{"file:///a", "file:///a/b", true},
+		{"file:///a/", "file:///a/b", true},
+		{"file:///a", "file:///ab", false},
+		{"file:///a/b", "file:///a", false},
+		{"db://orders", "db://orders_archive", false},
+	}
+	for _, tt := range tests {
+		w := newResourceWatcher("session")
+		w.subscribed[subscriptionKey(service.ID, tt.subscribed)] = service
+		if got := w.notify(service.ID, tt.updated); got != tt.notified {
+			t.Errorf("subscribed %s, updated %s: notified = %v, want %v", tt.subscribed, tt.updated, got, tt.notified)
+		}
+		if got := w.notify("other", tt.subscribed); got {
+			t.Errorf("subscribed %s: notified for another service", tt.subscribed)
+		}
+		if tt.notified && len(w.DrainUpdates()) != 1 {
+			t.Errorf("subscribed %s, updated %s: expected a pending update", tt.subscribed, tt.updated)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	knowledgeBasesURI        = "weknora://knowledge-bases"
+	knowledgeBaseURITemplate = "weknora://knowledge-bases/{kb_id}"
+	documentURITemplate      = "weknora://documents/{knowledge_id}"
+
+	// resourceDocumentLimit is the maximum number of documents listed in a knowledge base resource
+	resourceDocumentLimit = 100
+	// resourceChunkLimit is the maximum number of chunks concatenated in a document resource
+	resourceChunkLimit = 500
+)
+
+// registerResources registers knowledge bases and documents as resources
+func (s *Server) registerResources() {
+	s.mcpServer.AddResource(mcp.NewResource(knowledgeBasesURI, "Knowledge bases",
+		mcp.WithResourceDescription("The knowledge bases available to this client"),
+		mcp.WithMIMEType("application/json"),
+	), s.readKnowledgeBases)
+
+	s.mcpServer.AddResourceTemplate(mcp.NewResourceTemplate(knowledgeBaseURITemplate, "Knowledge base",
+		mcp.WithTemplateDescription("A knowledge base and its documents"),
+		mcp.WithTemplateMIMEType("application/json"),
+	), s.readKnowledgeBase)
+
+	s.mcpServer.AddResourceTemplate(mcp.NewResourceTemplate(documentURITemplate, "Document",
+		mcp.WithTemplateDescription("The parsed text content of a document"),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	), s.readDocument)
+}
+
+func (s *Server) readKnowledgeBases(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	kbs, err := s.allowedKnowledgeBases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, 0, len(kbs))
+	for _, kb := range kbs {
+		items = append(items, map[string]interface{}{
+			"uri":            strings.Replace(knowledgeBaseURITemplate, "{kb_id}", kb.ID, 1),
+			"knowledge_base": newKnowledgeBaseInfo(kb),
+		})
+	}
+	return jsonResource(req.Params.URI, items)
+}
+
+func (s *Server) readKnowledgeBase(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	kbID := resourceArgument(req, "kb_id")
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &types.Pagination{Page: 1, PageSize: resourceDocumentLimit}
+	result, err := s.knowledgeService.ListPagedKnowledgeByKnowledgeBaseID(ctx, kb.ID, page, "", "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	knowledges, _ := result.Data.([]*types.Knowledge)
+	documents := make([]map[string]interface{}, 0, len(knowledges))
+	for _, knowledge := range knowledges {
+		documents = append(documents, map[string]interface{}{
+			"uri":      strings.Replace(documentURITemplate, "{knowledge_id}", knowledge.ID, 1),
+			"document": newDocumentInfo(knowledge),
+		})
+	}
+
+	return jsonResource(req.Params.URI, map[string]interface{}{
+		"knowledge_base":  newKnowledgeBaseInfo(kb),
+		"total_documents": result.Total,
+		"documents":       documents,
+	})
+}
+
+func (s *Server) readDocument(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	knowledgeID := resourceArgument(req, "knowledge_id")
+	knowledge, err := s.getKnowledge(ctx, knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 分页读取分块（单页最多 100 条），最多拼接 resourceChunkLimit 个
+	var chunks []*types.Chunk
+	var total int64
+	for page := 1; len(chunks) < resourceChunkLimit; page++ {
+		result, err := s.chunkService.ListPagedChunksByKnowledgeID(ctx, knowledge.ID,
+			&types.Pagination{Page: page, PageSize: 100}, []types.ChunkType{types.ChunkTypeText})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list chunks: %w", err)
+		}
+		pageChunks, _ := result.Data.([]*types.Chunk)
+		chunks = append(chunks, pageChunks...)
+		total = result.Total
+		if len(pageChunks) == 0 || int64(len(chunks)) >= total {
+			break
+		}
+	}
+
+	var builder strings.Builder
+	title := knowledge.Title
+	if title == "" {
+		title = knowledge.FileName
+	}
+	builder.WriteString("# " + title + "\n\n")
+	for _, chunk := range chunks {
+		builder.WriteString(chunk.Content)
+		builder.WriteString("\n\n")
+	}
+	if total > int64(len(chunks)) {
+		builder.WriteString(fmt.Sprintf("[... %d more chunks, use the list_chunks tool to read them]\n",
+			total-int64(len(chunks))))
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: "text/markdown",
+			Text:     builder.String(),
+		},
+	}, nil
+}
+
+// resourceArgument returns a variable matched from the resource URI template
+func resourceArgument(req mcp.ReadResourceRequest, name string) string {
+	switch value := req.Params.Arguments[name].(type) {
+	case string:
+		return value
+	case []string:
+		if len(value) > 0 {
+			return value[0]
+		}
+	}
+	return ""
+}
+
+// jsonResource returns the data as a JSON text resource
+func jsonResource(uri string, data interface{}) ([]mcp.ResourceContents, error) {
+	bytes, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource: %w", err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(bytes),
+		},
+	}, nil
+}
@@ -0,0 +1,119 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KnowledgeBaseScopeHeader lets an MCP client narrow the knowledge bases published by the tenant (comma separated)
+// The same restriction can be set with the "kb_ids" query parameter of the endpoint URL
+const KnowledgeBaseScopeHeader = "X-Knowledge-Base-IDs"
+
+type scopeContextKey struct{}
+
+// parseScope reads the knowledge base IDs requested by the client, nil means all published knowledge bases
+func parseScope(r *http.Request) []string {
+	raw := r.Header.Get(KnowledgeBaseScopeHeader)
+	if raw == "" {
+		raw = r.URL.Query().Get("kb_ids")
+	}
+	var ids []string
+	for _, id := range strings.Split(raw, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func withScope(ctx context.Context, kbIDs []string) context.Context {
+	return context.WithValue(ctx, scopeContextKey{}, kbIDs)
+}
+
+func scopeFromContext(ctx context.Context) []string {
+	ids, _ := ctx.Value(scopeContextKey{}).([]string)
+	return ids
+}
+
+// tenantFromContext returns the authenticated tenant ID
+func tenantFromContext(ctx context.Context) (uint64, error) {
+	tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64)
+	if !ok || tenantID == 0 {
+		return 0, fmt.Errorf("unauthenticated request")
+	}
+	return tenantID, nil
+}
+
+// publishedKnowledgeBases returns the knowledge bases the tenant publishes through the MCP server,
+// configured server side so that a client can never widen its own scope
+func publishedKnowledgeBases(ctx context.Context) []string {
+	tenant, _ := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil || tenant.MCPServerConfig == nil {
+		return nil
+	}
+	return tenant.MCPServerConfig.KnowledgeBaseIDs
+}
+
+// inScope reports whether the knowledge base is published by the tenant and requested by the client
+func inScope(ctx context.Context, kbID string) bool {
+	if !slices.Contains(publishedKnowledgeBases(ctx), kbID) {
+		return false
+	}
+	scope := scopeFromContext(ctx)
+	return len(scope) == 0 || slices.Contains(scope, kbID)
+}
+
+// allowedKnowledgeBases lists the knowledge bases of the tenant visible to the client
+func (s *Server) allowedKnowledgeBases(ctx context.Context) ([]*types.KnowledgeBase, error) {
+	if _, err := tenantFromContext(ctx); err != nil {
+		return nil, err
+	}
+	kbs, err := s.kbService.ListKnowledgeBases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list knowledge bases: %w", err)
+	}
+	result := make([]*types.KnowledgeBase, 0, len(kbs))
+	for _, kb := range kbs {
+		if kb.IsTemporary || !inScope(ctx, kb.ID) {
+			continue
+		}
+		result = append(result, kb)
+	}
+	return result, nil
+}
+
+// getKnowledgeBase returns the knowledge base if it belongs to the tenant and is in scope
+func (s *Server) getKnowledgeBase(ctx context.Context, kbID string) (*types.KnowledgeBase, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !inScope(ctx, kbID) {
+		return nil, fmt.Errorf("knowledge base %s is not accessible", kbID)
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID || kb.IsTemporary {
+		return nil, fmt.Errorf("knowledge base %s is not accessible", kbID)
+	}
+	return kb, nil
+}
+
+// getKnowledge returns the document if its knowledge base is accessible
+func (s *Server) getKnowledge(ctx context.Context, knowledgeID string) (*types.Knowledge, error) {
+	if _, err := tenantFromContext(ctx); err != nil {
+		return nil, err
+	}
+	knowledge, err := s.knowledgeService.GetKnowledgeByID(ctx, knowledgeID)
+	if err != nil || knowledge == nil {
+		return nil, fmt.Errorf("document %s is not accessible", knowledgeID)
+	}
+	if _, err := s.getKnowledgeBase(ctx, knowledge.KnowledgeBaseID); err != nil {
+		return nil, fmt.Errorf("document %s is not accessible", knowledgeID)
+	}
+	return knowledge, nil
+}
@@ -0,0 +1,42 @@
+package mcpserver
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInScope(t *testing.T) {
+	tenant := &types.Tenant{
+		ID:              1,
+		MCPServerConfig: &types.TenantMCPServerConfig{KnowledgeBaseIDs: []string{"kb1", "kb2"}},
+	}
+	ctx := context.WithValue(context.Background(), types.TenantInfoContextKey, tenant)
+
+	// 客户端未指定范围时可访问租户发布的全部知识库
+	assert.True(t, inScope(ctx, "kb1"))
+	assert.True(t, inScope(ctx, "kb2"))
+	assert.False(t, inScope(ctx, "kb3"))
+
+	// 客户端只能缩小范围，不能扩大
+	narrowed := withScope(ctx, []string{"kb2", "kb3"})
+	assert.False(t, inScope(narrowed, "kb1"))
+	assert.True(t, inScope(narrowed, "kb2"))
+	assert.False(t, inScope(narrowed, "kb3"))
+
+	// 未发布知识库的租户不可访问任何知识库
+	unpublished := context.WithValue(context.Background(), types.TenantInfoContextKey, &types.Tenant{ID: 2})
+	assert.False(t, inScope(unpublished, "kb1"))
+	assert.False(t, inScope(withScope(unpublished, []string{"kb1"}), "kb1"))
+}
+
+func TestParseScope(t *testing.T) {
+	r := httptest.NewRequest("GET", "/mcp?kb_ids=kb3", nil)
+	assert.Equal(t, []string{"kb3"}, parseScope(r))
+
+	r.Header.Set(KnowledgeBaseScopeHeader, " kb1, ,kb2 ")
+	assert.Equal(t, []string{"kb1", "kb2"}, parseScope(r))
+}
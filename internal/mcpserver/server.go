@@ -0,0 +1,110 @@
+// Package mcpserver exposes WeKnora knowledge bases to external MCP clients.
+// Knowledge search, chunk listing, document info and FAQ search are published as tools,
+// knowledge bases and documents as resources. Requests are authenticated with tenant API keys and
+// only reach the knowledge bases the tenant publishes, which the client can narrow further.
+package mcpserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	serverName        = "weknora"
+	serverVersion     = "1.0.0"
+	defaultBasePath   = "/mcp"
+	defaultMaxResults = 10
+)
+
+// Server is the built-in MCP server of WeKnora
+type Server struct {
+	basePath   string
+	maxResults int
+
+	mcpServer  *server.MCPServer
+	streamable *server.StreamableHTTPServer
+	sse        *server.SSEServer
+
+	kbService        interfaces.KnowledgeBaseService
+	knowledgeService interfaces.KnowledgeService
+	chunkService     interfaces.ChunkService
+	sessionService   interfaces.SessionService
+}
+
+// NewServer creates the MCP server; it returns nil when the server is disabled in config
+func NewServer(
+	cfg *config.Config,
+	kbService interfaces.KnowledgeBaseService,
+	knowledgeService interfaces.KnowledgeService,
+	chunkService interfaces.ChunkService,
+	sessionService interfaces.SessionService,
+) *Server {
+	if cfg.MCPServer == nil || !cfg.MCPServer.Enabled {
+		return nil
+	}
+
+	s := &Server{
+		basePath:         defaultBasePath,
+		maxResults:       defaultMaxResults,
+		kbService:        kbService,
+		knowledgeService: knowledgeService,
+		chunkService:     chunkService,
+		sessionService:   sessionService,
+	}
+	if basePath := strings.TrimRight(cfg.MCPServer.BasePath, "/"); basePath != "" {
+		s.basePath = basePath
+	}
+	if cfg.MCPServer.MaxResults > 0 {
+		s.maxResults = cfg.MCPServer.MaxResults
+	}
+
+	s.mcpServer = server.NewMCPServer(serverName, serverVersion,
+		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(false, false),
+		server.WithRecovery(),
+		server.WithInstructions("Search and read the knowledge bases of the authenticated WeKnora tenant."),
+	)
+	s.registerTools()
+	s.registerResources()
+
+	s.streamable = server.NewStreamableHTTPServer(s.mcpServer,
+		server.WithEndpointPath(s.basePath),
+		server.WithHTTPContextFunc(withRequestScope),
+	)
+	s.sse = server.NewSSEServer(s.mcpServer,
+		server.WithStaticBasePath(s.basePath),
+		server.WithSSEContextFunc(withRequestScope),
+	)
+	return s
+}
+
+// BasePath returns the path the MCP endpoints are mounted on
+func (s *Server) BasePath() string {
+	return s.basePath
+}
+
+// StreamableHTTPHandler returns the handler of the streamable HTTP transport ({base_path})
+func (s *Server) StreamableHTTPHandler() http.Handler {
+	return s.streamable
+}
+
+// SSEHandler returns the handler of the SSE stream endpoint ({base_path}/sse)
+func (s *Server) SSEHandler() http.Handler {
+	return s.sse.SSEHandler()
+}
+
+// MessageHandler returns the handler of the SSE message endpoint ({base_path}/message)
+func (s *Server) MessageHandler() http.Handler {
+	return s.sse.MessageHandler()
+}
+
+// withRequestScope reads the knowledge base scope requested by the client into the context
+// The tenant is already in the request context, placed there by the MCP auth middleware
+func withRequestScope(ctx context.Context, r *http.Request) context.Context {
+	return withScope(ctx, parseScope(r))
+}
@@ -0,0 +1,335 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxChunkPageSize is the maximum page size of the list_chunks tool
+const maxChunkPageSize = 50
+
+// knowledgeBaseInfo is the public view of a knowledge base (configs with credentials are omitted)
+type knowledgeBaseInfo struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Description    string `json:"description"`
+	KnowledgeCount int64  `json:"knowledge_count"`
+	ChunkCount     int64  `json:"chunk_count"`
+}
+
+// documentInfo is the public view of a document
+type documentInfo struct {
+	ID              string            `json:"id"`
+	KnowledgeBaseID string            `json:"knowledge_base_id"`
+	Title           string            `json:"title"`
+	Description     string            `json:"description,omitempty"`
+	Type            string            `json:"type"`
+	Source          string            `json:"source,omitempty"`
+	FileName        string            `json:"file_name,omitempty"`
+	FileType        string            `json:"file_type,omitempty"`
+	FileSize        int64             `json:"file_size,omitempty"`
+	ParseStatus     string            `json:"parse_status"`
+	EnableStatus    string            `json:"enable_status"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	CreatedAt       string            `json:"created_at"`
+	UpdatedAt       string            `json:"updated_at"`
+}
+
+// chunkInfo is the public view of a chunk
+type chunkInfo struct {
+	ID         string `json:"id"`
+	ChunkIndex int    `json:"chunk_index"`
+	ChunkType  string `json:"chunk_type"`
+	Content    string `json:"content"`
+}
+
+// searchResultInfo is the public view of a search hit
+type searchResultInfo struct {
+	ChunkID        string  `json:"chunk_id"`
+	KnowledgeID    string  `json:"knowledge_id"`
+	KnowledgeTitle string  `json:"knowledge_title"`
+	ChunkIndex     int     `json:"chunk_index"`
+	Score          float64 `json:"score"`
+	MatchType      string  `json:"match_type"`
+	Content        string  `json:"content"`
+}
+
+// faqResultInfo is the public view of a matched FAQ entry
+type faqResultInfo struct {
+	ID               string   `json:"id"`
+	KnowledgeBaseID  string   `json:"knowledge_base_id"`
+	StandardQuestion string   `json:"standard_question"`
+	SimilarQuestions []string `json:"similar_questions,omitempty"`
+	Answers          []string `json:"answers"`
+	Score            float64  `json:"score"`
+}
+
+func newKnowledgeBaseInfo(kb *types.KnowledgeBase) knowledgeBaseInfo {
+	return knowledgeBaseInfo{
+		ID:             kb.ID,
+		Name:           kb.Name,
+		Type:           kb.Type,
+		Description:    kb.Description,
+		KnowledgeCount: kb.KnowledgeCount,
+		ChunkCount:     kb.ChunkCount,
+	}
+}
+
+func newDocumentInfo(knowledge *types.Knowledge) documentInfo {
+	return documentInfo{
+		ID:              knowledge.ID,
+		KnowledgeBaseID: knowledge.KnowledgeBaseID,
+		Title:           knowledge.Title,
+		Description:     knowledge.Description,
+		Type:            knowledge.Type,
+		Source:          knowledge.Source,
+		FileName:        knowledge.FileName,
+		FileType:        knowledge.FileType,
+		FileSize:        knowledge.FileSize,
+		ParseStatus:     knowledge.ParseStatus,
+		EnableStatus:    knowledge.EnableStatus,
+		Metadata:        knowledge.GetMetadata(),
+		CreatedAt:       knowledge.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:       knowledge.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// registerTools registers the knowledge tools
+func (s *Server) registerTools() {
+	s.mcpServer.AddTool(mcp.NewTool("list_knowledge_bases",
+		mcp.WithDescription("List the knowledge bases available to this client."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleListKnowledgeBases)
+
+	s.mcpServer.AddTool(mcp.NewTool("knowledge_search",
+		mcp.WithDescription("Hybrid (vector + keyword) search over document knowledge bases. "+
+			"Returns the most relevant chunks with their document titles."),
+		mcp.WithString("query", mcp.Required(), mcp.Description("The search query")),
+		mcp.WithArray("knowledge_base_ids", mcp.WithStringItems(),
+			mcp.Description("Knowledge bases to search, all accessible knowledge bases when omitted")),
+		mcp.WithArray("knowledge_ids", mcp.WithStringItems(),
+			mcp.Description("Restrict the search to these documents")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleKnowledgeSearch)
+
+	s.mcpServer.AddTool(mcp.NewTool("list_chunks",
+		mcp.WithDescription("List the chunks of a document in order, with pagination."),
+		mcp.WithString("knowledge_id", mcp.Required(), mcp.Description("The document ID")),
+		mcp.WithNumber("page", mcp.Description("Page number, starting from 1"), mcp.Min(1)),
+		mcp.WithNumber("page_size", mcp.Description("Chunks per page (max 50)"), mcp.Min(1), mcp.Max(maxChunkPageSize)),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleListChunks)
+
+	s.mcpServer.AddTool(mcp.NewTool("get_document",
+		mcp.WithDescription("Get the information of a document: title, type, source, parse status and metadata."),
+		mcp.WithString("knowledge_id", mcp.Required(), mcp.Description("The document ID")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleGetDocument)
+
+	s.mcpServer.AddTool(mcp.NewTool("faq_search",
+		mcp.WithDescription("Search FAQ knowledge bases for questions similar to the query and return their answers."),
+		mcp.WithString("query", mcp.Required(), mcp.Description("The question to match")),
+		mcp.WithArray("knowledge_base_ids", mcp.WithStringItems(),
+			mcp.Description("FAQ knowledge bases to search, all accessible FAQ knowledge bases when omitted")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleFAQSearch)
+}
+
+func (s *Server) handleListKnowledgeBases(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	kbs, err := s.allowedKnowledgeBases(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	result := make([]knowledgeBaseInfo, 0, len(kbs))
+	for _, kb := range kbs {
+		result = append(result, newKnowledgeBaseInfo(kb))
+	}
+	return jsonResult(result)
+}
+
+func (s *Server) handleKnowledgeSearch(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := req.RequireString("query")
+	if err != nil || query == "" {
+		return mcp.NewToolResultError("query is required"), nil
+	}
+
+	knowledgeIDs := req.GetStringSlice("knowledge_ids", nil)
+	for _, knowledgeID := range knowledgeIDs {
+		if _, err := s.getKnowledge(ctx, knowledgeID); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	// 指定了文档时只在这些文档中检索，除非同时显式指定了知识库
+	var kbIDs []string
+	requestedKBs := req.GetStringSlice("knowledge_base_ids", nil)
+	if len(requestedKBs) > 0 || len(knowledgeIDs) == 0 {
+		kbIDs, err = s.resolveKnowledgeBases(ctx, requestedKBs, types.KnowledgeBaseTypeDocument)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	if len(kbIDs) == 0 && len(knowledgeIDs) == 0 {
+		return jsonResult([]searchResultInfo{})
+	}
+
+	results, err := s.sessionService.SearchKnowledge(ctx, kbIDs, knowledgeIDs, query)
+	if err != nil {
+		logger.Errorf(ctx, "MCP knowledge search failed: %v", err)
+		return mcp.NewToolResultErrorf("search failed: %v", err), nil
+	}
+
+	items := make([]searchResultInfo, 0, len(results))
+	for _, r := range results {
+		if len(items) >= s.maxResults {
+			break
+		}
+		items = append(items, searchResultInfo{
+			ChunkID:        r.ID,
+			KnowledgeID:    r.KnowledgeID,
+			KnowledgeTitle: r.KnowledgeTitle,
+			ChunkIndex:     r.ChunkIndex,
+			Score:          r.Score,
+			MatchType:      fmt.Sprintf("%v", r.MatchType),
+			Content:        r.Content,
+		})
+	}
+	return jsonResult(items)
+}
+
+func (s *Server) handleListChunks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	knowledgeID, err := req.RequireString("knowledge_id")
+	if err != nil {
+		return mcp.NewToolResultError("knowledge_id is required"), nil
+	}
+	if _, err := s.getKnowledge(ctx, knowledgeID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	page := &types.Pagination{
+		Page:     req.GetInt("page", 1),
+		PageSize: min(req.GetInt("page_size", 20), maxChunkPageSize),
+	}
+	result, err := s.chunkService.ListPagedChunksByKnowledgeID(ctx, knowledgeID, page,
+		[]types.ChunkType{types.ChunkTypeText})
+	if err != nil {
+		return mcp.NewToolResultErrorf("failed to list chunks: %v", err), nil
+	}
+
+	chunks, _ := result.Data.([]*types.Chunk)
+	items := make([]chunkInfo, 0, len(chunks))
+	for _, chunk := range chunks {
+		items = append(items, chunkInfo{
+			ID:         chunk.ID,
+			ChunkIndex: chunk.ChunkIndex,
+			ChunkType:  string(chunk.ChunkType),
+			Content:    chunk.Content,
+		})
+	}
+	return jsonResult(map[string]interface{}{
+		"total":     result.Total,
+		"page":      result.Page,
+		"page_size": result.PageSize,
+		"chunks":    items,
+	})
+}
+
+func (s *Server) handleGetDocument(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	knowledgeID, err := req.RequireString("knowledge_id")
+	if err != nil {
+		return mcp.NewToolResultError("knowledge_id is required"), nil
+	}
+	knowledge, err := s.getKnowledge(ctx, knowledgeID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return jsonResult(newDocumentInfo(knowledge))
+}
+
+func (s *Server) handleFAQSearch(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := req.RequireString("query")
+	if err != nil || query == "" {
+		return mcp.NewToolResultError("query is required"), nil
+	}
+
+	kbIDs, err := s.resolveKnowledgeBases(ctx, req.GetStringSlice("knowledge_base_ids", nil), types.KnowledgeBaseTypeFAQ)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	items := make([]faqResultInfo, 0)
+	for _, kbID := range kbIDs {
+		entries, err := s.knowledgeService.SearchFAQEntries(ctx, kbID, &types.FAQSearchRequest{
+			QueryText:  query,
+			MatchCount: s.maxResults,
+		})
+		if err != nil {
+			logger.Warnf(ctx, "MCP FAQ search failed in knowledge base %s: %v", kbID, err)
+			continue
+		}
+		for _, entry := range entries {
+			items = append(items, faqResultInfo{
+				ID:               entry.ID,
+				KnowledgeBaseID:  kbID,
+				StandardQuestion: entry.StandardQuestion,
+				SimilarQuestions: entry.SimilarQuestions,
+				Answers:          entry.Answers,
+				Score:            entry.Score,
+			})
+		}
+	}
+	return jsonResult(items)
+}
+
+// resolveKnowledgeBases validates the requested knowledge bases of the given type,
+// defaulting to every accessible knowledge base of that type
+func (s *Server) resolveKnowledgeBases(ctx context.Context, requested []string, kbType string) ([]string, error) {
+	if len(requested) == 0 {
+		kbs, err := s.allowedKnowledgeBases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(kbs))
+		for _, kb := range kbs {
+			if isKnowledgeBaseType(kb, kbType) {
+				ids = append(ids, kb.ID)
+			}
+		}
+		return ids, nil
+	}
+
+	ids := make([]string, 0, len(requested))
+	for _, kbID := range requested {
+		kb, err := s.getKnowledgeBase(ctx, kbID)
+		if err != nil {
+			return nil, err
+		}
+		if !isKnowledgeBaseType(kb, kbType) {
+			return nil, fmt.Errorf("knowledge base %s is not a %s knowledge base", kbID, kbType)
+		}
+		ids = append(ids, kbID)
+	}
+	return ids, nil
+}
+
+// isKnowledgeBaseType reports whether the knowledge base has the given type; legacy ones have an empty type
+func isKnowledgeBaseType(kb *types.KnowledgeBase, kbType string) bool {
+	if kb.Type == "" {
+		return kbType == types.KnowledgeBaseTypeDocument
+	}
+	return kb.Type == kbType
+}
+
+// jsonResult returns the data as indented JSON text
+func jsonResult(data interface{}) (*mcp.CallToolResult, error) {
+	bytes, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return mcp.NewToolResultText(string(bytes)), nil
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AsynqMiddleware records processing time and outcome of every task handled by the mux
+func AsynqMiddleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		start := time.Now()
+		err := next.ProcessTask(ctx, t)
+		ObserveTask(t.Type(), Status(err), time.Since(start))
+		return err
+	})
+}
+
+// queueCollector reports asynq queue depth by state at scrape time
+type queueCollector struct {
+	inspector *asynq.Inspector
+	size      *prometheus.Desc
+	latency   *prometheus.Desc
+	paused    *prometheus.Desc
+}
+
+// NewQueueCollector creates a collector reading queue statistics from the asynq inspector
+func NewQueueCollector(inspector *asynq.Inspector) prometheus.Collector {
+	return &queueCollector{
+		inspector: inspector,
+		size: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "tasks"),
+			"Number of tasks in the queue by state",
+			[]string{"queue", "state"}, nil,
+		),
+		latency: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "latency_seconds"),
+			"Time the oldest pending task has been waiting in the queue",
+			[]string{"queue"}, nil,
+		),
+		paused: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "paused"),
+			"Whether the queue is paused (1) or not (0)",
+			[]string{"queue"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.size
+	ch <- c.latency
+	ch <- c.paused
+}
+
+// Collect implements prometheus.Collector
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	queues, err := c.inspector.Queues()
+	if err != nil {
+		return
+	}
+	for _, queue := range queues {
+		info, err := c.inspector.GetQueueInfo(queue)
+		if err != nil {
+			continue
+		}
+		states := map[string]int{
+			"pending":   info.Pending,
+			"active":    info.Active,
+			"scheduled": info.Scheduled,
+			"retry":     info.Retry,
+			"archived":  info.Archived,
+			"completed": info.Completed,
+		}
+		for state, count := range states {
+			ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(count), queue, state)
+		}
+		ch <- prometheus.MustNewConstMetric(c.latency, prometheus.GaugeValue, info.Latency.Seconds(), queue)
+		paused := 0.0
+		if info.Paused {
+			paused = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.paused, prometheus.GaugeValue, paused, queue)
+	}
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// circuitBreakerStates maps the circuit breaker state names to gauge values
+var circuitBreakerStates = map[string]float64{
+	utils.StateClosed.String():   0,
+	utils.StateOpen.String():     1,
+	utils.StateHalfOpen.String(): 2,
+}
+
+// circuitBreakerCollector reports the state of the global circuit breakers at scrape time
+type circuitBreakerCollector struct {
+	state    *prometheus.Desc
+	failures *prometheus.Desc
+}
+
+func newCircuitBreakerCollector() *circuitBreakerCollector {
+	return &circuitBreakerCollector{
+		state: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "circuit_breaker", "state"),
+			"Circuit breaker state (0=closed, 1=open, 2=half-open)",
+			[]string{"name"}, nil,
+		),
+		failures: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "circuit_breaker", "failures"),
+			"Consecutive failures recorded by the circuit breaker",
+			[]string{"name"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *circuitBreakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.state
+	ch <- c.failures
+}
+
+// Collect implements prometheus.Collector
+func (c *circuitBreakerCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, stats := range utils.GetAllCircuitBreakerStats() {
+		if state, ok := stats["state"].(string); ok {
+			if value, known := circuitBreakerStates[state]; known {
+				ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, value, name)
+			}
+		}
+		if failures, ok := stats["failures"].(int); ok {
+			ch <- prometheus.MustNewConstMetric(c.failures, prometheus.GaugeValue, float64(failures), name)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package metrics exposes Prometheus metrics of the application.
+// Collectors are registered on a dedicated registry served by Handler.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "weknora"
+
+// Status label values
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status code",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Number of HTTP requests being served",
+	})
+
+	pipelineStageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "stage_duration_seconds",
+		Help:      "Chat pipeline stage duration by event type and outcome",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"stage", "result"})
+
+	agentRounds = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "agent",
+		Name:      "rounds_total",
+		Help:      "Total number of agent ReAct rounds",
+	})
+
+	agentRoundsPerRun = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "agent",
+		Name:      "rounds_per_run",
+		Help:      "Number of rounds used by an agent run",
+		Buckets:   []float64{1, 2, 3, 4, 5, 6, 8, 10, 15, 20, 30, 50},
+	})
+
+	agentToolDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "agent",
+		Name:      "tool_call_duration_seconds",
+		Help:      "Agent tool call latency by tool and outcome",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"tool", "status"})
+
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "duration_seconds",
+		Help:      "Asynchronous task processing time by task type and outcome",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600, 1800},
+	}, []string{"task_type", "status"})
+
+	modelRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "model",
+		Name:      "request_duration_seconds",
+		Help:      "Model call latency by model type, model and outcome",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"type", "model", "status"})
+
+	modelTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "model",
+		Name:      "tokens_total",
+		Help:      "Tokens consumed by chat models, split into prompt and completion tokens",
+	}, []string{"model", "kind", "estimated"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		httpRequestsInFlight,
+		pipelineStageDuration,
+		agentRounds,
+		agentRoundsPerRun,
+		agentToolDuration,
+		taskDuration,
+		modelRequestDuration,
+		modelTokens,
+		newCircuitBreakerCollector(),
+	)
+}
+
+// Handler returns the HTTP handler serving metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// Register adds extra collectors to the metrics registry
+func Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status returns the status label value of an error
+func Status(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusSuccess
+}
+
+// HTTPRequestStarted tracks a request in flight, call the returned function when it is done
+func HTTPRequestStarted() func() {
+	httpRequestsInFlight.Inc()
+	return httpRequestsInFlight.Dec
+}
+
+// ObserveHTTPRequest records the latency of a served HTTP request
+func ObserveHTTPRequest(method, route, status string, duration time.Duration) {
+	httpRequestDuration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
+
+// ObservePipelineStage records the duration of a chat pipeline stage
+// result is "success" or the plugin error type
+func ObservePipelineStage(stage, result string, duration time.Duration) {
+	pipelineStageDuration.WithLabelValues(stage, result).Observe(duration.Seconds())
+}
+
+// IncAgentRound counts an agent round
+func IncAgentRound() {
+	agentRounds.Inc()
+}
+
+// ObserveAgentRun records the number of rounds used by a finished agent run
+func ObserveAgentRun(rounds int) {
+	agentRoundsPerRun.Observe(float64(rounds))
+}
+
+// ObserveToolCall records the latency and outcome of an agent tool call
+func ObserveToolCall(tool, status string, duration time.Duration) {
+	agentToolDuration.WithLabelValues(tool, status).Observe(duration.Seconds())
+}
+
+// ObserveTask records the processing time and outcome of an asynchronous task
+func ObserveTask(taskType, status string, duration time.Duration) {
+	taskDuration.WithLabelValues(taskType, status).Observe(duration.Seconds())
+}
+
+// ObserveModelRequest records the latency and outcome of a model call
+// modelType is one of "chat", "chat_stream", "embedding" or "rerank"
+func ObserveModelRequest(modelType, model, status string, duration time.Duration) {
+	modelRequestDuration.WithLabelValues(modelType, model, status).Observe(duration.Seconds())
+}
+
+// AddModelTokens counts the prompt and completion tokens used by a chat model
+// estimated marks counts estimated from the message lengths because the model reported no usage
+func AddModelTokens(model string, promptTokens, completionTokens int, estimated bool) {
+	estimatedLabel := strconv.FormatBool(estimated)
+	if promptTokens > 0 {
+		modelTokens.WithLabelValues(model, "prompt", estimatedLabel).Add(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		modelTokens.WithLabelValues(model, "completion", estimatedLabel).Add(float64(completionTokens))
+	}
+}
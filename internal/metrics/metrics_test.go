@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+func TestHandler_ExposesMetrics(t *testing.T) {
+	ObserveHTTPRequest("GET", "/api/v1/sessions/:id", "200", 20*time.Millisecond)
+	ObservePipelineStage("chunk_search", StatusSuccess, time.Second)
+	ObserveToolCall("knowledge_search", StatusError, time.Second)
+	ObserveModelRequest("chat", "test-model", StatusSuccess, time.Second)
+	AddModelTokens("test-model", 10, 5, false)
+	utils.GetCircuitBreaker("metrics-test")
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", recorder.Code)
+	}
+
+	body := recorder.Body.String()
+	for _, want := range []string{
+		`weknora_http_request_duration_seconds_count{method="GET",route="/api/v1/sessions/:id",status="200"} 1`,
+		`weknora_pipeline_stage_duration_seconds_count{result="success",stage="chunk_search"} 1`,
+		`weknora_agent_tool_call_duration_seconds_count{status="error",tool="knowledge_search"} 1`,
+		`weknora_model_tokens_total{estimated="false",kind="prompt",model="test-model"} 10`,
+		`weknora_circuit_breaker_state{name="metrics-test"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
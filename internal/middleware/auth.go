@@ -21,6 +21,7 @@ var noAuthAPI = map[string][]string{
 	"/api/v1/auth/register": {"POST"},
 	"/api/v1/auth/login":    {"POST"},
 	"/api/v1/auth/refresh":  {"POST"},
+	"/api/v1/shared/*":      {"GET"},
 }
 
 // 检查请求是否在无需认证的API列表中
@@ -109,6 +110,9 @@ func Auth(
 					"user", defaultUser,
 				),
 			)
+			c.Request = c.Request.WithContext(
+				context.WithValue(c.Request.Context(), types.UserIDContextKey, defaultUser.ID),
+			)
 			
 			log.Printf("[Auth Middleware] User set in context, path: %s", c.Request.URL.Path)
 			c.Next()
@@ -186,6 +190,9 @@ func Auth(
 						"user", user,
 					),
 				)
+				c.Request = c.Request.WithContext(
+					context.WithValue(c.Request.Context(), types.UserIDContextKey, user.ID),
+				)
 				c.Next()
 				return
 			}
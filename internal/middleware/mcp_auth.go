@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// MCPAuth 内置 MCP Server 的认证中间件
+// 仅支持租户 API Key，可通过 X-API-Key 或 Authorization: Bearer <api_key> 传递（便于 MCP 客户端配置）
+func MCPAuth(tenantService interfaces.TenantService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		var tenant *types.Tenant
+		if cfg != nil && cfg.Server != nil && cfg.Server.DisableAuth {
+			t, err := tenantService.GetOrCreateDefaultTenant(c.Request.Context())
+			if err != nil {
+				logger.Errorf(c.Request.Context(), "[MCP Auth] Error getting or creating default tenant: %v", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize default tenant"})
+				return
+			}
+			tenant = t
+		} else {
+			apiKey := c.GetHeader("X-API-Key")
+			if apiKey == "" {
+				apiKey = strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+			}
+			if apiKey == "" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: missing API key"})
+				return
+			}
+
+			tenantID, err := tenantService.ExtractTenantIDFromAPIKey(apiKey)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: invalid API key format"})
+				return
+			}
+			t, err := tenantService.GetTenantByID(c.Request.Context(), tenantID)
+			if err != nil || t == nil || subtle.ConstantTimeCompare([]byte(t.APIKey), []byte(apiKey)) != 1 {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: invalid API key"})
+				return
+			}
+			tenant = t
+		}
+
+		c.Set(types.TenantIDContextKey.String(), tenant.ID)
+		c.Set(types.TenantInfoContextKey.String(), tenant)
+		c.Request = c.Request.WithContext(
+			context.WithValue(
+				context.WithValue(c.Request.Context(), types.TenantIDContextKey, tenant.ID),
+				types.TenantInfoContextKey, tenant,
+			),
+		)
+		c.Next()
+	}
+}
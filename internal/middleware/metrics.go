@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 记录 HTTP 请求的延迟与状态码（按路由模板聚合，避免路径参数导致标签膨胀）
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		done := metrics.HTTPRequestStarted()
+		defer done()
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTPRequest(c.Request.Method, route, strconv.Itoa(c.Writer.Status()), time.Since(start))
+	}
+}
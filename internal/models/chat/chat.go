@@ -2,6 +2,7 @@ package chat
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -46,6 +47,7 @@ type Message struct {
 	Name       string     `json:"name,omitempty"`         // Function/tool name (for tool role)
 	ToolCallID string     `json:"tool_call_id,omitempty"` // Tool call ID (for tool role)
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // Tool calls (for assistant role)
+	Images     []string   `json:"images,omitempty"`       // 图片附件（URL 或 data URI），仅 user 角色，适配器会转换为多模态内容
 }
 
 // ToolCall represents a tool call in a message
@@ -86,8 +88,16 @@ type ChatConfig struct {
 	Extra     map[string]any
 }
 
-// NewChat 创建聊天实例
+// NewChat 创建聊天实例（附带调用指标统计）
 func NewChat(config *ChatConfig) (Chat, error) {
+	chat, err := newChat(config)
+	if err != nil || chat == nil {
+		return chat, err
+	}
+	return withMetrics(chat), nil
+}
+
+func newChat(config *ChatConfig) (Chat, error) {
 	var chat Chat
 	var err error
 	switch strings.ToLower(string(config.Source)) {
@@ -105,3 +115,28 @@ func NewChat(config *ChatConfig) (Chat, error) {
 		return nil, fmt.Errorf("unsupported chat model source: %s", config.Source)
 	}
 }
+
+// BuildImageDataURI 将图片内容编码为 data URI，供多模态模型使用
+func BuildImageDataURI(mimeType string, data []byte) string {
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data))
+}
+
+// decodeImageDataURI 解析 data URI，返回原始图片字节
+// 非 data URI（如 http 链接）返回 false
+func decodeImageDataURI(uri string) ([]byte, bool) {
+	if !strings.HasPrefix(uri, "data:") {
+		return nil, false
+	}
+	idx := strings.Index(uri, ";base64,")
+	if idx < 0 {
+		return nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(uri[idx+len(";base64,"):])
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
@@ -0,0 +1,106 @@
+package chat
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// metricsChat records latency, outcome and token usage of the wrapped chat model
+type metricsChat struct {
+	inner Chat
+}
+
+func withMetrics(chat Chat) Chat {
+	return &metricsChat{inner: chat}
+}
+
+// GetModelName 获取模型名称
+func (c *metricsChat) GetModelName() string {
+	return c.inner.GetModelName()
+}
+
+// GetModelID 获取模型ID
+func (c *metricsChat) GetModelID() string {
+	return c.inner.GetModelID()
+}
+
+// Chat 进行非流式聊天，并记录延迟与 token 用量
+func (c *metricsChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	start := time.Now()
+	resp, err := c.inner.Chat(ctx, messages, opts)
+	metrics.ObserveModelRequest("chat", c.GetModelName(), metrics.Status(err), time.Since(start))
+	if resp != nil {
+		metrics.AddModelTokens(c.GetModelName(), resp.Usage.PromptTokens, resp.Usage.CompletionTokens, false)
+	}
+	return resp, err
+}
+
+// ChatStream 进行流式聊天，流结束时记录总耗时与 token 用量
+// 模型未在最后一个数据块中返回用量时，按消息长度估算并标记为估算值
+func (c *metricsChat) ChatStream(
+	ctx context.Context, messages []Message, opts *ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	start := time.Now()
+	stream, err := c.inner.ChatStream(ctx, messages, opts)
+	if err != nil {
+		metrics.ObserveModelRequest("chat_stream", c.GetModelName(), metrics.StatusError, time.Since(start))
+		return stream, err
+	}
+
+	out := make(chan types.StreamResponse)
+	go func() {
+		defer close(out)
+		status := metrics.StatusSuccess
+		var usage *types.TokenUsage
+		var toolCalls []types.LLMToolCall
+		completionBytes := 0
+		for resp := range stream {
+			switch resp.ResponseType {
+			case types.ResponseTypeError:
+				status = metrics.StatusError
+			case types.ResponseTypeAnswer, types.ResponseTypeThinking:
+				completionBytes += len(resp.Content)
+			}
+			if resp.Usage != nil {
+				usage = resp.Usage
+			}
+			// 工具调用在各数据块中累积返回，以最后一次为准
+			if len(resp.ToolCalls) > 0 {
+				toolCalls = resp.ToolCalls
+			}
+			out <- resp
+		}
+		metrics.ObserveModelRequest("chat_stream", c.GetModelName(), status, time.Since(start))
+
+		if usage != nil && usage.PromptTokens+usage.CompletionTokens > 0 {
+			metrics.AddModelTokens(c.GetModelName(), usage.PromptTokens, usage.CompletionTokens, false)
+			return
+		}
+		for _, tc := range toolCalls {
+			completionBytes += len(tc.Function.Name) + len(tc.Function.Arguments)
+		}
+		metrics.AddModelTokens(c.GetModelName(), estimatePromptTokens(messages),
+			estimateTokens(completionBytes), true)
+	}()
+	return out, nil
+}
+
+// estimateTokens estimates the tokens of a text from its length (rough approximation: 4 bytes ≈ 1 token)
+func estimateTokens(bytes int) int {
+	return (bytes + 3) / 4
+}
+
+// estimatePromptTokens estimates the prompt tokens of the messages, images are not counted
+func estimatePromptTokens(messages []Message) int {
+	bytes := 0
+	for _, msg := range messages {
+		bytes += len(msg.Role) + len(msg.Content)
+		for _, tc := range msg.ToolCalls {
+			bytes += len(tc.Function.Name) + len(tc.Function.Arguments)
+		}
+	}
+	return estimateTokens(bytes)
+}
@@ -0,0 +1,79 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamChat replays fixed stream responses
+type streamChat struct {
+	name      string
+	responses []types.StreamResponse
+}
+
+func (c *streamChat) Chat(context.Context, []Message, *ChatOptions) (*types.ChatResponse, error) {
+	return &types.ChatResponse{}, nil
+}
+
+func (c *streamChat) ChatStream(context.Context, []Message, *ChatOptions) (<-chan types.StreamResponse, error) {
+	stream := make(chan types.StreamResponse, len(c.responses))
+	for _, resp := range c.responses {
+		stream <- resp
+	}
+	close(stream)
+	return stream, nil
+}
+
+func (c *streamChat) GetModelName() string { return c.name }
+
+func (c *streamChat) GetModelID() string { return c.name }
+
+func TestMetricsChatStreamTokens(t *testing.T) {
+	// 计数器为全局变量，每次运行使用不同的模型名
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	reported := withMetrics(&streamChat{name: "stream-reported-" + suffix, responses: []types.StreamResponse{
+		{ResponseType: types.ResponseTypeAnswer, Content: "Hello"},
+		{ResponseType: types.ResponseTypeAnswer, Done: true, Usage: &types.TokenUsage{PromptTokens: 12, CompletionTokens: 3}},
+	}})
+	estimated := withMetrics(&streamChat{name: "stream-estimated-" + suffix, responses: []types.StreamResponse{
+		{ResponseType: types.ResponseTypeAnswer, Content: "12345678"},
+		{ResponseType: types.ResponseTypeAnswer, Done: true},
+	}})
+
+	messages := []Message{{Role: "user", Content: "0123456789"}}
+	for _, model := range []Chat{reported, estimated} {
+		stream, err := model.ChatStream(context.Background(), messages, nil)
+		require.NoError(t, err)
+		for range stream {
+		}
+	}
+
+	// 输出通道在用量记录后才关闭
+	body := scrapeMetrics(t)
+	tokens := func(estimated bool, kind, model string, value int) string {
+		return fmt.Sprintf(`weknora_model_tokens_total{estimated="%t",kind="%s",model="%s"} %d`,
+			estimated, kind, model, value)
+	}
+	assert.Contains(t, body, tokens(false, "prompt", reported.GetModelName(), 12))
+	assert.Contains(t, body, tokens(false, "completion", reported.GetModelName(), 3))
+	// "user" + 10 bytes → 4 tokens, 8 bytes → 2 tokens
+	assert.Contains(t, body, tokens(true, "prompt", estimated.GetModelName(), 4))
+	assert.Contains(t, body, tokens(true, "completion", estimated.GetModelName(), 2))
+}
+
+func scrapeMetrics(t *testing.T) string {
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	return recorder.Body.String()
+}
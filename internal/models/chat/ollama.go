@@ -40,6 +40,12 @@ func (c *OllamaChat) convertMessages(messages []Message) []ollamaapi.Message {
 		if msg.Role == "tool" {
 			msgOllama.ToolName = msg.Name
 		}
+		// Ollama 只接受原始图片字节，忽略无法解析的远程链接
+		for _, image := range msg.Images {
+			if data, ok := decodeImageDataURI(image); ok {
+				msgOllama.Images = append(msgOllama.Images, ollamaapi.ImageData(data))
+			}
+		}
 		ollamaMessages = append(ollamaMessages, msgOllama)
 	}
 	return ollamaMessages
@@ -180,6 +186,11 @@ func (c *OllamaChat) ChatStream(
 				streamChan <- types.StreamResponse{
 					ResponseType: types.ResponseTypeAnswer,
 					Done:         true,
+					Usage: &types.TokenUsage{
+						PromptTokens:     resp.PromptEvalCount,
+						CompletionTokens: resp.EvalCount,
+						TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+					},
 				}
 			}
 
@@ -62,7 +62,10 @@ func (c *RemoteAPIChat) convertMessages(messages []Message) []openai.ChatComplet
 		}
 
 		// 处理内容：对于 assistant 角色，内容可能为空（当有 tool_calls 时）
-		if msg.Content != "" {
+		// 携带图片时使用多模态内容（text + image_url），Content 与 MultiContent 不能同时设置
+		if len(msg.Images) > 0 && msg.Role == "user" {
+			openaiMsg.MultiContent = buildMultiContent(msg.Content, msg.Images)
+		} else if msg.Content != "" {
 			openaiMsg.Content = msg.Content
 		}
 
@@ -93,6 +96,27 @@ func (c *RemoteAPIChat) convertMessages(messages []Message) []openai.ChatComplet
 	return openaiMessages
 }
 
+// buildMultiContent 构建 OpenAI 兼容的多模态内容
+func buildMultiContent(text string, images []string) []openai.ChatMessagePart {
+	parts := make([]openai.ChatMessagePart, 0, len(images)+1)
+	if text != "" {
+		parts = append(parts, openai.ChatMessagePart{
+			Type: openai.ChatMessagePartTypeText,
+			Text: text,
+		})
+	}
+	for _, image := range images {
+		parts = append(parts, openai.ChatMessagePart{
+			Type: openai.ChatMessagePartTypeImageURL,
+			ImageURL: &openai.ChatMessageImageURL{
+				URL:    image,
+				Detail: openai.ImageURLDetailAuto,
+			},
+		})
+	}
+	return parts
+}
+
 // isQwenModel 检查是否为 qwen 模型
 func (c *RemoteAPIChat) isAliyunQwen3Model() bool {
 	return c.provider == provider.ProviderAliyun && provider.IsQwen3Model(c.modelName)
@@ -223,6 +247,10 @@ func (c *RemoteAPIChat) buildChatCompletionRequest(messages []Message,
 		Messages: c.convertMessages(messages),
 		Stream:   isStream,
 	}
+	if isStream {
+		// 流式响应默认不返回 token 用量，要求在最后一个数据块中返回
+		req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
 	thinking := false
 
 	// 添加可选参数
@@ -499,6 +527,7 @@ func (c *RemoteAPIChat) ChatStream(ctx context.Context,
 		toolCallMap := make(map[int]*types.LLMToolCall)
 		lastFunctionName := make(map[int]string)
 		nameNotified := make(map[int]bool)
+		var usage *types.TokenUsage
 
 		buildOrderedToolCalls := func() []types.LLMToolCall {
 			if len(toolCallMap) == 0 {
@@ -527,6 +556,7 @@ func (c *RemoteAPIChat) ChatStream(ctx context.Context,
 						Content:      "",
 						Done:         true,
 						ToolCalls:    buildOrderedToolCalls(),
+						Usage:        usage,
 					}
 				} else {
 					// Actual error, send error response
@@ -539,6 +569,15 @@ func (c *RemoteAPIChat) ChatStream(ctx context.Context,
 				return
 			}
 
+			// 用量在 choices 为空的最后一个数据块中返回
+			if response.Usage != nil {
+				usage = &types.TokenUsage{
+					PromptTokens:     response.Usage.PromptTokens,
+					CompletionTokens: response.Usage.CompletionTokens,
+					TotalTokens:      response.Usage.TotalTokens,
+				}
+			}
+
 			if len(response.Choices) > 0 {
 				delta := response.Choices[0].Delta
 				isDone := string(response.Choices[0].FinishReason) != ""
@@ -125,3 +125,33 @@ func TestRemoteAPIChat(t *testing.T) {
 		})
 	}
 }
+
+// TestConvertMessagesWithImages 测试携带图片的消息转换为多模态内容
+func TestConvertMessagesWithImages(t *testing.T) {
+	chat, err := NewRemoteAPIChat(&ChatConfig{
+		Source:    types.ModelSourceRemote,
+		BaseURL:   "https://api.openai.com/v1",
+		ModelName: "gpt-4o",
+	})
+	require.NoError(t, err)
+
+	image := BuildImageDataURI("image/png", []byte("fake-png"))
+	messages := chat.convertMessages([]Message{
+		{Role: "system", Content: "You are a helpful assistant."},
+		{Role: "user", Content: "这个报错是什么意思？", Images: []string{image}},
+	})
+	require.Len(t, messages, 2)
+
+	assert.Equal(t, "You are a helpful assistant.", messages[0].Content)
+	assert.Empty(t, messages[0].MultiContent)
+
+	assert.Empty(t, messages[1].Content)
+	require.Len(t, messages[1].MultiContent, 2)
+	assert.Equal(t, "这个报错是什么意思？", messages[1].MultiContent[0].Text)
+	require.NotNil(t, messages[1].MultiContent[1].ImageURL)
+	assert.Equal(t, image, messages[1].MultiContent[1].ImageURL.URL)
+
+	data, ok := decodeImageDataURI(image)
+	require.True(t, ok)
+	assert.Equal(t, []byte("fake-png"), data)
+}
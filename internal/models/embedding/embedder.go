@@ -51,7 +51,16 @@ type Config struct {
 }
 
 // NewEmbedder creates an embedder based on the configuration
+// The returned embedder records call metrics
 func NewEmbedder(config Config) (Embedder, error) {
+	embedder, err := newEmbedder(config)
+	if err != nil || embedder == nil {
+		return embedder, err
+	}
+	return withMetrics(embedder), nil
+}
+
+func newEmbedder(config Config) (Embedder, error) {
 	var embedder Embedder
 	var err error
 	switch strings.ToLower(string(config.Source)) {
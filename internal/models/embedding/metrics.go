@@ -0,0 +1,33 @@
+package embedding
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+)
+
+// metricsEmbedder records latency and outcome of the wrapped embedder
+type metricsEmbedder struct {
+	Embedder
+}
+
+func withMetrics(embedder Embedder) Embedder {
+	return &metricsEmbedder{Embedder: embedder}
+}
+
+// Embed converts text to vector and records the call
+func (e *metricsEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	start := time.Now()
+	vector, err := e.Embedder.Embed(ctx, text)
+	metrics.ObserveModelRequest("embedding", e.GetModelName(), metrics.Status(err), time.Since(start))
+	return vector, err
+}
+
+// BatchEmbed converts multiple texts to vectors and records the call
+func (e *metricsEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	start := time.Now()
+	vectors, err := e.Embedder.BatchEmbed(ctx, texts)
+	metrics.ObserveModelRequest("embedding", e.GetModelName(), metrics.Status(err), time.Since(start))
+	return vectors, err
+}
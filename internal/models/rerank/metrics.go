@@ -0,0 +1,25 @@
+package rerank
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+)
+
+// metricsReranker records latency and outcome of the wrapped reranker
+type metricsReranker struct {
+	Reranker
+}
+
+func withMetrics(reranker Reranker) Reranker {
+	return &metricsReranker{Reranker: reranker}
+}
+
+// Rerank reranks documents and records the call
+func (r *metricsReranker) Rerank(ctx context.Context, query string, documents []string) ([]RankResult, error) {
+	start := time.Now()
+	results, err := r.Reranker.Rerank(ctx, query, documents)
+	metrics.ObserveModelRequest("rerank", r.GetModelName(), metrics.Status(err), time.Since(start))
+	return results, err
+}
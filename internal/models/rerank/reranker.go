@@ -87,7 +87,16 @@ type RerankerConfig struct {
 }
 
 // NewReranker creates a reranker based on the configuration
+// The returned reranker records call metrics
 func NewReranker(config *RerankerConfig) (Reranker, error) {
+	reranker, err := newReranker(config)
+	if err != nil {
+		return nil, err
+	}
+	return withMetrics(reranker), nil
+}
+
+func newReranker(config *RerankerConfig) (Reranker, error) {
 	// Use provider field if set, otherwise detect from URL using provider registry
 	providerName := provider.ProviderName(config.Provider)
 	if providerName == "" {
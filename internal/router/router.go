@@ -12,6 +12,8 @@ import (
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/handler"
 	"github.com/Tencent/WeKnora/internal/handler/session"
+	"github.com/Tencent/WeKnora/internal/mcpserver"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/middleware"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 
@@ -54,6 +56,16 @@ type RouterParams struct {
 	CustomAgentHandler    *handler.CustomAgentHandler
 	SocialMediaHandler    *handler.SocialMediaHandler
 	BackupHandler         *handler.BackupHandler
+	TaskHandler           *handler.TaskHandler
+	WebhookHandler        *handler.WebhookHandler
+	MemoryHandler         *handler.MemoryHandler
+	SessionShareHandler   *handler.SessionShareHandler
+	ToolApprovalHandler   *handler.ToolApprovalHandler
+	DataSourceHandler     *handler.DataSourceHandler
+	AgentScheduleHandler  *handler.AgentScheduleHandler
+	ArtifactHandler       *handler.ArtifactHandler
+	KnowledgeGraphHandler *handler.KnowledgeGraphHandler
+	MCPServer             *mcpserver.Server
 }
 
 // NewRouter 创建新的路由
@@ -64,8 +76,9 @@ func NewRouter(params RouterParams) *gin.Engine {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Request-ID"},
-		ExposeHeaders:    []string{"Content-Length", "Access-Control-Allow-Origin"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Request-ID",
+			"Mcp-Session-Id", "Mcp-Protocol-Version", mcpserver.KnowledgeBaseScopeHeader},
+		ExposeHeaders:    []string{"Content-Length", "Access-Control-Allow-Origin", "Mcp-Session-Id"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -75,6 +88,7 @@ func NewRouter(params RouterParams) *gin.Engine {
 	r.Use(middleware.Logger())
 	r.Use(middleware.Recovery())
 	r.Use(middleware.ErrorHandler())
+	r.Use(middleware.Metrics())
 
 	// 健康检查端点（不需要认证）
 	// /health - 完整健康检查，检查所有依赖
@@ -84,6 +98,9 @@ func NewRouter(params RouterParams) *gin.Engine {
 	// /health/ready - 就绪检查，用于 Kubernetes readiness probe
 	r.GET("/health/ready", params.HealthHandler.ReadinessCheck)
 
+	// Prometheus 指标端点（不需要认证）
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// Swagger API 文档（仅在非生产环境下启用）
 	// 通过 GIN_MODE 环境变量判断：release 模式下禁用 Swagger
 	if gin.Mode() != gin.ReleaseMode {
@@ -95,6 +112,9 @@ func NewRouter(params RouterParams) *gin.Engine {
 		))
 	}
 
+	// 内置 MCP Server（使用租户 API Key 单独认证）
+	RegisterMCPServerRoutes(r, params.MCPServer, params.TenantService, params.Config)
+
 	// 认证中间件
 	r.Use(middleware.Auth(params.TenantService, params.UserService, params.Config))
 
@@ -125,6 +145,15 @@ func NewRouter(params RouterParams) *gin.Engine {
 		RegisterCustomAgentRoutes(v1, params.CustomAgentHandler)
 		RegisterSocialMediaRoutes(v1, params.SocialMediaHandler)
 		RegisterBackupRoutes(v1, params.BackupHandler)
+		RegisterTaskRoutes(v1, params.TaskHandler)
+		RegisterWebhookRoutes(v1, params.WebhookHandler)
+		RegisterMemoryRoutes(v1, params.MemoryHandler)
+		RegisterSessionShareRoutes(v1, params.SessionShareHandler)
+		RegisterToolApprovalRoutes(v1, params.ToolApprovalHandler)
+		RegisterDataSourceRoutes(v1, params.DataSourceHandler)
+		RegisterAgentScheduleRoutes(v1, params.AgentScheduleHandler)
+		RegisterArtifactRoutes(v1, params.ArtifactHandler)
+		RegisterKnowledgeGraphRoutes(v1, params.KnowledgeGraphHandler)
 	}
 
 	return r
@@ -241,6 +270,31 @@ func RegisterKnowledgeBaseRoutes(r *gin.RouterGroup, handler *handler.KnowledgeB
 	}
 }
 
+// RegisterKnowledgeGraphRoutes 注册知识图谱浏览与查询相关路由
+func RegisterKnowledgeGraphRoutes(r *gin.RouterGroup, handler *handler.KnowledgeGraphHandler) {
+	graph := r.Group("/knowledge-bases/:id/graph")
+	{
+		// 实体列表及搜索
+		graph.GET("/entities", handler.ListGraphEntities)
+		// 合并实体
+		graph.POST("/entities/merge", handler.MergeGraphEntities)
+		// 实体详情及修正，实体名称通过 name 参数传递
+		graph.GET("/entity", handler.GetGraphEntity)
+		graph.PUT("/entity", handler.UpdateGraphEntity)
+		// 实体来源分块
+		graph.GET("/entity/chunks", handler.ListGraphEntityChunks)
+		// 关系来源分块
+		graph.GET("/relation/chunks", handler.ListGraphRelationChunks)
+		// 实体邻域
+		graph.GET("/neighbourhood", handler.GetGraphNeighbourhood)
+		// 实体间最短路径
+		graph.GET("/path", handler.FindGraphPath)
+		// 图谱社区及其摘要，用于全局检索
+		graph.GET("/communities", handler.ListGraphCommunities)
+		graph.POST("/communities/rebuild", handler.RebuildGraphCommunities)
+	}
+}
+
 // RegisterKnowledgeTagRoutes 注册知识库标签相关路由
 func RegisterKnowledgeTagRoutes(r *gin.RouterGroup, tagHandler *handler.TagHandler) {
 	if tagHandler == nil {
@@ -260,10 +314,14 @@ func RegisterMessageRoutes(r *gin.RouterGroup, handler *handler.MessageHandler)
 	// 消息路由组
 	messages := r.Group("/messages")
 	{
+		// 搜索聊天记录
+		messages.GET("/search", handler.SearchMessages)
 		// 加载更早的消息，用于向上滚动加载
 		messages.GET("/:session_id/load", handler.LoadMessages)
 		// 删除消息
 		messages.DELETE("/:session_id/:id", handler.DeleteMessage)
+		// 评价回答
+		messages.PUT("/:session_id/:id/rating", handler.RateMessage)
 	}
 }
 
@@ -278,8 +336,15 @@ func RegisterSessionRoutes(r *gin.RouterGroup, handler *session.Handler) {
 		sessions.DELETE("/:id", handler.DeleteSession)
 		sessions.POST("/:session_id/generate_title", handler.GenerateTitle)
 		sessions.POST("/:session_id/stop", handler.StopSession)
+		// 对话图片附件
+		sessions.POST("/:session_id/images", handler.UploadImage)
+		sessions.GET("/:session_id/images", handler.GetImage)
 		// 继续接收活跃流
 		sessions.GET("/continue-stream/:session_id", handler.ContinueStream)
+		// 消息分支：编辑问题、重新生成回答、切换分支
+		sessions.POST("/:session_id/messages/:message_id/edit", handler.EditMessage)
+		sessions.POST("/:session_id/messages/:message_id/regenerate", handler.RegenerateMessage)
+		sessions.POST("/:session_id/messages/:message_id/activate", handler.ActivateMessage)
 	}
 }
 
@@ -328,6 +393,9 @@ func RegisterTenantRoutes(r *gin.RouterGroup, handler *handler.TenantHandler) {
 		tenantRoutes.GET("/:id", handler.GetTenant)
 		tenantRoutes.PUT("/:id", handler.UpdateTenant)
 		tenantRoutes.DELETE("/:id", handler.DeleteTenant)
+		// 租户任务并发及模型调用限流
+		tenantRoutes.GET("/:id/rate-limits", handler.GetTenantRateLimits)
+		tenantRoutes.PUT("/:id/rate-limits", handler.UpdateTenantRateLimits)
 	}
 }
 
@@ -463,6 +531,7 @@ func RegisterMCPServiceRoutes(r *gin.RouterGroup, handler *handler.MCPServiceHan
 		mcpServices.GET("/:id/tools", handler.GetMCPServiceTools)
 		// Get MCP service resources
 		mcpServices.GET("/:id/resources", handler.GetMCPServiceResources)
+		mcpServices.GET("/:id/prompts", handler.GetMCPServicePrompts)
 	}
 }
 
@@ -494,6 +563,16 @@ func RegisterCustomAgentRoutes(r *gin.RouterGroup, agentHandler *handler.CustomA
 		agents.DELETE("/:id", agentHandler.DeleteAgent)
 		// Copy agent
 		agents.POST("/:id/copy", agentHandler.CopyAgent)
+		// Import agent from an export document (must be before /:id to avoid conflict)
+		agents.POST("/import/preview", agentHandler.PreviewAgentImport)
+		agents.POST("/import", agentHandler.ImportAgent)
+		// Export agent as YAML or JSON
+		agents.GET("/:id/export", agentHandler.ExportAgent)
+		// Version history
+		agents.GET("/:id/versions", agentHandler.ListAgentVersions)
+		agents.GET("/:id/versions/:version", agentHandler.GetAgentVersion)
+		agents.GET("/:id/versions/:version/diff", agentHandler.DiffAgentVersions)
+		agents.POST("/:id/versions/:version/rollback", agentHandler.RollbackAgent)
 	}
 }
 
@@ -504,8 +583,33 @@ func RegisterSocialMediaRoutes(r *gin.RouterGroup, handler *handler.SocialMediaH
 	}
 	socialMedia := r.Group("/social-media")
 	{
-		// Extract content from social media platform
+		// Submit extraction tasks for social media videos
 		socialMedia.POST("/extract", handler.ExtractContent)
+		socialMedia.POST("/extract/batch", handler.BatchExtractContent)
+		// Get extraction task progress
+		socialMedia.GET("/extract/progress/:task_id", handler.GetExtractProgress)
+		// List configured extractors
+		socialMedia.GET("/extractors", handler.ListExtractors)
+	}
+}
+
+// RegisterMCPServerRoutes registers the built-in MCP server endpoints
+func RegisterMCPServerRoutes(
+	r *gin.Engine,
+	mcpServer *mcpserver.Server,
+	tenantService interfaces.TenantService,
+	cfg *config.Config,
+) {
+	if mcpServer == nil {
+		return
+	}
+	mcp := r.Group(mcpServer.BasePath(), middleware.MCPAuth(tenantService, cfg))
+	{
+		// Streamable HTTP transport
+		mcp.Any("", gin.WrapH(mcpServer.StreamableHTTPHandler()))
+		// SSE transport
+		mcp.GET("/sse", gin.WrapH(mcpServer.SSEHandler()))
+		mcp.POST("/message", gin.WrapH(mcpServer.MessageHandler()))
 	}
 }
 
@@ -524,3 +628,116 @@ func RegisterBackupRoutes(r *gin.RouterGroup, handler *handler.BackupHandler) {
 		backup.POST("/import", handler.Import)
 	}
 }
+
+// RegisterTaskRoutes registers background task administration routes
+func RegisterTaskRoutes(r *gin.RouterGroup, handler *handler.TaskHandler) {
+	if handler == nil {
+		return
+	}
+	tasks := r.Group("/tasks")
+	{
+		// List tasks of the current tenant
+		tasks.GET("", handler.ListTasks)
+		// Queue status and pause/resume (shared by all tenants)
+		tasks.GET("/queues", handler.ListQueues)
+		tasks.POST("/queues/:queue/pause", handler.PauseQueue)
+		tasks.POST("/queues/:queue/resume", handler.ResumeQueue)
+		// Retry all failed document parses of a knowledge base
+		tasks.POST("/knowledge-bases/:id/retry-failed", handler.RetryFailedDocuments)
+		// Single task operations
+		tasks.GET("/:queue/:id", handler.GetTask)
+		tasks.POST("/:queue/:id/retry", handler.RetryTask)
+		tasks.POST("/:queue/:id/cancel", handler.CancelTask)
+	}
+}
+
+// RegisterWebhookRoutes registers webhook subscription routes
+func RegisterWebhookRoutes(r *gin.RouterGroup, handler *handler.WebhookHandler) {
+	webhooks := r.Group("/webhooks")
+	{
+		// Event types that can be subscribed to
+		webhooks.GET("/events", handler.ListEventTypes)
+		webhooks.POST("", handler.CreateWebhook)
+		webhooks.GET("", handler.ListWebhooks)
+		webhooks.GET("/:id", handler.GetWebhook)
+		webhooks.PUT("/:id", handler.UpdateWebhook)
+		webhooks.DELETE("/:id", handler.DeleteWebhook)
+		// Delivery log and test-fire
+		webhooks.GET("/:id/deliveries", handler.ListWebhookDeliveries)
+		webhooks.POST("/:id/test", handler.TestWebhook)
+	}
+}
+
+// RegisterSessionShareRoutes registers conversation export and share link routes
+func RegisterSessionShareRoutes(r *gin.RouterGroup, handler *handler.SessionShareHandler) {
+	sessions := r.Group("/sessions")
+	{
+		sessions.GET("/:session_id/export", handler.ExportSession)
+		sessions.POST("/:session_id/shares", handler.CreateSessionShare)
+		sessions.GET("/:session_id/shares", handler.ListSessionShares)
+		sessions.DELETE("/:session_id/shares/:share_id", handler.RevokeSessionShare)
+	}
+	// Read-only snapshot behind a share link, opened without login
+	r.GET("/shared/:token", handler.GetSharedSession)
+}
+
+// RegisterToolApprovalRoutes registers routes answering the tool calls agents wait on for approval
+func RegisterToolApprovalRoutes(r *gin.RouterGroup, handler *handler.ToolApprovalHandler) {
+	sessions := r.Group("/sessions")
+	{
+		sessions.GET("/:session_id/tool-approvals", handler.ListToolApprovals)
+		sessions.POST("/:session_id/tool-approvals/:approval_id", handler.DecideToolApproval)
+	}
+}
+
+// RegisterDataSourceRoutes registers routes managing the external data sources agents can query
+func RegisterDataSourceRoutes(r *gin.RouterGroup, handler *handler.DataSourceHandler) {
+	dataSources := r.Group("/data-sources")
+	{
+		dataSources.POST("", handler.CreateDataSource)
+		dataSources.GET("", handler.ListDataSources)
+		dataSources.GET("/:id", handler.GetDataSource)
+		dataSources.PUT("/:id", handler.UpdateDataSource)
+		dataSources.DELETE("/:id", handler.DeleteDataSource)
+		// Describe the allowed tables, also tests the connection
+		dataSources.GET("/:id/schema", handler.GetDataSourceSchema)
+	}
+}
+
+// RegisterArtifactRoutes registers routes reading the charts and files agent tools attached to messages
+func RegisterArtifactRoutes(r *gin.RouterGroup, handler *handler.ArtifactHandler) {
+	sessions := r.Group("/sessions")
+	{
+		sessions.GET("/:session_id/messages/:message_id/artifacts", handler.ListMessageArtifacts)
+		sessions.GET("/:session_id/artifacts/:artifact_id", handler.GetArtifact)
+		sessions.GET("/:session_id/artifacts/:artifact_id/download", handler.DownloadArtifact)
+	}
+}
+
+// RegisterMemoryRoutes registers routes managing the long-term memories of the current user
+func RegisterMemoryRoutes(r *gin.RouterGroup, handler *handler.MemoryHandler) {
+	memories := r.Group("/memories")
+	{
+		memories.GET("", handler.ListMemories)
+		memories.POST("", handler.CreateMemory)
+		memories.DELETE("", handler.ClearMemories)
+		memories.PUT("/:id", handler.UpdateMemory)
+		memories.DELETE("/:id", handler.DeleteMemory)
+	}
+}
+
+// RegisterAgentScheduleRoutes registers routes managing scheduled and event-triggered agent runs
+func RegisterAgentScheduleRoutes(r *gin.RouterGroup, handler *handler.AgentScheduleHandler) {
+	schedules := r.Group("/agent-schedules")
+	{
+		schedules.POST("", handler.CreateAgentSchedule)
+		schedules.GET("", handler.ListAgentSchedules)
+		schedules.GET("/:id", handler.GetAgentSchedule)
+		schedules.PUT("/:id", handler.UpdateAgentSchedule)
+		schedules.DELETE("/:id", handler.DeleteAgentSchedule)
+		// Run history and manual runs
+		schedules.POST("/:id/run", handler.RunAgentSchedule)
+		schedules.GET("/:id/runs", handler.ListAgentScheduleRuns)
+		schedules.GET("/:id/runs/:run_id", handler.GetAgentScheduleRun)
+	}
+}
@@ -1,11 +1,19 @@
 package router
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math/rand"
 	"os"
 	"strconv"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/hibiken/asynq"
@@ -16,11 +24,20 @@ type AsynqTaskParams struct {
 	dig.In
 
 	Server               *asynq.Server
+	Inspector            *asynq.Inspector
+	Config               *config.Config
+	TenantLimiter        interfaces.TenantLimiter
 	KnowledgeService     interfaces.KnowledgeService
 	KnowledgeBaseService interfaces.KnowledgeBaseService
 	TagService           interfaces.KnowledgeTagService
+	SocialMediaService   interfaces.SocialMediaService
+	WebhookService       interfaces.WebhookService
+	MemoryService        interfaces.UserMemoryService
+	CommunityService     interfaces.GraphCommunityService
+	AgentScheduleService interfaces.AgentScheduleService
 	ChunkExtracter       interfaces.TaskHandler `name:"chunkExtracter"`
 	DataTableSummary     interfaces.TaskHandler `name:"dataTableSummary"`
+	ScheduledAgentRunner interfaces.TaskHandler `name:"scheduledAgentRunner"`
 }
 
 func getAsynqRedisClientOpt() *asynq.RedisClientOpt {
@@ -50,24 +67,111 @@ func NewAsyncqClient() (*asynq.Client, error) {
 	return client, nil
 }
 
-func NewAsynqServer() *asynq.Server {
+// NewAsynqInspector creates an inspector used to monitor and manage the task queues
+func NewAsynqInspector() *asynq.Inspector {
+	return asynq.NewInspector(getAsynqRedisClientOpt())
+}
+
+func NewAsynqServer(cfg *config.Config) *asynq.Server {
 	opt := getAsynqRedisClientOpt()
+	throttleDelay := 10 * time.Second
+	if cfg.TaskScheduling != nil && cfg.TaskScheduling.ThrottleDelay > 0 {
+		throttleDelay = time.Duration(cfg.TaskScheduling.ThrottleDelay) * time.Second
+	}
+	concurrency := 0
+	shutdownTimeout := 60 * time.Second
+	if cfg.Worker != nil {
+		concurrency = cfg.Worker.Concurrency
+		if cfg.Worker.ShutdownTimeout > 0 {
+			shutdownTimeout = time.Duration(cfg.Worker.ShutdownTimeout) * time.Second
+		}
+	}
 	srv := asynq.NewServer(
 		opt,
 		asynq.Config{
+			Concurrency: concurrency,
+			// In-flight tasks get this long to finish on shutdown before they are requeued
+			ShutdownTimeout: shutdownTimeout,
 			Queues: map[string]int{
 				"critical": 6, // Highest priority queue
 				"default":  3, // Default priority queue
 				"low":      1, // Lowest priority queue
 			},
+			// Tasks deferred by tenant fair scheduling are neither failures nor consume retries
+			IsFailure: func(err error) bool {
+				return !errors.Is(err, types.ErrTaskThrottled)
+			},
+			RetryDelayFunc: func(n int, err error, t *asynq.Task) time.Duration {
+				if errors.Is(err, types.ErrTaskThrottled) {
+					// Jitter spreads the deferred tasks of a tenant between other tenants' tasks
+					return throttleDelay + time.Duration(rand.Int63n(int64(throttleDelay)))
+				}
+				return asynq.DefaultRetryDelayFunc(n, err, t)
+			},
 		},
 	)
 	return srv
 }
 
-func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
+// tenantFairnessMiddleware caps the ingestion tasks a tenant runs at the same time.
+// A task over the cap is put back behind the queue with ErrTaskThrottled, so the
+// workers pick up the tasks of other tenants in between (round-robin across tenants).
+func tenantFairnessMiddleware(cfg *config.Config, limiter interfaces.TenantLimiter) asynq.MiddlewareFunc {
+	fairTypes := make(map[string]bool)
+	if cfg.TaskScheduling != nil {
+		for _, taskType := range cfg.TaskScheduling.FairTaskTypes {
+			fairTypes[taskType] = true
+		}
+	}
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+			if !fairTypes[t.Type()] {
+				return next.ProcessTask(ctx, t)
+			}
+			// Only ingestion waits for the tenant's embedding limit, interactive retrieval never queues behind it
+			ctx = context.WithValue(ctx, types.IngestionContextKey, true)
+			var payload struct {
+				TenantID uint64 `json:"tenant_id"`
+			}
+			taskID, _ := asynq.GetTaskID(ctx)
+			if err := json.Unmarshal(t.Payload(), &payload); err != nil || payload.TenantID == 0 || taskID == "" {
+				return next.ProcessTask(ctx, t)
+			}
+
+			// The slot expires with the task so a crashed worker does not hold it forever
+			expiresAt := time.Now().Add(time.Hour)
+			if deadline, ok := ctx.Deadline(); ok {
+				expiresAt = deadline.Add(time.Minute)
+			}
+			acquired, err := limiter.AcquireTaskSlot(ctx, payload.TenantID, taskID, expiresAt)
+			if err != nil {
+				logger.Warnf(ctx, "Failed to acquire task slot for tenant %d, running task %s anyway: %v",
+					payload.TenantID, taskID, err)
+				return next.ProcessTask(ctx, t)
+			}
+			if !acquired {
+				logger.Infof(ctx, "Tenant %d reached its concurrent task limit, deferring task %s (%s)",
+					payload.TenantID, taskID, t.Type())
+				return types.ErrTaskThrottled
+			}
+			defer limiter.ReleaseTaskSlot(ctx, payload.TenantID, taskID)
+			return next.ProcessTask(ctx, t)
+		})
+	}
+}
+
+// RunAsynqServer registers the task handlers and starts processing tasks in the background.
+// The caller owns shutdown: Server.Shutdown drains the in-flight tasks.
+func RunAsynqServer(params AsynqTaskParams) (*asynq.ServeMux, error) {
 	// Create a new mux and register all handlers
 	mux := asynq.NewServeMux()
+	// Per-tenant concurrency caps; registered first so deferred tasks are not recorded as processed
+	mux.Use(tenantFairnessMiddleware(params.Config, params.TenantLimiter))
+	// Record task outcomes and expose queue depth for Prometheus
+	mux.Use(metrics.AsynqMiddleware)
+	if err := metrics.Register(metrics.NewQueueCollector(params.Inspector)); err != nil {
+		log.Printf("failed to register asynq queue metrics: %v", err)
+	}
 
 	// Register extract handlers - router will dispatch to appropriate handler
 	mux.HandleFunc(types.TypeChunkExtract, params.ChunkExtracter.Handle)
@@ -88,17 +192,52 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// Register KB clone handler
 	mux.HandleFunc(types.TypeKBClone, params.KnowledgeService.ProcessKBClone)
 
+	// Register social media extract handler
+	mux.HandleFunc(types.TypeSocialMediaExtract, params.SocialMediaService.ProcessSocialMediaExtract)
+
 	// Register index delete handler
 	mux.HandleFunc(types.TypeIndexDelete, params.TagService.ProcessIndexDelete)
 
 	// Register KB delete handler
 	mux.HandleFunc(types.TypeKBDelete, params.KnowledgeBaseService.ProcessKBDelete)
 
-	go func() {
-		// Start the server
-		if err := params.Server.Run(mux); err != nil {
-			log.Fatalf("could not run server: %v", err)
-		}
-	}()
-	return mux
+	// Register webhook delivery handler
+	mux.HandleFunc(types.TypeWebhookDelivery, params.WebhookService.ProcessDelivery)
+	mux.HandleFunc(types.TypeMemoryExtract, params.MemoryService.ProcessExtraction)
+
+	// Register graph community rebuild handler
+	mux.HandleFunc(types.TypeGraphCommunity, params.CommunityService.ProcessRebuild)
+
+	// Register agent schedule handlers
+	mux.HandleFunc(types.TypeAgentScheduleDispatch, params.AgentScheduleService.ProcessDispatch)
+	mux.HandleFunc(types.TypeAgentScheduledRun, params.ScheduledAgentRunner.Handle)
+
+	// Start instead of Run, which would install its own signal handling and race the process shutdown
+	if err := params.Server.Start(mux); err != nil {
+		return nil, fmt.Errorf("could not start asynq server: %w", err)
+	}
+	return mux, nil
+}
+
+// RunAgentScheduleDispatcher enqueues the dispatch of due agent schedules every minute.
+// Every worker runs the scheduler, the dispatch claims each due run in the database so it is enqueued once.
+func RunAgentScheduleDispatcher(cleaner interfaces.ResourceCleaner) error {
+	scheduler := asynq.NewScheduler(getAsynqRedisClientOpt(), &asynq.SchedulerOpts{
+		Location: time.UTC,
+	})
+	task := asynq.NewTask(types.TypeAgentScheduleDispatch, nil)
+	// The dispatch is skipped rather than queued up when workers fall behind
+	if _, err := scheduler.Register("@every 1m", task,
+		asynq.Queue("critical"), asynq.MaxRetry(0), asynq.Timeout(time.Minute), asynq.Unique(time.Minute),
+	); err != nil {
+		return fmt.Errorf("could not register agent schedule dispatch: %w", err)
+	}
+	if err := scheduler.Start(); err != nil {
+		return fmt.Errorf("could not start agent schedule dispatcher: %w", err)
+	}
+	cleaner.RegisterWithName("AgentScheduleDispatcher", func() error {
+		scheduler.Shutdown()
+		return nil
+	})
+	return nil
 }
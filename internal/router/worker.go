@@ -0,0 +1,23 @@
+package router
+
+import (
+	"github.com/Tencent/WeKnora/internal/handler"
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// NewWorkerRouter 创建 worker 模式下的路由，仅提供健康检查和 Prometheus 指标
+func NewWorkerRouter(healthHandler *handler.HealthHandler) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.Recovery())
+
+	r.GET("/health", healthHandler.HealthCheck)
+	r.GET("/health/live", healthHandler.LivenessCheck)
+	r.GET("/health/ready", healthHandler.ReadinessCheck)
+
+	// 任务执行相关指标由 worker 进程记录
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	return r
+}
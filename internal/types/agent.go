@@ -26,9 +26,39 @@ type AgentConfig struct {
 	MultiTurnEnabled        bool          `json:"multi_turn_enabled"`                   // Whether multi-turn conversation is enabled
 	HistoryTurns            int           `json:"history_turns"`                        // Number of history turns to keep in context
 	SearchTargets           SearchTargets `json:"-"`                                    // Pre-computed unified search targets (runtime only)
+	UserMemories            []string      `json:"-"`                                    // Long-term memories recalled for the current user (runtime only)
 	// MCP service selection
 	MCPSelectionMode string   `json:"mcp_selection_mode"` // MCP selection mode: "all", "selected", "none"
 	MCPServices      []string `json:"mcp_services"`       // Selected MCP service IDs (when mode is "selected")
+	// MCPPrompt binds an MCP prompt as the system prompt template (overrides SystemPrompt when set)
+	MCPPrompt *MCPPromptBinding `json:"mcp_prompt,omitempty"`
+	// ToolApprovalPolicy decides which tool calls run immediately, wait for the user or are refused
+	ToolApprovalPolicy *ToolApprovalPolicy `json:"tool_approval_policy,omitempty"`
+	// DataSources are the IDs of the external data sources the agent can query read-only
+	DataSources []string `json:"data_sources,omitempty"`
+	// Budget limits the tokens, time and tool calls of a run, merged from the agent and tenant budgets
+	Budget *AgentBudget `json:"budget,omitempty"`
+	// Sub-agent delegation (runtime only)
+	SubAgents      []SubAgentInfo `json:"-"` // Custom agents this agent can delegate sub-tasks to
+	DelegationPath []string       `json:"-"` // IDs of the delegating agents and this agent, outermost first
+}
+
+// MaxAgentDelegationDepth is how deep sub-agents may delegate further (the top-level agent is depth 0)
+const MaxAgentDelegationDepth = 2
+
+// SubAgentInfo describes a custom agent that is callable as a tool by another agent
+type SubAgentInfo struct {
+	ID          string
+	Name        string
+	Description string
+}
+
+// DelegationDepth returns the nesting depth of the agent, 0 for the agent answering the user
+func (c *AgentConfig) DelegationDepth() int {
+	if c == nil || len(c.DelegationPath) == 0 {
+		return 0
+	}
+	return len(c.DelegationPath) - 1
 }
 
 // SessionAgentConfig represents session-level agent configuration
@@ -121,6 +151,11 @@ type ToolResult struct {
 	Output  string                 `json:"output"`          // Human-readable output
 	Data    map[string]interface{} `json:"data,omitempty"`  // Structured data for programmatic use
 	Error   string                 `json:"error,omitempty"` // Error message if execution failed
+	Steps   []AgentStep            `json:"steps,omitempty"` // Steps of the sub-agent the task was delegated to
+	// Charts and files produced by the tool, saved against the assistant message by the engine
+	Artifacts []*MessageArtifact `json:"-"`
+	// Consumption of the sub-agent the task was delegated to, counted against the budget of the caller
+	Usage *AgentUsage `json:"usage,omitempty"`
 }
 
 // ToolCall represents a single tool invocation within an agent step
@@ -158,11 +193,12 @@ func (s *AgentStep) GetObservations() []string {
 
 // AgentState tracks the execution state of an agent across iterations
 type AgentState struct {
-	CurrentRound  int             `json:"current_round"`  // Current round number
-	RoundSteps    []AgentStep     `json:"round_steps"`    // All steps taken so far in the current round
-	IsComplete    bool            `json:"is_complete"`    // Whether agent has finished
-	FinalAnswer   string          `json:"final_answer"`   // The final answer to the query
-	KnowledgeRefs []*SearchResult `json:"knowledge_refs"` // Collected knowledge references
+	CurrentRound  int             `json:"current_round"`   // Current round number
+	RoundSteps    []AgentStep     `json:"round_steps"`     // All steps taken so far in the current round
+	IsComplete    bool            `json:"is_complete"`     // Whether agent has finished
+	FinalAnswer   string          `json:"final_answer"`    // The final answer to the query
+	KnowledgeRefs []*SearchResult `json:"knowledge_refs"`  // Collected knowledge references
+	Usage         *AgentUsage     `json:"usage,omitempty"` // Consumption of the run
 }
 
 // FunctionDefinition represents a function definition for LLM function calling
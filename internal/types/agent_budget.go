@@ -0,0 +1,95 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// AgentBudgetWarnRatio is the share of a budget after which the agent is told to wrap up
+const AgentBudgetWarnRatio = 0.8
+
+// AgentBudgetLimit names a limit of an agent budget
+type AgentBudgetLimit string
+
+const (
+	AgentBudgetTokens    AgentBudgetLimit = "tokens"
+	AgentBudgetDuration  AgentBudgetLimit = "duration"
+	AgentBudgetToolCalls AgentBudgetLimit = "tool_calls"
+)
+
+// AgentBudget limits the resources one agent run may consume, 0 means unlimited.
+// When a limit is nearly reached the agent is asked to wrap up, once it is exceeded
+// the agent stops calling tools and answers with what it has.
+type AgentBudget struct {
+	// Total prompt and completion tokens of all model calls of the run, including delegated sub-agents
+	MaxTokens int `yaml:"max_tokens" json:"max_tokens"`
+	// Wall-clock time of the run in seconds, time spent waiting for tool approvals included.
+	// Model calls, tool calls and approval waits still running at the limit are cancelled
+	MaxDurationSeconds int `yaml:"max_duration_seconds" json:"max_duration_seconds"`
+	// Number of tool calls executed in the run
+	MaxToolCalls int `yaml:"max_tool_calls" json:"max_tool_calls"`
+}
+
+// Validate checks the limits of the budget, a nil budget is valid
+func (b *AgentBudget) Validate() error {
+	if b == nil {
+		return nil
+	}
+	if b.MaxTokens < 0 || b.MaxDurationSeconds < 0 || b.MaxToolCalls < 0 {
+		return fmt.Errorf("agent budget limits must not be negative")
+	}
+	return nil
+}
+
+// IsZero reports whether the budget sets no limit
+func (b *AgentBudget) IsZero() bool {
+	return b == nil || (b.MaxTokens == 0 && b.MaxDurationSeconds == 0 && b.MaxToolCalls == 0)
+}
+
+// MaxDuration returns the time limit, 0 if unlimited
+func (b *AgentBudget) MaxDuration() time.Duration {
+	if b == nil {
+		return 0
+	}
+	return time.Duration(b.MaxDurationSeconds) * time.Second
+}
+
+// MergeAgentBudgets combines budgets limit by limit, keeping the tightest one set,
+// so that an agent cannot go beyond the budget of its tenant. It returns nil if no limit is set
+func MergeAgentBudgets(budgets ...*AgentBudget) *AgentBudget {
+	tighter := func(a, b int) int {
+		if a == 0 || (b > 0 && b < a) {
+			return b
+		}
+		return a
+	}
+	merged := &AgentBudget{}
+	for _, b := range budgets {
+		if b == nil {
+			continue
+		}
+		merged.MaxTokens = tighter(merged.MaxTokens, b.MaxTokens)
+		merged.MaxDurationSeconds = tighter(merged.MaxDurationSeconds, b.MaxDurationSeconds)
+		merged.MaxToolCalls = tighter(merged.MaxToolCalls, b.MaxToolCalls)
+	}
+	if merged.IsZero() {
+		return nil
+	}
+	return merged
+}
+
+// AgentUsage is the consumption of an agent run.
+// Tokens are estimated from the length of the messages, as streamed responses carry no usage.
+type AgentUsage struct {
+	Rounds           int   `json:"rounds"`
+	LLMCalls         int   `json:"llm_calls"`
+	PromptTokens     int   `json:"prompt_tokens"`
+	CompletionTokens int   `json:"completion_tokens"`
+	TotalTokens      int   `json:"total_tokens"`
+	ToolCalls        int   `json:"tool_calls"`
+	DurationMs       int64 `json:"duration_ms"`
+	// Budget applied to the run, nil if unlimited
+	Budget *AgentBudget `json:"budget,omitempty"`
+	// Limit that ended the run early, empty if it finished within its budget
+	BudgetExceeded AgentBudgetLimit `json:"budget_exceeded,omitempty"`
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// AgentExportFormatVersion is the version of the agent export document format
+	AgentExportFormatVersion = 1
+	// MaxAgentExportSize is the maximum size of an agent export document accepted on import
+	MaxAgentExportSize = 1 << 20
+)
+
+// AgentExport is a portable document describing a custom agent.
+// The config keeps the IDs of the exporting environment; References names what each ID
+// points to, so that an import can resolve them to the resources of another environment.
+type AgentExport struct {
+	FormatVersion int       `yaml:"format_version" json:"format_version"`
+	ExportedAt    time.Time `yaml:"exported_at"    json:"exported_at"`
+	// Version of the agent that was exported, 0 for a built-in agent that was never changed
+	AgentVersion int               `yaml:"agent_version" json:"agent_version"`
+	Name         string            `yaml:"name"          json:"name"`
+	Description  string            `yaml:"description"   json:"description"`
+	Avatar       string            `yaml:"avatar"        json:"avatar"`
+	Config       CustomAgentConfig `yaml:"config"        json:"config"`
+	References   []AgentReference  `yaml:"references"    json:"references"`
+}
+
+// AgentReferenceKind is the kind of resource an agent config refers to
+type AgentReferenceKind string
+
+const (
+	AgentReferenceModel         AgentReferenceKind = "model"
+	AgentReferenceKnowledgeBase AgentReferenceKind = "knowledge_base"
+	AgentReferenceMCPService    AgentReferenceKind = "mcp_service"
+	AgentReferenceSubAgent      AgentReferenceKind = "sub_agent"
+	AgentReferenceDataSource    AgentReferenceKind = "data_source"
+)
+
+// AgentReference is a resource of the exporting environment the agent config refers to
+type AgentReference struct {
+	Kind AgentReferenceKind `yaml:"kind"           json:"kind"`
+	// ID in the exporting environment, as used in the config
+	ID   string `yaml:"id"             json:"id"`
+	Name string `yaml:"name"           json:"name"`
+	// Model type, a model is only resolved to a model of the same type
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+}
+
+// AgentReferenceStatus tells how a reference was resolved on import
+type AgentReferenceStatus string
+
+const (
+	// AgentReferenceMapped is resolved by the mapping given with the import
+	AgentReferenceMapped AgentReferenceStatus = "mapped"
+	// AgentReferenceMatched is resolved to the resource with the same ID, or else the only one with the same name
+	AgentReferenceMatched AgentReferenceStatus = "matched"
+	// AgentReferenceAmbiguous has several resources with the same name, a mapping is needed
+	AgentReferenceAmbiguous AgentReferenceStatus = "ambiguous"
+	// AgentReferenceMissing has no resource with the same name, a mapping is needed
+	AgentReferenceMissing AgentReferenceStatus = "missing"
+)
+
+// AgentReferenceCandidate is a resource of the importing tenant a reference may be mapped to
+type AgentReferenceCandidate struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// AgentReferenceResolution is how a reference of an export resolves in the importing tenant
+type AgentReferenceResolution struct {
+	AgentReference
+	Status AgentReferenceStatus `json:"status"`
+	// ID in the importing tenant, empty if unresolved
+	TargetID string `json:"target_id,omitempty"`
+	// Resources with the same name when the reference is ambiguous
+	Candidates []AgentReferenceCandidate `json:"candidates,omitempty"`
+}
+
+// Resolved reports whether the reference resolves to a resource of the importing tenant
+func (r *AgentReferenceResolution) Resolved() bool {
+	return r.TargetID != ""
+}
+
+// AgentReferenceMapping maps a reference of an export to a resource of the importing tenant
+type AgentReferenceMapping struct {
+	Kind     AgentReferenceKind `json:"kind"`
+	ID       string             `json:"id"`
+	TargetID string             `json:"target_id"`
+}
+
+// AgentImportPlan is the outcome of resolving the references of an export
+type AgentImportPlan struct {
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	AgentMode   string                     `json:"agent_mode"`
+	References  []AgentReferenceResolution `json:"references"`
+	// Number of references that still need a mapping
+	Unresolved int `json:"unresolved"`
+}
+
+// EncodeAgentExport encodes an export as "yaml" or "json".
+// Both formats use the JSON field names, so that a YAML document reads like the API.
+func EncodeAgentExport(export *AgentExport, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "json":
+		return data, nil
+	case "yaml":
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(doc)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// DecodeAgentExport decodes a YAML or JSON export and checks its format version
+func DecodeAgentExport(content []byte) (*AgentExport, error) {
+	if len(content) == 0 {
+		return nil, errors.New("export content is empty")
+	}
+	if len(content) > MaxAgentExportSize {
+		return nil, fmt.Errorf("export content exceeds %d bytes", MaxAgentExportSize)
+	}
+	// JSON 是 YAML 的子集，统一按 YAML 解析后再转换为 JSON 结构
+	var doc interface{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("invalid export content: %w", err)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export content: %w", err)
+	}
+	var export AgentExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid export content: %w", err)
+	}
+	if export.FormatVersion == 0 {
+		return nil, errors.New("export content has no format_version")
+	}
+	if export.FormatVersion > AgentExportFormatVersion {
+		return nil, fmt.Errorf("unsupported export format version %d", export.FormatVersion)
+	}
+	if export.Name == "" {
+		return nil, errors.New("export content has no agent name")
+	}
+	return &export, nil
+}
@@ -0,0 +1,177 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// TypeAgentScheduleDispatch is the periodic asynq task enqueueing the runs of due agent schedules
+	TypeAgentScheduleDispatch = "agent_schedule:dispatch"
+	// TypeAgentScheduledRun is the asynq task running an agent headlessly for a schedule
+	TypeAgentScheduledRun = "agent_schedule:run"
+)
+
+// AgentScheduleTriggerEvents are the knowledge lifecycle events a schedule can be triggered by
+var AgentScheduleTriggerEvents = []WebhookEventType{
+	WebhookEventKnowledgeParsed,
+	WebhookEventKnowledgeFailed,
+	WebhookEventKnowledgeDeleted,
+}
+
+// IsValidAgentScheduleTriggerEvent checks whether a schedule can be triggered by the event
+func IsValidAgentScheduleTriggerEvent(eventType WebhookEventType) bool {
+	for _, t := range AgentScheduleTriggerEvents {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// AgentSchedule runs a custom agent with a fixed prompt on a cron expression
+// and/or when knowledge lifecycle events occur, without an interactive chat request
+type AgentSchedule struct {
+	ID          string `json:"id"          gorm:"type:varchar(36);primaryKey"`
+	TenantID    uint64 `json:"tenant_id"   gorm:"index"`
+	AgentID     string `json:"agent_id"    gorm:"type:varchar(36)"`
+	Name        string `json:"name"        gorm:"type:varchar(255)"`
+	Description string `json:"description" gorm:"type:text"`
+	// Prompt 每次运行发送给智能体的问题
+	Prompt string `json:"prompt" gorm:"type:text"`
+	// Cron 标准 5 段 cron 表达式（支持 @daily 等），为空时仅由事件或手动触发
+	Cron string `json:"cron" gorm:"type:varchar(128)"`
+	// Timezone cron 表达式所在时区（IANA 名称），默认 UTC
+	Timezone string `json:"timezone" gorm:"type:varchar(64)"`
+	// TriggerEvents 触发运行的知识事件
+	TriggerEvents []WebhookEventType `json:"trigger_events" gorm:"type:jsonb;serializer:json"`
+	// TriggerKnowledgeBaseIDs 仅由这些知识库的事件触发，为空表示所有知识库
+	TriggerKnowledgeBaseIDs []string `json:"trigger_knowledge_base_ids" gorm:"type:jsonb;serializer:json"`
+	// OutputKnowledgeBaseID 运行结果通过 add_knowledge_to_kb 写回的知识库，为空时不写回
+	OutputKnowledgeBaseID string `json:"output_knowledge_base_id" gorm:"type:varchar(36)"`
+	// NotifyWebhook 运行结束后向订阅了 agent_run.finished 的 webhook 投递结果
+	NotifyWebhook bool `json:"notify_webhook"`
+	// SessionID 保存运行结果的会话，首次运行时创建
+	SessionID string `json:"session_id" gorm:"type:varchar(36)"`
+	Enabled   bool   `json:"enabled"`
+	// NextRunAt cron 的下一次运行时间，未配置 cron 或已停用时为空
+	NextRunAt *time.Time     `json:"next_run_at"`
+	LastRunAt *time.Time     `json:"last_run_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-"           gorm:"index"`
+}
+
+// TableName returns the table name of agent schedules
+func (AgentSchedule) TableName() string {
+	return "agent_schedules"
+}
+
+// BeforeCreate generates the schedule ID
+func (s *AgentSchedule) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TriggeredBy reports whether the schedule runs on the event of a knowledge in the knowledge base
+func (s *AgentSchedule) TriggeredBy(eventType WebhookEventType, knowledgeBaseID string) bool {
+	if !s.Enabled {
+		return false
+	}
+	// 写回的知识在解析完成后会再次产生事件，不能触发自身
+	if s.OutputKnowledgeBaseID != "" && s.OutputKnowledgeBaseID == knowledgeBaseID {
+		return false
+	}
+	subscribed := false
+	for _, t := range s.TriggerEvents {
+		if t == eventType {
+			subscribed = true
+			break
+		}
+	}
+	if !subscribed {
+		return false
+	}
+	if len(s.TriggerKnowledgeBaseIDs) == 0 {
+		return true
+	}
+	for _, id := range s.TriggerKnowledgeBaseIDs {
+		if id == knowledgeBaseID {
+			return true
+		}
+	}
+	return false
+}
+
+// AgentRunTrigger is what started a scheduled agent run
+type AgentRunTrigger string
+
+const (
+	AgentRunTriggerCron   AgentRunTrigger = "cron"
+	AgentRunTriggerEvent  AgentRunTrigger = "event"
+	AgentRunTriggerManual AgentRunTrigger = "manual"
+)
+
+// AgentRunStatus is the status of a scheduled agent run
+type AgentRunStatus string
+
+const (
+	AgentRunPending   AgentRunStatus = "pending" // 等待执行，事件触发的运行在此期间合并后续事件
+	AgentRunRunning   AgentRunStatus = "running"
+	AgentRunSucceeded AgentRunStatus = "succeeded"
+	AgentRunFailed    AgentRunStatus = "failed"
+)
+
+// AgentRunEvent is a knowledge lifecycle event that triggered a run
+type AgentRunEvent struct {
+	Type            WebhookEventType `json:"type"`
+	KnowledgeID     string           `json:"knowledge_id"`
+	KnowledgeBaseID string           `json:"knowledge_base_id"`
+	Title           string           `json:"title"`
+	OccurredAt      time.Time        `json:"occurred_at"`
+}
+
+// AgentScheduleRun records one headless run of a schedule
+type AgentScheduleRun struct {
+	ID         string          `json:"id"          gorm:"type:varchar(36);primaryKey"`
+	TenantID   uint64          `json:"tenant_id"   gorm:"index"`
+	ScheduleID string          `json:"schedule_id" gorm:"type:varchar(36);index"`
+	Trigger    AgentRunTrigger `json:"trigger"     gorm:"type:varchar(16)"`
+	// Events 触发本次运行的知识事件，多个事件在运行开始前合并为一次运行
+	Events    []AgentRunEvent `json:"events,omitempty" gorm:"type:jsonb;serializer:json"`
+	Status    AgentRunStatus  `json:"status"           gorm:"type:varchar(16)"`
+	SessionID string          `json:"session_id"       gorm:"type:varchar(36)"`
+	MessageID string          `json:"message_id"       gorm:"type:varchar(36)"`
+	Answer    string          `json:"answer"           gorm:"type:text"`
+	// OutputKnowledgeID 写回知识库后创建的知识
+	OutputKnowledgeID string      `json:"output_knowledge_id,omitempty" gorm:"type:varchar(36)"`
+	Usage             *AgentUsage `json:"usage,omitempty"               gorm:"type:jsonb;serializer:json"`
+	Error             string      `json:"error,omitempty"               gorm:"type:text"`
+	StartedAt         *time.Time  `json:"started_at"`
+	FinishedAt        *time.Time  `json:"finished_at"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+// TableName returns the table name of scheduled agent runs
+func (AgentScheduleRun) TableName() string {
+	return "agent_schedule_runs"
+}
+
+// BeforeCreate generates the run ID
+func (r *AgentScheduleRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// AgentScheduledRunPayload is the payload of a scheduled agent run task
+type AgentScheduledRunPayload struct {
+	TenantID uint64 `json:"tenant_id"`
+	RunID    string `json:"run_id"`
+}
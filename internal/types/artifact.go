@@ -0,0 +1,58 @@
+package types
+
+import (
+	"time"
+)
+
+// ArtifactType is the kind of structured output a tool attaches to an assistant message
+type ArtifactType string
+
+const (
+	// ArtifactChart is a Vega-Lite chart specification
+	ArtifactChart ArtifactType = "chart"
+	// ArtifactCSV is a downloadable CSV file of a result set
+	ArtifactCSV ArtifactType = "csv"
+	// ArtifactXLSX is a downloadable Excel workbook of a result set
+	ArtifactXLSX ArtifactType = "xlsx"
+)
+
+// IsValidArtifactType checks whether the artifact type is supported
+func IsValidArtifactType(t ArtifactType) bool {
+	switch t {
+	case ArtifactChart, ArtifactCSV, ArtifactXLSX:
+		return true
+	}
+	return false
+}
+
+// MessageArtifact is a chart or file produced by a tool call and stored against the assistant message.
+// The final answer references it as <artifact id="..."/> so clients can render it in place.
+type MessageArtifact struct {
+	ID         string       `json:"id"           gorm:"type:varchar(36);primaryKey"`
+	TenantID   uint64       `json:"-"            gorm:"index:idx_message_artifacts_session"`
+	SessionID  string       `json:"session_id"   gorm:"type:varchar(36);index:idx_message_artifacts_session"`
+	MessageID  string       `json:"message_id"   gorm:"type:varchar(36);index"`
+	ToolCallID string       `json:"tool_call_id" gorm:"type:varchar(128)"`
+	Type       ArtifactType `json:"type"         gorm:"type:varchar(16)"`
+	Title      string       `json:"title"        gorm:"type:varchar(255)"`
+	// Vega-Lite 图表定义，仅 chart 类型
+	Spec JSON `json:"spec,omitempty" gorm:"type:jsonb"`
+	// 文件名、类型及大小，仅 csv/xlsx 类型
+	FileName    string `json:"file_name,omitempty"    gorm:"type:varchar(255)"`
+	ContentType string `json:"content_type,omitempty" gorm:"type:varchar(128)"`
+	Size        int64  `json:"size,omitempty"`
+	// 文件内容，通过下载接口获取
+	Content   []byte    `json:"-"         gorm:"type:bytea"`
+	RowCount  int       `json:"row_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name of message artifacts
+func (MessageArtifact) TableName() string {
+	return "message_artifacts"
+}
+
+// ArtifactReference returns the tag the final answer uses to place the artifact
+func (a *MessageArtifact) ArtifactReference() string {
+	return `<artifact id="` + a.ID + `"/>`
+}
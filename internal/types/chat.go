@@ -50,6 +50,10 @@ const (
 	ResponseTypeToolCall ResponseType = "tool_call"
 	// Tool result response type (for agent tool results)
 	ResponseTypeToolResult ResponseType = "tool_result"
+	// Tool approval response type (a tool call waiting for the user's approval, or its decision)
+	ResponseTypeToolApproval ResponseType = "tool_approval"
+	// Artifact response type (a chart or downloadable file produced by a tool)
+	ResponseTypeArtifact ResponseType = "artifact"
 	// Error response type
 	ResponseTypeError ResponseType = "error"
 	// Reflection response type (for agent reflection)
@@ -82,6 +86,15 @@ type StreamResponse struct {
 	ToolCalls []LLMToolCall `json:"tool_calls,omitempty"`
 	// Additional metadata for enhanced display
 	Data map[string]interface{} `json:"data,omitempty"`
+	// Token usage of the whole call, only set on the final chunk when the model reports it
+	Usage *TokenUsage `json:"-"`
+}
+
+// TokenUsage is the token consumption reported by a model
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 // References references
@@ -44,6 +44,7 @@ type ChatManage struct {
 	EntityKnowledge map[string]string `json:"-"` // KnowledgeID -> KnowledgeBaseID mapping for graph-enabled files
 	GraphResult     *GraphData        `json:"-"` // Graph data from search phase
 	UserContent     string            `json:"-"` // Processed user content
+	Images          []string          `json:"-"` // Image attachments (data URIs) passed to vision-capable chat models
 	ChatResponse    *ChatResponse     `json:"-"` // Final response from chat model
 
 	// Event system for streaming responses
@@ -123,6 +124,7 @@ func (c *ChatManage) Clone() *ChatManage {
 		EnableRewrite:        c.EnableRewrite,
 		EnableQueryExpansion: c.EnableQueryExpansion,
 		TenantID:             c.TenantID,
+		Images:               append([]string(nil), c.Images...),
 		// FAQ Strategy Settings
 		FAQPriorityEnabled:       c.FAQPriorityEnabled,
 		FAQDirectAnswerThreshold: c.FAQDirectAnswerThreshold,
@@ -147,6 +149,7 @@ const (
 	CHAT_COMPLETION_STREAM EventType = "chat_completion_stream" // Stream chat completion
 	STREAM_FILTER          EventType = "stream_filter"          // Filter streaming output
 	FILTER_TOP_K           EventType = "filter_top_k"           // Keep only top K results
+	COMMUNITY_SEARCH       EventType = "community_search"       // Search graph community summaries
 )
 
 // Pipline defines the sequence of events for different chat modes
@@ -181,4 +184,11 @@ var Pipline = map[string][]EventType{
 		CHAT_COMPLETION_STREAM,
 		STREAM_FILTER,
 	},
+	"rag_global_stream": { // Streaming global search answering from graph community summaries
+		REWRITE_QUERY,
+		COMMUNITY_SEARCH,
+		INTO_CHAT_MESSAGE,
+		CHAT_COMPLETION_STREAM,
+		STREAM_FILTER,
+	},
 }
@@ -32,6 +32,8 @@ const (
 	ChunkTypeTableSummary ChunkType = "table_summary"
 	// ChunkTypeTableColumn 表示数据表列描述的 Chunk
 	ChunkTypeTableColumn ChunkType = "table_column"
+	// ChunkTypeGraphCommunity 表示知识图谱社区摘要，仅出现在全局检索结果中
+	ChunkTypeGraphCommunity ChunkType = "graph_community"
 )
 
 // ChunkStatus 定义了不同状态的 Chunk
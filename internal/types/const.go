@@ -12,6 +12,14 @@ const (
 	RequestIDContextKey ContextKey = "RequestID"
 	// LoggerContextKey is the context key for logger
 	LoggerContextKey ContextKey = "Logger"
+	// UserIDContextKey is the context key for the authenticated user ID, absent for API key requests
+	UserIDContextKey ContextKey = "UserID"
+	// ToolCallIDContextKey is the context key for the ID of the agent tool call being executed
+	ToolCallIDContextKey ContextKey = "ToolCallID"
+	// MessageIDContextKey is the context key for the ID of the assistant message an agent tool call belongs to
+	MessageIDContextKey ContextKey = "MessageID"
+	// IngestionContextKey marks background ingestion work, whose embedding calls are subject to the tenant's limits
+	IngestionContextKey ContextKey = "Ingestion"
 )
 
 // String returns the string representation of the context key
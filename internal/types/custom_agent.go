@@ -3,6 +3,9 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
 	"time"
 
 	"gorm.io/gorm"
@@ -55,6 +58,8 @@ type CustomAgent struct {
 
 	// Agent configuration
 	Config CustomAgentConfig `yaml:"config" json:"config" gorm:"type:json"`
+	// Current version of the settings, 0 for a built-in agent that was never changed
+	Version int `yaml:"version" json:"version" gorm:"default:0"`
 
 	// Timestamps
 	CreatedAt time.Time      `yaml:"created_at" json:"created_at"`
@@ -93,6 +98,16 @@ type CustomAgentConfig struct {
 	MCPSelectionMode string `yaml:"mcp_selection_mode" json:"mcp_selection_mode"`
 	// Selected MCP service IDs (only used when MCPSelectionMode is "selected")
 	MCPServices []string `yaml:"mcp_services" json:"mcp_services"`
+	// MCP prompt bound as the system prompt template (only for agent type, rendered at runtime)
+	MCPPrompt *MCPPromptBinding `yaml:"mcp_prompt,omitempty" json:"mcp_prompt,omitempty"`
+	// Approval policy of tools with side effects: auto, confirm or deny per tool (only for agent type)
+	ToolApprovalPolicy *ToolApprovalPolicy `yaml:"tool_approval_policy,omitempty" json:"tool_approval_policy,omitempty"`
+	// IDs of custom agents this agent can delegate sub-tasks to, each is exposed as a tool (only for agent type)
+	SubAgents []string `yaml:"sub_agents,omitempty" json:"sub_agents,omitempty"`
+	// IDs of external data sources the agent can describe and query read-only (only for agent type)
+	DataSources []string `yaml:"data_sources,omitempty" json:"data_sources,omitempty"`
+	// Limits of tokens, time and tool calls per run, capped by the budget of the tenant (only for agent type)
+	Budget *AgentBudget `yaml:"budget,omitempty" json:"budget,omitempty"`
 
 	// ===== Knowledge Base Settings =====
 	// Knowledge base selection mode: "all" = all KBs, "selected" = specific KBs, "none" = no KB
@@ -126,6 +141,12 @@ type CustomAgentConfig struct {
 	// Number of history turns to keep in context
 	HistoryTurns int `yaml:"history_turns" json:"history_turns"`
 
+	// ===== Long-term Memory Settings =====
+	// Whether the agent remembers durable facts about the user across sessions (requires a logged-in user)
+	MemoryEnabled bool `yaml:"memory_enabled" json:"memory_enabled"`
+	// Embedding model used to recall memories, empty means recalling the most recently updated memories
+	MemoryEmbeddingModelID string `yaml:"memory_embedding_model_id" json:"memory_embedding_model_id"`
+
 	// ===== Retrieval Strategy Settings (for both modes) =====
 	// Embedding/Vector retrieval top K
 	EmbeddingTopK int `yaml:"embedding_top_k" json:"embedding_top_k"`
@@ -137,6 +158,9 @@ type CustomAgentConfig struct {
 	RerankTopK int `yaml:"rerank_top_k" json:"rerank_top_k"`
 	// Rerank threshold
 	RerankThreshold float64 `yaml:"rerank_threshold" json:"rerank_threshold"`
+	// Knowledge search mode of normal mode: "local" (default) searches chunks and entities,
+	// "global" answers corpus-wide questions from the summaries of the knowledge graph communities
+	KnowledgeSearchMode string `yaml:"knowledge_search_mode" json:"knowledge_search_mode"`
 
 	// ===== Advanced Settings (mainly for normal mode) =====
 	// Whether to enable query expansion
@@ -228,6 +252,28 @@ func (a *CustomAgent) IsAgentMode() bool {
 	return a.Config.AgentMode == AgentModeSmartReasoning
 }
 
+// MaxSubAgents is the maximum number of agents one agent can delegate sub-tasks to
+const MaxSubAgents = 10
+
+// ValidateSubAgents checks the sub-agents configured for the agent with the given ID (empty when creating)
+func (c *CustomAgentConfig) ValidateSubAgents(agentID string) error {
+	if len(c.SubAgents) > MaxSubAgents {
+		return fmt.Errorf("at most %d sub-agents are allowed", MaxSubAgents)
+	}
+	for i, id := range c.SubAgents {
+		if id == "" {
+			return errors.New("sub-agent ID cannot be empty")
+		}
+		if agentID != "" && id == agentID {
+			return errors.New("an agent cannot delegate to itself")
+		}
+		if slices.Contains(c.SubAgents[:i], id) {
+			return fmt.Errorf("duplicate sub-agent: %s", id)
+		}
+	}
+	return nil
+}
+
 // GetBuiltinQuickAnswerAgent returns the built-in quick answer (RAG) mode agent
 func GetBuiltinQuickAnswerAgent(tenantID uint64) *CustomAgent {
 	return &CustomAgent{
@@ -342,7 +388,7 @@ Help users explore, analyze, and derive insights from their tabular data through
 
 ### Tool Guidelines
 - **data_schema:** ALWAYS use first. Required before any query.
-- **data_analysis:** Execute SQL queries. Only SELECT queries allowed.
+- **data_analysis:** Execute SQL queries. Only SELECT queries allowed. Set artifact to chart (with mark, x and y) to visualize trends, comparisons or shares, or to csv/xlsx when the user wants to download the data.
 - **thinking:** Plan complex analyses, debug query issues.
 - **todo_write:** Track multi-step analysis tasks.
 
@@ -350,6 +396,7 @@ Help users explore, analyze, and derive insights from their tabular data through
 - Present results in well-formatted tables or summaries
 - Provide actionable insights, not just raw numbers
 - Relate findings back to the user's original question
+- Place each <artifact id="..."/> tag returned by data_analysis in the answer exactly as given, where the chart or download should appear
 
 Current Time: {{current_time}}
 `,
@@ -508,6 +555,7 @@ func GetBuiltinKnowledgeGraphExpertAgent(tenantID uint64) *CustomAgent {
 2. **知识网络分析**：分析实体的关联网络和语义连接
 3. **关系可视化解释**：清晰解释实体之间的关系类型和连接路径
 4. **深度关联挖掘**：发现隐藏的知识关联和间接关系
+5. **全局概览**：使用 graph_global_search 基于图谱社区摘要回答涉及整个知识库的问题
 
 ### When to Use Knowledge Graph
 ✅ **适合使用图谱查询的场景**：
@@ -547,6 +595,7 @@ func GetBuiltinKnowledgeGraphExpertAgent(tenantID uint64) *CustomAgent {
 
 ### Tool Guidelines
 - **query_knowledge_graph**：核心工具，查询实体和关系
+- **graph_global_search**：回答"主要主题有哪些"等全局性问题，基于图谱社区摘要
 - **knowledge_search**：补充文本搜索，获取上下文
 - **list_knowledge_chunks**：获取详细文档内容
 - **get_document_info**：了解文档元信息
@@ -577,6 +626,7 @@ func GetBuiltinKnowledgeGraphExpertAgent(tenantID uint64) *CustomAgent {
 				"thinking",
 				"todo_write",
 				"query_knowledge_graph", // 核心工具：查询知识图谱
+				"graph_global_search",   // 全局性问题：检索图谱社区摘要
 				"knowledge_search",      // 补充工具：文本搜索
 				"list_knowledge_chunks", // 获取详细内容
 				"get_document_info",     // 获取文档信息
@@ -0,0 +1,185 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notes recorded with the versions of a custom agent
+const (
+	AgentVersionNoteCreated = "created"
+	AgentVersionNoteUpdated = "updated"
+	AgentVersionNoteCopied  = "copied"
+)
+
+// CustomAgentVersion is an immutable snapshot of the settings of a custom agent.
+// A version is recorded whenever the name, description, avatar or config of the agent changes.
+type CustomAgentVersion struct {
+	ID       string `json:"id"        gorm:"type:varchar(36);primaryKey"`
+	TenantID uint64 `json:"tenant_id" gorm:"index"`
+	AgentID  string `json:"agent_id"  gorm:"type:varchar(36)"`
+	// Version number, starting from 1 for each agent
+	Version     int               `json:"version"`
+	Name        string            `json:"name"        gorm:"type:varchar(255)"`
+	Description string            `json:"description" gorm:"type:text"`
+	Avatar      string            `json:"avatar"      gorm:"type:varchar(64)"`
+	Config      CustomAgentConfig `json:"config"      gorm:"type:json"`
+	// What produced the version, e.g. "updated" or "rolled back to version 3"
+	Note      string    `json:"note"       gorm:"type:varchar(255)"`
+	CreatedBy string    `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for CustomAgentVersion
+func (CustomAgentVersion) TableName() string {
+	return "custom_agent_versions"
+}
+
+// BeforeCreate generates the ID of a version
+func (v *CustomAgentVersion) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// NewVersion returns the current settings of the agent as its next version
+func (a *CustomAgent) NewVersion(note, createdBy string) *CustomAgentVersion {
+	return &CustomAgentVersion{
+		TenantID:    a.TenantID,
+		AgentID:     a.ID,
+		Version:     a.Version + 1,
+		Name:        a.Name,
+		Description: a.Description,
+		Avatar:      a.Avatar,
+		Config:      a.Config,
+		Note:        note,
+		CreatedBy:   createdBy,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// SameSettings reports whether two agents have the same name, description, avatar and config
+func (a *CustomAgent) SameSettings(other *CustomAgent) bool {
+	if a.Name != other.Name || a.Description != other.Description || a.Avatar != other.Avatar {
+		return false
+	}
+	// 比较序列化后的配置，nil 与空切片视为相同
+	left, err1 := json.Marshal(a.Config)
+	right, err2 := json.Marshal(other.Config)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	var l, r interface{}
+	_ = json.Unmarshal(left, &l)
+	_ = json.Unmarshal(right, &r)
+	return reflect.DeepEqual(normalizeAgentSetting(l), normalizeAgentSetting(r))
+}
+
+// AgentSettingChange is a setting that differs between two versions of an agent
+type AgentSettingChange struct {
+	// Dotted path of the setting, e.g. "name" or "config.model_id"
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AgentVersionDiff lists the settings changed from one version of an agent to another
+type AgentVersionDiff struct {
+	AgentID     string               `json:"agent_id"`
+	FromVersion int                  `json:"from_version"`
+	ToVersion   int                  `json:"to_version"`
+	Changes     []AgentSettingChange `json:"changes"`
+}
+
+// DiffAgentVersions compares the settings of two versions of an agent.
+// Nested objects are compared setting by setting, lists as a whole.
+func DiffAgentVersions(from, to *CustomAgentVersion) (*AgentVersionDiff, error) {
+	left, err := agentVersionSettings(from)
+	if err != nil {
+		return nil, err
+	}
+	right, err := agentVersionSettings(to)
+	if err != nil {
+		return nil, err
+	}
+	diff := &AgentVersionDiff{
+		AgentID:     to.AgentID,
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Changes:     []AgentSettingChange{},
+	}
+	diffAgentSettings("", left, right, &diff.Changes)
+	sort.Slice(diff.Changes, func(i, j int) bool { return diff.Changes[i].Path < diff.Changes[j].Path })
+	return diff, nil
+}
+
+// agentVersionSettings returns the settings of a version as a generic JSON object
+func agentVersionSettings(v *CustomAgentVersion) (map[string]interface{}, error) {
+	data, err := json.Marshal(struct {
+		Name        string            `json:"name"`
+		Description string            `json:"description"`
+		Avatar      string            `json:"avatar"`
+		Config      CustomAgentConfig `json:"config"`
+	}{v.Name, v.Description, v.Avatar, v.Config})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode version %d: %w", v.Version, err)
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to decode version %d: %w", v.Version, err)
+	}
+	return settings, nil
+}
+
+func diffAgentSettings(prefix string, from, to map[string]interface{}, changes *[]AgentSettingChange) {
+	keys := make(map[string]struct{}, len(from)+len(to))
+	for key := range from {
+		keys[key] = struct{}{}
+	}
+	for key := range to {
+		keys[key] = struct{}{}
+	}
+	for key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		left, right := normalizeAgentSetting(from[key]), normalizeAgentSetting(to[key])
+		leftMap, leftIsMap := left.(map[string]interface{})
+		rightMap, rightIsMap := right.(map[string]interface{})
+		if leftIsMap && rightIsMap {
+			diffAgentSettings(path, leftMap, rightMap, changes)
+			continue
+		}
+		if !reflect.DeepEqual(left, right) {
+			*changes = append(*changes, AgentSettingChange{Path: path, From: left, To: right})
+		}
+	}
+}
+
+// normalizeAgentSetting treats empty lists and objects as unset
+func normalizeAgentSetting(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		normalized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized[key] = normalizeAgentSetting(item)
+		}
+		return normalized
+	}
+	return value
+}
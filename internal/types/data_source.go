@@ -0,0 +1,130 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DataSourceType is the kind of database behind a data source
+type DataSourceType string
+
+const (
+	DataSourcePostgres DataSourceType = "postgres"
+	DataSourceMySQL    DataSourceType = "mysql"
+	DataSourceSQLite   DataSourceType = "sqlite"
+)
+
+// IsValidDataSourceType checks whether the data source type is supported
+func IsValidDataSourceType(t DataSourceType) bool {
+	switch t {
+	case DataSourcePostgres, DataSourceMySQL, DataSourceSQLite:
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultDataSourceRowLimit is the row limit of queries when the data source does not set one
+	DefaultDataSourceRowLimit = 200
+	// MaxDataSourceRowLimit bounds the row limit a data source may set
+	MaxDataSourceRowLimit = 5000
+)
+
+// DataSourceConnection holds how to connect to a data source
+type DataSourceConnection struct {
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Database string `json:"database,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	SSLMode  string `json:"ssl_mode,omitempty"` // PostgreSQL sslmode, e.g. disable, require
+	Path     string `json:"path,omitempty"`     // SQLite file, relative to the configured SQLite directory
+}
+
+// Value implements driver.Valuer interface for DataSourceConnection
+func (c DataSourceConnection) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements sql.Scanner interface for DataSourceConnection
+func (c *DataSourceConnection) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// DataSource is an external database a tenant registers for agents to query read-only
+type DataSource struct {
+	ID          string               `json:"id"             gorm:"type:varchar(36);primaryKey"`
+	TenantID    uint64               `json:"tenant_id"      gorm:"index"`
+	Name        string               `json:"name"           gorm:"type:varchar(255)"`
+	Description string               `json:"description"    gorm:"type:text"` // 提供给智能体，说明数据源包含的业务数据
+	Type        DataSourceType       `json:"type"           gorm:"type:varchar(32)"`
+	Connection  DataSourceConnection `json:"connection"     gorm:"type:jsonb"`
+	// AllowedTables 允许查询的表，可带 schema 前缀，如 sales.orders
+	AllowedTables []string       `json:"allowed_tables" gorm:"type:jsonb;serializer:json"`
+	RowLimit      int            `json:"row_limit"` // 单次查询返回的最大行数
+	Enabled       bool           `json:"enabled"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-"              gorm:"index"`
+}
+
+// TableName returns the table name of data sources
+func (DataSource) TableName() string {
+	return "data_sources"
+}
+
+// BeforeCreate generates the data source ID
+func (d *DataSource) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// EffectiveRowLimit returns the row limit applied to queries
+func (d *DataSource) EffectiveRowLimit() int {
+	if d.RowLimit <= 0 {
+		return DefaultDataSourceRowLimit
+	}
+	return min(d.RowLimit, MaxDataSourceRowLimit)
+}
+
+// HideSecret returns a copy of the data source without the password
+func (d *DataSource) HideSecret() *DataSource {
+	copy := *d
+	copy.Connection.Password = ""
+	return &copy
+}
+
+// DataSourceColumn describes a column of a data source table
+type DataSourceColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// DataSourceTable describes an allowed table of a data source
+type DataSourceTable struct {
+	Name    string              `json:"name"`
+	Columns []*DataSourceColumn `json:"columns"`
+}
+
+// DataSourceQueryResult is the result of a read-only query on a data source
+type DataSourceQueryResult struct {
+	Columns   []string                 `json:"columns"`
+	Rows      []map[string]interface{} `json:"rows"`
+	RowCount  int                      `json:"row_count"`
+	Truncated bool                     `json:"truncated"` // 结果超过行数上限，仅返回前 row_limit 行
+	Query     string                   `json:"query"`
+}
@@ -136,6 +136,8 @@ type GraphRelation struct {
 	Node1 string `json:"node1,omitempty"`
 	Node2 string `json:"node2,omitempty"`
 	Type  string `json:"type,omitempty"`
+	// Chunks the relation was extracted from
+	Chunks []string `json:"chunks,omitempty"`
 }
 
 type GraphData struct {
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TypeGraphCommunity is the asynq task type resolving duplicate entities and rebuilding
+// the communities of the knowledge graph of a knowledge base
+const TypeGraphCommunity = "graph:community"
+
+// Knowledge search modes of normal mode agents
+const (
+	// KnowledgeSearchModeLocal searches the chunks and entities matching the question
+	KnowledgeSearchModeLocal = "local"
+	// KnowledgeSearchModeGlobal answers corpus-wide questions from the summaries of the graph communities
+	KnowledgeSearchModeGlobal = "global"
+)
+
+// GraphCommunity is a group of closely related entities of the knowledge graph of a knowledge base,
+// summarized by the LLM so questions about the whole corpus can be answered without reading every chunk
+type GraphCommunity struct {
+	ID              string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
+	Title           string `json:"title"             gorm:"type:varchar(255)"`
+	Summary         string `json:"summary"           gorm:"type:text"`
+	// 社区包含的实体，按关系数从多到少排列
+	Entities StringArray `json:"entities"          gorm:"type:jsonb"`
+	// 实体数及社区内部的关系数
+	Size          int `json:"size"`
+	RelationCount int `json:"relation_count"`
+	// 检索用的摘要向量及生成向量的模型
+	Embedding        GraphCommunityEmbedding `json:"-"                 gorm:"type:jsonb"`
+	EmbeddingModelID string                  `json:"-"                 gorm:"type:varchar(64)"`
+	// Similarity to the query, only set by searches
+	Score     float64   `json:"score,omitempty"   gorm:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name of graph communities
+func (GraphCommunity) TableName() string {
+	return "graph_communities"
+}
+
+// BeforeCreate generates the community ID
+func (c *GraphCommunity) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// GraphCommunityEmbedding is the embedding of a community summary, stored as a JSON array like memory embeddings
+type GraphCommunityEmbedding = MemoryEmbedding
+
+// GraphCommunityPayload is the payload of graph community tasks
+type GraphCommunityPayload struct {
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	// 重建社区前先合并重复实体
+	ResolveEntities bool `json:"resolve_entities"`
+}
+
+// GraphCommunityRebuild is the result of scheduling a community rebuild
+type GraphCommunityRebuild struct {
+	TaskID          string `json:"task_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	ResolveEntities bool   `json:"resolve_entities"`
+}
@@ -21,10 +21,25 @@ type AgentStreamEvent struct {
 // AgentEngine defines the interface for agent execution engine
 type AgentEngine interface {
 	// Execute executes the agent with conversation history and returns a stream of events
+	// images are optional image attachments (data URIs) of the current query
 	Execute(
 		ctx context.Context,
 		sessionID, messageID, query string,
 		llmContext []chat.Message,
+		images []string,
+	) (*types.AgentState, error)
+}
+
+// SubAgentRunner runs custom agents that another agent delegates sub-tasks to
+type SubAgentRunner interface {
+	// RunSubAgent runs the custom agent on the task and returns its final state.
+	// path holds the IDs of the delegating agents, outermost first; events of the run are emitted on eventBus
+	RunSubAgent(
+		ctx context.Context,
+		agentID, task string,
+		path []string,
+		sessionID string,
+		eventBus *event.EventBus,
 	) (*types.AgentState, error)
 }
 
@@ -39,6 +54,7 @@ type AgentService interface {
 		eventBus *event.EventBus,
 		contextManager ContextManager,
 		sessionID string,
+		subAgentRunner SubAgentRunner,
 	) (AgentEngine, error)
 
 	// ValidateConfig validates an agent configuration
@@ -0,0 +1,65 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// AgentScheduleService manages the agent schedules of the current tenant and enqueues their runs
+type AgentScheduleService interface {
+	// CreateSchedule creates a schedule and computes its next cron run
+	CreateSchedule(ctx context.Context, schedule *types.AgentSchedule) error
+	// GetSchedule gets a schedule of the current tenant
+	GetSchedule(ctx context.Context, id string) (*types.AgentSchedule, error)
+	// ListSchedules lists the schedules of the current tenant
+	ListSchedules(ctx context.Context) ([]*types.AgentSchedule, error)
+	// UpdateSchedule updates a schedule and recomputes its next cron run
+	UpdateSchedule(ctx context.Context, schedule *types.AgentSchedule) (*types.AgentSchedule, error)
+	// DeleteSchedule deletes a schedule of the current tenant, its session and runs are kept
+	DeleteSchedule(ctx context.Context, id string) error
+	// ListRuns lists the runs of a schedule, newest first
+	ListRuns(ctx context.Context, scheduleID string, page *types.Pagination) (*types.PageResult, error)
+	// GetRun gets a run of a schedule
+	GetRun(ctx context.Context, scheduleID, runID string) (*types.AgentScheduleRun, error)
+	// RunNow enqueues a run of a schedule immediately
+	RunNow(ctx context.Context, id string) (*types.AgentScheduleRun, error)
+	// TriggerKnowledgeEvent enqueues the runs of the schedules triggered by a knowledge lifecycle event.
+	// Failures are logged only, triggering never fails the operation that raised the event
+	TriggerKnowledgeEvent(ctx context.Context, eventType types.WebhookEventType, knowledge *types.Knowledge)
+	// ProcessDispatch handles the periodic task enqueueing the runs of due cron schedules of all tenants
+	ProcessDispatch(ctx context.Context, t *asynq.Task) error
+}
+
+// AgentScheduleRepository stores agent schedules and their runs
+type AgentScheduleRepository interface {
+	CreateSchedule(ctx context.Context, schedule *types.AgentSchedule) error
+	GetSchedule(ctx context.Context, tenantID uint64, id string) (*types.AgentSchedule, error)
+	ListSchedules(ctx context.Context, tenantID uint64) ([]*types.AgentSchedule, error)
+	// ListEventSchedules lists the enabled schedules of a tenant triggered by knowledge events
+	ListEventSchedules(ctx context.Context, tenantID uint64) ([]*types.AgentSchedule, error)
+	// ListDueSchedules lists the enabled cron schedules of all tenants due at the time
+	ListDueSchedules(ctx context.Context, now time.Time, limit int) ([]*types.AgentSchedule, error)
+	UpdateSchedule(ctx context.Context, schedule *types.AgentSchedule) error
+	// ClaimCronRun moves the next run of a schedule forward if it is still the one read,
+	// so that a due run is enqueued once when several workers dispatch at the same time
+	ClaimCronRun(ctx context.Context, schedule *types.AgentSchedule, nextRunAt *time.Time) (bool, error)
+	// UpdateSessionID records the session collecting the results of a schedule
+	UpdateSessionID(ctx context.Context, tenantID uint64, id, sessionID string) error
+	DeleteSchedule(ctx context.Context, tenantID uint64, id string) error
+
+	CreateRun(ctx context.Context, run *types.AgentScheduleRun) error
+	GetRun(ctx context.Context, tenantID uint64, id string) (*types.AgentScheduleRun, error)
+	// GetPendingEventRun gets the event run of a schedule still waiting to start, nil if none
+	GetPendingEventRun(ctx context.Context, tenantID uint64, scheduleID string) (*types.AgentScheduleRun, error)
+	// AppendRunEvents adds events to a run if it has not started yet, reporting whether it had not
+	AppendRunEvents(ctx context.Context, run *types.AgentScheduleRun, events []types.AgentRunEvent) (bool, error)
+	UpdateRun(ctx context.Context, run *types.AgentScheduleRun) error
+	// IsRunOutput reports whether a knowledge was written back by a scheduled run
+	IsRunOutput(ctx context.Context, tenantID uint64, knowledgeID string) (bool, error)
+	ListRuns(
+		ctx context.Context, tenantID uint64, scheduleID string, page *types.Pagination,
+	) ([]*types.AgentScheduleRun, int64, error)
+}
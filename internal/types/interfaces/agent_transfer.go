@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// AgentTransferService exports custom agents as portable documents and imports them into the current tenant.
+// Models, knowledge bases, MCP services, sub-agents and data sources referenced by an agent are
+// resolved by name on import, or by the mappings given with the import.
+type AgentTransferService interface {
+	// ExportAgent exports the current settings of an agent together with the names of the resources it references
+	ExportAgent(ctx context.Context, id string) (*types.AgentExport, error)
+	// PlanImport resolves the references of an export in the current tenant without importing it
+	PlanImport(ctx context.Context, export *types.AgentExport,
+		mappings []types.AgentReferenceMapping) (*types.AgentImportPlan, error)
+	// ImportAgent creates an agent from an export, failing if a reference is unresolved unless skipUnresolved
+	// is set, in which case unresolved references are removed from the config. A non-empty name overrides the exported one.
+	ImportAgent(ctx context.Context, export *types.AgentExport, mappings []types.AgentReferenceMapping,
+		name string, skipUnresolved bool) (*types.CustomAgent, error)
+}
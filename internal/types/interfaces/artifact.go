@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ArtifactService stores the charts and files agent tools attach to assistant messages
+type ArtifactService interface {
+	// SaveArtifact persists an artifact produced by a tool call
+	SaveArtifact(ctx context.Context, artifact *types.MessageArtifact) error
+	// ListMessageArtifacts lists the artifacts of a message of a session of the current tenant, without file content
+	ListMessageArtifacts(ctx context.Context, sessionID string, messageID string) ([]*types.MessageArtifact, error)
+	// GetArtifact gets an artifact of a session of the current tenant, including file content
+	GetArtifact(ctx context.Context, sessionID string, id string) (*types.MessageArtifact, error)
+}
+
+// ArtifactRepository stores message artifacts
+type ArtifactRepository interface {
+	CreateArtifact(ctx context.Context, artifact *types.MessageArtifact) error
+	GetArtifact(ctx context.Context, tenantID uint64, id string) (*types.MessageArtifact, error)
+	ListMessageArtifacts(ctx context.Context, tenantID uint64, messageID string) ([]*types.MessageArtifact, error)
+}
@@ -61,6 +61,56 @@ type CustomAgentService interface {
 	//   - The newly created agent copy
 	//   - Possible errors such as not existing, insufficient permissions, etc.
 	CopyAgent(ctx context.Context, id string) (*types.CustomAgent, error)
+
+	// ImportAgent creates an agent from an export whose references were resolved
+	// Parameters:
+	//   - ctx: Context information
+	//   - agent: Agent object built from the export
+	//   - note: Note recorded with the first version, describing where the agent came from
+	// Returns:
+	//   - The newly created agent
+	//   - Possible errors such as validation errors, etc.
+	ImportAgent(ctx context.Context, agent *types.CustomAgent, note string) (*types.CustomAgent, error)
+
+	// ListAgentVersions lists the versions of an agent's settings
+	// Parameters:
+	//   - ctx: Context information
+	//   - id: Unique identifier of the agent
+	// Returns:
+	//   - List of versions, newest first (empty for a built-in agent that was never changed)
+	//   - Possible errors such as not existing, etc.
+	ListAgentVersions(ctx context.Context, id string) ([]*types.CustomAgentVersion, error)
+
+	// GetAgentVersion gets a version of an agent's settings
+	// Parameters:
+	//   - ctx: Context information
+	//   - id: Unique identifier of the agent
+	//   - version: Version number
+	// Returns:
+	//   - Version object
+	//   - Possible errors such as not existing, etc.
+	GetAgentVersion(ctx context.Context, id string, version int) (*types.CustomAgentVersion, error)
+
+	// DiffAgentVersions compares two versions of an agent's settings
+	// Parameters:
+	//   - ctx: Context information
+	//   - id: Unique identifier of the agent
+	//   - from: Version compared against
+	//   - to: Version whose changes are listed
+	// Returns:
+	//   - The settings changed from the first version to the second
+	//   - Possible errors such as not existing, etc.
+	DiffAgentVersions(ctx context.Context, id string, from, to int) (*types.AgentVersionDiff, error)
+
+	// RollbackAgent restores the settings of a previous version, recording them as a new version
+	// Parameters:
+	//   - ctx: Context information
+	//   - id: Unique identifier of the agent
+	//   - version: Version to restore
+	// Returns:
+	//   - Updated agent object
+	//   - Possible errors such as not existing, etc.
+	RollbackAgent(ctx context.Context, id string, version int) (*types.CustomAgent, error)
 }
 
 // CustomAgentRepository defines the custom agent repository interface
@@ -119,4 +169,43 @@ type CustomAgentRepository interface {
 	//   - true if the agent is soft deleted, false otherwise
 	//   - Possible errors such as database errors, etc.
 	IsBuiltinAgentDeleted(ctx context.Context, agentID string, tenantID uint64) (bool, error)
+
+	// CreateAgentWithVersion creates an agent record together with its first version, in one transaction
+	// Parameters:
+	//   - ctx: Context information
+	//   - agent: Agent object
+	//   - version: First version of the agent settings
+	// Returns:
+	//   - Possible errors such as unique constraint conflicts, database errors, etc.
+	CreateAgentWithVersion(ctx context.Context, agent *types.CustomAgent, version *types.CustomAgentVersion) error
+
+	// UpdateAgentWithVersion updates an agent record and records a new version, in one transaction
+	// Parameters:
+	//   - ctx: Context information
+	//   - agent: Agent object containing update information
+	//   - version: New version of the agent settings
+	// Returns:
+	//   - Possible errors such as a version recorded concurrently, database errors, etc.
+	UpdateAgentWithVersion(ctx context.Context, agent *types.CustomAgent, version *types.CustomAgentVersion) error
+
+	// ListAgentVersions lists the versions of an agent
+	// Parameters:
+	//   - ctx: Context information
+	//   - agentID: Agent ID
+	//   - tenantID: Tenant ID for isolation
+	// Returns:
+	//   - List of versions, newest first
+	//   - Possible errors such as database errors, etc.
+	ListAgentVersions(ctx context.Context, agentID string, tenantID uint64) ([]*types.CustomAgentVersion, error)
+
+	// GetAgentVersion gets a version of an agent
+	// Parameters:
+	//   - ctx: Context information
+	//   - agentID: Agent ID
+	//   - tenantID: Tenant ID for isolation
+	//   - version: Version number
+	// Returns:
+	//   - Version object, if found
+	//   - Possible errors such as record not existing, database errors, etc.
+	GetAgentVersion(ctx context.Context, agentID string, tenantID uint64, version int) (*types.CustomAgentVersion, error)
 }
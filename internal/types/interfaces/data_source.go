@@ -0,0 +1,37 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// DataSourceService manages the external data sources of the current tenant and runs read-only queries on them
+type DataSourceService interface {
+	// CreateDataSource creates a data source
+	CreateDataSource(ctx context.Context, dataSource *types.DataSource) error
+	// GetDataSource gets a data source of the current tenant
+	GetDataSource(ctx context.Context, id string) (*types.DataSource, error)
+	// ListDataSources lists the data sources of the current tenant
+	ListDataSources(ctx context.Context) ([]*types.DataSource, error)
+	// ListDataSourcesByIDs lists the data sources of the current tenant with the given IDs
+	ListDataSourcesByIDs(ctx context.Context, ids []string) ([]*types.DataSource, error)
+	// UpdateDataSource updates a data source, an empty password keeps the current one
+	UpdateDataSource(ctx context.Context, dataSource *types.DataSource) (*types.DataSource, error)
+	// DeleteDataSource deletes a data source of the current tenant
+	DeleteDataSource(ctx context.Context, id string) error
+	// DescribeDataSource connects to a data source and describes the columns of its allowed tables
+	DescribeDataSource(ctx context.Context, id string) ([]*types.DataSourceTable, error)
+	// QueryDataSource validates and runs a read-only SELECT on a data source
+	QueryDataSource(ctx context.Context, id string, sql string) (*types.DataSourceQueryResult, error)
+}
+
+// DataSourceRepository stores external data sources
+type DataSourceRepository interface {
+	CreateDataSource(ctx context.Context, dataSource *types.DataSource) error
+	GetDataSource(ctx context.Context, tenantID uint64, id string) (*types.DataSource, error)
+	ListDataSources(ctx context.Context, tenantID uint64) ([]*types.DataSource, error)
+	ListDataSourcesByIDs(ctx context.Context, tenantID uint64, ids []string) ([]*types.DataSource, error)
+	UpdateDataSource(ctx context.Context, dataSource *types.DataSource) error
+	DeleteDataSource(ctx context.Context, tenantID uint64, id string) error
+}
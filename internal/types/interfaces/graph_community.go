@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// GraphCommunityService resolves duplicate entities and summarizes the communities of knowledge graphs
+// for global search
+type GraphCommunityService interface {
+	// RebuildCommunities schedules the rebuild of the communities of a knowledge base of the current tenant,
+	// merging duplicate entities first if resolveEntities is set
+	RebuildCommunities(ctx context.Context, kbID string, resolveEntities bool) (*types.GraphCommunityRebuild, error)
+	// ListCommunities lists the communities of a knowledge base of the current tenant, largest first
+	ListCommunities(ctx context.Context, kbID string) ([]*types.GraphCommunity, error)
+	// SearchCommunities returns the topK communities of the knowledge bases whose summaries are most
+	// relevant to the query. Callers are responsible for checking access to the knowledge bases.
+	SearchCommunities(ctx context.Context, kbIDs []string, query string, topK int) ([]*types.GraphCommunity, error)
+	// ProcessRebuild handles graph community tasks
+	ProcessRebuild(ctx context.Context, t *asynq.Task) error
+}
+
+// GraphCommunityRepository stores graph communities
+type GraphCommunityRepository interface {
+	// ReplaceCommunities replaces the communities of a knowledge base
+	ReplaceCommunities(ctx context.Context, kbID string, communities []*types.GraphCommunity) error
+	// ListCommunities lists the communities of the knowledge bases, largest first
+	ListCommunities(ctx context.Context, kbIDs []string) ([]*types.GraphCommunity, error)
+	// DeleteCommunities deletes the communities of a knowledge base
+	DeleteCommunities(ctx context.Context, kbID string) error
+}
@@ -32,6 +32,11 @@ type KnowledgeService interface {
 	CreateKnowledgeFromPassage(ctx context.Context, kbID string, passage []string) (*types.Knowledge, error)
 	// CreateKnowledgeFromPassageSync creates knowledge from text passages and waits until chunks are indexed.
 	CreateKnowledgeFromPassageSync(ctx context.Context, kbID string, passage []string) (*types.Knowledge, error)
+	// CreateKnowledgeFromPassageSyncWithID is CreateKnowledgeFromPassageSync with a knowledge ID chosen by the caller,
+	// so that a retried import can find the knowledge it created before.
+	CreateKnowledgeFromPassageSyncWithID(
+		ctx context.Context, kbID, knowledgeID string, passage []string,
+	) (*types.Knowledge, error)
 	// CreateKnowledgeFromManual creates or saves manual Markdown knowledge content.
 	CreateKnowledgeFromManual(
 		ctx context.Context,
@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KnowledgeGraphService browses, queries and corrects the knowledge graphs of the knowledge bases of the current tenant
+type KnowledgeGraphService interface {
+	// ListEntities lists the entities of a knowledge base whose name contains the keyword, ordered by name
+	ListEntities(ctx context.Context, kbID, keyword string, page *types.Pagination) (*types.PageResult, error)
+	// GetEntity gets an entity of a knowledge base by name
+	GetEntity(ctx context.Context, kbID, name string) (*types.GraphEntity, error)
+	// GetNeighbourhood returns the entities within depth hops of an entity and the relations between them
+	GetNeighbourhood(ctx context.Context, kbID, name string, depth int) (*types.GraphData, error)
+	// FindPath finds a shortest path of at most maxDepth hops between two entities
+	FindPath(ctx context.Context, kbID, from, to string, maxDepth int) (*types.GraphPath, error)
+	// ListEntityChunks lists the chunks an entity was extracted from
+	ListEntityChunks(ctx context.Context, kbID, name string) ([]*types.Chunk, error)
+	// ListRelationChunks lists the chunks the relations between two entities were extracted from,
+	// only the relations of the given type if it is not empty
+	ListRelationChunks(ctx context.Context, kbID, source, target, relationType string) ([]*types.Chunk, error)
+	// UpdateEntity corrects the name or attributes of an entity
+	UpdateEntity(ctx context.Context, kbID, name string, update *types.GraphEntityUpdate) (*types.GraphEntity, error)
+	// MergeEntities merges entities into the target entity, which is created if it does not exist
+	MergeEntities(ctx context.Context, kbID string, sources []string, target string) (*types.GraphEntity, error)
+}
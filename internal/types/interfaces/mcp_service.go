@@ -58,4 +58,7 @@ type MCPServiceService interface {
 
 	// GetMCPServiceResources retrieves the list of resources from an MCP service
 	GetMCPServiceResources(ctx context.Context, tenantID uint64, id string) ([]*types.MCPResource, error)
+
+	// GetMCPServicePrompts retrieves the list of prompts from an MCP service
+	GetMCPServicePrompts(ctx context.Context, tenantID uint64, id string) ([]*types.MCPPrompt, error)
 }
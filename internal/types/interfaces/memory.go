@@ -0,0 +1,40 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// UserMemoryService manages the long-term memories agents keep about the current user
+type UserMemoryService interface {
+	// ListMemories lists the memories of the current user, most recently updated first
+	ListMemories(ctx context.Context) ([]*types.UserMemory, error)
+	// CreateMemory adds a memory for the current user
+	CreateMemory(ctx context.Context, content string) (*types.UserMemory, error)
+	// UpdateMemory changes the content of a memory of the current user
+	UpdateMemory(ctx context.Context, id string, content string) (*types.UserMemory, error)
+	// DeleteMemory deletes a memory of the current user
+	DeleteMemory(ctx context.Context, id string) error
+	// ClearMemories deletes all memories of the current user
+	ClearMemories(ctx context.Context) error
+	// RecallMemories returns the memories of a user most relevant to a query
+	RecallMemories(ctx context.Context, userID string, query string, embeddingModelID string) ([]*types.UserMemory, error)
+	// ScheduleExtraction extracts memories from a session once it has been idle for a while
+	ScheduleExtraction(ctx context.Context, payload *types.MemoryExtractPayload)
+	// ProcessExtraction handles memory extraction tasks
+	ProcessExtraction(ctx context.Context, t *asynq.Task) error
+}
+
+// UserMemoryRepository stores user memories
+type UserMemoryRepository interface {
+	CreateMemory(ctx context.Context, memory *types.UserMemory) error
+	GetMemory(ctx context.Context, tenantID uint64, userID string, id string) (*types.UserMemory, error)
+	ListMemories(ctx context.Context, tenantID uint64, userID string) ([]*types.UserMemory, error)
+	UpdateMemory(ctx context.Context, memory *types.UserMemory) error
+	// UpdateEmbedding saves a regenerated embedding without touching the update time
+	UpdateEmbedding(ctx context.Context, memory *types.UserMemory) error
+	DeleteMemories(ctx context.Context, tenantID uint64, userID string, ids []string) error
+	DeleteAllMemories(ctx context.Context, tenantID uint64, userID string) error
+}
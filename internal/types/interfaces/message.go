@@ -9,7 +9,8 @@ import (
 
 // MessageService defines the message service interface
 type MessageService interface {
-	// CreateMessage creates a message
+	// CreateMessage creates a message as a child of message.ParentID
+	// and makes it the last message of the session's active branch
 	CreateMessage(ctx context.Context, message *types.Message) (*types.Message, error)
 
 	// GetMessage gets a message
@@ -18,10 +19,10 @@ type MessageService interface {
 	// GetMessagesBySession gets all messages of a session
 	GetMessagesBySession(ctx context.Context, sessionID string, page int, pageSize int) ([]*types.Message, error)
 
-	// GetRecentMessagesBySession gets recent messages of a session
+	// GetRecentMessagesBySession gets recent messages on the active branch of a session
 	GetRecentMessagesBySession(ctx context.Context, sessionID string, limit int) ([]*types.Message, error)
 
-	// GetMessagesBySessionBeforeTime gets messages before a specific time of a session
+	// GetMessagesBySessionBeforeTime gets messages on the active branch of a session before a specific time
 	GetMessagesBySessionBeforeTime(
 		ctx context.Context, sessionID string, beforeTime time.Time, limit int,
 	) ([]*types.Message, error)
@@ -29,8 +30,16 @@ type MessageService interface {
 	// UpdateMessage updates a message
 	UpdateMessage(ctx context.Context, message *types.Message) error
 
-	// DeleteMessage deletes a message
+	// DeleteMessage deletes a message, its children are attached to its parent
 	DeleteMessage(ctx context.Context, sessionID string, id string) error
+
+	// GetChildMessageIDs gets the IDs of the children of each parent message in creation order,
+	// children of the first message level are keyed by an empty parent ID
+	GetChildMessageIDs(ctx context.Context, sessionID string, parentIDs []string) (map[string][]string, error)
+
+	// ActivateMessage switches the active branch of a session to the most recent path through a message
+	// and returns the last message of that path
+	ActivateMessage(ctx context.Context, sessionID string, id string) (*types.Message, error)
 }
 
 // MessageRepository defines the message repository interface
@@ -38,4 +47,16 @@ type MessageRepository interface {
 	MessageService
 	// GetFirstMessageOfUser gets the first message of a user
 	GetFirstMessageOfUser(ctx context.Context, sessionID string) (*types.Message, error)
+	// SearchMessages finds the messages of a tenant's sessions matching the search terms,
+	// messages containing every term first, then the most recent
+	SearchMessages(ctx context.Context, tenantID uint64, params *types.MessageSearchParams,
+		page *types.Pagination) ([]*types.MessageSearchHit, int64, error)
+	// GetActiveBranchMessageIDs gets the IDs of the messages on the active branch of a session
+	GetActiveBranchMessageIDs(ctx context.Context, sessionID string) ([]string, error)
+}
+
+// MessageSearchService searches the chat history of the current tenant
+type MessageSearchService interface {
+	// SearchMessages searches message content and session titles, returning highlighted snippets
+	SearchMessages(ctx context.Context, params *types.MessageSearchParams, page *types.Pagination) (*types.PageResult, error)
 }
@@ -14,4 +14,24 @@ type RetrieveGraphRepository interface {
 	DelGraph(ctx context.Context, namespace []types.NameSpace) error
 	// SearchNode searches for nodes in the repository
 	SearchNode(ctx context.Context, namespace types.NameSpace, nodes []string) (*types.GraphData, error)
+
+	// The methods below work on the graph of a whole knowledge base, where an entity groups
+	// the nodes of the same name extracted from all its documents.
+	// They return types.ErrGraphStoreDisabled when no graph store is configured.
+
+	// ListEntities lists the entities whose name contains the keyword (case-insensitive, all if empty),
+	// ordered by name, and the total number of matching entities
+	ListEntities(ctx context.Context, knowledgeBaseID, keyword string,
+		offset, limit int) ([]*types.GraphEntity, int64, error)
+	// GetEntity gets an entity by name, nil if it does not exist
+	GetEntity(ctx context.Context, knowledgeBaseID, name string) (*types.GraphEntity, error)
+	// ExpandEntities returns the entities within depth hops of the named entities and the relations between them,
+	// stopping at limit relations. The relations of the same endpoints and type are merged.
+	ExpandEntities(ctx context.Context, knowledgeBaseID string, names []string,
+		depth, limit int) (*types.GraphData, error)
+	// UpdateEntityAttributes replaces the attributes of an entity
+	UpdateEntityAttributes(ctx context.Context, knowledgeBaseID, name string, attributes []string) error
+	// MergeEntities merges the source entities into the target entity, which is created if it does not exist.
+	// Chunks, attributes and relations are combined; relations between merged entities are removed.
+	MergeEntities(ctx context.Context, knowledgeBaseID string, sources []string, target string) error
 }
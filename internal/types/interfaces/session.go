@@ -29,6 +29,7 @@ type SessionService interface {
 	// modelID: optional model ID to use for title generation (if empty, uses first available KnowledgeQA model)
 	GenerateTitleAsync(ctx context.Context, session *types.Session, userQuery string, modelID string, eventBus *event.EventBus)
 	// KnowledgeQA performs knowledge-based question answering
+	// images: optional image attachments, captions generated by the KB's VLM are written back in place
 	// knowledgeBaseIDs: list of knowledge base IDs to search (supports multi-KB)
 	// knowledgeIDs: list of specific knowledge (file) IDs to search
 	// summaryModelID: optional summary model ID override (if empty, uses session/KB default)
@@ -36,7 +37,7 @@ type SessionService interface {
 	// customAgent: optional custom agent for config override (multiTurnEnabled, historyTurns)
 	// Events are emitted through eventBus (references, answer chunks, completion)
 	KnowledgeQA(ctx context.Context,
-		session *types.Session, query string, knowledgeBaseIDs []string, knowledgeIDs []string,
+		session *types.Session, query string, images types.MessageImages, knowledgeBaseIDs []string, knowledgeIDs []string,
 		assistantMessageID string, summaryModelID string, webSearchEnabled bool, eventBus *event.EventBus,
 		customAgent *types.CustomAgent,
 	) error
@@ -50,10 +51,12 @@ type SessionService interface {
 	// eventBus is optional - if nil, uses service's default EventBus
 	// customAgent is optional - if provided, uses custom agent configuration instead of tenant defaults
 	// summaryModelID is optional - if provided, overrides the model from customAgent config
+	// images is optional - image attachments of the query, captions are written back in place
 	AgentQA(
 		ctx context.Context,
 		session *types.Session,
 		query string,
+		images types.MessageImages,
 		assistantMessageID string,
 		summaryModelID string,
 		eventBus *event.EventBus,
@@ -63,6 +66,8 @@ type SessionService interface {
 	) error
 	// ClearContext clears the LLM context for a session
 	ClearContext(ctx context.Context, sessionID string) error
+	// RebuildContext rebuilds the LLM context for a session from the messages on its active branch
+	RebuildContext(ctx context.Context, sessionID string) error
 }
 
 // SessionRepository defines the session repository interface
@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// SessionShareService exports conversations and manages their read-only share links
+type SessionShareService interface {
+	// ExportSession renders the active branch of a session of the current tenant.
+	// It returns the rendered file, its content type and a suggested file name.
+	ExportSession(ctx context.Context, sessionID string, format types.SessionExportFormat,
+		includeChunkContent bool) ([]byte, string, string, error)
+	// CreateShare snapshots a session and creates a share link expiring after the given number of days
+	CreateShare(ctx context.Context, sessionID string, includeChunkContent bool, expiresInDays int) (*types.SessionShare, error)
+	// ListShares lists the active share links of a session
+	ListShares(ctx context.Context, sessionID string) ([]*types.SessionShare, error)
+	// RevokeShare revokes a share link of a session
+	RevokeShare(ctx context.Context, sessionID string, shareID string) error
+	// GetSharedTranscript returns the snapshot behind a share link, without requiring login
+	GetSharedTranscript(ctx context.Context, token string) (*types.SessionTranscript, error)
+	// RenderTranscript renders a transcript in an export format
+	RenderTranscript(transcript *types.SessionTranscript, format types.SessionExportFormat) ([]byte, string, error)
+}
+
+// SessionShareRepository stores session share links
+type SessionShareRepository interface {
+	CreateShare(ctx context.Context, share *types.SessionShare) error
+	GetShareByToken(ctx context.Context, token string) (*types.SessionShare, error)
+	ListShares(ctx context.Context, tenantID uint64, sessionID string) ([]*types.SessionShare, error)
+	DeleteShare(ctx context.Context, tenantID uint64, sessionID string, id string) (bool, error)
+}
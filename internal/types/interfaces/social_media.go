@@ -0,0 +1,31 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// SocialMediaExtractor defines the interface for social media content extractors
+type SocialMediaExtractor interface {
+	// Name returns the ID of the extractor
+	Name() string
+	// Supports reports whether the extractor can handle the given platform
+	Supports(platform string) bool
+	// Extract extracts the transcript/text content of a video or post
+	Extract(ctx context.Context, req *types.SocialMediaExtractRequest) (*types.SocialMediaContent, error)
+}
+
+// SocialMediaService defines the interface for social media content import
+type SocialMediaService interface {
+	// SubmitExtraction enqueues an extraction task for the given URLs and returns its initial progress
+	SubmitExtraction(ctx context.Context, kbID string, provider string,
+		items []types.SocialMediaExtractItem) (*types.SocialMediaExtractProgress, error)
+	// GetExtractionProgress retrieves the progress of an extraction task
+	GetExtractionProgress(ctx context.Context, taskID string) (*types.SocialMediaExtractProgress, error)
+	// ListExtractors lists the configured extractors
+	ListExtractors(ctx context.Context) []types.SocialMediaExtractorInfo
+	// ProcessSocialMediaExtract handles Asynq social media extraction tasks
+	ProcessSocialMediaExtract(ctx context.Context, t *asynq.Task) error
+}
@@ -0,0 +1,49 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// TaskAdminService manages the asynchronous tasks of the current tenant
+type TaskAdminService interface {
+	// ListTasks lists the tasks of the current tenant matching the filter
+	ListTasks(ctx context.Context, filter *types.TaskListFilter, page *types.Pagination) (*types.PageResult, error)
+	// GetTask gets a task of the current tenant
+	GetTask(ctx context.Context, queue, taskID string) (*types.TaskInfo, error)
+	// RetryTask runs a failed, scheduled or archived task immediately
+	RetryTask(ctx context.Context, queue, taskID string) error
+	// CancelTask cancels a running task or deletes a task waiting in the queue
+	CancelTask(ctx context.Context, queue, taskID string) error
+	// RetryFailedDocuments re-runs the failed document parsing tasks of a knowledge base
+	RetryFailedDocuments(ctx context.Context, kbID string) (*types.TaskBulkRetryResult, error)
+	// ListQueues lists the task queues and their statistics
+	ListQueues(ctx context.Context) ([]*types.TaskQueueInfo, error)
+	// PauseQueue pauses the processing of a queue
+	PauseQueue(ctx context.Context, queue string) error
+	// ResumeQueue resumes the processing of a paused queue
+	ResumeQueue(ctx context.Context, queue string) error
+}
+
+// TenantLimiter enforces per-tenant task concurrency and model call rate limits
+type TenantLimiter interface {
+	// Limits returns the limits in effect for a tenant
+	Limits(ctx context.Context, tenantID uint64) *types.EffectiveRateLimits
+	// Usage returns the running tasks and the model calls of the current minute of a tenant
+	Usage(ctx context.Context, tenantID uint64) (*types.TenantRateLimitUsage, error)
+	// InvalidateLimits drops the cached limits of a tenant after they were updated
+	InvalidateLimits(tenantID uint64)
+	// AcquireTaskSlot reserves a concurrency slot for a task until expiresAt,
+	// returning false when the tenant has reached its concurrency limit
+	AcquireTaskSlot(ctx context.Context, tenantID uint64, taskID string, expiresAt time.Time) (bool, error)
+	// ReleaseTaskSlot releases the concurrency slot held by a task
+	ReleaseTaskSlot(ctx context.Context, tenantID uint64, taskID string)
+	// Wait blocks until the tenant may make n calls of the given kind and records them
+	Wait(ctx context.Context, tenantID uint64, kind types.RateLimitKind, n int) error
+	// Remaining returns the calls of the given kind left in the current minute, -1 if unlimited
+	Remaining(ctx context.Context, tenantID uint64, kind types.RateLimitKind) int
+	// Record counts n calls of the given kind that were made outside of Wait
+	Record(ctx context.Context, tenantID uint64, kind types.RateLimitKind, n int)
+}
@@ -0,0 +1,34 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ToolApprovalService pauses agent tool calls until the user approves or rejects them
+type ToolApprovalService interface {
+	// RequestApproval persists a pending approval of a tool call, expiring after timeout
+	RequestApproval(ctx context.Context, approval *types.ToolApproval, timeout time.Duration) error
+	// WaitForDecision blocks until the approval is decided or expires, or ctx is done;
+	// an approval left undecided is marked expired before returning
+	WaitForDecision(ctx context.Context, tenantID uint64, id string) (*types.ToolApproval, error)
+	// ListApprovals lists the approvals of a session of the current tenant, optionally only those with a status
+	ListApprovals(ctx context.Context, sessionID string, status types.ToolApprovalStatus) ([]*types.ToolApproval, error)
+	// DecideApproval approves or rejects a pending approval of a session of the current tenant
+	DecideApproval(
+		ctx context.Context, sessionID string, id string, approved bool, reason string,
+	) (*types.ToolApproval, error)
+}
+
+// ToolApprovalRepository stores tool approvals
+type ToolApprovalRepository interface {
+	CreateApproval(ctx context.Context, approval *types.ToolApproval) error
+	GetApproval(ctx context.Context, tenantID uint64, id string) (*types.ToolApproval, error)
+	ListApprovals(
+		ctx context.Context, tenantID uint64, sessionID string, status types.ToolApprovalStatus,
+	) ([]*types.ToolApproval, error)
+	// ResolveApproval sets the decision of an approval that is still pending, returning whether it was pending
+	ResolveApproval(ctx context.Context, approval *types.ToolApproval) (bool, error)
+}
@@ -0,0 +1,46 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// WebhookService manages the webhook subscriptions of the current tenant and delivers events to them
+type WebhookService interface {
+	// CreateSubscription creates a subscription and generates its signing secret
+	CreateSubscription(ctx context.Context, subscription *types.WebhookSubscription) error
+	// GetSubscription gets a subscription of the current tenant
+	GetSubscription(ctx context.Context, id string) (*types.WebhookSubscription, error)
+	// ListSubscriptions lists the subscriptions of the current tenant
+	ListSubscriptions(ctx context.Context) ([]*types.WebhookSubscription, error)
+	// UpdateSubscription updates the name, URL, events and enabled state of a subscription
+	UpdateSubscription(ctx context.Context, subscription *types.WebhookSubscription) (*types.WebhookSubscription, error)
+	// DeleteSubscription deletes a subscription of the current tenant
+	DeleteSubscription(ctx context.Context, id string) error
+	// ListDeliveries lists the delivery log of a subscription, newest first
+	ListDeliveries(ctx context.Context, subscriptionID string, page *types.Pagination) (*types.PageResult, error)
+	// TestSubscription sends a webhook.test event to a subscription synchronously
+	TestSubscription(ctx context.Context, id string) (*types.WebhookDelivery, error)
+	// Publish delivers an event of a tenant to its subscriptions in the background
+	Publish(ctx context.Context, tenantID uint64, eventType types.WebhookEventType, data interface{})
+	// ProcessDelivery handles webhook delivery tasks
+	ProcessDelivery(ctx context.Context, t *asynq.Task) error
+}
+
+// WebhookRepository stores webhook subscriptions and deliveries
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *types.WebhookSubscription) error
+	GetSubscription(ctx context.Context, tenantID uint64, id string) (*types.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, tenantID uint64) ([]*types.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription *types.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, tenantID uint64, id string) error
+
+	CreateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error
+	GetDelivery(ctx context.Context, tenantID uint64, id string) (*types.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error
+	ListDeliveries(
+		ctx context.Context, tenantID uint64, subscriptionID string, page *types.Pagination,
+	) ([]*types.WebhookDelivery, int64, error)
+}
@@ -0,0 +1,99 @@
+package types
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ErrGraphStoreDisabled is returned by graph repositories when no graph store is configured
+var ErrGraphStoreDisabled = errors.New("knowledge graph storage is not enabled")
+
+// Graph stores selectable by GRAPH_DRIVER
+const (
+	GraphDriverNeo4j    = "neo4j"
+	GraphDriverPostgres = "postgres"
+)
+
+// GetGraphDriver returns the graph store selected by GRAPH_DRIVER, empty when knowledge graphs are disabled.
+// NEO4J_ENABLE=true selects Neo4j when GRAPH_DRIVER is not set.
+func GetGraphDriver() string {
+	if driver := strings.ToLower(strings.TrimSpace(os.Getenv("GRAPH_DRIVER"))); driver != "" {
+		return driver
+	}
+	if strings.ToLower(os.Getenv("NEO4J_ENABLE")) == "true" {
+		return GraphDriverNeo4j
+	}
+	return ""
+}
+
+// IsGraphEnabled reports whether a graph store is configured for entity and relation extraction
+func IsGraphEnabled() bool {
+	return GetGraphDriver() != ""
+}
+
+// GraphEntity is an entity of the knowledge graph of a knowledge base.
+// Entities are extracted per document; an entity groups the nodes of the same name in all documents.
+type GraphEntity struct {
+	Name       string   `json:"name"`
+	Attributes []string `json:"attributes"`
+	// Chunks the entity was extracted from
+	Chunks []string `json:"chunks"`
+	// Documents the entity was extracted from
+	KnowledgeIDs []string `json:"knowledge_ids"`
+	// Number of relations of the entity
+	Degree int `json:"degree"`
+}
+
+// GraphPath is a path between two entities, Relation[i] connects Node[i] and Node[i+1]
+type GraphPath struct {
+	Node     []string         `json:"node"`
+	Relation []*GraphRelation `json:"relation"`
+}
+
+// Length returns the number of hops of the path
+func (p *GraphPath) Length() int {
+	return len(p.Relation)
+}
+
+// GraphEntityUpdate corrects an entity, nil fields are left unchanged
+type GraphEntityUpdate struct {
+	// New name, the entity is merged into the entity of that name if it exists
+	Name *string `json:"name"`
+	// Replaces the attributes of the entity
+	Attributes *[]string `json:"attributes"`
+}
+
+// MergeGraphNode adds the chunks and attributes of a node of the same name to another
+func MergeGraphNode(into, node *GraphNode) {
+	into.Chunks = appendUnique(into.Chunks, node.Chunks...)
+	into.Attributes = appendUnique(into.Attributes, node.Attributes...)
+}
+
+// MergeGraphRelations merges relations of the same endpoints and type, combining their chunks
+func MergeGraphRelations(relations []*GraphRelation) []*GraphRelation {
+	merged := make([]*GraphRelation, 0, len(relations))
+	index := make(map[[3]string]*GraphRelation, len(relations))
+	for _, rel := range relations {
+		key := [3]string{rel.Node1, rel.Node2, rel.Type}
+		if existing, ok := index[key]; ok {
+			existing.Chunks = appendUnique(existing.Chunks, rel.Chunks...)
+			continue
+		}
+		copied := *rel
+		copied.Chunks = appendUnique(nil, rel.Chunks...)
+		index[key] = &copied
+		merged = append(merged, &copied)
+	}
+	return merged
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		if !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -78,12 +78,34 @@ type MCPResource struct {
 	MimeType    string `json:"mimeType,omitempty"`
 }
 
+// MCPPrompt represents a prompt (or prompt template) exposed by an MCP service
+type MCPPrompt struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description,omitempty"`
+	Arguments   []*MCPPromptArgument `json:"arguments,omitempty"`
+}
+
+// MCPPromptArgument represents an argument of an MCP prompt template
+type MCPPromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// MCPPromptBinding binds an MCP prompt as the system prompt template of an agent
+type MCPPromptBinding struct {
+	ServiceID  string            `yaml:"service_id" json:"service_id"`   // MCP service providing the prompt
+	PromptName string            `yaml:"prompt_name" json:"prompt_name"` // Prompt name on the MCP service
+	Arguments  map[string]string `yaml:"arguments" json:"arguments"`     // Arguments used to render the prompt template
+}
+
 // MCPTestResult represents the result of testing an MCP service connection
 type MCPTestResult struct {
 	Success   bool           `json:"success"`
 	Message   string         `json:"message,omitempty"`
 	Tools     []*MCPTool     `json:"tools,omitempty"`
 	Resources []*MCPResource `json:"resources,omitempty"`
+	Prompts   []*MCPPrompt   `json:"prompts,omitempty"`
 }
 
 // BeforeCreate is a GORM hook that runs before creating a new MCP service
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TypeMemoryExtract is the asynq task type extracting long-term memories from a finished conversation
+const TypeMemoryExtract = "memory:extract"
+
+// UserMemory is a durable fact about a user, remembered by agents across sessions
+type UserMemory struct {
+	ID       string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID uint64 `json:"tenant_id"         gorm:"index:idx_user_memories_owner"`
+	UserID   string `json:"user_id"           gorm:"type:varchar(36);index:idx_user_memories_owner"`
+	Content  string `json:"content"           gorm:"type:text"`
+	// 提取来源会话，用户手动添加的记忆为空
+	SourceSessionID string `json:"source_session_id" gorm:"type:varchar(36)"`
+	// 召回用的向量及生成向量的模型，模型变化或内容修改后重新生成
+	Embedding        MemoryEmbedding `json:"-"                 gorm:"type:jsonb"`
+	EmbeddingModelID string          `json:"-"                 gorm:"type:varchar(64)"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt  `json:"-"                 gorm:"index"`
+}
+
+// TableName returns the table name of user memories
+func (UserMemory) TableName() string {
+	return "user_memories"
+}
+
+// BeforeCreate generates the memory ID
+func (m *UserMemory) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// MemoryEmbedding is the embedding of a memory, stored as a JSON array
+type MemoryEmbedding []float32
+
+// Value implements the driver.Valuer interface
+func (e MemoryEmbedding) Value() (driver.Value, error) {
+	if e == nil {
+		return nil, nil
+	}
+	return json.Marshal(e)
+}
+
+// Scan implements the sql.Scanner interface
+func (e *MemoryEmbedding) Scan(value interface{}) error {
+	if value == nil {
+		*e = nil
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, e)
+}
+
+// MemoryExtractPayload is the payload of memory extraction tasks
+type MemoryExtractPayload struct {
+	TenantID  uint64 `json:"tenant_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"`
+	// 入队时会话的最后一条消息，会话之后又有新消息时跳过，由更晚的任务提取
+	MessageID string `json:"message_id"`
+	// 提取记忆所用的对话模型及召回所用的向量模型
+	ModelID          string `json:"model_id"`
+	EmbeddingModelID string `json:"embedding_model_id"`
+}
@@ -53,6 +53,40 @@ func (m *MentionedItems) Scan(value interface{}) error {
 	return json.Unmarshal(b, m)
 }
 
+// MessageImage represents an image attached to a user message
+// The file itself is stored through FileService, only the storage path is persisted
+type MessageImage struct {
+	FilePath string `json:"file_path"`         // Storage path returned by FileService
+	FileName string `json:"file_name"`         // Original file name
+	MimeType string `json:"mime_type"`         // MIME type, e.g. image/png
+	Caption  string `json:"caption,omitempty"` // OCR/caption text generated by the VLM (optional)
+}
+
+// MessageImages is a slice of MessageImage for database storage
+type MessageImages []MessageImage
+
+// Value implements the driver.Valuer interface for database serialization
+func (m MessageImages) Value() (driver.Value, error) {
+	if m == nil {
+		return json.Marshal([]MessageImage{})
+	}
+	return json.Marshal(m)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (m *MessageImages) Scan(value interface{}) error {
+	if value == nil {
+		*m = make(MessageImages, 0)
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		*m = make(MessageImages, 0)
+		return nil
+	}
+	return json.Unmarshal(b, m)
+}
+
 // Message represents a conversation message
 // Each message belongs to a conversation session and can be from either user or system
 // Messages can contain references to knowledge chunks used to generate responses
@@ -61,12 +95,20 @@ type Message struct {
 	ID string `json:"id"                    gorm:"type:varchar(36);primaryKey"`
 	// ID of the session this message belongs to
 	SessionID string `json:"session_id"`
+	// ID of the previous message in the conversation, empty for the first message
+	// Messages of a session form a tree: editing a question or regenerating an answer
+	// adds a sibling instead of overwriting the original message
+	ParentID string `json:"parent_id"             gorm:"type:varchar(36);index"`
 	// Request identifier for tracking API requests
 	RequestID string `json:"request_id"`
 	// Message text content
 	Content string `json:"content"`
 	// Message role: "user", "assistant", "system"
 	Role string `json:"role"`
+	// ID of the custom agent that handled the question, empty if none was selected
+	AgentID string `json:"agent_id,omitempty"    gorm:"type:varchar(36);index"`
+	// Version of the custom agent that answered, 0 for a built-in agent with its default settings
+	AgentVersion int `json:"agent_version,omitempty"`
 	// References to knowledge chunks used in the response
 	KnowledgeReferences References `json:"knowledge_references"  gorm:"type:json,column:knowledge_references"`
 	// Agent execution steps (only for assistant messages generated by agent)
@@ -76,16 +118,33 @@ type Message struct {
 	// Mentioned knowledge bases and files (for user messages)
 	// Stores the @mentioned items when user sends a message
 	MentionedItems MentionedItems `json:"mentioned_items,omitempty" gorm:"type:jsonb,column:mentioned_items"`
+	// Image attachments (for user messages)
+	Images MessageImages `json:"images,omitempty" gorm:"type:jsonb,column:images"`
 	// Whether message generation is complete
 	IsCompleted bool `json:"is_completed"`
+	// User rating of an assistant message, from 1 (worst) to 5 (best)
+	Rating *int `json:"rating,omitempty"`
+	// Optional comment left with the rating
+	RatingComment string `json:"rating_comment,omitempty"`
 	// Message creation timestamp
 	CreatedAt time.Time `json:"created_at"`
 	// Last update timestamp
 	UpdatedAt time.Time `json:"updated_at"`
 	// Soft delete timestamp
 	DeletedAt gorm.DeletedAt `json:"deleted_at"            gorm:"index"`
+	// IDs of all messages sharing this message's parent (including itself) in creation order,
+	// only filled when the message has alternative versions; not stored in the database
+	SiblingIDs []string `json:"sibling_ids,omitempty" gorm:"-"`
 }
 
+// Message ratings range from MinMessageRating to MaxMessageRating; ratings at or
+// below LowMessageRating trigger the message.low_rated webhook event
+const (
+	MinMessageRating = 1
+	MaxMessageRating = 5
+	LowMessageRating = 2
+)
+
 // AgentSteps represents a collection of agent execution steps
 // Used for storing agent reasoning process in database
 type AgentSteps []AgentStep
@@ -130,5 +189,8 @@ func (m *Message) BeforeCreate(tx *gorm.DB) (err error) {
 	if m.MentionedItems == nil {
 		m.MentionedItems = make(MentionedItems, 0)
 	}
+	if m.Images == nil {
+		m.Images = make(MessageImages, 0)
+	}
 	return nil
 }
@@ -0,0 +1,41 @@
+package types
+
+import "time"
+
+// MessageSearchParams are the filters of a chat history search
+type MessageSearchParams struct {
+	// Query is split on whitespace, every term must appear in the message or the title of its session
+	Query string `form:"query"             json:"query"`
+	// Role limits results to "user" or "assistant" messages
+	Role string `form:"role"              json:"role"`
+	// AgentID limits results to questions handled by a custom agent
+	AgentID string `form:"agent_id"          json:"agent_id"`
+	// KnowledgeBaseID limits results to turns that cited or @mentioned a knowledge base
+	KnowledgeBaseID string     `form:"knowledge_base_id" json:"knowledge_base_id"`
+	StartTime       *time.Time `form:"start_time"        json:"start_time"        time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime         *time.Time `form:"end_time"          json:"end_time"          time_format:"2006-01-02T15:04:05Z07:00"`
+	// Terms are the search terms parsed from Query, filled by the search service
+	Terms []string `form:"-"                 json:"-"`
+}
+
+// MessageSearchHit is a message matching a chat history search
+type MessageSearchHit struct {
+	SessionID    string    `json:"session_id"`
+	SessionTitle string    `json:"session_title"`
+	MessageID    string    `json:"message_id"`
+	RequestID    string    `json:"request_id"`
+	Role         string    `json:"role"`
+	AgentID      string    `json:"agent_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	// Content is the full message content, only used to build the snippet
+	Content string `json:"-"`
+	// Snippet is an HTML-escaped excerpt of the message with the matched terms wrapped in <mark>
+	Snippet string `json:"snippet"`
+	// TitleSnippet is the HTML-escaped session title with the matched terms wrapped in <mark>
+	TitleSnippet string `json:"title_snippet"`
+	// OnActiveBranch reports whether the message is on the session's active branch;
+	// messages of other branches are shown after switching to them
+	OnActiveBranch bool `json:"on_active_branch"`
+	// LoadBeforeTime is the before_time for /messages/:session_id/load that ends the page at this message
+	LoadBeforeTime string `json:"load_before_time"`
+}
@@ -81,6 +81,8 @@ type Session struct {
 	Description string `json:"description"`
 	// Tenant ID
 	TenantID uint64 `json:"tenant_id"   gorm:"index"`
+	// ID of the last message on the active branch, maintained by the message repository
+	ActiveMessageID string `json:"active_message_id" gorm:"type:varchar(36);->"`
 
 	// // Strategy configuration
 	// KnowledgeBaseID   string              `json:"knowledge_base_id"`                    // 关联的知识库ID
@@ -0,0 +1,121 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionExportFormat is the file format a conversation is exported as
+type SessionExportFormat string
+
+const (
+	SessionExportMarkdown SessionExportFormat = "markdown"
+	SessionExportHTML     SessionExportFormat = "html"
+	SessionExportJSON     SessionExportFormat = "json"
+)
+
+// IsValidSessionExportFormat reports whether a conversation can be exported in the format
+func IsValidSessionExportFormat(format SessionExportFormat) bool {
+	switch format {
+	case SessionExportMarkdown, SessionExportHTML, SessionExportJSON:
+		return true
+	default:
+		return false
+	}
+}
+
+// SessionTranscript is a self-contained copy of the active branch of a conversation,
+// used for exports and as the snapshot behind share links
+type SessionTranscript struct {
+	SessionID   string    `json:"session_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExportedAt  time.Time `json:"exported_at"`
+	// 是否包含引用分块的原文及工具调用的输出
+	IncludesChunkContent bool                `json:"includes_chunk_content"`
+	Messages             []TranscriptMessage `json:"messages"`
+}
+
+// TranscriptMessage is a message of a session transcript
+type TranscriptMessage struct {
+	ID             string                `json:"id"`
+	Role           string                `json:"role"`
+	Content        string                `json:"content"`
+	CreatedAt      time.Time             `json:"created_at"`
+	MentionedItems MentionedItems        `json:"mentioned_items,omitempty"`
+	Images         []string              `json:"images,omitempty"` // File names of the attached images
+	References     []TranscriptReference `json:"references,omitempty"`
+	AgentSteps     AgentSteps            `json:"agent_steps,omitempty"`
+}
+
+// TranscriptReference is a citation of an answer, resolved to the title of the cited document
+type TranscriptReference struct {
+	Index          int     `json:"index"` // Citation number, starting from 1
+	KnowledgeID    string  `json:"knowledge_id"`
+	KnowledgeTitle string  `json:"knowledge_title"`
+	ChunkID        string  `json:"chunk_id"`
+	ChunkIndex     int     `json:"chunk_index"`
+	Score          float64 `json:"score"`
+	// 分块原文，仅在允许时包含
+	Content string `json:"content,omitempty"`
+}
+
+// Value implements the driver.Valuer interface
+func (t SessionTranscript) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+// Scan implements the sql.Scanner interface
+func (t *SessionTranscript) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, t)
+}
+
+// SessionShare is a revocable, expiring link to a read-only snapshot of a conversation
+type SessionShare struct {
+	ID        string `json:"id"         gorm:"type:varchar(36);primaryKey"`
+	TenantID  uint64 `json:"tenant_id"  gorm:"index"`
+	SessionID string `json:"session_id" gorm:"type:varchar(36);index"`
+	// 访问令牌，持有链接即可在无需登录的情况下查看快照
+	Token string `json:"token"      gorm:"type:varchar(64);uniqueIndex"`
+	// 快照中是否包含引用分块的原文及工具调用的输出
+	IncludeChunkContent bool   `json:"include_chunk_content"`
+	CreatedBy           string `json:"created_by" gorm:"type:varchar(36)"`
+	// 会话在创建链接时的快照，之后的对话不会出现在链接中
+	Snapshot  SessionTranscript `json:"-"          gorm:"type:jsonb"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	DeletedAt gorm.DeletedAt    `json:"-"          gorm:"index"`
+	// Path of the public read-only page, not stored in the database
+	URL string `json:"url"        gorm:"-"`
+}
+
+// TableName returns the table name of session shares
+func (SessionShare) TableName() string {
+	return "session_shares"
+}
+
+// BeforeCreate generates the share ID
+func (s *SessionShare) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// IsExpired reports whether the share link can no longer be opened
+func (s *SessionShare) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
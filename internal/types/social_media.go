@@ -0,0 +1,110 @@
+package types
+
+import (
+	"encoding/json"
+)
+
+// TypeSocialMediaExtract 自媒体文案提取任务
+const TypeSocialMediaExtract = "social_media:extract"
+
+// SocialMediaExtractRequest describes a single content extraction request sent to an extractor
+type SocialMediaExtractRequest struct {
+	// Platform is the source platform, e.g. xiaohongshu, douyin, bilibili, youtube
+	Platform string `json:"platform"`
+	// URL is the original video/post URL
+	URL string `json:"url"`
+	// AliyunAPIKey is the Aliyun API key configured on the knowledge base (used by the Coze workflow)
+	AliyunAPIKey string `json:"-"`
+}
+
+// SocialMediaContent is the content extracted from a social media video/post
+type SocialMediaContent struct {
+	Title       string `json:"title"`
+	Content     string `json:"content"`
+	Author      string `json:"author,omitempty"`
+	PublishTime string `json:"publish_time,omitempty"` // RFC3339 when the platform time can be parsed
+	OriginalURL string `json:"original_url,omitempty"`
+}
+
+// SocialMediaMetadata is stored in Knowledge.Metadata for knowledge imported from social media
+type SocialMediaMetadata struct {
+	Platform    string `json:"platform"`
+	Provider    string `json:"provider"`
+	Author      string `json:"author,omitempty"`
+	PublishTime string `json:"publish_time,omitempty"`
+	OriginalURL string `json:"original_url"`
+	ExtractedAt string `json:"extracted_at"`
+}
+
+// ToJSON converts the metadata to JSON type.
+func (m *SocialMediaMetadata) ToJSON() (JSON, error) {
+	if m == nil {
+		return nil, nil
+	}
+	bytes, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return JSON(bytes), nil
+}
+
+// SocialMediaExtractItem is one URL submitted for extraction
+type SocialMediaExtractItem struct {
+	Platform string `json:"platform"`
+	URL      string `json:"url"`
+}
+
+// SocialMediaExtractPayload represents the social media extraction task payload
+type SocialMediaExtractPayload struct {
+	TenantID        uint64                   `json:"tenant_id"`
+	TaskID          string                   `json:"task_id"`
+	KnowledgeBaseID string                   `json:"knowledge_base_id"`
+	Provider        string                   `json:"provider,omitempty"` // 指定提取器，为空时按平台自动选择
+	Items           []SocialMediaExtractItem `json:"items"`
+}
+
+// SocialMediaTaskStatus represents the status of a social media extraction task or item
+type SocialMediaTaskStatus string
+
+const (
+	SocialMediaStatusPending    SocialMediaTaskStatus = "pending"
+	SocialMediaStatusProcessing SocialMediaTaskStatus = "processing"
+	SocialMediaStatusCompleted  SocialMediaTaskStatus = "completed"
+	SocialMediaStatusFailed     SocialMediaTaskStatus = "failed"
+)
+
+// SocialMediaItemProgress represents the progress of a single URL in an extraction task
+type SocialMediaItemProgress struct {
+	Platform    string                `json:"platform"`
+	URL         string                `json:"url"`
+	Status      SocialMediaTaskStatus `json:"status"`
+	Provider    string                `json:"provider,omitempty"`
+	KnowledgeID string                `json:"knowledge_id,omitempty"`
+	Title       string                `json:"title,omitempty"`
+	Error       string                `json:"error,omitempty"`
+}
+
+// SocialMediaExtractProgress represents the progress of a social media extraction task
+type SocialMediaExtractProgress struct {
+	TaskID          string                    `json:"task_id"`
+	TenantID        uint64                    `json:"tenant_id"`
+	KnowledgeBaseID string                    `json:"knowledge_base_id"`
+	Status          SocialMediaTaskStatus     `json:"status"`
+	Progress        int                       `json:"progress"`  // 0-100
+	Total           int                       `json:"total"`     // 总链接数
+	Processed       int                       `json:"processed"` // 已处理数
+	Succeeded       int                       `json:"succeeded"` // 成功数
+	Failed          int                       `json:"failed"`    // 失败数
+	Items           []SocialMediaItemProgress `json:"items"`
+	Message         string                    `json:"message"`    // 状态消息
+	Error           string                    `json:"error"`      // 错误信息
+	CreatedAt       int64                     `json:"created_at"` // 任务创建时间
+	UpdatedAt       int64                     `json:"updated_at"` // 最后更新时间
+}
+
+// SocialMediaExtractorInfo describes an available extractor
+type SocialMediaExtractorInfo struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Platforms []string `json:"platforms"` // 为空表示支持所有平台
+}
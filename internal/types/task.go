@@ -0,0 +1,76 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// TaskState represents the state of an asynchronous task in the queue
+type TaskState string
+
+const (
+	TaskStatePending   TaskState = "pending"
+	TaskStateActive    TaskState = "active"
+	TaskStateScheduled TaskState = "scheduled"
+	TaskStateRetry     TaskState = "retry"     // 执行失败，等待重试
+	TaskStateArchived  TaskState = "archived"  // 重试次数用尽，已归档（失败）
+	TaskStateCompleted TaskState = "completed" // 已完成（仅保留设置了 Retention 的任务）
+)
+
+// ErrTaskThrottled is returned by a task handler when the tenant has reached its
+// concurrency limit; the task is rescheduled without counting as a failure
+var ErrTaskThrottled = errors.New("tenant task concurrency limit reached, task rescheduled")
+
+// RateLimitKind identifies a kind of model call limited per tenant
+type RateLimitKind string
+
+const (
+	RateLimitEmbedding RateLimitKind = "embedding"
+	RateLimitVLM       RateLimitKind = "vlm"
+)
+
+// TaskQueues lists the queues used by the asynchronous task server
+var TaskQueues = []string{"critical", "default", "low"}
+
+// TaskInfo describes an asynchronous task visible to a tenant
+type TaskInfo struct {
+	ID            string                 `json:"id"`
+	Queue         string                 `json:"queue"`
+	Type          string                 `json:"type"`
+	State         TaskState              `json:"state"`
+	Payload       map[string]interface{} `json:"payload"` // 任务参数摘要（不含大字段）
+	MaxRetry      int                    `json:"max_retry"`
+	Retried       int                    `json:"retried"`
+	LastError     string                 `json:"last_error,omitempty"`
+	LastFailedAt  *time.Time             `json:"last_failed_at,omitempty"`
+	NextProcessAt *time.Time             `json:"next_process_at,omitempty"`
+	CompletedAt   *time.Time             `json:"completed_at,omitempty"`
+}
+
+// TaskListFilter filters the tasks listed for a tenant
+type TaskListFilter struct {
+	Queue           string    `form:"queue"`
+	State           TaskState `form:"state"`
+	Type            string    `form:"type"`
+	KnowledgeBaseID string    `form:"knowledge_base_id"`
+}
+
+// TaskQueueInfo describes the state of a task queue
+type TaskQueueInfo struct {
+	Queue     string `json:"queue"`
+	Paused    bool   `json:"paused"`
+	Pending   int    `json:"pending"`
+	Active    int    `json:"active"`
+	Scheduled int    `json:"scheduled"`
+	Retry     int    `json:"retry"`
+	Archived  int    `json:"archived"`
+	Completed int    `json:"completed"`
+	Processed int    `json:"processed"` // 今日已处理
+	Failed    int    `json:"failed"`    // 今日失败
+}
+
+// TaskBulkRetryResult is the result of retrying the failed tasks of a knowledge base
+type TaskBulkRetryResult struct {
+	Retried      int      `json:"retried"`       // 重新执行的任务数
+	KnowledgeIDs []string `json:"knowledge_ids"` // 重新解析的知识ID
+}
@@ -81,6 +81,10 @@ type Tenant struct {
 	ConversationConfig *ConversationConfig `yaml:"conversation_config" json:"conversation_config" gorm:"type:jsonb"`
 	// Brand configuration for custom logo and app name
 	BrandConfig *BrandConfig `yaml:"brand_config"        json:"brand_config"        gorm:"type:jsonb"`
+	// Per-tenant task concurrency and model call limits, overriding the system defaults
+	RateLimits *TenantRateLimits `yaml:"rate_limits"         json:"rate_limits"         gorm:"type:jsonb"`
+	// Knowledge bases published through the built-in MCP server
+	MCPServerConfig *TenantMCPServerConfig `yaml:"mcp_server_config"   json:"mcp_server_config"   gorm:"type:jsonb"`
 	// Creation time
 	CreatedAt time.Time `yaml:"created_at"          json:"created_at"`
 	// Last updated time
@@ -221,3 +225,78 @@ func (c *BrandConfig) Scan(value interface{}) error {
 	}
 	return json.Unmarshal(b, c)
 }
+
+// TenantMCPServerConfig represents what a tenant publishes through the built-in MCP server.
+// Only the listed knowledge bases are visible to MCP clients; none are when the list is empty.
+type TenantMCPServerConfig struct {
+	// KnowledgeBaseIDs are the knowledge bases MCP clients of the tenant may access
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`
+}
+
+// Value implements the driver.Valuer interface
+func (c *TenantMCPServerConfig) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *TenantMCPServerConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// TenantRateLimits represents the background task and model call limits of a tenant.
+// A nil field falls back to the system default, 0 means unlimited.
+type TenantRateLimits struct {
+	// MaxConcurrentTasks is the maximum number of ingestion tasks running at the same time
+	MaxConcurrentTasks *int `json:"max_concurrent_tasks,omitempty"`
+	// EmbeddingCallsPerMinute is the maximum number of embedding calls per minute
+	EmbeddingCallsPerMinute *int `json:"embedding_calls_per_minute,omitempty"`
+	// VLMCallsPerMinute is the maximum number of VLM calls per minute
+	VLMCallsPerMinute *int `json:"vlm_calls_per_minute,omitempty"`
+	// AgentBudget caps the tokens, time and tool calls of every agent run of the tenant
+	AgentBudget *AgentBudget `json:"agent_budget,omitempty"`
+}
+
+// Value implements the driver.Valuer interface
+func (c *TenantRateLimits) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *TenantRateLimits) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// EffectiveRateLimits represents the limits applied to a tenant after merging
+// its overrides with the system defaults. 0 means unlimited.
+type EffectiveRateLimits struct {
+	MaxConcurrentTasks      int `json:"max_concurrent_tasks"`
+	EmbeddingCallsPerMinute int `json:"embedding_calls_per_minute"`
+	VLMCallsPerMinute       int `json:"vlm_calls_per_minute"`
+}
+
+// TenantRateLimitUsage represents the current consumption of a tenant's limits
+type TenantRateLimitUsage struct {
+	RunningTasks   int `json:"running_tasks"`
+	EmbeddingCalls int `json:"embedding_calls"` // 当前分钟内的调用次数
+	VLMCalls       int `json:"vlm_calls"`       // 当前分钟内的调用次数
+}
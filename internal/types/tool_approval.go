@@ -0,0 +1,144 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ToolApprovalMode decides how an agent tool call is authorized
+type ToolApprovalMode string
+
+const (
+	// ToolApprovalAuto runs the tool immediately
+	ToolApprovalAuto ToolApprovalMode = "auto"
+	// ToolApprovalConfirm pauses the agent until the user approves or rejects the call
+	ToolApprovalConfirm ToolApprovalMode = "confirm"
+	// ToolApprovalDeny never runs the tool, the call is answered with a refusal
+	ToolApprovalDeny ToolApprovalMode = "deny"
+)
+
+const (
+	// DefaultToolApprovalTimeout is how long an agent waits for a decision by default
+	DefaultToolApprovalTimeout = 10 * time.Minute
+	// MaxToolApprovalTimeout bounds the wait, matching the maximum lifetime of an SSE stream
+	MaxToolApprovalTimeout = 30 * time.Minute
+)
+
+// ToolApprovalPolicy marks the tools of an agent as auto, confirm or deny
+type ToolApprovalPolicy struct {
+	// Mode of tools not listed in Tools, empty means auto
+	Default ToolApprovalMode `yaml:"default" json:"default"`
+	// Mode per tool name, a trailing "*" matches a prefix, e.g. "mcp.*" or "mcp.github.*"
+	Tools map[string]ToolApprovalMode `yaml:"tools" json:"tools"`
+	// Seconds to wait for a decision before the call is treated as rejected, 0 means the default
+	TimeoutSeconds int `yaml:"timeout_seconds" json:"timeout_seconds"`
+}
+
+// IsValidToolApprovalMode reports whether mode is a known approval mode
+func IsValidToolApprovalMode(mode ToolApprovalMode) bool {
+	switch mode {
+	case ToolApprovalAuto, ToolApprovalConfirm, ToolApprovalDeny:
+		return true
+	default:
+		return false
+	}
+}
+
+// Validate checks the modes and timeout of the policy, a nil policy is valid
+func (p *ToolApprovalPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Default != "" && !IsValidToolApprovalMode(p.Default) {
+		return fmt.Errorf("invalid default tool approval mode %q", p.Default)
+	}
+	for name, mode := range p.Tools {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("tool approval policy contains an empty tool name")
+		}
+		if !IsValidToolApprovalMode(mode) {
+			return fmt.Errorf("invalid tool approval mode %q for tool %s", mode, name)
+		}
+	}
+	if p.TimeoutSeconds < 0 || time.Duration(p.TimeoutSeconds)*time.Second > MaxToolApprovalTimeout {
+		return fmt.Errorf("tool approval timeout must be between 0 and %d seconds", int(MaxToolApprovalTimeout.Seconds()))
+	}
+	return nil
+}
+
+// ModeFor returns the mode of a tool: an exact entry wins over the longest matching prefix,
+// which wins over the default
+func (p *ToolApprovalPolicy) ModeFor(toolName string) ToolApprovalMode {
+	if p == nil {
+		return ToolApprovalAuto
+	}
+	if mode, ok := p.Tools[toolName]; ok {
+		return mode
+	}
+	mode, matched := p.Default, -1
+	for pattern, patternMode := range p.Tools {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && strings.HasPrefix(toolName, prefix) && len(prefix) > matched {
+			mode, matched = patternMode, len(prefix)
+		}
+	}
+	if mode == "" {
+		return ToolApprovalAuto
+	}
+	return mode
+}
+
+// Timeout returns how long to wait for a decision
+func (p *ToolApprovalPolicy) Timeout() time.Duration {
+	if p == nil || p.TimeoutSeconds <= 0 {
+		return DefaultToolApprovalTimeout
+	}
+	return min(time.Duration(p.TimeoutSeconds)*time.Second, MaxToolApprovalTimeout)
+}
+
+// ToolApprovalStatus is the state of a tool approval
+type ToolApprovalStatus string
+
+const (
+	ToolApprovalPending  ToolApprovalStatus = "pending"
+	ToolApprovalApproved ToolApprovalStatus = "approved"
+	ToolApprovalRejected ToolApprovalStatus = "rejected"
+	// ToolApprovalExpired means no decision was made in time, or the agent stopped while waiting
+	ToolApprovalExpired ToolApprovalStatus = "expired"
+)
+
+// ToolApproval is a tool call of an agent waiting for, or decided by, the user
+type ToolApproval struct {
+	ID         string             `json:"id"           gorm:"type:varchar(36);primaryKey"`
+	TenantID   uint64             `json:"tenant_id"    gorm:"index:idx_tool_approvals_session"`
+	SessionID  string             `json:"session_id"   gorm:"type:varchar(36);index:idx_tool_approvals_session"`
+	MessageID  string             `json:"message_id"   gorm:"type:varchar(36)"`
+	ToolCallID string             `json:"tool_call_id" gorm:"type:varchar(128)"`
+	ToolName   string             `json:"tool_name"    gorm:"type:varchar(255)"`
+	Arguments  JSON               `json:"arguments"    gorm:"type:jsonb"`
+	Status     ToolApprovalStatus `json:"status"       gorm:"type:varchar(16)"`
+	// 拒绝或过期的原因，会作为工具结果返回给智能体
+	Reason string `json:"reason,omitempty" gorm:"type:text"`
+	// 作出决定的用户，API Key 调用时为空
+	DecidedBy string     `json:"decided_by,omitempty" gorm:"type:varchar(36)"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name of tool approvals
+func (ToolApproval) TableName() string {
+	return "tool_approvals"
+}
+
+// BeforeCreate generates the approval ID
+func (a *ToolApproval) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -19,6 +19,25 @@ type WebSearchConfig struct {
 	EmbeddingDimension int    `json:"embedding_dimension,omitempty"` // 嵌入维度（用于RAG压缩）
 	RerankModelID      string `json:"rerank_model_id,omitempty"`     // 重排模型ID（用于RAG压缩）
 	DocumentFragments  int    `json:"document_fragments,omitempty"`  // 文档片段数量（用于RAG压缩）
+	// 多搜索引擎配置
+	FallbackProviders   []string                               `json:"fallback_providers,omitempty"`   // 主搜索引擎出错或限流时依次尝试的备用搜索引擎
+	ProviderCredentials map[string]WebSearchProviderCredential `json:"provider_credentials,omitempty"` // 各搜索引擎的租户级凭证（按提供商ID）
+}
+
+// WebSearchProviderCredential holds tenant-level credentials of a web search provider
+type WebSearchProviderCredential struct {
+	APIKey   string `json:"api_key,omitempty"`   // API密钥
+	EngineID string `json:"engine_id,omitempty"` // 搜索引擎ID（Google Programmable Search 的 cx）
+}
+
+// ProviderCredential returns the tenant credential of a provider
+// The legacy APIKey field applies to the primary provider
+func (c *WebSearchConfig) ProviderCredential(providerID string) WebSearchProviderCredential {
+	credential := c.ProviderCredentials[providerID]
+	if credential.APIKey == "" && providerID == c.Provider {
+		credential.APIKey = c.APIKey
+	}
+	return credential
 }
 
 // Value implements driver.Valuer interface for WebSearchConfig
@@ -0,0 +1,149 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TypeWebhookDelivery is the asynq task type delivering a webhook event to a subscription
+const TypeWebhookDelivery = "webhook:deliver"
+
+// WebhookEventType identifies an event that can be delivered to webhook subscriptions
+type WebhookEventType string
+
+const (
+	WebhookEventKnowledgeParsed    WebhookEventType = "knowledge.parsed"    // 文档解析完成
+	WebhookEventKnowledgeFailed    WebhookEventType = "knowledge.failed"    // 文档解析失败
+	WebhookEventKnowledgeDeleted   WebhookEventType = "knowledge.deleted"   // 知识已删除
+	WebhookEventFAQImportFinished  WebhookEventType = "faq_import.finished" // FAQ 导入结束（成功或失败）
+	WebhookEventKBCloneFinished    WebhookEventType = "kb_clone.finished"   // 知识库复制结束（成功或失败）
+	WebhookEventEvaluationFinished WebhookEventType = "evaluation.finished" // 评估任务结束（成功或失败）
+	WebhookEventMessageLowRated    WebhookEventType = "message.low_rated"   // 回答被用户评为低分
+	WebhookEventAgentRunFinished   WebhookEventType = "agent_run.finished"  // 定时/触发的智能体运行结束（成功或失败）
+	WebhookEventTest               WebhookEventType = "webhook.test"        // 测试事件，仅由测试接口发送
+)
+
+// WebhookEventTypes lists the events a subscription may subscribe to
+var WebhookEventTypes = []WebhookEventType{
+	WebhookEventKnowledgeParsed,
+	WebhookEventKnowledgeFailed,
+	WebhookEventKnowledgeDeleted,
+	WebhookEventFAQImportFinished,
+	WebhookEventKBCloneFinished,
+	WebhookEventEvaluationFinished,
+	WebhookEventMessageLowRated,
+	WebhookEventAgentRunFinished,
+}
+
+// IsValidWebhookEventType checks whether the event type can be subscribed to
+func IsValidWebhookEventType(eventType WebhookEventType) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscription is a tenant's subscription to webhook events
+type WebhookSubscription struct {
+	ID          string             `json:"id"               gorm:"type:varchar(36);primaryKey"`
+	TenantID    uint64             `json:"tenant_id"        gorm:"index"`
+	Name        string             `json:"name"             gorm:"type:varchar(255)"`
+	Description string             `json:"description"      gorm:"type:text"`
+	URL         string             `json:"url"              gorm:"type:varchar(2048)"`
+	Secret      string             `json:"secret,omitempty" gorm:"type:varchar(255)"` // HMAC-SHA256 签名密钥，仅创建时返回
+	Events      []WebhookEventType `json:"events"           gorm:"type:jsonb;serializer:json"`
+	Enabled     bool               `json:"enabled"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt     `json:"-"                gorm:"index"`
+}
+
+// TableName returns the table name of webhook subscriptions
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// BeforeCreate generates the subscription ID
+func (s *WebhookSubscription) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Subscribes reports whether the subscription receives the event
+func (s *WebhookSubscription) Subscribes(eventType WebhookEventType) bool {
+	for _, t := range s.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// HideSecret returns a copy of the subscription without the signing secret
+func (s *WebhookSubscription) HideSecret() *WebhookSubscription {
+	copy := *s
+	copy.Secret = ""
+	return &copy
+}
+
+// WebhookDeliveryStatus is the status of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending" // 等待发送或等待重试
+	WebhookDeliverySuccess WebhookDeliveryStatus = "success"
+	WebhookDeliveryFailed  WebhookDeliveryStatus = "failed" // 重试次数用尽
+)
+
+// WebhookDelivery records the delivery of an event to a subscription
+type WebhookDelivery struct {
+	ID             string                `json:"id"              gorm:"type:varchar(36);primaryKey"`
+	TenantID       uint64                `json:"tenant_id"       gorm:"index"`
+	SubscriptionID string                `json:"subscription_id" gorm:"type:varchar(36);index"`
+	EventID        string                `json:"event_id"        gorm:"type:varchar(36)"`
+	EventType      WebhookEventType      `json:"event_type"      gorm:"type:varchar(64)"`
+	Payload        JSON                  `json:"payload"         gorm:"type:jsonb"`
+	Status         WebhookDeliveryStatus `json:"status"          gorm:"type:varchar(32)"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus int                   `json:"response_status"`
+	ResponseBody   string                `json:"response_body"   gorm:"type:text"`
+	Error          string                `json:"error"           gorm:"type:text"`
+	DurationMs     int64                 `json:"duration_ms"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// TableName returns the table name of webhook deliveries
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// BeforeCreate generates the delivery ID
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// WebhookEvent is the JSON body posted to the subscription URL
+type WebhookEvent struct {
+	ID        string           `json:"id"`
+	Type      WebhookEventType `json:"type"`
+	TenantID  uint64           `json:"tenant_id"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      interface{}      `json:"data"`
+}
+
+// WebhookDeliveryPayload is the payload of a webhook delivery task
+type WebhookDeliveryPayload struct {
+	TenantID   uint64 `json:"tenant_id"`
+	DeliveryID string `json:"delivery_id"`
+}
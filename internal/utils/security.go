@@ -3,8 +3,10 @@ package utils
 import (
 	"fmt"
 	"html"
+	"net"
 	"regexp"
 	"strings"
+	"syscall"
 	"unicode/utf8"
 )
 
@@ -117,6 +119,25 @@ func IsValidURL(url string) bool {
 	return true
 }
 
+// IsPrivateIP reports whether ip is a loopback, private, unspecified or link-local address
+func IsPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()
+}
+
+// DenyPrivateNetwork is a net.Dialer Control function rejecting connections to loopback, private and
+// link-local addresses. It checks the resolved address, so DNS names pointing inside cannot bypass it.
+func DenyPrivateNetwork(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || IsPrivateIP(ip) {
+		return fmt.Errorf("address %s is not allowed: private network access is disabled", host)
+	}
+	return nil
+}
+
 // IsValidImageURL 验证图片 URL 是否安全
 func IsValidImageURL(url string) bool {
 	if !IsValidURL(url) {
@@ -0,0 +1,3 @@
+-- Remove images column from messages table
+
+ALTER TABLE messages DROP COLUMN IF EXISTS images;
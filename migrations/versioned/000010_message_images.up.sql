@@ -0,0 +1,7 @@
+-- Add images column to messages table
+-- This column stores image attachments (file path, name, mime type, caption) sent with a user message
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS images JSONB DEFAULT '[]';
+
+-- Add comment for the column
+COMMENT ON COLUMN messages.images IS 'Stores image attachments (file_path, file_name, mime_type, caption) sent with a user message';
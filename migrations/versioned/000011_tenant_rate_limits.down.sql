@@ -0,0 +1,2 @@
+-- Remove rate_limits column from tenants table
+ALTER TABLE tenants DROP COLUMN IF EXISTS rate_limits;
@@ -0,0 +1,6 @@
+-- Add rate_limits column to tenants table
+-- Overrides the system default task concurrency and model call limits for a tenant
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS rate_limits JSONB DEFAULT NULL;
+
+-- Add comment
+COMMENT ON COLUMN tenants.rate_limits IS 'Per-tenant ingestion task concurrency and embedding/VLM calls per minute limits';
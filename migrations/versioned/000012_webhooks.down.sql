@@ -0,0 +1,6 @@
+-- Rollback: Webhooks
+
+ALTER TABLE messages DROP COLUMN IF EXISTS rating_comment;
+ALTER TABLE messages DROP COLUMN IF EXISTS rating;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
@@ -0,0 +1,48 @@
+-- Migration: Webhooks
+-- Description: 出站 Webhook 订阅、投递记录，以及用于低分回答事件的消息评分
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000012] Creating table: webhook_subscriptions'; END $$;
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4()::varchar,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events JSONB NOT NULL DEFAULT '[]',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_tenant ON webhook_subscriptions(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_deleted_at ON webhook_subscriptions(deleted_at);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000012] Creating table: webhook_deliveries'; END $$;
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4()::varchar,
+    tenant_id INTEGER NOT NULL,
+    subscription_id VARCHAR(36) NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER NOT NULL DEFAULT 0,
+    response_body TEXT,
+    error TEXT,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_tenant ON webhook_deliveries(tenant_id);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000012] Adding rating columns to messages'; END $$;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS rating INTEGER DEFAULT NULL;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS rating_comment TEXT DEFAULT NULL;
+
+COMMENT ON COLUMN messages.rating IS 'User rating of an assistant message (1-5)';
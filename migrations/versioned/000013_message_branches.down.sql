@@ -0,0 +1,6 @@
+-- Remove message branching columns
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS active_message_id;
+
+DROP INDEX IF EXISTS idx_messages_parent_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,38 @@
+-- Link messages to their parent so that a session becomes a tree of messages
+-- Editing a question or regenerating an answer adds a sibling branch instead of overwriting history
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000013] Adding parent_id to messages'; END $$;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS parent_id VARCHAR(36) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_messages_parent_id ON messages(parent_id);
+
+COMMENT ON COLUMN messages.parent_id IS 'ID of the previous message in the conversation, empty for the first message';
+
+-- Existing sessions are linear: chain each message to the one before it
+WITH ordered AS (
+    SELECT id,
+           LAG(id) OVER (
+               PARTITION BY session_id
+               ORDER BY created_at, CASE WHEN role = 'user' THEN 0 ELSE 1 END
+           ) AS prev_id
+    FROM messages
+    WHERE deleted_at IS NULL
+)
+UPDATE messages m
+SET parent_id = ordered.prev_id
+FROM ordered
+WHERE m.id = ordered.id AND ordered.prev_id IS NOT NULL;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000013] Adding active_message_id to sessions'; END $$;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS active_message_id VARCHAR(36) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN sessions.active_message_id IS 'ID of the last message on the active branch of the session';
+
+UPDATE sessions s
+SET active_message_id = latest.id
+FROM (
+    SELECT DISTINCT ON (session_id) session_id, id
+    FROM messages
+    WHERE deleted_at IS NULL
+    ORDER BY session_id, created_at DESC, CASE WHEN role = 'user' THEN 1 ELSE 0 END
+) latest
+WHERE s.id = latest.session_id;
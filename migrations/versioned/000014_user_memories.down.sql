@@ -0,0 +1,3 @@
+-- Remove user memories
+
+DROP TABLE IF EXISTS user_memories;
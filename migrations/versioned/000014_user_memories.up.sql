@@ -0,0 +1,22 @@
+-- Long-term memories agents keep about a user across sessions
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000014] Creating user_memories table'; END $$;
+CREATE TABLE IF NOT EXISTS user_memories (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    content TEXT NOT NULL,
+    source_session_id VARCHAR(36) NOT NULL DEFAULT '',
+    embedding JSONB,
+    embedding_model_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_memories_owner ON user_memories(tenant_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_user_memories_deleted_at ON user_memories(deleted_at);
+
+COMMENT ON TABLE user_memories IS 'Durable facts about a user recalled by agents across sessions';
+COMMENT ON COLUMN user_memories.source_session_id IS 'Session the memory was extracted from, empty for memories added by the user';
+COMMENT ON COLUMN user_memories.embedding IS 'Embedding used for recall, regenerated when the content or the embedding model changes';
@@ -0,0 +1,3 @@
+-- Rollback: Session shares
+
+DROP TABLE IF EXISTS session_shares;
@@ -0,0 +1,22 @@
+-- Migration: Session shares
+-- Description: 会话的只读分享链接，保存创建时的会话快照
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000015] Creating table: session_shares'; END $$;
+CREATE TABLE IF NOT EXISTS session_shares (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4()::varchar,
+    tenant_id INTEGER NOT NULL,
+    session_id VARCHAR(36) NOT NULL,
+    token VARCHAR(64) NOT NULL,
+    include_chunk_content BOOLEAN NOT NULL DEFAULT false,
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    snapshot JSONB NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_session_shares_token ON session_shares(token);
+CREATE INDEX IF NOT EXISTS idx_session_shares_tenant_id ON session_shares(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_session_shares_session_id ON session_shares(session_id);
+CREATE INDEX IF NOT EXISTS idx_session_shares_deleted_at ON session_shares(deleted_at);
@@ -0,0 +1,6 @@
+-- Rollback: Message search
+
+DROP INDEX IF EXISTS idx_sessions_title_trgm;
+DROP INDEX IF EXISTS idx_messages_content_trgm;
+DROP INDEX IF EXISTS idx_messages_agent_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS agent_id;
@@ -0,0 +1,21 @@
+-- Migration: Message search
+-- Description: 记录回答问题的智能体，并为聊天记录搜索创建三元组索引
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000016] Adding column: messages.agent_id'; END $$;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS agent_id VARCHAR(36) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_messages_agent_id ON messages(agent_id);
+
+-- 三元组索引加速 ILIKE 子串匹配；扩展不可用时搜索仍可工作，只是退化为顺序扫描
+DO $$
+BEGIN
+    BEGIN
+        CREATE EXTENSION IF NOT EXISTS pg_trgm;
+    EXCEPTION WHEN OTHERS THEN
+        RAISE NOTICE '[Migration 000016] pg_trgm is not available, skipping trigram indexes: %', SQLERRM;
+        RETURN;
+    END;
+
+    RAISE NOTICE '[Migration 000016] Creating trigram indexes on messages.content and sessions.title';
+    CREATE INDEX IF NOT EXISTS idx_messages_content_trgm ON messages USING gin (content gin_trgm_ops);
+    CREATE INDEX IF NOT EXISTS idx_sessions_title_trgm ON sessions USING gin (title gin_trgm_ops);
+END $$;
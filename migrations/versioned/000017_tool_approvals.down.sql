@@ -0,0 +1,3 @@
+-- Remove tool approvals
+
+DROP TABLE IF EXISTS tool_approvals;
@@ -0,0 +1,24 @@
+-- Tool calls of agents paused for approval by the user
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000017] Creating tool_approvals table'; END $$;
+CREATE TABLE IF NOT EXISTS tool_approvals (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    session_id VARCHAR(36) NOT NULL,
+    message_id VARCHAR(36) NOT NULL DEFAULT '',
+    tool_call_id VARCHAR(128) NOT NULL DEFAULT '',
+    tool_name VARCHAR(255) NOT NULL,
+    arguments JSONB,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    reason TEXT NOT NULL DEFAULT '',
+    decided_by VARCHAR(36) NOT NULL DEFAULT '',
+    decided_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tool_approvals_session ON tool_approvals(tenant_id, session_id);
+
+COMMENT ON TABLE tool_approvals IS 'Tool calls of agents whose approval policy requires confirmation by the user';
+COMMENT ON COLUMN tool_approvals.status IS 'pending, approved, rejected or expired';
+COMMENT ON COLUMN tool_approvals.reason IS 'Reason of a rejection or expiry, returned to the agent as the tool result';
@@ -0,0 +1,3 @@
+-- Remove data sources
+
+DROP TABLE IF EXISTS data_sources;
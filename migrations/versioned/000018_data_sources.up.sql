@@ -0,0 +1,26 @@
+-- External databases tenants register for agents to query read-only
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000018] Creating data_sources table'; END $$;
+CREATE TABLE IF NOT EXISTS data_sources (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    type VARCHAR(32) NOT NULL,
+    connection JSONB NOT NULL DEFAULT '{}',
+    allowed_tables JSONB NOT NULL DEFAULT '[]',
+    row_limit INTEGER NOT NULL DEFAULT 200,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_sources_tenant_id ON data_sources(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_data_sources_deleted_at ON data_sources(deleted_at);
+
+COMMENT ON TABLE data_sources IS 'External PostgreSQL, MySQL or SQLite databases agents query read-only';
+COMMENT ON COLUMN data_sources.type IS 'postgres, mysql or sqlite';
+COMMENT ON COLUMN data_sources.connection IS 'Host, port, database and credentials, or the SQLite file path';
+COMMENT ON COLUMN data_sources.allowed_tables IS 'Tables agents may query, optionally schema-qualified';
+COMMENT ON COLUMN data_sources.row_limit IS 'Maximum number of rows returned by a query';
@@ -0,0 +1,3 @@
+-- Remove message artifacts
+
+DROP TABLE IF EXISTS message_artifacts;
@@ -0,0 +1,26 @@
+-- Charts and files produced by agent tools, stored against the assistant message
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000019] Creating message_artifacts table'; END $$;
+CREATE TABLE IF NOT EXISTS message_artifacts (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    session_id VARCHAR(36) NOT NULL,
+    message_id VARCHAR(36) NOT NULL DEFAULT '',
+    tool_call_id VARCHAR(128) NOT NULL DEFAULT '',
+    type VARCHAR(16) NOT NULL,
+    title VARCHAR(255) NOT NULL DEFAULT '',
+    spec JSONB,
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    content_type VARCHAR(128) NOT NULL DEFAULT '',
+    size BIGINT NOT NULL DEFAULT 0,
+    content BYTEA,
+    row_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_artifacts_session ON message_artifacts(tenant_id, session_id);
+CREATE INDEX IF NOT EXISTS idx_message_artifacts_message_id ON message_artifacts(message_id);
+
+COMMENT ON TABLE message_artifacts IS 'Charts and downloadable files produced by agent tool calls';
+COMMENT ON COLUMN message_artifacts.type IS 'chart (Vega-Lite spec), csv or xlsx';
+COMMENT ON COLUMN message_artifacts.content IS 'File content of csv and xlsx artifacts';
@@ -0,0 +1,4 @@
+-- Rollback: Agent schedules
+
+DROP TABLE IF EXISTS agent_schedule_runs;
+DROP TABLE IF EXISTS agent_schedules;
@@ -0,0 +1,57 @@
+-- Migration: Agent schedules
+-- Description: 定时或由知识事件触发的智能体运行，以及每次运行的记录
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000020] Creating table: agent_schedules'; END $$;
+CREATE TABLE IF NOT EXISTS agent_schedules (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4()::varchar,
+    tenant_id BIGINT NOT NULL,
+    agent_id VARCHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    prompt TEXT NOT NULL,
+    cron VARCHAR(128) NOT NULL DEFAULT '',
+    timezone VARCHAR(64) NOT NULL DEFAULT '',
+    trigger_events JSONB NOT NULL DEFAULT '[]',
+    trigger_knowledge_base_ids JSONB NOT NULL DEFAULT '[]',
+    output_knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    notify_webhook BOOLEAN NOT NULL DEFAULT false,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_schedules_tenant ON agent_schedules(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_agent_schedules_next_run ON agent_schedules(next_run_at) WHERE enabled AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_agent_schedules_deleted_at ON agent_schedules(deleted_at);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000020] Creating table: agent_schedule_runs'; END $$;
+CREATE TABLE IF NOT EXISTS agent_schedule_runs (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4()::varchar,
+    tenant_id BIGINT NOT NULL,
+    schedule_id VARCHAR(36) NOT NULL,
+    trigger VARCHAR(16) NOT NULL,
+    events JSONB,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    message_id VARCHAR(36) NOT NULL DEFAULT '',
+    answer TEXT,
+    output_knowledge_id VARCHAR(36) NOT NULL DEFAULT '',
+    usage JSONB,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_schedule_runs_schedule ON agent_schedule_runs(schedule_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_agent_schedule_runs_tenant ON agent_schedule_runs(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_agent_schedule_runs_output ON agent_schedule_runs(output_knowledge_id) WHERE output_knowledge_id <> '';
+
+COMMENT ON TABLE agent_schedules IS 'Custom agents run headlessly with a fixed prompt on a cron expression or knowledge events';
+COMMENT ON COLUMN agent_schedules.next_run_at IS 'Next cron run, advanced atomically when a worker claims the run';
+COMMENT ON COLUMN agent_schedule_runs.events IS 'Knowledge events coalesced into an event-triggered run';
@@ -0,0 +1,5 @@
+-- Rollback: Custom agent versions
+
+ALTER TABLE messages DROP COLUMN IF EXISTS agent_version;
+ALTER TABLE custom_agents DROP COLUMN IF EXISTS version;
+DROP TABLE IF EXISTS custom_agent_versions;
@@ -0,0 +1,33 @@
+-- Migration: Custom agent versions
+-- Description: 智能体配置的不可变版本历史，消息记录回答所用的智能体版本
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000021] Creating table: custom_agent_versions'; END $$;
+CREATE TABLE IF NOT EXISTS custom_agent_versions (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4()::varchar,
+    tenant_id INTEGER NOT NULL,
+    agent_id VARCHAR(36) NOT NULL,
+    version INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    avatar VARCHAR(64),
+    config JSONB NOT NULL DEFAULT '{}',
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    created_by VARCHAR(36),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant_id, agent_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_custom_agent_versions_tenant ON custom_agent_versions(tenant_id);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000021] Adding column: custom_agents.version, messages.agent_version'; END $$;
+ALTER TABLE custom_agents ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS agent_version INT NOT NULL DEFAULT 0;
+
+-- 为已有智能体记录当前配置作为版本 1
+INSERT INTO custom_agent_versions (tenant_id, agent_id, version, name, description, avatar, config, note, created_by, created_at)
+SELECT tenant_id, id, 1, name, description, avatar, config, 'created', created_by, COALESCE(updated_at, CURRENT_TIMESTAMP)
+FROM custom_agents
+WHERE deleted_at IS NULL AND version = 0
+ON CONFLICT (tenant_id, agent_id, version) DO NOTHING;
+
+UPDATE custom_agents SET version = 1 WHERE deleted_at IS NULL AND version = 0;
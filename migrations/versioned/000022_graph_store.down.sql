@@ -0,0 +1,4 @@
+-- Rollback: Graph store
+
+DROP TABLE IF EXISTS graph_edges;
+DROP TABLE IF EXISTS graph_nodes;
@@ -0,0 +1,52 @@
+-- Migration: Graph store
+-- Description: 在关系数据库中存储知识图谱的实体和关系，作为 Neo4j 的替代（GRAPH_DRIVER=postgres）
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000022] Creating table: graph_nodes'; END $$;
+CREATE TABLE IF NOT EXISTS graph_nodes (
+    id BIGSERIAL PRIMARY KEY,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    name TEXT NOT NULL,
+    chunks JSONB NOT NULL DEFAULT '[]',
+    attributes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_graph_nodes_identity ON graph_nodes(knowledge_base_id, knowledge_id, name);
+CREATE INDEX IF NOT EXISTS idx_graph_nodes_name ON graph_nodes(knowledge_base_id, name);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000022] Creating table: graph_edges'; END $$;
+CREATE TABLE IF NOT EXISTS graph_edges (
+    id BIGSERIAL PRIMARY KEY,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    source TEXT NOT NULL,
+    target TEXT NOT NULL,
+    type TEXT NOT NULL,
+    chunks JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_graph_edges_identity ON graph_edges(knowledge_base_id, knowledge_id, source, target, type);
+CREATE INDEX IF NOT EXISTS idx_graph_edges_source ON graph_edges(knowledge_base_id, source);
+CREATE INDEX IF NOT EXISTS idx_graph_edges_target ON graph_edges(knowledge_base_id, target);
+CREATE INDEX IF NOT EXISTS idx_graph_edges_knowledge ON graph_edges(knowledge_id);
+
+-- 三元组索引支持实体名称的模糊匹配；扩展不可用时退化为子串匹配
+DO $$
+BEGIN
+    BEGIN
+        CREATE EXTENSION IF NOT EXISTS pg_trgm;
+    EXCEPTION WHEN OTHERS THEN
+        RAISE NOTICE '[Migration 000022] pg_trgm is not available, skipping trigram index: %', SQLERRM;
+        RETURN;
+    END;
+
+    RAISE NOTICE '[Migration 000022] Creating trigram index on graph_nodes.name';
+    CREATE INDEX IF NOT EXISTS idx_graph_nodes_name_trgm ON graph_nodes USING gin (name gin_trgm_ops);
+END $$;
+
+COMMENT ON TABLE graph_nodes IS 'Knowledge graph entities, one row per entity name and document';
+COMMENT ON TABLE graph_edges IS 'Knowledge graph relations between entity names, one row per relation and document';
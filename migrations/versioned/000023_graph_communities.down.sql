@@ -0,0 +1,3 @@
+-- Remove graph communities
+
+DROP TABLE IF EXISTS graph_communities;
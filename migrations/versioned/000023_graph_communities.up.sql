@@ -0,0 +1,23 @@
+-- Communities of the knowledge graph of a knowledge base, summarized for global search
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000023] Creating graph_communities table'; END $$;
+CREATE TABLE IF NOT EXISTS graph_communities (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    title VARCHAR(255) NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    entities JSONB NOT NULL DEFAULT '[]',
+    size INTEGER NOT NULL DEFAULT 0,
+    relation_count INTEGER NOT NULL DEFAULT 0,
+    embedding JSONB,
+    embedding_model_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_graph_communities_knowledge_base_id ON graph_communities(knowledge_base_id);
+
+COMMENT ON TABLE graph_communities IS 'Groups of closely related graph entities with LLM-generated summaries, replaced on every rebuild';
+COMMENT ON COLUMN graph_communities.entities IS 'Entity names of the community, most connected first';
+COMMENT ON COLUMN graph_communities.embedding IS 'Embedding of the title and summary used by global search';
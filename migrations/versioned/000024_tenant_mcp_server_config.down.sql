@@ -0,0 +1,2 @@
+-- Remove mcp_server_config column from tenants table
+ALTER TABLE tenants DROP COLUMN IF EXISTS mcp_server_config;
@@ -0,0 +1,6 @@
+-- Add mcp_server_config column to tenants table
+-- Lists the knowledge bases a tenant publishes through the built-in MCP server
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS mcp_server_config JSONB DEFAULT NULL;
+
+-- Add comment
+COMMENT ON COLUMN tenants.mcp_server_config IS 'Knowledge bases accessible to MCP clients of the tenant';